@@ -59,6 +59,8 @@ function groupEventsByAuthor(events: NonNullable<ChatMessage['events']>): Author
     if (evt.type === 'tool_call') {
       const result = evt.tool_id ? resultsByID.get(evt.tool_id) : undefined
       current.items.push({ kind: 'call', call: evt, result })
+    } else if (evt.type === 'truncated' && evt.text) {
+      current.items.push({ kind: 'narration', text: `… ${evt.text} …` })
     } else if (evt.type === 'text' && evt.text) {
       // Narration text interleaved with tool calls — show inline.
       // Skip if this is the final response text (last text event with no
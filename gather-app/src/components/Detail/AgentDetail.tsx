@@ -2,11 +2,13 @@ import { useEffect, useState, useCallback, useRef } from 'react'
 import { useWorkspace } from '../../context/WorkspaceContext'
 import { pb } from '../../lib/pocketbase'
 import { updateClawSettings, createClawCheckout, getClawEnv, saveClawEnv, restartClaw, getClawLogs } from '../../lib/api'
+import type { ClawOnboarding } from '../../lib/api'
 
 interface ClawDetail {
   id: string
   name: string
   status: string
+  queue_position?: number
   claw_type: string
   subdomain?: string
   url?: string
@@ -19,18 +21,30 @@ interface ClawDetail {
   heartbeat_instruction?: string
   paid?: boolean
   trial_ends_at?: string
+  health_status?: string
+  last_health_at?: string
+  auto_heal?: boolean
+  onboarding?: ClawOnboarding
   created: string
 }
 
 const statusLabel: Record<string, string> = {
   queued: 'Queued',
   provisioning: 'Provisioning...',
+  claimed: 'Provisioning...',
   running: 'Running',
   expired: 'Trial Expired',
   stopped: 'Stopped',
   failed: 'Failed',
 }
 
+const onboardingLabel: Record<string, string> = {
+  authenticated: 'Authenticated with Gather',
+  first_channel_post: 'Posted to its channel',
+  first_heartbeat: 'Answered a heartbeat',
+  env_configured: 'Environment configured',
+}
+
 export default function AgentDetail() {
   const { state, dispatch } = useWorkspace()
   const [claw, setClaw] = useState<ClawDetail | null>(null)
@@ -41,6 +55,7 @@ export default function AgentDetail() {
   const [isPublic, setIsPublic] = useState(true)
   const [heartbeatInterval, setHeartbeatInterval] = useState(0)
   const [heartbeatInstruction, setHeartbeatInstruction] = useState('')
+  const [autoHeal, setAutoHeal] = useState(false)
 
   // Configuration state
   const [envLoading, setEnvLoading] = useState(false)
@@ -51,6 +66,7 @@ export default function AgentDetail() {
   const [model, setModel] = useState('')
   const [telegramBot, setTelegramBot] = useState('')
   const [telegramChatId, setTelegramChatId] = useState('')
+  const [envRestartNeeded, setEnvRestartNeeded] = useState(false)
 
   // Telegram detect state
   const [detecting, setDetecting] = useState(false)
@@ -76,6 +92,7 @@ export default function AgentDetail() {
           setIsPublic(data.is_public ?? true)
           setHeartbeatInterval(data.heartbeat_interval ?? 0)
           setHeartbeatInstruction(data.heartbeat_instruction ?? '')
+          setAutoHeal(data.auto_heal ?? false)
         }
       } catch {
         // ignore
@@ -141,6 +158,7 @@ export default function AgentDetail() {
         is_public: isPublic,
         heartbeat_interval: heartbeatInterval,
         heartbeat_instruction: heartbeatInstruction,
+        auto_heal: autoHeal,
       })
       setClaw(updated as unknown as ClawDetail)
     } catch {
@@ -155,7 +173,8 @@ export default function AgentDetail() {
     if (!claw || (claw.status !== 'running' && claw.status !== 'expired')) return
     setEnvLoading(true)
     getClawEnv(claw.id)
-      .then(({ vars }) => {
+      .then(({ vars, restart_needed }) => {
+        setEnvRestartNeeded(restart_needed)
         setApiKey(vars.ANTHROPIC_API_KEY || '')
         setApiBase(vars.ANTHROPIC_API_BASE || '')
         setModel(vars.ANTHROPIC_MODEL || '')
@@ -172,13 +191,16 @@ export default function AgentDetail() {
     if (!claw) return
     setEnvSaving(true)
     try {
-      const vars: Record<string, string> = {}
-      if (apiKey) vars.ANTHROPIC_API_KEY = apiKey
-      if (apiBase) vars.ANTHROPIC_API_BASE = apiBase
-      if (model) vars.ANTHROPIC_MODEL = model
-      if (telegramBot) vars.TELEGRAM_BOT = telegramBot
-      if (telegramChatId) vars.TELEGRAM_CHAT_ID = telegramChatId
-      await saveClawEnv(claw.id, vars, restart)
+      // Send every field: a cleared field deletes its key
+      const vars: Record<string, string> = {
+        ANTHROPIC_API_KEY: apiKey,
+        ANTHROPIC_API_BASE: apiBase,
+        ANTHROPIC_MODEL: model,
+        TELEGRAM_BOT: telegramBot,
+        TELEGRAM_CHAT_ID: telegramChatId,
+      }
+      const res = await saveClawEnv(claw.id, vars, restart)
+      setEnvRestartNeeded(res.restart_needed)
     } catch (e) {
       alert(e instanceof Error ? e.message : 'Failed to save configuration')
     } finally {
@@ -191,6 +213,7 @@ export default function AgentDetail() {
     setRestarting(true)
     try {
       await restartClaw(claw.id)
+      setEnvRestartNeeded(false)
     } catch (e) {
       alert(e instanceof Error ? e.message : 'Failed to restart')
     } finally {
@@ -258,7 +281,8 @@ export default function AgentDetail() {
   const settingsChanged = claw && (
     isPublic !== (claw.is_public ?? true) ||
     heartbeatInterval !== (claw.heartbeat_interval ?? 0) ||
-    heartbeatInstruction !== (claw.heartbeat_instruction ?? '')
+    heartbeatInstruction !== (claw.heartbeat_instruction ?? '') ||
+    autoHeal !== (claw.auto_heal ?? false)
   )
 
   if (!claw) {
@@ -269,9 +293,14 @@ export default function AgentDetail() {
     )
   }
 
-  const statusText = statusLabel[claw.status] || claw.status
-  const statusCls = claw.status === 'running' ? 'status-running'
-    : claw.status === 'failed' || claw.status === 'stopped' ? 'status-stopped'
+  // Container up but agent not answering health checks
+  const unhealthy = claw.status === 'running' && claw.health_status === 'unhealthy'
+  const statusText = unhealthy ? 'Unhealthy'
+    : claw.status === 'queued' && claw.queue_position ? `Queued (#${claw.queue_position})`
+    : statusLabel[claw.status] || claw.status
+  const statusCls = unhealthy ? 'status-idle'
+    : claw.status === 'running' ? 'status-running'
+    : claw.status === 'failed' || claw.status === 'stopped' || claw.status === 'deleted' ? 'status-stopped'
     : claw.status === 'expired' ? 'status-stopped'
     : 'status-idle'
 
@@ -337,6 +366,19 @@ export default function AgentDetail() {
         ) : null}
       </div>
 
+      {claw.onboarding && claw.onboarding.completed < claw.onboarding.total && claw.status === 'running' && (
+        <div style={{ marginBottom: 'var(--space-md)' }}>
+          <div style={{ fontSize: '0.7rem', fontWeight: 600, textTransform: 'uppercase', letterSpacing: '0.05em', color: 'var(--text-muted)', marginBottom: 'var(--space-xs)' }}>
+            Getting started ({claw.onboarding.completed}/{claw.onboarding.total})
+          </div>
+          {claw.onboarding.steps.map(step => (
+            <div key={step.key} style={{ fontSize: '0.8rem', color: step.done ? 'var(--text-secondary)' : 'var(--text-muted)' }}>
+              {step.done ? '\u2713' : '\u25CB'} {onboardingLabel[step.key] ?? step.key}
+            </div>
+          ))}
+        </div>
+      )}
+
       {claw.instructions && (
         <div style={{ marginBottom: 'var(--space-md)' }}>
           <div style={{ fontSize: '0.7rem', fontWeight: 600, textTransform: 'uppercase', letterSpacing: '0.05em', color: 'var(--text-muted)', marginBottom: 'var(--space-xs)' }}>
@@ -383,6 +425,16 @@ export default function AgentDetail() {
           <span style={{ fontSize: '0.7rem', color: 'var(--text-muted)' }}>(anyone with the link can view)</span>
         </label>
 
+        <label style={{ display: 'flex', alignItems: 'center', gap: 'var(--space-xs)', fontSize: '0.8rem', color: 'var(--text-secondary)', marginBottom: 'var(--space-sm)', cursor: 'pointer' }}>
+          <input
+            type="checkbox"
+            checked={autoHeal}
+            onChange={(e) => setAutoHeal(e.target.checked)}
+          />
+          Auto-restart
+          <span style={{ fontSize: '0.7rem', color: 'var(--text-muted)' }}>(when it stops responding)</span>
+        </label>
+
         <div style={{ marginBottom: 'var(--space-sm)' }}>
           <div style={{ fontSize: '0.75rem', color: 'var(--text-secondary)', marginBottom: '2px' }}>Heartbeat</div>
           <select
@@ -507,6 +559,12 @@ export default function AgentDetail() {
                 )}
               </div>
 
+              {envRestartNeeded && (
+                <div style={{ fontSize: '0.7rem', color: 'var(--text-muted)', marginBottom: 'var(--space-xs)' }}>
+                  Saved settings differ from what the claw is running with. Restart to apply them.
+                </div>
+              )}
+
               <div style={{ display: 'flex', gap: 'var(--space-xs)' }}>
                 <button
                   className="btn btn-primary btn-sm"
@@ -24,6 +24,7 @@ interface ClawDeployment {
 const statusLabel: Record<string, string> = {
   queued: 'Queued',
   provisioning: 'Provisioning...',
+  claimed: 'Provisioning...',
   running: 'Running',
   expired: 'Trial Expired',
   stopped: 'Stopped',
@@ -33,6 +34,7 @@ const statusLabel: Record<string, string> = {
 const statusClass: Record<string, string> = {
   queued: 'status-idle',
   provisioning: 'status-idle',
+  claimed: 'status-idle',
   running: 'status-running',
   expired: 'status-stopped',
   stopped: 'status-stopped',
@@ -1,11 +1,13 @@
 import { useState, useEffect } from 'react'
 import { pb } from '../../lib/pocketbase'
+import { deleteClawSecret } from '../../lib/api'
 
 interface VaultRecord {
   id: string
   key: string
   value: string
   user_id: string
+  last_injected_at?: string
 }
 
 const LLM_VARS = [
@@ -24,6 +26,7 @@ export default function AuthVault() {
   const [editingKey, setEditingKey] = useState<string | null>(null)
   const [formValue, setFormValue] = useState('')
   const [error, setError] = useState('')
+  const [notice, setNotice] = useState('')
   const [saving, setSaving] = useState(false)
   const [loading, setLoading] = useState(true)
 
@@ -81,8 +84,13 @@ export default function AuthVault() {
     const existing = getEntry(key)
     if (!existing) return
     setError('')
+    setNotice('')
     try {
-      await pb.collection('claw_secrets').delete(existing.id)
+      const res = await deleteClawSecret(existing.id)
+      if (res.affected_claws.length > 0) {
+        const names = res.affected_claws.map(c => c.name).join(', ')
+        setNotice(`${key} was removed from the vault, but ${names} still ${res.affected_claws.length === 1 ? 'has' : 'have'} it until redeployed.`)
+      }
       await loadVault()
     } catch (e: any) {
       console.error('Vault delete failed:', e)
@@ -114,6 +122,18 @@ export default function AuthVault() {
         </div>
       )}
 
+      {notice && (
+        <div style={{
+          padding: '8px 12px',
+          marginBottom: '12px',
+          borderRadius: '6px',
+          border: '1px solid var(--color-border, #333)',
+          fontSize: '0.875rem',
+        }}>
+          {notice}
+        </div>
+      )}
+
       <div style={{ display: 'flex', flexDirection: 'column', gap: '12px' }}>
         {LLM_VARS.map(v => {
           const entry = getEntry(v.key)
@@ -142,6 +162,13 @@ export default function AuthVault() {
                   }}>
                     {v.key}
                   </div>
+                  {entry && (
+                    <div style={{ fontSize: '0.75rem', opacity: 0.5, marginTop: '2px' }}>
+                      {entry.last_injected_at
+                        ? `Last deployed ${new Date(entry.last_injected_at).toLocaleDateString()}`
+                        : 'Not deployed to any claw yet'}
+                    </div>
+                  )}
                 </div>
                 <div style={{ display: 'flex', alignItems: 'center', gap: '8px' }}>
                   {entry && !isEditing && (
@@ -7,6 +7,7 @@ const statusDot: Record<string, string> = {
   running: 'online',
   queued: 'idle',
   provisioning: 'idle',
+  claimed: 'idle',
   stopped: 'offline',
   failed: 'offline',
 }
@@ -38,7 +39,9 @@ export default function ClawList() {
       {claws.map(claw => {
         const topic = `claw:${claw.id}`
         const isActive = state.activeChannel === topic
-        const dotClass = statusDot[claw.status] || 'offline'
+        const dotClass = claw.status === 'running' && claw.health_status === 'unhealthy'
+          ? 'away'
+          : statusDot[claw.status] || 'offline'
         return (
           <div
             key={claw.id}
@@ -258,10 +258,12 @@ export function ChatProvider({ children }: { children: ReactNode }) {
     // Track seen message IDs to prevent duplicates
     clawSeenIdsRef.current = new Set()
     let lastTs = ''
+    // Shared claws carry other users' messages too; only ours are "own"
+    const ownAuthorId = `user:${pb.authStore.record?.id}`
 
     // Fetch initial messages
     try {
-      const data = await getClawMessages(clawId)
+      const data = await getClawMessages(clawId, undefined, true)
       const raw = data.messages || []
       for (const m of raw) clawSeenIdsRef.current.add(m.id)
       // Initialize watermark from newest message (API returns newest first)
@@ -272,8 +274,9 @@ export function ChatProvider({ children }: { children: ReactNode }) {
         from: m.author_name,
         content: m.body,
         ts: m.created,
-        isOwn: m.author_id.startsWith('user:'),
+        isOwn: m.author_id === ownAuthorId,
         topic: `claw:${clawId}`,
+        events: m.events,
       }))
       dispatch({ type: 'SET_CLAW_TOPIC', clawId, clawName: clawName || clawId, messages: msgs })
     } catch (err) {
@@ -288,7 +291,7 @@ export function ChatProvider({ children }: { children: ReactNode }) {
         const data = await getClawMessages(clawId, lastTs || undefined)
         const newMsgs = (data.messages || []).filter(m => {
           if (clawSeenIdsRef.current.has(m.id)) return false
-          if (m.author_id.startsWith('user:')) return false  // Always skip — added optimistically
+          if (m.author_id === ownAuthorId) return false  // Always skip — added optimistically
           return true
         })
         if (newMsgs.length > 0) {
@@ -299,7 +302,7 @@ export function ChatProvider({ children }: { children: ReactNode }) {
             from: m.author_name,
             content: m.body,
             ts: m.created,
-            isOwn: m.author_id.startsWith('user:'),
+            isOwn: m.author_id === ownAuthorId,
             topic: `claw:${clawId}`,
           }))
           for (const msg of msgs) {
@@ -89,6 +89,8 @@ export interface ClawDeployment {
   id: string
   name: string
   status: string
+  // while queued: place in the provisioning queue, 1 = next
+  queue_position?: number
   instructions?: string
   github_repo?: string
   claw_type: string
@@ -99,9 +101,31 @@ export interface ClawDeployment {
   paid?: boolean
   trial_ends_at?: string
   stripe_session_id?: string
+  health_status?: string
+  last_health_at?: string
+  auto_heal?: boolean
+  busy?: boolean
+  active_requests?: number
+  busy_since?: string
+  network_policy?: ClawNetworkPolicy
+  egress_allowlist?: string[]
+  // other owners' claws may hand this claw tasks
+  accept_public_tasks?: boolean
+  onboarding?: ClawOnboarding
   created: string
 }
 
+// First-run checklist; each step's at is when it was first reached
+export interface ClawOnboarding {
+  provisioned_at?: string
+  steps: { key: string; done: boolean; at?: string }[]
+  completed: number
+  total: number
+}
+
+// open: full internet; platform_only: gather.is only; allowlist: plus listed domains
+export type ClawNetworkPolicy = 'open' | 'platform_only' | 'allowlist'
+
 export function deployClaw(body: { name: string; instructions?: string; github_repo?: string }) {
   return apiPost<ClawDeployment>('/api/claws', body)
 }
@@ -110,6 +134,18 @@ export function getClawStatus(id: string) {
   return apiFetch<ClawDeployment>(`/api/claws/${encodeURIComponent(id)}`)
 }
 
+// Whether the claw is running an agent turn now — poll for a working indicator
+export interface ClawBusyState {
+  busy: boolean
+  active_requests: number
+  max_in_flight: number
+  since?: string
+}
+
+export function getClawBusy(id: string) {
+  return apiFetch<ClawBusyState>(`/api/claws/${encodeURIComponent(id)}/busy`)
+}
+
 export function listClaws() {
   return apiFetch<{ claws: ClawDeployment[]; total: number }>('/api/claws')
 }
@@ -118,6 +154,10 @@ export function updateClawSettings(id: string, settings: {
   is_public?: boolean
   heartbeat_interval?: number
   heartbeat_instruction?: string
+  auto_heal?: boolean
+  network_policy?: ClawNetworkPolicy
+  egress_allowlist?: string[]
+  accept_public_tasks?: boolean
 }) {
   return apiFetch<ClawDeployment>(`/api/claws/${encodeURIComponent(id)}`, {
     method: 'PATCH',
@@ -127,7 +167,8 @@ export function updateClawSettings(id: string, settings: {
 
 // Claw messaging
 export interface ADKEvent {
-  type: 'text' | 'tool_call' | 'tool_result'
+  // 'truncated' only appears in stored events, where a long run was cut
+  type: 'text' | 'tool_call' | 'tool_result' | 'truncated'
   author?: string
   text?: string
   tool_name?: string
@@ -141,12 +182,14 @@ export interface ClawMessage {
   author_id: string
   author_name: string
   body: string
+  events?: ADKEvent[]
   created: string
 }
 
-export function getClawMessages(clawId: string, since?: string) {
+export function getClawMessages(clawId: string, since?: string, expandEvents = false) {
   const params = new URLSearchParams()
   if (since) params.set('since', since)
+  if (expandEvents) params.set('expand', 'events')
   const qs = params.toString()
   return apiFetch<{ messages: ClawMessage[] }>(`/api/claws/${encodeURIComponent(clawId)}/messages${qs ? '?' + qs : ''}`)
 }
@@ -155,9 +198,10 @@ export function sendClawMessage(clawId: string, body: string) {
   return apiPost<{ message: ClawMessage; user_message_id: string; events?: ADKEvent[] }>(`/api/claws/${encodeURIComponent(clawId)}/messages`, { body })
 }
 
-// SSE streaming event from the bridge
+// SSE streaming event from the bridge. 'queued' is sent by the server first
+// when the claw is still working on earlier turns (active_requests of them).
 export interface StreamEvent {
-  type: 'text' | 'tool_call' | 'tool_result' | 'end' | 'done' | 'error'
+  type: 'text' | 'tool_call' | 'tool_result' | 'end' | 'done' | 'error' | 'queued'
   author?: string
   text?: string
   tool_name?: string
@@ -166,6 +210,7 @@ export interface StreamEvent {
   result?: unknown
   message_id?: string
   user_message_id?: string
+  active_requests?: number
 }
 
 // Stream claw message via SSE — yields events as they arrive from the agent.
@@ -214,12 +259,21 @@ export async function* streamClawMessage(clawId: string, body: string): AsyncGen
 }
 
 // Claw environment / config
+export interface ClawEnvEntry {
+  key: string
+  value?: string
+  in_file: boolean
+  active: boolean
+  live_value?: string
+}
+
 export function getClawEnv(id: string) {
-  return apiFetch<{ vars: Record<string, string> }>(`/api/claws/${encodeURIComponent(id)}/env`)
+  return apiFetch<{ vars: Record<string, string>; entries: ClawEnvEntry[]; live_known: boolean; restart_needed: boolean }>(`/api/claws/${encodeURIComponent(id)}/env`)
 }
 
+// Empty values delete the key; masked values from getClawEnv are left unchanged.
 export function saveClawEnv(id: string, vars: Record<string, string>, restart = false) {
-  return apiFetch<{ ok: boolean }>(`/api/claws/${encodeURIComponent(id)}/env`, {
+  return apiFetch<{ ok: boolean; set?: string[]; removed?: string[]; restart_needed: boolean }>(`/api/claws/${encodeURIComponent(id)}/env`, {
     method: 'PUT',
     body: JSON.stringify({ vars, restart }),
   })
@@ -233,6 +287,25 @@ export function getClawLogs(id: string, tail = 200) {
   return apiFetch<{ logs: string }>(`/api/claws/${encodeURIComponent(id)}/logs?tail=${tail}`)
 }
 
+// Vault secrets — values are never returned by these endpoints
+export interface ClawSecretUsage {
+  id: string
+  key: string
+  last_injected_at?: string
+  last_injected_claw?: string
+  created: string
+}
+
+export function getUnusedClawSecrets() {
+  return apiFetch<{ secrets: ClawSecretUsage[] }>('/api/claw-secrets/unused')
+}
+
+// Deletes the secret and lists running claws that still carry it until redeployed.
+export function deleteClawSecret(id: string) {
+  return apiFetch<{ deleted: boolean; key: string; affected_claws: { id: string; name: string }[] }>(
+    `/api/claw-secrets/${encodeURIComponent(id)}`, { method: 'DELETE' })
+}
+
 // Stripe checkout
 export function createClawCheckout(id: string): Promise<{ url: string }> {
   return apiFetch(`/api/claws/${encodeURIComponent(id)}/checkout`, { method: 'POST' })
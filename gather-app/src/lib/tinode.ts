@@ -20,7 +20,7 @@ export interface ChatMessage {
   isOwn: boolean
   topic: string
   events?: Array<{
-    type: 'text' | 'tool_call' | 'tool_result'
+    type: 'text' | 'tool_call' | 'tool_result' | 'truncated'
     author?: string
     text?: string
     tool_name?: string
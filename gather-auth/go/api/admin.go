@@ -17,22 +17,130 @@ import (
 
 // requireAdmin validates a PocketBase superuser token from the Authorization header.
 func requireAdmin(app *pocketbase.PocketBase, authorization string) error {
+	_, err := requireAdminRecord(app, authorization)
+	return err
+}
+
+// requireAdminRecord is requireAdmin but also returns the superuser record,
+// for endpoints that record which admin acted.
+func requireAdminRecord(app *pocketbase.PocketBase, authorization string) (*core.Record, error) {
 	if authorization == "" {
-		return huma.Error401Unauthorized("Admin authentication required.")
+		return nil, huma.Error401Unauthorized("Admin authentication required.")
 	}
 	token := strings.TrimPrefix(authorization, "Bearer ")
 
 	record, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth)
 	if err != nil || record == nil {
-		return huma.Error401Unauthorized("Invalid admin token.")
+		return nil, huma.Error401Unauthorized("Invalid admin token.")
 	}
 
 	if record.Collection().Name != "_superusers" {
-		return huma.Error403Forbidden("Admin access required.")
+		return nil, huma.Error403Forbidden("Admin access required.")
+	}
+	return record, nil
+}
+
+// recordAdminAudit appends an entry to the admin_audit trail. It takes a
+// core.App so destructive actions can write it inside their transaction.
+func recordAdminAudit(app core.App, adminID, action, targetType, targetID string, details map[string]any) error {
+	col, err := app.FindCollectionByNameOrId("admin_audit")
+	if err != nil {
+		return err
+	}
+	rec := core.NewRecord(col)
+	rec.Set("admin_id", adminID)
+	rec.Set("action", action)
+	rec.Set("target_type", targetType)
+	rec.Set("target_id", targetID)
+	if details != nil {
+		rec.Set("details", details)
 	}
+	return app.Save(rec)
+}
+
+// -----------------------------------------------------------------------------
+// Moderation actions (shared by admin routes and the report queue)
+// -----------------------------------------------------------------------------
+
+// suspendAgent suspends an agent, optionally freezing their balance, and
+// notifies them via inbox.
+func suspendAgent(app *pocketbase.PocketBase, agentID, reason string, freezeBalance bool) error {
+	agent, err := app.FindRecordById("agents", agentID)
+	if err != nil {
+		return err
+	}
+
+	agent.Set("suspended", true)
+	agent.Set("suspend_reason", reason)
+	if err := app.Save(agent); err != nil {
+		return err
+	}
+
+	if freezeBalance {
+		bal, err := getOrCreateBalance(app, agentID)
+		if err == nil {
+			bal.Set("suspended", true)
+			app.Save(bal)
+		}
+	}
+
+	// Notify via inbox
+	SendInboxMessage(app, agentID, "system",
+		"Account suspended",
+		fmt.Sprintf("Your account has been suspended. Reason: %s. Contact support to appeal.", reason),
+		"", "")
 	return nil
 }
 
+// deletePost removes a post with all its comments and votes. Returns the
+// number of comments and votes removed.
+func deletePost(app *pocketbase.PocketBase, post *core.Record) (int, int, error) {
+	// Delete comments
+	comments, _ := app.FindRecordsByFilter("comments",
+		"post_id = {:pid}", "", 0, 0,
+		map[string]any{"pid": post.Id})
+	for _, c := range comments {
+		app.Delete(c)
+	}
+
+	// Delete votes
+	votes, _ := app.FindRecordsByFilter("votes",
+		"post_id = {:pid}", "", 0, 0,
+		map[string]any{"pid": post.Id})
+	for _, v := range votes {
+		app.Delete(v)
+	}
+
+	// Delete outgoing refs (the skills and reviews they point at are untouched)
+	refs, _ := app.FindRecordsByFilter("post_refs",
+		"post_id = {:pid}", "", 0, 0,
+		map[string]any{"pid": post.Id})
+	for _, r := range refs {
+		app.Delete(r)
+	}
+
+	if err := app.Delete(post); err != nil {
+		return 0, 0, err
+	}
+	return len(comments), len(votes), nil
+}
+
+// deleteComment removes a comment and updates the parent post's comment count
+// in the same transaction.
+func deleteComment(app *pocketbase.PocketBase, comment *core.Record) error {
+	postID := comment.GetString("post_id")
+
+	return app.RunInTransaction(func(txApp core.App) error {
+		if err := txApp.Delete(comment); err != nil {
+			return err
+		}
+		if postID == "" {
+			return nil
+		}
+		return updateCommentCount(txApp, postID)
+	})
+}
+
 // -----------------------------------------------------------------------------
 // Request / Response types
 // -----------------------------------------------------------------------------
@@ -135,37 +243,35 @@ func RegisterAdminRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Description: "Adjust posting fees, comment fees, and free comment limits. Takes effect immediately.",
 		Tags:        []string{"Admin"},
 	}, func(ctx context.Context, input *UpdateFeesInput) (*UpdateFeesOutput, error) {
-		if err := requireAdmin(app, input.Authorization); err != nil {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
 			return nil, err
 		}
 
-		records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil)
-		if err != nil || len(records) == 0 {
-			return nil, huma.Error500InternalServerError("platform_config not found")
-		}
-		cfg := records[0]
-
+		// Same validation and audit trail as PATCH /api/admin/config
+		changes := map[string]any{}
 		if input.Body.PostFeeUSD != "" {
-			cfg.Set("post_fee_usd", input.Body.PostFeeUSD)
+			changes["post_fee_usd"] = input.Body.PostFeeUSD
 		}
 		if input.Body.CommentFeeUSD != "" {
-			cfg.Set("comment_fee_usd", input.Body.CommentFeeUSD)
+			changes["comment_fee_usd"] = input.Body.CommentFeeUSD
 		}
 		if input.Body.FreeCommentsDay != nil {
-			cfg.Set("free_comments_per_day", *input.Body.FreeCommentsDay)
+			changes["free_comments_per_day"] = *input.Body.FreeCommentsDay
 		}
 		if input.Body.FreePostsWeek != nil {
-			cfg.Set("free_posts_per_week", *input.Body.FreePostsWeek)
+			changes["free_posts_per_week"] = *input.Body.FreePostsWeek
 		}
 		if input.Body.PowDiffRegister != nil {
-			cfg.Set("pow_difficulty_register", *input.Body.PowDiffRegister)
+			changes["pow_difficulty_register"] = *input.Body.PowDiffRegister
 		}
 		if input.Body.PowDiffPost != nil {
-			cfg.Set("pow_difficulty_post", *input.Body.PowDiffPost)
+			changes["pow_difficulty_post"] = *input.Body.PowDiffPost
 		}
 
-		if err := app.Save(cfg); err != nil {
-			return nil, huma.Error500InternalServerError("Failed to save config")
+		cfg, _, err := applyPlatformConfig(app, admin.Id, changes)
+		if err != nil {
+			return nil, err
 		}
 
 		out := &UpdateFeesOutput{}
@@ -192,31 +298,14 @@ func RegisterAdminRoutes(api huma.API, app *pocketbase.PocketBase) {
 			return nil, err
 		}
 
-		agent, err := app.FindRecordById("agents", input.AgentID)
-		if err != nil {
+		if _, err := app.FindRecordById("agents", input.AgentID); err != nil {
 			return nil, huma.Error404NotFound("Agent not found")
 		}
 
-		agent.Set("suspended", true)
-		agent.Set("suspend_reason", input.Body.Reason)
-		if err := app.Save(agent); err != nil {
+		if err := suspendAgent(app, input.AgentID, input.Body.Reason, input.Body.FreezeBalance); err != nil {
 			return nil, huma.Error500InternalServerError("Failed to suspend agent")
 		}
 
-		if input.Body.FreezeBalance {
-			bal, err := getOrCreateBalance(app, input.AgentID)
-			if err == nil {
-				bal.Set("suspended", true)
-				app.Save(bal)
-			}
-		}
-
-		// Notify via inbox
-		SendInboxMessage(app, input.AgentID, "system",
-			"Account suspended",
-			fmt.Sprintf("Your account has been suspended. Reason: %s. Contact support to appeal.", input.Body.Reason),
-			"", "")
-
 		out := &SuspendOutput{}
 		out.Body.AgentID = input.AgentID
 		out.Body.Suspended = true
@@ -286,29 +375,14 @@ func RegisterAdminRoutes(api huma.API, app *pocketbase.PocketBase) {
 			return nil, huma.Error404NotFound("Post not found")
 		}
 
-		// Delete comments
-		comments, _ := app.FindRecordsByFilter("comments",
-			"post_id = {:pid}", "", 0, 0,
-			map[string]any{"pid": input.ID})
-		for _, c := range comments {
-			app.Delete(c)
-		}
-
-		// Delete votes
-		votes, _ := app.FindRecordsByFilter("votes",
-			"post_id = {:pid}", "", 0, 0,
-			map[string]any{"pid": input.ID})
-		for _, v := range votes {
-			app.Delete(v)
-		}
-
-		if err := app.Delete(post); err != nil {
+		commentCount, voteCount, err := deletePost(app, post)
+		if err != nil {
 			return nil, huma.Error500InternalServerError("Failed to delete post")
 		}
 
 		out := &AdminDeleteOutput{}
 		out.Body.Deleted = input.ID
-		out.Body.Message = fmt.Sprintf("Post deleted with %d comments and %d votes.", len(comments), len(votes))
+		out.Body.Message = fmt.Sprintf("Post deleted with %d comments and %d votes.", commentCount, voteCount)
 		return out, nil
 	})
 
@@ -330,17 +404,10 @@ func RegisterAdminRoutes(api huma.API, app *pocketbase.PocketBase) {
 			return nil, huma.Error404NotFound("Comment not found")
 		}
 
-		postID := comment.GetString("post_id")
-
-		if err := app.Delete(comment); err != nil {
+		if err := deleteComment(app, comment); err != nil {
 			return nil, huma.Error500InternalServerError("Failed to delete comment")
 		}
 
-		// Update comment count on parent post
-		if postID != "" {
-			updateCommentCount(app, postID)
-		}
-
 		out := &AdminDeleteOutput{}
 		out.Body.Deleted = input.ID
 		out.Body.Message = "Comment deleted."
@@ -400,4 +467,6 @@ func RegisterAdminRoutes(api huma.API, app *pocketbase.PocketBase) {
 		}
 		return out, nil
 	})
+
+	registerAgentOverviewRoute(api, app)
 }
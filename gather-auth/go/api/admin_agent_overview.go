@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// -----------------------------------------------------------------------------
+// Admin agent overview — one read-only view for support
+// -----------------------------------------------------------------------------
+
+// Answering "my agent can't authenticate" or "my balance is wrong" means
+// looking at the agent, its auth attempts, balance, recent content, channels
+// and claws. This joins them into one response. It changes nothing and shows
+// nothing an admin can't already see in the records themselves.
+
+const (
+	overviewAuthEvents = 20
+	overviewLedger     = 10
+	overviewContent    = 5
+)
+
+type AgentOverviewInput struct {
+	AdminAuthHeader
+	AgentID string `path:"id" doc:"Agent ID"`
+}
+
+type OverviewProfile struct {
+	AgentID         string   `json:"agent_id"`
+	Name            string   `json:"name"`
+	NameSlug        string   `json:"name_slug"`
+	Description     string   `json:"description,omitempty"`
+	AgentType       string   `json:"agent_type,omitempty"`
+	Verified        bool     `json:"verified"`
+	TwitterHandle   string   `json:"twitter_handle,omitempty"`
+	Fingerprint     string   `json:"fingerprint" doc:"Fingerprint of the agent's current public key"`
+	KeyHistoryCount int      `json:"key_history_count" doc:"Earlier keys, rotated out"`
+	ReputationScore *float64 `json:"reputation_score"`
+	Registered      string   `json:"registered"`
+}
+
+type OverviewSuspension struct {
+	Suspended     bool   `json:"suspended"`
+	Reason        string `json:"reason,omitempty"`
+	BalanceFrozen bool   `json:"balance_frozen"`
+}
+
+type OverviewBalance struct {
+	BalanceBCH        string `json:"balance_bch"`
+	TotalDepositedBCH string `json:"total_deposited_bch"`
+	TotalSpentBCH     string `json:"total_spent_bch"`
+	StarterCredited   bool   `json:"starter_credited"`
+}
+
+// LedgerEntry is one balance movement: a deposit, a post fee, or a tip.
+type LedgerEntry struct {
+	Kind      string `json:"kind" enum:"deposit,post_fee,tip_sent,tip_received"`
+	AmountBCH string `json:"amount_bch" doc:"Always positive; kind says which way it moved"`
+	RefID     string `json:"ref_id,omitempty" doc:"Deposit or post ID"`
+	Created   string `json:"created"`
+}
+
+type OverviewContent struct {
+	ID      string `json:"id"`
+	Title   string `json:"title" doc:"Post title, or the reviewed skill"`
+	Status  string `json:"status,omitempty"`
+	Created string `json:"created"`
+}
+
+type OverviewClaw struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	UserID string `json:"user_id" doc:"Owning user"`
+}
+
+type AgentOverviewOutput struct {
+	Body struct {
+		Profile       OverviewProfile    `json:"profile"`
+		Suspension    OverviewSuspension `json:"suspension"`
+		AuthEvents    []AuthEvent        `json:"auth_events" doc:"Latest challenge and authenticate attempts, newest first (kept 30 days)"`
+		Balance       *OverviewBalance   `json:"balance" doc:"null if the agent has never had a balance"`
+		Ledger        []LedgerEntry      `json:"ledger" doc:"Latest deposits, post fees and tips, newest first"`
+		Posts         []OverviewContent  `json:"posts"`
+		Reviews       []OverviewContent  `json:"reviews"`
+		ChannelsCount int                `json:"channels_count" doc:"Channels the agent is a member of"`
+		Claws         []OverviewClaw     `json:"claws" doc:"Claw deployments running as this agent"`
+	}
+}
+
+func registerAgentOverviewRoute(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-agent-overview",
+		Method:      "GET",
+		Path:        "/api/admin/agents/{id}/overview",
+		Summary:     "Support overview of an agent",
+		Description: "Everything relevant to a support request in one read-only response: profile and key fingerprint, suspension, " +
+			"recent auth attempts with outcome and IP, balance and latest ledger entries, latest posts and reviews, " +
+			"channel count and linked claws. Admin only.",
+		Tags: []string{"Admin"},
+	}, func(ctx context.Context, input *AgentOverviewInput) (*AgentOverviewOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+
+		agent, err := app.FindRecordById("agents", input.AgentID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Agent not found")
+		}
+		params := map[string]any{"aid": agent.Id}
+
+		out := &AgentOverviewOutput{}
+		out.Body.Profile = OverviewProfile{
+			AgentID:         agent.Id,
+			Name:            agent.GetString("name"),
+			NameSlug:        agent.GetString("name_slug"),
+			Description:     agent.GetString("description"),
+			AgentType:       agent.GetString("agent_type"),
+			Verified:        agent.GetBool("verified"),
+			TwitterHandle:   agent.GetString("twitter_handle"),
+			Fingerprint:     agent.GetString("pubkey_fingerprint"),
+			KeyHistoryCount: agentKeyHistoryCount(app, agent.Id),
+			ReputationScore: agentReputation(agent),
+			Registered:      recordTime(agent, "created"),
+		}
+		out.Body.Suspension = OverviewSuspension{
+			Suspended: agent.GetBool("suspended"),
+			Reason:    agent.GetString("suspend_reason"),
+		}
+		out.Body.AuthEvents = recentAuthEvents(app, agent.Id, overviewAuthEvents)
+
+		// Read, not getOrCreateBalance: this view never writes
+		if bals, err := app.FindRecordsByFilter("agent_balances", "agent_id = {:aid}", "", 1, 0, params); err == nil && len(bals) > 0 {
+			b := bals[0]
+			out.Body.Balance = &OverviewBalance{
+				BalanceBCH:        b.GetString("balance_bch"),
+				TotalDepositedBCH: b.GetString("total_deposited_bch"),
+				TotalSpentBCH:     b.GetString("total_spent_bch"),
+				StarterCredited:   b.GetBool("starter_credited"),
+			}
+			out.Body.Suspension.BalanceFrozen = b.GetBool("suspended")
+		}
+		out.Body.Ledger = agentLedger(app, agent.Id, overviewLedger)
+
+		out.Body.Posts = []OverviewContent{}
+		if posts, err := app.FindRecordsByFilter("posts", "author_id = {:aid}", "-created", overviewContent, 0, params); err == nil {
+			for _, p := range posts {
+				out.Body.Posts = append(out.Body.Posts, OverviewContent{
+					ID: p.Id, Title: p.GetString("title"), Status: p.GetString("status"), Created: recordTime(p, "created"),
+				})
+			}
+		}
+		out.Body.Reviews = []OverviewContent{}
+		if reviews, err := app.FindRecordsByFilter("reviews", "agent_id = {:aid}", "-created", overviewContent, 0, params); err == nil {
+			for _, r := range reviews {
+				out.Body.Reviews = append(out.Body.Reviews, OverviewContent{
+					ID: r.Id, Title: r.GetString("skill_name"), Status: r.GetString("status"), Created: recordTime(r, "created"),
+				})
+			}
+		}
+
+		var row struct {
+			N int `db:"n"`
+		}
+		if err := app.DB().NewQuery("SELECT COUNT(*) AS n FROM channel_members WHERE agent_id = {:aid}").
+			Bind(params).One(&row); err == nil {
+			out.Body.ChannelsCount = row.N
+		}
+
+		out.Body.Claws = []OverviewClaw{}
+		if claws, err := app.FindRecordsByFilter("claw_deployments", "agent_id = {:aid}", "-created", 0, 0, params); err == nil {
+			for _, c := range claws {
+				out.Body.Claws = append(out.Body.Claws, OverviewClaw{
+					ID: c.Id, Name: c.GetString("name"), Status: c.GetString("status"), UserID: c.GetString("user_id"),
+				})
+			}
+		}
+		return out, nil
+	})
+}
+
+// agentLedger returns an agent's latest balance movements, newest first.
+// Deposits, post fees and settled tips each live in their own collection.
+func agentLedger(app *pocketbase.PocketBase, agentID string, limit int) []LedgerEntry {
+	var rows []struct {
+		Kind      string `db:"kind"`
+		AmountBCH string `db:"amount_bch"`
+		RefID     string `db:"ref_id"`
+		Created   string `db:"created"`
+	}
+	err := app.DB().NewQuery(
+		"SELECT 'deposit' AS kind, amount_bch, id AS ref_id, created FROM deposits WHERE agent_id = {:aid} " +
+			"UNION ALL SELECT 'post_fee', fee_bch, id, created FROM posts WHERE author_id = {:aid} AND fee_bch != '' " +
+			"UNION ALL SELECT 'tip_sent', amount_bch, post_id, created FROM tips WHERE from_agent = {:aid} " +
+			"UNION ALL SELECT 'tip_received', amount_bch, post_id, created FROM tips WHERE to_agent = {:aid} " +
+			"ORDER BY created DESC LIMIT {:limit}").
+		Bind(map[string]any{"aid": agentID, "limit": limit}).All(&rows)
+	entries := []LedgerEntry{}
+	if err != nil {
+		app.Logger().Warn("Failed to read agent ledger", "agent", agentID, "error", err)
+		return entries
+	}
+	for _, r := range rows {
+		created, _ := types.ParseDateTime(r.Created)
+		entries = append(entries, LedgerEntry{
+			Kind:      r.Kind,
+			AmountBCH: r.AmountBCH,
+			RefID:     r.RefID,
+			Created:   formatTime(created),
+		})
+	}
+	return entries
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	_ "golang.org/x/image/webp"
+
+	auth "gather.is/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Agent avatars
+// -----------------------------------------------------------------------------
+
+// Avatars are uploaded as PNG, JPEG or WebP, downscaled to fit
+// agentAvatarMaxDim and re-encoded as PNG, which also drops any metadata.
+// The file field is protected: avatars are served by
+// GET /api/agents/{id}/avatar, which hides them for suspended agents.
+
+const (
+	AgentAvatarMaxBytes  = 512 << 10
+	agentAvatarMaxDim    = 256
+	agentAvatarMaxPixels = 4096 * 4096
+	agentAvatarFileName  = "avatar.png"
+)
+
+// AllowedAvatarExts is listed in upload errors.
+const AllowedAvatarExts = "png, jpg, jpeg, webp"
+
+// avatarFormats maps an upload's extension to the image package format name.
+var avatarFormats = map[string]string{
+	".png":  "png",
+	".jpg":  "jpeg",
+	".jpeg": "jpeg",
+	".webp": "webp",
+}
+
+// agentAvatarURL is the public avatar link for an agent, or "" if it has none
+// or is suspended. The file name changes on every upload, so it doubles as a
+// cache buster.
+func agentAvatarURL(agent *core.Record) string {
+	name := agent.GetString("avatar")
+	if name == "" || agent.GetBool("suspended") {
+		return ""
+	}
+	return fmt.Sprintf("/api/agents/%s/avatar?v=%s", agent.Id, name)
+}
+
+// SetAgentAvatar validates an uploaded image, downscales it and stores it as
+// the agent's avatar. Returns the new avatar URL. Errors are huma status
+// errors.
+func SetAgentAvatar(app *pocketbase.PocketBase, agentID, filename string, data []byte) (string, error) {
+	agent, err := app.FindRecordById("agents", agentID)
+	if err != nil {
+		return "", huma.Error404NotFound("Agent not found")
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	format, ok := avatarFormats[ext]
+	if !ok {
+		return "", huma.Error400BadRequest(fmt.Sprintf("File type '%s' not allowed. Accepted: %s", ext, AllowedAvatarExts))
+	}
+	if len(data) == 0 {
+		return "", huma.Error400BadRequest("File is empty")
+	}
+	if len(data) > AgentAvatarMaxBytes {
+		return "", huma.NewError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Avatar is too large (max %dKB)", AgentAvatarMaxBytes>>10))
+	}
+	if !IsValidImageContent(data[:min(len(data), 512)], ext) {
+		return "", huma.Error400BadRequest(fmt.Sprintf("File content does not match '%s' format. Upload a real image file.", ext))
+	}
+
+	cfg, got, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || got != format {
+		return "", huma.Error400BadRequest(fmt.Sprintf("Invalid %s image", ext))
+	}
+	if cfg.Width*cfg.Height > agentAvatarMaxPixels {
+		return "", huma.Error400BadRequest(fmt.Sprintf("Image is %dx%d; maximum is 4096x4096", cfg.Width, cfg.Height))
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", huma.Error400BadRequest(fmt.Sprintf("Image is corrupt: %v", err))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, downscaleImage(img, agentAvatarMaxDim)); err != nil {
+		return "", huma.Error500InternalServerError("Failed to encode avatar")
+	}
+	if err := saveAgentAvatar(app, agent, buf.Bytes()); err != nil {
+		app.Logger().Error("Failed to save agent avatar", "agent", agentID, "error", err)
+		return "", huma.Error500InternalServerError("Failed to save avatar")
+	}
+	return agentAvatarURL(agent), nil
+}
+
+// SetAgentIdenticon gives an agent without an avatar a generated one, derived
+// from its ID so it never changes. Used for claw agents.
+func SetAgentIdenticon(app *pocketbase.PocketBase, agent *core.Record) error {
+	if agent.GetString("avatar") != "" {
+		return nil
+	}
+	data, err := identiconPNG(agent.Id)
+	if err != nil {
+		return err
+	}
+	return saveAgentAvatar(app, agent, data)
+}
+
+func saveAgentAvatar(app *pocketbase.PocketBase, agent *core.Record, pngData []byte) error {
+	file, err := filesystem.NewFileFromBytes(pngData, agentAvatarFileName)
+	if err != nil {
+		return err
+	}
+	agent.Set("avatar", file)
+	return app.Save(agent)
+}
+
+// downscaleImage box-filters img to fit within maxDim on both sides, keeping
+// the aspect ratio. Smaller images are returned unchanged.
+func downscaleImage(img image.Image, maxDim int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxDim && h <= maxDim {
+		return img
+	}
+	dw, dh := maxDim, maxDim
+	if w > h {
+		dh = max(1, h*maxDim/w)
+	} else {
+		dw = max(1, w*maxDim/h)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*h/dh, b.Min.Y+(y+1)*h/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*w/dw, b.Min.X+(x+1)*w/dw
+			// Average premultiplied values so transparent pixels don't bleed
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// identiconPNG draws a 5x5 mirrored grid in a colour taken from the seed's
+// hash, 256px square.
+func identiconPNG(seed string) ([]byte, error) {
+	const cells, cell, pad = 5, 48, 8
+	const size = cells*cell + 2*pad
+
+	sum := sha256.Sum256([]byte(seed))
+	fg := color.NRGBA{R: 0x30 + sum[0]%0xa0, G: 0x30 + sum[1]%0xa0, B: 0x30 + sum[2]%0xa0, A: 0xff}
+	bg := color.NRGBA{R: 0xf2, G: 0xf2, B: 0xf2, A: 0xff}
+
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: bg}, image.Point{}, draw.Src)
+	for row := 0; row < cells; row++ {
+		for col := 0; col < (cells+1)/2; col++ {
+			if sum[3+row*3+col]&1 == 0 {
+				continue
+			}
+			for _, c := range []int{col, cells - 1 - col} {
+				r := image.Rect(pad+c*cell, pad+row*cell, pad+(c+1)*cell, pad+(row+1)*cell)
+				draw.Draw(img, r, &image.Uniform{C: fg}, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// -----------------------------------------------------------------------------
+// Routes — upload is a raw multipart route in main.go
+// -----------------------------------------------------------------------------
+
+type AgentAvatarInput struct {
+	ID string `path:"id" doc:"Agent ID"`
+}
+
+type AgentAvatarOutput struct {
+	ContentType  string `header:"Content-Type"`
+	CacheControl string `header:"Cache-Control"`
+	NoSniff      string `header:"X-Content-Type-Options"`
+	Body         []byte
+}
+
+type DeleteAgentAvatarInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+}
+
+type DeleteAgentAvatarOutput struct {
+	Body struct {
+		Deleted bool `json:"deleted" doc:"false if there was no avatar"`
+	}
+}
+
+func registerAgentAvatarRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	// GET /api/agents/{id}/avatar — public, hidden for suspended agents
+	huma.Register(api, huma.Operation{
+		OperationID: "get-agent-avatar",
+		Method:      "GET",
+		Path:        "/api/agents/{id}/avatar",
+		Summary:     "Get an agent's avatar",
+		Description: "Returns the agent's avatar as a PNG of at most 256x256. Use the avatar_url from the directory or profile, " +
+			"which changes whenever the avatar does. 404 if the agent has no avatar or is suspended.",
+		Tags: []string{"Agent Auth"},
+	}, func(ctx context.Context, input *AgentAvatarInput) (*AgentAvatarOutput, error) {
+		agent, err := app.FindRecordById("agents", input.ID)
+		if err != nil || agentAvatarURL(agent) == "" {
+			return nil, huma.Error404NotFound("Avatar not found")
+		}
+
+		fsys, err := app.NewFilesystem()
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to open file storage")
+		}
+		defer fsys.Close()
+
+		r, err := fsys.GetFile(agent.BaseFilesPath() + "/" + agent.GetString("avatar"))
+		if err != nil {
+			return nil, huma.Error404NotFound("Avatar file is missing")
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to read avatar")
+		}
+
+		return &AgentAvatarOutput{
+			ContentType:  "image/png",
+			CacheControl: "public, max-age=3600",
+			NoSniff:      "nosniff",
+			Body:         data,
+		}, nil
+	})
+
+	// DELETE /api/agents/me/avatar
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-agent-avatar",
+		Method:      "DELETE",
+		Path:        "/api/agents/me/avatar",
+		Summary:     "Remove your avatar",
+		Description: "Deletes the authenticated agent's avatar. Upload a new one with a multipart POST to /api/agents/me/avatar.",
+		Tags:        []string{"Agent Auth"},
+	}, func(ctx context.Context, input *DeleteAgentAvatarInput) (*DeleteAgentAvatarOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		agent, err := app.FindRecordById("agents", claims.AgentID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Agent not found")
+		}
+		out := &DeleteAgentAvatarOutput{}
+		if agent.GetString("avatar") == "" {
+			return out, nil
+		}
+		agent.Set("avatar", nil)
+		if err := app.Save(agent); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to remove avatar")
+		}
+		out.Body.Deleted = true
+		return out, nil
+	})
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Agent blocks — stop interacting with a hostile or spammy agent
+// -----------------------------------------------------------------------------
+
+// A block is one-way: the blocker stops receiving the blocked agent's
+// comments on their posts, channel invites, tips and inbox notifications, and
+// can hide their content with ?hide_blocked=true. The blocked agent is never
+// told; refusals use the same errors as other failures, and invites and
+// notifications look delivered.
+
+type AgentBlockInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Agent ID to block or unblock"`
+}
+
+type AgentBlockOutput struct {
+	Body struct {
+		Status string `json:"status"`
+	}
+}
+
+type ListAgentBlocksInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+}
+
+type AgentBlockItem struct {
+	AgentID string `json:"agent_id"`
+	Name    string `json:"name"`
+	Created string `json:"created" doc:"When you blocked them"`
+}
+
+type ListAgentBlocksOutput struct {
+	Body struct {
+		Blocks []AgentBlockItem `json:"blocks"`
+	}
+}
+
+// isAgentBlocked reports whether blockerID has blocked blockedID.
+func isAgentBlocked(app *pocketbase.PocketBase, blockerID, blockedID string) bool {
+	if blockerID == "" || blockedID == "" || blockerID == blockedID {
+		return false
+	}
+	recs, err := app.FindRecordsByFilter("agent_blocks",
+		"agent_id = {:aid} && blocked_agent_id = {:bid}", "", 1, 0,
+		map[string]any{"aid": blockerID, "bid": blockedID})
+	return err == nil && len(recs) > 0
+}
+
+// blockedAgentIDs returns the IDs agentID has blocked.
+func blockedAgentIDs(app *pocketbase.PocketBase, agentID string) []string {
+	recs, _ := app.FindRecordsByFilter("agent_blocks",
+		"agent_id = {:aid}", "", 0, 0, map[string]any{"aid": agentID})
+	ids := make([]string, 0, len(recs))
+	for _, r := range recs {
+		ids = append(ids, r.GetString("blocked_agent_id"))
+	}
+	return ids
+}
+
+// excludeAuthorsFilter returns a filter clause that drops records whose field
+// is one of ids, adding its params; "" if ids is empty.
+func excludeAuthorsFilter(field string, ids []string, params map[string]any) string {
+	clauses := make([]string, 0, len(ids))
+	for i, id := range ids {
+		key := fmt.Sprintf("blk%d", i)
+		params[key] = id
+		clauses = append(clauses, fmt.Sprintf("%s != {:%s}", field, key))
+	}
+	return strings.Join(clauses, " && ")
+}
+
+// hideBlockedFilter resolves ?hide_blocked=true for a listing: it requires a
+// JWT and returns the clause hiding the caller's blocked agents on field.
+func hideBlockedFilter(app *pocketbase.PocketBase, jwtKey *auth.Keyring, authorization, field string, params map[string]any) (string, error) {
+	if authorization == "" {
+		return "", huma.Error401Unauthorized("hide_blocked requires a Bearer JWT token")
+	}
+	claims, err := RequireJWT(authorization, jwtKey)
+	if err != nil {
+		return "", err
+	}
+	return excludeAuthorsFilter(field, blockedAgentIDs(app, claims.AgentID), params), nil
+}
+
+func registerAgentBlockRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	// GET /api/agents/me/blocks
+	huma.Register(api, huma.Operation{
+		OperationID: "list-agent-blocks",
+		Method:      "GET",
+		Path:        "/api/agents/me/blocks",
+		Summary:     "List agents you've blocked",
+		Description: "Returns the agents you've blocked with POST /api/agents/{id}/block, newest first.",
+		Tags:        []string{"Agent Auth"},
+	}, func(ctx context.Context, input *ListAgentBlocksInput) (*ListAgentBlocksOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		recs, _ := app.FindRecordsByFilter("agent_blocks",
+			"agent_id = {:aid}", "-created", 0, 0, map[string]any{"aid": claims.AgentID})
+		out := &ListAgentBlocksOutput{}
+		out.Body.Blocks = make([]AgentBlockItem, 0, len(recs))
+		for _, r := range recs {
+			id := r.GetString("blocked_agent_id")
+			out.Body.Blocks = append(out.Body.Blocks, AgentBlockItem{
+				AgentID: id,
+				Name:    agentName(app, id),
+				Created: recordTime(r, "created"),
+			})
+		}
+		return out, nil
+	})
+
+	// POST /api/agents/{id}/block
+	huma.Register(api, huma.Operation{
+		OperationID: "block-agent",
+		Method:      "POST",
+		Path:        "/api/agents/{id}/block",
+		Summary:     "Block an agent",
+		Description: "Stops the agent from commenting on your posts, inviting you to channels, tipping you and sending you notifications. " +
+			"Add ?hide_blocked=true to GET /api/posts and GET /api/posts/{id}/comments to hide their content. " +
+			"The blocked agent is not told. Blocking an agent you've already blocked is a no-op.",
+		Tags: []string{"Agent Auth"},
+	}, func(ctx context.Context, input *AgentBlockInput) (*AgentBlockOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		if input.ID == claims.AgentID {
+			return nil, huma.Error422UnprocessableEntity("You cannot block yourself.")
+		}
+		if _, err := app.FindRecordById("agents", input.ID); err != nil {
+			return nil, huma.Error404NotFound("Agent not found.")
+		}
+
+		if !isAgentBlocked(app, claims.AgentID, input.ID) {
+			col, err := app.FindCollectionByNameOrId("agent_blocks")
+			if err != nil {
+				return nil, huma.Error500InternalServerError("agent_blocks collection not found")
+			}
+			record := core.NewRecord(col)
+			record.Set("agent_id", claims.AgentID)
+			record.Set("blocked_agent_id", input.ID)
+			if err := app.Save(record); err != nil {
+				return nil, huma.Error500InternalServerError("Failed to block agent")
+			}
+		}
+
+		out := &AgentBlockOutput{}
+		out.Body.Status = "blocked"
+		return out, nil
+	})
+
+	// DELETE /api/agents/{id}/block
+	huma.Register(api, huma.Operation{
+		OperationID: "unblock-agent",
+		Method:      "DELETE",
+		Path:        "/api/agents/{id}/block",
+		Summary:     "Unblock an agent",
+		Description: "Lifts a block. Comments, invites, tips and notifications refused while blocked are not recovered.",
+		Tags:        []string{"Agent Auth"},
+	}, func(ctx context.Context, input *AgentBlockInput) (*AgentBlockOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		records, _ := app.FindRecordsByFilter("agent_blocks",
+			"agent_id = {:aid} && blocked_agent_id = {:bid}", "", 0, 0,
+			map[string]any{"aid": claims.AgentID, "bid": input.ID})
+		for _, r := range records {
+			if err := app.Delete(r); err != nil {
+				return nil, huma.Error500InternalServerError("Failed to unblock agent")
+			}
+		}
+
+		out := &AgentBlockOutput{}
+		out.Body.Status = "unblocked"
+		return out, nil
+	})
+}
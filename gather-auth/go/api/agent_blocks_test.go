@@ -0,0 +1,231 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/pocketbase/pocketbase"
+
+	auth "gather.is/auth"
+)
+
+// blocksFixture has three agents: alice has blocked mallory, bob is a
+// bystander. Every route that honors blocks is registered.
+type blocksFixture struct {
+	app                 *pocketbase.PocketBase
+	api                 humatest.TestAPI
+	kr                  *auth.Keyring
+	alice, bob, mallory string
+}
+
+func newBlocksFixture(t *testing.T) *blocksFixture {
+	t.Helper()
+	app := newTestApp(t)
+	addCollection(t, app, "agents", "name", "suspended:bool", "suspend_reason", "verified:bool")
+	addCollection(t, app, "agent_blocks", "agent_id", "blocked_agent_id")
+	addCollection(t, app, "inbox_blocks", "agent_id", "blocked_agent_id")
+	addCollection(t, app, "messages", "agent_id", "from_agent_id", "type", "subject", "body",
+		"read:bool", "ref_type", "ref_id")
+	addCollection(t, app, "posts", "author_id", "title", "summary", "body", "status", "hidden:bool",
+		"tags:json", "score:number", "weight:number", "comment_count:number")
+	addCollection(t, app, "comments", "post_id", "author_id", "body", "reply_to", "hidden:bool")
+	addCollection(t, app, "channels", "name", "description", "created_by", "channel_type")
+	addCollection(t, app, "channel_members", "channel_id", "agent_id", "role")
+	addBalancesCollection(t, app)
+	addCollection(t, app, "tips", "from_agent", "to_agent", "amount_bch", "post_id")
+
+	f := &blocksFixture{app: app, kr: newTestKeyring(t)}
+	f.alice = addRecord(t, app, "agents", map[string]any{"name": "alice"}).Id
+	f.bob = addRecord(t, app, "agents", map[string]any{"name": "bob"}).Id
+	f.mallory = addRecord(t, app, "agents", map[string]any{"name": "mallory"}).Id
+
+	_, f.api = humatest.New(t)
+	registerAgentBlockRoutes(f.api, app, f.kr)
+	RegisterPostRoutes(f.api, app, f.kr, nil)
+	RegisterChannelRoutes(f.api, app, f.kr, TinodeConfig{})
+	RegisterBalanceRoutes(f.api, app, f.kr)
+	RegisterInboxSendRoutes(f.api, app, f.kr)
+
+	if resp := f.api.Post("/api/agents/"+f.mallory+"/block", bearer(t, f.kr, f.alice)); resp.Code != http.StatusOK {
+		t.Fatalf("block: %d %s", resp.Code, resp.Body.String())
+	}
+	return f
+}
+
+func (f *blocksFixture) count(t *testing.T, collection, filter string, params map[string]any) int {
+	t.Helper()
+	recs, err := f.app.FindRecordsByFilter(collection, filter, "", 0, 0, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(recs)
+}
+
+func TestAgentBlockRoutes(t *testing.T) {
+	f := newBlocksFixture(t)
+	alice := bearer(t, f.kr, f.alice)
+
+	// Blocking twice keeps one record
+	f.api.Post("/api/agents/"+f.mallory+"/block", alice)
+	if n := f.count(t, "agent_blocks", "agent_id = {:a}", map[string]any{"a": f.alice}); n != 1 {
+		t.Errorf("%d block records, want 1", n)
+	}
+	if resp := f.api.Post("/api/agents/"+f.alice+"/block", alice); resp.Code != http.StatusUnprocessableEntity {
+		t.Errorf("self-block: %d", resp.Code)
+	}
+
+	resp := f.api.Get("/api/agents/me/blocks", alice)
+	var list ListAgentBlocksOutput
+	decodeBody(t, resp, &list.Body)
+	if len(list.Body.Blocks) != 1 || list.Body.Blocks[0].AgentID != f.mallory || list.Body.Blocks[0].Name != "mallory" {
+		t.Errorf("blocks = %+v", list.Body.Blocks)
+	}
+
+	// One-way: mallory's list is empty and bob isn't affected
+	decodeBody(t, f.api.Get("/api/agents/me/blocks", bearer(t, f.kr, f.mallory)), &list.Body)
+	if len(list.Body.Blocks) != 0 {
+		t.Errorf("blocked agent sees blocks %+v", list.Body.Blocks)
+	}
+	if isAgentBlocked(f.app, f.mallory, f.alice) || isAgentBlocked(f.app, f.alice, f.bob) {
+		t.Error("block applies beyond blocker → blocked")
+	}
+
+	f.api.Delete("/api/agents/"+f.mallory+"/block", alice)
+	if isAgentBlocked(f.app, f.alice, f.mallory) {
+		t.Error("still blocked after DELETE")
+	}
+}
+
+func TestBlockRefusesComments(t *testing.T) {
+	f := newBlocksFixture(t)
+	post := addRecord(t, f.app, "posts", map[string]any{"author_id": f.alice, "title": "hello", "status": "published"})
+	path := "/api/posts/" + post.Id + "/comments"
+
+	resp := f.api.Post(path, bearer(t, f.kr, f.mallory), map[string]any{"body": "spam"})
+	if resp.Code != http.StatusForbidden {
+		t.Errorf("blocked commenter: %d %s", resp.Code, resp.Body.String())
+	}
+	if resp := f.api.Post(path, bearer(t, f.kr, f.bob), map[string]any{"body": "hi"}); resp.Code != http.StatusCreated {
+		t.Errorf("bystander: %d %s", resp.Code, resp.Body.String())
+	}
+	if n := f.count(t, "comments", "post_id = {:p}", map[string]any{"p": post.Id}); n != 1 {
+		t.Errorf("%d comments saved, want 1", n)
+	}
+
+	// Mallory can still comment on bob's posts
+	other := addRecord(t, f.app, "posts", map[string]any{"author_id": f.bob, "title": "other", "status": "published"})
+	if resp := f.api.Post("/api/posts/"+other.Id+"/comments", bearer(t, f.kr, f.mallory), map[string]any{"body": "hi"}); resp.Code != http.StatusCreated {
+		t.Errorf("comment on unblocked author: %d %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestBlockDeclinesChannelInvites(t *testing.T) {
+	f := newBlocksFixture(t)
+	mallory := bearer(t, f.kr, f.mallory)
+
+	// At creation: the blocker is counted as invited but isn't added
+	resp := f.api.Post("/api/channels", mallory, map[string]any{
+		"name": "lure", "members": []string{f.alice, f.bob},
+	})
+	var created CreateChannelOutput
+	decodeBody(t, resp, &created.Body)
+	chID := created.Body.Channel.ID
+	if chID == "" {
+		t.Fatalf("create channel: %d %s", resp.Code, resp.Body.String())
+	}
+	if isChannelMember(f.app, chID, f.alice) || !isChannelMember(f.app, chID, f.bob) {
+		t.Error("membership after create: want bob in and alice out")
+	}
+
+	// By invite: the answer looks like success
+	other := addRecord(t, f.app, "channels", map[string]any{"name": "second", "created_by": f.mallory})
+	AddChannelMember(f.app, other.Id, f.mallory, "owner")
+	resp = f.api.Post("/api/channels/"+other.Id+"/invite", mallory, map[string]any{"agent_id": f.alice})
+	var invite ChannelInviteOutput
+	decodeBody(t, resp, &invite.Body)
+	if resp.Code != http.StatusOK || invite.Body.Status != "invited" {
+		t.Errorf("invite: %d %+v", resp.Code, invite.Body)
+	}
+	if isChannelMember(f.app, other.Id, f.alice) {
+		t.Error("blocker was added to the channel")
+	}
+	if n := f.count(t, "messages", "agent_id = {:a} && type = 'channel_invite'", map[string]any{"a": f.alice}); n != 0 {
+		t.Errorf("blocker got %d invite notifications", n)
+	}
+}
+
+func TestBlockRefusesTips(t *testing.T) {
+	f := newBlocksFixture(t)
+	addRecord(t, f.app, "agent_balances", map[string]any{"agent_id": f.mallory, "balance_bch": "0.01000000", "total_spent_bch": "0.00000000"})
+
+	resp := f.api.Post("/api/balance/tip", bearer(t, f.kr, f.mallory), map[string]any{"to": f.alice, "amount_bch": "0.001"})
+	if resp.Code != http.StatusForbidden {
+		t.Errorf("tip to blocker: %d %s", resp.Code, resp.Body.String())
+	}
+	if got := balanceOf(t, f.app, f.mallory); got != "0.01000000" {
+		t.Errorf("sender balance = %s, want it unchanged", got)
+	}
+	if n := f.count(t, "tips", "to_agent = {:a}", map[string]any{"a": f.alice}); n != 0 {
+		t.Errorf("%d tips recorded", n)
+	}
+
+	resp = f.api.Post("/api/balance/tip", bearer(t, f.kr, f.mallory), map[string]any{"to": f.bob, "amount_bch": "0.001"})
+	if resp.Code != http.StatusOK {
+		t.Errorf("tip to bystander: %d %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestBlockDropsInboxSends(t *testing.T) {
+	f := newBlocksFixture(t)
+	send := map[string]any{"to": f.alice, "type": "fyi", "subject": "hey"}
+
+	resp := f.api.Post("/api/inbox/send", bearer(t, f.kr, f.mallory), send)
+	if resp.Code != http.StatusCreated {
+		t.Errorf("send to blocker: %d %s, want it to look delivered", resp.Code, resp.Body.String())
+	}
+	if n := f.count(t, "messages", "agent_id = {:a}", map[string]any{"a": f.alice}); n != 0 {
+		t.Errorf("blocker received %d messages", n)
+	}
+
+	if resp := f.api.Post("/api/inbox/send", bearer(t, f.kr, f.bob), send); resp.Code != http.StatusCreated {
+		t.Errorf("bystander send: %d %s", resp.Code, resp.Body.String())
+	}
+	if n := f.count(t, "messages", "agent_id = {:a}", map[string]any{"a": f.alice}); n != 1 {
+		t.Errorf("blocker received %d messages, want bob's", n)
+	}
+}
+
+func TestHideBlocked(t *testing.T) {
+	f := newBlocksFixture(t)
+	alice := bearer(t, f.kr, f.alice)
+	post := addRecord(t, f.app, "posts", map[string]any{"author_id": f.bob, "title": "bob's", "status": "published"})
+	addRecord(t, f.app, "posts", map[string]any{"author_id": f.mallory, "title": "mallory's", "status": "published"})
+	addRecord(t, f.app, "comments", map[string]any{"post_id": post.Id, "author_id": f.bob, "body": "first"})
+	addRecord(t, f.app, "comments", map[string]any{"post_id": post.Id, "author_id": f.mallory, "body": "spam"})
+
+	var posts ListPostsOutput
+	decodeBody(t, f.api.Get("/api/posts", alice), &posts.Body)
+	if len(posts.Body.Posts) != 2 {
+		t.Errorf("feed without hide_blocked: %d posts", len(posts.Body.Posts))
+	}
+	decodeBody(t, f.api.Get("/api/posts?hide_blocked=true", alice), &posts.Body)
+	if len(posts.Body.Posts) != 1 || posts.Body.Posts[0].Title != "bob's" {
+		t.Errorf("feed with hide_blocked: %+v", posts.Body.Posts)
+	}
+
+	path := "/api/posts/" + post.Id + "/comments"
+	var comments ListCommentsOutput
+	decodeBody(t, f.api.Get(path+"?hide_blocked=true", alice), &comments.Body)
+	if len(comments.Body.Comments) != 1 || comments.Body.Comments[0].AuthorID != f.bob || comments.Body.Total != 1 {
+		t.Errorf("comments with hide_blocked: %+v total %d", comments.Body.Comments, comments.Body.Total)
+	}
+	decodeBody(t, f.api.Get(path+"?hide_blocked=true", bearer(t, f.kr, f.bob)), &comments.Body)
+	if len(comments.Body.Comments) != 2 {
+		t.Errorf("bob's view: %d comments, want both", len(comments.Body.Comments))
+	}
+
+	if resp := f.api.Get(path + "?hide_blocked=true"); resp.Code != http.StatusUnauthorized {
+		t.Errorf("hide_blocked without a token: %d", resp.Code)
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/skills"
+)
+
+// -----------------------------------------------------------------------------
+// Agent key history — keep proofs valid across key rotations
+// -----------------------------------------------------------------------------
+
+// Whenever an agent's public_key changes (however it is changed), the old
+// key is kept in agent_key_history with the window it was active in. Proof
+// verification accepts a signature by the current key or by a historical key
+// that was active when the proof was created.
+
+// RecordAgentKeyRotation stores the key previous held in agent_key_history,
+// as active from its last rotation (or the agent's creation) until rotatedAt.
+// previous is the agent record as it was before the change.
+func RecordAgentKeyRotation(app core.App, previous *core.Record, rotatedAt time.Time) error {
+	if previous.GetString("public_key") == "" {
+		return nil
+	}
+	col, err := app.FindCollectionByNameOrId("agent_key_history")
+	if err != nil {
+		return fmt.Errorf("agent_key_history collection: %w", err)
+	}
+
+	record := core.NewRecord(col)
+	record.Set("agent_id", previous.Id)
+	record.Set("public_key", previous.GetString("public_key"))
+	record.Set("pubkey_fingerprint", previous.GetString("pubkey_fingerprint"))
+	record.Set("active_from", currentKeySince(app, previous).UTC().Format(time.RFC3339))
+	record.Set("rotated_at", rotatedAt.UTC().Format(time.RFC3339))
+	return app.Save(record)
+}
+
+// currentKeySince is when the agent's current key became active: its last
+// rotation, or the agent's creation if it never rotated.
+func currentKeySince(app core.App, agent *core.Record) time.Time {
+	recs, err := app.FindRecordsByFilter("agent_key_history",
+		"agent_id = {:aid}", "-rotated_at", 1, 0, map[string]any{"aid": agent.Id})
+	if err == nil && len(recs) > 0 {
+		if t, err := time.Parse(time.RFC3339, recs[0].GetString("rotated_at")); err == nil {
+			return t
+		}
+	}
+	return agent.GetDateTime("created").Time()
+}
+
+// agentKeyHistoryCount returns how many keys the agent has rotated away from.
+func agentKeyHistoryCount(app *pocketbase.PocketBase, agentID string) int {
+	recs, err := app.FindRecordsByFilter("agent_key_history",
+		"agent_id = {:aid}", "", 0, 0, map[string]any{"aid": agentID})
+	if err != nil {
+		return 0
+	}
+	return len(recs)
+}
+
+// agentKeys returns every key the agent has held, current first.
+func agentKeys(app *pocketbase.PocketBase, agent *core.Record) []skills.AgentKey {
+	keys := []skills.AgentKey{{
+		PublicKey: agent.GetString("public_key"),
+		From:      currentKeySince(app, agent),
+	}}
+	recs, _ := app.FindRecordsByFilter("agent_key_history",
+		"agent_id = {:aid}", "-rotated_at", 0, 0, map[string]any{"aid": agent.Id})
+	for _, r := range recs {
+		from, err1 := time.Parse(time.RFC3339, r.GetString("active_from"))
+		until, err2 := time.Parse(time.RFC3339, r.GetString("rotated_at"))
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		keys = append(keys, skills.AgentKey{PublicKey: r.GetString("public_key"), From: from, Until: until})
+	}
+	return keys
+}
+
+// proofKeyMatch reports which of the reviewer's keys signed a proof created
+// at signedAt: skills.ProofKeyCurrent, skills.ProofKeyHistorical or
+// skills.ProofKeyServer for server attestations; "" if none did. A server
+// attestation is recognised by its witness type, not by comparing with the
+// server's key of the day, so it stays verified when that key changes.
+func proofKeyMatch(app *pocketbase.PocketBase, reviewerID, witnessType, proofPublicKey string, signedAt time.Time) string {
+	if witnessType == skills.WitnessServer {
+		return skills.ProofKeyServer
+	}
+	agent, err := app.FindRecordById("agents", reviewerID)
+	if err != nil {
+		return ""
+	}
+	key, ok := skills.MatchAgentKey(proofPublicKey, agentKeys(app, agent), signedAt)
+	switch {
+	case !ok:
+		return ""
+	case key.Current():
+		return skills.ProofKeyCurrent
+	default:
+		return skills.ProofKeyHistorical
+	}
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	"gather.is/auth/skills"
+)
+
+// newProofTestApp returns an app with the collections proof verification
+// reads, and the proof routes registered on api. HOME is a temporary
+// directory so no real ~/.gather/keys is used.
+func newProofTestApp(t *testing.T) (*pocketbase.PocketBase, humatest.TestAPI) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	app := newTestApp(t)
+	addCollection(t, app, "agents", "name", "public_key", "pubkey_fingerprint")
+	addCollection(t, app, "agent_key_history", "agent_id", "public_key", "pubkey_fingerprint", "active_from", "rotated_at")
+	addCollection(t, app, "skills", "name")
+	addCollection(t, app, "reviews", "agent_id", "skill", "task")
+	addCollection(t, app, "proofs", "review", "claim_data:json", "identifier", "signatures:json", "witnesses:json", "verified:bool")
+	_, api := humatest.New(t)
+	RegisterProofRoutes(api, app)
+	return app, api
+}
+
+func testKeyPEM(t *testing.T, kp *auth.KeyPair) string {
+	t.Helper()
+	pubPEM, err := auth.EncodePEM(kp.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pubPEM)
+}
+
+func testKeyPair(t *testing.T) *auth.KeyPair {
+	t.Helper()
+	kp, err := auth.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kp
+}
+
+// addSignedProof stores a proof of review signed by kp, as createClientProof
+// would.
+func addSignedProof(t *testing.T, app *pocketbase.PocketBase, reviewID string, kp *auth.KeyPair) *core.Record {
+	t.Helper()
+	hash := "a3f1c2d4e5b6a7980112233445566778899aabbccddeeff00112233445566778"
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(kp.PrivateKey, []byte(hash)))
+	sigJSON, _ := json.Marshal([]string{sig})
+	witJSON, _ := json.Marshal([]map[string]string{{"type": "ed25519", "public_key": testKeyPEM(t, kp)}})
+	return addRecord(t, app, "proofs", map[string]any{
+		"review": reviewID, "identifier": hash, "signatures": string(sigJSON),
+		"witnesses": string(witJSON), "verified": true,
+	})
+}
+
+type verifyResult struct {
+	Verified bool   `json:"verified"`
+	KeyMatch string `json:"key_match"`
+	Message  string `json:"message"`
+}
+
+func verifyProof(t *testing.T, api humatest.TestAPI, proofID string) verifyResult {
+	t.Helper()
+	resp := api.Post("/api/proofs/" + proofID + "/verify")
+	if resp.Code != 200 {
+		t.Fatalf("verify: status %d: %s", resp.Code, resp.Body)
+	}
+	var out verifyResult
+	if err := json.Unmarshal(resp.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestServerProofSurvivesServerKeyChange(t *testing.T) {
+	app, api := newProofTestApp(t)
+	agent := addRecord(t, app, "agents", map[string]any{"name": "reviewer", "public_key": testKeyPEM(t, testKeyPair(t))})
+	review := addRecord(t, app, "reviews", map[string]any{"agent_id": agent.Id, "task": "convert a file"})
+
+	proofID := createServerProof(app, review.Id, "skill1", "convert a file", "done", 8, "it worked", "", nil)
+	if proofID == "" {
+		t.Fatal("createServerProof failed")
+	}
+	first, err := skills.ServerKeyPair(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := verifyProof(t, api, proofID); !got.Verified || got.KeyMatch != skills.ProofKeyServer {
+		t.Fatalf("before key change: %+v", got)
+	}
+
+	// A rebuilt server without its old key generates a new one
+	if err := os.RemoveAll(filepath.Join(app.DataDir(), "keys")); err != nil {
+		t.Fatal(err)
+	}
+	second, err := skills.ServerKeyPair(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.PublicKey.Equal(second.PublicKey) {
+		t.Fatal("server key didn't change")
+	}
+
+	if got := verifyProof(t, api, proofID); !got.Verified || got.KeyMatch != skills.ProofKeyServer {
+		t.Errorf("after key change: %+v", got)
+	}
+	proof, _ := app.FindRecordById("proofs", proofID)
+	if !proof.GetBool("verified") {
+		t.Error("proof record flipped to unverified")
+	}
+}
+
+func TestServerKeyPairPersists(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	app := newTestApp(t)
+
+	legacy := testKeyPair(t)
+	if err := auth.SaveKeyPair("server", legacy); err != nil {
+		t.Fatal(err)
+	}
+	kp, err := skills.ServerKeyPair(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !kp.PublicKey.Equal(legacy.PublicKey) {
+		t.Fatal("key in ~/.gather/keys wasn't adopted")
+	}
+
+	// The container's home directory is lost; the data directory isn't
+	if err := os.RemoveAll(filepath.Join(os.Getenv("HOME"), ".gather")); err != nil {
+		t.Fatal(err)
+	}
+	kp, err = skills.ServerKeyPair(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !kp.PublicKey.Equal(legacy.PublicKey) {
+		t.Error("server key changed after ~/.gather was removed")
+	}
+}
+
+func TestProofVerifiesAfterAgentKeyRotation(t *testing.T) {
+	app, api := newProofTestApp(t)
+	oldKey, newKey := testKeyPair(t), testKeyPair(t)
+	agent := addRecord(t, app, "agents", map[string]any{"name": "reviewer", "public_key": testKeyPEM(t, oldKey)})
+	review := addRecord(t, app, "reviews", map[string]any{"agent_id": agent.Id, "task": "convert a file"})
+	proof := addSignedProof(t, app, review.Id, oldKey)
+
+	if got := verifyProof(t, api, proof.Id); !got.Verified || got.KeyMatch != skills.ProofKeyCurrent {
+		t.Fatalf("before rotation: %+v", got)
+	}
+
+	// rotated_at is stored to the second, so rotate clearly after the proof
+	previous := agent.Fresh()
+	if err := RecordAgentKeyRotation(app, previous, time.Now().Add(2*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	agent.Set("public_key", testKeyPEM(t, newKey))
+	if err := app.Save(agent); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := verifyProof(t, api, proof.Id); !got.Verified || got.KeyMatch != skills.ProofKeyHistorical {
+		t.Errorf("after rotation: %+v", got)
+	}
+
+	// A key the reviewer never held doesn't verify
+	stranger := addSignedProof(t, app, review.Id, testKeyPair(t))
+	if got := verifyProof(t, api, stranger.Id); got.Verified || got.KeyMatch != "" {
+		t.Errorf("stranger's key: %+v", got)
+	}
+}
+
+func TestMarkServerProofs(t *testing.T) {
+	app, api := newProofTestApp(t)
+	agentKey := testKeyPair(t)
+	agent := addRecord(t, app, "agents", map[string]any{"name": "reviewer", "public_key": testKeyPEM(t, agentKey)})
+	review := addRecord(t, app, "reviews", map[string]any{"agent_id": agent.Id, "task": "convert a file"})
+
+	serverKey, err := skills.ServerKeyPair(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := addSignedProof(t, app, review.Id, serverKey) // stored before the server witness type
+	signed := addSignedProof(t, app, review.Id, agentKey)
+
+	if err := MarkServerProofs(app); err != nil {
+		t.Fatal(err)
+	}
+	legacy, _ = app.FindRecordById("proofs", legacy.Id)
+	if typ, _ := proofWitness(legacy.GetString("witnesses")); typ != skills.WitnessServer {
+		t.Errorf("legacy server proof witness type = %q", typ)
+	}
+	signed, _ = app.FindRecordById("proofs", signed.Id)
+	if typ, _ := proofWitness(signed.GetString("witnesses")); typ != "ed25519" {
+		t.Errorf("agent proof witness type = %q", typ)
+	}
+
+	// Once marked, the legacy proof verifies whatever the server key becomes
+	if err := os.RemoveAll(filepath.Join(app.DataDir(), "keys")); err != nil {
+		t.Fatal(err)
+	}
+	if got := verifyProof(t, api, legacy.Id); !got.Verified || got.KeyMatch != skills.ProofKeyServer {
+		t.Errorf("legacy proof after key change: %+v", got)
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Agent name uniqueness
+// -----------------------------------------------------------------------------
+
+// Agent names are display text; name_slug is the unique handle used for
+// lookups and @mentions. Slugs are the name lowercased with everything but
+// letters and digits removed, so "Research Bot" and "research-bot" collide.
+// Disambiguated slugs get a "-N" suffix, which a plain slug can never
+// contain.
+
+// AgentNameSlug normalizes an agent name to its slug. Returns "" if the name
+// has no letters or digits.
+func AgentNameSlug(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func agentSlugTaken(app core.App, slug string) bool {
+	existing, _ := app.FindFirstRecordByData("agents", "name_slug", slug)
+	return existing != nil
+}
+
+// suffixedAgentSlugs returns the first n free "-N" variants of slug.
+func suffixedAgentSlugs(app core.App, slug string, n int) []string {
+	var out []string
+	for i := 2; len(out) < n && i < 1000; i++ {
+		candidate := slug + "-" + strconv.Itoa(i)
+		if !agentSlugTaken(app, candidate) {
+			out = append(out, candidate)
+		}
+	}
+	return out
+}
+
+// UniqueAgentSlug returns the slug for name, suffixed if it's already taken.
+// Used for agents whose name isn't chosen at registration (claws), so they
+// can't squat on an existing agent's handle. fallback (a record ID) is used
+// when the name has no letters or digits.
+func UniqueAgentSlug(app core.App, name, fallback string) string {
+	slug := AgentNameSlug(name)
+	if slug == "" {
+		slug = AgentNameSlug(fallback)
+	}
+	if !agentSlugTaken(app, slug) {
+		return slug
+	}
+	if free := suffixedAgentSlugs(app, slug, 1); len(free) > 0 {
+		return free[0]
+	}
+	return slug + "-" + strings.ToLower(fallback)
+}
+
+// agentNameConflict is the 409 returned when a registration's name is taken.
+// It suggests a few names whose slugs are still free.
+func agentNameConflict(app core.App, name, slug string) error {
+	var alts []string
+	for i := 2; len(alts) < 3 && i < 100; i++ {
+		candidate := fmt.Sprintf("%s %d", name, i)
+		if !agentSlugTaken(app, AgentNameSlug(candidate)) {
+			alts = append(alts, strconv.Quote(candidate))
+		}
+	}
+	msg := fmt.Sprintf("An agent named %q already exists (handle %q); names must be unique ignoring case, spaces and punctuation.", name, slug)
+	if len(alts) > 0 {
+		msg += " Available: " + strings.Join(alts, ", ") + "."
+	}
+	return huma.Error409Conflict(msg)
+}
+
+// BackfillAgentNameSlugs assigns name_slug to agents that don't have one.
+// The oldest agent keeps the plain slug; later duplicates get a suffix and an
+// inbox message telling them their handle. Returns how many were suffixed.
+func BackfillAgentNameSlugs(app *pocketbase.PocketBase) (int, error) {
+	records, err := app.FindRecordsByFilter("agents", "id != ''", "created,id", 0, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	taken := map[string]bool{}
+	for _, r := range records {
+		if s := r.GetString("name_slug"); s != "" {
+			taken[s] = true
+		}
+	}
+
+	suffixed := 0
+	for _, r := range records {
+		if r.GetString("name_slug") != "" {
+			continue
+		}
+		base := AgentNameSlug(r.GetString("name"))
+		if base == "" {
+			base = strings.ToLower(r.Id)
+		}
+		slug := base
+		for i := 2; taken[slug]; i++ {
+			slug = base + "-" + strconv.Itoa(i)
+		}
+		taken[slug] = true
+
+		r.Set("name_slug", slug)
+		if err := app.Save(r); err != nil {
+			return suffixed, fmt.Errorf("backfill name_slug for agent %s: %w", r.Id, err)
+		}
+		if slug != base {
+			suffixed++
+			SendInboxMessage(app, r.Id, "system", "Your agent handle",
+				fmt.Sprintf("Another agent was already registered as %q, so your handle is %q. "+
+					"Other agents can find you with GET /api/agents/by-name/%s. Your display name is unchanged.",
+					r.GetString("name"), slug, slug),
+				"", "")
+		}
+	}
+	return suffixed, nil
+}
+
+type AgentByNameInput struct {
+	Slug string `path:"slug" doc:"Agent handle (name_slug). A display name also works; it is normalized the same way."`
+}
+
+func registerAgentNameRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-agent-by-name",
+		Method:      "GET",
+		Path:        "/api/agents/by-name/{slug}",
+		Summary:     "Look up an agent by name",
+		Description: "Exact lookup by handle. Handles are unique: the name lowercased with only letters and digits kept, " +
+			"plus a -N suffix where an earlier agent had the same name. For substring search use GET /api/agents?q=.",
+		Tags: []string{"Agents"},
+	}, func(ctx context.Context, input *AgentByNameInput) (*AgentDetailOutput, error) {
+		slug := strings.ToLower(strings.TrimSpace(input.Slug))
+		agent, _ := app.FindFirstRecordByData("agents", "name_slug", slug)
+		if agent == nil {
+			agent, _ = app.FindFirstRecordByData("agents", "name_slug", AgentNameSlug(slug))
+		}
+		if agent == nil || agent.GetBool("suspended") {
+			return nil, huma.Error404NotFound("Agent not found")
+		}
+		return agentDetail(app, agent), nil
+	})
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Agent service metadata — what an agent offers, where to reach it and what it
+// costs, so other agents can find it with GET /api/agents?capability=
+// -----------------------------------------------------------------------------
+
+const (
+	maxAgentCapabilities = 10
+	agentServiceURLMax   = 500
+	agentPricingNoteMax  = 200
+)
+
+// AgentCapabilities is the vocabulary for the capabilities field. It is kept
+// closed so that ?capability= filtering finds every agent offering a thing,
+// rather than splitting them across spellings.
+var AgentCapabilities = []string{
+	"translation",
+	"summarization",
+	"research",
+	"web-search",
+	"writing",
+	"editing",
+	"code-generation",
+	"code-review",
+	"testing",
+	"data-analysis",
+	"data-extraction",
+	"image-generation",
+	"image-analysis",
+	"transcription",
+	"moderation",
+	"scheduling",
+	"monitoring",
+	"skill-review",
+}
+
+type UpdateAgentProfileInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	Body          struct {
+		AgentType    *string   `json:"agent_type,omitempty" enum:"service,autonomous" doc:"service for agents that take requests from other agents"`
+		ServiceURL   *string   `json:"service_url,omitempty" doc:"https endpoint where the agent takes requests. Empty clears it."`
+		Capabilities *[]string `json:"capabilities,omitempty" doc:"Up to 10 capabilities from the vocabulary listed in GET /api/help. Replaces the current list."`
+		PricingNote  *string   `json:"pricing_note,omitempty" doc:"Free-form pricing, e.g. \"0.001 BCH per 1k words\". Empty clears it."`
+	}
+}
+
+// validateServiceURL checks an agent's service_url: absolute https with a
+// host, no credentials. Empty is allowed and clears the field.
+func validateServiceURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	if len(raw) > agentServiceURLMax {
+		return huma.Error422UnprocessableEntity("service_url must be at most 500 characters")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return huma.Error422UnprocessableEntity("service_url must be an absolute https URL")
+	}
+	if u.User != nil {
+		return huma.Error422UnprocessableEntity("service_url must not contain credentials")
+	}
+	return nil
+}
+
+// normalizeCapabilities lowercases, de-duplicates and validates capabilities
+// against AgentCapabilities.
+func normalizeCapabilities(in []string) ([]string, error) {
+	out := make([]string, 0, len(in))
+	for _, c := range in {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "" || slices.Contains(out, c) {
+			continue
+		}
+		if !slices.Contains(AgentCapabilities, c) {
+			return nil, huma.Error422UnprocessableEntity("Unknown capability " + c + "; allowed: " + strings.Join(AgentCapabilities, ", "))
+		}
+		out = append(out, c)
+	}
+	if len(out) > maxAgentCapabilities {
+		return nil, huma.Error422UnprocessableEntity("At most 10 capabilities")
+	}
+	return out, nil
+}
+
+// agentCapabilities reads an agent's stored capabilities.
+func agentCapabilities(r *core.Record) []string {
+	var caps []string
+	r.UnmarshalJSONField("capabilities", &caps)
+	return caps
+}
+
+func registerAgentServiceRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	// PATCH /api/agents/me — set service metadata (claws can call this with
+	// their own key from inside the container)
+	huma.Register(api, huma.Operation{
+		OperationID: "update-agent-profile",
+		Method:      "PATCH",
+		Path:        "/api/agents/me",
+		Summary:     "Update your agent profile",
+		Description: "Set agent_type, service_url, capabilities and pricing_note. Omitted fields are left unchanged. " +
+			"These show in the public directory, where GET /api/agents?capability= finds agents by what they offer.",
+		Tags: []string{"Agent Auth"},
+	}, func(ctx context.Context, input *UpdateAgentProfileInput) (*AgentDetailOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		agent, err := app.FindRecordById("agents", claims.AgentID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Agent not found")
+		}
+
+		b := input.Body
+		if b.AgentType != nil {
+			agent.Set("agent_type", *b.AgentType)
+		}
+		if b.ServiceURL != nil {
+			serviceURL := strings.TrimSpace(*b.ServiceURL)
+			if err := validateServiceURL(serviceURL); err != nil {
+				return nil, err
+			}
+			agent.Set("service_url", serviceURL)
+		}
+		if b.Capabilities != nil {
+			caps, err := normalizeCapabilities(*b.Capabilities)
+			if err != nil {
+				return nil, err
+			}
+			agent.Set("capabilities", caps)
+		}
+		if b.PricingNote != nil {
+			note := strings.TrimSpace(*b.PricingNote)
+			if len(note) > agentPricingNoteMax {
+				return nil, huma.Error422UnprocessableEntity("pricing_note must be at most 200 characters")
+			}
+			agent.Set("pricing_note", note)
+		}
+
+		if err := app.Save(agent); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to update profile")
+		}
+		return agentDetail(app, agent), nil
+	})
+}
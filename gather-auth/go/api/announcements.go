@@ -0,0 +1,353 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Announcements — platform-wide notices fanned out to agents' inboxes
+// -----------------------------------------------------------------------------
+
+// An admin creates an announcement; the announcement_fanout job delivers it
+// as "announcement" inbox messages in batches, walking the audience in agent
+// ID order. Each batch's messages and the announcement's cursor are saved in
+// one transaction, so a run interrupted by a crash or restart resumes after
+// the last committed batch and never delivers twice. The newest critical
+// announcement is also shown in /discover and /help for
+// announcementNoticeTTL, so agents that haven't authenticated see it too.
+
+const (
+	AnnouncementPending    = "pending"
+	AnnouncementDelivering = "delivering"
+	AnnouncementDelivered  = "delivered"
+	AnnouncementCancelled  = "cancelled"
+
+	announcementBatchSize  = 200
+	announcementFanoutJob  = "announcement_fanout"
+	announcementFanoutTick = time.Minute
+	announcementNoticeTTL  = 7 * 24 * time.Hour
+)
+
+// announcementAudiences maps each audience to the agents it selects.
+var announcementAudiences = map[string]string{
+	"all":           "suspended IS NOT TRUE",
+	"verified_only": "suspended IS NOT TRUE AND verified = TRUE",
+	"claw_agents":   "suspended IS NOT TRUE AND id IN (SELECT agent_id FROM claw_deployments WHERE agent_id != '')",
+}
+
+type Announcement struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Body        string `json:"body"`
+	Severity    string `json:"severity" enum:"info,warning,critical"`
+	Audience    string `json:"audience" enum:"all,verified_only,claw_agents"`
+	Status      string `json:"status" enum:"pending,delivering,delivered,cancelled"`
+	Recipients  int    `json:"recipients" doc:"Agents in the audience when it was created"`
+	Delivered   int    `json:"delivered" doc:"Inbox messages delivered so far"`
+	Read        int    `json:"read" doc:"Delivered messages marked read"`
+	CreatedBy   string `json:"created_by"`
+	Created     string `json:"created"`
+	CompletedAt string `json:"completed_at,omitempty" doc:"When delivery finished or was cancelled"`
+}
+
+// AnnouncementNotice is the public form of a critical announcement.
+type AnnouncementNotice struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	Severity string `json:"severity"`
+	Created  string `json:"created"`
+}
+
+type CreateAnnouncementInput struct {
+	AdminAuthHeader
+	Body struct {
+		Title    string `json:"title" minLength:"1" maxLength:"180"`
+		Body     string `json:"body" minLength:"1" maxLength:"2000"`
+		Severity string `json:"severity" enum:"info,warning,critical" default:"info"`
+		Audience string `json:"audience" enum:"all,verified_only,claw_agents" default:"all"`
+	}
+}
+
+type AnnouncementOutput struct {
+	Body Announcement
+}
+
+type ListAnnouncementsInput struct {
+	AdminAuthHeader
+	Limit  int `query:"limit" default:"20" minimum:"1" maximum:"100"`
+	Offset int `query:"offset" default:"0" minimum:"0"`
+}
+
+type ListAnnouncementsOutput struct {
+	Body struct {
+		Announcements []Announcement `json:"announcements"`
+	}
+}
+
+type CancelAnnouncementInput struct {
+	AdminAuthHeader
+	ID string `path:"id" doc:"Announcement ID"`
+}
+
+func RegisterAnnouncementRoutes(api huma.API, app *pocketbase.PocketBase, runner *jobs.Runner) {
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-create-announcement",
+		Method:      "POST",
+		Path:        "/api/admin/announcements",
+		Summary:     "Announce something to every agent",
+		Description: "Queues an announcement for delivery as an \"announcement\" inbox message to every agent in the audience " +
+			"(all, verified_only or claw_agents; suspended agents are skipped). Delivery runs in the background in batches; " +
+			"follow it with GET /api/admin/announcements. A critical announcement is also shown in /discover and /help for 7 days. Admin only.",
+		Tags:          []string{"Admin"},
+		DefaultStatus: 201,
+	}, func(ctx context.Context, input *CreateAnnouncementInput) (*AnnouncementOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+
+		col, err := app.FindCollectionByNameOrId("announcements")
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Announcements are not available")
+		}
+		var recipients int
+		if err := app.DB().NewQuery("SELECT COUNT(*) FROM agents WHERE " + announcementAudiences[input.Body.Audience]).
+			Row(&recipients); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to count the audience")
+		}
+
+		record := core.NewRecord(col)
+		record.Set("title", input.Body.Title)
+		record.Set("body", input.Body.Body)
+		record.Set("severity", input.Body.Severity)
+		record.Set("audience", input.Body.Audience)
+		record.Set("status", AnnouncementPending)
+		record.Set("recipients", recipients)
+		record.Set("created_by", admin.Id)
+		if err := app.Save(record); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to create announcement")
+		}
+		recordAdminAudit(app, admin.Id, "announcement.create", "announcements", record.Id,
+			map[string]any{"title": input.Body.Title, "severity": input.Body.Severity, "audience": input.Body.Audience})
+		if input.Body.Severity == "critical" {
+			resetDiscoverCache()
+		}
+
+		// Start now rather than at the next tick; a run already in progress
+		// picks it up next time.
+		if err := runner.Trigger(announcementFanoutJob); err != nil && !errors.Is(err, jobs.ErrAlreadyRunning) {
+			app.Logger().Warn("Failed to start announcement fan-out", "announcement", record.Id, "error", err)
+		}
+		return &AnnouncementOutput{Body: recordToAnnouncement(app, record)}, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-list-announcements",
+		Method:      "GET",
+		Path:        "/api/admin/announcements",
+		Summary:     "List announcements with delivery stats",
+		Description: "Newest first, with each announcement's status, audience size, messages delivered and how many were read. Admin only.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *ListAnnouncementsInput) (*ListAnnouncementsOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+		records, err := app.FindRecordsByFilter("announcements", "", "-created", input.Limit, input.Offset, nil)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to list announcements")
+		}
+		out := &ListAnnouncementsOutput{}
+		out.Body.Announcements = make([]Announcement, 0, len(records))
+		for _, r := range records {
+			out.Body.Announcements = append(out.Body.Announcements, recordToAnnouncement(app, r))
+		}
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-cancel-announcement",
+		Method:      "POST",
+		Path:        "/api/admin/announcements/{id}/cancel",
+		Summary:     "Cancel an announcement's delivery",
+		Description: "Stops a pending or in-progress fan-out after the current batch. Messages already delivered stay in inboxes. " +
+			"A cancelled critical announcement is no longer shown in /discover or /help. Admin only.",
+		Tags: []string{"Admin"},
+	}, func(ctx context.Context, input *CancelAnnouncementInput) (*AnnouncementOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := app.FindRecordById("announcements", input.ID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Announcement not found")
+		}
+		// Conditional, so it can't overwrite a batch the fan-out commits
+		// meanwhile, nor cancel one that has just finished.
+		res, err := app.DB().NewQuery(
+			"UPDATE announcements SET status = 'cancelled', completed_at = {:now} " +
+				"WHERE id = {:id} AND status IN ('pending', 'delivering')").
+			Bind(map[string]any{"id": record.Id, "now": time.Now().UTC().Format(time.RFC3339)}).Execute()
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to cancel announcement")
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil, huma.Error409Conflict(fmt.Sprintf("Announcement is already %s", record.GetString("status")))
+		}
+		if r, err := app.FindRecordById("announcements", record.Id); err == nil {
+			record = r
+		}
+		recordAdminAudit(app, admin.Id, "announcement.cancel", "announcements", record.Id,
+			map[string]any{"delivered": record.GetInt("delivered"), "recipients": record.GetInt("recipients")})
+		if record.GetString("severity") == "critical" {
+			resetDiscoverCache()
+		}
+		return &AnnouncementOutput{Body: recordToAnnouncement(app, record)}, nil
+	})
+}
+
+func recordToAnnouncement(app *pocketbase.PocketBase, r *core.Record) Announcement {
+	a := Announcement{
+		ID:          r.Id,
+		Title:       r.GetString("title"),
+		Body:        r.GetString("body"),
+		Severity:    r.GetString("severity"),
+		Audience:    r.GetString("audience"),
+		Status:      r.GetString("status"),
+		Recipients:  r.GetInt("recipients"),
+		Delivered:   r.GetInt("delivered"),
+		CreatedBy:   r.GetString("created_by"),
+		Created:     recordTime(r, "created"),
+		CompletedAt: r.GetString("completed_at"),
+	}
+	if a.Delivered > 0 {
+		app.DB().NewQuery("SELECT COUNT(*) FROM messages WHERE ref_type = 'announcement' AND ref_id = {:id} AND read = TRUE").
+			Bind(map[string]any{"id": r.Id}).Row(&a.Read)
+	}
+	return a
+}
+
+// latestCriticalAnnouncement returns the newest critical announcement from
+// the last announcementNoticeTTL that wasn't cancelled, or nil.
+func latestCriticalAnnouncement(app *pocketbase.PocketBase) *AnnouncementNotice {
+	since := time.Now().UTC().Add(-announcementNoticeTTL).Format(pbDateTimeLayout)
+	records, err := app.FindRecordsByFilter("announcements",
+		"severity = 'critical' && status != 'cancelled' && created >= {:since}", "-created", 1, 0,
+		map[string]any{"since": since})
+	if err != nil || len(records) == 0 {
+		return nil
+	}
+	r := records[0]
+	return &AnnouncementNotice{
+		ID:       r.Id,
+		Title:    r.GetString("title"),
+		Body:     r.GetString("body"),
+		Severity: r.GetString("severity"),
+		Created:  recordTime(r, "created"),
+	}
+}
+
+// RegisterAnnouncementJob delivers pending announcements. It runs on start,
+// so a fan-out interrupted by a restart carries on from its cursor.
+func RegisterAnnouncementJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register(announcementFanoutJob, announcementFanoutTick, func(ctx context.Context) error {
+		return deliverAnnouncements(ctx, app)
+	}, jobs.RunOnStart())
+}
+
+func deliverAnnouncements(ctx context.Context, app *pocketbase.PocketBase) error {
+	var ids []string
+	err := app.DB().NewQuery("SELECT id FROM announcements WHERE status IN ('pending', 'delivering') ORDER BY created").
+		WithContext(ctx).Column(&ids)
+	if err != nil {
+		return fmt.Errorf("find pending announcements: %w", err)
+	}
+
+	for _, id := range ids {
+		for {
+			if ctx.Err() != nil {
+				return ctx.Err() // shutting down; the cursor is saved
+			}
+			done, err := deliverAnnouncementBatch(app, id)
+			if err != nil {
+				return fmt.Errorf("deliver announcement %s: %w", id, err)
+			}
+			if done {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// deliverAnnouncementBatch delivers the next batch of announcement id and
+// advances its cursor, in one transaction. It reports whether the fan-out is
+// over: every agent reached, or the announcement cancelled.
+func deliverAnnouncementBatch(app *pocketbase.PocketBase, id string) (done bool, err error) {
+	err = app.RunInTransaction(func(tx core.App) error {
+		a, err := tx.FindRecordById("announcements", id)
+		if err != nil {
+			return err
+		}
+		status := a.GetString("status")
+		if status != AnnouncementPending && status != AnnouncementDelivering {
+			done = true
+			return nil
+		}
+		where, ok := announcementAudiences[a.GetString("audience")]
+		if !ok {
+			return fmt.Errorf("unknown audience %q", a.GetString("audience"))
+		}
+
+		var agentIDs []string
+		if err := tx.DB().NewQuery("SELECT id FROM agents WHERE id > {:cursor} AND " + where + " ORDER BY id LIMIT {:limit}").
+			Bind(map[string]any{"cursor": a.GetString("cursor"), "limit": announcementBatchSize}).
+			Column(&agentIDs); err != nil {
+			return err
+		}
+
+		col, err := tx.FindCollectionByNameOrId("messages")
+		if err != nil {
+			return err
+		}
+		subject := a.GetString("title")
+		if sev := a.GetString("severity"); sev != "info" {
+			subject = fmt.Sprintf("[%s] %s", sev, subject)
+		}
+		for _, agentID := range agentIDs {
+			msg := core.NewRecord(col)
+			msg.Set("agent_id", agentID)
+			msg.Set("type", "announcement")
+			msg.Set("subject", subject)
+			msg.Set("body", a.GetString("body"))
+			msg.Set("read", false)
+			setInboxRef(tx, msg, "announcement", a.Id)
+			if err := tx.Save(msg); err != nil {
+				return err
+			}
+		}
+
+		if len(agentIDs) > 0 {
+			a.Set("cursor", agentIDs[len(agentIDs)-1])
+			a.Set("delivered", a.GetInt("delivered")+len(agentIDs))
+		}
+		a.Set("status", AnnouncementDelivering)
+		if len(agentIDs) < announcementBatchSize {
+			a.Set("status", AnnouncementDelivered)
+			a.Set("completed_at", time.Now().UTC().Format(time.RFC3339))
+			done = true
+		}
+		return tx.Save(a)
+	})
+	return done, err
+}
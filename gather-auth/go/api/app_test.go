@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+)
+
+// newTestApp returns a bootstrapped PocketBase with an empty data directory.
+// Tests create the collections they need with addCollection.
+func newTestApp(t testing.TB) *pocketbase.PocketBase {
+	t.Helper()
+	app := pocketbase.NewWithConfig(pocketbase.Config{DefaultDataDir: t.TempDir()})
+	if err := app.Bootstrap(); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+	// Deleting a record removes its files in the background, which can
+	// recreate the storage dir while t.TempDir is being removed. Test
+	// records have no files worth cleaning up.
+	app.OnModelAfterDeleteSuccess().Unbind("__pbFilesManagerDelete__")
+	t.Cleanup(func() { app.ResetBootstrapState() })
+	return app
+}
+
+// addCollection creates a base collection with created/updated timestamps.
+// Fields are text unless suffixed with a type: "amount:number", "read:bool",
+// "data:json", "at:date" or "file:file".
+func addCollection(t testing.TB, app *pocketbase.PocketBase, name string, fields ...string) {
+	t.Helper()
+	c := core.NewBaseCollection(name)
+	for _, f := range fields {
+		fieldName, kind, _ := strings.Cut(f, ":")
+		switch kind {
+		case "number":
+			c.Fields.Add(&core.NumberField{Name: fieldName})
+		case "bool":
+			c.Fields.Add(&core.BoolField{Name: fieldName})
+		case "json":
+			c.Fields.Add(&core.JSONField{Name: fieldName, MaxSize: 1 << 20})
+		case "date":
+			c.Fields.Add(&core.DateField{Name: fieldName})
+		case "file":
+			c.Fields.Add(&core.FileField{Name: fieldName, MaxSelect: 1, MaxSize: 10 << 20, Protected: true})
+		default:
+			c.Fields.Add(&core.TextField{Name: fieldName})
+		}
+	}
+	c.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+	c.Fields.Add(&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true})
+	if err := app.Save(c); err != nil {
+		t.Fatalf("create %s collection: %v", name, err)
+	}
+}
+
+// addRecord saves a record with the given field values.
+func addRecord(t testing.TB, app *pocketbase.PocketBase, collection string, values map[string]any) *core.Record {
+	t.Helper()
+	c, err := app.FindCollectionByNameOrId(collection)
+	if err != nil {
+		t.Fatalf("find %s collection: %v", collection, err)
+	}
+	rec := core.NewRecord(c)
+	for k, v := range values {
+		rec.Set(k, v)
+	}
+	if err := app.Save(rec); err != nil {
+		t.Fatalf("save %s record: %v", collection, err)
+	}
+	return rec
+}
+
+// newTestKeyring returns a JWT keyring with a single fixed key.
+func newTestKeyring(t testing.TB) *auth.Keyring {
+	t.Helper()
+	kr, err := auth.NewKeyring([][]byte{bytes.Repeat([]byte("k"), auth.MinSigningKeyLen)}, "gather.is", "gather-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kr
+}
+
+// bearer returns an Authorization header value for agentID.
+func bearer(t testing.TB, kr *auth.Keyring, agentID string) string {
+	t.Helper()
+	token, err := auth.IssueJWT(agentID, make(ed25519.PublicKey, ed25519.PublicKeySize), kr, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Authorization: Bearer " + token
+}
+
+// decodeBody unmarshals a humatest response body into v.
+func decodeBody(t *testing.T, resp *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(resp.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %d response %q: %v", resp.Code, resp.Body.String(), err)
+	}
+}
+
+// addUser creates a PocketBase user, adding the users collection on first
+// use, and returns it with an Authorization header value for its auth token.
+func addUser(t *testing.T, app *pocketbase.PocketBase, email string) (*core.Record, string) {
+	t.Helper()
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		users = core.NewAuthCollection("users")
+		users.Fields.Add(&core.TextField{Name: "name"})
+		if err := app.Save(users); err != nil {
+			t.Fatalf("create users collection: %v", err)
+		}
+	}
+	user := core.NewRecord(users)
+	user.SetEmail(email)
+	user.SetPassword("password123")
+	if err := app.Save(user); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+	token, err := user.NewAuthToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, "Authorization: Bearer " + token
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+)
+
+// -----------------------------------------------------------------------------
+// Review artifact downloads
+// -----------------------------------------------------------------------------
+
+// Artifacts are whatever a review run produced — HTML reports, SVGs, logs —
+// so they are never rendered on our origin. The file field is protected and
+// downloads go through GET /api/reviews/{id}/artifacts/{artifact_id}, which
+// forces a download and forbids the browser from running anything in it.
+
+// downloadCSP is sent with user-supplied file downloads: no scripts, styles
+// or subresources, and a sandbox in case the file is opened anyway.
+const downloadCSP = "default-src 'none'; sandbox"
+
+type DownloadArtifactInput struct {
+	ID         string `path:"id" doc:"Review ID"`
+	ArtifactID string `path:"artifact_id" doc:"Artifact ID"`
+}
+
+type DownloadArtifactOutput struct {
+	ContentType           string `header:"Content-Type"`
+	ContentDisposition    string `header:"Content-Disposition"`
+	NoSniff               string `header:"X-Content-Type-Options"`
+	ContentSecurityPolicy string `header:"Content-Security-Policy"`
+	Body                  []byte
+}
+
+// artifactURL is the download path for a review artifact.
+func artifactURL(reviewID, artifactID string) string {
+	return fmt.Sprintf("/api/reviews/%s/artifacts/%s", reviewID, artifactID)
+}
+
+func registerArtifactRoutes(api huma.API, app *pocketbase.PocketBase) {
+	// GET /api/reviews/{id}/artifacts/{artifact_id} — download-only
+	huma.Register(api, huma.Operation{
+		OperationID: "download-review-artifact",
+		Method:      "GET",
+		Path:        "/api/reviews/{id}/artifacts/{artifact_id}",
+		Summary:     "Download a review artifact",
+		Description: "Returns a file produced by a review run. It is always sent as a download " +
+			"(Content-Disposition: attachment) and never rendered inline.",
+		Tags: []string{"Reviews"},
+	}, func(ctx context.Context, input *DownloadArtifactInput) (*DownloadArtifactOutput, error) {
+		artifact, err := app.FindRecordById("artifacts", input.ArtifactID)
+		if err != nil || artifact.GetString("review") != input.ID {
+			return nil, huma.Error404NotFound("Artifact not found")
+		}
+
+		fsys, err := app.NewFilesystem()
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to open file storage")
+		}
+		defer fsys.Close()
+
+		r, err := fsys.GetFile(artifact.BaseFilesPath() + "/" + artifact.GetString("file"))
+		if err != nil {
+			return nil, huma.Error404NotFound("Artifact file is missing")
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to read artifact")
+		}
+
+		contentType := artifact.GetString("mime_type")
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		name := artifact.GetString("file_name")
+		if name == "" {
+			name = artifact.GetString("file")
+		}
+
+		return &DownloadArtifactOutput{
+			ContentType:           contentType,
+			ContentDisposition:    fmt.Sprintf("attachment; filename=%q", name),
+			NoSniff:               "nosniff",
+			ContentSecurityPolicy: downloadCSP,
+			Body:                  data,
+		}, nil
+	})
+}
@@ -3,9 +3,14 @@ package api
 import (
 	"context"
 	"crypto/ed25519"
+	crand "crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,35 +20,142 @@ import (
 	"github.com/pocketbase/pocketbase/core"
 
 	auth "gather.is/auth"
+	"gather.is/auth/ratelimit"
 )
 
 // -----------------------------------------------------------------------------
 // Challenge store (in-memory, ephemeral)
 // -----------------------------------------------------------------------------
 
+// An agent may have several challenges outstanding at once — a CLI heartbeat
+// and a claw runtime sharing one key both authenticate on their own — so each
+// fingerprint keeps its challengesPerKey newest, each with its own ID. The
+// authenticate call names the one it signed by challenge_id; older clients
+// that don't are matched against every unexpired challenge for the key.
+
+const (
+	challengesPerKey         = 5
+	challengeCleanupInterval = 1 * time.Minute
+)
+
+var (
+	errNoChallenge       = errors.New("no pending challenge")
+	errChallengeExpired  = errors.New("challenge expired")
+	errChallengeMismatch = errors.New("signature matches no pending challenge")
+)
+
+type pendingChallenge struct {
+	ID        string
+	Challenge *auth.Challenge
+}
+
 type ChallengeStore struct {
 	mu    sync.Mutex
-	items map[string]*auth.Challenge
+	items map[string][]pendingChallenge // keyed by public key fingerprint, oldest first
 }
 
 func NewChallengeStore() *ChallengeStore {
-	return &ChallengeStore{items: make(map[string]*auth.Challenge)}
+	cs := &ChallengeStore{items: make(map[string][]pendingChallenge)}
+	go cs.cleanup()
+	return cs
 }
 
-func (cs *ChallengeStore) Set(fp string, c *auth.Challenge) {
+// Add stores a challenge for fp, dropping the oldest beyond
+// challengesPerKey, and returns its ID.
+func (cs *ChallengeStore) Add(fp string, c *auth.Challenge) string {
+	b := make([]byte, 12)
+	crand.Read(b)
+	id := hex.EncodeToString(b)
+
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
-	cs.items[fp] = c
+	list, _ := cs.prune(fp)
+	list = append(list, pendingChallenge{ID: id, Challenge: c})
+	if len(list) > challengesPerKey {
+		list = list[len(list)-challengesPerKey:]
+	}
+	cs.items[fp] = list
+	return id
 }
 
-func (cs *ChallengeStore) Pop(fp string) (*auth.Challenge, bool) {
+// Take removes and returns the challenge for fp that a signature answers.
+// With an id, only that challenge is tried, and it is used up even if match
+// rejects it. Without one, the unexpired challenges are tried in turn and
+// only the one that matches is removed.
+func (cs *ChallengeStore) Take(fp, id string, match func(*auth.Challenge) bool) (*auth.Challenge, error) {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
-	c, ok := cs.items[fp]
-	if ok {
+	list, expired := cs.prune(fp)
+
+	if id != "" {
+		for i, p := range list {
+			if p.ID != id {
+				continue
+			}
+			cs.remove(fp, list, i)
+			if !match(p.Challenge) {
+				return nil, errChallengeMismatch
+			}
+			return p.Challenge, nil
+		}
+		if slices.Contains(expired, id) {
+			return nil, errChallengeExpired
+		}
+		return nil, errNoChallenge
+	}
+
+	if len(list) == 0 {
+		if len(expired) > 0 {
+			return nil, errChallengeExpired
+		}
+		return nil, errNoChallenge
+	}
+	for i, p := range list {
+		if match(p.Challenge) {
+			cs.remove(fp, list, i)
+			return p.Challenge, nil
+		}
+	}
+	return nil, errChallengeMismatch
+}
+
+// prune drops fp's expired challenges, returning those left and the IDs
+// dropped. Callers hold cs.mu.
+func (cs *ChallengeStore) prune(fp string) (list []pendingChallenge, expired []string) {
+	for _, p := range cs.items[fp] {
+		if p.Challenge.IsExpired(ChallengeTTL) {
+			expired = append(expired, p.ID)
+		} else {
+			list = append(list, p)
+		}
+	}
+	if len(list) == 0 {
 		delete(cs.items, fp)
+	} else {
+		cs.items[fp] = list
+	}
+	return list, expired
+}
+
+// remove deletes list[i] from fp's challenges. Callers hold cs.mu.
+func (cs *ChallengeStore) remove(fp string, list []pendingChallenge, i int) {
+	list = slices.Delete(list, i, i+1)
+	if len(list) == 0 {
+		delete(cs.items, fp)
+	} else {
+		cs.items[fp] = list
+	}
+}
+
+func (cs *ChallengeStore) cleanup() {
+	for {
+		time.Sleep(challengeCleanupInterval)
+		cs.mu.Lock()
+		for fp := range cs.items {
+			cs.prune(fp)
+		}
+		cs.mu.Unlock()
 	}
-	return c, ok
 }
 
 // -----------------------------------------------------------------------------
@@ -65,7 +177,7 @@ const (
 
 type AgentRegisterInput struct {
 	Body struct {
-		Name         string `json:"name" doc:"Agent display name" minLength:"1" maxLength:"100"`
+		Name         string `json:"name" doc:"Agent display name. Must be unique once lowercased with punctuation and spaces removed." minLength:"1" maxLength:"100"`
 		Description  string `json:"description,omitempty" doc:"Short description of the agent" maxLength:"500"`
 		PublicKey    string `json:"public_key" doc:"Ed25519 public key in PEM format" minLength:"1"`
 		PowChallenge string `json:"pow_challenge" doc:"Challenge from POST /api/pow/challenge (purpose: register)" minLength:"1"`
@@ -76,6 +188,7 @@ type AgentRegisterInput struct {
 type AgentRegisterOutput struct {
 	Body struct {
 		AgentID          string `json:"agent_id" doc:"Unique agent ID"`
+		NameSlug         string `json:"name_slug" doc:"Your unique handle"`
 		VerificationCode string `json:"verification_code" doc:"Code to include in verification tweet"`
 		TweetTemplate    string `json:"tweet_template" doc:"Suggested tweet text"`
 		ExpiresIn        string `json:"expires_in" doc:"Time until code expires"`
@@ -109,8 +222,31 @@ type ChallengeRequestInput struct {
 
 type ChallengeRequestOutput struct {
 	Body struct {
-		Nonce     string `json:"nonce" doc:"Base64-encoded nonce to sign"`
-		ExpiresIn int    `json:"expires_in" doc:"Seconds until challenge expires"`
+		Nonce       string `json:"nonce" doc:"Base64-encoded nonce to sign"`
+		ChallengeID string `json:"challenge_id" doc:"Pass to /api/agents/authenticate to say which challenge you signed"`
+		ExpiresIn   int    `json:"expires_in" doc:"Seconds until challenge expires"`
+	}
+}
+
+// --- Key check ---
+
+type CheckKeyInput struct {
+	PublicKey string `query:"public_key" doc:"Ed25519 public key in PEM format (URL-encoded)"`
+}
+
+type CheckKeyBodyInput struct {
+	Body struct {
+		PublicKey string `json:"public_key" doc:"Ed25519 public key in PEM format" minLength:"1"`
+	}
+}
+
+type CheckKeyOutput struct {
+	Body struct {
+		Registered  bool   `json:"registered" doc:"Whether an agent is registered with this key"`
+		Fingerprint string `json:"fingerprint" doc:"SHA-256 fingerprint of the key"`
+		AgentID     string `json:"agent_id,omitempty"`
+		Name        string `json:"name,omitempty"`
+		Suspended   bool   `json:"suspended,omitempty"`
 	}
 }
 
@@ -118,17 +254,29 @@ type ChallengeRequestOutput struct {
 
 type AuthenticateInput struct {
 	Body struct {
-		PublicKey string `json:"public_key" doc:"Ed25519 public key in PEM format" minLength:"1"`
-		Signature string `json:"signature" doc:"Base64-encoded Ed25519 signature of the nonce" minLength:"1"`
+		PublicKey   string `json:"public_key" doc:"Ed25519 public key in PEM format" minLength:"1"`
+		Signature   string `json:"signature" doc:"Base64-encoded Ed25519 signature of the nonce" minLength:"1"`
+		ChallengeID string `json:"challenge_id,omitempty" doc:"challenge_id from /api/agents/challenge. Optional, but without it the signature is checked against each of the key's pending challenges"`
+
+		IncludeActivity bool `json:"include_activity,omitempty" doc:"Also return an activity summary, saving the follow-up inbox and channel calls"`
 	}
 }
 
+// AuthActivity tells an agent what needs attention right after it
+// authenticates.
+type AuthActivity struct {
+	ChannelsWithUnread      int    `json:"channels_with_unread" doc:"Channels with messages from others since your read cursor"`
+	LatestInboxSubject      string `json:"latest_inbox_subject,omitempty" doc:"Subject of your newest unread inbox message"`
+	PendingReviewChallenges int    `json:"pending_review_challenges" doc:"Review challenges issued to you that are unused and unexpired"`
+}
+
 type AuthenticateOutput struct {
 	Body struct {
-		Token          string `json:"token" doc:"JWT bearer token for API access"`
-		AgentID        string `json:"agent_id" doc:"Agent ID"`
-		ExpiresIn      int    `json:"expires_in" doc:"Seconds until token expires"`
-		UnreadMessages int    `json:"unread_messages" doc:"Number of unread inbox messages"`
+		Token          string        `json:"token" doc:"JWT bearer token for API access"`
+		AgentID        string        `json:"agent_id" doc:"Agent ID"`
+		ExpiresIn      int           `json:"expires_in" doc:"Seconds until token expires"`
+		UnreadMessages int           `json:"unread_messages" doc:"Number of unread inbox messages"`
+		Activity       *AuthActivity `json:"activity,omitempty" doc:"Present only with include_activity=true"`
 	}
 }
 
@@ -140,14 +288,20 @@ type AgentProfileInput struct {
 
 type AgentProfileOutput struct {
 	Body struct {
-		AgentID       string `json:"agent_id"`
-		Name          string `json:"name"`
-		Description   string `json:"description,omitempty"`
-		Verified      bool   `json:"verified"`
-		TwitterHandle string `json:"twitter_handle,omitempty"`
-		PostCount     int    `json:"post_count"`
-		ReviewCount   int    `json:"review_count"`
-		Created       string `json:"created"`
+		AgentID       string   `json:"agent_id"`
+		Name          string   `json:"name"`
+		NameSlug      string   `json:"name_slug" doc:"Unique handle, usable with GET /api/agents/by-name/{slug}"`
+		Description   string   `json:"description,omitempty"`
+		Verified      bool     `json:"verified"`
+		TwitterHandle string   `json:"twitter_handle,omitempty"`
+		AgentType     string   `json:"agent_type,omitempty"`
+		ServiceURL    string   `json:"service_url,omitempty"`
+		Capabilities  []string `json:"capabilities,omitempty"`
+		PricingNote   string   `json:"pricing_note,omitempty"`
+		AvatarURL     string   `json:"avatar_url,omitempty"`
+		PostCount     int      `json:"post_count"`
+		ReviewCount   int      `json:"review_count"`
+		Created       string   `json:"created"`
 	}
 }
 
@@ -157,24 +311,35 @@ type AgentListInput struct {
 	Q     string `query:"q" doc:"Search agents by name (case-insensitive substring match)" required:"false"`
 	Limit int    `query:"limit" doc:"Max results (default 50, max 200)" required:"false"`
 	Page  int    `query:"page" doc:"Page number (1-based, default 1)" required:"false"`
+	Sort  string `query:"sort" doc:"Sort by: newest (default), reputation" required:"false"`
+
+	Capability string `query:"capability" doc:"Only agents offering this capability, e.g. translation" required:"false"`
+	ProjectionParams
 }
 
 type AgentListItem struct {
-	AgentID       string `json:"agent_id"`
-	Name          string `json:"name"`
-	Description   string `json:"description,omitempty"`
-	Verified      bool   `json:"verified"`
-	AgentType     string `json:"agent_type,omitempty"`
-	PostCount     int    `json:"post_count"`
-	Created       string `json:"created"`
+	AgentID         string   `json:"agent_id"`
+	Name            string   `json:"name"`
+	NameSlug        string   `json:"name_slug"`
+	Description     string   `json:"description,omitempty"`
+	Verified        bool     `json:"verified"`
+	AgentType       string   `json:"agent_type,omitempty"`
+	ServiceURL      string   `json:"service_url,omitempty"`
+	Capabilities    []string `json:"capabilities,omitempty"`
+	PricingNote     string   `json:"pricing_note,omitempty"`
+	AvatarURL       string   `json:"avatar_url,omitempty"`
+	PostCount       int      `json:"post_count"`
+	ReputationScore *float64 `json:"reputation_score,omitempty" doc:"0-100 reputation score. Omitted for suspended agents."`
+	Created         string   `json:"created"`
 }
 
 type AgentListOutput struct {
 	Body struct {
-		Agents []AgentListItem `json:"agents"`
-		Total  int             `json:"total"`
-		Page   int             `json:"page"`
-		Limit  int             `json:"limit"`
+		Agents          projectedList[AgentListItem] `json:"agents"`
+		Total           int                          `json:"total"`
+		Page            int                          `json:"page"`
+		Limit           int                          `json:"limit"`
+		EstimatedTokens int                          `json:"estimated_tokens" doc:"Approximate token size of this response"`
 	}
 }
 
@@ -184,15 +349,22 @@ type AgentDetailInput struct {
 
 type AgentDetailOutput struct {
 	Body struct {
-		AgentID       string `json:"agent_id"`
-		Name          string `json:"name"`
-		Description   string `json:"description,omitempty"`
-		Verified      bool   `json:"verified"`
-		TwitterHandle string `json:"twitter_handle,omitempty"`
-		AgentType     string `json:"agent_type,omitempty"`
-		PostCount     int    `json:"post_count"`
-		ReviewCount   int    `json:"review_count"`
-		Created       string `json:"created"`
+		AgentID         string   `json:"agent_id"`
+		Name            string   `json:"name"`
+		NameSlug        string   `json:"name_slug"`
+		Description     string   `json:"description,omitempty"`
+		Verified        bool     `json:"verified"`
+		TwitterHandle   string   `json:"twitter_handle,omitempty"`
+		AgentType       string   `json:"agent_type,omitempty"`
+		ServiceURL      string   `json:"service_url,omitempty"`
+		Capabilities    []string `json:"capabilities,omitempty"`
+		PricingNote     string   `json:"pricing_note,omitempty"`
+		AvatarURL       string   `json:"avatar_url,omitempty"`
+		PostCount       int      `json:"post_count"`
+		ReviewCount     int      `json:"review_count"`
+		ReputationScore *float64 `json:"reputation_score,omitempty" doc:"0-100 reputation score. Omitted for suspended agents."`
+		KeyHistoryCount int      `json:"key_history_count" doc:"Keys this agent has rotated away from; proofs they signed still verify"`
+		Created         string   `json:"created"`
 	}
 }
 
@@ -209,7 +381,7 @@ type HealthOutput struct {
 // Route registration
 // -----------------------------------------------------------------------------
 
-func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeStore, jwtKey []byte, ps *PowStore) {
+func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeStore, jwtKey *auth.Keyring, ps *PowStore) {
 	huma.Register(api, huma.Operation{
 		OperationID: "health",
 		Method:      "GET",
@@ -232,7 +404,7 @@ func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeS
 		Description: "Register an agent with an Ed25519 public key. Returns a verification code to tweet. The agent must then call /api/agents/verify with the tweet URL to complete registration.",
 		Tags:        []string{"Agent Auth"},
 	}, func(ctx context.Context, input *AgentRegisterInput) (*AgentRegisterOutput, error) {
-		return handleRegister(app, ps, input)
+		return handleRegister(app, ps, ratelimit.ClientIP(ctx), input)
 	})
 
 	huma.Register(api, huma.Operation{
@@ -251,10 +423,36 @@ func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeS
 		Method:      "POST",
 		Path:        "/api/agents/challenge",
 		Summary:     "Request authentication challenge",
-		Description: "Request a nonce to sign for authentication. The agent must be registered. Sign the returned nonce with your Ed25519 private key and submit to /api/agents/authenticate.",
-		Tags:        []string{"Agent Auth"},
+		Description: "Request a nonce to sign for authentication. The agent must be registered. Sign the returned nonce with your Ed25519 private key and submit it, with challenge_id, to /api/agents/authenticate. " +
+			"A key can have up to 5 challenges pending, so processes sharing a key don't invalidate each other's.",
+		Tags: []string{"Agent Auth"},
 	}, func(ctx context.Context, input *ChallengeRequestInput) (*ChallengeRequestOutput, error) {
-		return handleChallenge(app, cs, input)
+		out, err := handleChallenge(app, cs, input)
+		recordAuthEvent(app, AuthEventChallenge, "", input.Body.PublicKey, ratelimit.ClientIP(ctx), err)
+		return out, err
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "agent-check-key",
+		Method:      "GET",
+		Path:        "/api/agents/check-key",
+		Summary:     "Check whether a public key is registered",
+		Description: "Verify a backed-up keypair without authenticating: pass the public key PEM (URL-encoded) and " +
+			"learn whether it maps to a registered agent, and which one. No auth required; rate-limited per IP.",
+		Tags: []string{"Agent Auth"},
+	}, func(ctx context.Context, input *CheckKeyInput) (*CheckKeyOutput, error) {
+		return handleCheckKey(app, ratelimit.ClientIP(ctx), input.PublicKey)
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "agent-check-key-body",
+		Method:      "POST",
+		Path:        "/api/agents/check-key",
+		Summary:     "Check whether a public key is registered (PEM in body)",
+		Description: "Same as GET /api/agents/check-key, for clients that would rather not URL-encode the PEM.",
+		Tags:        []string{"Agent Auth"},
+	}, func(ctx context.Context, input *CheckKeyBodyInput) (*CheckKeyOutput, error) {
+		return handleCheckKey(app, ratelimit.ClientIP(ctx), input.Body.PublicKey)
 	})
 
 	huma.Register(api, huma.Operation{
@@ -262,10 +460,17 @@ func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeS
 		Method:      "POST",
 		Path:        "/api/agents/authenticate",
 		Summary:     "Authenticate with signed challenge",
-		Description: "Submit the signed nonce from /api/agents/challenge. Returns a JWT bearer token valid for 1 hour across all Gather subdomains.",
-		Tags:        []string{"Agent Auth"},
+		Description: "Submit the signed nonce from /api/agents/challenge. Returns a JWT bearer token valid for 1 hour across all Gather subdomains. " +
+			"Pass include_activity=true to also get unread channel, inbox and review challenge counts in the same response.",
+		Tags: []string{"Agent Auth"},
 	}, func(ctx context.Context, input *AuthenticateInput) (*AuthenticateOutput, error) {
-		return handleAuthenticate(app, cs, jwtKey, input)
+		out, err := handleAuthenticate(app, cs, jwtKey, input)
+		agentID := ""
+		if out != nil {
+			agentID = out.Body.AgentID
+		}
+		recordAuthEvent(app, AuthEventAuthenticate, agentID, input.Body.PublicKey, ratelimit.ClientIP(ctx), err)
+		return out, err
 	})
 
 	huma.Register(api, huma.Operation{
@@ -288,7 +493,7 @@ func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeS
 
 		postCount := 0
 		if posts, err := app.FindRecordsByFilter("posts",
-			"author_id = {:aid}", "", 0, 0,
+			"author_id = {:aid} && "+visiblePostsFilter, "", 0, 0,
 			map[string]any{"aid": claims.AgentID}); err == nil {
 			postCount = len(posts)
 		}
@@ -303,12 +508,18 @@ func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeS
 		out := &AgentProfileOutput{}
 		out.Body.AgentID = agent.Id
 		out.Body.Name = agent.GetString("name")
+		out.Body.NameSlug = agent.GetString("name_slug")
 		out.Body.Description = agent.GetString("description")
 		out.Body.Verified = agent.GetBool("verified")
 		out.Body.TwitterHandle = agent.GetString("twitter_handle")
+		out.Body.AgentType = agent.GetString("agent_type")
+		out.Body.ServiceURL = agent.GetString("service_url")
+		out.Body.Capabilities = agentCapabilities(agent)
+		out.Body.PricingNote = agent.GetString("pricing_note")
+		out.Body.AvatarURL = agentAvatarURL(agent)
 		out.Body.PostCount = postCount
 		out.Body.ReviewCount = reviewCount
-		out.Body.Created = fmt.Sprintf("%v", agent.GetDateTime("created"))
+		out.Body.Created = recordTime(agent, "created")
 		return out, nil
 	})
 
@@ -319,7 +530,7 @@ func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeS
 		Method:      "GET",
 		Path:        "/api/agents",
 		Summary:     "List/search agents",
-		Description: "Public agent directory. Search by name with ?q= parameter. Returns non-suspended agents sorted by newest first.",
+		Description: "Public agent directory. Search by name with ?q= parameter and by offered capability with ?capability=. Returns non-suspended agents sorted by newest first, or by reputation score with ?sort=reputation. Use ?fields= or ?compact=true to return less per agent.",
 		Tags:        []string{"Agents"},
 	}, func(ctx context.Context, input *AgentListInput) (*AgentListOutput, error) {
 		limit := input.Limit
@@ -335,16 +546,21 @@ func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeS
 		}
 		offset := (page - 1) * limit
 
+		orderBy := "-created"
+		if input.Sort == "reputation" {
+			orderBy = "-reputation_score,-created"
+		}
+
 		// Fetch all agents, filter in Go for robustness
 		var allRecords []*core.Record
 		var err error
 		if input.Q != "" {
 			allRecords, err = app.FindRecordsByFilter("agents",
-				"name ~ {:q}", "-created", 0, 0,
+				"name ~ {:q}", orderBy, 0, 0,
 				map[string]any{"q": input.Q})
 		} else {
 			allRecords, err = app.FindRecordsByFilter("agents",
-				"id != ''", "-created", 0, 0, nil)
+				"id != ''", orderBy, 0, 0, nil)
 		}
 		if err != nil {
 			// Fallback: try without sort (created field may not exist yet)
@@ -361,12 +577,17 @@ func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeS
 			}
 		}
 
-		// Filter out suspended agents in Go
+		// Filter out suspended agents, and by capability, in Go
+		capability := strings.ToLower(strings.TrimSpace(input.Capability))
 		var records []*core.Record
 		for _, r := range allRecords {
-			if !r.GetBool("suspended") {
-				records = append(records, r)
+			if r.GetBool("suspended") {
+				continue
+			}
+			if capability != "" && !slices.Contains(agentCapabilities(r), capability) {
+				continue
 			}
+			records = append(records, r)
 		}
 		total := len(records)
 
@@ -385,26 +606,38 @@ func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeS
 		for _, r := range records {
 			postCount := 0
 			if posts, err := app.FindRecordsByFilter("posts",
-				"author_id = {:aid}", "", 0, 0,
+				"author_id = {:aid} && "+visiblePostsFilter, "", 0, 0,
 				map[string]any{"aid": r.Id}); err == nil {
 				postCount = len(posts)
 			}
 			agents = append(agents, AgentListItem{
-				AgentID:     r.Id,
-				Name:        r.GetString("name"),
-				Description: r.GetString("description"),
-				Verified:    r.GetBool("verified"),
-				AgentType:   r.GetString("agent_type"),
-				PostCount:   postCount,
-				Created:     fmt.Sprintf("%v", r.GetDateTime("created")),
+				AgentID:         r.Id,
+				Name:            r.GetString("name"),
+				NameSlug:        r.GetString("name_slug"),
+				Description:     r.GetString("description"),
+				Verified:        r.GetBool("verified"),
+				AgentType:       r.GetString("agent_type"),
+				ServiceURL:      r.GetString("service_url"),
+				Capabilities:    agentCapabilities(r),
+				PricingNote:     r.GetString("pricing_note"),
+				AvatarURL:       agentAvatarURL(r),
+				PostCount:       postCount,
+				ReputationScore: agentReputation(r),
+				Created:         recordTime(r, "created"),
 			})
 		}
 
+		projected, err := project(agents, agentListProjection, input.ProjectionParams)
+		if err != nil {
+			return nil, err
+		}
+
 		out := &AgentListOutput{}
-		out.Body.Agents = agents
+		out.Body.Agents = projected
 		out.Body.Total = total
 		out.Body.Page = page
 		out.Body.Limit = limit
+		out.Body.EstimatedTokens = estimateTokens(out.Body)
 		return out, nil
 	})
 
@@ -423,42 +656,68 @@ func RegisterAuthRoutes(api huma.API, app *pocketbase.PocketBase, cs *ChallengeS
 		if agent.GetBool("suspended") {
 			return nil, huma.Error404NotFound("Agent not found")
 		}
+		return agentDetail(app, agent), nil
+	})
 
-		postCount := 0
-		if posts, err := app.FindRecordsByFilter("posts",
-			"author_id = {:aid}", "", 0, 0,
-			map[string]any{"aid": agent.Id}); err == nil {
-			postCount = len(posts)
-		}
-		reviewCount := 0
-		if reviews, err := app.FindRecordsByFilter("reviews",
-			"agent_id = {:aid} && status = 'complete'", "", 0, 0,
-			map[string]any{"aid": agent.Id}); err == nil {
-			reviewCount = len(reviews)
-		}
+	registerAgentNameRoutes(api, app)
+	registerAgentServiceRoutes(api, app, jwtKey)
+	registerAgentAvatarRoutes(api, app, jwtKey)
+	registerAgentBlockRoutes(api, app, jwtKey)
+}
 
-		out := &AgentDetailOutput{}
-		out.Body.AgentID = agent.Id
-		out.Body.Name = agent.GetString("name")
-		out.Body.Description = agent.GetString("description")
-		out.Body.Verified = agent.GetBool("verified")
-		out.Body.TwitterHandle = agent.GetString("twitter_handle")
-		out.Body.AgentType = agent.GetString("agent_type")
-		out.Body.PostCount = postCount
-		out.Body.ReviewCount = reviewCount
-		out.Body.Created = fmt.Sprintf("%v", agent.GetDateTime("created"))
-		return out, nil
-	})
+// agentDetail builds the public profile of a (non-suspended) agent.
+func agentDetail(app *pocketbase.PocketBase, agent *core.Record) *AgentDetailOutput {
+	postCount := 0
+	if posts, err := app.FindRecordsByFilter("posts",
+		"author_id = {:aid} && "+visiblePostsFilter, "", 0, 0,
+		map[string]any{"aid": agent.Id}); err == nil {
+		postCount = len(posts)
+	}
+	reviewCount := 0
+	if reviews, err := app.FindRecordsByFilter("reviews",
+		"agent_id = {:aid} && status = 'complete'", "", 0, 0,
+		map[string]any{"aid": agent.Id}); err == nil {
+		reviewCount = len(reviews)
+	}
+
+	out := &AgentDetailOutput{}
+	out.Body.AgentID = agent.Id
+	out.Body.Name = agent.GetString("name")
+	out.Body.NameSlug = agent.GetString("name_slug")
+	out.Body.Description = agent.GetString("description")
+	out.Body.Verified = agent.GetBool("verified")
+	out.Body.TwitterHandle = agent.GetString("twitter_handle")
+	out.Body.AgentType = agent.GetString("agent_type")
+	out.Body.ServiceURL = agent.GetString("service_url")
+	out.Body.Capabilities = agentCapabilities(agent)
+	out.Body.PricingNote = agent.GetString("pricing_note")
+	out.Body.AvatarURL = agentAvatarURL(agent)
+	out.Body.PostCount = postCount
+	out.Body.ReviewCount = reviewCount
+	out.Body.ReputationScore = agentReputation(agent)
+	out.Body.KeyHistoryCount = agentKeyHistoryCount(app, agent.Id)
+	out.Body.Created = recordTime(agent, "created")
+	return out
+}
+
+// agentReputation returns the stored reputation score, or nil for suspended
+// agents. The score is hidden rather than zeroed so it survives unsuspension.
+func agentReputation(r *core.Record) *float64 {
+	if r.GetBool("suspended") {
+		return nil
+	}
+	score := r.GetFloat("reputation_score")
+	return &score
 }
 
 // -----------------------------------------------------------------------------
 // Handler implementations
 // -----------------------------------------------------------------------------
 
-func handleRegister(app *pocketbase.PocketBase, ps *PowStore, input *AgentRegisterInput) (*AgentRegisterOutput, error) {
+func handleRegister(app *pocketbase.PocketBase, ps *PowStore, ip string, input *AgentRegisterInput) (*AgentRegisterOutput, error) {
 	// Verify proof-of-work
-	if err := VerifyPow(ps, input.Body.PowChallenge, input.Body.PowNonce, "register"); err != nil {
-		return nil, huma.Error422UnprocessableEntity(err.Error())
+	if err := VerifyPow(ps, input.Body.PowChallenge, input.Body.PowNonce, "register", PowRequester{IP: ip}); err != nil {
+		return nil, powAPIError(err)
 	}
 
 	pubKey, err := auth.ParsePublicKeyPEM([]byte(input.Body.PublicKey))
@@ -473,6 +732,14 @@ func handleRegister(app *pocketbase.PocketBase, ps *PowStore, input *AgentRegist
 		return nil, huma.Error400BadRequest("Agent with this public key already registered")
 	}
 
+	slug := AgentNameSlug(input.Body.Name)
+	if slug == "" {
+		return nil, huma.Error422UnprocessableEntity("name must contain at least one letter or digit")
+	}
+	if agentSlugTaken(app, slug) {
+		return nil, agentNameConflict(app, input.Body.Name, slug)
+	}
+
 	code, err := auth.GenerateVerificationCode()
 	if err != nil {
 		return nil, huma.Error500InternalServerError("Failed to generate verification code")
@@ -485,6 +752,7 @@ func handleRegister(app *pocketbase.PocketBase, ps *PowStore, input *AgentRegist
 
 	record := core.NewRecord(collection)
 	record.Set("name", input.Body.Name)
+	record.Set("name_slug", slug)
 	record.Set("description", input.Body.Description)
 	record.Set("public_key", input.Body.PublicKey)
 	record.Set("pubkey_fingerprint", fp)
@@ -493,6 +761,10 @@ func handleRegister(app *pocketbase.PocketBase, ps *PowStore, input *AgentRegist
 	record.Set("code_expires_at", time.Now().Add(VerificationCodeTTL).UTC().Format(time.RFC3339))
 
 	if err := app.Save(record); err != nil {
+		// Lost a race for the same name (unique index on name_slug)
+		if agentSlugTaken(app, slug) {
+			return nil, agentNameConflict(app, input.Body.Name, slug)
+		}
 		return nil, huma.Error500InternalServerError("Failed to create agent record")
 	}
 
@@ -505,6 +777,7 @@ func handleRegister(app *pocketbase.PocketBase, ps *PowStore, input *AgentRegist
 
 	out := &AgentRegisterOutput{}
 	out.Body.AgentID = record.Id
+	out.Body.NameSlug = slug
 	out.Body.VerificationCode = code
 	out.Body.TweetTemplate = fmt.Sprintf("Registering my agent '%s' on %s! Code: %s", input.Body.Name, RequiredMention, code)
 	out.Body.ExpiresIn = "30 minutes"
@@ -567,13 +840,15 @@ func handleVerify(app *pocketbase.PocketBase, input *AgentVerifyInput) (*AgentVe
 func handleChallenge(app *pocketbase.PocketBase, cs *ChallengeStore, input *ChallengeRequestInput) (*ChallengeRequestOutput, error) {
 	pubKey, err := auth.ParsePublicKeyPEM([]byte(input.Body.PublicKey))
 	if err != nil {
-		return nil, huma.Error400BadRequest("Invalid Ed25519 public key PEM", err)
+		return nil, huma.Error400BadRequest("public_key is not a valid Ed25519 public key PEM — check the file wasn't truncated or re-encoded", err)
 	}
 
 	fp := auth.Fingerprint(pubKey)
 	agent, _ := app.FindFirstRecordByData("agents", "pubkey_fingerprint", fp)
 	if agent == nil {
-		return nil, huma.Error404NotFound("Agent not registered")
+		return nil, huma.Error404NotFound(fmt.Sprintf(
+			"Key parsed, but no agent is registered with fingerprint %s. If this is a restored backup it may be the wrong key; "+
+				"check with GET /api/agents/check-key, or register it via POST /api/agents/register", fp))
 	}
 
 	challenge, err := auth.NewChallenge(pubKey)
@@ -581,36 +856,66 @@ func handleChallenge(app *pocketbase.PocketBase, cs *ChallengeStore, input *Chal
 		return nil, huma.Error500InternalServerError("Failed to generate challenge")
 	}
 
-	cs.Set(fp, challenge)
+	id := cs.Add(fp, challenge)
 
 	out := &ChallengeRequestOutput{}
 	out.Body.Nonce = challenge.NonceBase64()
+	out.Body.ChallengeID = id
 	out.Body.ExpiresIn = int(ChallengeTTL.Seconds())
 	return out, nil
 }
 
-func handleAuthenticate(app *pocketbase.PocketBase, cs *ChallengeStore, jwtKey []byte, input *AuthenticateInput) (*AuthenticateOutput, error) {
-	pubKey, err := auth.ParsePublicKeyPEM([]byte(input.Body.PublicKey))
+// handleCheckKey reports whether a key belongs to a registered agent. Only
+// public identity is returned — never email, tokens or owner details.
+func handleCheckKey(app *pocketbase.PocketBase, ip, pem string) (*CheckKeyOutput, error) {
+	if ip == "" {
+		ip = "direct"
+	}
+	if err := ratelimit.CheckKeyLookup(ip); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(pem) == "" {
+		return nil, huma.Error400BadRequest("public_key is required")
+	}
+	pubKey, err := auth.ParsePublicKeyPEM([]byte(pem))
 	if err != nil {
-		return nil, huma.Error400BadRequest("Invalid Ed25519 public key PEM", err)
+		return nil, huma.Error400BadRequest("public_key is not a valid Ed25519 public key PEM — check the file wasn't truncated or re-encoded", err)
 	}
 
-	fp := auth.Fingerprint(pubKey)
-
-	challenge, ok := cs.Pop(fp)
-	if !ok {
-		return nil, huma.Error400BadRequest("No pending challenge. Call /api/agents/challenge first.")
+	out := &CheckKeyOutput{}
+	out.Body.Fingerprint = auth.Fingerprint(pubKey)
+	agent, _ := app.FindFirstRecordByData("agents", "pubkey_fingerprint", out.Body.Fingerprint)
+	if agent == nil {
+		return out, nil
 	}
+	out.Body.Registered = true
+	out.Body.AgentID = agent.Id
+	out.Body.Name = agent.GetString("name")
+	out.Body.Suspended = agent.GetBool("suspended")
+	return out, nil
+}
 
-	if challenge.IsExpired(ChallengeTTL) {
-		return nil, huma.Error400BadRequest("Challenge expired. Request a new one.")
+func handleAuthenticate(app *pocketbase.PocketBase, cs *ChallengeStore, jwtKey *auth.Keyring, input *AuthenticateInput) (*AuthenticateOutput, error) {
+	pubKey, err := auth.ParsePublicKeyPEM([]byte(input.Body.PublicKey))
+	if err != nil {
+		return nil, huma.Error400BadRequest("Invalid Ed25519 public key PEM", err)
 	}
 
-	valid, err := challenge.VerifyResponse(input.Body.Signature)
-	if err != nil {
+	fp := auth.Fingerprint(pubKey)
+
+	if _, err := base64.StdEncoding.DecodeString(input.Body.Signature); err != nil {
 		return nil, huma.Error400BadRequest("Invalid signature encoding", err)
 	}
-	if !valid {
+	_, err = cs.Take(fp, input.Body.ChallengeID, func(c *auth.Challenge) bool {
+		valid, err := c.VerifyResponse(input.Body.Signature)
+		return err == nil && valid
+	})
+	switch {
+	case errors.Is(err, errNoChallenge):
+		return nil, huma.Error400BadRequest("No pending challenge. Call /api/agents/challenge first.")
+	case errors.Is(err, errChallengeExpired):
+		return nil, huma.Error400BadRequest("Challenge expired. Request a new one.")
+	case err != nil:
 		return nil, huma.Error401Unauthorized("Signature verification failed")
 	}
 
@@ -623,20 +928,58 @@ func handleAuthenticate(app *pocketbase.PocketBase, cs *ChallengeStore, jwtKey [
 	if err != nil {
 		return nil, huma.Error500InternalServerError("Failed to issue JWT")
 	}
+	MarkClawAgentMilestone(app, agent.Id, ClawMilestoneAuthenticated)
 
 	out := &AuthenticateOutput{}
 	out.Body.Token = token
 	out.Body.AgentID = agent.Id
 	out.Body.ExpiresIn = int(JwtTTL.Seconds())
 	out.Body.UnreadMessages = UnreadCount(app, agent.Id)
+	if input.Body.IncludeActivity {
+		out.Body.Activity = authActivity(app, agent.Id)
+	}
 	return out, nil
 }
 
+// authActivity assembles the authenticate activity summary from three
+// indexed lookups, so opting in keeps the auth path fast.
+func authActivity(app *pocketbase.PocketBase, agentID string) *AuthActivity {
+	activity := &AuthActivity{}
+	params := map[string]any{"aid": agentID}
+
+	var row struct {
+		N int `db:"n"`
+	}
+	err := app.DB().NewQuery(
+		"SELECT COUNT(*) AS n FROM channel_members m WHERE m.agent_id = {:aid} AND EXISTS (" +
+			"SELECT 1 FROM channel_messages msg WHERE msg.channel_id = m.channel_id " +
+			"AND msg.created > COALESCE(m.last_read_at, '') AND msg.author_id != m.agent_id)").
+		Bind(params).One(&row)
+	if err != nil {
+		app.Logger().Warn("Failed to count unread channels", "agent", agentID, "error", err)
+	}
+	activity.ChannelsWithUnread = row.N
+
+	if latest, err := app.FindRecordsByFilter("messages", "agent_id = {:aid} && read = false", "-created", 1, 0, params); err == nil && len(latest) > 0 {
+		activity.LatestInboxSubject = latest[0].GetString("subject")
+	}
+
+	row.N = 0
+	err = app.DB().NewQuery(
+		"SELECT COUNT(*) AS n FROM review_challenges WHERE agent_id = {:aid} AND used IS NOT TRUE AND replaced_by = '' AND expires > {:now}").
+		Bind(map[string]any{"aid": agentID, "now": time.Now().UTC().Format(time.RFC3339)}).One(&row)
+	if err != nil {
+		app.Logger().Warn("Failed to count pending review challenges", "agent", agentID, "error", err)
+	}
+	activity.PendingReviewChallenges = row.N
+	return activity
+}
+
 // -----------------------------------------------------------------------------
 // JWT resolution helper (used by other route packages)
 // -----------------------------------------------------------------------------
 
-func ResolveAgent(ctx huma.Context, jwtKey []byte) (*auth.AgentClaims, error) {
+func ResolveAgent(ctx huma.Context, jwtKey *auth.Keyring) (*auth.AgentClaims, error) {
 	header := ctx.Header("Authorization")
 	if header == "" {
 		return nil, nil
@@ -651,7 +994,7 @@ func ResolveAgent(ctx huma.Context, jwtKey []byte) (*auth.AgentClaims, error) {
 
 // RequireJWT validates the Authorization header and returns claims or a 401 error.
 // Use this in handlers that need authenticated agents.
-func RequireJWT(authorization string, jwtKey []byte) (*auth.AgentClaims, error) {
+func RequireJWT(authorization string, jwtKey *auth.Keyring) (*auth.AgentClaims, error) {
 	if authorization == "" {
 		return nil, huma.Error401Unauthorized(
 			"Authentication required. Get a JWT via: POST /api/agents/challenge → sign nonce → POST /api/agents/authenticate")
@@ -686,10 +1029,13 @@ func RegisterForwardAuthRoutes(mux *http.ServeMux, app *pocketbase.PocketBase) {
 // handleVerifySession is the Traefik ForwardAuth endpoint.
 // Implements ownership-aware gating for claw routes:
 //   - Extract subdomain from X-Forwarded-Host
+//   - Public bypass paths (e.g. /healthz): always allow (200)
 //   - Look up claw_deployments by subdomain
-//   - /debug path: always require auth + ownership
+//   - /debug path: always require auth + owner or operator
 //   - is_public=true: allow anyone (200)
-//   - is_public=false: require auth + ownership
+//   - is_public=false: require auth + owner or operator
+//
+// Decisions are cached per (subdomain, debug, session token) — see session_cache.go.
 func handleVerifySession(app *pocketbase.PocketBase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		host := r.Header.Get("X-Forwarded-Host")
@@ -704,67 +1050,89 @@ func handleVerifySession(app *pocketbase.PocketBase) http.HandlerFunc {
 			return
 		}
 
+		// Determine if this is a debug request
+		isDebugPath := isDebugSubdomain || strings.HasPrefix(uri, "/debug")
+
+		if !isDebugPath && isPublicForwardAuthPath(uri) {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		token := ""
+		if cookie, err := r.Cookie(sessionCookieName); err == nil {
+			token = cookie.Value
+		}
+
+		// Unknown and sleeping claws are cached under token-independent keys
+		// so scanners can't bypass the cache by varying cookies.
+		for _, k := range []string{"missing", "asleep"} {
+			if d, ok := forwardAuthCache.Get(subdomain, k); ok {
+				writeSessionDecision(w, r, d)
+				return
+			}
+		}
+		key := sessionCacheKey(isDebugPath, token)
+		if d, ok := forwardAuthCache.Get(subdomain, key); ok {
+			writeSessionDecision(w, r, d)
+			return
+		}
+
 		// Look up the claw deployment
 		claws, err := app.FindRecordsByFilter("claw_deployments",
-			"subdomain = {:sub} && status = 'running'", "", 1, 0,
+			"subdomain = {:sub} && (status = 'running' || status = 'sleeping')", "", 1, 0,
 			map[string]any{"sub": subdomain})
 		if err != nil || len(claws) == 0 {
-			http.Error(w, "Claw not found", http.StatusNotFound)
+			d := sessionDecision{kind: decisionNotFound}
+			forwardAuthCache.Set(subdomain, "missing", d)
+			writeSessionDecision(w, r, d)
 			return
 		}
 		claw := claws[0]
-
-		// Determine if this is a debug request
-		isDebugPath := isDebugSubdomain || strings.HasPrefix(uri, "/debug")
-
-		if isDebugPath {
-			// Debug always requires auth + ownership
-			requireOwnership(w, r, app, claw)
+		if claw.GetString("status") == "sleeping" {
+			d := sessionDecision{kind: decisionAsleep,
+				message: fmt.Sprintf("This claw is asleep, %s", describeClawWake(claw))}
+			forwardAuthCache.Set(subdomain, "asleep", d)
+			writeSessionDecision(w, r, d)
 			return
 		}
 
-		if claw.GetBool("is_public") {
+		var d sessionDecision
+		if !isDebugPath && claw.GetBool("is_public") {
 			// Public claw — anyone can view
-			w.WriteHeader(http.StatusOK)
-			return
+			d = sessionDecision{kind: decisionAllow}
+		} else {
+			// Debug or private claw — require auth + operator access
+			d = checkClawSessionAccess(app, token, claw)
 		}
-
-		// Private claw — require auth + ownership
-		requireOwnership(w, r, app, claw)
+		forwardAuthCache.Set(subdomain, key, d)
+		writeSessionDecision(w, r, d)
 	}
 }
 
-// requireOwnership checks the session cookie and verifies the user
-// is either an admin or the claw owner. Returns 200 on success, 302 on failure.
-func requireOwnership(w http.ResponseWriter, r *http.Request, app *pocketbase.PocketBase, claw *core.Record) {
-	cookie, err := r.Cookie(sessionCookieName)
-	if err != nil || cookie.Value == "" {
-		redirectToLogin(w, r)
-		return
+// checkClawSessionAccess validates the session token and verifies the user
+// is an admin, the claw owner or an operator (see claw_collaborators.go).
+func checkClawSessionAccess(app *pocketbase.PocketBase, token string, claw *core.Record) sessionDecision {
+	if token == "" {
+		return sessionDecision{kind: decisionDeny}
 	}
 
-	record, err := app.FindAuthRecordByToken(cookie.Value, core.TokenTypeAuth)
+	record, err := app.FindAuthRecordByToken(token, core.TokenTypeAuth)
 	if err != nil || record == nil {
-		redirectToLogin(w, r)
-		return
+		return sessionDecision{kind: decisionDeny}
 	}
 
 	// Superusers (admins) can access everything
 	if record.Collection().Name == "_superusers" {
-		w.Header().Set("X-Auth-User", record.GetString("email"))
-		w.WriteHeader(http.StatusOK)
-		return
+		return sessionDecision{kind: decisionAllow, authUser: record.GetString("email")}
 	}
 
-	// Regular users — must own this claw
-	if record.Collection().Name == "users" && record.Id == claw.GetString("user_id") {
-		w.Header().Set("X-Auth-User", record.GetString("email"))
-		w.WriteHeader(http.StatusOK)
-		return
+	// Regular users — must own or operate this claw
+	if record.Collection().Name == "users" && clawRoleAtLeast(clawRole(app, claw, record.Id), ClawRoleOperator) {
+		return sessionDecision{kind: decisionAllow, authUser: record.GetString("email")}
 	}
 
 	// Wrong user or unknown collection
-	redirectToLogin(w, r)
+	return sessionDecision{kind: decisionDeny}
 }
 
 // extractSubdomain parses a claw subdomain from a host header.
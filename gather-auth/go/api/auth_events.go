@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Auth events — a short log of challenge and authenticate attempts
+// -----------------------------------------------------------------------------
+
+// Each POST /api/agents/challenge and /api/agents/authenticate is recorded
+// with its outcome and client IP, so support can see why an agent can't get
+// a JWT. Attempts with a key no agent has are kept under the key's
+// fingerprint. Events older than authEventRetention are deleted daily.
+
+const (
+	AuthEventChallenge    = "challenge"
+	AuthEventAuthenticate = "authenticate"
+
+	authEventRetention = 30 * 24 * time.Hour
+)
+
+type AuthEvent struct {
+	Kind        string `json:"kind" enum:"challenge,authenticate"`
+	Success     bool   `json:"success"`
+	Reason      string `json:"reason,omitempty" doc:"Why the attempt failed"`
+	Status      int    `json:"status,omitempty" doc:"HTTP status returned for a failed attempt"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	IP          string `json:"ip,omitempty"`
+	Created     string `json:"created"`
+}
+
+func recordToAuthEvent(r *core.Record) AuthEvent {
+	return AuthEvent{
+		Kind:        r.GetString("kind"),
+		Success:     r.GetBool("success"),
+		Reason:      r.GetString("reason"),
+		Status:      r.GetInt("status"),
+		Fingerprint: r.GetString("fingerprint"),
+		IP:          r.GetString("ip"),
+		Created:     recordTime(r, "created"),
+	}
+}
+
+// recordAuthEvent logs the outcome err of an auth attempt with publicKeyPEM.
+// agentID may be empty; it is then looked up from the key. A key that
+// doesn't parse isn't logged, since it can't be tied to an agent.
+func recordAuthEvent(app *pocketbase.PocketBase, kind, agentID, publicKeyPEM, ip string, err error) {
+	pubKey, perr := auth.ParsePublicKeyPEM([]byte(publicKeyPEM))
+	if perr != nil {
+		return
+	}
+	fp := auth.Fingerprint(pubKey)
+	if agentID == "" {
+		if agent, _ := app.FindFirstRecordByData("agents", "pubkey_fingerprint", fp); agent != nil {
+			agentID = agent.Id
+		}
+	}
+
+	col, cerr := app.FindCollectionByNameOrId("auth_events")
+	if cerr != nil {
+		return
+	}
+	record := core.NewRecord(col)
+	record.Set("agent_id", agentID)
+	record.Set("fingerprint", fp)
+	record.Set("kind", kind)
+	record.Set("success", err == nil)
+	record.Set("ip", ip)
+	if err != nil {
+		var se huma.StatusError
+		if errors.As(err, &se) {
+			record.Set("status", se.GetStatus())
+		}
+		record.Set("reason", truncate(err.Error(), 200))
+	}
+	if serr := app.Save(record); serr != nil {
+		app.Logger().Warn("Failed to record auth event", "kind", kind, "agent", agentID, "error", serr)
+	}
+}
+
+// recentAuthEvents returns an agent's latest auth attempts, newest first.
+func recentAuthEvents(app *pocketbase.PocketBase, agentID string, limit int) []AuthEvent {
+	events := []AuthEvent{}
+	records, err := app.FindRecordsByFilter("auth_events", "agent_id = {:aid}", "-created", limit, 0,
+		map[string]any{"aid": agentID})
+	if err != nil {
+		return events
+	}
+	for _, r := range records {
+		events = append(events, recordToAuthEvent(r))
+	}
+	return events
+}
+
+// RegisterAuthEventCleanupJob deletes auth events older than
+// authEventRetention, daily.
+func RegisterAuthEventCleanupJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("auth_event_cleanup", 24*time.Hour, func(ctx context.Context) error {
+		cutoff := time.Now().UTC().Add(-authEventRetention).Format(pbDateTimeLayout)
+		_, err := app.DB().NewQuery("DELETE FROM auth_events WHERE created < {:cutoff}").
+			Bind(map[string]any{"cutoff": cutoff}).WithContext(ctx).Execute()
+		if err != nil {
+			return fmt.Errorf("clean old auth events: %w", err)
+		}
+		return nil
+	}, jobs.RunOnStart())
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	auth "gather.is/auth"
+)
+
+// signNonce answers a challenge the way a client does.
+func signNonce(t *testing.T, priv ed25519.PrivateKey, nonceB64 string) string {
+	t.Helper()
+	nonce, err := base64.StdEncoding.DecodeString(nonceB64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, nonce))
+}
+
+func TestInterleavedAuthenticate(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "agents", "name", "pubkey_fingerprint")
+	addCollection(t, app, "messages", "agent_id", "type", "subject", "body", "read:bool")
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey, _ := auth.EncodePEM(pub)
+	agent := addRecord(t, app, "agents", map[string]any{"name": "twin", "pubkey_fingerprint": auth.Fingerprint(pub)})
+	cs := &ChallengeStore{items: map[string][]pendingChallenge{}}
+	kr := newTestKeyring(t)
+
+	challenge := func() *ChallengeRequestOutput {
+		in := &ChallengeRequestInput{}
+		in.Body.PublicKey = string(pemKey)
+		out, err := handleChallenge(app, cs, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	authenticate := func(signature, challengeID string) error {
+		in := &AuthenticateInput{}
+		in.Body.PublicKey = string(pemKey)
+		in.Body.Signature = signature
+		in.Body.ChallengeID = challengeID
+		out, err := handleAuthenticate(app, cs, kr, in)
+		if err == nil && out.Body.AgentID != agent.Id {
+			t.Errorf("authenticated as %s", out.Body.AgentID)
+		}
+		return err
+	}
+
+	// Two processes sharing the key: heartbeat asks, claw asks, claw
+	// answers, heartbeat answers
+	heartbeat := challenge()
+	claw := challenge()
+	if err := authenticate(signNonce(t, priv, claw.Body.Nonce), claw.Body.ChallengeID); err != nil {
+		t.Errorf("claw: %v", err)
+	}
+	if err := authenticate(signNonce(t, priv, heartbeat.Body.Nonce), heartbeat.Body.ChallengeID); err != nil {
+		t.Errorf("heartbeat: %v", err)
+	}
+
+	// Older clients don't send challenge_id; the signature picks the challenge
+	heartbeat = challenge()
+	claw = challenge()
+	if err := authenticate(signNonce(t, priv, claw.Body.Nonce), ""); err != nil {
+		t.Errorf("claw without challenge_id: %v", err)
+	}
+	if err := authenticate(signNonce(t, priv, heartbeat.Body.Nonce), ""); err != nil {
+		t.Errorf("heartbeat without challenge_id: %v", err)
+	}
+
+	// Each challenge works once
+	if err := authenticate(signNonce(t, priv, heartbeat.Body.Nonce), ""); apiStatus(err) != 400 {
+		t.Errorf("replay: %v, want 400", err)
+	}
+}
+
+func TestChallengeStore(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp := auth.Fingerprint(pub)
+	newChallenge := func() *auth.Challenge {
+		c, err := auth.NewChallenge(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	signedBy := func(sig []byte) func(*auth.Challenge) bool {
+		return func(c *auth.Challenge) bool { return ed25519.Verify(c.PublicKey, c.Nonce, sig) }
+	}
+	cs := &ChallengeStore{items: map[string][]pendingChallenge{}}
+
+	// Only the newest challengesPerKey are kept
+	first := newChallenge()
+	firstID := cs.Add(fp, first)
+	for i := 0; i < challengesPerKey; i++ {
+		cs.Add(fp, newChallenge())
+	}
+	if len(cs.items[fp]) != challengesPerKey {
+		t.Errorf("%d pending, want %d", len(cs.items[fp]), challengesPerKey)
+	}
+	if _, err := cs.Take(fp, firstID, signedBy(ed25519.Sign(priv, first.Nonce))); !errors.Is(err, errNoChallenge) {
+		t.Errorf("evicted challenge: %v", err)
+	}
+
+	// A named challenge is used up even when the signature is wrong
+	c := newChallenge()
+	id := cs.Add(fp, c)
+	if _, err := cs.Take(fp, id, signedBy([]byte("bad"))); !errors.Is(err, errChallengeMismatch) {
+		t.Errorf("bad signature: %v", err)
+	}
+	if _, err := cs.Take(fp, id, signedBy(ed25519.Sign(priv, c.Nonce))); !errors.Is(err, errNoChallenge) {
+		t.Errorf("retry after bad signature: %v", err)
+	}
+
+	// Without an ID a bad signature uses nothing up
+	n := len(cs.items[fp])
+	if _, err := cs.Take(fp, "", signedBy([]byte("bad"))); !errors.Is(err, errChallengeMismatch) {
+		t.Errorf("bad signature without ID: %v", err)
+	}
+	if len(cs.items[fp]) != n {
+		t.Errorf("%d pending after a failed match, want %d", len(cs.items[fp]), n)
+	}
+
+	// Expired challenges are reported as such and pruned on access
+	other := "other-fp"
+	old := newChallenge()
+	old.CreatedAt = time.Now().Add(-ChallengeTTL - time.Second)
+	oldID := cs.Add(other, newChallenge())
+	cs.items[other][0].Challenge = old
+	if _, err := cs.Take(other, oldID, signedBy(ed25519.Sign(priv, old.Nonce))); !errors.Is(err, errChallengeExpired) {
+		t.Errorf("expired with ID: %v", err)
+	}
+	if _, ok := cs.items[other]; ok {
+		t.Error("expired challenge not pruned")
+	}
+	cs.Add(other, old)
+	if _, err := cs.Take(other, "", signedBy(ed25519.Sign(priv, old.Nonce))); !errors.Is(err, errChallengeExpired) {
+		t.Errorf("expired without ID: %v", err)
+	}
+}
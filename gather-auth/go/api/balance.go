@@ -16,7 +16,7 @@ const defaultFreeCommentsPerDay = 10
 const defaultFreePostsPerWeek = 1
 
 // getOrCreateBalance finds or creates a balance record for an agent.
-func getOrCreateBalance(app *pocketbase.PocketBase, agentID string) (*core.Record, error) {
+func getOrCreateBalance(app core.App, agentID string) (*core.Record, error) {
 	records, err := app.FindRecordsByFilter("agent_balances",
 		"agent_id = {:aid}", "", 1, 0,
 		map[string]any{"aid": agentID})
@@ -88,7 +88,7 @@ func freeCommentsPerDay(app *pocketbase.PocketBase) int {
 }
 
 // deductBalance subtracts amountBCH from the balance. Returns error if insufficient.
-func deductBalance(app *pocketbase.PocketBase, bal *core.Record, amountBCH string) error {
+func deductBalance(app core.App, bal *core.Record, amountBCH string) error {
 	current := parseBCH(bal.GetString("balance_bch"))
 	amount := parseBCH(amountBCH)
 
@@ -171,7 +171,7 @@ func computePostWeight(app *pocketbase.PocketBase, agentID string, paid bool) in
 }
 
 // creditBalance adds amountBCH to the balance (for tips, refunds, etc).
-func creditBalance(app *pocketbase.PocketBase, bal *core.Record, amountBCH string) error {
+func creditBalance(app core.App, bal *core.Record, amountBCH string) error {
 	current := parseBCH(bal.GetString("balance_bch"))
 	amount := parseBCH(amountBCH)
 	current.Add(current, amount)
@@ -179,6 +179,25 @@ func creditBalance(app *pocketbase.PocketBase, bal *core.Record, amountBCH strin
 	return app.Save(bal)
 }
 
+// refundBalance returns a previously deducted amount, reversing both the
+// balance and total_spent_bch.
+func refundBalance(app core.App, bal *core.Record, amountBCH string) error {
+	amount := parseBCH(amountBCH)
+
+	current := parseBCH(bal.GetString("balance_bch"))
+	current.Add(current, amount)
+	bal.Set("balance_bch", current.FloatString(8))
+
+	spent := parseBCH(bal.GetString("total_spent_bch"))
+	spent.Sub(spent, amount)
+	if spent.Sign() < 0 {
+		spent.SetInt64(0)
+	}
+	bal.Set("total_spent_bch", spent.FloatString(8))
+
+	return app.Save(bal)
+}
+
 // getPlatformConfig reads a field from the platform_config singleton.
 func getPlatformConfig(app *pocketbase.PocketBase, field, fallback string) string {
 	records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil)
@@ -9,6 +9,8 @@ import (
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
 
+	auth "gather.is/auth"
+	"gather.is/auth/reputation"
 	"gather.is/auth/shop"
 )
 
@@ -18,13 +20,15 @@ import (
 
 type BalanceOutput struct {
 	Body struct {
-		BalanceBCH            string `json:"balance_bch"`
-		BalanceUSDApprox      string `json:"balance_usd_approx"`
-		PostingFeeBCH         string `json:"posting_fee_bch"`
-		CommentFeeBCH         string `json:"comment_fee_bch"`
-		FreeCommentsRemaining int    `json:"free_comments_remaining"`
-		FreePostsRemaining    int    `json:"free_posts_remaining_this_week"`
-		Suspended             bool   `json:"suspended"`
+		BalanceBCH            string       `json:"balance_bch"`
+		BalanceUSDApprox      string       `json:"balance_usd_approx"`
+		PostingFeeBCH         string       `json:"posting_fee_bch"`
+		CommentFeeBCH         string       `json:"comment_fee_bch"`
+		FreeCommentsRemaining int          `json:"free_comments_remaining"`
+		FreePostsRemaining    int          `json:"free_posts_remaining_this_week"`
+		Suspended             bool         `json:"suspended"`
+		PendingTipsIncoming   []PendingTip `json:"pending_tips_incoming" doc:"Escrowed tips on your posts waiting for you to claim"`
+		PendingTipsOutgoing   []PendingTip `json:"pending_tips_outgoing" doc:"Tips you sent that are still unclaimed (already debited from your balance)"`
 	}
 }
 
@@ -47,15 +51,31 @@ type DepositOutput struct {
 	}
 }
 
+type FeeSchedule struct {
+	PostFeeUSD       string `json:"post_fee_usd"`
+	PostFeeBCH       string `json:"post_fee_bch"`
+	PostFreeWeekly   int    `json:"post_free_weekly"`
+	CommentFreeDaily int    `json:"comment_free_daily"`
+	CommentFeeUSD    string `json:"comment_fee_usd"`
+	CommentFeeBCH    string `json:"comment_fee_bch"`
+	DepositAddress   string `json:"deposit_address"`
+}
+
 type FeesOutput struct {
-	Body struct {
-		PostFeeUSD       string `json:"post_fee_usd"`
-		PostFeeBCH       string `json:"post_fee_bch"`
-		PostFreeWeekly   int    `json:"post_free_weekly"`
-		CommentFreeDaily int    `json:"comment_free_daily"`
-		CommentFeeUSD    string `json:"comment_fee_usd"`
-		CommentFeeBCH    string `json:"comment_fee_bch"`
-		DepositAddress   string `json:"deposit_address"`
+	Body FeeSchedule
+}
+
+// currentFeeSchedule is the public fee schedule, shared by /api/balance/fees
+// and /discover.
+func currentFeeSchedule(app *pocketbase.PocketBase) FeeSchedule {
+	return FeeSchedule{
+		PostFeeUSD:       getPlatformConfig(app, "post_fee_usd", "0.02"),
+		PostFeeBCH:       postingFeeBCH(app),
+		PostFreeWeekly:   freePostsPerWeek(app),
+		CommentFreeDaily: freeCommentsPerDay(app),
+		CommentFeeUSD:    getPlatformConfig(app, "comment_fee_usd", "0.005"),
+		CommentFeeBCH:    commentFeeBCH(app),
+		DepositAddress:   shop.ShopBCHAddress(),
 	}
 }
 
@@ -63,7 +83,7 @@ type FeesOutput struct {
 // Route registration
 // -----------------------------------------------------------------------------
 
-func RegisterBalanceRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte) {
+func RegisterBalanceRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
 
 	// GET /api/balance — agent's current balance and fee info
 	huma.Register(api, huma.Operation{
@@ -114,6 +134,7 @@ func RegisterBalanceRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		out.Body.FreeCommentsRemaining = remaining
 		out.Body.FreePostsRemaining = postsRemaining
 		out.Body.Suspended = bal.GetBool("suspended")
+		out.Body.PendingTipsIncoming, out.Body.PendingTipsOutgoing = pendingTipsFor(app, claims.AgentID)
 		return out, nil
 	})
 
@@ -196,37 +217,28 @@ func RegisterBalanceRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		Description: "Returns current posting and comment fees. No authentication required.",
 		Tags:        []string{"Balance"},
 	}, func(ctx context.Context, input *struct{}) (*FeesOutput, error) {
-		postUSD := getPlatformConfig(app, "post_fee_usd", "0.02")
-		commentUSD := getPlatformConfig(app, "comment_fee_usd", "0.005")
-
-		out := &FeesOutput{}
-		out.Body.PostFeeUSD = postUSD
-		out.Body.PostFeeBCH = postingFeeBCH(app)
-		out.Body.PostFreeWeekly = freePostsPerWeek(app)
-		out.Body.CommentFreeDaily = freeCommentsPerDay(app)
-		out.Body.CommentFeeUSD = commentUSD
-		out.Body.CommentFeeBCH = commentFeeBCH(app)
-		out.Body.DepositAddress = shop.ShopBCHAddress()
-		return out, nil
+		return &FeesOutput{Body: currentFeeSchedule(app)}, nil
 	})
 
 	// POST /api/balance/tip — tip another agent
 	type TipInput struct {
 		Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
 		Body          struct {
-			To        string `json:"to" doc:"Recipient agent ID" minLength:"1"`
+			To        string `json:"to,omitempty" doc:"Recipient agent ID (required unless post_id is given)"`
 			AmountBCH string `json:"amount_bch" doc:"BCH amount to tip (e.g. 0.00010000)" minLength:"1"`
-			PostID    string `json:"post_id,omitempty" doc:"Optional: post this tip is for"`
+			PostID    string `json:"post_id,omitempty" doc:"Optional: post this tip is for. The tip is escrowed until the post's author claims it."`
 			Message   string `json:"message,omitempty" doc:"Optional: short note" maxLength:"200"`
 		}
 	}
 
 	type TipOutput struct {
 		Body struct {
-			FromBalance string `json:"from_balance_bch"`
-			ToBalance   string `json:"to_balance_bch"`
-			Amount      string `json:"amount_bch"`
-			Message     string `json:"message"`
+			FromBalance  string `json:"from_balance_bch"`
+			ToBalance    string `json:"to_balance_bch,omitempty"`
+			Amount       string `json:"amount_bch"`
+			Message      string `json:"message"`
+			PendingTipID string `json:"pending_tip_id,omitempty" doc:"Set when the tip is escrowed"`
+			ClaimBy      string `json:"claim_by,omitempty" doc:"Escrowed tips not claimed by then return to you"`
 		}
 	}
 
@@ -235,16 +247,32 @@ func RegisterBalanceRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		Method:      "POST",
 		Path:        "/api/balance/tip",
 		Summary:     "Tip another agent",
-		Description: "Transfer BCH from your balance to another agent. Optionally reference a post and include a message.",
-		Tags:        []string{"Balance"},
+		Description: "Transfer BCH from your balance to another agent, optionally with a message. " +
+			"Tips with post_id go to the post's author and are held in escrow until they claim them " +
+			"(POST /api/balance/tips/{id}/claim) within 14 days; unclaimed tips are refunded. Tips without post_id are instant.",
+		Tags: []string{"Balance"},
 	}, func(ctx context.Context, input *TipInput) (*TipOutput, error) {
 		claims, err := RequireJWT(input.Authorization, jwtKey)
 		if err != nil {
 			return nil, err
 		}
 
+		// A post tip goes to the post's author; 'to' is optional but must agree
+		if input.Body.PostID != "" {
+			post, err := app.FindRecordById("posts", input.Body.PostID)
+			if err != nil || !postVisible(post) {
+				return nil, huma.Error404NotFound("Post not found")
+			}
+			author := post.GetString("author_id")
+			if input.Body.To != "" && input.Body.To != author {
+				return nil, huma.Error422UnprocessableEntity(
+					fmt.Sprintf("Post %s was written by %s, not %s. Omit 'to' to tip the author.", post.Id, author, input.Body.To))
+			}
+			input.Body.To = author
+		}
+
 		if input.Body.To == "" {
-			return nil, huma.Error422UnprocessableEntity("'to' (recipient agent ID) is required")
+			return nil, huma.Error422UnprocessableEntity("'to' (recipient agent ID) or post_id is required")
 		}
 
 		if input.Body.To == claims.AgentID {
@@ -261,32 +289,42 @@ func RegisterBalanceRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		if err != nil {
 			return nil, huma.Error404NotFound("Recipient agent not found")
 		}
-
-		// Deduct from sender
-		senderBal, err := getOrCreateBalance(app, claims.AgentID)
-		if err != nil {
-			return nil, huma.Error500InternalServerError("Failed to get sender balance")
-		}
-		if err := deductBalance(app, senderBal, input.Body.AmountBCH); err != nil {
-			return nil, huma.Error402PaymentRequired("Insufficient balance for tip")
-		}
-
-		// Credit recipient
-		recipientBal, err := getOrCreateBalance(app, input.Body.To)
-		if err != nil {
-			return nil, huma.Error500InternalServerError("Failed to get recipient balance")
-		}
-		if err := creditBalance(app, recipientBal, input.Body.AmountBCH); err != nil {
-			return nil, huma.Error500InternalServerError("Failed to credit recipient")
+		if isAgentBlocked(app, input.Body.To, claims.AgentID) {
+			return nil, huma.Error403Forbidden("This tip can't be sent")
 		}
 
-		// Inbox notifications
 		senderName := claims.AgentID
 		if agent, err := app.FindRecordById("agents", claims.AgentID); err == nil {
 			senderName = agent.GetString("name")
 		}
 		recipientName := recipient.GetString("name")
 
+		if input.Body.PostID != "" {
+			tip, err := createEscrowedTip(app, claims.AgentID, input.Body.To,
+				input.Body.AmountBCH, input.Body.PostID, input.Body.Message)
+			if err != nil {
+				return nil, err
+			}
+			notifyEscrowedTip(app, tip, senderName, recipientName)
+
+			senderBal, _ := getOrCreateBalance(app, claims.AgentID)
+			out := &TipOutput{}
+			if senderBal != nil {
+				out.Body.FromBalance = senderBal.GetString("balance_bch")
+			}
+			out.Body.Amount = input.Body.AmountBCH
+			out.Body.PendingTipID = tip.Id
+			out.Body.ClaimBy = recordToPendingTip(tip).ExpiresAt
+			out.Body.Message = "Tip escrowed until " + recipientName + " claims it"
+			return out, nil
+		}
+
+		if err := sendInstantTip(app, claims.AgentID, input.Body.To, input.Body.AmountBCH); err != nil {
+			return nil, err
+		}
+		reputation.UpdateAgentReputation(app, input.Body.To)
+
+		// Inbox notifications
 		tipMsg := fmt.Sprintf("Tipped %s BCH to %s", input.Body.AmountBCH, recipientName)
 		if input.Body.Message != "" {
 			tipMsg += ": " + input.Body.Message
@@ -297,15 +335,11 @@ func RegisterBalanceRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		if input.Body.Message != "" {
 			recvMsg += ": " + input.Body.Message
 		}
-		refType, refID := "", ""
-		if input.Body.PostID != "" {
-			refType, refID = "post", input.Body.PostID
-		}
-		SendInboxMessage(app, input.Body.To, "tip_received", "Tip received", recvMsg, refType, refID)
+		SendInboxMessage(app, input.Body.To, "tip_received", "Tip received", recvMsg, "", "")
 
 		// Re-read balances for response
-		senderBal, _ = getOrCreateBalance(app, claims.AgentID)
-		recipientBal, _ = getOrCreateBalance(app, input.Body.To)
+		senderBal, _ := getOrCreateBalance(app, claims.AgentID)
+		recipientBal, _ := getOrCreateBalance(app, input.Body.To)
 
 		out := &TipOutput{}
 		out.Body.FromBalance = senderBal.GetString("balance_bch")
@@ -314,4 +348,48 @@ func RegisterBalanceRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		out.Body.Message = "Tip sent successfully"
 		return out, nil
 	})
+	registerTipEscrowRoutes(api, app, jwtKey)
+}
+
+// sendInstantTip moves amountBCH from one agent's balance to another's and
+// records it in the tips ledger, all in one transaction: either the tip
+// happened and counts towards the recipient's reputation, or nothing did.
+func sendInstantTip(app *pocketbase.PocketBase, from, to, amountBCH string) error {
+	var insufficient bool
+	err := app.RunInTransaction(func(txApp core.App) error {
+		senderBal, err := getOrCreateBalance(txApp, from)
+		if err != nil {
+			return err
+		}
+		if err := deductBalance(txApp, senderBal, amountBCH); err != nil {
+			insufficient = true
+			return err
+		}
+		recipientBal, err := getOrCreateBalance(txApp, to)
+		if err != nil {
+			return err
+		}
+		if err := creditBalance(txApp, recipientBal, amountBCH); err != nil {
+			return err
+		}
+
+		// Record in the tips ledger (feeds recipient reputation)
+		tipsCol, err := txApp.FindCollectionByNameOrId("tips")
+		if err != nil {
+			return err
+		}
+		tip := core.NewRecord(tipsCol)
+		tip.Set("from_agent", from)
+		tip.Set("to_agent", to)
+		tip.Set("amount_bch", amountBCH)
+		return txApp.Save(tip)
+	})
+	if insufficient {
+		return huma.Error402PaymentRequired("Insufficient balance for tip")
+	}
+	if err != nil {
+		app.Logger().Error("Failed to send tip", "from", from, "to", to, "error", err)
+		return huma.Error500InternalServerError("Failed to send tip")
+	}
+	return nil
 }
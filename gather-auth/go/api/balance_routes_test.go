@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+)
+
+func addBalancesCollection(t *testing.T, app *pocketbase.PocketBase) {
+	t.Helper()
+	addCollection(t, app, "agent_balances", "agent_id", "balance_bch", "total_deposited_bch",
+		"total_spent_bch", "starter_credited:bool", "suspended:bool")
+}
+
+func balanceOf(t *testing.T, app *pocketbase.PocketBase, agentID string) string {
+	t.Helper()
+	bal, err := getOrCreateBalance(app, agentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bal.GetString("balance_bch")
+}
+
+func apiStatus(err error) int {
+	var se huma.StatusError
+	if errors.As(err, &se) {
+		return se.GetStatus()
+	}
+	return 0
+}
+
+func TestSendInstantTip(t *testing.T) {
+	app := newTestApp(t)
+	addBalancesCollection(t, app)
+	addCollection(t, app, "tips", "from_agent", "to_agent", "amount_bch", "post_id")
+	addRecord(t, app, "agent_balances", map[string]any{"agent_id": "alice", "balance_bch": "0.01000000", "total_spent_bch": "0.00000000"})
+
+	if err := sendInstantTip(app, "alice", "bob", "0.00250000"); err != nil {
+		t.Fatal(err)
+	}
+	if got := balanceOf(t, app, "alice"); got != "0.00750000" {
+		t.Errorf("sender balance = %s", got)
+	}
+	if got := balanceOf(t, app, "bob"); got != "0.00250000" {
+		t.Errorf("recipient balance = %s", got)
+	}
+	tips, _ := app.FindRecordsByFilter("tips", "to_agent = 'bob'", "", 0, 0, nil)
+	if len(tips) != 1 || tips[0].GetString("from_agent") != "alice" || tips[0].GetString("amount_bch") != "0.00250000" {
+		t.Errorf("tips ledger = %v", tips)
+	}
+
+	if err := sendInstantTip(app, "alice", "bob", "1"); apiStatus(err) != 402 {
+		t.Errorf("overdrawn tip: %v, want 402", err)
+	}
+	if got := balanceOf(t, app, "alice"); got != "0.00750000" {
+		t.Errorf("sender balance after refused tip = %s", got)
+	}
+}
+
+func TestSendInstantTipRollsBackWithoutLedger(t *testing.T) {
+	app := newTestApp(t)
+	addBalancesCollection(t, app)
+	// No tips collection: the ledger write fails
+	addRecord(t, app, "agent_balances", map[string]any{"agent_id": "alice", "balance_bch": "0.01000000", "total_spent_bch": "0.00000000"})
+	addRecord(t, app, "agent_balances", map[string]any{"agent_id": "bob", "balance_bch": "0.00000000", "total_spent_bch": "0.00000000"})
+
+	if err := sendInstantTip(app, "alice", "bob", "0.00250000"); apiStatus(err) != 500 {
+		t.Fatalf("err = %v, want 500", err)
+	}
+	if got := balanceOf(t, app, "alice"); got != "0.01000000" {
+		t.Errorf("sender balance = %s, want it unchanged", got)
+	}
+	if got := balanceOf(t, app, "bob"); got != "0.00000000" {
+		t.Errorf("recipient balance = %s, want it unchanged", got)
+	}
+}
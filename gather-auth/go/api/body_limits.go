@@ -0,0 +1,177 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Request body limits — size caps and read deadlines per route group
+// -----------------------------------------------------------------------------
+
+// Huma caps each operation's body on its own, but only once it is reading
+// it, and raw routes have whatever their handler does. Every request with a
+// body goes through limitRequestBody first: one whose Content-Length is over
+// its group's cap gets 413 before a byte is read, and the rest must arrive
+// within the group's read timeout. JSON bodies without a Content-Length are
+// read here, at most cap+1 bytes, so an oversized chunked body is a 413 too
+// rather than a failed read in the handler. Uploads stream to their handler
+// through http.MaxBytesReader.
+
+const (
+	// ServerReadHeaderTimeout and ServerReadTimeout bound every request on
+	// the underlying server; ServerIdleTimeout closes idle keep-alives.
+	ServerReadHeaderTimeout = 10 * time.Second
+	ServerReadTimeout       = 2 * time.Minute
+	ServerIdleTimeout       = 2 * time.Minute
+
+	bodyLimitTiny    = 16 << 10
+	bodyLimitDefault = 1 << 20
+	bodyLimitPosts   = 256 << 10
+)
+
+// bodyLimit is the body cap and read timeout of routes under Prefix (and
+// ending in Suffix, if set).
+type bodyLimit struct {
+	Prefix      string
+	Suffix      string
+	MaxBytes    int64
+	ReadTimeout time.Duration
+	Stream      bool   // multipart upload: streamed to the handler, not buffered here
+	TooLarge    string // 413 detail, when the group has a more helpful one
+}
+
+// bodyLimits maps paths to limits; the longest match wins, and anything
+// unlisted gets the "/" entry.
+var bodyLimits = []bodyLimit{
+	{Prefix: "/", MaxBytes: bodyLimitDefault, ReadTimeout: 15 * time.Second},
+
+	// Unauthenticated auth and contact forms: tiny
+	{Prefix: "/api/agents/register", MaxBytes: bodyLimitTiny, ReadTimeout: 10 * time.Second},
+	{Prefix: "/api/agents/challenge", MaxBytes: bodyLimitTiny, ReadTimeout: 10 * time.Second},
+	{Prefix: "/api/agents/authenticate", MaxBytes: bodyLimitTiny, ReadTimeout: 10 * time.Second},
+	{Prefix: "/api/auth/", MaxBytes: bodyLimitTiny, ReadTimeout: 10 * time.Second},
+	{Prefix: "/api/pow/", MaxBytes: bodyLimitTiny, ReadTimeout: 10 * time.Second},
+	{Prefix: "/api/feedback", MaxBytes: bodyLimitTiny, ReadTimeout: 10 * time.Second},
+	{Prefix: "/api/waitlist", MaxBytes: bodyLimitTiny, ReadTimeout: 10 * time.Second},
+
+	// Posts and comments: text capped at 10,000 characters
+	{Prefix: "/api/posts", MaxBytes: bodyLimitPosts, ReadTimeout: 15 * time.Second},
+	{Prefix: "/api/comments/", MaxBytes: bodyLimitPosts, ReadTimeout: 15 * time.Second},
+
+	// Reviews carry CLI output and inline artifacts
+	{Prefix: "/api/reviews/submit", MaxBytes: reviewSubmitMaxBytes, ReadTimeout: time.Minute, TooLarge: reviewSubmitLimitsMessage},
+	{Prefix: "/api/admin/skills/import", MaxBytes: skillImportMaxBytes, ReadTimeout: time.Minute},
+
+	// Uploads (raw routes), with room for the multipart envelope
+	{Prefix: "/api/designs/upload", MaxBytes: DesignMaxBytes() + 1<<20, ReadTimeout: ServerReadTimeout, Stream: true},
+	{Prefix: "/api/agents/me/avatar", MaxBytes: AgentAvatarMaxBytes + 64<<10, ReadTimeout: 30 * time.Second, Stream: true},
+	{Prefix: "/api/channels/", Suffix: "/messages/attachments", MaxBytes: ChannelAttachmentMaxBytes + 1<<20, ReadTimeout: time.Minute, Stream: true},
+}
+
+// bodyLimitFor returns the limit that applies to path.
+func bodyLimitFor(path string) bodyLimit {
+	best := bodyLimits[0]
+	for _, l := range bodyLimits[1:] {
+		if strings.HasPrefix(path, l.Prefix) && strings.HasSuffix(path, l.Suffix) &&
+			len(l.Prefix)+len(l.Suffix) > len(best.Prefix)+len(best.Suffix) {
+			best = l
+		}
+	}
+	return best
+}
+
+// LimitRequestBodies wraps a handler so every request body is held to its
+// route group's limit.
+func LimitRequestBodies(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limitRequestBody(w, r) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// LimitRequestBody is LimitRequestBodies as a PocketBase route middleware,
+// for the raw routes.
+func LimitRequestBody(re *core.RequestEvent) error {
+	if !limitRequestBody(re.Response, re.Request) {
+		return nil
+	}
+	return re.Next()
+}
+
+// limitRequestBody applies r's limit, replacing r.Body. It returns false when
+// it has already answered with 413 or 408.
+func limitRequestBody(w http.ResponseWriter, r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return true
+	}
+	limit := bodyLimitFor(r.URL.Path)
+	if r.ContentLength > limit.MaxBytes {
+		writeBodyLimitError(w, http.StatusRequestEntityTooLarge, limit,
+			fmt.Sprintf("Request body is %d bytes; the limit for %s is %d bytes", r.ContentLength, r.URL.Path, limit.MaxBytes))
+		return false
+	}
+
+	// Writers that can't reach the connection can't set a deadline; then
+	// only the server-wide ServerReadTimeout applies.
+	_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(limit.ReadTimeout))
+	r.Body = http.MaxBytesReader(w, r.Body, limit.MaxBytes)
+	if limit.Stream || strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		return true
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		var netErr net.Error
+		switch {
+		case errors.As(err, &tooLarge):
+			writeBodyLimitError(w, http.StatusRequestEntityTooLarge, limit,
+				fmt.Sprintf("Request body exceeds the limit for %s of %d bytes", r.URL.Path, limit.MaxBytes))
+		case errors.As(err, &netErr) && netErr.Timeout():
+			writeBodyLimitError(w, http.StatusRequestTimeout, limit,
+				fmt.Sprintf("Request body was not received within %s", limit.ReadTimeout))
+		default:
+			writeBodyLimitError(w, http.StatusBadRequest, limit, "Failed to read request body")
+		}
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	r.ContentLength = int64(len(data))
+	return true
+}
+
+// bodyLimitError matches Huma's error model, plus the limits that applied.
+type bodyLimitError struct {
+	Title          string `json:"title"`
+	Status         int    `json:"status"`
+	Detail         string `json:"detail"`
+	LimitBytes     int64  `json:"limit_bytes"`
+	ReadTimeoutSec int    `json:"read_timeout_seconds"`
+}
+
+func writeBodyLimitError(w http.ResponseWriter, status int, limit bodyLimit, detail string) {
+	if status == http.StatusRequestEntityTooLarge && limit.TooLarge != "" {
+		detail = limit.TooLarge
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Connection", "close") // don't read the rest of the body to reuse the connection
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(bodyLimitError{
+		Title:          http.StatusText(status),
+		Status:         status,
+		Detail:         detail,
+		LimitBytes:     limit.MaxBytes,
+		ReadTimeoutSec: int(limit.ReadTimeout / time.Second),
+	})
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// countingReader yields n bytes of '{' and counts how many were read.
+type countingReader struct {
+	n, read int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	if c.read >= c.n {
+		return 0, io.EOF
+	}
+	k := min(int64(len(p)), c.n-c.read)
+	for i := range p[:k] {
+		p[i] = '{'
+	}
+	c.read += k
+	return int(k), nil
+}
+
+// timeoutReader fails like a connection whose read deadline passed.
+type timeoutReader struct{}
+
+func (timeoutReader) Read([]byte) (int, error) { return 0, timeoutError{} }
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// serveLimited sends body through LimitRequestBodies, reporting whether the
+// handler ran.
+func serveLimited(t *testing.T, path string, body io.Reader, contentLength int64) (*httptest.ResponseRecorder, bool) {
+	t.Helper()
+	reached := false
+	h := LimitRequestBodies(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		io.Copy(io.Discard, r.Body)
+	}))
+	req := httptest.NewRequest("POST", path, body)
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = contentLength
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec, reached
+}
+
+func TestLargePostBodyRejectedBeforeDecoding(t *testing.T) {
+	const size = 30 << 20
+
+	// With a Content-Length: refused before a byte is read
+	body := &countingReader{n: size}
+	rec, reached := serveLimited(t, "/api/posts", body, size)
+	if rec.Code != http.StatusRequestEntityTooLarge || reached {
+		t.Errorf("Content-Length: status %d, handler reached %v", rec.Code, reached)
+	}
+	if body.read != 0 {
+		t.Errorf("Content-Length: read %d bytes, want 0", body.read)
+	}
+	var e bodyLimitError
+	if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil || e.LimitBytes != bodyLimitPosts || e.Status != 413 {
+		t.Errorf("error body %s (%v)", rec.Body.String(), err)
+	}
+
+	// Chunked: read only up to the cap
+	body = &countingReader{n: size}
+	rec, reached = serveLimited(t, "/api/posts", body, -1)
+	if rec.Code != http.StatusRequestEntityTooLarge || reached {
+		t.Errorf("chunked: status %d, handler reached %v", rec.Code, reached)
+	}
+	if body.read > bodyLimitPosts+64<<10 {
+		t.Errorf("chunked: read %d bytes of %d before refusing", body.read, size)
+	}
+}
+
+func TestBodyWithinLimitReachesHandler(t *testing.T) {
+	payload := `{"title":"hi"}`
+	var got string
+	h := LimitRequestBodies(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+	}))
+	for _, cl := range []int64{int64(len(payload)), -1} {
+		got = ""
+		req := httptest.NewRequest("POST", "/api/posts", strings.NewReader(payload))
+		req.ContentLength = cl
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || got != payload {
+			t.Errorf("Content-Length %d: status %d, handler got %q", cl, rec.Code, got)
+		}
+	}
+}
+
+func TestBodyReadTimeout(t *testing.T) {
+	rec, reached := serveLimited(t, "/api/feedback", timeoutReader{}, -1)
+	if rec.Code != http.StatusRequestTimeout || reached {
+		t.Errorf("status %d, handler reached %v", rec.Code, reached)
+	}
+}
+
+func TestStreamedUploadCapped(t *testing.T) {
+	limit := bodyLimitFor("/api/agents/me/avatar")
+	var readErr error
+	h := LimitRequestBodies(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.Copy(io.Discard, r.Body)
+	}))
+	req := httptest.NewRequest("POST", "/api/agents/me/avatar", bytes.NewReader(make([]byte, limit.MaxBytes+1)))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	req.ContentLength = -1
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if readErr == nil {
+		t.Error("upload over the cap streamed to the handler without error")
+	}
+}
+
+func TestBodyLimitFor(t *testing.T) {
+	cases := map[string]int64{
+		"/api/agents/register":                   bodyLimitTiny,
+		"/api/feedback":                          bodyLimitTiny,
+		"/api/posts":                             bodyLimitPosts,
+		"/api/posts/abc/comments":                bodyLimitPosts,
+		"/api/reviews/submit":                    reviewSubmitMaxBytes,
+		"/api/channels/abc/messages":             bodyLimitDefault,
+		"/api/channels/abc/messages/attachments": ChannelAttachmentMaxBytes + 1<<20,
+		"/api/unlisted":                          bodyLimitDefault,
+	}
+	for path, want := range cases {
+		if got := bodyLimitFor(path).MaxBytes; got != want {
+			t.Errorf("%s: limit %d, want %d", path, got, want)
+		}
+	}
+}
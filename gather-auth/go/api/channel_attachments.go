@@ -0,0 +1,313 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+
+	auth "gather.is/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Channel message attachments
+// -----------------------------------------------------------------------------
+
+// Attachments are stored in channel_attachments as protected files, so the
+// public PocketBase file URL doesn't serve them; downloads go through
+// GET /api/channels/{id}/attachments/{attachment_id}, which checks channel
+// membership. A per-agent daily count and a per-channel storage cap
+// (platform_config) keep channels from turning into free file hosting.
+
+// ChannelAttachmentMaxBytes caps a single attachment.
+const ChannelAttachmentMaxBytes = 5 << 20
+
+// Default attachment limits, used when platform_config has no value.
+const (
+	DefaultAttachmentsPerDay          = 20
+	DefaultAttachmentChannelStorageMB = 100
+)
+
+// attachmentTypes maps allowed extensions to the MIME type we store and serve.
+// The client's Content-Type is ignored.
+var attachmentTypes = map[string]string{
+	".png":   "image/png",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".webp":  "image/webp",
+	".pdf":   "application/pdf",
+	".txt":   "text/plain; charset=utf-8",
+	".log":   "text/plain; charset=utf-8",
+	".md":    "text/markdown; charset=utf-8",
+	".csv":   "text/csv; charset=utf-8",
+	".json":  "application/json",
+	".diff":  "text/x-diff; charset=utf-8",
+	".patch": "text/x-diff; charset=utf-8",
+}
+
+// AllowedAttachmentExts lists the accepted extensions, for error messages.
+const AllowedAttachmentExts = "png, jpg, jpeg, webp, pdf, txt, log, md, csv, json, diff, patch"
+
+type ChannelAttachment struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Size     int    `json:"size" doc:"Bytes"`
+	MimeType string `json:"mime_type"`
+	URL      string `json:"url" doc:"Download URL (requires JWT and channel membership)"`
+}
+
+func recordToChannelAttachment(r *core.Record) *ChannelAttachment {
+	return &ChannelAttachment{
+		ID:       r.Id,
+		Name:     r.GetString("original_name"),
+		Size:     r.GetInt("size"),
+		MimeType: r.GetString("mime_type"),
+		URL:      fmt.Sprintf("/api/channels/%s/attachments/%s", r.GetString("channel_id"), r.Id),
+	}
+}
+
+// channelAttachmentsByID loads attachment metadata for a page of messages in
+// one query.
+func channelAttachmentsByID(app *pocketbase.PocketBase, ids []string) map[string]*ChannelAttachment {
+	out := map[string]*ChannelAttachment{}
+	if len(ids) == 0 {
+		return out
+	}
+	records, err := app.FindRecordsByIds("channel_attachments", ids)
+	if err != nil {
+		return out
+	}
+	for _, r := range records {
+		out[r.Id] = recordToChannelAttachment(r)
+	}
+	return out
+}
+
+// validateAttachmentContent checks that the bytes match the extension:
+// magic bytes for images (shared with design uploads) and PDFs, valid UTF-8
+// without NULs for text.
+func validateAttachmentContent(data []byte, ext string) error {
+	switch ext {
+	case ".png", ".jpg", ".jpeg", ".webp":
+		if !IsValidImageContent(data[:min(len(data), 512)], ext) {
+			return fmt.Errorf("file content does not match '%s' format", ext)
+		}
+	case ".pdf":
+		if !bytes.HasPrefix(data, []byte("%PDF-")) {
+			return fmt.Errorf("file content is not a PDF")
+		}
+	default:
+		if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+			return fmt.Errorf("'%s' attachments must be UTF-8 text", ext)
+		}
+	}
+	return nil
+}
+
+// attachmentLimits returns the per-agent daily attachment count and the
+// per-channel storage cap in bytes.
+func attachmentLimits(app *pocketbase.PocketBase) (perDay int, channelBytes int64) {
+	perDay, storageMB := DefaultAttachmentsPerDay, DefaultAttachmentChannelStorageMB
+	records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil)
+	if err == nil && len(records) > 0 {
+		if v := int(records[0].GetFloat("attachments_per_day")); v > 0 {
+			perDay = v
+		}
+		if v := int(records[0].GetFloat("attachment_channel_storage_mb")); v > 0 {
+			storageMB = v
+		}
+	}
+	return perDay, int64(storageMB) << 20
+}
+
+// checkAttachmentQuota enforces the daily per-agent count and the channel
+// storage cap for an upload of size bytes.
+func checkAttachmentQuota(app *pocketbase.PocketBase, agentID, channelID string, size int) error {
+	perDay, channelBytes := attachmentLimits(app)
+
+	since := time.Now().UTC().Add(-24 * time.Hour).Format(pbDateTimeLayout)
+	recent, _ := app.FindRecordsByFilter("channel_attachments",
+		"agent_id = {:aid} && created >= {:since}", "", 0, 0,
+		map[string]any{"aid": agentID, "since": since})
+	if len(recent) >= perDay {
+		return huma.Error429TooManyRequests(fmt.Sprintf("Attachment limit reached (%d per 24 hours)", perDay))
+	}
+
+	var used struct {
+		Total int64 `db:"total"`
+	}
+	err := app.DB().NewQuery("SELECT COALESCE(SUM(size), 0) AS total FROM channel_attachments WHERE channel_id = {:cid}").
+		Bind(map[string]any{"cid": channelID}).One(&used)
+	if err != nil {
+		return huma.Error500InternalServerError("Failed to check channel storage")
+	}
+	if used.Total+int64(size) > channelBytes {
+		return huma.NewError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Channel attachment storage is full (%dMB cap)", channelBytes>>20))
+	}
+	return nil
+}
+
+// CreateChannelAttachment validates an uploaded file and posts it to the
+// channel as a message. caption becomes the message body; it defaults to the
+// file name. Errors are huma status errors.
+func CreateChannelAttachment(app *pocketbase.PocketBase, channelID, agentID, filename string, data []byte, caption string) (*ChannelMsg, error) {
+	if _, err := app.FindRecordById("channels", channelID); err != nil {
+		return nil, huma.Error404NotFound("Channel not found")
+	}
+	if !isChannelMember(app, channelID, agentID) {
+		return nil, huma.Error403Forbidden("You are not a member of this channel")
+	}
+
+	filename = filepath.Base(filename)
+	ext := strings.ToLower(filepath.Ext(filename))
+	mimeType, ok := attachmentTypes[ext]
+	if !ok {
+		return nil, huma.Error400BadRequest(fmt.Sprintf("File type '%s' not allowed. Accepted: %s", ext, AllowedAttachmentExts))
+	}
+	if len(data) == 0 {
+		return nil, huma.Error400BadRequest("File is empty")
+	}
+	if len(data) > ChannelAttachmentMaxBytes {
+		return nil, huma.NewError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("File is too large (max %dMB)", ChannelAttachmentMaxBytes>>20))
+	}
+	if err := validateAttachmentContent(data, ext); err != nil {
+		return nil, huma.Error400BadRequest(err.Error())
+	}
+
+	caption = strings.TrimSpace(caption)
+	if caption == "" {
+		caption = filename
+	}
+	if utf8.RuneCountInString(caption) > 5000 {
+		return nil, huma.Error400BadRequest("Caption must be at most 5000 characters")
+	}
+
+	if err := checkAttachmentQuota(app, agentID, channelID, len(data)); err != nil {
+		return nil, err
+	}
+
+	msgCol, err := app.FindCollectionByNameOrId("channel_messages")
+	if err != nil {
+		return nil, huma.Error500InternalServerError("channel_messages collection not found")
+	}
+	attCol, err := app.FindCollectionByNameOrId("channel_attachments")
+	if err != nil {
+		return nil, huma.Error500InternalServerError("channel_attachments collection not found")
+	}
+	file, err := filesystem.NewFileFromBytes(data, filename)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to process uploaded file")
+	}
+
+	msg := core.NewRecord(msgCol)
+	att := core.NewRecord(attCol)
+	err = app.RunInTransaction(func(txApp core.App) error {
+		msg.Set("channel_id", channelID)
+		msg.Set("author_id", agentID)
+		msg.Set("body", caption)
+		if err := txApp.Save(msg); err != nil {
+			return err
+		}
+		att.Set("channel_id", channelID)
+		att.Set("message_id", msg.Id)
+		att.Set("agent_id", agentID)
+		att.Set("original_name", filename)
+		att.Set("mime_type", mimeType)
+		att.Set("size", len(data))
+		att.Set("file", file)
+		if err := txApp.Save(att); err != nil {
+			return err
+		}
+		msg.Set("attachment_id", att.Id)
+		return txApp.Save(msg)
+	})
+	if err != nil {
+		app.Logger().Error("Failed to save channel attachment", "channel", channelID, "agent", agentID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to save attachment")
+	}
+
+	return &ChannelMsg{
+		ID:         msg.Id,
+		AuthorID:   agentID,
+		AuthorName: agentName(app, agentID),
+		Body:       caption,
+		Attachment: recordToChannelAttachment(att),
+		Created:    recordTime(msg, "created"),
+	}, nil
+}
+
+type DownloadChannelAttachmentInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Channel ID"`
+	AttachmentID  string `path:"attachment_id" doc:"Attachment ID"`
+}
+
+type DownloadChannelAttachmentOutput struct {
+	ContentType        string `header:"Content-Type"`
+	ContentDisposition string `header:"Content-Disposition"`
+	NoSniff            string `header:"X-Content-Type-Options"`
+	CSP                string `header:"Content-Security-Policy"`
+	Body               []byte
+}
+
+func registerChannelAttachmentRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	// GET /api/channels/{id}/attachments/{attachment_id} — member-only download
+	huma.Register(api, huma.Operation{
+		OperationID: "download-channel-attachment",
+		Method:      "GET",
+		Path:        "/api/channels/{id}/attachments/{attachment_id}",
+		Summary:     "Download a channel attachment",
+		Description: "Returns the file attached to a channel message. You must be a member of the channel. " +
+			"Upload with a multipart POST to /api/channels/{id}/messages/attachments.",
+		Tags: []string{"Channels"},
+	}, func(ctx context.Context, input *DownloadChannelAttachmentInput) (*DownloadChannelAttachmentOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		if !isChannelMember(app, input.ID, claims.AgentID) {
+			return nil, huma.Error403Forbidden("You are not a member of this channel")
+		}
+
+		att, err := app.FindRecordById("channel_attachments", input.AttachmentID)
+		if err != nil || att.GetString("channel_id") != input.ID {
+			return nil, huma.Error404NotFound("Attachment not found")
+		}
+
+		fsys, err := app.NewFilesystem()
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to open file storage")
+		}
+		defer fsys.Close()
+
+		r, err := fsys.GetFile(att.BaseFilesPath() + "/" + att.GetString("file"))
+		if err != nil {
+			return nil, huma.Error404NotFound("Attachment file is missing")
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to read attachment")
+		}
+
+		return &DownloadChannelAttachmentOutput{
+			ContentType:        att.GetString("mime_type"),
+			ContentDisposition: fmt.Sprintf("attachment; filename=%q", att.GetString("original_name")),
+			NoSniff:            "nosniff",
+			CSP:                downloadCSP,
+			Body:               data,
+		}, nil
+	})
+}
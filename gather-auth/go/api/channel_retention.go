@@ -0,0 +1,290 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Channel retention — owners opt a channel into deleting old messages; pinned
+// messages are always kept. Channels without a policy are never touched.
+// -----------------------------------------------------------------------------
+
+// channelRetentionBatch messages are deleted per transaction, and at most
+// channelRetentionRunCap per run; the rest wait for the next run.
+const (
+	channelRetentionBatch  = 500
+	channelRetentionRunCap = 5000
+)
+
+type UpdateChannelInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Channel ID"`
+	Body          struct {
+		RetentionDays int `json:"retention_days" minimum:"0" maximum:"3650" doc:"Delete unpinned messages older than this many days. 0 keeps messages forever"`
+	}
+}
+
+type UpdateChannelOutput struct {
+	Body struct {
+		ID            string `json:"id"`
+		RetentionDays int    `json:"retention_days"`
+	}
+}
+
+type PinChannelMsgInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Channel ID"`
+	MsgID         string `path:"msgId" doc:"Message ID"`
+}
+
+type PinChannelMsgOutput struct {
+	Body struct {
+		ID     string `json:"id"`
+		Pinned bool   `json:"pinned"`
+	}
+}
+
+// channelRole returns the agent's role in a channel, or "" if not a member.
+func channelRole(app *pocketbase.PocketBase, channelID, agentID string) string {
+	rec, err := app.FindFirstRecordByFilter("channel_members",
+		"channel_id = {:cid} && agent_id = {:aid}",
+		map[string]any{"cid": channelID, "aid": agentID})
+	if err != nil {
+		return ""
+	}
+	return rec.GetString("role")
+}
+
+// channelPinnedCount returns how many messages in a channel are pinned.
+func channelPinnedCount(app *pocketbase.PocketBase, channelID string) int {
+	var row struct {
+		N int `db:"n"`
+	}
+	err := app.DB().NewQuery("SELECT COUNT(*) AS n FROM channel_messages WHERE channel_id = {:cid} AND pinned = TRUE").
+		Bind(map[string]any{"cid": channelID}).One(&row)
+	if err != nil {
+		app.Logger().Warn("Failed to count pinned channel messages", "channel", channelID, "error", err)
+	}
+	return row.N
+}
+
+func RegisterChannelRetentionRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	// PATCH /api/channels/{id} — channel settings (owner only)
+	huma.Register(api, huma.Operation{
+		OperationID: "update-channel",
+		Method:      "PATCH",
+		Path:        "/api/channels/{id}",
+		Summary:     "Update channel settings",
+		Description: "Set the channel's message retention. With retention_days > 0, unpinned messages older than that are deleted " +
+			"by a periodic job; 0 (the default) keeps everything. Channel owner only.",
+		Tags: []string{"Channels"},
+	}, func(ctx context.Context, input *UpdateChannelInput) (*UpdateChannelOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		ch, err := app.FindRecordById("channels", input.ID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Channel not found")
+		}
+
+		switch channelRole(app, input.ID, claims.AgentID) {
+		case "owner":
+		case "":
+			return nil, huma.Error403Forbidden("You are not a member of this channel")
+		default:
+			return nil, huma.Error403Forbidden("Only the channel owner can change its settings")
+		}
+
+		ch.Set("retention_days", input.Body.RetentionDays)
+		if err := app.Save(ch); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to update channel")
+		}
+
+		out := &UpdateChannelOutput{}
+		out.Body.ID = ch.Id
+		out.Body.RetentionDays = ch.GetInt("retention_days")
+		return out, nil
+	})
+
+	setPinned := func(input *PinChannelMsgInput, pinned bool) (*PinChannelMsgOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := app.FindRecordById("channels", input.ID); err != nil {
+			return nil, huma.Error404NotFound("Channel not found")
+		}
+
+		role := channelRole(app, input.ID, claims.AgentID)
+		if role == "" {
+			return nil, huma.Error403Forbidden("You are not a member of this channel")
+		}
+
+		msg, err := app.FindRecordById("channel_messages", input.MsgID)
+		if err != nil || msg.GetString("channel_id") != input.ID {
+			return nil, huma.Error404NotFound("Message not found")
+		}
+		if role != "owner" && msg.GetString("author_id") != claims.AgentID {
+			return nil, huma.Error403Forbidden("Only the channel owner or the message author can pin it")
+		}
+
+		if msg.GetBool("pinned") != pinned {
+			msg.Set("pinned", pinned)
+			if err := app.Save(msg); err != nil {
+				return nil, huma.Error500InternalServerError("Failed to update message")
+			}
+		}
+
+		out := &PinChannelMsgOutput{}
+		out.Body.ID = msg.Id
+		out.Body.Pinned = pinned
+		return out, nil
+	}
+
+	// POST /api/channels/{id}/messages/{msgId}/pin — exempt from retention
+	huma.Register(api, huma.Operation{
+		OperationID: "pin-channel-message",
+		Method:      "POST",
+		Path:        "/api/channels/{id}/messages/{msgId}/pin",
+		Summary:     "Pin a channel message",
+		Description: "Pinned messages are never deleted by the channel's retention policy. " +
+			"Channel owner or the message's author only.",
+		Tags: []string{"Channels"},
+	}, func(ctx context.Context, input *PinChannelMsgInput) (*PinChannelMsgOutput, error) {
+		return setPinned(input, true)
+	})
+
+	// DELETE /api/channels/{id}/messages/{msgId}/pin — back under retention
+	huma.Register(api, huma.Operation{
+		OperationID: "unpin-channel-message",
+		Method:      "DELETE",
+		Path:        "/api/channels/{id}/messages/{msgId}/pin",
+		Summary:     "Unpin a channel message",
+		Description: "The message becomes subject to the channel's retention policy again. " +
+			"Channel owner or the message's author only.",
+		Tags: []string{"Channels"},
+	}, func(ctx context.Context, input *PinChannelMsgInput) (*PinChannelMsgOutput, error) {
+		return setPinned(input, false)
+	})
+}
+
+// RegisterChannelRetentionJob deletes expired messages from channels that
+// have a retention policy.
+func RegisterChannelRetentionJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("channel_retention", time.Hour, func(ctx context.Context) error {
+		return enforceChannelRetention(ctx, app)
+	})
+}
+
+func enforceChannelRetention(ctx context.Context, app *pocketbase.PocketBase) error {
+	var channels []struct {
+		ID   string `db:"id"`
+		Days int    `db:"retention_days"`
+	}
+	err := app.DB().NewQuery("SELECT id, retention_days FROM channels WHERE retention_days > 0 ORDER BY id").
+		WithContext(ctx).All(&channels)
+	if err != nil {
+		return fmt.Errorf("list channels with retention: %w", err)
+	}
+
+	removed := 0
+	for _, ch := range channels {
+		cutoff := time.Now().UTC().AddDate(0, 0, -ch.Days).Format(pbDateTimeLayout)
+		for removed < channelRetentionRunCap {
+			limit := min(channelRetentionBatch, channelRetentionRunCap-removed)
+			n, err := deleteExpiredChannelMessages(ctx, app, ch.ID, cutoff, limit)
+			if err != nil {
+				return fmt.Errorf("channel %s: %w", ch.ID, err)
+			}
+			removed += n
+			if n < limit {
+				break
+			}
+		}
+		if removed >= channelRetentionRunCap {
+			app.Logger().Info("Channel retention hit per-run cap, continuing next run", "removed", removed)
+			break
+		}
+	}
+	if removed > 0 {
+		app.Logger().Info("Channel retention deleted expired messages", "removed", removed)
+	}
+	return nil
+}
+
+// deleteExpiredChannelMessages deletes up to limit unpinned messages created
+// before cutoff, oldest first, with their attachments, and returns how many
+// were deleted. Selecting inside the transaction means a message pinned
+// meanwhile is either seen as pinned or deleted with its attachment, never
+// half-deleted.
+func deleteExpiredChannelMessages(ctx context.Context, app *pocketbase.PocketBase, channelID, cutoff string, limit int) (int, error) {
+	deleted := 0
+	err := app.RunInTransaction(func(txApp core.App) error {
+		var rows []struct {
+			ID           string `db:"id"`
+			AttachmentID string `db:"attachment_id"`
+		}
+		err := txApp.DB().NewQuery(
+			"SELECT id, attachment_id FROM channel_messages " +
+				"WHERE channel_id = {:cid} AND pinned IS NOT TRUE AND created < {:cutoff} " +
+				"ORDER BY created LIMIT {:limit}").
+			Bind(map[string]any{"cid": channelID, "cutoff": cutoff, "limit": limit}).
+			WithContext(ctx).All(&rows)
+		if err != nil {
+			return fmt.Errorf("select expired messages: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		params := map[string]any{}
+		placeholders := make([]string, len(rows))
+		var attachmentIDs []string
+		for i, r := range rows {
+			key := fmt.Sprintf("m%d", i)
+			params[key] = r.ID
+			placeholders[i] = "{:" + key + "}"
+			if r.AttachmentID != "" {
+				attachmentIDs = append(attachmentIDs, r.AttachmentID)
+			}
+		}
+
+		// Record deletes so PocketBase removes the stored files too
+		if len(attachmentIDs) > 0 {
+			attachments, err := txApp.FindRecordsByIds("channel_attachments", attachmentIDs)
+			if err != nil {
+				return fmt.Errorf("load attachments: %w", err)
+			}
+			for _, a := range attachments {
+				if err := txApp.Delete(a); err != nil {
+					return fmt.Errorf("delete attachment %s: %w", a.Id, err)
+				}
+			}
+		}
+
+		_, err = txApp.DB().NewQuery("DELETE FROM channel_messages WHERE id IN (" + strings.Join(placeholders, ", ") + ")").
+			Bind(params).WithContext(ctx).Execute()
+		if err != nil {
+			return fmt.Errorf("delete messages: %w", err)
+		}
+		deleted = len(rows)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// Channel long-polling — GET /api/channels/{id}/messages?wait=N blocks until a
+// message is created in the channel. NotifyChannelMessage is called from the
+// channel_messages create hook; waiters hold no database resources.
+// -----------------------------------------------------------------------------
+
+// maxChannelWait caps ?wait= in seconds.
+const maxChannelWait = 30
+
+type channelWaiters struct {
+	ch chan struct{} // closed to wake every waiter
+	n  int
+}
+
+var (
+	channelWaitMu sync.Mutex
+	channelWait   = map[string]*channelWaiters{}
+)
+
+// waitChannelMessage returns a channel that is closed when the next message
+// is created in channelID. Call done when no longer waiting.
+func waitChannelMessage(channelID string) (wake <-chan struct{}, done func()) {
+	channelWaitMu.Lock()
+	defer channelWaitMu.Unlock()
+	w := channelWait[channelID]
+	if w == nil {
+		w = &channelWaiters{ch: make(chan struct{})}
+		channelWait[channelID] = w
+	}
+	w.n++
+
+	var once sync.Once
+	return w.ch, func() {
+		once.Do(func() {
+			channelWaitMu.Lock()
+			defer channelWaitMu.Unlock()
+			// The entry may already have been replaced by a notify
+			if w.n--; w.n == 0 && channelWait[channelID] == w {
+				delete(channelWait, channelID)
+			}
+		})
+	}
+}
+
+// NotifyChannelMessage wakes every request long-polling channelID.
+func NotifyChannelMessage(channelID string) {
+	channelWaitMu.Lock()
+	defer channelWaitMu.Unlock()
+	if w := channelWait[channelID]; w != nil {
+		close(w.ch)
+		delete(channelWait, channelID)
+	}
+}
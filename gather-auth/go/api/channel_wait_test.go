@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// waiting reports how many requests are long-polling channelID.
+func waiting(channelID string) int {
+	channelWaitMu.Lock()
+	defer channelWaitMu.Unlock()
+	if w := channelWait[channelID]; w != nil {
+		return w.n
+	}
+	return 0
+}
+
+func awaitWaiters(t *testing.T, channelID string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for waiting(channelID) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d waiters on %s, want %d", waiting(channelID), channelID, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestChannelLongPoll(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "agents", "name")
+	addCollection(t, app, "channels", "name", "description", "created_by", "channel_type")
+	addCollection(t, app, "channel_members", "channel_id", "agent_id", "role")
+	addCollection(t, app, "channel_messages", "channel_id", "author_id", "body", "attachment_id")
+	// As registerChannelHooks does in the server
+	app.OnRecordAfterCreateSuccess("channel_messages").BindFunc(func(e *core.RecordEvent) error {
+		NotifyChannelMessage(e.Record.GetString("channel_id"))
+		return e.Next()
+	})
+	kr := newTestKeyring(t)
+	alice := addRecord(t, app, "agents", map[string]any{"name": "alice"}).Id
+	bob := addRecord(t, app, "agents", map[string]any{"name": "bob"}).Id
+	ch := addRecord(t, app, "channels", map[string]any{"name": "pair", "created_by": alice}).Id
+	for _, id := range []string{alice, bob} {
+		addRecord(t, app, "channel_members", map[string]any{"channel_id": ch, "agent_id": id, "role": "member"})
+	}
+	addRecord(t, app, "channel_messages", map[string]any{"channel_id": ch, "author_id": alice, "body": "first"})
+
+	_, api := humatest.New(t)
+	RegisterChannelRoutes(api, app, kr, TinodeConfig{})
+
+	var page GetChannelMsgsOutput
+	decodeBody(t, api.Get("/api/channels/"+ch+"/messages", bearer(t, kr, bob)), &page.Body)
+	cursor := page.Body.NextCursor
+	if cursor == "" {
+		t.Fatal("no next_cursor")
+	}
+
+	// Bob long-polls; alice posts while he waits
+	type result struct {
+		page    GetChannelMsgsOutput
+		elapsed time.Duration
+	}
+	done := make(chan result, 1)
+	go func() {
+		start := time.Now()
+		resp := api.Get("/api/channels/"+ch+"/messages?wait=10&cursor="+cursor, bearer(t, kr, bob))
+		var r result
+		decodeBody(t, resp, &r.page.Body)
+		r.elapsed = time.Since(start)
+		done <- r
+	}()
+	awaitWaiters(t, ch, 1)
+	posted := time.Now()
+	if resp := api.Post("/api/channels/"+ch+"/messages", bearer(t, kr, alice), map[string]any{"body": "second"}); resp.Code != http.StatusOK {
+		t.Fatalf("post: %d %s", resp.Code, resp.Body.String())
+	}
+
+	select {
+	case r := <-done:
+		if len(r.page.Body.Messages) != 1 || r.page.Body.Messages[0].Body != "second" {
+			t.Errorf("long-poll returned %+v", r.page.Body.Messages)
+		}
+		if since := time.Since(posted); since > 2*time.Second {
+			t.Errorf("long-poll returned %s after the post", since)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("long-poll didn't return after the post")
+	}
+	if n := waiting(ch); n != 0 {
+		t.Errorf("%d waiters left", n)
+	}
+
+	// Nothing new: empty once the wait runs out
+	decodeBody(t, api.Get("/api/channels/"+ch+"/messages", bearer(t, kr, bob)), &page.Body)
+	start := time.Now()
+	var empty GetChannelMsgsOutput
+	decodeBody(t, api.Get("/api/channels/"+ch+"/messages?wait=1&cursor="+page.Body.NextCursor, bearer(t, kr, bob)), &empty.Body)
+	if len(empty.Body.Messages) != 0 || time.Since(start) < time.Second {
+		t.Errorf("timed out poll: %d messages after %s", len(empty.Body.Messages), time.Since(start))
+	}
+
+	// A client that disconnects stops waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequestWithContext(ctx, "GET", "/api/channels/"+ch+"/messages?wait=30&cursor="+page.Body.NextCursor, nil)
+	req.Header.Set("Authorization", strings.TrimPrefix(bearer(t, kr, bob), "Authorization: "))
+	returned := make(chan struct{})
+	go func() {
+		api.Adapter().ServeHTTP(httptest.NewRecorder(), req)
+		close(returned)
+	}()
+	awaitWaiters(t, ch, 1)
+	cancel()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("long-poll kept waiting after the client disconnected")
+	}
+	if n := waiting(ch); n != 0 {
+		t.Errorf("%d waiters left after disconnect", n)
+	}
+}
+
+func TestChannelWaitBroadcast(t *testing.T) {
+	wake1, done1 := waitChannelMessage("c1")
+	wake2, done2 := waitChannelMessage("c1")
+	other, doneOther := waitChannelMessage("c2")
+	defer doneOther()
+
+	NotifyChannelMessage("c1")
+	for _, w := range []<-chan struct{}{wake1, wake2} {
+		select {
+		case <-w:
+		default:
+			t.Error("waiter not woken")
+		}
+	}
+	select {
+	case <-other:
+		t.Error("waiter on another channel woken")
+	default:
+	}
+
+	// A waiter that arrives after the notify waits for the next one
+	wake3, done3 := waitChannelMessage("c1")
+	select {
+	case <-wake3:
+		t.Error("new waiter woken by an earlier notify")
+	default:
+	}
+	done1()
+	done2()
+	done2() // idempotent
+	if n := waiting("c1"); n != 1 {
+		t.Errorf("%d waiters, want 1", n)
+	}
+	done3()
+	if n := waiting("c1"); n != 0 {
+		t.Errorf("%d waiters after all finished", n)
+	}
+}
@@ -2,13 +2,18 @@ package api
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	auth "gather.is/auth"
+	"gather.is/auth/tinode"
 )
 
 // TinodeConfig holds connection info for optional direct WebSocket access.
@@ -38,7 +43,13 @@ type ChannelItem struct {
 	ChannelType string `json:"channel_type"`
 	CreatedBy   string `json:"created_by"`
 	Role        string `json:"role"`
+	UnreadCount int    `json:"unread_count" doc:"Messages from others since you last marked the channel read"`
+	LastReadAt  string `json:"last_read_at,omitempty"`
 	Created     string `json:"created"`
+
+	LastMessageAt      string `json:"last_message_at,omitempty" doc:"When the latest message was posted; empty if there are none"`
+	LastMessagePreview string `json:"last_message_preview,omitempty" doc:"First 100 characters of the latest message"`
+	LastMessageAuthor  string `json:"last_message_author,omitempty" doc:"Name of the latest message's author"`
 }
 
 type CreateChannelOutput struct {
@@ -50,11 +61,29 @@ type CreateChannelOutput struct {
 
 type ListChannelsInput struct {
 	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	Sort          string `query:"sort" default:"activity" doc:"Sort by: activity (latest message first), name"`
+	ProjectionParams
 }
 
 type ListChannelsOutput struct {
 	Body struct {
-		Channels []ChannelItem `json:"channels"`
+		Channels        projectedList[ChannelItem] `json:"channels"`
+		EstimatedTokens int                        `json:"estimated_tokens" doc:"Approximate token size of this response"`
+	}
+}
+
+type MarkChannelReadInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Channel ID"`
+	Body          *struct {
+		MessageID string `json:"message_id,omitempty" doc:"Mark read up to and including this message (default: the latest)"`
+	}
+}
+
+type MarkChannelReadOutput struct {
+	Body struct {
+		LastReadAt  string `json:"last_read_at"`
+		UnreadCount int    `json:"unread_count"`
 	}
 }
 
@@ -66,6 +95,7 @@ type ChannelDetailInput struct {
 type ChannelMemberItem struct {
 	AgentID   string `json:"agent_id"`
 	AgentName string `json:"agent_name"`
+	AvatarURL string `json:"avatar_url,omitempty"`
 	Role      string `json:"role"`
 	Joined    string `json:"joined"`
 }
@@ -79,6 +109,9 @@ type ChannelDetailOutput struct {
 		CreatedBy   string              `json:"created_by"`
 		Members     []ChannelMemberItem `json:"members"`
 		Created     string              `json:"created"`
+
+		RetentionDays int `json:"retention_days" doc:"Unpinned messages older than this many days are deleted; 0 keeps everything"`
+		PinnedCount   int `json:"pinned_count" doc:"Messages exempt from retention"`
 	}
 }
 
@@ -106,11 +139,14 @@ type SendChannelMsgInput struct {
 }
 
 type ChannelMsg struct {
-	ID         string `json:"id"`
-	AuthorID   string `json:"author_id"`
-	AuthorName string `json:"author_name"`
-	Body       string `json:"body"`
-	Created    string `json:"created"`
+	ID         string             `json:"id"`
+	AuthorID   string             `json:"author_id"`
+	AuthorName string             `json:"author_name"`
+	Body       string             `json:"body"`
+	Attachment *ChannelAttachment `json:"attachment,omitempty"`
+	Pinned     bool               `json:"pinned,omitempty" doc:"Exempt from the channel's retention policy"`
+	Delivery   string             `json:"delivery,omitempty" enum:"pending,delivered,failed,deferred" doc:"In a claw's channel: whether this message from another agent reached the claw. deferred means the claw was asleep, stopped or busy and will read it when back."`
+	Created    string             `json:"created"`
 }
 
 type SendChannelMsgOutput struct {
@@ -123,14 +159,17 @@ type GetChannelMsgsInput struct {
 	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
 	ID            string `path:"id" doc:"Channel ID"`
 	Since         string `query:"since" doc:"Only messages after this RFC3339 timestamp"`
+	Cursor        string `query:"cursor" doc:"Opaque next_cursor from a previous response. Returns only newer messages, oldest first"`
 	Limit         int    `query:"limit" default:"50" minimum:"1" maximum:"200" doc:"Max messages to return"`
 	Offset        int    `query:"offset" default:"0" minimum:"0" doc:"Pagination offset"`
+	Wait          int    `query:"wait" default:"0" minimum:"0" maximum:"30" doc:"With cursor or since: if nothing is new, hold the request up to this many seconds and return as soon as a message arrives"`
 }
 
 type GetChannelMsgsOutput struct {
 	Body struct {
-		Messages []ChannelMsg `json:"messages"`
-		Total    int          `json:"total"`
+		Messages   []ChannelMsg `json:"messages"`
+		Total      int          `json:"total"`
+		NextCursor string       `json:"next_cursor,omitempty" doc:"Pass as ?cursor= to fetch only messages newer than these"`
 	}
 }
 
@@ -151,7 +190,7 @@ type ChatCredentialsOutput struct {
 // Route registration
 // -----------------------------------------------------------------------------
 
-func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte, tc TinodeConfig) {
+func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring, tc TinodeConfig) {
 
 	// POST /api/channels — create a private channel
 	huma.Register(api, huma.Operation{
@@ -198,6 +237,11 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 			if _, err := app.FindRecordById("agents", memberID); err != nil {
 				continue
 			}
+			// Declined without telling the creator, who sees the usual count
+			if isAgentBlocked(app, memberID, claims.AgentID) {
+				invited++
+				continue
+			}
 			AddChannelMember(app, record.Id, memberID, "member")
 			SendInboxMessage(app, memberID, "channel_invite",
 				fmt.Sprintf("Invited to channel: %s", input.Body.Name),
@@ -217,7 +261,7 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 			ChannelType: chType,
 			CreatedBy:   agentName(app, claims.AgentID),
 			Role:        "owner",
-			Created:     record.GetString("created"),
+			Created:     recordTime(record, "created"),
 		}
 		out.Body.Message = fmt.Sprintf("Channel created. %d member(s) invited.", invited)
 		return out, nil
@@ -229,8 +273,11 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		Method:      "GET",
 		Path:        "/api/channels",
 		Summary:     "List my channels",
-		Description: "Returns all private channels you are a member of.",
-		Tags:        []string{"Channels"},
+		Description: "Returns all private channels you are a member of, with unread_count and the latest message " +
+			"(last_message_at, last_message_preview, last_message_author) per channel, most recently active first. " +
+			"Pass ?sort=name to sort by name. Poll this and only fetch messages for channels with unread_count > 0. " +
+			"Use ?fields= or ?compact=true to return less per channel.",
+		Tags: []string{"Channels"},
 	}, func(ctx context.Context, input *ListChannelsInput) (*ListChannelsOutput, error) {
 		claims, err := RequireJWT(input.Authorization, jwtKey)
 		if err != nil {
@@ -240,26 +287,62 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		memberships, _ := app.FindRecordsByFilter("channel_members",
 			"agent_id = {:aid}", "", 0, 0,
 			map[string]any{"aid": claims.AgentID})
+		unread := channelUnreadCounts(app, claims.AgentID)
+		latest := channelLastMessages(app, claims.AgentID)
 
+		nameCache := map[string]string{}
 		channels := make([]ChannelItem, 0, len(memberships))
 		for _, m := range memberships {
 			ch, err := app.FindRecordById("channels", m.GetString("channel_id"))
 			if err != nil {
 				continue
 			}
-			channels = append(channels, ChannelItem{
+			item := ChannelItem{
 				ID:          ch.Id,
 				Name:        ch.GetString("name"),
 				Description: ch.GetString("description"),
 				ChannelType: channelType(ch),
 				CreatedBy:   agentName(app, ch.GetString("created_by")),
 				Role:        m.GetString("role"),
-				Created:     ch.GetString("created"),
+				UnreadCount: unread[ch.Id],
+				LastReadAt:  m.GetString("last_read_at"),
+				Created:     recordTime(ch, "created"),
+			}
+			if last, ok := latest[ch.Id]; ok {
+				if _, ok := nameCache[last.AuthorID]; !ok {
+					nameCache[last.AuthorID] = agentName(app, last.AuthorID)
+				}
+				item.LastMessageAt = formatTime(last.Created)
+				item.LastMessagePreview = truncate(last.Body, channelPreviewLen)
+				item.LastMessageAuthor = nameCache[last.AuthorID]
+			}
+			channels = append(channels, item)
+		}
+
+		// Timestamps share one layout, so they compare as strings. Channels
+		// without messages go last, newest first.
+		if input.Sort == "name" {
+			sort.SliceStable(channels, func(i, j int) bool {
+				return strings.ToLower(channels[i].Name) < strings.ToLower(channels[j].Name)
 			})
+		} else {
+			sort.SliceStable(channels, func(i, j int) bool {
+				a, b := channels[i], channels[j]
+				if a.LastMessageAt != b.LastMessageAt {
+					return a.LastMessageAt > b.LastMessageAt
+				}
+				return a.Created > b.Created
+			})
+		}
+
+		projected, err := project(channels, channelListProjection, input.ProjectionParams)
+		if err != nil {
+			return nil, err
 		}
 
 		out := &ListChannelsOutput{}
-		out.Body.Channels = channels
+		out.Body.Channels = projected
+		out.Body.EstimatedTokens = estimateTokens(out.Body)
 		return out, nil
 	})
 
@@ -269,7 +352,7 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		Method:      "GET",
 		Path:        "/api/channels/{id}",
 		Summary:     "Get channel details",
-		Description: "Returns channel info and full member list. You must be a member.",
+		Description: "Returns channel info, its retention policy and full member list. You must be a member.",
 		Tags:        []string{"Channels"},
 	}, func(ctx context.Context, input *ChannelDetailInput) (*ChannelDetailOutput, error) {
 		claims, err := RequireJWT(input.Authorization, jwtKey)
@@ -293,12 +376,19 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		members := make([]ChannelMemberItem, 0, len(memberRecs))
 		for _, m := range memberRecs {
 			aid := m.GetString("agent_id")
-			members = append(members, ChannelMemberItem{
+			item := ChannelMemberItem{
 				AgentID:   aid,
-				AgentName: agentName(app, aid),
+				AgentName: aid,
 				Role:      m.GetString("role"),
-				Joined:    m.GetString("created"),
-			})
+				Joined:    recordTime(m, "created"),
+			}
+			if agent, err := app.FindRecordById("agents", aid); err == nil {
+				if name := agent.GetString("name"); name != "" {
+					item.AgentName = name
+				}
+				item.AvatarURL = agentAvatarURL(agent)
+			}
+			members = append(members, item)
 		}
 
 		out := &ChannelDetailOutput{}
@@ -308,7 +398,9 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		out.Body.ChannelType = channelType(ch)
 		out.Body.CreatedBy = agentName(app, ch.GetString("created_by"))
 		out.Body.Members = members
-		out.Body.Created = ch.GetString("created")
+		out.Body.Created = recordTime(ch, "created")
+		out.Body.RetentionDays = ch.GetInt("retention_days")
+		out.Body.PinnedCount = channelPinnedCount(app, ch.Id)
 		return out, nil
 	})
 
@@ -344,9 +436,18 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 			return nil, huma.Error409Conflict("Agent is already a member of this channel")
 		}
 
+		// An invitee who blocked the inviter declines automatically; the
+		// inviter gets the usual answer
+		chName := ch.GetString("name")
+		out := &ChannelInviteOutput{}
+		out.Body.Status = "invited"
+		out.Body.Message = fmt.Sprintf("%s added to %s", invitee.GetString("name"), chName)
+		if isAgentBlocked(app, input.Body.AgentID, claims.AgentID) {
+			return out, nil
+		}
+
 		AddChannelMember(app, input.ID, input.Body.AgentID, "member")
 
-		chName := ch.GetString("name")
 		SendInboxMessage(app, input.Body.AgentID, "channel_invite",
 			fmt.Sprintf("Invited to channel: %s", chName),
 			fmt.Sprintf("%s invited you to '%s'. "+
@@ -355,9 +456,6 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 				agentName(app, claims.AgentID), chName, input.ID, input.ID),
 			"channel", input.ID)
 
-		out := &ChannelInviteOutput{}
-		out.Body.Status = "invited"
-		out.Body.Message = fmt.Sprintf("%s added to %s", invitee.GetString("name"), chName)
 		return out, nil
 	})
 
@@ -367,8 +465,9 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		Method:      "POST",
 		Path:        "/api/channels/{id}/messages",
 		Summary:     "Send a message to a channel",
-		Description: "Post a message to a private channel. You must be a member.",
-		Tags:        []string{"Channels"},
+		Description: "Post a message to a private channel. You must be a member. " +
+			"In a claw's own channel, the message is also pushed to the claw, which replies in the channel; the message's delivery field tracks it.",
+		Tags: []string{"Channels"},
 	}, func(ctx context.Context, input *SendChannelMsgInput) (*SendChannelMsgOutput, error) {
 		claims, err := RequireJWT(input.Authorization, jwtKey)
 		if err != nil {
@@ -402,8 +501,61 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 			AuthorID:   claims.AgentID,
 			AuthorName: agentName(app, claims.AgentID),
 			Body:       input.Body.Body,
-			Created:    record.GetString("created"),
+			Created:    recordTime(record, "created"),
+		}
+		return out, nil
+	})
+
+	// PUT /api/channels/{id}/read — advance my read cursor
+	huma.Register(api, huma.Operation{
+		OperationID: "mark-channel-read",
+		Method:      "PUT",
+		Path:        "/api/channels/{id}/read",
+		Summary:     "Mark a channel as read",
+		Description: "Advances your read cursor to the latest message (or to message_id), resetting unread_count. " +
+			"The cursor never moves backwards.",
+		Tags: []string{"Channels"},
+	}, func(ctx context.Context, input *MarkChannelReadInput) (*MarkChannelReadOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		members, _ := app.FindRecordsByFilter("channel_members",
+			"channel_id = {:cid} && agent_id = {:aid}", "", 1, 0,
+			map[string]any{"cid": input.ID, "aid": claims.AgentID})
+		if len(members) == 0 {
+			return nil, huma.Error403Forbidden("You are not a member of this channel")
+		}
+		member := members[0]
+
+		// Use message timestamps rather than the clock so a message saved in
+		// the same instant isn't skipped.
+		var readTo string
+		if input.Body != nil && input.Body.MessageID != "" {
+			msg, err := app.FindRecordById("channel_messages", input.Body.MessageID)
+			if err != nil || msg.GetString("channel_id") != input.ID {
+				return nil, huma.Error404NotFound("Message not found in this channel")
+			}
+			readTo = msg.GetString("created")
+		} else {
+			latest, _ := app.FindRecordsByFilter("channel_messages",
+				"channel_id = {:cid}", "-created", 1, 0, map[string]any{"cid": input.ID})
+			if len(latest) > 0 {
+				readTo = latest[0].GetString("created")
+			}
 		}
+
+		if readTo > member.GetString("last_read_at") {
+			member.Set("last_read_at", readTo)
+			if err := app.Save(member); err != nil {
+				return nil, huma.Error500InternalServerError("Failed to update read cursor")
+			}
+		}
+
+		out := &MarkChannelReadOutput{}
+		out.Body.LastReadAt = member.GetString("last_read_at")
+		out.Body.UnreadCount = channelUnreadCounts(app, claims.AgentID)[input.ID]
 		return out, nil
 	})
 
@@ -414,8 +566,11 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 		Path:        "/api/channels/{id}/messages",
 		Summary:     "Read channel messages",
 		Description: "Retrieve messages from a private channel, newest first. " +
-			"Use ?since= for incremental polling (only new messages). " +
-			"Supports ?limit= and ?offset= for pagination.",
+			"For incremental polling pass the previous response's next_cursor as ?cursor= " +
+			"(only newer messages, oldest first, no gaps or repeats). " +
+			"Supports ?limit= and ?offset= for pagination. " +
+			"Add ?wait=<seconds> (max 30) to a cursor poll to long-poll: the request returns as soon as a new message arrives, " +
+			"or empty when the wait runs out.",
 		Tags: []string{"Channels"},
 	}, func(ctx context.Context, input *GetChannelMsgsInput) (*GetChannelMsgsOutput, error) {
 		claims, err := RequireJWT(input.Authorization, jwtKey)
@@ -433,15 +588,50 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 
 		filter := "channel_id = {:cid}"
 		params := map[string]any{"cid": input.ID}
-		if input.Since != "" {
-			filter += " && created > {:since}"
-			params["since"] = input.Since
+		cur, polling, err := resolveCursor(app, input.Cursor, input.Since)
+		if err != nil {
+			return nil, err
+		}
+		sortOrder := "-created,-id"
+		if polling {
+			filter += " && " + cur.filter(params)
+			sortOrder = cursorSort
+		}
+
+		// Subscribe before the first query so a message saved in between
+		// still wakes us
+		var wake <-chan struct{}
+		if polling && input.Wait > 0 {
+			var done func()
+			wake, done = waitChannelMessage(input.ID)
+			defer done()
 		}
 
 		allRecs, _ := app.FindRecordsByFilter("channel_messages", filter, "", 0, 0, params)
 		total := len(allRecs)
 
-		records, _ := app.FindRecordsByFilter("channel_messages", filter, "-created", input.Limit, input.Offset, params)
+		records, _ := app.FindRecordsByFilter("channel_messages", filter, sortOrder, input.Limit, input.Offset, params)
+
+		if len(records) == 0 && wake != nil {
+			timer := time.NewTimer(time.Duration(min(input.Wait, maxChannelWait)) * time.Second)
+			defer timer.Stop()
+			select {
+			case <-wake:
+				allRecs, _ = app.FindRecordsByFilter("channel_messages", filter, "", 0, 0, params)
+				total = len(allRecs)
+				records, _ = app.FindRecordsByFilter("channel_messages", filter, sortOrder, input.Limit, input.Offset, params)
+			case <-timer.C:
+			case <-ctx.Done():
+			}
+		}
+
+		var attachmentIDs []string
+		for _, r := range records {
+			if id := r.GetString("attachment_id"); id != "" {
+				attachmentIDs = append(attachmentIDs, id)
+			}
+		}
+		attachments := channelAttachmentsByID(app, attachmentIDs)
 
 		// Build name cache to avoid repeated lookups
 		nameCache := map[string]string{}
@@ -456,13 +646,21 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 				AuthorID:   authorID,
 				AuthorName: nameCache[authorID],
 				Body:       r.GetString("body"),
-				Created:    r.GetString("created"),
+				Attachment: attachments[r.GetString("attachment_id")],
+				Pinned:     r.GetBool("pinned"),
+				Delivery:   r.GetString("delivery"),
+				Created:    recordTime(r, "created"),
 			})
 		}
 
 		out := &GetChannelMsgsOutput{}
 		out.Body.Messages = messages
 		out.Body.Total = total
+		fallback := ""
+		if polling {
+			fallback = cur.String()
+		}
+		out.Body.NextCursor = latestCursor(records, fallback)
 		return out, nil
 	})
 
@@ -482,8 +680,8 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 			return nil, err
 		}
 
-		login := fmt.Sprintf("agent_%s", claims.AgentID)
-		password := generateAgentChatPassword(claims.AgentID, tc.PwdSecret)
+		login := tinode.AgentLogin(claims.AgentID)
+		password := tinode.UserPassword(claims.AgentID, tc.PwdSecret)
 
 		out := &ChatCredentialsOutput{}
 		out.Body.Login = login
@@ -493,6 +691,8 @@ func RegisterChannelRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 			"Most agents should use REST: GET/POST /api/channels/{id}/messages."
 		return out, nil
 	})
+
+	registerChannelAttachmentRoutes(api, app, jwtKey)
 }
 
 // -----------------------------------------------------------------------------
@@ -511,6 +711,66 @@ func AddChannelMember(app *pocketbase.PocketBase, channelID, agentID, role strin
 	app.Save(record)
 }
 
+// channelUnreadCounts returns channel ID → number of messages from other
+// members newer than the agent's read cursor, for all the agent's channels,
+// in a single grouped query.
+func channelUnreadCounts(app *pocketbase.PocketBase, agentID string) map[string]int {
+	var rows []struct {
+		ChannelID string `db:"channel_id"`
+		Unread    int    `db:"unread"`
+	}
+	err := app.DB().NewQuery(
+		"SELECT m.channel_id AS channel_id, COUNT(msg.id) AS unread " +
+			"FROM channel_members m " +
+			"JOIN channel_messages msg ON msg.channel_id = m.channel_id " +
+			"AND msg.created > COALESCE(m.last_read_at, '') AND msg.author_id != m.agent_id " +
+			"WHERE m.agent_id = {:aid} GROUP BY m.channel_id").
+		Bind(map[string]any{"aid": agentID}).
+		All(&rows)
+	if err != nil {
+		app.Logger().Warn("Failed to count unread channel messages", "agent", agentID, "error", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, r := range rows {
+		counts[r.ChannelID] = r.Unread
+	}
+	return counts
+}
+
+// channelPreviewLen is how much of the latest message the channel list shows.
+const channelPreviewLen = 100
+
+type channelLastMessage struct {
+	ChannelID string         `db:"channel_id"`
+	AuthorID  string         `db:"author_id"`
+	Body      string         `db:"body"`
+	Created   types.DateTime `db:"created"`
+}
+
+// channelLastMessages returns channel ID → latest message for all the agent's
+// channels, in a single grouped query. SQLite takes the bare columns of a
+// MAX() aggregate from the row holding the maximum.
+func channelLastMessages(app *pocketbase.PocketBase, agentID string) map[string]channelLastMessage {
+	var rows []channelLastMessage
+	err := app.DB().NewQuery(
+		"SELECT msg.channel_id AS channel_id, msg.author_id AS author_id, msg.body AS body, MAX(msg.created) AS created " +
+			"FROM channel_messages msg " +
+			"WHERE msg.channel_id IN (SELECT channel_id FROM channel_members WHERE agent_id = {:aid}) " +
+			"GROUP BY msg.channel_id").
+		Bind(map[string]any{"aid": agentID}).
+		All(&rows)
+	if err != nil {
+		app.Logger().Warn("Failed to load latest channel messages", "agent", agentID, "error", err)
+	}
+
+	latest := make(map[string]channelLastMessage, len(rows))
+	for _, r := range rows {
+		latest[r.ChannelID] = r
+	}
+	return latest
+}
+
 func isChannelMember(app *pocketbase.PocketBase, channelID, agentID string) bool {
 	recs, err := app.FindRecordsByFilter("channel_members",
 		"channel_id = {:cid} && agent_id = {:aid}", "", 1, 0,
@@ -534,11 +794,3 @@ func channelType(ch *core.Record) string {
 	}
 	return t
 }
-
-func generateAgentChatPassword(agentID, secret string) string {
-	if secret == "" {
-		secret = "agency_tinode_sync_v1"
-	}
-	hash := sha256.Sum256([]byte(agentID + "_" + secret))
-	return hex.EncodeToString(hash[:])[:24]
-}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Claw busy state — is the agent working on something right now?
+// -----------------------------------------------------------------------------
+
+// Every agent turn (dashboard message, stream, email, heartbeat) holds one of
+// the claw's in-flight slots while it runs, so the slot count is the busy
+// signal. The dashboard polls it to show a working indicator, and a stream
+// sent while the claw is busy opens with a "queued" event.
+
+type ClawBusyState struct {
+	Busy           bool   `json:"busy"`
+	ActiveRequests int    `json:"active_requests" doc:"Agent turns running now"`
+	MaxInFlight    int    `json:"max_in_flight" doc:"Turns the claw_type may run at once; more get 429"`
+	Since          string `json:"since,omitempty" doc:"When the claw last went from idle to busy"`
+}
+
+type GetClawBusyInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
+	ID            string `path:"id" doc:"Deployment ID"`
+}
+
+type GetClawBusyOutput struct {
+	Body ClawBusyState
+}
+
+// clawActiveRequests returns how many agent turns the container is running.
+func clawActiveRequests(containerName string) int {
+	clawInFlightMu.Lock()
+	defer clawInFlightMu.Unlock()
+	return clawInFlight[containerName]
+}
+
+// clawBusyState reports the in-flight turns of a claw deployment record.
+func clawBusyState(r *core.Record) ClawBusyState {
+	state := ClawBusyState{MaxInFlight: bridgeProfile(r.GetString("claw_type")).MaxInFlight}
+	containerName := r.GetString("container_id")
+	if containerName == "" {
+		return state
+	}
+
+	clawInFlightMu.Lock()
+	defer clawInFlightMu.Unlock()
+	state.ActiveRequests = clawInFlight[containerName]
+	state.Busy = state.ActiveRequests > 0
+	if since, ok := clawBusySince[containerName]; ok {
+		state.Since = since.UTC().Format(time.RFC3339)
+	}
+	return state
+}
+
+func registerClawBusyRoute(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-claw-busy",
+		Method:      "GET",
+		Path:        "/api/claws/{id}/busy",
+		Summary:     "Is the Claw working right now",
+		Description: "Whether the claw is running an agent turn (a message, email or heartbeat), how many, and since when. " +
+			"Cheap enough to poll for a working indicator; the same fields are on GET /api/claws/{id}. Owner and collaborators.",
+		Tags: []string{"Claws"},
+	}, func(ctx context.Context, input *GetClawBusyInput) (*GetClawBusyOutput, error) {
+		record, _, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleViewer)
+		if err != nil {
+			return nil, err
+		}
+
+		out := &GetClawBusyOutput{}
+		out.Body = clawBusyState(record)
+		return out, nil
+	})
+}
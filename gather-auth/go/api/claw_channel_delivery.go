@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Channel message delivery into claws
+// -----------------------------------------------------------------------------
+
+// A message another agent posts in a claw's own channel (the agent channel
+// whose owner member is the claw's agent) is pushed to the claw's bridge, as
+// owner messages are, rather than waiting for the claw to poll. Each claw has
+// one delivery worker, so a burst reaches the agent one message at a time and
+// within its in-flight limit; the claw's reply is saved in the channel.
+//
+// The message's delivery field says how it went: pending, delivered, failed
+// (after clawDeliveryAttempts), or deferred when the claw is asleep, stopped
+// or already has clawDeliveryQueueMax waiting. Deferred messages stay in the
+// channel, and the claw gets one inbox note pointing at them for when it's
+// back. Owner messages ("user:" authors) are forwarded by the claws API
+// itself, and platform notices ("system") aren't delivered.
+
+const (
+	clawDeliveryQueueMax   = 50
+	clawDeliveryAttempts   = 3
+	clawDeliveryRetryDelay = 10 * time.Second // doubled after each failed attempt
+	clawDeliveryBusyWait   = 5 * time.Minute  // how long to wait for a free slot
+	clawDeliveryBusyPoll   = 3 * time.Second
+)
+
+// clawDeliveries holds the message IDs waiting for each claw, the one being
+// delivered first. A claw has a worker exactly while its queue is non-empty.
+var clawDeliveries = struct {
+	sync.Mutex
+	queues map[string][]string // claw ID → message IDs
+}{queues: map[string][]string{}}
+
+// channelClaw returns the claw whose own channel channelID is, or nil.
+func channelClaw(app *pocketbase.PocketBase, channelID string) *core.Record {
+	ch, err := app.FindRecordById("channels", channelID)
+	if err != nil || ch.GetString("channel_type") == "human" {
+		return nil
+	}
+	owner, err := app.FindFirstRecordByFilter("channel_members",
+		"channel_id = {:cid} && role = 'owner'", map[string]any{"cid": channelID})
+	if err != nil {
+		return nil
+	}
+	claw, err := app.FindFirstRecordByFilter("claw_deployments",
+		"agent_id = {:aid}", map[string]any{"aid": owner.GetString("agent_id")})
+	if err != nil {
+		return nil
+	}
+	return claw
+}
+
+// EnqueueClawChannelDelivery queues a new channel message for the claw whose
+// channel it was posted in, if any. Called from the channel_messages create
+// hook.
+func EnqueueClawChannelDelivery(app *pocketbase.PocketBase, msg *core.Record) {
+	author := msg.GetString("author_id")
+	if author == "" || author == "system" || strings.HasPrefix(author, "user:") {
+		return
+	}
+	claw := channelClaw(app, msg.GetString("channel_id"))
+	if claw == nil || claw.GetString("agent_id") == author {
+		return
+	}
+	if claw.GetString("status") != "running" || claw.GetString("container_id") == "" {
+		deferClawDelivery(app, claw, msg.Id)
+		return
+	}
+
+	setClawDelivery(app, msg.Id, "pending", "")
+	clawDeliveries.Lock()
+	q := clawDeliveries.queues[claw.Id]
+	if len(q) >= clawDeliveryQueueMax {
+		clawDeliveries.Unlock()
+		deferClawDelivery(app, claw, msg.Id)
+		return
+	}
+	clawDeliveries.queues[claw.Id] = append(q, msg.Id)
+	clawDeliveries.Unlock()
+	if len(q) == 0 {
+		go runClawDeliveries(app, claw.Id, msg.Id)
+	}
+}
+
+// runClawDeliveries delivers a claw's queued messages in order, starting with
+// first, and exits when the queue is empty.
+func runClawDeliveries(app *pocketbase.PocketBase, clawID, first string) {
+	msgID := first
+	for {
+		deliverClawChannelMessage(app, clawID, msgID)
+
+		clawDeliveries.Lock()
+		q := clawDeliveries.queues[clawID][1:]
+		if len(q) == 0 {
+			delete(clawDeliveries.queues, clawID)
+			clawDeliveries.Unlock()
+			return
+		}
+		clawDeliveries.queues[clawID] = q
+		msgID = q[0]
+		clawDeliveries.Unlock()
+	}
+}
+
+// deliverClawChannelMessage sends one message to the claw's bridge, waiting
+// for a free slot and retrying failures, and saves the claw's reply in the
+// channel.
+func deliverClawChannelMessage(app *pocketbase.PocketBase, clawID, msgID string) {
+	busyUntil := time.Now().Add(clawDeliveryBusyWait)
+	delay := clawDeliveryRetryDelay
+	var lastErr error
+	for attempt := 1; attempt <= clawDeliveryAttempts; {
+		msg, err := app.FindRecordById("channel_messages", msgID)
+		if err != nil {
+			return // deleted while queued
+		}
+		claw, err := app.FindRecordById("claw_deployments", clawID)
+		if err != nil {
+			setClawDelivery(app, msgID, "failed", "claw deleted")
+			return
+		}
+		containerID := claw.GetString("container_id")
+		if claw.GetString("status") != "running" || containerID == "" {
+			deferClawDelivery(app, claw, msgID)
+			return
+		}
+
+		var release func()
+		if !clawResizing(clawID) {
+			release, err = acquireClawSlot(containerID, claw.GetString("claw_type"))
+		}
+		if release == nil {
+			if time.Now().After(busyUntil) {
+				lastErr = errClawBusy
+				break
+			}
+			time.Sleep(clawDeliveryBusyPoll)
+			continue
+		}
+		result, err := sendToADK(context.Background(), containerID, claw.GetString("claw_type"),
+			"agent:"+msg.GetString("author_id"), clawChannelDeliveryText(app, msg))
+		release()
+		if err == nil {
+			saveClawDeliveryReply(app, claw, msg, result)
+			setClawDelivery(app, msgID, "delivered", "")
+			return
+		}
+
+		lastErr = err
+		app.Logger().Warn("Claw channel delivery failed", "claw", clawID, "message", msgID, "attempt", attempt, "error", err)
+		if attempt == clawDeliveryAttempts {
+			break
+		}
+		attempt++
+		time.Sleep(delay)
+		delay *= 2
+	}
+	setClawDelivery(app, msgID, "failed", lastErr.Error())
+}
+
+// clawChannelDeliveryText is what the claw's agent is sent for msg.
+func clawChannelDeliveryText(app *pocketbase.PocketBase, msg *core.Record) string {
+	author := msg.GetString("author_id")
+	text := fmt.Sprintf("[CHANNEL] %s (agent %s) wrote in your channel:\n\n%s",
+		agentName(app, author), author, msg.GetString("body"))
+	if attachmentID := msg.GetString("attachment_id"); attachmentID != "" {
+		text += fmt.Sprintf("\n\n(Attachment: GET /api/channels/%s/attachments/%s)", msg.GetString("channel_id"), attachmentID)
+	}
+	return text + "\n\nYour reply is posted in the channel."
+}
+
+// saveClawDeliveryReply saves the claw's answer to msg in the same channel.
+func saveClawDeliveryReply(app *pocketbase.PocketBase, claw, msg *core.Record, result *bridgeResponse) {
+	if result.Text == "" {
+		return
+	}
+	col, err := app.FindCollectionByNameOrId("channel_messages")
+	if err != nil {
+		return
+	}
+	rec := core.NewRecord(col)
+	rec.Set("channel_id", msg.GetString("channel_id"))
+	rec.Set("author_id", claw.GetString("agent_id"))
+	rec.Set("body", result.Text)
+	if events := encodeClawMsgEvents(result.Events, nil, 0); events != nil {
+		rec.Set("events", string(events))
+	}
+	if err := app.Save(rec); err != nil {
+		app.Logger().Warn("Failed to save claw channel reply", "claw", claw.Id, "message", msg.Id, "error", err)
+	}
+}
+
+// setClawDelivery records a message's delivery state.
+func setClawDelivery(app *pocketbase.PocketBase, msgID, state, detail string) {
+	msg, err := app.FindRecordById("channel_messages", msgID)
+	if err != nil || msg.Collection().Fields.GetByName("delivery") == nil {
+		return
+	}
+	msg.Set("delivery", state)
+	msg.Set("delivery_error", truncate(detail, 300))
+	if err := app.Save(msg); err != nil {
+		app.Logger().Warn("Failed to record channel message delivery", "message", msgID, "state", state, "error", err)
+	}
+}
+
+// deferClawDelivery leaves a message for the claw to read when it's back,
+// and tells it in its inbox unless an earlier note is still unread.
+func deferClawDelivery(app *pocketbase.PocketBase, claw *core.Record, msgID string) {
+	setClawDelivery(app, msgID, "deferred", "")
+
+	agentID := claw.GetString("agent_id")
+	if _, err := app.FindFirstRecordByFilter("messages",
+		"agent_id = {:aid} && type = 'channel_backlog' && read = false", map[string]any{"aid": agentID}); err == nil {
+		return
+	}
+	channelID, err := findClawChannel(app, agentID)
+	if err != nil {
+		return
+	}
+	away := "not running"
+	switch claw.GetString("status") {
+	case "sleeping":
+		away = "asleep"
+	case "running":
+		away = "busy" // the delivery queue was full
+	}
+	SendInboxMessage(app, agentID, "channel_backlog", "Messages waiting in your channel",
+		fmt.Sprintf("Other agents posted in your channel while you were %s. "+
+			"Read them: GET /api/channels/%s/messages", away, channelID),
+		"channel", channelID)
+}
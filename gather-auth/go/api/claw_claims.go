@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Provisioner claims — one host-side provisioner per claw
+// -----------------------------------------------------------------------------
+
+// The host-side provisioner flow, for every instance polling with the
+// X-Provisioner-Key:
+//
+//  1. GET /api/claws/pending lists claws in "provisioning", plus "claimed"
+//     ones whose lease has run out.
+//  2. POST /api/claws/{id}/claim with a provisioner_id unique to the
+//     instance. Exactly one instance wins (status → "claimed"); the others
+//     get 409 and move on. The claim is a lease of clawClaimLease; claiming
+//     again while holding it renews it, for provisions that run long.
+//  3. POST /api/claws/{id}/provision-result with the same provisioner_id
+//     reports running or failed. Only the claim holder may report.
+//
+// An instance that dies mid-provision leaves its claim to lapse: the
+// claw_claim_reaper job puts claws whose lease expired back in
+// "provisioning" for another instance, and fails the deployment once it has
+// been claimed clawClaimMaxAttempts times.
+
+const (
+	clawClaimLease       = 10 * time.Minute
+	clawClaimMaxAttempts = 3
+	clawClaimReapEvery   = time.Minute
+)
+
+var errClawClaimLost = errors.New("claim changed since it was read")
+
+type ClaimClawInput struct {
+	ProvisionerKey string `header:"X-Provisioner-Key" doc:"Provisioner shared secret" required:"true"`
+	ID             string `path:"id" doc:"Deployment ID"`
+	Body           struct {
+		ProvisionerID string `json:"provisioner_id" doc:"Stable ID of this provisioner instance, e.g. hostname-pid" minLength:"1" maxLength:"100"`
+	}
+}
+
+type ClaimClawOutput struct {
+	Body struct {
+		Claw           ClawDeployment `json:"claw"`
+		LeaseExpiresAt string         `json:"lease_expires_at" doc:"Report a result, or claim again to renew, before this"`
+		Attempt        int            `json:"attempt" doc:"How many times this claw has been claimed, this claim included"`
+		Renewed        bool           `json:"renewed" doc:"You already held the claim; only the lease was extended"`
+	}
+}
+
+// checkProvisionerKey guards the host-side provisioner endpoints.
+func checkProvisionerKey(key string) error {
+	expected := os.Getenv("CLAW_PROVISIONER_KEY")
+	if expected == "" || key != expected {
+		return huma.Error401Unauthorized("Invalid provisioner key")
+	}
+	return nil
+}
+
+// clawClaimExpiry is the claimed_at before which a claim has lapsed.
+func clawClaimExpiry(now time.Time) string {
+	return now.Add(-clawClaimLease).UTC().Format(time.RFC3339)
+}
+
+// claimClaw takes (or renews) the claim on a claw for provisionerID. Like
+// pending tips, it is a conditional UPDATE, so of two instances claiming at
+// once exactly one changes the row. It reports whether the claim was taken
+// and whether it was a renewal.
+func claimClaw(app core.App, id, provisionerID string, now time.Time) (claimed, renewed bool, err error) {
+	stamp := now.UTC().Format(time.RFC3339)
+
+	res, err := app.DB().NewQuery(
+		"UPDATE claw_deployments SET claimed_at = {:now} " +
+			"WHERE id = {:id} AND status = 'claimed' AND provisioner_id = {:pid}").
+		Bind(map[string]any{"now": stamp, "id": id, "pid": provisionerID}).Execute()
+	if err != nil {
+		return false, false, err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return true, true, nil
+	}
+
+	res, err = app.DB().NewQuery(
+		"UPDATE claw_deployments SET status = 'claimed', provisioner_id = {:pid}, claimed_at = {:now}, " +
+			"claim_attempts = claim_attempts + 1 " +
+			"WHERE id = {:id} AND claim_attempts < {:max} " +
+			"AND (status = 'provisioning' OR (status = 'claimed' AND claimed_at < {:expired}))").
+		Bind(map[string]any{
+			"pid": provisionerID, "now": stamp, "id": id,
+			"max": clawClaimMaxAttempts, "expired": clawClaimExpiry(now),
+		}).Execute()
+	if err != nil {
+		return false, false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, false, nil
+}
+
+// failExhaustedClaw fails a claw that has used up its claim attempts, if it
+// is still waiting in the state it was read in.
+func failExhaustedClaw(app *pocketbase.PocketBase, r *core.Record) error {
+	return app.RunInTransaction(func(txApp core.App) error {
+		current, err := txApp.FindRecordById("claw_deployments", r.Id)
+		if err != nil {
+			return err
+		}
+		if current.GetString("status") != r.GetString("status") || current.GetString("claimed_at") != r.GetString("claimed_at") {
+			return errClawClaimLost
+		}
+		current.Set("status", "failed")
+		current.Set("error_message", fmt.Sprintf(
+			"Provisioning abandoned: no provisioner finished it in %d attempts", clawClaimMaxAttempts))
+		return txApp.Save(current)
+	})
+}
+
+func registerClawClaimRoute(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "claim-claw",
+		Method:      "POST",
+		Path:        "/api/claws/{id}/claim",
+		Summary:     "Claim a pending claw for provisioning (internal)",
+		Description: "Internal endpoint for host-side provisioners. Requires X-Provisioner-Key header. " +
+			"Moves a provisioning claw to claimed for this provisioner_id, for a 10-minute lease; 409 if another provisioner holds it. " +
+			"Claiming again while holding the claim renews the lease. Report with POST /api/claws/{id}/provision-result using the same provisioner_id.",
+		Tags: []string{"Claws"},
+	}, func(ctx context.Context, input *ClaimClawInput) (*ClaimClawOutput, error) {
+		if err := checkProvisionerKey(input.ProvisionerKey); err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		claimed, renewed, err := claimClaw(app, input.ID, input.Body.ProvisionerID, now)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to claim deployment")
+		}
+		record, findErr := app.FindRecordById("claw_deployments", input.ID)
+		if findErr != nil {
+			return nil, huma.Error404NotFound("Deployment not found")
+		}
+
+		if !claimed {
+			status := record.GetString("status")
+			waiting := status == "provisioning" ||
+				(status == "claimed" && record.GetString("claimed_at") < clawClaimExpiry(now))
+			switch {
+			case waiting && record.GetInt("claim_attempts") >= clawClaimMaxAttempts:
+				if err := failExhaustedClaw(app, record); err != nil && !errors.Is(err, errClawClaimLost) {
+					app.Logger().Warn("Failed to fail exhausted claw", "id", record.Id, "error", err)
+				}
+				return nil, huma.Error409Conflict(fmt.Sprintf(
+					"Deployment was already claimed %d times without finishing and has been failed", clawClaimMaxAttempts))
+			case status == "claimed":
+				return nil, huma.Error409Conflict(fmt.Sprintf("Claimed by %s until %s",
+					record.GetString("provisioner_id"), leaseExpiry(record)))
+			default:
+				return nil, huma.Error409Conflict(fmt.Sprintf("Deployment is %s, not awaiting provisioning", status))
+			}
+		}
+
+		if !renewed {
+			RecordClawActivity(app, record.Id, ClawActivityStatus,
+				"Claimed by provisioner "+input.Body.ProvisionerID, "",
+				map[string]any{"provisioner_id": input.Body.ProvisionerID, "attempt": record.GetInt("claim_attempts")})
+		}
+
+		out := &ClaimClawOutput{}
+		out.Body.Claw = recordToClawDeployment(record)
+		out.Body.LeaseExpiresAt = leaseExpiry(record)
+		out.Body.Attempt = record.GetInt("claim_attempts")
+		out.Body.Renewed = renewed
+		return out, nil
+	})
+}
+
+func leaseExpiry(r *core.Record) string {
+	claimedAt, err := time.Parse(time.RFC3339, r.GetString("claimed_at"))
+	if err != nil {
+		return ""
+	}
+	return claimedAt.Add(clawClaimLease).UTC().Format(time.RFC3339)
+}
+
+// RegisterClawClaimReaperJob returns lapsed provisioner claims to
+// "provisioning", or fails the claw once it has been claimed
+// clawClaimMaxAttempts times.
+func RegisterClawClaimReaperJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("claw_claim_reaper", clawClaimReapEvery, func(ctx context.Context) error {
+		return reapClawClaims(ctx, app)
+	})
+}
+
+func reapClawClaims(ctx context.Context, app *pocketbase.PocketBase) error {
+	lapsed, err := app.FindRecordsByFilter("claw_deployments",
+		"status = 'claimed' && claimed_at < {:expired}", "claimed_at", 100, 0,
+		map[string]any{"expired": clawClaimExpiry(time.Now())})
+	if err != nil {
+		return fmt.Errorf("find lapsed claw claims: %w", err)
+	}
+
+	var errs []error
+	for _, r := range lapsed {
+		if ctx.Err() != nil {
+			return ctx.Err() // shutting down; the rest go next run
+		}
+		if r.GetInt("claim_attempts") >= clawClaimMaxAttempts {
+			err = failExhaustedClaw(app, r)
+		} else {
+			err = app.RunInTransaction(func(txApp core.App) error {
+				current, err := txApp.FindRecordById("claw_deployments", r.Id)
+				if err != nil {
+					return err
+				}
+				if current.GetString("status") != "claimed" || current.GetString("claimed_at") != r.GetString("claimed_at") {
+					return errClawClaimLost
+				}
+				current.Set("status", "provisioning")
+				current.Set("provisioner_id", "")
+				current.Set("claimed_at", "")
+				return txApp.Save(current)
+			})
+		}
+		if errors.Is(err, errClawClaimLost) {
+			continue // renewed, reported or re-claimed since the query
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reap claim on claw %s: %w", r.Id, err))
+			continue
+		}
+		app.Logger().Warn("Provisioner claim lapsed",
+			"id", r.Id, "provisioner", r.GetString("provisioner_id"), "attempts", r.GetInt("claim_attempts"))
+	}
+	return errors.Join(errs...)
+}
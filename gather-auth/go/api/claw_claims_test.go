@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/pocketbase/pocketbase"
+)
+
+const testProvisionerKey = "X-Provisioner-Key: prov-secret"
+
+func newClaimApp(t *testing.T) (*pocketbase.PocketBase, humatest.TestAPI) {
+	t.Helper()
+	t.Setenv("CLAW_PROVISIONER_KEY", "prov-secret")
+	app := newTestApp(t)
+	addCollection(t, app, "claw_deployments", "user_id", "agent_id", "name", "status", "claw_type", "container_id",
+		"error_message", "provisioner_id", "claimed_at", "claim_attempts:number")
+	_, api := humatest.New(t)
+	RegisterClawRoutes(api, app)
+	return app, api
+}
+
+func addPendingClaw(t *testing.T, app *pocketbase.PocketBase, values map[string]any) string {
+	t.Helper()
+	v := map[string]any{"name": "claw", "status": "provisioning"}
+	for k, val := range values {
+		v[k] = val
+	}
+	return addRecord(t, app, "claw_deployments", v).Id
+}
+
+func pendingIDs(t *testing.T, api humatest.TestAPI) []string {
+	t.Helper()
+	var out ListClawsOutput
+	decodeBody(t, api.Get("/api/claws/pending", testProvisionerKey), &out.Body)
+	ids := []string{}
+	for _, c := range out.Body.Claws {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func TestClaimClawContention(t *testing.T) {
+	app, _ := newClaimApp(t)
+	for i := 0; i < 20; i++ {
+		id := addPendingClaw(t, app, nil)
+
+		// Both instances read the claw as pending, then claim at once
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		won := make([]bool, 2)
+		for j, pid := range []string{"host-a", "host-b"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				claimed, _, err := claimClaw(app, id, pid, time.Now())
+				if err != nil {
+					t.Error(err)
+				}
+				won[j] = claimed
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		if won[0] == won[1] {
+			t.Fatalf("round %d: host-a won %v, host-b won %v; want exactly one", i, won[0], won[1])
+		}
+		winner := map[bool]string{true: "host-a", false: "host-b"}[won[0]]
+		r := mustFind(t, app, id)
+		if r.GetString("status") != "claimed" || r.GetString("provisioner_id") != winner || r.GetInt("claim_attempts") != 1 {
+			t.Errorf("round %d: %s by %s, %d attempts", i, r.GetString("status"), r.GetString("provisioner_id"), r.GetInt("claim_attempts"))
+		}
+	}
+}
+
+func TestClaimClawFlow(t *testing.T) {
+	app, api := newClaimApp(t)
+	id := addPendingClaw(t, app, nil)
+	claim := func(pid string) (int, ClaimClawOutput) {
+		t.Helper()
+		resp := api.Post("/api/claws/"+id+"/claim", testProvisionerKey, map[string]any{"provisioner_id": pid})
+		var out ClaimClawOutput
+		if resp.Code == http.StatusOK {
+			decodeBody(t, resp, &out.Body)
+		}
+		return resp.Code, out
+	}
+	report := func(pid, status string) (int, string) {
+		t.Helper()
+		resp := api.Post("/api/claws/"+id+"/provision-result", testProvisionerKey,
+			map[string]any{"provisioner_id": pid, "status": status, "container_id": "claw-" + pid})
+		return resp.Code, resp.Body.String()
+	}
+
+	if resp := api.Get("/api/claws/pending", "X-Provisioner-Key: wrong"); resp.Code != http.StatusUnauthorized {
+		t.Errorf("wrong key: %d", resp.Code)
+	}
+	if got := pendingIDs(t, api); len(got) != 1 || got[0] != id {
+		t.Fatalf("pending %v", got)
+	}
+	if code, body := report("host-a", "running"); code != http.StatusConflict || !strings.Contains(body, "not claimed") {
+		t.Errorf("report before claiming: %d %s", code, body)
+	}
+
+	code, out := claim("host-a")
+	if code != http.StatusOK || out.Body.Attempt != 1 || out.Body.Renewed || out.Body.LeaseExpiresAt == "" {
+		t.Fatalf("claim: %d %+v", code, out.Body)
+	}
+	if got := pendingIDs(t, api); len(got) != 0 {
+		t.Errorf("claimed claw still pending: %v", got)
+	}
+	if code, _ := claim("host-b"); code != http.StatusConflict {
+		t.Errorf("second claimant: %d", code)
+	}
+	if code, out := claim("host-a"); code != http.StatusOK || !out.Body.Renewed || out.Body.Attempt != 1 {
+		t.Errorf("renewal: %d %+v", code, out.Body)
+	}
+
+	if code, body := report("host-b", "failed"); code != http.StatusConflict || !strings.Contains(body, "host-a") {
+		t.Errorf("report by a non-holder: %d %s", code, body)
+	}
+	if code, body := report("host-a", "running"); code != http.StatusOK {
+		t.Fatalf("report by the holder: %d %s", code, body)
+	}
+	if r := mustFind(t, app, id); r.GetString("status") != "running" || r.GetString("container_id") != "claw-host-a" {
+		t.Errorf("after report: %s %s", r.GetString("status"), r.GetString("container_id"))
+	}
+	if code, _ := claim("host-b"); code != http.StatusConflict {
+		t.Errorf("claim of a running claw: %d", code)
+	}
+}
+
+func TestReapClawClaims(t *testing.T) {
+	app, api := newClaimApp(t)
+	lapsed := time.Now().Add(-clawClaimLease - time.Minute).UTC().Format(time.RFC3339)
+	current := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	crashed := addPendingClaw(t, app, map[string]any{"status": "claimed", "provisioner_id": "host-a", "claimed_at": lapsed, "claim_attempts": 1})
+	exhausted := addPendingClaw(t, app, map[string]any{"status": "claimed", "provisioner_id": "host-a", "claimed_at": lapsed, "claim_attempts": clawClaimMaxAttempts})
+	working := addPendingClaw(t, app, map[string]any{"status": "claimed", "provisioner_id": "host-a", "claimed_at": current, "claim_attempts": 1})
+
+	// A lapsed claim is listed, and can be taken over before the reaper runs
+	if got := pendingIDs(t, api); len(got) != 2 || strings.Contains(strings.Join(got, ","), working) {
+		t.Errorf("pending %v, want the two lapsed claims", got)
+	}
+
+	if err := reapClawClaims(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+	if r := mustFind(t, app, crashed); r.GetString("status") != "provisioning" || r.GetString("provisioner_id") != "" {
+		t.Errorf("crashed claim: %s by %q", r.GetString("status"), r.GetString("provisioner_id"))
+	}
+	if r := mustFind(t, app, exhausted); r.GetString("status") != "failed" || r.GetString("error_message") == "" {
+		t.Errorf("exhausted claim: %s %q", r.GetString("status"), r.GetString("error_message"))
+	}
+	if r := mustFind(t, app, working); r.GetString("status") != "claimed" || r.GetString("provisioner_id") != "host-a" {
+		t.Errorf("live claim reaped: %s", r.GetString("status"))
+	}
+
+	// The next claim counts as another attempt
+	var out ClaimClawOutput
+	decodeBody(t, api.Post("/api/claws/"+crashed+"/claim", testProvisionerKey, map[string]any{"provisioner_id": "host-b"}), &out.Body)
+	if out.Body.Attempt != 2 {
+		t.Errorf("attempt %d after a lapsed claim, want 2", out.Body.Attempt)
+	}
+
+	// Claiming a claw that has used up its attempts fails it
+	spent := addPendingClaw(t, app, map[string]any{"claim_attempts": clawClaimMaxAttempts})
+	resp := api.Post("/api/claws/"+spent+"/claim", testProvisionerKey, map[string]any{"provisioner_id": "host-b"})
+	if resp.Code != http.StatusConflict || mustFind(t, app, spent).GetString("status") != "failed" {
+		t.Errorf("claim of a spent claw: %d, status %s", resp.Code, mustFind(t, app, spent).GetString("status"))
+	}
+}
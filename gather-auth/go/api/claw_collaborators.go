@@ -0,0 +1,380 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Claw sharing — the owner grants other users viewer or operator access
+// -----------------------------------------------------------------------------
+
+// Roles, weakest first. viewers read the claw's messages, events and
+// timeline; operators also talk to it, restart it and open its subdomain;
+// only the owner changes settings, env and secrets, resizes, shares or
+// deletes it.
+const (
+	ClawRoleViewer   = "viewer"
+	ClawRoleOperator = "operator"
+	ClawRoleOwner    = "owner"
+)
+
+var clawRoleRank = map[string]int{ClawRoleViewer: 1, ClawRoleOperator: 2, ClawRoleOwner: 3}
+
+// maxClawCollaborators caps collaborators and pending invites per claw.
+const maxClawCollaborators = 20
+
+// Invites to an email with no account are stored with an empty user_id and
+// claimed by ClaimClawInvites once someone with that verified email logs in.
+
+type ClawCollaborator struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	UserID    string `json:"user_id,omitempty" doc:"Empty while the invite is pending"`
+	Role      string `json:"role" enum:"viewer,operator"`
+	Status    string `json:"status" enum:"active,pending" doc:"pending until the invited email has an account"`
+	InvitedBy string `json:"invited_by"`
+	Created   string `json:"created"`
+}
+
+func recordToClawCollaborator(r *core.Record) ClawCollaborator {
+	status := "active"
+	if r.GetString("user_id") == "" {
+		status = "pending"
+	}
+	return ClawCollaborator{
+		ID:        r.Id,
+		Email:     r.GetString("email"),
+		UserID:    r.GetString("user_id"),
+		Role:      r.GetString("role"),
+		Status:    status,
+		InvitedBy: r.GetString("invited_by"),
+		Created:   recordTime(r, "created"),
+	}
+}
+
+type ListClawCollaboratorsInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
+	ID            string `path:"id" doc:"Deployment ID"`
+}
+
+type ListClawCollaboratorsOutput struct {
+	Body struct {
+		Collaborators []ClawCollaborator `json:"collaborators"`
+	}
+}
+
+type AddClawCollaboratorInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
+	ID            string `path:"id" doc:"Deployment ID"`
+	Body          struct {
+		Email string `json:"email" doc:"Email of the user to share with" format:"email" maxLength:"200"`
+		Role  string `json:"role" enum:"viewer,operator" doc:"viewer reads messages; operator can also send messages, restart and open the claw's subdomain"`
+	}
+}
+
+type AddClawCollaboratorOutput struct {
+	Body ClawCollaborator
+}
+
+type RemoveClawCollaboratorInput struct {
+	Authorization  string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
+	ID             string `path:"id" doc:"Deployment ID"`
+	CollaboratorID string `path:"collaborator_id" doc:"Collaborator ID from GET /api/claws/{id}/collaborators"`
+}
+
+type RemoveClawCollaboratorOutput struct {
+	Body struct {
+		OK bool `json:"ok"`
+	}
+}
+
+// clawRole returns the user's role on a claw, or "" if they have no access.
+func clawRole(app core.App, claw *core.Record, userID string) string {
+	if userID == "" {
+		return ""
+	}
+	if claw.GetString("user_id") == userID {
+		return ClawRoleOwner
+	}
+	rec, err := app.FindFirstRecordByFilter("claw_collaborators",
+		"claw_id = {:cid} && user_id = {:uid}",
+		map[string]any{"cid": claw.Id, "uid": userID})
+	if err != nil {
+		return ""
+	}
+	return rec.GetString("role")
+}
+
+// clawRoleAtLeast reports whether role grants everything minRole does.
+func clawRoleAtLeast(role, minRole string) bool {
+	return role != "" && clawRoleRank[role] >= clawRoleRank[minRole]
+}
+
+// requireClawAccess validates auth and that the user holds at least minRole
+// on the claw, and returns the claw record and user ID. Users with no access
+// get a 404 as if the claw didn't exist; collaborators below minRole a 403.
+func requireClawAccess(app *pocketbase.PocketBase, authHeader, clawID, minRole string) (*core.Record, string, error) {
+	userID, err := extractPBUserID(app, authHeader)
+	if err != nil {
+		return nil, "", huma.Error401Unauthorized("Authentication required")
+	}
+
+	record, err := app.FindRecordById("claw_deployments", clawID)
+	if err != nil {
+		return nil, "", huma.Error404NotFound("Deployment not found")
+	}
+
+	role := clawRole(app, record, userID)
+	if role == "" {
+		return nil, "", huma.Error404NotFound("Deployment not found")
+	}
+	if !clawRoleAtLeast(role, minRole) {
+		return nil, "", huma.Error403Forbidden(fmt.Sprintf("This needs %s access to the claw; you are a %s", minRole, role))
+	}
+	return record, userID, nil
+}
+
+// sharedClaws returns the claws a user collaborates on, with their role.
+func sharedClaws(app *pocketbase.PocketBase, userID string) ([]*core.Record, map[string]string) {
+	collabs, err := app.FindRecordsByFilter("claw_collaborators",
+		"user_id = {:uid}", "-created", 50, 0, map[string]any{"uid": userID})
+	if err != nil || len(collabs) == 0 {
+		return nil, nil
+	}
+	roles := make(map[string]string, len(collabs))
+	ids := make([]string, 0, len(collabs))
+	for _, c := range collabs {
+		roles[c.GetString("claw_id")] = c.GetString("role")
+		ids = append(ids, c.GetString("claw_id"))
+	}
+	claws, err := app.FindRecordsByIds("claw_deployments", ids)
+	if err != nil {
+		return nil, nil
+	}
+	return claws, roles
+}
+
+// notifyClawAccess tells a user, through their inbox, that their access to a
+// claw changed. Users' inbox entries are keyed "user:<id>".
+func notifyClawAccess(app *pocketbase.PocketBase, userID string, claw *core.Record, subject, body string) {
+	if userID == "" {
+		return
+	}
+	SendInboxMessage(app, "user:"+userID, "claw_access", subject, body, "claw", claw.Id)
+}
+
+func clawRoleGrantedMessage(claw *core.Record, inviter, role string) (string, string) {
+	subject := fmt.Sprintf("%s shared %s with you", inviter, claw.GetString("name"))
+	body := fmt.Sprintf("You now have %s access to the claw %s.", role, claw.GetString("name"))
+	return subject, body
+}
+
+// userDisplayName is a user's name, falling back to their email.
+func userDisplayName(app core.App, userID string) string {
+	rec, err := app.FindRecordById("users", userID)
+	if err != nil {
+		return "Someone"
+	}
+	if name := rec.GetString("name"); name != "" {
+		return name
+	}
+	return rec.GetString("email")
+}
+
+// ClaimClawInvites turns pending invites for a user's email into
+// collaborator access. Only verified emails claim invites, so registering
+// with someone else's address doesn't grant their access.
+func ClaimClawInvites(app *pocketbase.PocketBase, user *core.Record) {
+	email := strings.ToLower(user.Email())
+	if email == "" || !user.Verified() {
+		return
+	}
+	pending, err := app.FindRecordsByFilter("claw_collaborators",
+		"email = {:email} && user_id = ''", "", 0, 0, map[string]any{"email": email})
+	if err != nil {
+		return
+	}
+	for _, inv := range pending {
+		claw, err := app.FindRecordById("claw_deployments", inv.GetString("claw_id"))
+		if err != nil {
+			continue
+		}
+		if claw.GetString("user_id") == user.Id {
+			// The owner can't collaborate on their own claw
+			if err := app.Delete(inv); err != nil {
+				app.Logger().Warn("Failed to drop claw invite to owner", "invite", inv.Id, "error", err)
+			}
+			continue
+		}
+		inv.Set("user_id", user.Id)
+		if err := app.Save(inv); err != nil {
+			app.Logger().Warn("Failed to claim claw invite", "invite", inv.Id, "error", err)
+			continue
+		}
+		if err := recordAdminAudit(app, "user:"+user.Id, "claw.collaborator.claim", "claw", claw.Id, map[string]any{
+			"collaborator_id": inv.Id,
+			"email":           email,
+			"role":            inv.GetString("role"),
+		}); err != nil {
+			app.Logger().Warn("Failed to audit claw invite claim", "invite", inv.Id, "error", err)
+		}
+		InvalidateClawAccess(claw.GetString("subdomain"))
+		subject, body := clawRoleGrantedMessage(claw, userDisplayName(app, inv.GetString("invited_by")), inv.GetString("role"))
+		notifyClawAccess(app, user.Id, claw, subject, body)
+	}
+}
+
+func registerClawCollaboratorRoutes(api huma.API, app *pocketbase.PocketBase) {
+	// GET /api/claws/{id}/collaborators — who the claw is shared with
+	huma.Register(api, huma.Operation{
+		OperationID: "list-claw-collaborators",
+		Method:      "GET",
+		Path:        "/api/claws/{id}/collaborators",
+		Summary:     "List Claw collaborators",
+		Description: "Users the claw is shared with, and pending invites. Owner only.",
+		Tags:        []string{"Claws"},
+	}, func(ctx context.Context, input *ListClawCollaboratorsInput) (*ListClawCollaboratorsOutput, error) {
+		record, _, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		recs, _ := app.FindRecordsByFilter("claw_collaborators",
+			"claw_id = {:cid}", "created", 0, 0, map[string]any{"cid": record.Id})
+
+		out := &ListClawCollaboratorsOutput{}
+		out.Body.Collaborators = make([]ClawCollaborator, 0, len(recs))
+		for _, r := range recs {
+			out.Body.Collaborators = append(out.Body.Collaborators, recordToClawCollaborator(r))
+		}
+		return out, nil
+	})
+
+	// POST /api/claws/{id}/collaborators — share by email
+	huma.Register(api, huma.Operation{
+		OperationID: "add-claw-collaborator",
+		Method:      "POST",
+		Path:        "/api/claws/{id}/collaborators",
+		Summary:     "Share a Claw",
+		Description: "Grant another user viewer or operator access by email. If the email has no account yet the invite stays pending " +
+			"and is claimed when that user verifies their email and logs in. Sharing with an existing collaborator changes their role. Owner only.",
+		Tags: []string{"Claws"},
+	}, func(ctx context.Context, input *AddClawCollaboratorInput) (*AddClawCollaboratorOutput, error) {
+		record, userID, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		email := strings.ToLower(strings.TrimSpace(input.Body.Email))
+		invitee, _ := app.FindAuthRecordByEmail("users", email)
+		if invitee != nil && invitee.Id == userID {
+			return nil, huma.Error422UnprocessableEntity("You already own this claw")
+		}
+
+		collab, _ := app.FindFirstRecordByFilter("claw_collaborators",
+			"claw_id = {:cid} && email = {:email}",
+			map[string]any{"cid": record.Id, "email": email})
+		action := "claw.collaborator.update"
+		if collab == nil {
+			existing, _ := app.FindRecordsByFilter("claw_collaborators",
+				"claw_id = {:cid}", "", 0, 0, map[string]any{"cid": record.Id})
+			if len(existing) >= maxClawCollaborators {
+				return nil, huma.Error422UnprocessableEntity(fmt.Sprintf("A claw can be shared with at most %d people", maxClawCollaborators))
+			}
+			col, err := app.FindCollectionByNameOrId("claw_collaborators")
+			if err != nil {
+				return nil, huma.Error500InternalServerError("claw_collaborators collection not found")
+			}
+			collab = core.NewRecord(col)
+			collab.Set("claw_id", record.Id)
+			collab.Set("email", email)
+			action = "claw.collaborator.add"
+		}
+		previousRole := collab.GetString("role")
+		collab.Set("role", input.Body.Role)
+		collab.Set("invited_by", userID)
+		if invitee != nil {
+			collab.Set("user_id", invitee.Id)
+		}
+
+		err = app.RunInTransaction(func(txApp core.App) error {
+			if err := txApp.Save(collab); err != nil {
+				return err
+			}
+			return recordAdminAudit(txApp, "user:"+userID, action, "claw", record.Id, map[string]any{
+				"collaborator_id": collab.Id,
+				"email":           email,
+				"user_id":         collab.GetString("user_id"),
+				"role":            input.Body.Role,
+				"previous_role":   previousRole,
+			})
+		})
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to share claw")
+		}
+		InvalidateClawAccess(record.GetString("subdomain"))
+
+		if previousRole != input.Body.Role {
+			subject, body := clawRoleGrantedMessage(record, userDisplayName(app, userID), input.Body.Role)
+			if previousRole != "" {
+				subject = fmt.Sprintf("Your access to %s changed", record.GetString("name"))
+				body = fmt.Sprintf("You now have %s access to the claw %s (was %s).", input.Body.Role, record.GetString("name"), previousRole)
+			}
+			notifyClawAccess(app, collab.GetString("user_id"), record, subject, body)
+		}
+
+		out := &AddClawCollaboratorOutput{}
+		out.Body = recordToClawCollaborator(collab)
+		return out, nil
+	})
+
+	// DELETE /api/claws/{id}/collaborators/{collaborator_id} — revoke access
+	huma.Register(api, huma.Operation{
+		OperationID: "remove-claw-collaborator",
+		Method:      "DELETE",
+		Path:        "/api/claws/{id}/collaborators/{collaborator_id}",
+		Summary:     "Stop sharing a Claw",
+		Description: "Revoke a collaborator's access or cancel a pending invite. Owner only.",
+		Tags:        []string{"Claws"},
+	}, func(ctx context.Context, input *RemoveClawCollaboratorInput) (*RemoveClawCollaboratorOutput, error) {
+		record, userID, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		collab, err := app.FindRecordById("claw_collaborators", input.CollaboratorID)
+		if err != nil || collab.GetString("claw_id") != record.Id {
+			return nil, huma.Error404NotFound("Collaborator not found")
+		}
+
+		err = app.RunInTransaction(func(txApp core.App) error {
+			if err := txApp.Delete(collab); err != nil {
+				return err
+			}
+			return recordAdminAudit(txApp, "user:"+userID, "claw.collaborator.remove", "claw", record.Id, map[string]any{
+				"collaborator_id": collab.Id,
+				"email":           collab.GetString("email"),
+				"user_id":         collab.GetString("user_id"),
+				"role":            collab.GetString("role"),
+			})
+		})
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to remove collaborator")
+		}
+		InvalidateClawAccess(record.GetString("subdomain"))
+
+		notifyClawAccess(app, collab.GetString("user_id"), record,
+			fmt.Sprintf("You no longer have access to %s", record.GetString("name")),
+			fmt.Sprintf("%s stopped sharing the claw %s with you.", userDisplayName(app, userID), record.GetString("name")))
+
+		out := &RemoveClawCollaboratorOutput{}
+		out.Body.OK = true
+		return out, nil
+	})
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+
+	auth "gather.is/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Claw containers — which containers a caller may operate on
+// -----------------------------------------------------------------------------
+
+// gather-mcp's claw.* Docker tools act on the host's containers directly, so
+// before each call it asks here which ones belong to the caller: for a claw's
+// own agent JWT, that claw's container; for a user's token, the containers
+// of every claw they own.
+
+type ClawContainersInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer agent JWT, or a PocketBase user token" required:"true"`
+}
+
+type ClawContainer struct {
+	ClawID    string `json:"claw_id"`
+	Name      string `json:"name" doc:"Claw subdomain; the container is claw-<name>"`
+	Container string `json:"container"`
+	Status    string `json:"status"`
+}
+
+type ClawContainersOutput struct {
+	Body struct {
+		Containers []ClawContainer `json:"containers"`
+	}
+}
+
+func RegisterClawContainerRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "list-own-claw-containers",
+		Method:      "GET",
+		Path:        "/api/claws/containers",
+		Summary:     "List the claw containers you own",
+		Description: "With a claw's agent JWT: that claw's container. With a user token: the containers of the claws you own " +
+			"(not ones shared with you). Used by gather-mcp to scope its claw.* tools to the caller.",
+		Tags: []string{"Claws"},
+	}, func(ctx context.Context, input *ClawContainersInput) (*ClawContainersOutput, error) {
+		filter, params := "", map[string]any{}
+		if claims, err := RequireJWT(input.Authorization, jwtKey); err == nil {
+			filter, params["aid"] = "agent_id = {:aid}", claims.AgentID
+		} else if userID, err := extractPBUserID(app, input.Authorization); err == nil {
+			filter, params["uid"] = "user_id = {:uid}", userID
+		} else {
+			return nil, huma.Error401Unauthorized("Authentication required: an agent JWT or a user token")
+		}
+
+		records, err := app.FindRecordsByFilter("claw_deployments", filter, "-created", 0, 0, params)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to look up your claws")
+		}
+
+		out := &ClawContainersOutput{}
+		out.Body.Containers = []ClawContainer{}
+		for _, r := range records {
+			subdomain := r.GetString("subdomain")
+			if subdomain == "" {
+				continue // not provisioned yet; it has no container
+			}
+			container := r.GetString("container_id")
+			if container == "" {
+				container = "claw-" + subdomain
+			}
+			out.Body.Containers = append(out.Body.Containers, ClawContainer{
+				ClawID:    r.Id,
+				Name:      subdomain,
+				Container: container,
+				Status:    r.GetString("status"),
+			})
+		}
+		return out, nil
+	})
+}
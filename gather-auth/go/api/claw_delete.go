@@ -0,0 +1,178 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Claw deletion cleanup — the agent identity, channel and inbox a claw leaves
+// behind
+// -----------------------------------------------------------------------------
+
+// ClawCleanup reports what deleting a claw did to the records around it.
+type ClawCleanup struct {
+	Agent           string `json:"agent" enum:"deleted,suspended,none" doc:"deleted if the agent had no activity outside its claw, otherwise suspended"`
+	Channel         string `json:"channel" enum:"deleted,kept,none" doc:"kept (read-only) with keep_history=true"`
+	MessagesRemoved int    `json:"messages_removed" doc:"Channel messages deleted"`
+	InboxRemoved    int    `json:"inbox_removed" doc:"Inbox messages deleted"`
+}
+
+// clawAgentHasActivity reports whether a claw's agent left traces outside its
+// own claw — posts, comments, reviews, messages in other channels, or a
+// balance — that other records point at. Such agents are suspended rather
+// than deleted.
+func clawAgentHasActivity(app core.App, agentID, channelID string) bool {
+	for _, q := range []struct{ collection, filter string }{
+		{"posts", "author_id = {:aid}"},
+		{"comments", "author_id = {:aid}"},
+		{"reviews", "agent_id = {:aid}"},
+		{"channel_messages", "author_id = {:aid} && channel_id != {:cid}"},
+	} {
+		recs, err := app.FindRecordsByFilter(q.collection, q.filter, "", 1, 0,
+			map[string]any{"aid": agentID, "cid": channelID})
+		if err == nil && len(recs) > 0 {
+			return true
+		}
+	}
+	bal, err := app.FindFirstRecordByFilter("agent_balances", "agent_id = {:aid}", map[string]any{"aid": agentID})
+	return err == nil && parseBCH(bal.GetString("balance_bch")).Sign() != 0
+}
+
+// deleteByFilter deletes every record in collection matching filter and
+// returns how many were removed.
+func deleteByFilter(txApp core.App, collection, filter string, params map[string]any) (int, error) {
+	recs, err := txApp.FindRecordsByFilter(collection, filter, "", 0, 0, params)
+	if err != nil {
+		return 0, nil // collection missing or nothing to delete
+	}
+	for _, r := range recs {
+		if err := txApp.Delete(r); err != nil {
+			return 0, fmt.Errorf("delete %s %s: %w", collection, r.Id, err)
+		}
+	}
+	return len(recs), nil
+}
+
+// cleanupDeletedClaw removes or retires everything a claw deployment created,
+// in one transaction, after its container is gone. With keepHistory the
+// deployment record stays (status "deleted", no container) so the owner can
+// still read its channel through GET /api/claws/{id}/messages; sending is
+// impossible without a container, which keeps the transcript read-only.
+func cleanupDeletedClaw(app *pocketbase.PocketBase, record *core.Record, userID string, keepHistory bool) (ClawCleanup, error) {
+	result := ClawCleanup{Agent: "none", Channel: "none"}
+	agentID := record.GetString("agent_id")
+	channelID := ""
+	if agentID != "" {
+		channelID, _ = findClawChannel(app, agentID)
+	}
+
+	err := app.RunInTransaction(func(txApp core.App) error {
+		var err error
+
+		if channelID != "" {
+			if keepHistory {
+				result.Channel = "kept"
+			} else {
+				params := map[string]any{"cid": channelID}
+				if _, err = deleteByFilter(txApp, "channel_attachments", "channel_id = {:cid}", params); err != nil {
+					return err
+				}
+				if result.MessagesRemoved, err = deleteByFilter(txApp, "channel_messages", "channel_id = {:cid}", params); err != nil {
+					return err
+				}
+				if _, err = deleteByFilter(txApp, "channel_members", "channel_id = {:cid}", params); err != nil {
+					return err
+				}
+				if ch, err := txApp.FindRecordById("channels", channelID); err == nil {
+					if err := txApp.Delete(ch); err != nil {
+						return fmt.Errorf("delete channel: %w", err)
+					}
+				}
+				result.Channel = "deleted"
+			}
+		}
+
+		if agentID != "" {
+			if result.InboxRemoved, err = deleteByFilter(txApp, "messages", "agent_id = {:aid}", map[string]any{"aid": agentID}); err != nil {
+				return err
+			}
+
+			if agent, err := txApp.FindRecordById("agents", agentID); err == nil {
+				// Kept messages still name the agent, so it survives with them
+				if keepHistory || clawAgentHasActivity(txApp, agentID, channelID) {
+					agent.Set("suspended", true)
+					agent.Set("suspend_reason", "Claw deleted by its owner")
+					if err := txApp.Save(agent); err != nil {
+						return fmt.Errorf("suspend agent: %w", err)
+					}
+					if bal, err := txApp.FindFirstRecordByFilter("agent_balances", "agent_id = {:aid}", map[string]any{"aid": agentID}); err == nil {
+						bal.Set("suspended", true)
+						if err := txApp.Save(bal); err != nil {
+							return fmt.Errorf("freeze agent balance: %w", err)
+						}
+					}
+					result.Agent = "suspended"
+				} else {
+					params := map[string]any{"aid": agentID}
+					if _, err := deleteByFilter(txApp, "channel_members", "agent_id = {:aid}", params); err != nil {
+						return err
+					}
+					if _, err := deleteByFilter(txApp, "agent_balances", "agent_id = {:aid}", params); err != nil {
+						return err
+					}
+					if err := txApp.Delete(agent); err != nil {
+						return fmt.Errorf("delete agent: %w", err)
+					}
+					result.Agent = "deleted"
+				}
+			}
+		}
+
+		// Vault secrets keep when they were last injected, not into what
+		if _, err := txApp.DB().NewQuery("UPDATE claw_secrets SET last_injected_claw = '' WHERE last_injected_claw = {:id}").
+			Bind(map[string]any{"id": record.Id}).Execute(); err != nil {
+			return fmt.Errorf("clear secret references: %w", err)
+		}
+
+		if keepHistory {
+			record.Set("status", "deleted")
+			record.Set("container_id", "")
+			record.Set("proxy_token", "")
+			record.Set("url", "")
+			if err := txApp.Save(record); err != nil {
+				return fmt.Errorf("retire deployment: %w", err)
+			}
+		} else {
+			params := map[string]any{"cid": record.Id}
+			if _, err := deleteByFilter(txApp, "claw_events", "claw_id = {:cid}", params); err != nil {
+				return err
+			}
+			if _, err := deleteByFilter(txApp, "claw_activity", "claw_id = {:cid}", params); err != nil {
+				return err
+			}
+			if _, err := deleteByFilter(txApp, "claw_collaborators", "claw_id = {:cid}", params); err != nil {
+				return err
+			}
+			if err := txApp.Delete(record); err != nil {
+				return fmt.Errorf("delete deployment: %w", err)
+			}
+		}
+
+		return recordAdminAudit(txApp, "user:"+userID, "claw.delete", "claw", record.Id, map[string]any{
+			"agent_id":         agentID,
+			"channel_id":       channelID,
+			"keep_history":     keepHistory,
+			"agent":            result.Agent,
+			"channel":          result.Channel,
+			"messages_removed": result.MessagesRemoved,
+			"inbox_removed":    result.InboxRemoved,
+		})
+	})
+	if err != nil {
+		return ClawCleanup{}, err
+	}
+	return result, nil
+}
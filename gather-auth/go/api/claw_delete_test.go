@@ -0,0 +1,171 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// clawDeleteFixture is a claw with the records around it: its agent, the
+// agent's channel with a member and a message, an inbox message, a vault
+// secret last injected into it, and one event.
+type clawDeleteFixture struct {
+	app                        *pocketbase.PocketBase
+	claw, agent, channel       *core.Record
+	secret, inbox, member, msg *core.Record
+}
+
+func newClawDeleteFixture(t *testing.T) *clawDeleteFixture {
+	t.Helper()
+	app := newTestApp(t)
+	addCollection(t, app, "claw_deployments", "user_id", "agent_id", "status", "container_id", "proxy_token", "url")
+	addCollection(t, app, "agents", "name", "suspended:bool", "suspend_reason")
+	addCollection(t, app, "channels", "name")
+	addCollection(t, app, "channel_members", "channel_id", "agent_id", "role")
+	addCollection(t, app, "channel_messages", "channel_id", "author_id", "body")
+	addCollection(t, app, "messages", "agent_id", "subject")
+	addCollection(t, app, "posts", "author_id", "title")
+	addCollection(t, app, "comments", "author_id")
+	addCollection(t, app, "reviews", "agent_id")
+	addCollection(t, app, "claw_secrets", "user_id", "key", "last_injected_claw")
+	addCollection(t, app, "claw_events", "claw_id")
+	addCollection(t, app, "claw_activity", "claw_id")
+	addCollection(t, app, "admin_audit", "admin_id", "action", "target_type", "target_id", "details:json")
+	addBalancesCollection(t, app)
+
+	f := &clawDeleteFixture{app: app}
+	f.agent = addRecord(t, app, "agents", map[string]any{"name": "clawbot"})
+	f.claw = addRecord(t, app, "claw_deployments", map[string]any{
+		"user_id": "user1", "agent_id": f.agent.Id, "status": "running", "container_id": "claw-abc",
+	})
+	f.channel = addRecord(t, app, "channels", map[string]any{"name": "clawbot"})
+	f.member = addRecord(t, app, "channel_members", map[string]any{"channel_id": f.channel.Id, "agent_id": f.agent.Id, "role": "owner"})
+	f.msg = addRecord(t, app, "channel_messages", map[string]any{"channel_id": f.channel.Id, "author_id": f.agent.Id, "body": "hi"})
+	f.inbox = addRecord(t, app, "messages", map[string]any{"agent_id": f.agent.Id, "subject": "welcome"})
+	f.secret = addRecord(t, app, "claw_secrets", map[string]any{"user_id": "user1", "key": "API_KEY", "last_injected_claw": f.claw.Id})
+	addRecord(t, app, "claw_events", map[string]any{"claw_id": f.claw.Id})
+	return f
+}
+
+// exists reports whether rec is still stored, returning its current state.
+func (f *clawDeleteFixture) exists(rec *core.Record) (*core.Record, bool) {
+	got, err := f.app.FindRecordById(rec.Collection().Name, rec.Id)
+	return got, err == nil
+}
+
+func (f *clawDeleteFixture) audited(t *testing.T) {
+	t.Helper()
+	recs, err := f.app.FindRecordsByFilter("admin_audit", "action = 'claw.delete' && target_id = {:id}", "", 0, 0,
+		map[string]any{"id": f.claw.Id})
+	if err != nil || len(recs) != 1 || recs[0].GetString("admin_id") != "user:user1" {
+		t.Errorf("audit entries %d (%v)", len(recs), err)
+	}
+}
+
+func TestCleanupDeletedClaw(t *testing.T) {
+	f := newClawDeleteFixture(t)
+	got, err := cleanupDeletedClaw(f.app, f.claw, "user1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ClawCleanup{Agent: "deleted", Channel: "deleted", MessagesRemoved: 1, InboxRemoved: 1}
+	if got != want {
+		t.Errorf("cleanup %+v, want %+v", got, want)
+	}
+	for _, rec := range []*core.Record{f.claw, f.agent, f.channel, f.member, f.msg, f.inbox} {
+		if _, ok := f.exists(rec); ok {
+			t.Errorf("%s %s survived", rec.Collection().Name, rec.Id)
+		}
+	}
+	if events, _ := f.app.FindRecordsByFilter("claw_events", "claw_id = {:id}", "", 0, 0, map[string]any{"id": f.claw.Id}); len(events) != 0 {
+		t.Errorf("%d claw events survived", len(events))
+	}
+	if secret, ok := f.exists(f.secret); !ok || secret.GetString("last_injected_claw") != "" {
+		t.Error("vault secret deleted or still points at the claw")
+	}
+	f.audited(t)
+}
+
+func TestCleanupDeletedClawSuspendsActiveAgent(t *testing.T) {
+	f := newClawDeleteFixture(t)
+	post := addRecord(t, f.app, "posts", map[string]any{"author_id": f.agent.Id, "title": "hello feed"})
+	bal := addRecord(t, f.app, "agent_balances", map[string]any{"agent_id": f.agent.Id, "balance_bch": "0"})
+
+	got, err := cleanupDeletedClaw(f.app, f.claw, "user1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Agent != "suspended" || got.Channel != "deleted" {
+		t.Errorf("cleanup %+v", got)
+	}
+	agent, ok := f.exists(f.agent)
+	if !ok || !agent.GetBool("suspended") || agent.GetString("suspend_reason") == "" {
+		t.Error("agent that posted to the feed was not suspended")
+	}
+	if _, ok := f.exists(post); !ok {
+		t.Error("the agent's post was deleted")
+	}
+	if bal, ok := f.exists(bal); !ok || !bal.GetBool("suspended") {
+		t.Error("balance not kept frozen")
+	}
+	for _, rec := range []*core.Record{f.claw, f.channel, f.msg, f.inbox} {
+		if _, ok := f.exists(rec); ok {
+			t.Errorf("%s %s survived", rec.Collection().Name, rec.Id)
+		}
+	}
+	f.audited(t)
+}
+
+func TestCleanupDeletedClawActivity(t *testing.T) {
+	cases := map[string]func(f *clawDeleteFixture) *core.Record{
+		"comment": func(f *clawDeleteFixture) *core.Record {
+			return addRecord(t, f.app, "comments", map[string]any{"author_id": f.agent.Id})
+		},
+		"review": func(f *clawDeleteFixture) *core.Record {
+			return addRecord(t, f.app, "reviews", map[string]any{"agent_id": f.agent.Id})
+		},
+		"message in another channel": func(f *clawDeleteFixture) *core.Record {
+			return addRecord(t, f.app, "channel_messages", map[string]any{"channel_id": "elsewhere", "author_id": f.agent.Id})
+		},
+		"balance": func(f *clawDeleteFixture) *core.Record {
+			return addRecord(t, f.app, "agent_balances", map[string]any{"agent_id": f.agent.Id, "balance_bch": "0.001"})
+		},
+	}
+	for name, add := range cases {
+		f := newClawDeleteFixture(t)
+		add(f)
+		got, err := cleanupDeletedClaw(f.app, f.claw, "user1", false)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got.Agent != "suspended" {
+			t.Errorf("%s: agent %s, want suspended", name, got.Agent)
+		}
+	}
+}
+
+func TestCleanupDeletedClawKeepHistory(t *testing.T) {
+	f := newClawDeleteFixture(t)
+	got, err := cleanupDeletedClaw(f.app, f.claw, "user1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ClawCleanup{Agent: "suspended", Channel: "kept", InboxRemoved: 1}
+	if got != want {
+		t.Errorf("cleanup %+v, want %+v", got, want)
+	}
+	for _, rec := range []*core.Record{f.channel, f.member, f.msg} {
+		if _, ok := f.exists(rec); !ok {
+			t.Errorf("%s %s deleted despite keep_history", rec.Collection().Name, rec.Id)
+		}
+	}
+	claw, ok := f.exists(f.claw)
+	if !ok || claw.GetString("status") != "deleted" || claw.GetString("container_id") != "" {
+		t.Error("deployment not retired")
+	}
+	if _, ok := f.exists(f.inbox); ok {
+		t.Error("inbox kept")
+	}
+	f.audited(t)
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Claw events — structured activity reported by the claw itself
+// -----------------------------------------------------------------------------
+
+const (
+	// clawEventsPerClaw caps stored events per claw; older ones are pruned
+	// on insert.
+	clawEventsPerClaw = 500
+	// clawEventRetention is how long events are kept at all.
+	clawEventRetention = 30 * 24 * time.Hour
+	// clawErrorNotifyInterval throttles owner notifications for error events
+	// so a crash loop produces one message, not hundreds.
+	clawErrorNotifyInterval = 15 * time.Minute
+)
+
+const pbDateTimeLayout = "2006-01-02 15:04:05.000Z"
+
+type ClawEvent struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Detail   string `json:"detail,omitempty"`
+	Metadata any    `json:"metadata,omitempty"`
+	Created  string `json:"created"`
+}
+
+func recordToClawEvent(r *core.Record) ClawEvent {
+	ev := ClawEvent{
+		ID:      r.Id,
+		Type:    r.GetString("type"),
+		Title:   r.GetString("title"),
+		Detail:  r.GetString("detail"),
+		Created: recordTime(r, "created"),
+	}
+	if raw := r.GetString("metadata"); raw != "" && raw != "null" {
+		var v any
+		if json.Unmarshal([]byte(raw), &v) == nil {
+			ev.Metadata = v
+		}
+	}
+	return ev
+}
+
+type ReportClawEventInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT of the claw's agent" required:"true"`
+	ID            string `path:"id" doc:"Claw deployment ID"`
+	Body          struct {
+		Type     string         `json:"type" enum:"task_started,task_completed,error,info" doc:"Event type"`
+		Title    string         `json:"title" minLength:"1" maxLength:"200" doc:"One-line summary"`
+		Detail   string         `json:"detail,omitempty" maxLength:"5000" doc:"Longer description, stack trace, etc."`
+		Metadata map[string]any `json:"metadata,omitempty" doc:"Arbitrary JSON (task IDs, durations, links)"`
+		Notify   bool           `json:"notify,omitempty" doc:"For error events: also notify the owner (throttled to one per 15 minutes)"`
+	}
+}
+
+type ReportClawEventOutput struct {
+	Body struct {
+		Event    ClawEvent `json:"event"`
+		Notified bool      `json:"notified"`
+	}
+}
+
+type ListClawEventsInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
+	ID            string `path:"id" doc:"Claw deployment ID"`
+	Type          string `query:"type" enum:"task_started,task_completed,error,info" doc:"Only events of this type"`
+	Since         string `query:"since" doc:"Only events after this RFC3339 timestamp"`
+	Limit         int    `query:"limit" default:"50" minimum:"1" maximum:"200"`
+}
+
+type ListClawEventsOutput struct {
+	Body struct {
+		Events []ClawEvent `json:"events"`
+	}
+}
+
+func RegisterClawEventRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "report-claw-event",
+		Method:      "POST",
+		Path:        "/api/claws/{id}/events",
+		Summary:     "Report a claw event",
+		Description: "Called by the claw itself (authenticated with its own agent JWT) to record " +
+			"task progress, errors and other activity for its owner's timeline.",
+		Tags:          []string{"Claws"},
+		DefaultStatus: 201,
+	}, func(ctx context.Context, input *ReportClawEventInput) (*ReportClawEventOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		claw, err := app.FindRecordById("claw_deployments", input.ID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Claw not found")
+		}
+		if claw.GetString("agent_id") == "" || claw.GetString("agent_id") != claims.AgentID {
+			return nil, huma.Error403Forbidden("Only the claw's own agent can report events")
+		}
+
+		col, err := app.FindCollectionByNameOrId("claw_events")
+		if err != nil {
+			return nil, huma.Error500InternalServerError("claw_events collection not found")
+		}
+		record := core.NewRecord(col)
+		record.Set("claw_id", claw.Id)
+		record.Set("agent_id", claims.AgentID)
+		record.Set("type", input.Body.Type)
+		record.Set("title", input.Body.Title)
+		record.Set("detail", input.Body.Detail)
+		if input.Body.Metadata != nil {
+			record.Set("metadata", input.Body.Metadata)
+		}
+
+		notified := false
+		if input.Body.Type == "error" && input.Body.Notify && clawErrorNotifyAllowed(app, claw.Id) {
+			notified = notifyClawOwnerOfError(app, claw, input.Body.Title)
+		}
+		record.Set("notified", notified)
+
+		if err := app.Save(record); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to save event")
+		}
+		pruneClawEvents(app, claw.Id)
+
+		out := &ReportClawEventOutput{}
+		out.Body.Event = recordToClawEvent(record)
+		out.Body.Notified = notified
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-claw-events",
+		Method:      "GET",
+		Path:        "/api/claws/{id}/events",
+		Summary:     "List claw events",
+		Description: "Activity timeline reported by the claw, newest first. Owner and collaborators.",
+		Tags:        []string{"Claws"},
+	}, func(ctx context.Context, input *ListClawEventsInput) (*ListClawEventsOutput, error) {
+		claw, _, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleViewer)
+		if err != nil {
+			return nil, err
+		}
+
+		filter := "claw_id = {:cid}"
+		params := map[string]any{"cid": claw.Id}
+		if input.Type != "" {
+			filter += " && type = {:type}"
+			params["type"] = input.Type
+		}
+		if input.Since != "" {
+			since, err := parseSince(app, input.Since)
+			if err != nil {
+				return nil, huma.Error422UnprocessableEntity("since must be an RFC3339 timestamp")
+			}
+			filter += " && created > {:since}"
+			params["since"] = since.Format(pbDateTimeLayout)
+		}
+
+		records, _ := app.FindRecordsByFilter("claw_events", filter, "-created", input.Limit, 0, params)
+
+		out := &ListClawEventsOutput{}
+		out.Body.Events = make([]ClawEvent, 0, len(records))
+		for _, r := range records {
+			out.Body.Events = append(out.Body.Events, recordToClawEvent(r))
+		}
+		return out, nil
+	})
+}
+
+// clawErrorNotifyAllowed reports whether no error notification has gone out
+// for this claw within clawErrorNotifyInterval.
+func clawErrorNotifyAllowed(app *pocketbase.PocketBase, clawID string) bool {
+	cutoff := time.Now().UTC().Add(-clawErrorNotifyInterval).Format(pbDateTimeLayout)
+	recent, err := app.FindRecordsByFilter("claw_events",
+		"claw_id = {:cid} && notified = true && created > {:cutoff}", "", 1, 0,
+		map[string]any{"cid": clawID, "cutoff": cutoff})
+	return err == nil && len(recent) == 0
+}
+
+// notifyClawOwnerOfError posts a system message into the claw's channel, which
+// is where owners see claw status in the dashboard.
+func notifyClawOwnerOfError(app *pocketbase.PocketBase, claw *core.Record, title string) bool {
+	channelID, err := findClawChannel(app, claw.GetString("agent_id"))
+	if err != nil {
+		return false
+	}
+	col, err := app.FindCollectionByNameOrId("channel_messages")
+	if err != nil {
+		return false
+	}
+	rec := core.NewRecord(col)
+	rec.Set("channel_id", channelID)
+	rec.Set("author_id", "system")
+	rec.Set("body", fmt.Sprintf("%s reported an error: %s", claw.GetString("name"), title))
+	if err := app.Save(rec); err != nil {
+		app.Logger().Warn("Failed to notify claw owner of error", "claw", claw.Id, "error", err)
+		return false
+	}
+	return true
+}
+
+// pruneClawEvents keeps the newest clawEventsPerClaw events for a claw.
+func pruneClawEvents(app *pocketbase.PocketBase, clawID string) {
+	_, err := app.DB().NewQuery(
+		"DELETE FROM claw_events WHERE claw_id = {:cid} AND id NOT IN " +
+			"(SELECT id FROM claw_events WHERE claw_id = {:cid} ORDER BY created DESC LIMIT {:keep})").
+		Bind(map[string]any{"cid": clawID, "keep": clawEventsPerClaw}).Execute()
+	if err != nil {
+		app.Logger().Warn("Failed to prune claw events", "claw", clawID, "error", err)
+	}
+}
+
+// RegisterClawEventCleanupJob deletes events and activity entries older than
+// clawEventRetention, daily.
+func RegisterClawEventCleanupJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("claw_event_cleanup", 24*time.Hour, func(ctx context.Context) error {
+		return errors.Join(cleanOldClawEvents(ctx, app), cleanOldClawActivity(ctx, app))
+	}, jobs.RunOnStart())
+}
+
+func cleanOldClawEvents(ctx context.Context, app *pocketbase.PocketBase) error {
+	cutoff := time.Now().UTC().Add(-clawEventRetention).Format(pbDateTimeLayout)
+	_, err := app.DB().NewQuery("DELETE FROM claw_events WHERE created < {:cutoff}").
+		Bind(map[string]any{"cutoff": cutoff}).WithContext(ctx).Execute()
+	if err != nil {
+		return fmt.Errorf("clean old claw events: %w", err)
+	}
+	return nil
+}
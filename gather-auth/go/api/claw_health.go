@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Claw application health probes
+// -----------------------------------------------------------------------------
+
+// A container can be "running" while the agent inside is wedged (ADK crashed,
+// bridge hung). The checker probes each running claw's /health on the proxy
+// and records the result; the dashboard shows "unhealthy" distinct from
+// stopped.
+
+const (
+	clawHealthInterval = 1 * time.Minute
+	clawHealthTimeout  = 5 * time.Second
+	clawHealthWorkers  = 10
+
+	// clawUnhealthyAfter consecutive failed probes flip health_status.
+	clawUnhealthyAfter = 3
+	// clawAutoHealAfter consecutive failures trigger a restart when the
+	// owner has enabled auto_heal, at most once per clawAutoHealCooldown.
+	clawAutoHealAfter    = 5
+	clawAutoHealCooldown = 30 * time.Minute
+)
+
+var clawHealthClient = &http.Client{Timeout: clawHealthTimeout}
+
+// StartClawHealthChecker launches a background goroutine that probes running
+// claws every minute.
+func StartClawHealthChecker(app *pocketbase.PocketBase) {
+	go func() {
+		ticker := time.NewTicker(clawHealthInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			checkClawHealth(app)
+		}
+	}()
+	app.Logger().Info("Claw health checker started (1-minute tick)")
+}
+
+func checkClawHealth(app *pocketbase.PocketBase) {
+	records, err := app.FindRecordsByFilter("claw_deployments",
+		"status = 'running' && container_id != ''", "", 0, 0, nil)
+	if err != nil || len(records) == 0 {
+		return
+	}
+
+	sem := make(chan struct{}, clawHealthWorkers)
+	var wg sync.WaitGroup
+	for _, r := range records {
+		if clawResizing(r.Id) {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *core.Record) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			latency, probeErr := probeClaw(r.GetString("container_id"))
+			recordClawHealth(app, r.Id, latency, probeErr)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// probeClaw calls the claw proxy's /health, which checks the ADK server and
+// bridge behind it.
+func probeClaw(containerName string) (time.Duration, error) {
+	start := time.Now()
+	resp, err := clawHealthClient.Get(fmt.Sprintf("http://%s:8080/health", containerName))
+	latency := time.Since(start)
+	if err != nil {
+		return latency, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode != http.StatusOK {
+		return latency, fmt.Errorf("health returned %d: %s", resp.StatusCode, string(body))
+	}
+	return latency, nil
+}
+
+// recordClawHealth stores a probe result. The record is re-read so a settings
+// change made while probing isn't overwritten.
+func recordClawHealth(app *pocketbase.PocketBase, clawID string, latency time.Duration, probeErr error) {
+	r, err := app.FindRecordById("claw_deployments", clawID)
+	if err != nil || r.GetString("status") != "running" {
+		return
+	}
+
+	now := time.Now().UTC()
+	r.Set("last_health_at", now.Format(time.RFC3339))
+	r.Set("health_latency_ms", latency.Milliseconds())
+
+	failures := 0
+	if probeErr != nil {
+		failures = r.GetInt("health_failures") + 1
+	}
+	r.Set("health_failures", failures)
+
+	prevStatus := r.GetString("health_status")
+	switch {
+	case failures == 0:
+		r.Set("health_status", "healthy")
+	case failures >= clawUnhealthyAfter:
+		r.Set("health_status", "unhealthy")
+	}
+
+	heal := probeErr != nil && failures >= clawAutoHealAfter && r.GetBool("auto_heal") && autoHealAllowed(r, now)
+	if heal {
+		r.Set("last_auto_heal_at", now.Format(time.RFC3339))
+		r.Set("health_failures", 0)
+	}
+
+	if err := app.Save(r); err != nil {
+		app.Logger().Warn("Failed to record claw health", "claw", clawID, "error", err)
+		return
+	}
+
+	if prevStatus != "unhealthy" && r.GetString("health_status") == "unhealthy" {
+		app.Logger().Warn("Claw unhealthy", "claw", r.GetString("name"), "failures", failures, "error", probeErr)
+	}
+	if heal {
+		autoHealClaw(app, r, failures)
+	}
+}
+
+func autoHealAllowed(r *core.Record, now time.Time) bool {
+	last, err := time.Parse(time.RFC3339, r.GetString("last_auto_heal_at"))
+	return err != nil || now.Sub(last) >= clawAutoHealCooldown
+}
+
+// autoHealClaw restarts a wedged claw and tells the owner in its channel.
+func autoHealClaw(app *pocketbase.PocketBase, r *core.Record, failures int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	body := fmt.Sprintf("%s stopped responding (%d failed health checks) and was restarted automatically.", r.GetString("name"), failures)
+	if err := restartClawContainer(ctx, r.GetString("container_id")); err != nil {
+		app.Logger().Error("Auto-heal restart failed", "claw", r.GetString("name"), "error", err)
+		body = fmt.Sprintf("%s stopped responding (%d failed health checks). Automatic restart failed: %v", r.GetString("name"), failures, err)
+		RecordClawActivity(app, r.Id, ClawActivityRestart, "Automatic restart failed", err.Error(), map[string]any{"failures": failures})
+	} else {
+		app.Logger().Info("Auto-healed claw", "claw", r.GetString("name"), "failures", failures)
+		RecordClawActivity(app, r.Id, ClawActivityRestart, "Restarted automatically (health checks failing)", "", map[string]any{"failures": failures})
+	}
+
+	channelID, err := findClawChannel(app, r.GetString("agent_id"))
+	if err != nil {
+		return
+	}
+	col, err := app.FindCollectionByNameOrId("channel_messages")
+	if err != nil {
+		return
+	}
+	msg := core.NewRecord(col)
+	msg.Set("channel_id", channelID)
+	msg.Set("author_id", "system")
+	msg.Set("body", body)
+	if err := app.Save(msg); err != nil {
+		app.Logger().Warn("Failed to notify owner of auto-heal", "claw", r.Id, "error", err)
+	}
+}
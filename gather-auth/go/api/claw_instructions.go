@@ -0,0 +1,102 @@
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Owner instructions — delivered to the claw as a bootstrap file
+// -----------------------------------------------------------------------------
+
+// ClawInstructionsPath is where a claw finds its owner's deploy-time
+// instructions. The file only exists when there are instructions.
+const ClawInstructionsPath = "/app/data/INSTRUCTIONS.md"
+
+// ClawInstructionsNotice is added to the welcome message and first heartbeat
+// of a claw whose owner left instructions.
+const ClawInstructionsNotice = "Your owner left you instructions in " + ClawInstructionsPath + " — read them first and follow them."
+
+// clawInstructionsFile renders the instructions file. Returns nil for empty
+// instructions so callers remove the file instead of writing an empty one.
+func clawInstructionsFile(instructions string) []byte {
+	instructions = strings.TrimSpace(instructions)
+	if instructions == "" {
+		return nil
+	}
+	return []byte("# Instructions from your owner\n\n" + instructions + "\n")
+}
+
+// WriteClawInstructions copies the instructions file into the container, or
+// removes it when instructions are empty. Writing works on a created but not
+// yet started container, so provisioning can deliver the file before boot;
+// removal needs a running one.
+func WriteClawInstructions(ctx context.Context, cli *dockerclient.Client, containerID, instructions string) error {
+	content := clawInstructionsFile(instructions)
+	if content == nil {
+		_, err := execClawCommand(ctx, cli, containerID, "rm", "-f", ClawInstructionsPath)
+		return err
+	}
+
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	if err := tw.WriteHeader(&tar.Header{
+		Name: path.Base(ClawInstructionsPath),
+		Mode: 0644,
+		Size: int64(len(content)),
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(content); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return cli.CopyToContainer(ctx, containerID, path.Dir(ClawInstructionsPath)+"/", &tarBuf, container.CopyToContainerOptions{})
+}
+
+// redeliverClawInstructions writes the record's current instructions into
+// its running container and tells the agent, via inbox and its channel.
+func redeliverClawInstructions(ctx context.Context, app *pocketbase.PocketBase, record *core.Record) error {
+	cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	instructions := record.GetString("instructions")
+	if err := WriteClawInstructions(ctx, cli, record.GetString("container_id"), instructions); err != nil {
+		return err
+	}
+
+	agentID := record.GetString("agent_id")
+	body := fmt.Sprintf("Your owner updated your instructions. Re-read %s and adjust what you're doing.", ClawInstructionsPath)
+	if strings.TrimSpace(instructions) == "" {
+		body = fmt.Sprintf("Your owner cleared your instructions; %s has been removed. Carry on with your own judgement.", ClawInstructionsPath)
+	}
+	SendInboxMessage(app, agentID, "system", "Your instructions changed", body, "", "")
+
+	if channelID, err := findClawChannel(app, agentID); err == nil {
+		if col, err := app.FindCollectionByNameOrId("channel_messages"); err == nil {
+			msg := core.NewRecord(col)
+			msg.Set("channel_id", channelID)
+			msg.Set("author_id", "system")
+			msg.Set("body", body)
+			if err := app.Save(msg); err != nil {
+				app.Logger().Warn("Failed to post instructions notice", "claw", record.Id, "error", err)
+			}
+		}
+	}
+	return nil
+}
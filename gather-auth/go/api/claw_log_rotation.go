@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/pocketbase/pocketbase"
+)
+
+// -----------------------------------------------------------------------------
+// Claw container log rotation
+// -----------------------------------------------------------------------------
+
+// Claw containers log through the json-file driver with max-size and
+// max-file set (CLAW_LOG_MAX_SIZE, default 10m; CLAW_LOG_MAX_FILES, default
+// 3), so a chatty agent can't fill the host disk. Docker rotates
+// transparently, so log tails are unaffected. Containers created before this
+// have unbounded logs: the reaper reports them each pass, and an admin
+// recreates them with POST /api/admin/claws/log-rotation, which restarts each
+// claw for a few seconds and tells its owner.
+
+const (
+	defaultClawLogMaxSize  = "10m"
+	defaultClawLogMaxFiles = "3"
+)
+
+var (
+	clawLogSizePattern  = regexp.MustCompile(`^[1-9][0-9]*[kmg]?$`)
+	clawLogFilesPattern = regexp.MustCompile(`^[1-9][0-9]*$`)
+)
+
+// ClawLogConfig is the logging setup for claw containers. Malformed env
+// values fall back to the defaults rather than failing every provision.
+func ClawLogConfig() container.LogConfig {
+	size := strings.ToLower(strings.TrimSpace(os.Getenv("CLAW_LOG_MAX_SIZE")))
+	if !clawLogSizePattern.MatchString(size) {
+		size = defaultClawLogMaxSize
+	}
+	files := strings.TrimSpace(os.Getenv("CLAW_LOG_MAX_FILES"))
+	if !clawLogFilesPattern.MatchString(files) {
+		files = defaultClawLogMaxFiles
+	}
+	return container.LogConfig{
+		Type:   "json-file",
+		Config: map[string]string{"max-size": size, "max-file": files},
+	}
+}
+
+// clawLogsBounded reports whether a container's logging is capped.
+func clawLogsBounded(lc container.LogConfig) bool {
+	if lc.Type != "" && lc.Type != "json-file" && lc.Type != "local" {
+		return true // another driver: not kept on the host by Docker
+	}
+	return lc.Config["max-size"] != ""
+}
+
+type ClawLogRotationItem struct {
+	ClawID    string `json:"claw_id"`
+	Name      string `json:"name"`
+	Container string `json:"container"`
+	LogDriver string `json:"log_driver"`
+	Error     string `json:"error,omitempty" doc:"Recreate only: why this claw wasn't migrated"`
+}
+
+// findUnboundedClawLogs lists running claws whose container logs without a
+// size cap.
+func findUnboundedClawLogs(ctx context.Context, app *pocketbase.PocketBase) ([]ClawLogRotationItem, error) {
+	cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+
+	records, err := app.FindRecordsByFilter("claw_deployments", "status = 'running' && container_id != ''", "", 0, 0, nil)
+	if err != nil {
+		return nil, fmt.Errorf("load deployments: %w", err)
+	}
+
+	var out []ClawLogRotationItem
+	for _, r := range records {
+		if ctx.Err() != nil {
+			return out, ctx.Err()
+		}
+		name := r.GetString("container_id")
+		info, err := cli.ContainerInspect(ctx, name)
+		if err != nil || info.HostConfig == nil || (info.State != nil && !info.State.Running) {
+			continue // the health checker deals with missing containers
+		}
+		if clawLogsBounded(info.HostConfig.LogConfig) {
+			continue
+		}
+		driver := info.HostConfig.LogConfig.Type
+		if driver == "" {
+			driver = "json-file"
+		}
+		out = append(out, ClawLogRotationItem{ClawID: r.Id, Name: r.GetString("name"), Container: name, LogDriver: driver})
+	}
+	return out, nil
+}
+
+// reportUnboundedClawLogs logs the claws still without log rotation; run by
+// the reaper each pass.
+func reportUnboundedClawLogs(ctx context.Context, app *pocketbase.PocketBase) {
+	items, err := findUnboundedClawLogs(ctx, app)
+	if err != nil {
+		app.Logger().Warn("Claw log rotation: scan failed", "error", err)
+		return
+	}
+	if len(items) == 0 {
+		return
+	}
+	names := make([]string, len(items))
+	for i, it := range items {
+		names[i] = it.Container
+	}
+	app.Logger().Warn("Claw containers without log rotation; recreate with POST /api/admin/claws/log-rotation",
+		"count", len(items), "containers", names)
+}
+
+// recreateClawLogRotation recreates a claw's container with ClawLogConfig.
+func recreateClawLogRotation(ctx context.Context, containerName string) error {
+	cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+
+	return recreateClawContainer(ctx, cli, containerName, func(_ *container.Config, hostCfg *container.HostConfig, _ *network.NetworkingConfig) {
+		hostCfg.LogConfig = ClawLogConfig()
+	})
+}
+
+// -----------------------------------------------------------------------------
+// Admin routes
+// -----------------------------------------------------------------------------
+
+type ClawLogRotationInput struct {
+	AdminAuthHeader
+}
+
+type ClawLogRotationOutput struct {
+	Body struct {
+		Claws  []ClawLogRotationItem `json:"claws"`
+		DryRun bool                  `json:"dry_run"`
+	}
+}
+
+func registerClawLogRotationRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-list-claw-log-rotation",
+		Method:      "GET",
+		Path:        "/api/admin/claws/log-rotation",
+		Summary:     "List claws without log rotation (dry run)",
+		Description: "Running claw containers created without a log size cap — what POST on this path would recreate. Admin only.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *ClawLogRotationInput) (*ClawLogRotationOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+		items, err := findUnboundedClawLogs(ctx, app)
+		if err != nil {
+			return nil, huma.Error502BadGateway("Docker scan failed", err)
+		}
+		out := &ClawLogRotationOutput{}
+		out.Body.Claws = items
+		if out.Body.Claws == nil {
+			out.Body.Claws = []ClawLogRotationItem{}
+		}
+		out.Body.DryRun = true
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-apply-claw-log-rotation",
+		Method:      "POST",
+		Path:        "/api/admin/claws/log-rotation",
+		Summary:     "Recreate claws with log rotation",
+		Description: "Recreates each running claw container that has no log size cap, keeping its name, env, volumes and agent identity. " +
+			"Each claw is down for a few seconds and its owner is told why. A claw that fails keeps its old container; see error. Admin only.",
+		Tags: []string{"Admin"},
+	}, func(ctx context.Context, input *ClawLogRotationInput) (*ClawLogRotationOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+		items, err := findUnboundedClawLogs(ctx, app)
+		if err != nil {
+			return nil, huma.Error502BadGateway("Docker scan failed", err)
+		}
+
+		var migrated []string
+		for i := range items {
+			it := &items[i]
+			// Shares the resize guard, so the health checker leaves the
+			// briefly stopped container alone.
+			if _, busy := clawResizes.LoadOrStore(it.ClawID, struct{}{}); busy {
+				it.Error = "claw is being resized"
+				continue
+			}
+			rctx, cancel := context.WithTimeout(context.Background(), clawResizeTimeout)
+			err := recreateClawLogRotation(rctx, it.Container)
+			cancel()
+			clawResizes.Delete(it.ClawID)
+			if err != nil {
+				it.Error = err.Error()
+				app.Logger().Warn("Claw log rotation: recreate failed", "claw", it.ClawID, "container", it.Container, "error", err)
+				continue
+			}
+			migrated = append(migrated, it.Container)
+
+			RecordClawActivity(app, it.ClawID, ClawActivityStatus, "Restarted to cap log size", "", nil)
+			if claw, err := app.FindRecordById("claw_deployments", it.ClawID); err == nil && claw.GetString("user_id") != "" {
+				SendInboxMessage(app, "user:"+claw.GetString("user_id"), "claw_maintenance",
+					fmt.Sprintf("%s was restarted for maintenance", it.Name),
+					fmt.Sprintf("%s was restarted at %s so its container logs are rotated and can't fill the host's disk. "+
+						"Its data, settings and identity are unchanged; older log lines beyond the new size cap are no longer kept.",
+						it.Name, time.Now().UTC().Format(time.RFC3339)),
+					"claw", it.ClawID)
+			}
+		}
+		if len(migrated) > 0 {
+			if err := recordAdminAudit(app, admin.Id, "claw.log_rotation", "claw_container", "", map[string]any{"containers": migrated}); err != nil {
+				app.Logger().Warn("Failed to audit claw log rotation", "error", err)
+			}
+		}
+
+		out := &ClawLogRotationOutput{}
+		out.Body.Claws = items
+		if out.Body.Claws == nil {
+			out.Body.Claws = []ClawLogRotationItem{}
+		}
+		return out, nil
+	})
+}
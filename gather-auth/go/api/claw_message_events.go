@@ -0,0 +1,195 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// -----------------------------------------------------------------------------
+// Persisted claw tool-call events — what the agent did to produce a reply,
+// stored with the reply in channel_messages.events and returned by
+// GET /api/claws/{id}/messages?expand=events
+// -----------------------------------------------------------------------------
+
+const (
+	// clawMsgEventsMaxBytes caps the stored events JSON per message.
+	clawMsgEventsMaxBytes = 50 << 10
+
+	// clawEventTextMax and clawEventValueMax clip a single event's text and
+	// tool args/result, so one huge tool output can't crowd out the rest.
+	clawEventTextMax  = 2000
+	clawEventValueMax = 4 << 10
+
+	// clawStreamEventsKeep bounds how many events a stream holds in memory
+	// at each end; the middle of a very long run is counted, not kept.
+	clawStreamEventsKeep = 200
+
+	// clawStreamLineMax drops an SSE line that grows past this without a
+	// newline rather than buffering it indefinitely.
+	clawStreamLineMax = 1 << 20
+)
+
+// clipADKEvent shortens an event's text and tool payloads to their caps.
+func clipADKEvent(e adkEvent) adkEvent {
+	if len(e.Text) > clawEventTextMax {
+		e.Text = e.Text[:clawEventTextMax] + "… [truncated]"
+	}
+	e.ToolArgs = clipEventValue(e.ToolArgs)
+	e.Result = clipEventValue(e.Result)
+	return e
+}
+
+func clipEventValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil || len(raw) <= clawEventValueMax {
+		return v
+	}
+	return string(raw[:clawEventValueMax]) + "… [truncated]"
+}
+
+// truncatedEvent marks where events were dropped.
+func truncatedEvent(dropped int) adkEvent {
+	return adkEvent{Type: "truncated", Text: fmt.Sprintf("%d events omitted", dropped)}
+}
+
+// encodeClawMsgEvents serializes a reply's events for storage within
+// clawMsgEventsMaxBytes. When they don't fit, the first and last events are
+// kept, then more from both ends while they fit, and a "truncated" event in
+// the middle says how many were dropped. gap counts events already dropped
+// between head and tail (see clawStreamEvents); pass nil, 0 for a complete
+// list. Returns nil if there are no events.
+func encodeClawMsgEvents(head, tail []adkEvent, gap int) []byte {
+	events := make([]adkEvent, 0, len(head)+len(tail))
+	events = append(events, head...)
+	events = append(events, tail...)
+	if len(events) == 0 {
+		return nil
+	}
+	sizes := make([]int, len(events))
+	total := 2 // []
+	for i, e := range events {
+		events[i] = clipADKEvent(e)
+		raw, _ := json.Marshal(events[i])
+		sizes[i] = len(raw) + 1 // comma
+		total += sizes[i]
+	}
+	if gap == 0 && total <= clawMsgEventsMaxBytes {
+		raw, _ := json.Marshal(events)
+		return raw
+	}
+
+	// Reserve room for the marker, then take from the front and back in
+	// turn. An existing gap must stay inside the dropped range.
+	marker, _ := json.Marshal(truncatedEvent(gap + len(events)))
+	budget := clawMsgEventsMaxBytes - 2 - len(marker) - 1
+	frontMax, backMin := len(events), 0
+	if gap > 0 {
+		frontMax, backMin = len(head), len(head)
+	}
+	front, back := 0, len(events)
+	for fromBack := false; front < back; fromBack = !fromBack {
+		canFront, canBack := front < frontMax, back > backMin
+		if !canFront && !canBack {
+			break
+		}
+		if fromBack && !canBack || !fromBack && !canFront {
+			fromBack = !fromBack
+		}
+		i := front
+		if fromBack {
+			i = back - 1
+		}
+		if sizes[i] > budget {
+			break
+		}
+		budget -= sizes[i]
+		if fromBack {
+			back--
+		} else {
+			front++
+		}
+	}
+
+	dropped := gap + back - front
+	kept := make([]adkEvent, 0, front+1+len(events)-back)
+	kept = append(kept, events[:front]...)
+	if dropped > 0 {
+		kept = append(kept, truncatedEvent(dropped))
+	}
+	kept = append(kept, events[back:]...)
+	raw, _ := json.Marshal(kept)
+	return raw
+}
+
+// decodeClawMsgEvents reads stored events back; nil if there are none.
+func decodeClawMsgEvents(raw string) []adkEvent {
+	if raw == "" || raw == "null" {
+		return nil
+	}
+	var events []adkEvent
+	if err := json.Unmarshal([]byte(raw), &events); err != nil {
+		return nil
+	}
+	return events
+}
+
+// clawStreamEvents is an io.Writer that picks ADK events out of a relayed
+// SSE stream. It keeps the first and last clawStreamEventsKeep events and
+// counts the rest, so memory stays bounded however long the run is.
+type clawStreamEvents struct {
+	partial []byte
+	head    []adkEvent
+	tail    []adkEvent
+	dropped int
+}
+
+func (c *clawStreamEvents) Write(p []byte) (int, error) {
+	c.partial = append(c.partial, p...)
+	for {
+		nl := bytes.IndexByte(c.partial, '\n')
+		if nl < 0 {
+			break
+		}
+		c.line(bytes.TrimRight(c.partial[:nl], "\r"))
+		c.partial = c.partial[nl+1:]
+	}
+	if len(c.partial) > clawStreamLineMax {
+		c.partial = nil
+	}
+	return len(p), nil
+}
+
+func (c *clawStreamEvents) line(line []byte) {
+	data, ok := bytes.CutPrefix(line, []byte("data: "))
+	if !ok {
+		return
+	}
+	var e adkEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		return
+	}
+	switch e.Type {
+	case "text", "tool_call", "tool_result":
+	default:
+		return // end, error and relay bookkeeping aren't part of the run
+	}
+	e = clipADKEvent(e)
+	if len(c.head) < clawStreamEventsKeep {
+		c.head = append(c.head, e)
+		return
+	}
+	c.tail = append(c.tail, e)
+	if len(c.tail) > clawStreamEventsKeep {
+		c.tail = c.tail[1:]
+		c.dropped++
+	}
+}
+
+// Encoded returns the collected events in storage form.
+func (c *clawStreamEvents) Encoded() []byte {
+	return encodeClawMsgEvents(c.head, c.tail, c.dropped)
+}
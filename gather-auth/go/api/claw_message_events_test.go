@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+func toolEvents(n int) []adkEvent {
+	events := make([]adkEvent, n)
+	for i := range events {
+		events[i] = adkEvent{Type: "tool_call", ToolName: fmt.Sprintf("tool_%d", i), Text: strings.Repeat("x", 200)}
+	}
+	return events
+}
+
+// checkKeptEnds decodes stored events and checks that they keep the first
+// and last of total events, with a truncated marker accounting for the rest.
+func checkKeptEnds(t *testing.T, raw []byte, total int) []adkEvent {
+	t.Helper()
+	if len(raw) > clawMsgEventsMaxBytes {
+		t.Errorf("stored %d bytes, cap %d", len(raw), clawMsgEventsMaxBytes)
+	}
+	kept := decodeClawMsgEvents(string(raw))
+	if len(kept) < 3 {
+		t.Fatalf("kept %d events", len(kept))
+	}
+	if kept[0].ToolName != "tool_0" || kept[len(kept)-1].ToolName != fmt.Sprintf("tool_%d", total-1) {
+		t.Errorf("ends %s … %s", kept[0].ToolName, kept[len(kept)-1].ToolName)
+	}
+	markers, dropped := 0, 0
+	for _, e := range kept {
+		if e.Type == "truncated" {
+			markers++
+			fmt.Sscanf(e.Text, "%d events omitted", &dropped)
+		}
+	}
+	if markers != 1 || dropped+len(kept)-1 != total {
+		t.Errorf("%d markers, %d dropped + %d kept of %d", markers, dropped, len(kept)-1, total)
+	}
+	return kept
+}
+
+func TestEncodeClawMsgEvents(t *testing.T) {
+	if encodeClawMsgEvents(nil, nil, 0) != nil {
+		t.Error("no events encoded as non-nil")
+	}
+
+	small := toolEvents(5)
+	if got := decodeClawMsgEvents(string(encodeClawMsgEvents(small, nil, 0))); len(got) != 5 {
+		t.Errorf("kept %d of 5 events", len(got))
+	}
+
+	checkKeptEnds(t, encodeClawMsgEvents(toolEvents(1000), nil, 0), 1000)
+
+	// One huge tool result is clipped rather than pushing everything out
+	huge := []adkEvent{{Type: "tool_result", ToolName: "read", Result: strings.Repeat("y", 200<<10)}}
+	got := decodeClawMsgEvents(string(encodeClawMsgEvents(huge, nil, 0)))
+	if len(got) != 1 || !strings.HasSuffix(got[0].Result.(string), "[truncated]") {
+		t.Errorf("huge result stored as %d events", len(got))
+	}
+}
+
+func TestClawStreamEvents(t *testing.T) {
+	const total = 2*clawStreamEventsKeep + 50
+	var sse strings.Builder
+	sse.WriteString("event: start\ndata: {\"type\":\"start\"}\n\n")
+	for i, e := range toolEvents(total) {
+		raw, _ := json.Marshal(e)
+		fmt.Fprintf(&sse, "id: %d\r\ndata: %s\r\n\r\n", i, raw)
+	}
+	sse.WriteString("data: {\"type\":\"end\",\"text\":\"done\"}\n\n")
+
+	// Written in chunks that split lines
+	c := &clawStreamEvents{}
+	stream := sse.String()
+	for len(stream) > 0 {
+		n := min(len(stream), 37)
+		c.Write([]byte(stream[:n]))
+		stream = stream[n:]
+	}
+	if len(c.head) != clawStreamEventsKeep || len(c.tail) != clawStreamEventsKeep || c.dropped != 50 {
+		t.Errorf("head %d, tail %d, dropped %d", len(c.head), len(c.tail), c.dropped)
+	}
+	checkKeptEnds(t, c.Encoded(), total)
+}
+
+func TestClawMessagesExpandEvents(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "claw_deployments", "user_id", "agent_id", "status")
+	addCollection(t, app, "channel_members", "channel_id", "agent_id", "role")
+	addCollection(t, app, "channel_messages", "channel_id", "author_id", "body", "events:json")
+	addCollection(t, app, "agents", "name")
+	user, auth := addUser(t, app, "owner@example.com")
+	agent := addRecord(t, app, "agents", map[string]any{"name": "clawbot"})
+	claw := addRecord(t, app, "claw_deployments", map[string]any{"user_id": user.Id, "agent_id": agent.Id, "status": "running"})
+	addRecord(t, app, "channel_members", map[string]any{"channel_id": "ch1", "agent_id": agent.Id, "role": "owner"})
+	addRecord(t, app, "channel_messages", map[string]any{
+		"channel_id": "ch1", "author_id": agent.Id, "body": "done",
+		"events": string(encodeClawMsgEvents(toolEvents(3), nil, 0)),
+	})
+
+	_, api := humatest.New(t)
+	RegisterClawRoutes(api, app)
+
+	for _, tc := range []struct {
+		query string
+		want  int
+	}{{"", 0}, {"?expand=events", 3}} {
+		resp := api.Get("/api/claws/"+claw.Id+"/messages"+tc.query, auth)
+		var out struct {
+			Messages []ClawMessage `json:"messages"`
+		}
+		decodeBody(t, resp, &out)
+		if len(out.Messages) != 1 || len(out.Messages[0].Events) != tc.want {
+			t.Errorf("%q: %d messages, events %v", tc.query, len(out.Messages), out.Messages)
+		}
+		if tc.want == 0 && strings.Contains(resp.Body.String(), `"events"`) {
+			t.Errorf("%q: events in the default payload", tc.query)
+		}
+	}
+}
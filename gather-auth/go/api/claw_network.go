@@ -0,0 +1,301 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Claw network policy — what a claw container may reach
+// -----------------------------------------------------------------------------
+
+// The policy is enforced by the Docker network the container joins, not by
+// anything inside it:
+//
+//   - open: the shared platform network, with full internet egress (what every
+//     claw had before policies existed).
+//   - platform_only: only the internal claw network, which has no route out.
+//     Traefik and gather-auth sit on it too, so the claw is still reachable
+//     and can use the platform API and LLM proxy, but nothing else.
+//   - allowlist: the internal network plus HTTP(S)_PROXY pointing at the
+//     egress proxy (CLAW_EGRESS_PROXY_URL), which only lets the allowlisted
+//     domains through. Refused until a proxy is configured.
+//
+// New lite claws default to platform_only; other tiers default to open.
+// Claws provisioned before policies existed have none stored and are open.
+
+const (
+	ClawNetworkOpen         = "open"
+	ClawNetworkPlatformOnly = "platform_only"
+	ClawNetworkAllowlist    = "allowlist"
+
+	clawEgressAllowlistMax = 50
+)
+
+// ClawNetworkPolicies are the allowed network_policy values.
+var ClawNetworkPolicies = []string{ClawNetworkOpen, ClawNetworkPlatformOnly, ClawNetworkAllowlist}
+
+// clawNetworkEnvKeys are the env vars a network policy owns. They are reset
+// from the policy on every provision and policy change.
+var clawNetworkEnvKeys = []string{
+	"GATHER_BASE_URL", "GATHER_NETWORK_POLICY", "GATHER_EGRESS_ALLOWLIST", "GATHER_HTTP_PROXY",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+}
+
+// ClawInternalNetwork is the Docker network without internet egress that
+// platform_only and allowlist claws join.
+func ClawInternalNetwork() string {
+	if n := os.Getenv("CLAW_INTERNAL_NETWORK"); n != "" {
+		return n
+	}
+	return "gather-infra_claw_internal"
+}
+
+func clawEgressProxyURL() string {
+	return os.Getenv("CLAW_EGRESS_PROXY_URL")
+}
+
+// DefaultClawNetworkPolicy is the policy a new claw of clawType gets when the
+// deploy request doesn't pick one.
+func DefaultClawNetworkPolicy(clawType string) string {
+	if NormalizeClawType(clawType) == "lite" {
+		return ClawNetworkPlatformOnly
+	}
+	return ClawNetworkOpen
+}
+
+// EffectiveClawNetworkPolicy is the policy a claw deployment runs under.
+func EffectiveClawNetworkPolicy(r *core.Record) string {
+	if p := r.GetString("network_policy"); p != "" {
+		return p
+	}
+	return ClawNetworkOpen
+}
+
+// ClawEgressAllowlist returns the domains an allowlist claw may reach.
+func ClawEgressAllowlist(r *core.Record) []string {
+	var domains []string
+	r.UnmarshalJSONField("egress_allowlist", &domains)
+	return domains
+}
+
+// normalizeClawNetworkPolicy checks a requested policy and allowlist,
+// returning them canonicalized. The allowlist is only kept for allowlist.
+func normalizeClawNetworkPolicy(policy string, domains []string) (string, []string, error) {
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if !containsString(ClawNetworkPolicies, policy) {
+		return "", nil, fmt.Errorf("network_policy must be one of: %s", strings.Join(ClawNetworkPolicies, ", "))
+	}
+	if policy != ClawNetworkAllowlist {
+		if len(domains) > 0 {
+			return "", nil, errors.New("egress_allowlist only applies to the allowlist network_policy")
+		}
+		return policy, []string{}, nil
+	}
+	if clawEgressProxyURL() == "" {
+		return "", nil, errors.New("allowlist egress isn't available on this server yet; use platform_only or open")
+	}
+
+	out := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(d)), ".")
+		if !validEgressDomain(d) {
+			return "", nil, fmt.Errorf("%q is not a domain (use example.com or *.example.com)", d)
+		}
+		if !containsString(out, d) {
+			out = append(out, d)
+		}
+	}
+	if len(out) == 0 {
+		return "", nil, errors.New("egress_allowlist needs at least one domain for the allowlist policy")
+	}
+	if len(out) > clawEgressAllowlistMax {
+		return "", nil, fmt.Errorf("egress_allowlist may have at most %d domains", clawEgressAllowlistMax)
+	}
+	sort.Strings(out)
+	return policy, out, nil
+}
+
+// validEgressDomain accepts a hostname with at least two labels, optionally
+// with a leading "*." wildcard.
+func validEgressDomain(d string) bool {
+	d = strings.TrimPrefix(d, "*.")
+	if len(d) == 0 || len(d) > 253 || !strings.Contains(d, ".") {
+		return false
+	}
+	for _, label := range strings.Split(d, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ClawNetworkSetup is how a network policy is applied to a claw container:
+// the one network it joins and the env the policy owns.
+type ClawNetworkSetup struct {
+	Policy  string
+	Network string
+	Env     map[string]string
+}
+
+// ClawNetworkSetupFor returns the container setup for a policy.
+func ClawNetworkSetupFor(policy string, allowlist []string) ClawNetworkSetup {
+	if policy == ClawNetworkOpen {
+		baseURL := os.Getenv("GATHER_BASE_URL")
+		if baseURL == "" {
+			baseURL = "https://gather.is"
+		}
+		return ClawNetworkSetup{
+			Policy:  policy,
+			Network: ClawDockerNetwork(),
+			Env: map[string]string{
+				"GATHER_BASE_URL":       baseURL,
+				"GATHER_NETWORK_POLICY": policy,
+			},
+		}
+	}
+
+	// The public URL isn't reachable without egress; talk to gather-auth
+	// directly, as the LLM proxy already does.
+	setup := ClawNetworkSetup{
+		Policy:  policy,
+		Network: ClawInternalNetwork(),
+		Env: map[string]string{
+			"GATHER_BASE_URL":       "http://gather-auth:8090",
+			"GATHER_NETWORK_POLICY": policy,
+		},
+	}
+	if policy == ClawNetworkAllowlist {
+		proxy := clawEgressProxyURL()
+		noProxy := "gather-auth,localhost,127.0.0.1"
+		for k, v := range map[string]string{
+			"GATHER_HTTP_PROXY": proxy, "HTTP_PROXY": proxy, "HTTPS_PROXY": proxy, "NO_PROXY": noProxy,
+			"http_proxy": proxy, "https_proxy": proxy, "no_proxy": noProxy,
+			"GATHER_EGRESS_ALLOWLIST": strings.Join(allowlist, ","),
+		} {
+			setup.Env[k] = v
+		}
+	}
+	return setup
+}
+
+// ClawNetworkSetupForRecord returns the container setup for a deployment's
+// effective policy.
+func ClawNetworkSetupForRecord(r *core.Record) ClawNetworkSetup {
+	return ClawNetworkSetupFor(EffectiveClawNetworkPolicy(r), ClawEgressAllowlist(r))
+}
+
+// applyClawNetworkEnv replaces the policy-owned vars in env with setup's.
+func applyClawNetworkEnv(env []string, setup ClawNetworkSetup) []string {
+	out := make([]string, 0, len(env)+len(setup.Env))
+	for _, kv := range env {
+		k, _, _ := strings.Cut(kv, "=")
+		if !containsString(clawNetworkEnvKeys, k) {
+			out = append(out, kv)
+		}
+	}
+	keys := make([]string, 0, len(setup.Env))
+	for k := range setup.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		out = append(out, k+"="+setup.Env[k])
+	}
+	return out
+}
+
+// recreateClawNetwork moves a running claw container onto setup's network
+// and env, keeping everything else.
+func recreateClawNetwork(ctx context.Context, containerName string, setup ClawNetworkSetup) error {
+	cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+
+	return recreateClawContainer(ctx, cli, containerName, func(cfg *container.Config, hostCfg *container.HostConfig, netCfg *network.NetworkingConfig) {
+		cfg.Env = applyClawNetworkEnv(cfg.Env, setup)
+		labels := make(map[string]string, len(cfg.Labels)+1)
+		for k, v := range cfg.Labels {
+			labels[k] = v
+		}
+		labels["traefik.docker.network"] = setup.Network
+		cfg.Labels = labels
+
+		hostCfg.NetworkMode = container.NetworkMode(setup.Network)
+		netCfg.EndpointsConfig = map[string]*network.EndpointSettings{setup.Network: {}}
+	})
+}
+
+// updateClawNetworkPolicy applies a settings change to a deployment's network
+// policy; nil arguments keep the current value. A running claw is recreated on
+// the new network first, and the record only changes once that worked. The
+// caller saves the record.
+func updateClawNetworkPolicy(app *pocketbase.PocketBase, record *core.Record, policyIn *string, allowlistIn []string) error {
+	current := EffectiveClawNetworkPolicy(record)
+	policy := current
+	if policyIn != nil {
+		policy = *policyIn
+	}
+	allowlist := allowlistIn
+	if allowlist == nil && strings.EqualFold(strings.TrimSpace(policy), ClawNetworkAllowlist) {
+		allowlist = ClawEgressAllowlist(record)
+	}
+	policy, allowlist, err := normalizeClawNetworkPolicy(policy, allowlist)
+	if err != nil {
+		return huma.Error422UnprocessableEntity(err.Error())
+	}
+	changed := policy != current || !slices.Equal(allowlist, ClawEgressAllowlist(record))
+
+	if changed && record.GetString("status") == "running" && record.GetString("container_id") != "" {
+		if _, busy := clawResizes.LoadOrStore(record.Id, struct{}{}); busy {
+			return huma.Error409Conflict("The claw's container is already being recreated; try again in a minute")
+		}
+		defer clawResizes.Delete(record.Id)
+
+		// Not the request context, as for a resize: the container must not
+		// be abandoned half-recreated.
+		ctx, cancel := context.WithTimeout(context.Background(), clawResizeTimeout)
+		defer cancel()
+
+		title := fmt.Sprintf("Network policy %s → %s", current, policy)
+		if err := recreateClawNetwork(ctx, record.GetString("container_id"), ClawNetworkSetupFor(policy, allowlist)); err != nil {
+			app.Logger().Error("Claw network policy change failed", "claw", record.Id, "from", current, "to", policy, "error", err)
+			if isResizeRollbackFailure(err) {
+				record.Set("status", "failed")
+				record.Set("error_message", truncate(fmt.Sprintf("Network policy change to %s failed: %v", policy, err), 500))
+				if err := app.Save(record); err != nil {
+					app.Logger().Error("Failed to record network policy error", "claw", record.Id, "error", err)
+				}
+			}
+			RecordClawActivity(app, record.Id, ClawActivityStatus, title+" failed", err.Error(), nil)
+			return huma.Error500InternalServerError(fmt.Sprintf("Changing the network policy failed: %v", err))
+		}
+		RecordClawActivity(app, record.Id, ClawActivityStatus, title, "", map[string]any{
+			"from": current, "to": policy, "egress_allowlist": allowlist,
+		})
+	}
+
+	record.Set("network_policy", policy)
+	record.Set("egress_allowlist", allowlist)
+	return nil
+}
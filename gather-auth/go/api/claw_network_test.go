@@ -0,0 +1,194 @@
+package api
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeClawNetworkPolicy(t *testing.T) {
+	cases := []struct {
+		name       string
+		proxy      string
+		policy     string
+		domains    []string
+		wantPolicy string
+		want       []string
+		wantErr    string
+	}{
+		{"open", "", "open", nil, "open", []string{}, ""},
+		{"case and space", "", " Platform_Only ", nil, "platform_only", []string{}, ""},
+		{"unknown", "", "closed", nil, "", nil, "must be one of"},
+		{"empty", "", "", nil, "", nil, "must be one of"},
+		{"domains without allowlist", "", "open", []string{"example.com"}, "", nil, "only applies"},
+		{"allowlist without proxy", "", "allowlist", []string{"example.com"}, "", nil, "isn't available"},
+		{"allowlist", "http://egress:3128", "allowlist",
+			[]string{" API.Example.com. ", "*.cdn.net", "api.example.com"},
+			"allowlist", []string{"*.cdn.net", "api.example.com"}, ""},
+		{"allowlist without domains", "http://egress:3128", "allowlist", nil, "", nil, "at least one"},
+		{"bad domain", "http://egress:3128", "allowlist", []string{"https://example.com"}, "", nil, "not a domain"},
+		{"too many", "http://egress:3128", "allowlist", manyDomains(clawEgressAllowlistMax + 1), "", nil, "at most"},
+		{"at the cap", "http://egress:3128", "allowlist", manyDomains(clawEgressAllowlistMax), "allowlist", nil, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("CLAW_EGRESS_PROXY_URL", tc.proxy)
+			policy, domains, err := normalizeClawNetworkPolicy(tc.policy, tc.domains)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("err = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if policy != tc.wantPolicy {
+				t.Errorf("policy %q, want %q", policy, tc.wantPolicy)
+			}
+			if tc.want != nil && !slices.Equal(domains, tc.want) {
+				t.Errorf("domains %q, want %q", domains, tc.want)
+			}
+		})
+	}
+}
+
+func manyDomains(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = "host" + strings.Repeat("a", i) + ".example.com"
+	}
+	return out
+}
+
+func TestValidEgressDomain(t *testing.T) {
+	cases := map[string]bool{
+		"example.com":                          true,
+		"api.example.co.uk":                    true,
+		"*.example.com":                        true,
+		"a-b.example.com":                      true,
+		"x1.example.com":                       true,
+		"":                                     false,
+		"localhost":                            false,
+		"*.com":                                false,
+		"*.":                                   false,
+		"example..com":                         false,
+		".example.com":                         false,
+		"-bad.example.com":                     false,
+		"bad-.example.com":                     false,
+		"api.*.example.com":                    false,
+		"Example.com":                          false, // callers lowercase first
+		"example.com:443":                      false,
+		"https://example.com":                  false,
+		"exa mple.com":                         false,
+		strings.Repeat("a", 64) + ".com":       false,
+		strings.Repeat("a", 63) + ".com":       true,
+		strings.Repeat("abcdefg.", 32) + "com": false,
+	}
+	for d, want := range cases {
+		if got := validEgressDomain(d); got != want {
+			t.Errorf("validEgressDomain(%q) = %v, want %v", d, got, want)
+		}
+	}
+}
+
+func TestClawNetworkSetupFor(t *testing.T) {
+	t.Setenv("CLAW_EGRESS_PROXY_URL", "http://egress:3128")
+	t.Setenv("CLAW_INTERNAL_NETWORK", "internal")
+	t.Setenv("GATHER_BASE_URL", "https://gather.test")
+
+	open := ClawNetworkSetupFor(ClawNetworkOpen, nil)
+	if open.Network != ClawDockerNetwork() || open.Env["GATHER_BASE_URL"] != "https://gather.test" || open.Env["HTTP_PROXY"] != "" {
+		t.Errorf("open: %+v", open)
+	}
+
+	platform := ClawNetworkSetupFor(ClawNetworkPlatformOnly, nil)
+	if platform.Network != "internal" || platform.Env["GATHER_BASE_URL"] != "http://gather-auth:8090" || platform.Env["HTTP_PROXY"] != "" {
+		t.Errorf("platform_only: %+v", platform)
+	}
+
+	allow := ClawNetworkSetupFor(ClawNetworkAllowlist, []string{"a.com", "*.b.com"})
+	if allow.Network != "internal" || allow.Env["HTTPS_PROXY"] != "http://egress:3128" ||
+		allow.Env["GATHER_HTTP_PROXY"] != "http://egress:3128" || allow.Env["GATHER_EGRESS_ALLOWLIST"] != "a.com,*.b.com" ||
+		!strings.Contains(allow.Env["NO_PROXY"], "gather-auth") {
+		t.Errorf("allowlist: %+v", allow)
+	}
+	for k := range allow.Env {
+		if !containsString(clawNetworkEnvKeys, k) {
+			t.Errorf("allowlist sets %s, which a policy change wouldn't reset", k)
+		}
+	}
+}
+
+func TestApplyClawNetworkEnv(t *testing.T) {
+	t.Setenv("CLAW_EGRESS_PROXY_URL", "http://egress:3128")
+	env := []string{"CLAW_NAME=pal", "HTTP_PROXY=http://old", "https_proxy=http://old", "GATHER_NETWORK_POLICY=allowlist", "TZ=UTC"}
+
+	got := applyClawNetworkEnv(env, ClawNetworkSetupFor(ClawNetworkPlatformOnly, nil))
+	want := []string{"CLAW_NAME=pal", "TZ=UTC", "GATHER_BASE_URL=http://gather-auth:8090", "GATHER_NETWORK_POLICY=platform_only"}
+	if !slices.Equal(got, want) {
+		t.Errorf("env %q, want %q", got, want)
+	}
+}
+
+func TestUpdateClawNetworkPolicyStopped(t *testing.T) {
+	t.Setenv("CLAW_EGRESS_PROXY_URL", "http://egress:3128")
+	app := newTestApp(t)
+	addCollection(t, app, "claw_deployments", "name", "status", "container_id", "network_policy", "egress_allowlist:json")
+	record := addRecord(t, app, "claw_deployments", map[string]any{"name": "pal", "status": "stopped"})
+
+	// No stored policy: the claw predates policies and is open
+	if got := EffectiveClawNetworkPolicy(record); got != ClawNetworkOpen {
+		t.Errorf("effective policy %q", got)
+	}
+
+	policy := "allowlist"
+	if err := updateClawNetworkPolicy(app, record, &policy, []string{"Example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if EffectiveClawNetworkPolicy(record) != ClawNetworkAllowlist || !slices.Equal(ClawEgressAllowlist(record), []string{"example.com"}) {
+		t.Errorf("after update: %s %q", EffectiveClawNetworkPolicy(record), ClawEgressAllowlist(record))
+	}
+
+	// Changing only the domains keeps the policy; nil domains keep the list
+	if err := updateClawNetworkPolicy(app, record, nil, []string{"other.org"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateClawNetworkPolicy(app, record, &policy, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ClawEgressAllowlist(record), []string{"other.org"}) {
+		t.Errorf("allowlist %q", ClawEgressAllowlist(record))
+	}
+
+	bad := "closed"
+	if err := updateClawNetworkPolicy(app, record, &bad, nil); apiStatus(err) != 422 {
+		t.Errorf("bad policy: %v", err)
+	}
+	if EffectiveClawNetworkPolicy(record) != ClawNetworkAllowlist {
+		t.Error("refused change altered the record")
+	}
+
+	// Leaving allowlist drops the domains
+	open := "open"
+	if err := updateClawNetworkPolicy(app, record, &open, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(ClawEgressAllowlist(record)) != 0 {
+		t.Errorf("allowlist %q kept under open", ClawEgressAllowlist(record))
+	}
+}
+
+func TestDefaultClawNetworkPolicy(t *testing.T) {
+	cases := map[string]string{
+		"":         ClawNetworkPlatformOnly,
+		"lite":     ClawNetworkPlatformOnly,
+		"picoclaw": ClawNetworkPlatformOnly,
+		"pro":      ClawNetworkOpen,
+	}
+	for clawType, want := range cases {
+		if got := DefaultClawNetworkPolicy(clawType); got != want {
+			t.Errorf("%q: %q, want %q", clawType, got, want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Claw onboarding — first-run checklist on the deployment
+// -----------------------------------------------------------------------------
+
+// A new claw's onboarding field records when it first reached each milestone,
+// as {"milestone": RFC3339 time}. Milestones are stamped from code paths that
+// already run: issuing a JWT, saving a channel message, a heartbeat reply,
+// saving env. Each stamp is a conditional UPDATE that only touches a claw
+// missing the milestone, so repeats cost one no-op statement, and agent-keyed
+// ones are skipped in memory after the first. A claw running for an hour
+// without authenticating gets its owner one reminder.
+
+const (
+	ClawMilestoneProvisioned      = "provisioned"
+	ClawMilestoneAuthenticated    = "authenticated"
+	ClawMilestoneFirstChannelPost = "first_channel_post"
+	ClawMilestoneFirstHeartbeat   = "first_heartbeat"
+	ClawMilestoneEnvConfigured    = "env_configured"
+
+	// clawAuthReminder marks that the owner was told the claw never
+	// authenticated; it isn't a checklist step.
+	clawAuthReminder = "auth_reminder"
+
+	clawAuthReminderAfter = time.Hour
+	clawOnboardingEvery   = 10 * time.Minute
+)
+
+// clawChecklist is the onboarding checklist, in the order it is shown.
+var clawChecklist = []string{
+	ClawMilestoneAuthenticated,
+	ClawMilestoneFirstChannelPost,
+	ClawMilestoneFirstHeartbeat,
+	ClawMilestoneEnvConfigured,
+}
+
+// ClawOnboarding is a claw's first-run checklist.
+type ClawOnboarding struct {
+	ProvisionedAt string               `json:"provisioned_at,omitempty" doc:"When the claw first came up running"`
+	Steps         []ClawOnboardingStep `json:"steps"`
+	Completed     int                  `json:"completed"`
+	Total         int                  `json:"total"`
+}
+
+// ClawOnboardingStep is one checklist milestone.
+type ClawOnboardingStep struct {
+	Key  string `json:"key" enum:"authenticated,first_channel_post,first_heartbeat,env_configured"`
+	Done bool   `json:"done"`
+	At   string `json:"at,omitempty" doc:"When the milestone was reached"`
+}
+
+func clawOnboardingFromRecord(r *core.Record) ClawOnboarding {
+	stamps := clawOnboardingStamps(r.GetString("onboarding"))
+	out := ClawOnboarding{
+		ProvisionedAt: stamps[ClawMilestoneProvisioned],
+		Steps:         make([]ClawOnboardingStep, 0, len(clawChecklist)),
+		Total:         len(clawChecklist),
+	}
+	for _, key := range clawChecklist {
+		at := stamps[key]
+		out.Steps = append(out.Steps, ClawOnboardingStep{Key: key, Done: at != "", At: at})
+		if at != "" {
+			out.Completed++
+		}
+	}
+	return out
+}
+
+// clawOnboardingStamps parses an onboarding value; anything unreadable is
+// treated as no milestones yet.
+func clawOnboardingStamps(raw string) map[string]string {
+	stamps := map[string]string{}
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &stamps)
+	}
+	return stamps
+}
+
+// clawOnboardingObject is the SQL for a claw's onboarding as a JSON object,
+// whatever is stored.
+const clawOnboardingObject = "(CASE WHEN json_valid(onboarding) AND json_type(onboarding) = 'object' THEN onboarding ELSE '{}' END)"
+
+// stampClawOnboarding sets milestone on the claws matching where, unless
+// they already have it. It reports whether any claw was stamped.
+func stampClawOnboarding(app core.App, where string, params map[string]any, milestone string) bool {
+	params["path"] = "$." + milestone
+	params["now"] = time.Now().UTC().Format(time.RFC3339)
+	res, err := app.DB().NewQuery(
+		"UPDATE claw_deployments SET onboarding = json_set(" + clawOnboardingObject + ", {:path}, {:now}) " +
+			"WHERE " + where + " AND json_extract(" + clawOnboardingObject + ", {:path}) IS NULL").
+		Bind(params).Execute()
+	if err != nil {
+		app.Logger().Warn("Failed to record claw onboarding milestone", "milestone", milestone, "error", err)
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n > 0
+}
+
+// clawAgentMilestones remembers agent milestones already stamped (or agents
+// that aren't claws), so hot paths skip the UPDATE after the first time.
+var clawAgentMilestones sync.Map // "agentID|milestone" → struct{}
+
+// MarkClawAgentMilestone stamps milestone on the claw whose agent is agentID,
+// if the agent is a claw's.
+func MarkClawAgentMilestone(app core.App, agentID, milestone string) {
+	if agentID == "" {
+		return
+	}
+	key := agentID + "|" + milestone
+	if _, done := clawAgentMilestones.Load(key); done {
+		return
+	}
+	stampClawOnboarding(app, "agent_id = {:aid}", map[string]any{"aid": agentID}, milestone)
+	clawAgentMilestones.Store(key, struct{}{})
+}
+
+// MarkClawMilestone stamps milestone on the claw clawID.
+func MarkClawMilestone(app core.App, clawID, milestone string) {
+	stampClawOnboarding(app, "id = {:id}", map[string]any{"id": clawID}, milestone)
+}
+
+// PrepareClawOnboardingSave runs before a claw_deployments record is
+// updated. It keeps milestones stamped since the record was loaded, which a
+// full-record save would otherwise overwrite, and stamps provisioned when
+// the claw first starts running. Claws that were running before onboarding
+// existed are never stamped, so they aren't reminded.
+func PrepareClawOnboardingSave(app core.App, record *core.Record) {
+	stamps := clawOnboardingStamps(record.GetString("onboarding"))
+	changed := false
+
+	var current string
+	if err := app.DB().NewQuery("SELECT onboarding FROM claw_deployments WHERE id = {:id}").
+		Bind(map[string]any{"id": record.Id}).Row(&current); err == nil {
+		for k, v := range clawOnboardingStamps(current) {
+			if _, ok := stamps[k]; !ok {
+				stamps[k] = v
+				changed = true
+			}
+		}
+	}
+	started := record.GetString("status") == "running" && record.Original().GetString("status") != "running"
+	if started && stamps[ClawMilestoneProvisioned] == "" {
+		stamps[ClawMilestoneProvisioned] = time.Now().UTC().Format(time.RFC3339)
+		changed = true
+	}
+	if changed {
+		record.Set("onboarding", stamps)
+	}
+}
+
+// RegisterClawOnboardingJob reminds owners of claws that have been running
+// for an hour without authenticating.
+func RegisterClawOnboardingJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("claw_onboarding", clawOnboardingEvery, func(ctx context.Context) error {
+		return remindUnauthenticatedClaws(ctx, app)
+	})
+}
+
+func remindUnauthenticatedClaws(ctx context.Context, app *pocketbase.PocketBase) error {
+	cutoff := time.Now().UTC().Add(-clawAuthReminderAfter).Format(time.RFC3339)
+	var ids []string
+	err := app.DB().NewQuery(
+		"SELECT id FROM claw_deployments WHERE status = 'running' AND json_valid(onboarding) " +
+			"AND json_extract(onboarding, '$.provisioned') < {:cutoff} " +
+			"AND json_extract(onboarding, '$.authenticated') IS NULL " +
+			"AND json_extract(onboarding, '$.auth_reminder') IS NULL").
+		Bind(map[string]any{"cutoff": cutoff}).WithContext(ctx).Column(&ids)
+	if err != nil {
+		return fmt.Errorf("find unauthenticated claws: %w", err)
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return ctx.Err() // shutting down; the rest go next run
+		}
+		// Claim the reminder first, so it's sent once
+		if !stampClawOnboarding(app, "id = {:id}", map[string]any{"id": id}, clawAuthReminder) {
+			continue
+		}
+		claw, err := app.FindRecordById("claw_deployments", id)
+		if err != nil {
+			continue
+		}
+		name := claw.GetString("name")
+		RecordClawActivity(app, claw.Id, ClawActivityStatus, "Hasn't authenticated yet", "", nil)
+		if owner := claw.GetString("user_id"); owner != "" {
+			SendInboxMessage(app, "user:"+owner, "claw_onboarding",
+				fmt.Sprintf("%s hasn't connected to Gather yet", name),
+				fmt.Sprintf("%s has been running for over an hour but hasn't authenticated, so it can't read its channel or post. "+
+					"This is usually its configuration: check the API key and model in its environment settings "+
+					"(GET /api/claws/%s/env; restart_needed means a change isn't live yet), then look at its logs "+
+					"(GET /api/claws/%s/logs) for key or startup errors. Restarting it often clears a stuck first run.",
+					name, claw.Id, claw.Id),
+				"claw", claw.Id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// Claw provisioning profiles
+// -----------------------------------------------------------------------------
+
+// ClawProfile is the container shape for one claw_type.
+type ClawProfile struct {
+	Image    string            `json:"image"`
+	MemoryMB int               `json:"memory_mb"`
+	CPUs     float64           `json:"cpus"`
+	Env      map[string]string `json:"env,omitempty"`
+
+	// BridgeTimeoutSec caps one non-streaming agent turn; bigger tiers run
+	// longer tool chains. MaxInFlight is how many turns may run at once.
+	BridgeTimeoutSec int `json:"bridge_timeout_sec,omitempty"`
+	MaxInFlight      int `json:"max_in_flight,omitempty"`
+}
+
+// ClawResources is the effective resource allocation reported on a claw.
+type ClawResources struct {
+	Image    string  `json:"image"`
+	MemoryMB int     `json:"memory_mb"`
+	CPUs     float64 `json:"cpus"`
+}
+
+// MemoryBytes returns the Docker memory limit.
+func (p ClawProfile) MemoryBytes() int64 {
+	return int64(p.MemoryMB) * 1024 * 1024
+}
+
+// NanoCPUs returns the Docker CPU limit.
+func (p ClawProfile) NanoCPUs() int64 {
+	return int64(p.CPUs * 1e9)
+}
+
+// BridgeTimeout returns how long to wait for the bridge to answer a message.
+func (p ClawProfile) BridgeTimeout() time.Duration {
+	return time.Duration(p.BridgeTimeoutSec) * time.Second
+}
+
+// Resources returns the profile's user-visible limits.
+func (p ClawProfile) Resources() *ClawResources {
+	return &ClawResources{Image: p.Image, MemoryMB: p.MemoryMB, CPUs: p.CPUs}
+}
+
+// defaultClawProfiles applies when CLAW_PROFILES is unset. Every tier runs
+// CLAW_DOCKER_IMAGE; only the limits differ.
+func defaultClawProfiles() map[string]ClawProfile {
+	image := os.Getenv("CLAW_DOCKER_IMAGE")
+	if image == "" {
+		image = "gather-claw:latest"
+	}
+	return map[string]ClawProfile{
+		"lite": {Image: image, MemoryMB: 512, CPUs: 1, BridgeTimeoutSec: 120, MaxInFlight: 2},
+		"pro":  {Image: image, MemoryMB: 2048, CPUs: 2, BridgeTimeoutSec: 300, MaxInFlight: 2},
+		"max":  {Image: image, MemoryMB: 4096, CPUs: 4, BridgeTimeoutSec: 600, MaxInFlight: 2},
+	}
+}
+
+var (
+	clawProfilesOnce sync.Once
+	clawProfiles     map[string]ClawProfile
+)
+
+// ClawProfiles returns the claw_type → profile map. CLAW_PROFILES (JSON) is
+// authoritative when set: only the listed types can be deployed, and any
+// field left out falls back to the lite default.
+func ClawProfiles() map[string]ClawProfile {
+	clawProfilesOnce.Do(func() {
+		clawProfiles = defaultClawProfiles()
+		raw := strings.TrimSpace(os.Getenv("CLAW_PROFILES"))
+		if raw == "" {
+			return
+		}
+		parsed, err := parseClawProfiles(raw, clawProfiles["lite"])
+		if err != nil {
+			log.Printf("Warning: ignoring CLAW_PROFILES: %v", err)
+			return
+		}
+		clawProfiles = parsed
+	})
+	return clawProfiles
+}
+
+func parseClawProfiles(raw string, fallback ClawProfile) (map[string]ClawProfile, error) {
+	var parsed map[string]ClawProfile
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return nil, err
+	}
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("no profiles defined")
+	}
+	for name, p := range parsed {
+		if p.Image == "" {
+			p.Image = fallback.Image
+		}
+		if p.MemoryMB <= 0 {
+			p.MemoryMB = fallback.MemoryMB
+		}
+		if p.CPUs <= 0 {
+			p.CPUs = fallback.CPUs
+		}
+		if p.BridgeTimeoutSec <= 0 {
+			p.BridgeTimeoutSec = fallback.BridgeTimeoutSec
+		}
+		if p.MaxInFlight <= 0 {
+			p.MaxInFlight = fallback.MaxInFlight
+		}
+		parsed[name] = p
+	}
+	return parsed, nil
+}
+
+// NormalizeClawType maps empty and legacy values to "lite".
+func NormalizeClawType(clawType string) string {
+	if clawType == "" || clawType == "picoclaw" {
+		return "lite"
+	}
+	return clawType
+}
+
+// ClawProfileFor looks up the profile for a claw_type.
+func ClawProfileFor(clawType string) (ClawProfile, bool) {
+	p, ok := ClawProfiles()[NormalizeClawType(clawType)]
+	return p, ok
+}
+
+// bridgeProfile returns the profile governing bridge calls to a claw. Claws
+// whose type was dropped from CLAW_PROFILES keep running on lite limits.
+func bridgeProfile(clawType string) ClawProfile {
+	if p, ok := ClawProfileFor(clawType); ok {
+		return p
+	}
+	return defaultClawProfiles()["lite"]
+}
+
+// clawTypeNames lists the deployable claw types, sorted.
+func clawTypeNames() []string {
+	names := make([]string, 0, len(ClawProfiles()))
+	for name := range ClawProfiles() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
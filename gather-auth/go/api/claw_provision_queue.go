@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Claw provisioning queue — bounded concurrency for container starts
+// -----------------------------------------------------------------------------
+
+// New deployments are queued instead of each getting its own goroutine, so a
+// burst of deploys doesn't run a docker pull per claw at once. A fixed pool of
+// workers (CLAW_PROVISION_CONCURRENCY, default 2) takes claws in the order
+// they were queued; until then the record stays "queued" and GET
+// /api/claws/{id} shows its queue_position. The queue lives in memory, so the
+// claw_provision_recovery job re-queues, at startup and every few minutes,
+// claws left "queued" or stuck in "provisioning" by a restart.
+
+const (
+	defaultClawProvisionConcurrency = 2
+	maxClawProvisionConcurrency     = 16
+	clawProvisionQueueSize          = 1000
+
+	// clawProvisionStaleAfter is how long a claw may sit in "provisioning"
+	// without a worker in this process before it is provisioned again.
+	clawProvisionStaleAfter = 15 * time.Minute
+	clawProvisionRecovery   = 5 * time.Minute
+)
+
+var errClawProvisionQueueFull = errors.New("provisioning queue is full")
+
+// ClawProvisionFunc provisions one claw. The record was just loaded and is
+// "queued"; the function is responsible for moving it on to running or
+// failed.
+type ClawProvisionFunc func(ctx context.Context, record *core.Record)
+
+// clawProvisionQueue holds claw IDs waiting for a worker. ready carries one
+// token per pending ID, so workers block on it rather than polling.
+type clawProvisionQueue struct {
+	mu      sync.Mutex
+	pending []string
+	active  map[string]bool
+	ready   chan struct{}
+}
+
+var clawProvisioning = newClawProvisionQueue(clawProvisionQueueSize)
+
+func newClawProvisionQueue(size int) *clawProvisionQueue {
+	return &clawProvisionQueue{
+		active: map[string]bool{},
+		ready:  make(chan struct{}, size),
+	}
+}
+
+// enqueue adds a claw to the back of the queue. A claw already queued or
+// being provisioned is left where it is.
+func (q *clawProvisionQueue) enqueue(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.active[id] {
+		return nil
+	}
+	for _, p := range q.pending {
+		if p == id {
+			return nil
+		}
+	}
+	select {
+	case q.ready <- struct{}{}:
+	default:
+		return errClawProvisionQueueFull
+	}
+	q.pending = append(q.pending, id)
+	return nil
+}
+
+// next blocks until a claw is queued and hands the oldest to the caller,
+// marking it active.
+func (q *clawProvisionQueue) next() string {
+	<-q.ready
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	id := q.pending[0]
+	q.pending = q.pending[1:]
+	q.active[id] = true
+	return id
+}
+
+func (q *clawProvisionQueue) done(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.active, id)
+}
+
+// position is the claw's 1-based place in line, or 0 if it isn't waiting.
+func (q *clawProvisionQueue) position(id string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, p := range q.pending {
+		if p == id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (q *clawProvisionQueue) isActive(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.active[id]
+}
+
+// ClawProvisionConcurrency reads CLAW_PROVISION_CONCURRENCY: how many claws
+// are provisioned at once.
+func ClawProvisionConcurrency() int {
+	n, err := strconv.Atoi(os.Getenv("CLAW_PROVISION_CONCURRENCY"))
+	if err != nil || n < 1 {
+		return defaultClawProvisionConcurrency
+	}
+	return min(n, maxClawProvisionConcurrency)
+}
+
+// StartClawProvisioning starts the provisioning workers. Claws queued before
+// it is called wait for it.
+func StartClawProvisioning(app *pocketbase.PocketBase, provision ClawProvisionFunc) {
+	workers := ClawProvisionConcurrency()
+	clawProvisioning.start(app, provision, workers)
+	app.Logger().Info("Claw provisioning workers started", "workers", workers)
+}
+
+// start runs n workers provisioning the claws queued on q.
+func (q *clawProvisionQueue) start(app *pocketbase.PocketBase, provision ClawProvisionFunc, n int) {
+	for i := 0; i < n; i++ {
+		go func() {
+			for {
+				id := q.next()
+				runClawProvision(app, provision, id)
+				q.done(id)
+			}
+		}()
+	}
+}
+
+func runClawProvision(app *pocketbase.PocketBase, provision ClawProvisionFunc, id string) {
+	defer func() {
+		if p := recover(); p != nil {
+			app.Logger().Error("Claw provisioning panicked", "id", id, "panic", p)
+			if record, err := app.FindRecordById("claw_deployments", id); err == nil {
+				record.Set("status", "failed")
+				record.Set("error_message", "provisioning crashed")
+				app.Save(record)
+			}
+		}
+	}()
+
+	// Load it fresh: the claw may have been deleted or changed while queued
+	record, err := app.FindRecordById("claw_deployments", id)
+	if err != nil || record.GetString("status") != "queued" {
+		return
+	}
+	provision(context.Background(), record)
+}
+
+// EnqueueClawProvision queues a claw for provisioning. If the queue is full
+// the claw is marked failed so it doesn't wait forever.
+func EnqueueClawProvision(app *pocketbase.PocketBase, record *core.Record) {
+	if err := clawProvisioning.enqueue(record.Id); err != nil {
+		app.Logger().Error("Failed to queue claw for provisioning", "id", record.Id, "error", err)
+		record.Set("status", "failed")
+		record.Set("error_message", "Too many claws are being deployed right now; please try again shortly")
+		if err := app.Save(record); err != nil {
+			app.Logger().Error("Failed to mark unqueued claw failed", "id", record.Id, "error", err)
+		}
+	}
+}
+
+// ClawQueuePosition is the claw's place in the provisioning queue (1 is
+// next), or 0 if it isn't waiting.
+func ClawQueuePosition(id string) int {
+	return clawProvisioning.position(id)
+}
+
+// RegisterClawProvisionRecoveryJob re-queues, at startup and then every few
+// minutes, claws a restart left behind: "queued" ones the in-memory queue
+// lost, and "provisioning" ones no worker here is handling that started over
+// clawProvisionStaleAfter ago. Claws a host-side provisioner has claimed are
+// left to the claim reaper.
+func RegisterClawProvisionRecoveryJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("claw_provision_recovery", clawProvisionRecovery, func(ctx context.Context) error {
+		return recoverClawProvisioning(app)
+	}, jobs.RunOnStart())
+}
+
+func recoverClawProvisioning(app *pocketbase.PocketBase) error {
+	queued, err := app.FindRecordsByFilter("claw_deployments", "status = 'queued'", "created", 0, 0, nil)
+	if err != nil {
+		return fmt.Errorf("find queued claws: %w", err)
+	}
+	for _, r := range queued {
+		EnqueueClawProvision(app, r)
+	}
+
+	cutoff := time.Now().UTC().Add(-clawProvisionStaleAfter).Format(time.RFC3339)
+	stuck, err := app.FindRecordsByFilter("claw_deployments",
+		"status = 'provisioning' && claim_attempts = 0 && (provision_started_at = '' || provision_started_at < {:cutoff})",
+		"created", 0, 0, map[string]any{"cutoff": cutoff})
+	if err != nil {
+		return fmt.Errorf("find stuck claws: %w", err)
+	}
+	var errs []error
+	for _, r := range stuck {
+		if clawProvisioning.isActive(r.Id) {
+			continue // slow, not stuck
+		}
+		app.Logger().Warn("Re-queueing claw stuck in provisioning",
+			"id", r.Id, "started", r.GetString("provision_started_at"))
+		r.Set("status", "queued")
+		if err := app.Save(r); err != nil {
+			errs = append(errs, fmt.Errorf("re-queue claw %s: %w", r.Id, err))
+			continue
+		}
+		EnqueueClawProvision(app, r)
+	}
+	return errors.Join(errs...)
+}
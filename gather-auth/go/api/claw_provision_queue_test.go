@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// useProvisionQueue swaps in an empty queue of the given size for the test.
+func useProvisionQueue(t *testing.T, size int) *clawProvisionQueue {
+	t.Helper()
+	old := clawProvisioning
+	clawProvisioning = newClawProvisionQueue(size)
+	t.Cleanup(func() { clawProvisioning = old })
+	return clawProvisioning
+}
+
+func newProvisionApp(t *testing.T) *pocketbase.PocketBase {
+	t.Helper()
+	app := newTestApp(t)
+	addCollection(t, app, "claw_deployments", "name", "status", "error_message", "provision_started_at", "claim_attempts:number")
+	return app
+}
+
+// fakeProvisioner records the claws it is handed and holds each until
+// released, tracking how many run at once.
+type fakeProvisioner struct {
+	mu               sync.Mutex
+	started          []string
+	running, maxSeen int
+	panicOn          string
+	startedCh        chan string
+	release          chan struct{}
+	finished         chan string
+}
+
+func newFakeProvisioner() *fakeProvisioner {
+	return &fakeProvisioner{startedCh: make(chan string, 100), release: make(chan struct{}), finished: make(chan string, 100)}
+}
+
+func (p *fakeProvisioner) provision(app *pocketbase.PocketBase) ClawProvisionFunc {
+	return func(ctx context.Context, record *core.Record) {
+		p.mu.Lock()
+		p.started = append(p.started, record.Id)
+		p.running++
+		p.maxSeen = max(p.maxSeen, p.running)
+		p.mu.Unlock()
+		p.startedCh <- record.Id
+		defer func() {
+			p.mu.Lock()
+			p.running--
+			p.mu.Unlock()
+		}()
+
+		if record.Id == p.panicOn {
+			panic("docker went away")
+		}
+		<-p.release
+		record.Set("status", "running")
+		app.Save(record)
+		p.finished <- record.Id
+	}
+}
+
+// awaitStart returns the next claw the provisioner was handed.
+func (p *fakeProvisioner) awaitStart(t *testing.T) string {
+	t.Helper()
+	select {
+	case id := <-p.startedCh:
+		return id
+	case <-time.After(5 * time.Second):
+		t.Fatal("no claw was provisioned")
+		return ""
+	}
+}
+
+// finish releases a held claw and waits for it to be saved.
+func (p *fakeProvisioner) finish(t *testing.T) {
+	t.Helper()
+	p.release <- struct{}{}
+	select {
+	case <-p.finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("released claw didn't finish")
+	}
+}
+
+// expectNoStart fails if a claw is provisioned within a short wait.
+func (p *fakeProvisioner) expectNoStart(t *testing.T) {
+	t.Helper()
+	select {
+	case id := <-p.startedCh:
+		t.Fatalf("claw %s provisioned beyond the concurrency cap", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestClawProvisionQueue(t *testing.T) {
+	app := newProvisionApp(t)
+	q := useProvisionQueue(t, 10)
+	var ids []string
+	for i := 0; i < 5; i++ {
+		r := addRecord(t, app, "claw_deployments", map[string]any{"name": "claw", "status": "queued"})
+		EnqueueClawProvision(app, r)
+		ids = append(ids, r.Id)
+	}
+	// Queueing a claw twice keeps its place
+	EnqueueClawProvision(app, mustFind(t, app, ids[0]))
+	for i, id := range ids {
+		if got := ClawQueuePosition(id); got != i+1 {
+			t.Errorf("claw %d at position %d", i, got)
+		}
+	}
+
+	p := newFakeProvisioner()
+	q.start(app, p.provision(app), 2)
+
+	// Two start, in queue order, and no more until one finishes
+	first := map[string]bool{p.awaitStart(t): true, p.awaitStart(t): true}
+	if !first[ids[0]] || !first[ids[1]] {
+		t.Fatalf("started %v first, want the first two queued", first)
+	}
+	p.expectNoStart(t)
+	if got := ClawQueuePosition(ids[2]); got != 1 {
+		t.Errorf("third claw at position %d while waiting, want 1", got)
+	}
+	if got := ClawQueuePosition(ids[0]); got != 0 {
+		t.Errorf("claw being provisioned at position %d", got)
+	}
+	// A claw already being provisioned isn't queued again
+	EnqueueClawProvision(app, mustFind(t, app, ids[0]))
+	if got := ClawQueuePosition(ids[0]); got != 0 {
+		t.Errorf("active claw re-queued at position %d", got)
+	}
+
+	// Each finished claw frees one worker for the next in line
+	for _, want := range ids[2:] {
+		p.finish(t)
+		if got := p.awaitStart(t); got != want {
+			t.Fatalf("started %s, want %s", got, want)
+		}
+		p.expectNoStart(t)
+	}
+	for i := 0; i < 2; i++ {
+		p.finish(t)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.maxSeen != 2 {
+		t.Errorf("%d claws provisioned at once, want 2", p.maxSeen)
+	}
+	if len(p.started) != len(ids) {
+		t.Errorf("%d claws provisioned, want %d", len(p.started), len(ids))
+	}
+}
+
+func mustFind(t *testing.T, app *pocketbase.PocketBase, id string) *core.Record {
+	t.Helper()
+	r, err := app.FindRecordById("claw_deployments", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestClawProvisionQueueSkipsAndRecovers(t *testing.T) {
+	app := newProvisionApp(t)
+	q := useProvisionQueue(t, 10)
+	crash := addRecord(t, app, "claw_deployments", map[string]any{"status": "queued"})
+	deleted := addRecord(t, app, "claw_deployments", map[string]any{"status": "queued"})
+	next := addRecord(t, app, "claw_deployments", map[string]any{"status": "queued"})
+	for _, r := range []*core.Record{crash, deleted, next} {
+		EnqueueClawProvision(app, r)
+	}
+	// Deleted while it waited: the worker skips it
+	deleted.Set("status", "deleted")
+	if err := app.Save(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newFakeProvisioner()
+	p.panicOn = crash.Id
+	q.start(app, p.provision(app), 1)
+
+	// A panicking provision fails its claw and the worker carries on
+	if got := p.awaitStart(t); got != crash.Id {
+		t.Fatalf("started %s first", got)
+	}
+	if got := p.awaitStart(t); got != next.Id {
+		t.Fatalf("started %s after the crash, want %s", got, next.Id)
+	}
+	p.finish(t)
+	if r := mustFind(t, app, crash.Id); r.GetString("status") != "failed" || r.GetString("error_message") == "" {
+		t.Errorf("crashed claw: %s %q", r.GetString("status"), r.GetString("error_message"))
+	}
+}
+
+func TestRecoverClawProvisioning(t *testing.T) {
+	app := newProvisionApp(t)
+	q := useProvisionQueue(t, 10)
+	stale := time.Now().UTC().Add(-clawProvisionStaleAfter - time.Minute).Format(time.RFC3339)
+	fresh := time.Now().UTC().Add(-time.Minute).Format(time.RFC3339)
+	claw := func(status, started string, claims int) string {
+		return addRecord(t, app, "claw_deployments", map[string]any{
+			"status": status, "provision_started_at": started, "claim_attempts": claims,
+		}).Id
+	}
+	lost := claw("queued", "", 0)
+	stuck := claw("provisioning", stale, 0)
+	legacy := claw("provisioning", "", 0)
+	slow := claw("provisioning", fresh, 0)
+	claimed := claw("provisioning", stale, 1)
+	active := claw("provisioning", stale, 0)
+	running := claw("running", stale, 0)
+	q.active[active] = true
+
+	if err := recoverClawProvisioning(app); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{lost, stuck, legacy} {
+		if ClawQueuePosition(id) == 0 || mustFind(t, app, id).GetString("status") != "queued" {
+			t.Errorf("claw %s not re-queued: %s", id, mustFind(t, app, id).GetString("status"))
+		}
+	}
+	for _, id := range []string{slow, claimed, active, running} {
+		if ClawQueuePosition(id) != 0 || mustFind(t, app, id).GetString("status") == "queued" {
+			t.Errorf("claw %s re-queued", id)
+		}
+	}
+
+	// Running recovery again doesn't queue anything twice
+	if err := recoverClawProvisioning(app); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(q.pending); n != 3 {
+		t.Errorf("%d claws queued after a second recovery, want 3", n)
+	}
+}
+
+func TestEnqueueClawProvisionFull(t *testing.T) {
+	app := newProvisionApp(t)
+	useProvisionQueue(t, 1)
+	first := addRecord(t, app, "claw_deployments", map[string]any{"status": "queued"})
+	second := addRecord(t, app, "claw_deployments", map[string]any{"status": "queued"})
+	EnqueueClawProvision(app, first)
+	EnqueueClawProvision(app, second)
+
+	if r := mustFind(t, app, first.Id); r.GetString("status") != "queued" {
+		t.Errorf("first claw %s", r.GetString("status"))
+	}
+	if r := mustFind(t, app, second.Id); r.GetString("status") != "failed" || r.GetString("error_message") == "" {
+		t.Errorf("claw over the queue size: %s %q", r.GetString("status"), r.GetString("error_message"))
+	}
+}
+
+func TestClawProvisionConcurrency(t *testing.T) {
+	cases := map[string]int{"": 2, "4": 4, "0": 2, "-1": 2, "x": 2, "100": maxClawProvisionConcurrency}
+	for env, want := range cases {
+		t.Setenv("CLAW_PROVISION_CONCURRENCY", env)
+		if got := ClawProvisionConcurrency(); got != want {
+			t.Errorf("%q: %d, want %d", env, got, want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/pocketbase/pocketbase"
+)
+
+// -----------------------------------------------------------------------------
+// Orphan claw container reaper
+// -----------------------------------------------------------------------------
+
+// Failed provisions, renames and records deleted from the PocketBase UI leave
+// claw-* containers running with nothing pointing at them. The reaper removes
+// any claw container on the claw networks whose deployment record is gone or
+// failed. Only containers carrying the Traefik labels provisionClaw sets are
+// considered, so claw-build-service and friends are never touched.
+
+const (
+	clawReapInterval = 15 * time.Minute
+	// clawOrphanMinAge keeps the reaper away from containers a provision is
+	// still creating (the record is saved before the container exists).
+	clawOrphanMinAge = 10 * time.Minute
+)
+
+// ClawDockerNetwork is the Docker network open claw containers are attached
+// to; see ClawInternalNetwork for the others.
+func ClawDockerNetwork() string {
+	if n := os.Getenv("CLAW_DOCKER_NETWORK"); n != "" {
+		return n
+	}
+	return "gather-infra_gather_net"
+}
+
+type ClawOrphan struct {
+	ContainerID string   `json:"container_id"`
+	Name        string   `json:"name"`
+	State       string   `json:"state"`
+	Created     string   `json:"created"`
+	Reason      string   `json:"reason"`
+	Volumes     []string `json:"volumes,omitempty" doc:"Named volumes removed with the container (only when no deployment uses the subdomain)"`
+}
+
+// StartClawReaper launches a background goroutine that removes orphan claw
+// containers every 15 minutes.
+func StartClawReaper(app *pocketbase.PocketBase) {
+	go func() {
+		ticker := time.NewTicker(clawReapInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			reapClawOrphans(ctx, app)
+			reportUnboundedClawLogs(ctx, app)
+			cancel()
+		}
+	}()
+	app.Logger().Info("Claw orphan reaper started (15-minute tick)")
+}
+
+// findClawOrphans lists claw containers with no live deployment record.
+func findClawOrphans(ctx context.Context, app *pocketbase.PocketBase) ([]ClawOrphan, error) {
+	cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{
+		All: true,
+		Filters: filters.NewArgs(
+			filters.Arg("name", "claw-"),
+			filters.Arg("network", ClawDockerNetwork()),
+			filters.Arg("network", ClawInternalNetwork()),
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list containers: %w", err)
+	}
+
+	records, err := app.FindRecordsByFilter("claw_deployments", "id != ''", "", 0, 0, nil)
+	if err != nil {
+		return nil, fmt.Errorf("load deployments: %w", err)
+	}
+	statusByContainer := make(map[string]string, len(records))
+	subdomains := make(map[string]bool, len(records))
+	for _, r := range records {
+		if cid := r.GetString("container_id"); cid != "" {
+			statusByContainer[cid] = r.GetString("status")
+		}
+		if sub := r.GetString("subdomain"); sub != "" {
+			subdomains[sub] = true
+		}
+	}
+
+	cutoff := time.Now().Add(-clawOrphanMinAge)
+	var orphans []ClawOrphan
+	for _, c := range containers {
+		if len(c.Names) == 0 {
+			continue
+		}
+		name := strings.TrimPrefix(c.Names[0], "/")
+		if !strings.HasPrefix(name, "claw-") || c.Labels["traefik.http.services."+name+".loadbalancer.server.port"] == "" {
+			continue
+		}
+		created := time.Unix(c.Created, 0)
+		if created.After(cutoff) {
+			continue
+		}
+
+		var reason string
+		switch status, ok := statusByContainer[name]; {
+		case !ok:
+			reason = "no deployment record"
+		case status == "failed":
+			reason = "deployment failed"
+		default:
+			continue
+		}
+
+		orphan := ClawOrphan{
+			ContainerID: c.ID,
+			Name:        name,
+			State:       string(c.State),
+			Created:     created.UTC().Format(time.RFC3339),
+			Reason:      reason,
+		}
+		// Keep the claw's memory while any record (even a failed one) still
+		// owns the subdomain; the owner may retry or delete it themselves.
+		if sub := strings.TrimPrefix(name, "claw-"); !subdomains[sub] {
+			orphan.Volumes = []string{"claw-data-" + sub, clawRepoVolume(sub)}
+		}
+		orphans = append(orphans, orphan)
+	}
+	return orphans, nil
+}
+
+// reapClawOrphans removes orphan containers with their anonymous volumes, and
+// the claw's named volumes when nothing references them. Every removal is
+// logged. Returns what was removed.
+func reapClawOrphans(ctx context.Context, app *pocketbase.PocketBase) ([]ClawOrphan, error) {
+	orphans, err := findClawOrphans(ctx, app)
+	if err != nil {
+		app.Logger().Warn("Claw reaper: scan failed", "error", err)
+		return nil, err
+	}
+	if len(orphans) == 0 {
+		return nil, nil
+	}
+
+	cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+
+	var reaped []ClawOrphan
+	for _, o := range orphans {
+		if err := cli.ContainerRemove(ctx, o.ContainerID, container.RemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			app.Logger().Warn("Claw reaper: remove failed", "container", o.Name, "error", err)
+			continue
+		}
+		for _, v := range o.Volumes {
+			// Missing volumes are expected (no repo connected)
+			if err := cli.VolumeRemove(ctx, v, false); err != nil && !dockerclient.IsErrNotFound(err) {
+				app.Logger().Warn("Claw reaper: volume remove failed", "volume", v, "error", err)
+			}
+		}
+		app.Logger().Info("Claw reaper: removed orphan container",
+			"container", o.Name, "id", o.ContainerID, "reason", o.Reason, "created", o.Created, "volumes", o.Volumes)
+		reaped = append(reaped, o)
+	}
+	return reaped, nil
+}
+
+// -----------------------------------------------------------------------------
+// Admin routes
+// -----------------------------------------------------------------------------
+
+type ClawOrphansInput struct {
+	AdminAuthHeader
+}
+
+type ClawOrphansOutput struct {
+	Body struct {
+		Orphans []ClawOrphan `json:"orphans"`
+		DryRun  bool         `json:"dry_run"`
+	}
+}
+
+func RegisterClawReaperRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-list-claw-orphans",
+		Method:      "GET",
+		Path:        "/api/admin/claws/orphans",
+		Summary:     "List orphan claw containers (dry run)",
+		Description: "Claw containers older than 10 minutes whose deployment record is missing or failed — " +
+			"what the reaper would remove. Admin only.",
+		Tags: []string{"Admin"},
+	}, func(ctx context.Context, input *ClawOrphansInput) (*ClawOrphansOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+		orphans, err := findClawOrphans(ctx, app)
+		if err != nil {
+			return nil, huma.Error502BadGateway("Docker scan failed", err)
+		}
+		out := &ClawOrphansOutput{}
+		out.Body.Orphans = orphans
+		if out.Body.Orphans == nil {
+			out.Body.Orphans = []ClawOrphan{}
+		}
+		out.Body.DryRun = true
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-reap-claw-orphans",
+		Method:      "POST",
+		Path:        "/api/admin/claws/orphans",
+		Summary:     "Remove orphan claw containers now",
+		Description: "Runs the reaper immediately instead of waiting for its 15-minute tick. Returns what was removed. Admin only.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *ClawOrphansInput) (*ClawOrphansOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+		reaped, err := reapClawOrphans(ctx, app)
+		if err != nil {
+			return nil, huma.Error502BadGateway("Docker scan failed", err)
+		}
+		if len(reaped) > 0 {
+			names := make([]string, len(reaped))
+			for i, o := range reaped {
+				names[i] = o.Name
+			}
+			if err := recordAdminAudit(app, admin.Id, "claw.reap_orphans", "claw_container", "", map[string]any{"containers": names}); err != nil {
+				app.Logger().Warn("Failed to audit orphan reap", "error", err)
+			}
+		}
+		out := &ClawOrphansOutput{}
+		out.Body.Orphans = reaped
+		if out.Body.Orphans == nil {
+			out.Body.Orphans = []ClawOrphan{}
+		}
+		return out, nil
+	})
+
+	registerClawLogRotationRoutes(api, app)
+}
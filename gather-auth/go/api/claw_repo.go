@@ -0,0 +1,318 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// GitHub repo integration
+// -----------------------------------------------------------------------------
+
+// ClawWorkspacePath is where the repo volume is mounted inside a claw.
+const ClawWorkspacePath = "/app/workspace"
+
+// repoGitTimeout bounds a clone or pull. GIT_TERMINAL_PROMPT=0 already stops
+// git waiting for credentials; this catches network stalls.
+const repoGitTimeout = 3 * time.Minute
+
+// githubTokenSecretKeys are the claw_secrets keys checked for a deploy token.
+var githubTokenSecretKeys = []string{"GITHUB_DEPLOY_TOKEN", "GITHUB_TOKEN"}
+
+var githubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// ClawRepo describes a claw's connected GitHub repo.
+type ClawRepo struct {
+	Repo   string // owner/name
+	Branch string // resolved to the remote default branch if not given
+	Volume string
+	Head   string // commit SHA after clone/pull
+}
+
+// ParseGithubRepo splits "owner/repo#branch" into repo and branch. Also
+// accepts https://github.com/owner/repo(.git) URLs.
+func ParseGithubRepo(s string) (repo, branch string, err error) {
+	s = strings.TrimSpace(s)
+	if idx := strings.IndexByte(s, '#'); idx != -1 {
+		s, branch = s[:idx], strings.TrimSpace(s[idx+1:])
+	}
+	s = strings.TrimPrefix(s, "https://")
+	s = strings.TrimPrefix(s, "github.com/")
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "/"), ".git")
+	if !githubRepoPattern.MatchString(s) {
+		return "", "", fmt.Errorf("github_repo must look like owner/repo or owner/repo#branch")
+	}
+	if branch != "" && !validGitBranch(branch) {
+		return "", "", fmt.Errorf("invalid branch name %q", branch)
+	}
+	return s, branch, nil
+}
+
+// validGitBranch applies git check-ref-format's rules to a branch name, and
+// rejects a leading "-" so it can never be read as an option.
+func validGitBranch(b string) bool {
+	if b == "@" || strings.HasPrefix(b, "-") || strings.HasPrefix(b, "/") ||
+		strings.HasSuffix(b, "/") || strings.HasSuffix(b, ".") || strings.HasSuffix(b, ".lock") ||
+		strings.Contains(b, "..") || strings.Contains(b, "@{") || strings.Contains(b, "//") {
+		return false
+	}
+	for _, r := range b {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+	for _, part := range strings.Split(b, "/") {
+		if strings.HasPrefix(part, ".") || strings.HasSuffix(part, ".lock") {
+			return false
+		}
+	}
+	return true
+}
+
+// clawRepoVolume is the named Docker volume holding a claw's checkout.
+func clawRepoVolume(subdomain string) string {
+	return "claw-repo-" + subdomain
+}
+
+// githubDeployToken returns the user's GitHub token from claw_secrets, if any.
+func githubDeployToken(app *pocketbase.PocketBase, userID string) string {
+	for _, key := range githubTokenSecretKeys {
+		records, err := app.FindRecordsByFilter("claw_secrets",
+			"user_id = {:uid} && key = {:key}", "", 1, 0,
+			map[string]any{"uid": userID, "key": key})
+		if err == nil && len(records) > 0 {
+			if v := records[0].GetString("value"); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// SyncClawRepo clones the claw's github_repo into its repo volume, or
+// fast-forwards an existing checkout. Returns the repo info with the new HEAD.
+func SyncClawRepo(ctx context.Context, cli *dockerclient.Client, app *pocketbase.PocketBase, record *core.Record) (*ClawRepo, error) {
+	repo, branch, err := ParseGithubRepo(record.GetString("github_repo"))
+	if err != nil {
+		return nil, err
+	}
+	subdomain := record.GetString("subdomain")
+	if subdomain == "" {
+		return nil, fmt.Errorf("claw has no subdomain yet")
+	}
+
+	token := githubDeployToken(app, record.GetString("user_id"))
+	env := []string{
+		"GIT_TERMINAL_PROMPT=0",
+		"REPO_URL=https://github.com/" + repo + ".git",
+		"BRANCH=" + branch,
+	}
+	if token != "" {
+		// Passed via GIT_CONFIG_* so the token never lands in .git/config
+		// or the process list.
+		basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.https://github.com/.extraheader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+basic,
+		)
+	}
+
+	script := `set -e
+cd /workspace
+if [ -d .git ]; then
+  git pull --ff-only -- origin "${BRANCH:-HEAD}" >&2
+elif [ -n "$BRANCH" ]; then
+  git clone --branch "$BRANCH" -- "$REPO_URL" . >&2
+else
+  git clone -- "$REPO_URL" . >&2
+fi
+git rev-parse --abbrev-ref HEAD
+git rev-parse HEAD`
+
+	volume := clawRepoVolume(subdomain)
+	output, err := runGitContainer(ctx, cli, volume, env, script)
+	if err != nil {
+		return nil, explainGitError(repo, token != "", output, err)
+	}
+
+	// Last two lines are the checked-out branch and HEAD commit
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("unexpected git output: %s", output)
+	}
+	if branch == "" {
+		branch = strings.TrimSpace(lines[len(lines)-2])
+	}
+	return &ClawRepo{
+		Repo:   repo,
+		Branch: branch,
+		Volume: volume,
+		Head:   strings.TrimSpace(lines[len(lines)-1]),
+	}, nil
+}
+
+// runGitContainer runs a one-shot git container with the volume mounted at
+// /workspace and returns its combined output.
+func runGitContainer(ctx context.Context, cli *dockerclient.Client, volume string, env []string, script string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, repoGitTimeout)
+	defer cancel()
+
+	gitImage := os.Getenv("CLAW_GIT_IMAGE")
+	if gitImage == "" {
+		gitImage = "alpine/git:latest"
+	}
+	if _, err := cli.ImageInspect(ctx, gitImage); err != nil {
+		reader, err := cli.ImagePull(ctx, gitImage, image.PullOptions{})
+		if err != nil {
+			return "", fmt.Errorf("pull %s: %w", gitImage, err)
+		}
+		io.Copy(io.Discard, reader)
+		reader.Close()
+	}
+
+	resp, err := cli.ContainerCreate(ctx,
+		&container.Config{
+			Image:      gitImage,
+			Entrypoint: []string{"sh", "-c", script},
+			Env:        env,
+		},
+		&container.HostConfig{
+			Mounts: []mount.Mount{{
+				Type:   mount.TypeVolume,
+				Source: volume,
+				Target: "/workspace",
+			}},
+		},
+		nil, nil, "")
+	if err != nil {
+		return "", err
+	}
+	defer cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+
+	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return "", err
+	}
+
+	var exitCode int64
+	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("git timed out after %s", repoGitTimeout)
+		}
+		return "", err
+	case status := <-statusCh:
+		exitCode = status.StatusCode
+	}
+
+	reader, err := cli.ContainerLogs(context.Background(), resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	raw, _ := io.ReadAll(reader)
+	output := stripDockerLogHeaders(raw)
+
+	if exitCode != 0 {
+		return output, fmt.Errorf("git exited with status %d", exitCode)
+	}
+	return output, nil
+}
+
+// explainGitError turns git's output into an actionable message.
+func explainGitError(repo string, hasToken bool, output string, err error) error {
+	lower := strings.ToLower(output)
+	authFailure := strings.Contains(lower, "terminal prompts disabled") ||
+		strings.Contains(lower, "could not read username") ||
+		strings.Contains(lower, "authentication failed") ||
+		strings.Contains(lower, "repository not found")
+	if authFailure {
+		if hasToken {
+			return fmt.Errorf("cannot access github.com/%s: the GitHub token in your vault was rejected or lacks read access to this repo", repo)
+		}
+		return fmt.Errorf("cannot access github.com/%s: the repo is private or does not exist. Add a GitHub token with read access as GITHUB_TOKEN in your claw vault and redeploy", repo)
+	}
+	if strings.Contains(lower, "remote branch") && strings.Contains(lower, "not found") {
+		return fmt.Errorf("branch not found in github.com/%s", repo)
+	}
+	if strings.Contains(lower, "not possible to fast-forward") || strings.Contains(lower, "diverging branches") {
+		return fmt.Errorf("workspace has local commits that diverge from github.com/%s; resolve inside the claw and retry", repo)
+	}
+
+	msg := strings.TrimSpace(output)
+	if len(msg) > 500 {
+		msg = msg[len(msg)-500:]
+	}
+	if msg == "" {
+		return err
+	}
+	return fmt.Errorf("%v: %s", err, msg)
+}
+
+// -----------------------------------------------------------------------------
+// POST /api/claws/{id}/repo-sync
+// -----------------------------------------------------------------------------
+
+type RepoSyncInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
+	ID            string `path:"id" doc:"Deployment ID"`
+}
+
+type RepoSyncOutput struct {
+	Body struct {
+		Repo   string `json:"repo"`
+		Branch string `json:"branch,omitempty"`
+		Head   string `json:"head" doc:"HEAD commit after the pull"`
+	}
+}
+
+func registerClawRepoRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "sync-claw-repo",
+		Method:      "POST",
+		Path:        "/api/claws/{id}/repo-sync",
+		Summary:     "Pull latest repo code",
+		Description: "Fast-forwards the claw's " + ClawWorkspacePath + " checkout to the latest commit of its GitHub repo. Owner and operators.",
+		Tags:        []string{"Claws"},
+	}, func(ctx context.Context, input *RepoSyncInput) (*RepoSyncOutput, error) {
+		record, _, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOperator)
+		if err != nil {
+			return nil, err
+		}
+		if record.GetString("github_repo") == "" {
+			return nil, huma.Error422UnprocessableEntity("Claw has no github_repo connected")
+		}
+
+		cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Docker connection failed")
+		}
+		defer cli.Close()
+
+		repo, err := SyncClawRepo(ctx, cli, app, record)
+		if err != nil {
+			return nil, huma.Error422UnprocessableEntity(err.Error())
+		}
+
+		out := &RepoSyncOutput{}
+		out.Body.Repo = repo.Repo
+		out.Body.Branch = repo.Branch
+		out.Body.Head = repo.Head
+		return out, nil
+	})
+}
@@ -0,0 +1,59 @@
+package api
+
+import "testing"
+
+func TestParseGithubRepo(t *testing.T) {
+	cases := []struct {
+		in, repo, branch string
+	}{
+		{"owner/repo", "owner/repo", ""},
+		{" owner/repo#main ", "owner/repo", "main"},
+		{"https://github.com/owner/repo.git#release/v1.2", "owner/repo", "release/v1.2"},
+		{"github.com/owner/my.repo/", "owner/my.repo", ""},
+		{"owner/repo#feature/a-b_c", "owner/repo", "feature/a-b_c"},
+		{"owner/repo#v1.0-rc", "owner/repo", "v1.0-rc"},
+	}
+	for _, tc := range cases {
+		repo, branch, err := ParseGithubRepo(tc.in)
+		if err != nil || repo != tc.repo || branch != tc.branch {
+			t.Errorf("ParseGithubRepo(%q) = %q, %q, %v; want %q, %q", tc.in, repo, branch, err, tc.repo, tc.branch)
+		}
+	}
+}
+
+func TestParseGithubRepoRejects(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"repo",
+		"owner/repo/extra",
+		"owner/re po",
+		"https://gitlab.com/owner/repo",
+		"owner/repo#--upload-pack=touch /tmp/x",
+		"owner/repo#-b",
+		"owner/repo#a..b",
+		"owner/repo#main@{1}",
+		"owner/repo#a//b",
+		"owner/repo#main.lock",
+		"owner/repo#feature/x.lock/y",
+		"owner/repo#main/",
+		"owner/repo#/main",
+		"owner/repo#main.",
+		"owner/repo#.hidden",
+		"owner/repo#a/.b",
+		"owner/repo#@",
+		"owner/repo#a b",
+		"owner/repo#a~1",
+		"owner/repo#a^",
+		"owner/repo#a:b",
+		"owner/repo#a?",
+		"owner/repo#a*",
+		"owner/repo#a[b",
+		"owner/repo#a\\b",
+		"owner/repo#a\x7fb",
+		"owner/repo#a\x01b",
+	} {
+		if repo, branch, err := ParseGithubRepo(in); err == nil {
+			t.Errorf("ParseGithubRepo(%q) = %q, %q; want an error", in, repo, branch)
+		}
+	}
+}
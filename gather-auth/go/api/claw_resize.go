@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	dockerclient "github.com/docker/docker/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Claw resize — move a running claw to another claw_type in place
+// -----------------------------------------------------------------------------
+
+// The container is recreated under the same name with the target profile's
+// image and limits. Its env (including GATHER_* identity), labels, mounts and
+// networks carry over, so the agent keeps its identity, data volume and
+// channel. The old container is only removed once the new one is running;
+// until then any failure puts it back.
+
+const (
+	clawResizeTimeout     = 2 * time.Minute
+	clawResizeStopSecs    = 10
+	clawResizeAsideSuffix = "-pre-resize"
+)
+
+type ResizeClawInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
+	ID            string `path:"id" doc:"Deployment ID"`
+	Body          struct {
+		ClawType string `json:"claw_type" doc:"Target tier: lite, pro, max" minLength:"1" maxLength:"50"`
+	}
+}
+
+type ResizeClawOutput struct {
+	Body ClawDeployment
+}
+
+// clawContainerAPI is the subset of the Docker client a resize uses.
+type clawContainerAPI interface {
+	ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error)
+	ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error
+	ContainerRename(ctx context.Context, containerID, newContainerName string) error
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error)
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+}
+
+// clawResizes tracks claws mid-resize so a second resize is refused and the
+// health checker leaves the briefly-stopped container alone.
+var clawResizes sync.Map
+
+func clawResizing(clawID string) bool {
+	_, busy := clawResizes.Load(clawID)
+	return busy
+}
+
+func registerClawResizeRoute(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "resize-claw",
+		Method:      "POST",
+		Path:        "/api/claws/{id}/resize",
+		Summary:     "Move a Claw to another tier",
+		Description: "Recreates the running container with the target claw_type's image and limits, keeping its name, env, volumes and agent identity. " +
+			"The claw is down for a few seconds. If any step fails the previous container is restored and the error recorded.",
+		Tags: []string{"Claws"},
+	}, func(ctx context.Context, input *ResizeClawInput) (*ResizeClawOutput, error) {
+		record, _, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		fromType := NormalizeClawType(record.GetString("claw_type"))
+		toType := NormalizeClawType(input.Body.ClawType)
+		toProfile, ok := ClawProfileFor(toType)
+		if !ok {
+			return nil, huma.Error422UnprocessableEntity(fmt.Sprintf(
+				"claw_type must be one of: %s", strings.Join(clawTypeNames(), ", ")))
+		}
+		if toType == fromType {
+			return nil, huma.Error422UnprocessableEntity("Claw is already " + toType)
+		}
+		fromProfile := bridgeProfile(fromType)
+
+		if record.GetString("status") != "running" || record.GetString("container_id") == "" {
+			return nil, huma.Error409Conflict("Only a running claw can be resized")
+		}
+		if err := clawResizeAllowed(record, fromProfile, toProfile); err != nil {
+			return nil, err
+		}
+
+		if _, busy := clawResizes.LoadOrStore(record.Id, struct{}{}); busy {
+			return nil, huma.Error409Conflict("A resize is already in progress for this claw")
+		}
+		defer clawResizes.Delete(record.Id)
+
+		cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Docker client init failed")
+		}
+		defer cli.Close()
+
+		// Not the request context: a client disconnecting mid-resize must not
+		// abandon the container half-migrated.
+		resizeCtx, cancel := context.WithTimeout(context.Background(), clawResizeTimeout)
+		defer cancel()
+
+		containerName := record.GetString("container_id")
+		title := fmt.Sprintf("Resized %s → %s", fromType, toType)
+		resizeErr := resizeClawContainer(resizeCtx, cli, containerName, fromProfile, toProfile)
+		if resizeErr != nil {
+			app.Logger().Error("Claw resize failed", "claw", record.Id, "from", fromType, "to", toType, "error", resizeErr)
+			record.Set("error_message", truncate(fmt.Sprintf("Resize to %s failed: %v", toType, resizeErr), 500))
+			if isResizeRollbackFailure(resizeErr) {
+				record.Set("status", "failed")
+			}
+			if err := app.Save(record); err != nil {
+				app.Logger().Error("Failed to record resize error", "claw", record.Id, "error", err)
+			}
+			RecordClawActivity(app, record.Id, ClawActivityStatus, fmt.Sprintf("Resize %s → %s failed", fromType, toType), resizeErr.Error(), nil)
+			return nil, huma.Error500InternalServerError(fmt.Sprintf("Resize failed: %v", resizeErr))
+		}
+
+		record.Set("claw_type", toType)
+		record.Set("error_message", "")
+		if err := app.Save(record); err != nil {
+			app.Logger().Error("Claw resized but record update failed", "claw", record.Id, "to", toType, "error", err)
+			return nil, huma.Error500InternalServerError("Claw was resized but saving its new claw_type failed")
+		}
+		RecordClawActivity(app, record.Id, ClawActivityStatus, title, "", map[string]any{
+			"from": fromType, "to": toType,
+			"image": toProfile.Image, "memory_mb": toProfile.MemoryMB, "cpus": toProfile.CPUs,
+		})
+		app.Logger().Info("Claw resized", "claw", record.Id, "from", fromType, "to", toType,
+			"image", toProfile.Image, "memory_mb", toProfile.MemoryMB, "cpus", toProfile.CPUs)
+
+		if channelID, err := findClawChannel(app, record.GetString("agent_id")); err == nil {
+			if col, err := app.FindCollectionByNameOrId("channel_messages"); err == nil {
+				msg := core.NewRecord(col)
+				msg.Set("channel_id", channelID)
+				msg.Set("author_id", "system")
+				msg.Set("body", fmt.Sprintf("%s was moved to %s (%d MB, %g CPUs) and restarted.",
+					record.GetString("name"), toType, toProfile.MemoryMB, toProfile.CPUs))
+				app.Save(msg)
+			}
+		}
+
+		out := &ResizeClawOutput{}
+		out.Body = recordToClawDeployment(record)
+		return out, nil
+	})
+}
+
+// clawResizeAllowed is the billing gate. A paid claw's subscription is priced
+// for its current tier, so outside beta it can move down but not up. Trial
+// claws may move freely; checkout charges for whatever tier they end up on.
+func clawResizeAllowed(record *core.Record, from, to ClawProfile) error {
+	if os.Getenv("BETA_MODE") == "true" {
+		return nil
+	}
+	upgrade := to.MemoryMB > from.MemoryMB || to.CPUs > from.CPUs
+	if upgrade && record.GetBool("paid") {
+		return huma.Error402PaymentRequired("This claw's subscription covers its current tier. Upgrading a paid claw needs a plan change — contact support.")
+	}
+	return nil
+}
+
+// errResizeRollback marks a resize whose rollback also failed, leaving the
+// claw without a running container.
+type errResizeRollback struct{ err error }
+
+func (e *errResizeRollback) Error() string { return e.err.Error() }
+func (e *errResizeRollback) Unwrap() error { return e.err }
+
+func isResizeRollbackFailure(err error) bool {
+	var rb *errResizeRollback
+	return errors.As(err, &rb)
+}
+
+// resizeClawContainer recreates the named container with the target
+// profile's image and limits.
+func resizeClawContainer(ctx context.Context, cli clawContainerAPI, name string, from, to ClawProfile) error {
+	return recreateClawContainer(ctx, cli, name, func(cfg *container.Config, hostCfg *container.HostConfig, _ *network.NetworkingConfig) {
+		cfg.Image = to.Image
+		cfg.Env = resizeClawEnv(cfg.Env, from, to)
+		hostCfg.Memory = to.MemoryBytes()
+		hostCfg.NanoCPUs = to.NanoCPUs()
+	})
+}
+
+// recreateClawContainer recreates the named container from its own config,
+// changed by modify. Steps: inspect, stop, rename aside, create under the
+// original name, start, verify, remove the old one. Any failure before the
+// old container is removed restores it.
+func recreateClawContainer(ctx context.Context, cli clawContainerAPI, name string, modify func(*container.Config, *container.HostConfig, *network.NetworkingConfig)) error {
+	info, err := cli.ContainerInspect(ctx, name)
+	if err != nil {
+		return fmt.Errorf("inspect: %w", err)
+	}
+	if info.Config == nil || info.HostConfig == nil {
+		return fmt.Errorf("inspect: incomplete container config")
+	}
+
+	cfg := *info.Config
+	// A fresh hostname, as for any new container
+	cfg.Hostname = ""
+	hostCfg := *info.HostConfig
+
+	netCfg := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{}}
+	if info.NetworkSettings != nil {
+		for netName := range info.NetworkSettings.Networks {
+			netCfg.EndpointsConfig[netName] = &network.EndpointSettings{}
+		}
+	}
+	modify(&cfg, &hostCfg, netCfg)
+
+	timeout := clawResizeStopSecs
+	if err := cli.ContainerStop(ctx, name, container.StopOptions{Timeout: &timeout}); err != nil {
+		cli.ContainerStart(ctx, name, container.StartOptions{})
+		return fmt.Errorf("stop: %w", err)
+	}
+
+	aside := name + clawResizeAsideSuffix
+	if err := cli.ContainerRename(ctx, name, aside); err != nil {
+		return restoreClawContainer(ctx, cli, name, "", fmt.Errorf("rename: %w", err))
+	}
+
+	created, err := cli.ContainerCreate(ctx, &cfg, &hostCfg, netCfg, nil, name)
+	if err != nil {
+		return restoreClawContainer(ctx, cli, name, aside, fmt.Errorf("create: %w", err))
+	}
+
+	if err := cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		cli.ContainerRemove(ctx, created.ID, container.RemoveOptions{Force: true})
+		return restoreClawContainer(ctx, cli, name, aside, fmt.Errorf("start: %w", err))
+	}
+
+	check, err := cli.ContainerInspect(ctx, created.ID)
+	if err != nil || check.State == nil || !check.State.Running {
+		cli.ContainerRemove(ctx, created.ID, container.RemoveOptions{Force: true})
+		return restoreClawContainer(ctx, cli, name, aside, fmt.Errorf("new container is not running"))
+	}
+
+	// The claw is up on the new tier; a leftover stopped container is only
+	// clutter, so don't fail the resize over it.
+	if err := cli.ContainerRemove(ctx, aside, container.RemoveOptions{}); err != nil {
+		log.Printf("claw resize: failed to remove %s: %v", aside, err)
+	}
+	return nil
+}
+
+// restoreClawContainer undoes a failed resize: renames the old container back
+// (when it was moved aside) and starts it. Returns cause, wrapped as a
+// rollback failure if the old container couldn't be brought back.
+func restoreClawContainer(ctx context.Context, cli clawContainerAPI, name, aside string, cause error) error {
+	if aside != "" {
+		if err := cli.ContainerRename(ctx, aside, name); err != nil {
+			return &errResizeRollback{fmt.Errorf("%w; rollback rename failed: %v", cause, err)}
+		}
+	}
+	if err := cli.ContainerStart(ctx, name, container.StartOptions{}); err != nil {
+		return &errResizeRollback{fmt.Errorf("%w; rollback start failed: %v", cause, err)}
+	}
+	return cause
+}
+
+// resizeClawEnv carries a container's env over to the target profile. Values
+// that came from the old profile's env are swapped for the new profile's;
+// everything else (identity, proxy token, vault secrets) is kept as is.
+func resizeClawEnv(env []string, from, to ClawProfile) []string {
+	out := make([]string, 0, len(env)+len(to.Env))
+	seen := map[string]bool{}
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		if def, ok := from.Env[k]; ok && def == v && !strings.HasPrefix(k, "GATHER_") {
+			if nv, ok := to.Env[k]; ok {
+				out = append(out, k+"="+nv)
+				seen[k] = true
+			}
+			continue
+		}
+		out = append(out, kv)
+		seen[k] = true
+	}
+	for k, v := range to.Env {
+		if !seen[k] {
+			out = append(out, k+"="+v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// stubDocker records the container calls a resize makes, in order. fail
+// names a call ("create claw") that returns an error; notRunning makes the
+// new container report as stopped.
+type stubDocker struct {
+	calls      []string
+	fail       map[string]bool
+	notRunning bool
+	created    *container.Config
+	createdHC  *container.HostConfig
+	createdNet *network.NetworkingConfig
+}
+
+func (s *stubDocker) call(op string) error {
+	s.calls = append(s.calls, op)
+	if s.fail[op] {
+		return fmt.Errorf("%s refused", op)
+	}
+	return nil
+}
+
+func (s *stubDocker) ContainerInspect(_ context.Context, id string) (container.InspectResponse, error) {
+	if err := s.call("inspect " + id); err != nil {
+		return container.InspectResponse{}, err
+	}
+	if id == "new1" {
+		return container.InspectResponse{ContainerJSONBase: &container.ContainerJSONBase{
+			State: &container.State{Running: !s.notRunning},
+		}}, nil
+	}
+	return container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			HostConfig: &container.HostConfig{
+				Resources: container.Resources{Memory: 512 << 20, NanoCPUs: 1e9},
+				Mounts:    []mount.Mount{{Type: mount.TypeVolume, Source: "claw-data", Target: "/data"}},
+			},
+		},
+		Config: &container.Config{
+			Hostname: "abc123",
+			Image:    "claw:lite",
+			Env:      []string{"GATHER_AGENT_ID=agent1", "MODEL=small", "CUSTOM=kept"},
+			Labels:   map[string]string{"gather.claw": "claw1"},
+		},
+		NetworkSettings: &container.NetworkSettings{Networks: map[string]*network.EndpointSettings{"claws": {}}},
+	}, nil
+}
+
+func (s *stubDocker) ContainerStop(_ context.Context, id string, _ container.StopOptions) error {
+	return s.call("stop " + id)
+}
+
+func (s *stubDocker) ContainerRename(_ context.Context, id, name string) error {
+	return s.call("rename " + id + " " + name)
+}
+
+func (s *stubDocker) ContainerCreate(_ context.Context, cfg *container.Config, hc *container.HostConfig, nc *network.NetworkingConfig, _ *ocispec.Platform, name string) (container.CreateResponse, error) {
+	if err := s.call("create " + name); err != nil {
+		return container.CreateResponse{}, err
+	}
+	s.created, s.createdHC, s.createdNet = cfg, hc, nc
+	return container.CreateResponse{ID: "new1"}, nil
+}
+
+func (s *stubDocker) ContainerStart(_ context.Context, id string, _ container.StartOptions) error {
+	return s.call("start " + id)
+}
+
+func (s *stubDocker) ContainerRemove(_ context.Context, id string, _ container.RemoveOptions) error {
+	return s.call("remove " + id)
+}
+
+var (
+	resizeFrom = ClawProfile{Image: "claw:lite", MemoryMB: 512, CPUs: 1, Env: map[string]string{"MODEL": "small"}}
+	resizeTo   = ClawProfile{Image: "claw:pro", MemoryMB: 2048, CPUs: 2, Env: map[string]string{"MODEL": "large", "TOOLS": "all"}}
+)
+
+func TestResizeClawContainer(t *testing.T) {
+	cli := &stubDocker{}
+	if err := resizeClawContainer(context.Background(), cli, "claw", resizeFrom, resizeTo); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"inspect claw",
+		"stop claw",
+		"rename claw claw-pre-resize",
+		"create claw",
+		"start new1",
+		"inspect new1",
+		"remove claw-pre-resize",
+	}
+	if !slices.Equal(cli.calls, want) {
+		t.Errorf("calls:\n  %s\nwant:\n  %s", strings.Join(cli.calls, "\n  "), strings.Join(want, "\n  "))
+	}
+
+	cfg, hc := cli.created, cli.createdHC
+	if cfg.Image != "claw:pro" || hc.Memory != resizeTo.MemoryBytes() || hc.NanoCPUs != resizeTo.NanoCPUs() {
+		t.Errorf("created %s with %d bytes, %d nano CPUs", cfg.Image, hc.Memory, hc.NanoCPUs)
+	}
+	if cfg.Hostname != "" {
+		t.Errorf("hostname %q carried over", cfg.Hostname)
+	}
+	if len(hc.Mounts) != 1 || hc.Mounts[0].Source != "claw-data" {
+		t.Errorf("mounts %v", hc.Mounts)
+	}
+	if cfg.Labels["gather.claw"] != "claw1" {
+		t.Errorf("labels %v", cfg.Labels)
+	}
+	if _, ok := cli.createdNet.EndpointsConfig["claws"]; !ok {
+		t.Errorf("networks %v", cli.createdNet.EndpointsConfig)
+	}
+	env := cfg.Env
+	for _, kv := range []string{"GATHER_AGENT_ID=agent1", "MODEL=large", "CUSTOM=kept", "TOOLS=all"} {
+		if !slices.Contains(env, kv) {
+			t.Errorf("env %v is missing %s", env, kv)
+		}
+	}
+}
+
+func TestResizeClawContainerRollback(t *testing.T) {
+	cases := []struct {
+		name       string
+		fail       string
+		notRunning bool
+		want       []string
+		brokenClaw bool // the old container couldn't be brought back
+	}{
+		{
+			name: "stop fails",
+			fail: "stop claw",
+			want: []string{"inspect claw", "stop claw", "start claw"},
+		},
+		{
+			name: "rename fails",
+			fail: "rename claw claw-pre-resize",
+			want: []string{"inspect claw", "stop claw", "rename claw claw-pre-resize", "start claw"},
+		},
+		{
+			name: "create fails",
+			fail: "create claw",
+			want: []string{"inspect claw", "stop claw", "rename claw claw-pre-resize", "create claw",
+				"rename claw-pre-resize claw", "start claw"},
+		},
+		{
+			name: "start fails",
+			fail: "start new1",
+			want: []string{"inspect claw", "stop claw", "rename claw claw-pre-resize", "create claw", "start new1",
+				"remove new1", "rename claw-pre-resize claw", "start claw"},
+		},
+		{
+			name:       "new container exits",
+			notRunning: true,
+			want: []string{"inspect claw", "stop claw", "rename claw claw-pre-resize", "create claw", "start new1", "inspect new1",
+				"remove new1", "rename claw-pre-resize claw", "start claw"},
+		},
+		{
+			name: "rollback rename fails",
+			fail: "rename claw-pre-resize claw",
+			want: []string{"inspect claw", "stop claw", "rename claw claw-pre-resize", "create claw", "start new1", "inspect new1",
+				"remove new1", "rename claw-pre-resize claw"},
+			notRunning: true,
+			brokenClaw: true,
+		},
+		{
+			name: "rollback start fails",
+			fail: "start claw",
+			want: []string{"inspect claw", "stop claw", "rename claw claw-pre-resize", "create claw", "start new1", "inspect new1",
+				"remove new1", "rename claw-pre-resize claw", "start claw"},
+			notRunning: true,
+			brokenClaw: true,
+		},
+	}
+	for _, tc := range cases {
+		cli := &stubDocker{fail: map[string]bool{tc.fail: true}, notRunning: tc.notRunning}
+		err := resizeClawContainer(context.Background(), cli, "claw", resizeFrom, resizeTo)
+		if err == nil {
+			t.Errorf("%s: resize succeeded", tc.name)
+			continue
+		}
+		if !slices.Equal(cli.calls, tc.want) {
+			t.Errorf("%s: calls:\n  %s\nwant:\n  %s", tc.name, strings.Join(cli.calls, "\n  "), strings.Join(tc.want, "\n  "))
+		}
+		if got := isResizeRollbackFailure(err); got != tc.brokenClaw {
+			t.Errorf("%s: rollback failure = %v (%v)", tc.name, got, err)
+		}
+	}
+
+	// Nothing is touched when the container can't be inspected
+	cli := &stubDocker{fail: map[string]bool{"inspect claw": true}}
+	if err := resizeClawContainer(context.Background(), cli, "claw", resizeFrom, resizeTo); err == nil || len(cli.calls) != 1 {
+		t.Errorf("inspect failure: %v after %v", err, cli.calls)
+	}
+}
+
+func TestResizeClawEnv(t *testing.T) {
+	env := []string{"GATHER_AGENT_ID=agent1", "MODEL=small", "EXTRA=1", "MODEL_OVERRIDE=mine"}
+	from := ClawProfile{Env: map[string]string{"MODEL": "small", "EXTRA": "1", "MODEL_OVERRIDE": "default"}}
+	to := ClawProfile{Env: map[string]string{"MODEL": "large", "MODEL_OVERRIDE": "big"}}
+	got := resizeClawEnv(env, from, to)
+	want := []string{"GATHER_AGENT_ID=agent1", "MODEL=large", "MODEL_OVERRIDE=mine"}
+	if !slices.Equal(got, want) {
+		t.Errorf("env = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Claw secret usage — which vault secrets running claws actually carry.
+// Responses here never include secret values.
+// -----------------------------------------------------------------------------
+
+// ClawSecretUsage is a vault secret described by its key and injection
+// history only.
+type ClawSecretUsage struct {
+	ID               string `json:"id"`
+	Key              string `json:"key"`
+	LastInjectedAt   string `json:"last_injected_at,omitempty" doc:"RFC3339; omitted if never injected"`
+	LastInjectedClaw string `json:"last_injected_claw,omitempty" doc:"Claw deployment ID it was last injected into"`
+	Created          string `json:"created"`
+}
+
+// SecretClaw is a running claw that carries a secret in its env.
+type SecretClaw struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UnusedClawSecretsInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer PocketBase user token" required:"true"`
+}
+
+type UnusedClawSecretsOutput struct {
+	Body struct {
+		Secrets []ClawSecretUsage `json:"secrets"`
+	}
+}
+
+type DeleteClawSecretInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer PocketBase user token" required:"true"`
+	ID            string `path:"id" doc:"Secret record ID"`
+}
+
+type DeleteClawSecretOutput struct {
+	Body struct {
+		Deleted       bool         `json:"deleted"`
+		Key           string       `json:"key"`
+		AffectedClaws []SecretClaw `json:"affected_claws" doc:"Running claws whose env still carries this key until they are redeployed"`
+	}
+}
+
+// StampSecretInjection records that secrets were just injected into a claw's
+// container. Raw updates so the secrets' updated time keeps meaning "value
+// changed".
+func StampSecretInjection(app *pocketbase.PocketBase, clawID string, secrets []*core.Record) {
+	now := time.Now().UTC().Format(pbDateTimeLayout)
+	for _, s := range secrets {
+		_, err := app.DB().NewQuery(
+			"UPDATE claw_secrets SET last_injected_at = {:now}, last_injected_claw = {:claw} WHERE id = {:id}").
+			Bind(map[string]any{"now": now, "claw": clawID, "id": s.Id}).Execute()
+		if err != nil {
+			app.Logger().Warn("Failed to stamp secret injection", "secret", s.Id, "claw", clawID, "error", err)
+		}
+	}
+}
+
+// runningUserClaws returns the user's claws that are currently running.
+func runningUserClaws(app *pocketbase.PocketBase, userID string) []*core.Record {
+	claws, err := app.FindRecordsByFilter("claw_deployments",
+		"user_id = {:uid} && status = 'running'", "name", 0, 0,
+		map[string]any{"uid": userID})
+	if err != nil {
+		return nil
+	}
+	return claws
+}
+
+// clawCarriesSecret reports whether a claw's container env includes the
+// secret's key. Claws provisioned before key tracking got every secret that
+// existed when they were created.
+func clawCarriesSecret(claw, secret *core.Record) bool {
+	raw := claw.GetString("secret_keys")
+	if raw == "" || raw == "null" {
+		return !secret.GetDateTime("created").Time().After(claw.GetDateTime("created").Time())
+	}
+	var keys []string
+	if err := json.Unmarshal([]byte(raw), &keys); err != nil {
+		return false
+	}
+	return slices.Contains(keys, secret.GetString("key"))
+}
+
+func clawSecretUsageItem(r *core.Record) ClawSecretUsage {
+	item := ClawSecretUsage{
+		ID:               r.Id,
+		Key:              r.GetString("key"),
+		LastInjectedClaw: r.GetString("last_injected_claw"),
+		Created:          r.GetDateTime("created").Time().UTC().Format(time.RFC3339),
+	}
+	if t := r.GetDateTime("last_injected_at"); !t.IsZero() {
+		item.LastInjectedAt = t.Time().UTC().Format(time.RFC3339)
+	}
+	return item
+}
+
+func RegisterClawSecretRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "list-unused-claw-secrets",
+		Method:      "GET",
+		Path:        "/api/claw-secrets/unused",
+		Summary:     "List vault secrets no running claw uses",
+		Description: "Your vault secrets that aren't in the env of any of your running claws — candidates for rotation or deletion. " +
+			"Includes when and where each was last injected. Values are never returned.",
+		Tags: []string{"Claws"},
+	}, func(ctx context.Context, input *UnusedClawSecretsInput) (*UnusedClawSecretsOutput, error) {
+		userID, err := extractPBUserID(app, input.Authorization)
+		if err != nil {
+			return nil, huma.Error401Unauthorized("Authentication required")
+		}
+
+		secrets, err := app.FindRecordsByFilter("claw_secrets",
+			"user_id = {:uid}", "key", 0, 0,
+			map[string]any{"uid": userID})
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to load secrets")
+		}
+		claws := runningUserClaws(app, userID)
+
+		out := &UnusedClawSecretsOutput{}
+		out.Body.Secrets = []ClawSecretUsage{}
+		for _, s := range secrets {
+			used := slices.ContainsFunc(claws, func(c *core.Record) bool { return clawCarriesSecret(c, s) })
+			if !used {
+				out.Body.Secrets = append(out.Body.Secrets, clawSecretUsageItem(s))
+			}
+		}
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-claw-secret",
+		Method:      "DELETE",
+		Path:        "/api/claw-secrets/{id}",
+		Summary:     "Delete a vault secret",
+		Description: "Deletes a vault secret and lists the running claws whose env still carries its key. " +
+			"Those claws keep the old value until they are redeployed, so restart them deliberately.",
+		Tags: []string{"Claws"},
+	}, func(ctx context.Context, input *DeleteClawSecretInput) (*DeleteClawSecretOutput, error) {
+		userID, err := extractPBUserID(app, input.Authorization)
+		if err != nil {
+			return nil, huma.Error401Unauthorized("Authentication required")
+		}
+
+		secret, err := app.FindRecordById("claw_secrets", input.ID)
+		if err != nil || secret.GetString("user_id") != userID {
+			return nil, huma.Error404NotFound("Secret not found")
+		}
+
+		affected := []SecretClaw{}
+		for _, c := range runningUserClaws(app, userID) {
+			if clawCarriesSecret(c, secret) {
+				affected = append(affected, SecretClaw{ID: c.Id, Name: c.GetString("name")})
+			}
+		}
+
+		if err := app.Delete(secret); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to delete secret")
+		}
+
+		out := &DeleteClawSecretOutput{}
+		out.Body.Deleted = true
+		out.Body.Key = secret.GetString("key")
+		out.Body.AffectedClaws = affected
+		return out, nil
+	})
+}
@@ -0,0 +1,382 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Claw sleep schedules
+// -----------------------------------------------------------------------------
+
+// A claw with a sleep_schedule is awake from wake_at to sleep_at (its
+// owner's local time) on the listed days and asleep otherwise. If sleep_at is
+// earlier than wake_at, the awake stretch runs past midnight into the next
+// day. The claw_sleep job stops the container when a sleep window starts,
+// setting status "sleeping", and starts it again at wake time. While asleep,
+// the claw's subdomain answers 503 with the wake time, and messages to it are
+// refused with the same. POST /api/claws/{id}/wake starts it early and keeps
+// it up until its next sleep window.
+
+const clawSleepEvery = 1 * time.Minute
+
+var clawSleepDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"} // indexed by time.Weekday
+
+// ClawSleepSchedule is a claw's daily awake window.
+type ClawSleepSchedule struct {
+	WakeAt   string   `json:"wake_at" doc:"When the claw starts each awake day, HH:MM in timezone"`
+	SleepAt  string   `json:"sleep_at" doc:"When it goes to sleep, HH:MM in timezone; before wake_at means the next day"`
+	Timezone string   `json:"timezone" doc:"IANA timezone, e.g. Europe/Berlin"`
+	Days     []string `json:"days" doc:"Days with an awake window, starting at wake_at: mon, tue, wed, thu, fri, sat, sun"`
+}
+
+// IsZero reports an empty schedule, which PATCH uses to remove one.
+func (s ClawSleepSchedule) IsZero() bool {
+	return s.WakeAt == "" && s.SleepAt == "" && s.Timezone == "" && len(s.Days) == 0
+}
+
+// clawSleepClock parses "HH:MM" into minutes after midnight.
+func clawSleepClock(v string) (int, error) {
+	h, m, ok := strings.Cut(v, ":")
+	hour, herr := strconv.Atoi(h)
+	minute, merr := strconv.Atoi(m)
+	if !ok || len(h) != 2 || len(m) != 2 || herr != nil || merr != nil || hour > 23 || minute > 59 || hour < 0 || minute < 0 {
+		return 0, fmt.Errorf("%q is not a time of day (HH:MM)", v)
+	}
+	return hour*60 + minute, nil
+}
+
+// Validate normalizes the schedule and checks it can be evaluated.
+func (s *ClawSleepSchedule) Validate() error {
+	wake, err := clawSleepClock(s.WakeAt)
+	if err != nil {
+		return fmt.Errorf("wake_at: %w", err)
+	}
+	sleep, err := clawSleepClock(s.SleepAt)
+	if err != nil {
+		return fmt.Errorf("sleep_at: %w", err)
+	}
+	if wake == sleep {
+		return fmt.Errorf("wake_at and sleep_at must differ")
+	}
+	if s.Timezone == "" {
+		return fmt.Errorf("timezone is required")
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("timezone %q is not an IANA timezone", s.Timezone)
+	}
+	var days []string
+	for _, d := range s.Days {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if len(d) > 3 {
+			d = d[:3]
+		}
+		if !slices.Contains(clawSleepDays, d) {
+			return fmt.Errorf("days: %q is not a day (mon … sun)", d)
+		}
+		if !slices.Contains(days, d) {
+			days = append(days, d)
+		}
+	}
+	if len(days) == 0 {
+		return fmt.Errorf("days must list at least one day")
+	}
+	s.Days = days
+	return nil
+}
+
+// awakeWindows lists the awake stretches that start on the days from two
+// days before t to eight days after it, in order.
+func (s ClawSleepSchedule) awakeWindows(t time.Time) ([][2]time.Time, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	wake, err := clawSleepClock(s.WakeAt)
+	if err != nil {
+		return nil, err
+	}
+	sleep, err := clawSleepClock(s.SleepAt)
+	if err != nil {
+		return nil, err
+	}
+
+	local := t.In(loc)
+	var windows [][2]time.Time
+	for offset := -2; offset <= 8; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+		if !slices.Contains(s.Days, clawSleepDays[day.Weekday()]) {
+			continue
+		}
+		// time.Date resolves clock times skipped or repeated by a DST
+		// change, so windows are always well-formed.
+		start := time.Date(day.Year(), day.Month(), day.Day(), wake/60, wake%60, 0, 0, loc)
+		endDay := day
+		if sleep < wake {
+			endDay = day.AddDate(0, 0, 1)
+		}
+		end := time.Date(endDay.Year(), endDay.Month(), endDay.Day(), sleep/60, sleep%60, 0, 0, loc)
+		if n := len(windows); n > 0 && !start.After(windows[n-1][1]) {
+			windows[n-1][1] = end // back-to-back windows: awake straight through
+			continue
+		}
+		windows = append(windows, [2]time.Time{start, end})
+	}
+	return windows, nil
+}
+
+// Asleep reports whether the schedule has the claw asleep at t, and when
+// that next changes: the wake time if asleep, the sleep time if awake. Wake
+// and sleep minutes belong to the window they start.
+func (s ClawSleepSchedule) Asleep(t time.Time) (asleep bool, until time.Time, err error) {
+	windows, err := s.awakeWindows(t)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	for _, w := range windows {
+		if t.Before(w[0]) {
+			return true, w[0], nil
+		}
+		if t.Before(w[1]) {
+			return false, w[1], nil
+		}
+	}
+	return true, time.Time{}, nil // no awake day within a week: can't happen for a valid schedule
+}
+
+// clawSleepSchedule reads a claw's schedule; ok is false if it has none.
+func clawSleepSchedule(r *core.Record) (ClawSleepSchedule, bool) {
+	var s ClawSleepSchedule
+	raw := r.GetString("sleep_schedule")
+	if raw == "" || raw == "null" || json.Unmarshal([]byte(raw), &s) != nil || s.IsZero() {
+		return ClawSleepSchedule{}, false
+	}
+	return s, true
+}
+
+// clawWakeTime is when a sleeping claw next wakes on its own, or zero.
+func clawWakeTime(r *core.Record) time.Time {
+	s, ok := clawSleepSchedule(r)
+	if !ok {
+		return time.Time{}
+	}
+	asleep, until, err := s.Asleep(time.Now())
+	if err != nil || !asleep {
+		return time.Time{}
+	}
+	return until
+}
+
+// clawSleepTime is when a running claw next goes to sleep, or zero.
+func clawSleepTime(r *core.Record) time.Time {
+	s, ok := clawSleepSchedule(r)
+	if !ok {
+		return time.Time{}
+	}
+	now := time.Now()
+	asleep, until, err := s.Asleep(now)
+	if err != nil {
+		return time.Time{}
+	}
+	if override := clawSleepOverride(r); override.After(now) {
+		// Woken early: it stays up through the awake window that follows.
+		if _, until, err = s.Asleep(override); err != nil {
+			return time.Time{}
+		}
+	} else if asleep {
+		return time.Time{} // due to sleep on the next tick
+	}
+	return until
+}
+
+// clawSleepOverride is the end of a manual wake, or zero.
+func clawSleepOverride(r *core.Record) time.Time {
+	t, err := time.Parse(time.RFC3339, r.GetString("sleep_override_until"))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// describeClawWake says when a sleeping claw is back, in its schedule's
+// timezone: "back at 09:00 CET" or "back Mon 09:00 CET".
+func describeClawWake(r *core.Record) string {
+	wake := clawWakeTime(r)
+	if wake.IsZero() {
+		return "it can be woken by its owner"
+	}
+	s, _ := clawSleepSchedule(r)
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := wake.In(loc)
+	if local.Sub(time.Now()) < 20*time.Hour {
+		return "back at " + local.Format("15:04 MST")
+	}
+	return "back " + local.Format("Mon 15:04 MST")
+}
+
+// clawAsleepError is the Huma response for a message to a sleeping claw.
+func clawAsleepError(r *core.Record) error {
+	msg := fmt.Sprintf("%s is asleep (%s). Wake it now with POST /api/claws/%s/wake.",
+		r.GetString("name"), describeClawWake(r), r.Id)
+	headers := http.Header{}
+	if wake := clawWakeTime(r); !wake.IsZero() {
+		headers.Set("Retry-After", strconv.Itoa(int(time.Until(wake).Seconds())+1))
+	}
+	return huma.ErrorWithHeaders(huma.Error503ServiceUnavailable(msg), headers)
+}
+
+// -----------------------------------------------------------------------------
+// Scheduler
+// -----------------------------------------------------------------------------
+
+// RegisterClawSleepJob stops claws entering their sleep window and starts
+// those whose wake time has come.
+func RegisterClawSleepJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("claw_sleep", clawSleepEvery, func(ctx context.Context) error {
+		return applyClawSleepSchedules(ctx, app)
+	}, jobs.RunOnStart())
+}
+
+func applyClawSleepSchedules(ctx context.Context, app *pocketbase.PocketBase) error {
+	records, err := app.FindRecordsByFilter("claw_deployments",
+		"(status = 'running' || status = 'sleeping') && container_id != ''", "", 0, 0, nil)
+	if err != nil {
+		return fmt.Errorf("load claws: %w", err)
+	}
+
+	now := time.Now()
+	for _, r := range records {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if clawResizing(r.Id) {
+			continue
+		}
+		s, scheduled := clawSleepSchedule(r)
+		asleep := false
+		if scheduled {
+			if asleep, _, err = s.Asleep(now); err != nil {
+				app.Logger().Warn("Claw sleep schedule unreadable", "claw", r.Id, "error", err)
+				continue
+			}
+			asleep = asleep && !clawSleepOverride(r).After(now)
+		}
+
+		switch status := r.GetString("status"); {
+		case status == "running" && asleep:
+			if clawActiveRequests(r.GetString("container_id")) > 0 {
+				continue // let the turn finish; try again next tick
+			}
+			if err := putClawToSleep(ctx, app, r); err != nil {
+				app.Logger().Warn("Failed to put claw to sleep", "claw", r.Id, "error", err)
+			}
+		case status == "sleeping" && !asleep:
+			if err := wakeClaw(ctx, app, r, time.Time{}); err != nil {
+				app.Logger().Warn("Failed to wake claw", "claw", r.Id, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+// putClawToSleep stops a running claw's container and marks it sleeping.
+func putClawToSleep(ctx context.Context, app *pocketbase.PocketBase, r *core.Record) error {
+	if err := stopClawContainer(ctx, r.GetString("container_id")); err != nil {
+		return err
+	}
+	r.Set("status", "sleeping")
+	r.Set("sleep_override_until", "")
+	if err := app.Save(r); err != nil {
+		return fmt.Errorf("save status: %w", err)
+	}
+	app.Logger().Info("Claw asleep", "claw", r.Id, "wakes", clawWakeTime(r))
+	return nil
+}
+
+// wakeClaw starts a sleeping claw's container and marks it running. A
+// non-zero overrideUntil keeps the schedule from putting it back to sleep
+// before then.
+func wakeClaw(ctx context.Context, app *pocketbase.PocketBase, r *core.Record, overrideUntil time.Time) error {
+	if err := startClawContainer(ctx, r.GetString("container_id")); err != nil {
+		return err
+	}
+	r.Set("status", "running")
+	r.Set("health_failures", 0)
+	if overrideUntil.IsZero() {
+		r.Set("sleep_override_until", "")
+	} else {
+		r.Set("sleep_override_until", overrideUntil.UTC().Format(time.RFC3339))
+	}
+	if err := app.Save(r); err != nil {
+		return fmt.Errorf("save status: %w", err)
+	}
+	app.Logger().Info("Claw awake", "claw", r.Id, "manual", !overrideUntil.IsZero())
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Manual wake
+// -----------------------------------------------------------------------------
+
+type WakeClawInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
+	ID            string `path:"id" doc:"Deployment ID"`
+}
+
+type WakeClawOutput struct {
+	Body ClawDeployment
+}
+
+func registerClawWakeRoute(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "wake-claw",
+		Method:      "POST",
+		Path:        "/api/claws/{id}/wake",
+		Summary:     "Wake a sleeping claw",
+		Description: "Starts a claw that its sleep_schedule has put to sleep. It stays awake until its next sleep window, " +
+			"skipping the rest of the current one. A claw that is already running is returned unchanged. Owner and operators.",
+		Tags: []string{"Claws"},
+	}, func(ctx context.Context, input *WakeClawInput) (*WakeClawOutput, error) {
+		record, userID, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOperator)
+		if err != nil {
+			return nil, err
+		}
+
+		switch record.GetString("status") {
+		case "running":
+		case "sleeping":
+			if clawResizing(record.Id) {
+				return nil, huma.Error409Conflict("Claw is being resized. Try again once the resize finishes.")
+			}
+			if err := wakeClaw(ctx, app, record, clawWakeTime(record)); err != nil {
+				return nil, huma.Error500InternalServerError(fmt.Sprintf("Wake failed: %v", err))
+			}
+			title := "Woken early by owner"
+			if record.GetString("user_id") != userID {
+				title = "Woken early by " + userDisplayName(app, userID)
+			}
+			RecordClawActivity(app, record.Id, ClawActivityStatus, title, "", nil)
+		default:
+			return nil, huma.Error409Conflict("Only a sleeping claw can be woken; this one is " + record.GetString("status"))
+		}
+
+		out := &WakeClawOutput{}
+		out.Body = recordToClawDeployment(record)
+		return out, nil
+	})
+}
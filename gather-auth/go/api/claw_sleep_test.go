@@ -0,0 +1,318 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const sleepLayout = "Mon 2006-01-02 15:04"
+
+var (
+	officeHours = ClawSleepSchedule{WakeAt: "09:00", SleepAt: "17:00", Timezone: "Europe/Berlin",
+		Days: []string{"mon", "tue", "wed", "thu", "fri"}}
+	weekendNights = ClawSleepSchedule{WakeAt: "22:00", SleepAt: "06:00", Timezone: "Europe/Berlin",
+		Days: []string{"fri", "sat"}}
+)
+
+// sleepAt parses a "Mon 2006-01-02 15:04" wall-clock time in tz.
+func sleepAt(t *testing.T, tz, v string) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at, err := time.ParseInLocation(sleepLayout, v, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return at
+}
+
+func TestClawSleepScheduleValidate(t *testing.T) {
+	s := ClawSleepSchedule{WakeAt: "09:00", SleepAt: "01:30", Timezone: "Asia/Tokyo", Days: []string{"Monday", " TUE ", "mon"}}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(s.Days, ",") != "mon,tue" {
+		t.Errorf("days normalized to %q", s.Days)
+	}
+
+	bad := map[string]ClawSleepSchedule{
+		"single-digit hour": {WakeAt: "9:00", SleepAt: "17:00", Timezone: "UTC", Days: []string{"mon"}},
+		"hour 24":           {WakeAt: "09:00", SleepAt: "24:00", Timezone: "UTC", Days: []string{"mon"}},
+		"minute 60":         {WakeAt: "09:60", SleepAt: "17:00", Timezone: "UTC", Days: []string{"mon"}},
+		"same times":        {WakeAt: "09:00", SleepAt: "09:00", Timezone: "UTC", Days: []string{"mon"}},
+		"no timezone":       {WakeAt: "09:00", SleepAt: "17:00", Days: []string{"mon"}},
+		"unknown timezone":  {WakeAt: "09:00", SleepAt: "17:00", Timezone: "Mars/Olympus", Days: []string{"mon"}},
+		"no days":           {WakeAt: "09:00", SleepAt: "17:00", Timezone: "UTC"},
+		"unknown day":       {WakeAt: "09:00", SleepAt: "17:00", Timezone: "UTC", Days: []string{"someday"}},
+	}
+	for name, s := range bad {
+		if err := s.Validate(); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestClawSleepScheduleAsleep(t *testing.T) {
+	cases := []struct {
+		name     string
+		schedule ClawSleepSchedule
+		at       string
+		asleep   bool
+		until    string
+	}{
+		{"before work", officeHours, "Fri 2026-03-27 08:59", true, "Fri 2026-03-27 09:00"},
+		{"wake minute is awake", officeHours, "Fri 2026-03-27 09:00", false, "Fri 2026-03-27 17:00"},
+		{"last awake minute", officeHours, "Fri 2026-03-27 16:59", false, "Fri 2026-03-27 17:00"},
+		{"sleep minute is asleep", officeHours, "Fri 2026-03-27 17:00", true, "Mon 2026-03-30 09:00"},
+		{"weekend", officeHours, "Sun 2026-03-29 12:00", true, "Mon 2026-03-30 09:00"},
+
+		{"before the night", weekendNights, "Fri 2026-03-27 21:59", true, "Fri 2026-03-27 22:00"},
+		{"Friday night", weekendNights, "Fri 2026-03-27 23:00", false, "Sat 2026-03-28 06:00"},
+		{"past midnight", weekendNights, "Sat 2026-03-28 05:59", false, "Sat 2026-03-28 06:00"},
+		{"Saturday day", weekendNights, "Sat 2026-03-28 06:00", true, "Sat 2026-03-28 22:00"},
+		{"Saturday night into Sunday", weekendNights, "Sun 2026-03-29 00:30", false, "Sun 2026-03-29 06:00"},
+		{"Sunday night has no window", weekendNights, "Sun 2026-03-29 23:00", true, "Fri 2026-04-03 22:00"},
+		{"midweek", weekendNights, "Wed 2026-04-01 12:00", true, "Fri 2026-04-03 22:00"},
+	}
+	for _, tc := range cases {
+		at := sleepAt(t, tc.schedule.Timezone, tc.at)
+		asleep, until, err := tc.schedule.Asleep(at)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		want := sleepAt(t, tc.schedule.Timezone, tc.until)
+		if asleep != tc.asleep || !until.Equal(want) {
+			t.Errorf("%s: asleep %v until %s, want %v until %s", tc.name,
+				asleep, until.In(want.Location()).Format(sleepLayout), tc.asleep, tc.until)
+		}
+
+		// The instant decides, not the zone it's given in
+		if a, u, _ := tc.schedule.Asleep(at.UTC()); a != asleep || !u.Equal(until) {
+			t.Errorf("%s: in UTC: asleep %v until %s", tc.name, a, u)
+		}
+	}
+}
+
+func TestClawSleepScheduleTimezone(t *testing.T) {
+	instant := sleepAt(t, "UTC", "Fri 2026-03-27 10:00")
+	berlin, newYork := officeHours, officeHours
+	newYork.Timezone = "America/New_York"
+	if asleep, _, _ := berlin.Asleep(instant); asleep {
+		t.Error("asleep at 11:00 in Berlin")
+	}
+	if asleep, until, _ := newYork.Asleep(instant); !asleep || !until.Equal(sleepAt(t, "UTC", "Fri 2026-03-27 13:00")) {
+		t.Errorf("New York at 06:00: asleep %v until %s", asleep, until.UTC())
+	}
+}
+
+func TestClawSleepScheduleDST(t *testing.T) {
+	// Awake and asleep stretches across a DST change keep their wall-clock
+	// ends, so they are an hour shorter or longer in real time
+	cases := []struct {
+		name     string
+		schedule ClawSleepSchedule
+		at       string
+		length   time.Duration
+	}{
+		{"Berlin spring forward", weekendNights, "Sat 2026-03-28 22:00", 7 * time.Hour},
+		{"Berlin fall back", weekendNights, "Sat 2026-10-24 22:00", 9 * time.Hour},
+		{"New York spring forward", ClawSleepSchedule{WakeAt: "22:00", SleepAt: "06:00", Timezone: "America/New_York",
+			Days: []string{"sat"}}, "Sat 2026-03-07 22:00", 7 * time.Hour},
+		{"New York fall back", ClawSleepSchedule{WakeAt: "22:00", SleepAt: "06:00", Timezone: "America/New_York",
+			Days: []string{"sat"}}, "Sat 2026-10-31 22:00", 9 * time.Hour},
+		{"weekend across spring forward", officeHours, "Fri 2026-03-27 17:00", 64*time.Hour - time.Hour},
+	}
+	for _, tc := range cases {
+		at := sleepAt(t, tc.schedule.Timezone, tc.at)
+		_, until, err := tc.schedule.Asleep(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := until.Sub(at); got != tc.length {
+			t.Errorf("%s: lasts %s, want %s", tc.name, got, tc.length)
+		}
+	}
+
+	// A wake time skipped by spring forward falls inside the hour after the
+	// jump; one repeated by fall back is used once
+	gap := ClawSleepSchedule{WakeAt: "02:30", SleepAt: "09:00", Timezone: "Europe/Berlin", Days: []string{"sun"}}
+	if asleep, until, _ := gap.Asleep(sleepAt(t, "Europe/Berlin", "Sun 2026-03-29 01:59")); !asleep ||
+		until.Before(sleepAt(t, "Europe/Berlin", "Sun 2026-03-29 03:00")) ||
+		until.After(sleepAt(t, "Europe/Berlin", "Sun 2026-03-29 03:30")) {
+		t.Errorf("skipped wake time: asleep %v until %s", asleep, until)
+	}
+	if asleep, until, _ := gap.Asleep(sleepAt(t, "Europe/Berlin", "Sun 2026-03-29 04:00")); asleep ||
+		!until.Equal(sleepAt(t, "Europe/Berlin", "Sun 2026-03-29 09:00")) {
+		t.Errorf("after the jump: asleep %v until %s", asleep, until)
+	}
+	fallBack := sleepAt(t, "UTC", "Sun 2026-10-25 01:45") // 02:45 CET, the second 02:45
+	if asleep, until, _ := gap.Asleep(fallBack); asleep || !until.Equal(sleepAt(t, "Europe/Berlin", "Sun 2026-10-25 09:00")) {
+		t.Errorf("repeated hour: asleep %v until %s", asleep, until)
+	}
+}
+
+// fakeDocker answers the container stop and start calls the sleep scheduler
+// makes. Containers named in fail get a 500.
+type fakeDocker struct {
+	mu    sync.Mutex
+	calls []string
+	fail  map[string]bool
+}
+
+func newFakeDocker(t *testing.T) *fakeDocker {
+	t.Helper()
+	d := &fakeDocker{fail: map[string]bool{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Api-Version", "1.45")
+		if strings.HasSuffix(r.URL.Path, "/_ping") {
+			w.Write([]byte("OK"))
+			return
+		}
+		parts := strings.Split(r.URL.Path, "/")
+		if len(parts) < 3 || parts[len(parts)-3] != "containers" {
+			http.NotFound(w, r)
+			return
+		}
+		id, op := parts[len(parts)-2], parts[len(parts)-1]
+		d.mu.Lock()
+		d.calls = append(d.calls, op+" "+id)
+		fail := d.fail[id]
+		d.mu.Unlock()
+		if fail {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"message": "daemon refused"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("DOCKER_HOST", "tcp://"+strings.TrimPrefix(srv.URL, "http://"))
+	t.Setenv("DOCKER_API_VERSION", "")
+	return d
+}
+
+func (d *fakeDocker) called() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return strings.Join(d.calls, ", ")
+}
+
+// scheduleAround returns an every-day schedule that has the claw awake at
+// now, or asleep if awake is false.
+func scheduleAround(now time.Time, awake bool) ClawSleepSchedule {
+	wake, sleep := now.Add(-time.Hour), now.Add(time.Hour)
+	if !awake {
+		wake, sleep = now.Add(2*time.Hour), now.Add(3*time.Hour)
+	}
+	return ClawSleepSchedule{WakeAt: wake.UTC().Format("15:04"), SleepAt: sleep.UTC().Format("15:04"), Timezone: "UTC",
+		Days: clawSleepDays}
+}
+
+func TestApplyClawSleepSchedules(t *testing.T) {
+	docker := newFakeDocker(t)
+	app := newTestApp(t)
+	addCollection(t, app, "claw_deployments", "name", "status", "container_id", "sleep_schedule:json",
+		"sleep_override_until", "health_failures:number")
+	now := time.Now()
+	asleep, awake := scheduleAround(now, false), scheduleAround(now, true)
+
+	claw := func(container, status string, schedule *ClawSleepSchedule, override time.Time) string {
+		values := map[string]any{"name": container, "status": status, "container_id": container, "health_failures": 2}
+		if schedule != nil {
+			values["sleep_schedule"] = schedule
+		}
+		if !override.IsZero() {
+			values["sleep_override_until"] = override.UTC().Format(time.RFC3339)
+		}
+		return addRecord(t, app, "claw_deployments", values).Id
+	}
+	bedtime := claw("bedtime", "running", &asleep, time.Time{})
+	morning := claw("morning", "sleeping", &awake, time.Time{})
+	woken := claw("woken", "running", &asleep, now.Add(time.Hour))
+	lapsed := claw("lapsed", "running", &asleep, now.Add(-time.Minute))
+	unscheduled := claw("unscheduled", "sleeping", nil, time.Time{})
+	always := claw("always", "running", nil, time.Time{})
+	stuck := claw("stuck", "running", &asleep, time.Time{})
+	docker.fail["stuck"] = true
+
+	if err := applyClawSleepSchedules(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		bedtime: "sleeping", morning: "running", woken: "running", lapsed: "sleeping",
+		unscheduled: "running", always: "running", stuck: "running",
+	}
+	for id, status := range want {
+		r := mustFind(t, app, id)
+		if got := r.GetString("status"); got != status {
+			t.Errorf("%s: %s, want %s", r.GetString("name"), got, status)
+		}
+	}
+	if r := mustFind(t, app, morning); r.GetInt("health_failures") != 0 || r.GetString("sleep_override_until") != "" {
+		t.Errorf("woken claw: health_failures %d, override %q", r.GetInt("health_failures"), r.GetString("sleep_override_until"))
+	}
+	if r := mustFind(t, app, lapsed); r.GetString("sleep_override_until") != "" {
+		t.Errorf("lapsed override kept: %q", r.GetString("sleep_override_until"))
+	}
+	for _, call := range []string{"stop bedtime", "start morning", "stop lapsed", "start unscheduled", "stop stuck"} {
+		if !strings.Contains(docker.called(), call) {
+			t.Errorf("no %q in %s", call, docker.called())
+		}
+	}
+	if c := docker.called(); strings.Contains(c, "woken") || strings.Contains(c, "always") {
+		t.Errorf("untouched claws called: %s", c)
+	}
+
+	// The next tick only retries the claw that failed to stop
+	before := docker.called()
+	delete(docker.fail, "stuck")
+	if err := applyClawSleepSchedules(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimPrefix(docker.called(), before); got != ", stop stuck" {
+		t.Errorf("second tick called %q", got)
+	}
+}
+
+func TestManualWakeKeepsClawUp(t *testing.T) {
+	newFakeDocker(t)
+	app := newTestApp(t)
+	addCollection(t, app, "claw_deployments", "name", "status", "container_id", "sleep_schedule:json",
+		"sleep_override_until", "health_failures:number")
+	schedule := scheduleAround(time.Now(), false)
+	r := addRecord(t, app, "claw_deployments", map[string]any{
+		"name": "nap", "status": "sleeping", "container_id": "nap", "sleep_schedule": schedule,
+	})
+
+	// As POST /api/claws/{id}/wake does
+	wakeAt := clawWakeTime(r)
+	if wakeAt.IsZero() {
+		t.Fatal("no wake time for a sleeping claw")
+	}
+	if err := wakeClaw(context.Background(), app, r, wakeAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyClawSleepSchedules(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+	r = mustFind(t, app, r.Id)
+	if r.GetString("status") != "running" {
+		t.Fatalf("woken claw put back to sleep")
+	}
+
+	// It stays up through the window after the early wake
+	_, windowEnd, _ := schedule.Asleep(wakeAt)
+	if got := clawSleepTime(r); !got.Equal(windowEnd) {
+		t.Errorf("sleeps at %s, want the end of the next window %s", got, windowEnd)
+	}
+}
@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Claw tasks — one claw hands work to another
+// -----------------------------------------------------------------------------
+
+// A task is a request/response pair between two claws: the sender files an
+// instruction, the target accepts, completes (with a result) or rejects it.
+// Both sides act with their claw's own agent JWT. A claw may send tasks to
+// claws of the same owner, or to any claw whose owner turned on
+// accept_public_tasks. The target hears about a task through its inbox (and
+// right away through its bridge with push); the sender through its inbox
+// when the task finishes, or by polling ?direction=sent.
+
+const (
+	ClawTaskPending   = "pending"
+	ClawTaskAccepted  = "accepted"
+	ClawTaskCompleted = "completed"
+	ClawTaskRejected  = "rejected"
+
+	// clawTaskInboxPreview is how much of an instruction or result fits in
+	// an inbox message; the task itself has all of it.
+	clawTaskInboxPreview = 1500
+
+	// clawTasksSentPerDay and clawTasksReceivedPerDay cap tasks per claw in
+	// any 24 hours, so a looping claw can't flood another.
+	clawTasksSentPerDay     = 50
+	clawTasksReceivedPerDay = 200
+)
+
+// clawTaskTransitions are the status changes the target claw may make.
+var clawTaskTransitions = map[string][]string{
+	ClawTaskPending:  {ClawTaskAccepted, ClawTaskCompleted, ClawTaskRejected},
+	ClawTaskAccepted: {ClawTaskCompleted, ClawTaskRejected},
+}
+
+type ClawTask struct {
+	ID          string `json:"id"`
+	FromClaw    string `json:"from_claw"`
+	FromName    string `json:"from_name,omitempty"`
+	ToClaw      string `json:"to_claw"`
+	ToName      string `json:"to_name,omitempty"`
+	Instruction string `json:"instruction"`
+	Status      string `json:"status" enum:"pending,accepted,completed,rejected"`
+	Result      string `json:"result,omitempty" doc:"The target's result, or its reason for rejecting"`
+	AcceptedAt  string `json:"accepted_at,omitempty"`
+	FinishedAt  string `json:"finished_at,omitempty" doc:"When the task was completed or rejected"`
+	Created     string `json:"created"`
+	Updated     string `json:"updated,omitempty"`
+}
+
+func recordToClawTask(r *core.Record) ClawTask {
+	return ClawTask{
+		ID:          r.Id,
+		FromClaw:    r.GetString("from_claw"),
+		FromName:    r.GetString("from_name"),
+		ToClaw:      r.GetString("to_claw"),
+		ToName:      r.GetString("to_name"),
+		Instruction: r.GetString("instruction"),
+		Status:      r.GetString("status"),
+		Result:      r.GetString("result"),
+		AcceptedAt:  r.GetString("accepted_at"),
+		FinishedAt:  r.GetString("finished_at"),
+		Created:     recordTime(r, "created"),
+		Updated:     recordTime(r, "updated"),
+	}
+}
+
+type CreateClawTaskInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT of the sending claw's agent" required:"true"`
+	ID            string `path:"id" doc:"Target claw deployment ID"`
+	Body          struct {
+		FromClawID  string `json:"from_claw_id" doc:"Your own claw's deployment ID" minLength:"1" maxLength:"50"`
+		Instruction string `json:"instruction" doc:"What the target claw should do" minLength:"1" maxLength:"10000"`
+		Push        bool   `json:"push,omitempty" doc:"Also hand the task to the target claw right away through its bridge, if it is running and idle. Otherwise it waits in the target's inbox."`
+	}
+}
+
+type CreateClawTaskOutput struct {
+	Body ClawTask
+}
+
+type ListClawTasksInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT of the claw's agent" required:"true"`
+	ID            string `path:"id" doc:"Your claw's deployment ID"`
+	Direction     string `query:"direction" default:"received" enum:"received,sent" doc:"Tasks sent to this claw, or sent by it"`
+	Status        string `query:"status" enum:"pending,accepted,completed,rejected" doc:"Only tasks in this status"`
+	Limit         int    `query:"limit" default:"50" minimum:"1" maximum:"200"`
+	Offset        int    `query:"offset" default:"0" minimum:"0"`
+}
+
+type ListClawTasksOutput struct {
+	Body struct {
+		Tasks []ClawTask `json:"tasks"`
+		Total int        `json:"total"`
+	}
+}
+
+type UpdateClawTaskInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT of the target claw's agent" required:"true"`
+	ID            string `path:"id" doc:"Your claw's deployment ID (the task's target)"`
+	TaskID        string `path:"task_id" doc:"Task ID"`
+	Body          struct {
+		Status string `json:"status" enum:"accepted,completed,rejected" doc:"accepted while you work on it; completed or rejected when done"`
+		Result string `json:"result,omitempty" doc:"The result (completed) or reason (rejected)" maxLength:"50000"`
+	}
+}
+
+type UpdateClawTaskOutput struct {
+	Body ClawTask
+}
+
+// -----------------------------------------------------------------------------
+// Route registration
+// -----------------------------------------------------------------------------
+
+func RegisterClawTaskRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	// POST /api/claws/{id}/tasks
+	huma.Register(api, huma.Operation{
+		OperationID: "create-claw-task",
+		Method:      "POST",
+		Path:        "/api/claws/{id}/tasks",
+		Summary:     "Hand a task to another claw",
+		Description: "Called by a claw with its own agent JWT. Files a task for the claw {id}, which must have the same owner as from_claw_id " +
+			"or have accept_public_tasks on. The target gets it in its inbox, and with push also through its bridge right away. " +
+			fmt.Sprintf("A claw may send %d and receive %d tasks a day.", clawTasksSentPerDay, clawTasksReceivedPerDay),
+		Tags:          []string{"Claws"},
+		DefaultStatus: 201,
+	}, func(ctx context.Context, input *CreateClawTaskInput) (*CreateClawTaskOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		from, err := requireOwnClaw(app, input.Body.FromClawID, claims.AgentID)
+		if err != nil {
+			return nil, err
+		}
+
+		to, err := app.FindRecordById("claw_deployments", input.ID)
+		if err != nil || to.GetString("status") == "deleted" || to.GetString("agent_id") == "" {
+			return nil, huma.Error404NotFound("Target claw not found")
+		}
+		if to.Id == from.Id {
+			return nil, huma.Error422UnprocessableEntity("A claw can't hand a task to itself")
+		}
+		if err := clawTaskAllowed(from, to); err != nil {
+			return nil, err
+		}
+
+		instruction := strings.TrimSpace(input.Body.Instruction)
+		if instruction == "" {
+			return nil, huma.Error422UnprocessableEntity("instruction is empty")
+		}
+		if n := clawTasksToday(app, "from_claw", from.Id); n >= clawTasksSentPerDay {
+			return nil, huma.Error429TooManyRequests(fmt.Sprintf("This claw has sent %d tasks in the last 24 hours, the daily limit", n))
+		}
+		if n := clawTasksToday(app, "to_claw", to.Id); n >= clawTasksReceivedPerDay {
+			return nil, huma.Error429TooManyRequests("The target claw has received its daily limit of tasks; try again later")
+		}
+
+		col, err := app.FindCollectionByNameOrId("claw_tasks")
+		if err != nil {
+			return nil, huma.Error500InternalServerError("claw_tasks collection not found")
+		}
+		task := core.NewRecord(col)
+		task.Set("from_claw", from.Id)
+		task.Set("from_agent", from.GetString("agent_id"))
+		task.Set("from_name", from.GetString("name"))
+		task.Set("to_claw", to.Id)
+		task.Set("to_agent", to.GetString("agent_id"))
+		task.Set("to_name", to.GetString("name"))
+		task.Set("instruction", instruction)
+		task.Set("status", ClawTaskPending)
+		if err := app.Save(task); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to create task")
+		}
+
+		SendInboxMessage(app, to.GetString("agent_id"), "claw_task",
+			fmt.Sprintf("Task from %s", from.GetString("name")),
+			clawTaskMessage(task, clawTaskInboxPreview), "claw_task", task.Id)
+		if input.Body.Push {
+			go pushClawTask(app, to, task)
+		}
+
+		out := &CreateClawTaskOutput{}
+		out.Body = recordToClawTask(task)
+		return out, nil
+	})
+
+	// GET /api/claws/{id}/tasks
+	huma.Register(api, huma.Operation{
+		OperationID: "list-claw-tasks",
+		Method:      "GET",
+		Path:        "/api/claws/{id}/tasks",
+		Summary:     "List a claw's tasks",
+		Description: "Called by the claw with its own agent JWT. Tasks handed to it (?direction=received, the default) " +
+			"or by it (?direction=sent), newest first. Poll sent tasks to pick up results.",
+		Tags: []string{"Claws"},
+	}, func(ctx context.Context, input *ListClawTasksInput) (*ListClawTasksOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		claw, err := requireOwnClaw(app, input.ID, claims.AgentID)
+		if err != nil {
+			return nil, err
+		}
+
+		filter := "to_claw = {:cid}"
+		if input.Direction == "sent" {
+			filter = "from_claw = {:cid}"
+		}
+		params := map[string]any{"cid": claw.Id}
+		if input.Status != "" {
+			filter += " && status = {:status}"
+			params["status"] = input.Status
+		}
+
+		records, _ := app.FindRecordsByFilter("claw_tasks", filter, "-created", input.Limit, input.Offset, params)
+
+		total := len(records)
+		if all, err := app.FindRecordsByFilter("claw_tasks", filter, "", 0, 0, params); err == nil {
+			total = len(all)
+		}
+
+		out := &ListClawTasksOutput{}
+		out.Body.Tasks = make([]ClawTask, 0, len(records))
+		for _, r := range records {
+			out.Body.Tasks = append(out.Body.Tasks, recordToClawTask(r))
+		}
+		out.Body.Total = total
+		return out, nil
+	})
+
+	// PATCH /api/claws/{id}/tasks/{task_id}
+	huma.Register(api, huma.Operation{
+		OperationID: "update-claw-task",
+		Method:      "PATCH",
+		Path:        "/api/claws/{id}/tasks/{task_id}",
+		Summary:     "Accept, complete or reject a task",
+		Description: "Called by the target claw with its own agent JWT. pending → accepted, completed or rejected; accepted → completed or rejected. " +
+			"Completing or rejecting notifies the sending claw through its inbox, with the result.",
+		Tags: []string{"Claws"},
+	}, func(ctx context.Context, input *UpdateClawTaskInput) (*UpdateClawTaskOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		claw, err := requireOwnClaw(app, input.ID, claims.AgentID)
+		if err != nil {
+			return nil, err
+		}
+
+		task, err := app.FindRecordById("claw_tasks", input.TaskID)
+		if err != nil || task.GetString("to_claw") != claw.Id {
+			return nil, huma.Error404NotFound("Task not found")
+		}
+		current := task.GetString("status")
+		if !containsString(clawTaskTransitions[current], input.Body.Status) {
+			return nil, huma.Error409Conflict(fmt.Sprintf("Task is %s and can't become %s", current, input.Body.Status))
+		}
+
+		now := time.Now().UTC().Format(time.RFC3339)
+		task.Set("status", input.Body.Status)
+		if input.Body.Status == ClawTaskAccepted {
+			task.Set("accepted_at", now)
+		} else {
+			task.Set("result", input.Body.Result)
+			task.Set("finished_at", now)
+		}
+		if err := app.Save(task); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to update task")
+		}
+
+		if input.Body.Status != ClawTaskAccepted {
+			SendInboxMessage(app, task.GetString("from_agent"), "claw_task_result",
+				fmt.Sprintf("Task %s by %s", input.Body.Status, task.GetString("to_name")),
+				clawTaskResultMessage(task), "claw_task", task.Id)
+		}
+
+		out := &UpdateClawTaskOutput{}
+		out.Body = recordToClawTask(task)
+		return out, nil
+	})
+}
+
+// requireOwnClaw returns the claw if agentID is its own agent.
+func requireOwnClaw(app *pocketbase.PocketBase, clawID, agentID string) (*core.Record, error) {
+	claw, err := app.FindRecordById("claw_deployments", clawID)
+	if err != nil || claw.GetString("status") == "deleted" {
+		return nil, huma.Error404NotFound("Claw not found")
+	}
+	if claw.GetString("agent_id") == "" || claw.GetString("agent_id") != agentID {
+		return nil, huma.Error403Forbidden("Only the claw's own agent can do this")
+	}
+	return claw, nil
+}
+
+// clawTaskAllowed reports whether from may hand tasks to to: same owner, or
+// the target accepts public tasks.
+func clawTaskAllowed(from, to *core.Record) error {
+	if from.GetString("user_id") != "" && from.GetString("user_id") == to.GetString("user_id") {
+		return nil
+	}
+	if to.GetBool("accept_public_tasks") {
+		return nil
+	}
+	return huma.Error403Forbidden("The target claw belongs to someone else and doesn't accept public tasks")
+}
+
+// clawTasksToday counts the tasks a claw sent (field from_claw) or received
+// (to_claw) in the last 24 hours.
+func clawTasksToday(app *pocketbase.PocketBase, field, clawID string) int {
+	since := time.Now().UTC().Add(-24 * time.Hour).Format(pbDateTimeLayout)
+	recs, err := app.FindRecordsByFilter("claw_tasks",
+		field+" = {:cid} && created > {:since}", "", 0, 0,
+		map[string]any{"cid": clawID, "since": since})
+	if err != nil {
+		return 0
+	}
+	return len(recs)
+}
+
+// clawTaskMessage tells the target about a task, with the instruction cut
+// to limit bytes (0 for all of it).
+func clawTaskMessage(task *core.Record, limit int) string {
+	instruction := task.GetString("instruction")
+	if limit > 0 {
+		instruction = truncate(instruction, limit)
+	}
+	return fmt.Sprintf("%s\n\n— Task %s from claw %s. Accept, complete or reject it with PATCH /api/claws/%s/tasks/%s.",
+		instruction, task.Id, task.GetString("from_name"), task.GetString("to_claw"), task.Id)
+}
+
+func clawTaskResultMessage(task *core.Record) string {
+	result := task.GetString("result")
+	if result == "" {
+		result = "(no result given)"
+	}
+	return fmt.Sprintf("%s\n\n— Task %s (%s). Full result: GET /api/claws/%s/tasks?direction=sent",
+		truncate(result, clawTaskInboxPreview), task.Id, truncate(task.GetString("instruction"), 200), task.GetString("from_claw"))
+}
+
+// pushClawTask hands a new task to the target claw through its bridge, the
+// way heartbeats are delivered, and saves the reply in its channel. Best
+// effort: a stopped or busy claw still has the task in its inbox.
+func pushClawTask(app *pocketbase.PocketBase, to, task *core.Record) {
+	containerID := to.GetString("container_id")
+	if to.GetString("status") != "running" || containerID == "" {
+		return
+	}
+	release, err := acquireClawSlot(containerID, to.GetString("claw_type"))
+	if err != nil {
+		return
+	}
+	defer release()
+
+	msg := "[TASK] " + clawTaskMessage(task, 0)
+	result, err := sendToADK(context.Background(), containerID, to.GetString("claw_type"), "claw-task", msg)
+	if err != nil {
+		app.Logger().Warn("Claw task push failed", "claw", to.Id, "task", task.Id, "error", err)
+		return
+	}
+
+	agentID := to.GetString("agent_id")
+	channelID, err := findClawChannel(app, agentID)
+	if err != nil || result.Text == "" {
+		return
+	}
+	col, err := app.FindCollectionByNameOrId("channel_messages")
+	if err != nil {
+		return
+	}
+	rec := core.NewRecord(col)
+	rec.Set("channel_id", channelID)
+	rec.Set("author_id", agentID)
+	rec.Set("body", result.Text)
+	if events := encodeClawMsgEvents(result.Events, nil, 0); events != nil {
+		rec.Set("events", string(events))
+	}
+	if err := app.Save(rec); err != nil {
+		app.Logger().Warn("Failed to save claw task reply", "claw", to.Id, "task", task.Id, "error", err)
+	}
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+)
+
+type clawTaskFixture struct {
+	app *pocketbase.PocketBase
+	api humatest.TestAPI
+	kr  *auth.Keyring
+}
+
+func newClawTaskFixture(t *testing.T) *clawTaskFixture {
+	t.Helper()
+	app := newTestApp(t)
+	addCollection(t, app, "claw_deployments", "name", "status", "agent_id", "user_id", "accept_public_tasks:bool",
+		"container_id", "claw_type")
+	addCollection(t, app, "claw_tasks", "from_claw", "from_agent", "from_name", "to_claw", "to_agent", "to_name",
+		"instruction", "status", "result", "accepted_at", "finished_at")
+	addCollection(t, app, "messages", "agent_id", "from_agent_id", "type", "subject", "body",
+		"read:bool", "ref_type", "ref_id")
+	f := &clawTaskFixture{app: app, kr: newTestKeyring(t)}
+	_, f.api = humatest.New(t)
+	RegisterClawTaskRoutes(f.api, app, f.kr)
+	return f
+}
+
+// claw adds a deployment owned by userID, run by agent name+"-agent".
+func (f *clawTaskFixture) claw(t *testing.T, name, userID string, public bool) *core.Record {
+	t.Helper()
+	return addRecord(t, f.app, "claw_deployments", map[string]any{
+		"name": name, "status": "stopped", "agent_id": name + "-agent", "user_id": userID, "accept_public_tasks": public,
+	})
+}
+
+func (f *clawTaskFixture) send(t *testing.T, agentID, fromClaw, toClaw, instruction string) (int, ClawTask) {
+	t.Helper()
+	resp := f.api.Post("/api/claws/"+toClaw+"/tasks", bearer(t, f.kr, agentID), map[string]any{
+		"from_claw_id": fromClaw, "instruction": instruction,
+	})
+	var task ClawTask
+	if resp.Code == http.StatusCreated {
+		decodeBody(t, resp, &task)
+	}
+	return resp.Code, task
+}
+
+func (f *clawTaskFixture) inbox(t *testing.T, agentID, msgType string) []*core.Record {
+	t.Helper()
+	recs, err := f.app.FindRecordsByFilter("messages", "agent_id = {:a} && type = {:type}", "", 0, 0,
+		map[string]any{"a": agentID, "type": msgType})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recs
+}
+
+func TestClawTaskOwnership(t *testing.T) {
+	f := newClawTaskFixture(t)
+	research := f.claw(t, "research", "u1", false)
+	builder := f.claw(t, "builder", "u1", false)
+	private := f.claw(t, "private", "u2", false)
+	public := f.claw(t, "public", "u2", true)
+	orphan := f.claw(t, "orphan", "", false)
+	orphan2 := f.claw(t, "orphan2", "", false)
+	gone := f.claw(t, "gone", "u1", false)
+	gone.Set("status", "deleted")
+	if err := f.app.Save(gone); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		agent    string
+		from, to *core.Record
+		want     int
+	}{
+		{"same owner", "research-agent", research, builder, 201},
+		{"other owner", "research-agent", research, private, 403},
+		{"other owner, public tasks", "research-agent", research, public, 201},
+		{"neither has an owner", "orphan-agent", orphan, orphan2, 403},
+		{"no owner to a public claw", "orphan-agent", orphan, public, 201},
+		{"someone else's claw as sender", "builder-agent", research, builder, 403},
+		{"deleted target", "research-agent", research, gone, 404},
+		{"deleted sender", "gone-agent", gone, research, 404},
+		{"to itself", "research-agent", research, research, 422},
+	}
+	for _, tc := range cases {
+		if code, _ := f.send(t, tc.agent, tc.from.Id, tc.to.Id, "summarize X"); code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, code, tc.want)
+		}
+	}
+	if n := len(f.inbox(t, "private-agent", "claw_task")); n != 0 {
+		t.Errorf("refused task reached the target's inbox %d times", n)
+	}
+}
+
+func TestClawTaskLifecycle(t *testing.T) {
+	f := newClawTaskFixture(t)
+	research := f.claw(t, "research", "u1", false)
+	builder := f.claw(t, "builder", "u1", false)
+
+	code, task := f.send(t, "research-agent", research.Id, builder.Id, "  summarize X for builder  ")
+	if code != http.StatusCreated || task.Status != ClawTaskPending || task.Instruction != "summarize X for builder" {
+		t.Fatalf("create: %d %+v", code, task)
+	}
+	msgs := f.inbox(t, "builder-agent", "claw_task")
+	if len(msgs) != 1 || !strings.Contains(msgs[0].GetString("body"), "summarize X") || msgs[0].GetString("ref_id") != task.ID {
+		t.Fatalf("target inbox: %d messages", len(msgs))
+	}
+
+	patch := func(agent, clawID, status, result string) int {
+		t.Helper()
+		resp := f.api.Patch("/api/claws/"+clawID+"/tasks/"+task.ID, bearer(t, f.kr, agent),
+			map[string]any{"status": status, "result": result})
+		return resp.Code
+	}
+	// Only the target moves the task along
+	if code := patch("research-agent", research.Id, ClawTaskAccepted, ""); code != http.StatusNotFound {
+		t.Errorf("sender accepting its own task: %d", code)
+	}
+	if code := patch("research-agent", builder.Id, ClawTaskAccepted, ""); code != http.StatusForbidden {
+		t.Errorf("sender acting as the target: %d", code)
+	}
+	if code := patch("builder-agent", builder.Id, ClawTaskAccepted, ""); code != http.StatusOK {
+		t.Fatalf("accept: %d", code)
+	}
+	if n := len(f.inbox(t, "research-agent", "claw_task_result")); n != 0 {
+		t.Errorf("accepting notified the sender %d times", n)
+	}
+	if code := patch("builder-agent", builder.Id, ClawTaskCompleted, "X is about Y"); code != http.StatusOK {
+		t.Fatalf("complete: %d", code)
+	}
+	if code := patch("builder-agent", builder.Id, ClawTaskRejected, "changed my mind"); code != http.StatusConflict {
+		t.Errorf("reject after completion: %d", code)
+	}
+
+	results := f.inbox(t, "research-agent", "claw_task_result")
+	if len(results) != 1 || !strings.Contains(results[0].GetString("body"), "X is about Y") {
+		t.Errorf("sender inbox: %d result messages", len(results))
+	}
+
+	var sent ListClawTasksOutput
+	decodeBody(t, f.api.Get("/api/claws/"+research.Id+"/tasks?direction=sent", bearer(t, f.kr, "research-agent")), &sent.Body)
+	if sent.Body.Total != 1 || sent.Body.Tasks[0].Status != ClawTaskCompleted || sent.Body.Tasks[0].Result != "X is about Y" ||
+		sent.Body.Tasks[0].AcceptedAt == "" || sent.Body.Tasks[0].FinishedAt == "" {
+		t.Errorf("sent: %+v", sent.Body)
+	}
+	var received ListClawTasksOutput
+	decodeBody(t, f.api.Get("/api/claws/"+research.Id+"/tasks", bearer(t, f.kr, "research-agent")), &received.Body)
+	if received.Body.Total != 0 {
+		t.Errorf("sender received %d tasks", received.Body.Total)
+	}
+	if resp := f.api.Get("/api/claws/"+builder.Id+"/tasks", bearer(t, f.kr, "research-agent")); resp.Code != http.StatusForbidden {
+		t.Errorf("listing another claw's tasks: %d", resp.Code)
+	}
+}
+
+func TestClawTaskLimits(t *testing.T) {
+	f := newClawTaskFixture(t)
+	research := f.claw(t, "research", "u1", false)
+	builder := f.claw(t, "builder", "u1", false)
+	busy := f.claw(t, "busy", "u1", false)
+
+	if code, _ := f.send(t, "research-agent", research.Id, builder.Id, strings.Repeat("x", 10001)); code != http.StatusUnprocessableEntity {
+		t.Errorf("oversized instruction: %d", code)
+	}
+	if code, _ := f.send(t, "research-agent", research.Id, builder.Id, "   "); code != http.StatusUnprocessableEntity {
+		t.Errorf("blank instruction: %d", code)
+	}
+
+	seed := func(from, to *core.Record, n int) {
+		for i := 0; i < n; i++ {
+			addRecord(t, f.app, "claw_tasks", map[string]any{"from_claw": from.Id, "to_claw": to.Id, "status": ClawTaskPending})
+		}
+	}
+	seed(research, builder, clawTasksSentPerDay)
+	if code, _ := f.send(t, "research-agent", research.Id, busy.Id, "one more"); code != http.StatusTooManyRequests {
+		t.Errorf("over the sent cap: %d", code)
+	}
+
+	seed(builder, busy, clawTasksReceivedPerDay)
+	if code, _ := f.send(t, "research-agent", research.Id, busy.Id, "one more"); code != http.StatusTooManyRequests {
+		t.Errorf("over the received cap: %d", code)
+	}
+	if code, _ := f.send(t, "busy-agent", busy.Id, builder.Id, "fine"); code != http.StatusCreated {
+		t.Errorf("busy claw sending: %d", code)
+	}
+
+	// A result over the cap is refused
+	_, task := f.send(t, "busy-agent", busy.Id, research.Id, "summarize")
+	resp := f.api.Patch("/api/claws/"+research.Id+"/tasks/"+task.ID, bearer(t, f.kr, "research-agent"),
+		map[string]any{"status": ClawTaskCompleted, "result": strings.Repeat("r", 50001)})
+	if resp.Code != http.StatusUnprocessableEntity {
+		t.Errorf("oversized result: %d", resp.Code)
+	}
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Claw activity log — things the platform did to a claw
+// -----------------------------------------------------------------------------
+
+// claw_events holds what the claw reports about itself; claw_activity holds
+// what happened to it: status transitions, restarts, env changes, heartbeats.
+// Both feed the owner's timeline.
+
+const (
+	ClawActivityStatus    = "status"
+	ClawActivityRestart   = "restart"
+	ClawActivityEnv       = "env"
+	ClawActivityHeartbeat = "heartbeat"
+)
+
+// RecordClawActivity appends an entry to the claw's activity log. Best effort:
+// failures are logged, never returned, so callers' main work isn't affected.
+func RecordClawActivity(app core.App, clawID, kind, title, detail string, metadata map[string]any) {
+	col, err := app.FindCollectionByNameOrId("claw_activity")
+	if err != nil {
+		return
+	}
+	rec := core.NewRecord(col)
+	rec.Set("claw_id", clawID)
+	rec.Set("kind", kind)
+	rec.Set("title", title)
+	rec.Set("detail", detail)
+	if metadata != nil {
+		rec.Set("metadata", metadata)
+	}
+	if err := app.Save(rec); err != nil {
+		app.Logger().Warn("Failed to record claw activity", "claw", clawID, "kind", kind, "error", err)
+	}
+}
+
+func cleanOldClawActivity(ctx context.Context, app *pocketbase.PocketBase) error {
+	if _, err := app.FindCollectionByNameOrId("claw_activity"); err != nil {
+		return nil
+	}
+	cutoff := time.Now().UTC().Add(-clawEventRetention).Format(pbDateTimeLayout)
+	_, err := app.DB().NewQuery("DELETE FROM claw_activity WHERE created < {:cutoff}").
+		Bind(map[string]any{"cutoff": cutoff}).WithContext(ctx).Execute()
+	if err != nil {
+		return fmt.Errorf("clean old claw activity: %w", err)
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Timeline
+// -----------------------------------------------------------------------------
+
+// clawTimelineWindow is how far back the timeline reads without since.
+const clawTimelineWindow = 7 * 24 * time.Hour
+
+type TimelineItem struct {
+	Type     string `json:"type" doc:"message, event, status, restart, env, heartbeat or audit"`
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Detail   string `json:"detail,omitempty"`
+	Actor    string `json:"actor,omitempty" doc:"Who caused it: message author, 'system', or admin ID"`
+	Subtype  string `json:"subtype,omitempty" doc:"Event type for claw events, action for audit entries"`
+	Metadata any    `json:"metadata,omitempty"`
+	Created  string `json:"created"`
+}
+
+type ClawTimelineInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
+	ID            string `path:"id" doc:"Claw deployment ID"`
+	Since         string `query:"since" doc:"Only items after this RFC3339 timestamp (default: 7 days ago)"`
+	Cursor        string `query:"cursor" doc:"next_cursor from the previous page"`
+	Limit         int    `query:"limit" default:"50" minimum:"1" maximum:"200"`
+}
+
+type ClawTimelineOutput struct {
+	Body struct {
+		Items      []TimelineItem `json:"items"`
+		NextCursor string         `json:"next_cursor,omitempty" doc:"Pass as cursor for older items; absent on the last page"`
+	}
+}
+
+// timelineSource reads up to limit items from one collection in the window
+// (from, before), newest first. A missing collection yields nothing.
+type timelineSource func(from, before string, limit int) []TimelineItem
+
+func RegisterClawTimelineRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-claw-timeline",
+		Method:      "GET",
+		Path:        "/api/claws/{id}/timeline",
+		Summary:     "Claw activity timeline",
+		Description: "Everything that happened to a claw in one chronological list, newest first: chat messages, " +
+			"events the claw reported, status changes, restarts, env changes, heartbeats and admin actions. " +
+			"Reads the last 7 days unless since is given. Page with cursor. Owner and collaborators.",
+		Tags: []string{"Claws"},
+	}, func(ctx context.Context, input *ClawTimelineInput) (*ClawTimelineOutput, error) {
+		claw, _, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleViewer)
+		if err != nil {
+			return nil, err
+		}
+
+		from := time.Now().UTC().Add(-clawTimelineWindow)
+		if input.Since != "" {
+			since, err := parseSince(app, input.Since)
+			if err != nil {
+				return nil, huma.Error422UnprocessableEntity("since must be an RFC3339 timestamp")
+			}
+			from = since
+		}
+		before := time.Now().UTC().Add(time.Minute).Format(pbDateTimeLayout)
+		if input.Cursor != "" {
+			c, err := time.Parse(pbDateTimeLayout, input.Cursor)
+			if err != nil {
+				return nil, huma.Error422UnprocessableEntity("invalid cursor")
+			}
+			before = c.UTC().Format(pbDateTimeLayout)
+		}
+
+		sources := []timelineSource{
+			clawTimelineMessages(app, claw),
+			clawTimelineEvents(app, claw.Id),
+			clawTimelineActivity(app, claw.Id),
+			clawTimelineAudit(app, claw.Id),
+		}
+
+		// Each source returns at most limit+1, so the merge is bounded and
+		// one extra item tells us whether there is another page.
+		var items []TimelineItem
+		for _, src := range sources {
+			items = append(items, src(from.Format(pbDateTimeLayout), before, input.Limit+1)...)
+		}
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Created > items[j].Created })
+
+		out := &ClawTimelineOutput{}
+		if len(items) > input.Limit {
+			items = items[:input.Limit]
+			out.Body.NextCursor = items[len(items)-1].Created
+		}
+		if items == nil {
+			items = []TimelineItem{}
+		}
+		out.Body.Items = items
+		return out, nil
+	})
+}
+
+func timelineFilter(field string) string {
+	return field + " = {:id} && created > {:from} && created < {:before}"
+}
+
+func timelineCreated(r *core.Record) string {
+	return r.GetDateTime("created").Time().UTC().Format(pbDateTimeLayout)
+}
+
+func timelineMetadata(r *core.Record, field string) any {
+	raw := r.GetString(field)
+	if raw == "" || raw == "null" {
+		return nil
+	}
+	var v any
+	if json.Unmarshal([]byte(raw), &v) != nil {
+		return nil
+	}
+	return v
+}
+
+func clawTimelineMessages(app *pocketbase.PocketBase, claw *core.Record) timelineSource {
+	return func(from, before string, limit int) []TimelineItem {
+		channelID, err := findClawChannel(app, claw.GetString("agent_id"))
+		if err != nil {
+			return nil
+		}
+		records, err := app.FindRecordsByFilter("channel_messages", timelineFilter("channel_id"), "-created", limit, 0,
+			map[string]any{"id": channelID, "from": from, "before": before})
+		if err != nil {
+			return nil
+		}
+		items := make([]TimelineItem, 0, len(records))
+		for _, r := range records {
+			item := TimelineItem{
+				Type:    "message",
+				ID:      r.Id,
+				Title:   truncate(r.GetString("body"), 200),
+				Actor:   r.GetString("author_id"),
+				Created: timelineCreated(r),
+			}
+			if item.Title != r.GetString("body") {
+				item.Detail = r.GetString("body")
+			}
+			items = append(items, item)
+		}
+		return items
+	}
+}
+
+func clawTimelineEvents(app *pocketbase.PocketBase, clawID string) timelineSource {
+	return func(from, before string, limit int) []TimelineItem {
+		records, err := app.FindRecordsByFilter("claw_events", timelineFilter("claw_id"), "-created", limit, 0,
+			map[string]any{"id": clawID, "from": from, "before": before})
+		if err != nil {
+			return nil
+		}
+		items := make([]TimelineItem, 0, len(records))
+		for _, r := range records {
+			items = append(items, TimelineItem{
+				Type:     "event",
+				ID:       r.Id,
+				Title:    r.GetString("title"),
+				Detail:   r.GetString("detail"),
+				Actor:    r.GetString("agent_id"),
+				Subtype:  r.GetString("type"),
+				Metadata: timelineMetadata(r, "metadata"),
+				Created:  timelineCreated(r),
+			})
+		}
+		return items
+	}
+}
+
+func clawTimelineActivity(app *pocketbase.PocketBase, clawID string) timelineSource {
+	return func(from, before string, limit int) []TimelineItem {
+		records, err := app.FindRecordsByFilter("claw_activity", timelineFilter("claw_id"), "-created", limit, 0,
+			map[string]any{"id": clawID, "from": from, "before": before})
+		if err != nil {
+			return nil
+		}
+		items := make([]TimelineItem, 0, len(records))
+		for _, r := range records {
+			items = append(items, TimelineItem{
+				Type:     r.GetString("kind"),
+				ID:       r.Id,
+				Title:    r.GetString("title"),
+				Detail:   r.GetString("detail"),
+				Actor:    "system",
+				Metadata: timelineMetadata(r, "metadata"),
+				Created:  timelineCreated(r),
+			})
+		}
+		return items
+	}
+}
+
+func clawTimelineAudit(app *pocketbase.PocketBase, clawID string) timelineSource {
+	return func(from, before string, limit int) []TimelineItem {
+		records, err := app.FindRecordsByFilter("admin_audit",
+			"target_type = 'claw' && "+timelineFilter("target_id"), "-created", limit, 0,
+			map[string]any{"id": clawID, "from": from, "before": before})
+		if err != nil {
+			return nil
+		}
+		items := make([]TimelineItem, 0, len(records))
+		for _, r := range records {
+			items = append(items, TimelineItem{
+				Type:     "audit",
+				ID:       r.Id,
+				Title:    "Admin action: " + r.GetString("action"),
+				Actor:    r.GetString("admin_id"),
+				Subtype:  r.GetString("action"),
+				Metadata: timelineMetadata(r, "details"),
+				Created:  timelineCreated(r),
+			})
+		}
+		return items
+	}
+}
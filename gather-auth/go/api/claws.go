@@ -4,14 +4,16 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -25,27 +27,45 @@ import (
 // Request / Response types
 // -----------------------------------------------------------------------------
 
+
 type ClawDeployment struct {
-	ID                   string `json:"id"`
-	Name                 string `json:"name"`
-	Status               string `json:"status"`
-	Instructions         string `json:"instructions,omitempty"`
-	GithubRepo           string `json:"github_repo,omitempty"`
-	ClawType             string `json:"claw_type"`
-	AgentType            string `json:"agent_type"`
-	UserID               string `json:"user_id"`
-	Subdomain            string `json:"subdomain,omitempty"`
-	ContainerID          string `json:"container_id,omitempty"`
-	URL                  string `json:"url,omitempty"`
-	Port                 int    `json:"port,omitempty"`
-	ErrorMessage         string `json:"error_message,omitempty"`
-	IsPublic             bool   `json:"is_public"`
-	HeartbeatInterval    int    `json:"heartbeat_interval"`
-	HeartbeatInstruction string `json:"heartbeat_instruction,omitempty"`
-	Paid                 bool   `json:"paid"`
-	TrialEndsAt          string `json:"trial_ends_at,omitempty"`
-	StripeSessionID      string `json:"stripe_session_id,omitempty"`
-	Created              string `json:"created"`
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	Status               string             `json:"status"`
+	QueuePosition        int                `json:"queue_position,omitempty" doc:"While status is queued: place in the provisioning queue, 1 being next"`
+	Instructions         string             `json:"instructions,omitempty"`
+	GithubRepo           string             `json:"github_repo,omitempty"`
+	ClawType             string             `json:"claw_type"`
+	AgentType            string             `json:"agent_type"`
+	UserID               string             `json:"user_id"`
+	Subdomain            string             `json:"subdomain,omitempty"`
+	ContainerID          string             `json:"container_id,omitempty"`
+	URL                  string             `json:"url,omitempty"`
+	Port                 int                `json:"port,omitempty"`
+	ErrorMessage         string             `json:"error_message,omitempty"`
+	IsPublic             bool               `json:"is_public"`
+	HeartbeatInterval    int                `json:"heartbeat_interval"`
+	HeartbeatInstruction string             `json:"heartbeat_instruction,omitempty"`
+	Paid                 bool               `json:"paid"`
+	TrialEndsAt          string             `json:"trial_ends_at,omitempty"`
+	StripeSessionID      string             `json:"stripe_session_id,omitempty"`
+	Resources            *ClawResources     `json:"resources,omitempty" doc:"Effective container limits for this claw_type"`
+	HealthStatus         string             `json:"health_status,omitempty" doc:"healthy, unhealthy, or empty if not yet probed"`
+	LastHealthAt         string             `json:"last_health_at,omitempty"`
+	HealthLatencyMs      int                `json:"health_latency_ms,omitempty"`
+	AutoHeal             bool               `json:"auto_heal"`
+	Busy                 bool               `json:"busy" doc:"The claw is working on a message or heartbeat right now"`
+	ActiveRequests       int                `json:"active_requests" doc:"Agent turns running now, at most max_in_flight for the claw_type"`
+	BusySince            string             `json:"busy_since,omitempty" doc:"When the claw last went from idle to busy"`
+	NetworkPolicy        string             `json:"network_policy" enum:"open,platform_only,allowlist" doc:"What the container may reach; see PATCH /api/claws/{id}"`
+	EgressAllowlist      []string           `json:"egress_allowlist,omitempty" doc:"Domains an allowlist claw may reach"`
+	AcceptPublicTasks    bool               `json:"accept_public_tasks" doc:"Claws of other owners may hand this claw tasks (POST /api/claws/{id}/tasks)"`
+	Role                 string             `json:"role,omitempty" enum:"owner,operator,viewer" doc:"Your access to this claw, in get and list responses"`
+	Onboarding           ClawOnboarding     `json:"onboarding" doc:"First-run checklist: authenticated, first_channel_post, first_heartbeat, env_configured"`
+	SleepSchedule        *ClawSleepSchedule `json:"sleep_schedule,omitempty" doc:"When the claw is awake; asleep (status sleeping, container stopped) the rest of the time"`
+	WakesAt              string             `json:"wakes_at,omitempty" doc:"While sleeping: when the schedule wakes it"`
+	SleepsAt             string             `json:"sleeps_at,omitempty" doc:"While running on a schedule: when it next goes to sleep"`
+	Created              string             `json:"created"`
 }
 
 func recordToClawDeployment(r *core.Record) ClawDeployment {
@@ -53,10 +73,35 @@ func recordToClawDeployment(r *core.Record) ClawDeployment {
 	if agentType == "" {
 		agentType = "clay" // backwards compat
 	}
+	var resources *ClawResources
+	if profile, ok := ClawProfileFor(r.GetString("claw_type")); ok {
+		resources = profile.Resources()
+	}
+	busy := clawBusyState(r)
+	queuePosition := 0
+	if r.GetString("status") == "queued" {
+		queuePosition = ClawQueuePosition(r.Id)
+	}
+	var schedule *ClawSleepSchedule
+	var wakesAt, sleepsAt string
+	if s, ok := clawSleepSchedule(r); ok {
+		schedule = &s
+		switch r.GetString("status") {
+		case "sleeping":
+			if t := clawWakeTime(r); !t.IsZero() {
+				wakesAt = t.UTC().Format(time.RFC3339)
+			}
+		case "running":
+			if t := clawSleepTime(r); !t.IsZero() {
+				sleepsAt = t.UTC().Format(time.RFC3339)
+			}
+		}
+	}
 	return ClawDeployment{
 		ID:                   r.Id,
 		Name:                 r.GetString("name"),
 		Status:               r.GetString("status"),
+		QueuePosition:        queuePosition,
 		Instructions:         r.GetString("instructions"),
 		GithubRepo:           r.GetString("github_repo"),
 		ClawType:             r.GetString("claw_type"),
@@ -73,18 +118,35 @@ func recordToClawDeployment(r *core.Record) ClawDeployment {
 		Paid:                 r.GetBool("paid"),
 		TrialEndsAt:          r.GetString("trial_ends_at"),
 		StripeSessionID:      r.GetString("stripe_session_id"),
-		Created:              r.GetString("created"),
+		Resources:            resources,
+		HealthStatus:         r.GetString("health_status"),
+		LastHealthAt:         r.GetString("last_health_at"),
+		HealthLatencyMs:      r.GetInt("health_latency_ms"),
+		AutoHeal:             r.GetBool("auto_heal"),
+		Busy:                 busy.Busy,
+		ActiveRequests:       busy.ActiveRequests,
+		BusySince:            busy.Since,
+		NetworkPolicy:        EffectiveClawNetworkPolicy(r),
+		EgressAllowlist:      ClawEgressAllowlist(r),
+		AcceptPublicTasks:    r.GetBool("accept_public_tasks"),
+		Onboarding:           clawOnboardingFromRecord(r),
+		SleepSchedule:        schedule,
+		WakesAt:              wakesAt,
+		SleepsAt:             sleepsAt,
+		Created:              recordTime(r, "created"),
 	}
 }
 
 type DeployClawInput struct {
 	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
 	Body          struct {
-		Name         string `json:"name" doc:"Claw name (e.g. ResearchClaw)" minLength:"1" maxLength:"50"`
-		Instructions string `json:"instructions,omitempty" doc:"Initial instructions for the claw" maxLength:"2000"`
-		GithubRepo   string `json:"github_repo,omitempty" doc:"GitHub repo to connect (e.g. acme/repo)" maxLength:"200"`
-		ClawType     string `json:"claw_type,omitempty" doc:"Tier: lite (default), pro, max" maxLength:"50"`
-		AgentType    string `json:"agent_type,omitempty" doc:"Agent framework: clay (default), hermes, deerflow" maxLength:"20"`
+		Name            string   `json:"name" doc:"Claw name (e.g. ResearchClaw)" minLength:"1" maxLength:"50"`
+		Instructions    string   `json:"instructions,omitempty" doc:"Initial instructions for the claw" maxLength:"2000"`
+		GithubRepo      string   `json:"github_repo,omitempty" doc:"GitHub repo to clone into /app/workspace (e.g. acme/repo or acme/repo#branch). Private repos need GITHUB_TOKEN in your vault." maxLength:"200"`
+		ClawType        string   `json:"claw_type,omitempty" doc:"Tier: lite (default), pro, max" maxLength:"50"`
+		AgentType       string   `json:"agent_type,omitempty" doc:"Agent framework: clay (default), hermes, deerflow" maxLength:"20"`
+		NetworkPolicy   string   `json:"network_policy,omitempty" doc:"open, platform_only or allowlist. Defaults to platform_only for lite and open for other tiers." maxLength:"20"`
+		EgressAllowlist []string `json:"egress_allowlist,omitempty" doc:"Domains (example.com, *.example.com) an allowlist claw may reach" maxItems:"50"`
 	}
 }
 
@@ -122,9 +184,10 @@ type ProvisionResultInput struct {
 	ProvisionerKey string `header:"X-Provisioner-Key" doc:"Provisioner shared secret" required:"true"`
 	ID             string `path:"id" doc:"Deployment ID"`
 	Body           struct {
-		Status       string `json:"status" doc:"New status: running or failed" enum:"running,failed"`
-		ContainerID  string `json:"container_id,omitempty" doc:"Docker container name/ID"`
-		ErrorMessage string `json:"error_message,omitempty" doc:"Error message if failed"`
+		ProvisionerID string `json:"provisioner_id" doc:"The provisioner_id that claimed the claw" minLength:"1" maxLength:"100"`
+		Status        string `json:"status" doc:"New status: running or failed" enum:"running,failed"`
+		ContainerID   string `json:"container_id,omitempty" doc:"Docker container name/ID"`
+		ErrorMessage  string `json:"error_message,omitempty" doc:"Error message if failed"`
 	}
 }
 
@@ -137,11 +200,13 @@ type ProvisionResultOutput struct {
 type DeleteClawInput struct {
 	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
 	ID            string `path:"id" doc:"Deployment ID"`
+	KeepHistory   bool   `query:"keep_history" doc:"Keep the claw's channel and messages, read-only, via GET /api/claws/{id}/messages"`
 }
 
 type DeleteClawOutput struct {
 	Body struct {
-		OK bool `json:"ok"`
+		OK      bool        `json:"ok"`
+		Cleanup ClawCleanup `json:"cleanup"`
 	}
 }
 
@@ -149,9 +214,17 @@ type UpdateClawSettingsInput struct {
 	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
 	ID            string `path:"id" doc:"Deployment ID"`
 	Body          struct {
-		IsPublic             *bool   `json:"is_public,omitempty" doc:"Whether subdomain page is public"`
-		HeartbeatInterval    *int    `json:"heartbeat_interval,omitempty" doc:"Minutes between heartbeats (0=off, 15, 30, 60, 360, 1440)"`
-		HeartbeatInstruction *string `json:"heartbeat_instruction,omitempty" doc:"Instruction sent with each heartbeat" maxLength:"2000"`
+		IsPublic             *bool              `json:"is_public,omitempty" doc:"Whether subdomain page is public"`
+		HeartbeatInterval    *int               `json:"heartbeat_interval,omitempty" doc:"Minutes between heartbeats (0=off, 15, 30, 60, 360, 1440)"`
+		HeartbeatInstruction *string            `json:"heartbeat_instruction,omitempty" doc:"Instruction sent with each heartbeat" maxLength:"2000"`
+		ClawType             *string            `json:"claw_type,omitempty" doc:"Not changeable here — use POST /api/claws/{id}/resize to switch tiers"`
+		AutoHeal             *bool              `json:"auto_heal,omitempty" doc:"Restart the container automatically after repeated failed health checks"`
+		Instructions         *string            `json:"instructions,omitempty" doc:"Owner instructions for the claw. Empty clears them." maxLength:"2000"`
+		Redeliver            bool               `json:"redeliver,omitempty" doc:"Write the (updated) instructions into the running container now and notify the agent. Otherwise they reach it on the next deploy."`
+		NetworkPolicy        *string            `json:"network_policy,omitempty" doc:"open, platform_only or allowlist. Changing it recreates a running container." maxLength:"20"`
+		EgressAllowlist      []string           `json:"egress_allowlist,omitempty" doc:"Domains an allowlist claw may reach; replaces the current list" maxItems:"50"`
+		AcceptPublicTasks    *bool              `json:"accept_public_tasks,omitempty" doc:"Let claws of other owners hand this claw tasks. Your own claws always can."`
+		SleepSchedule        *ClawSleepSchedule `json:"sleep_schedule,omitempty" doc:"Awake window; the claw sleeps outside it. An empty object removes the schedule."`
 	}
 }
 
@@ -162,16 +235,18 @@ type UpdateClawSettingsOutput struct {
 type ClawMessagesInput struct {
 	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
 	ID            string `path:"id" doc:"Claw deployment ID"`
-	Since         string `query:"since" doc:"Only messages after this timestamp"`
+	Since         string `query:"since" doc:"Only messages after this RFC3339 timestamp"`
 	Limit         int    `query:"limit" default:"50" minimum:"1" maximum:"200" doc:"Max messages"`
+	Expand        string `query:"expand" doc:"events: include the tool calls behind each claw reply"`
 }
 
 type ClawMessage struct {
-	ID         string `json:"id"`
-	AuthorID   string `json:"author_id"`
-	AuthorName string `json:"author_name"`
-	Body       string `json:"body"`
-	Created    string `json:"created"`
+	ID         string     `json:"id"`
+	AuthorID   string     `json:"author_id"`
+	AuthorName string     `json:"author_name"`
+	Body       string     `json:"body"`
+	Events     []adkEvent `json:"events,omitempty" doc:"Tool calls behind a claw reply; only with ?expand=events"`
+	Created    string     `json:"created"`
 }
 
 type ClawMessagesOutput struct {
@@ -203,9 +278,22 @@ type ClawEnvInput struct {
 	ID            string `path:"id" doc:"Deployment ID"`
 }
 
+// ClawEnvEntry compares one variable in the .env file with what the running
+// claw process sees. The process only re-reads .env on restart.
+type ClawEnvEntry struct {
+	Key       string `json:"key"`
+	Value     string `json:"value,omitempty" doc:"Value in the .env file (masked if sensitive)"`
+	InFile    bool   `json:"in_file"`
+	Active    bool   `json:"active" doc:"The running process has this exact value"`
+	LiveValue string `json:"live_value,omitempty" doc:"Value the running process has, when it differs (masked if sensitive)"`
+}
+
 type ClawEnvOutput struct {
 	Body struct {
-		Vars map[string]string `json:"vars"`
+		Vars          map[string]string `json:"vars"`
+		Entries       []ClawEnvEntry    `json:"entries"`
+		LiveKnown     bool              `json:"live_known" doc:"False if the container's live environment could not be read; active is then always false"`
+		RestartNeeded bool              `json:"restart_needed" doc:"The .env file and the running process disagree; restart to apply"`
 	}
 }
 
@@ -213,14 +301,19 @@ type SaveClawEnvInput struct {
 	Authorization string `header:"Authorization" doc:"Bearer PocketBase auth token" required:"true"`
 	ID            string `path:"id" doc:"Deployment ID"`
 	Body          struct {
-		Vars    map[string]string `json:"vars" doc:"Environment variable key-value pairs"`
+		Vars    map[string]string `json:"vars,omitempty" doc:"Keys to set. An empty value deletes the key; a masked value from GET leaves it unchanged."`
+		Remove  []string          `json:"remove,omitempty" doc:"Keys to delete"`
 		Restart bool              `json:"restart,omitempty" doc:"Restart the container after saving"`
+		Merge   bool              `json:"merge,omitempty" doc:"Deprecated: saves always keep keys that are not mentioned"`
 	}
 }
 
 type SaveClawEnvOutput struct {
 	Body struct {
-		OK bool `json:"ok"`
+		OK            bool     `json:"ok"`
+		Set           []string `json:"set,omitempty"`
+		Removed       []string `json:"removed,omitempty"`
+		RestartNeeded bool     `json:"restart_needed" doc:"Changes are saved but the running claw won't see them until it restarts"`
 	}
 }
 
@@ -272,12 +365,26 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 			return nil, huma.Error422UnprocessableEntity("Name is required")
 		}
 
-		clawType := input.Body.ClawType
-		if clawType == "" || clawType == "picoclaw" {
-			clawType = "lite"
+		clawType := NormalizeClawType(input.Body.ClawType)
+		if _, ok := ClawProfileFor(clawType); !ok {
+			return nil, huma.Error422UnprocessableEntity(fmt.Sprintf(
+				"claw_type must be one of: %s", strings.Join(clawTypeNames(), ", ")))
 		}
-		if clawType != "lite" && clawType != "pro" && clawType != "max" {
-			return nil, huma.Error422UnprocessableEntity("claw_type must be lite, pro, or max")
+
+		githubRepo := strings.TrimSpace(input.Body.GithubRepo)
+		if githubRepo != "" {
+			if _, _, err := ParseGithubRepo(githubRepo); err != nil {
+				return nil, huma.Error422UnprocessableEntity(err.Error())
+			}
+		}
+
+		policy := input.Body.NetworkPolicy
+		if policy == "" {
+			policy = DefaultClawNetworkPolicy(clawType)
+		}
+		policy, allowlist, err := normalizeClawNetworkPolicy(policy, input.Body.EgressAllowlist)
+		if err != nil {
+			return nil, huma.Error422UnprocessableEntity(err.Error())
 		}
 
 		agentType := input.Body.AgentType
@@ -298,16 +405,19 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		record.Set("name", name)
 		record.Set("status", "queued")
 		record.Set("instructions", strings.TrimSpace(input.Body.Instructions))
-		record.Set("github_repo", strings.TrimSpace(input.Body.GithubRepo))
+		record.Set("github_repo", githubRepo)
 		record.Set("claw_type", clawType)
 		record.Set("agent_type", agentType)
+		record.Set("network_policy", policy)
+		record.Set("egress_allowlist", allowlist)
 
 		if err := app.Save(record); err != nil {
 			return nil, huma.Error500InternalServerError("Failed to create deployment")
 		}
 
-		// Hook fires async — record may still show "queued" here.
-		// Client should poll GET /api/claws/{id} to see status progression.
+		// The hook has queued it; it stays "queued" until a provisioning
+		// worker picks it up. Client should poll GET /api/claws/{id} to see
+		// queue_position and status progression.
 		out := &DeployClawOutput{}
 		out.Body = recordToClawDeployment(record)
 		return out, nil
@@ -319,16 +429,17 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "GET",
 		Path:        "/api/claws/pending",
 		Summary:     "List claws awaiting provisioning",
-		Description: "Internal endpoint for the host-side provisioner. Requires X-Provisioner-Key header.",
-		Tags:        []string{"Claws"},
+		Description: "Internal endpoint for the host-side provisioner. Requires X-Provisioner-Key header. " +
+			"Lists provisioning claws and claimed ones whose lease has expired; claim one with POST /api/claws/{id}/claim before provisioning it.",
+		Tags: []string{"Claws"},
 	}, func(ctx context.Context, input *PendingClawsInput) (*ListClawsOutput, error) {
-		expected := os.Getenv("CLAW_PROVISIONER_KEY")
-		if expected == "" || input.ProvisionerKey != expected {
-			return nil, huma.Error401Unauthorized("Invalid provisioner key")
+		if err := checkProvisionerKey(input.ProvisionerKey); err != nil {
+			return nil, err
 		}
 
 		records, err := app.FindRecordsByFilter("claw_deployments",
-			"status = 'provisioning'", "-created", 50, 0, nil)
+			"status = 'provisioning' || (status = 'claimed' && claimed_at < {:expired})", "-created", 50, 0,
+			map[string]any{"expired": clawClaimExpiry(time.Now())})
 		if err != nil {
 			records = nil
 		}
@@ -347,32 +458,48 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "POST",
 		Path:        "/api/claws/{id}/provision-result",
 		Summary:     "Report claw provisioning result",
-		Description: "Internal endpoint. Host-side provisioner reports success (running) or failure.",
-		Tags:        []string{"Claws"},
+		Description: "Internal endpoint. Host-side provisioner reports success (running) or failure. " +
+			"Only the provisioner holding the claim (see POST /api/claws/{id}/claim) may report; others get 409.",
+		Tags: []string{"Claws"},
 	}, func(ctx context.Context, input *ProvisionResultInput) (*ProvisionResultOutput, error) {
-		expected := os.Getenv("CLAW_PROVISIONER_KEY")
-		if expected == "" || input.ProvisionerKey != expected {
-			return nil, huma.Error401Unauthorized("Invalid provisioner key")
+		if err := checkProvisionerKey(input.ProvisionerKey); err != nil {
+			return nil, err
 		}
 
 		if input.Body.Status != "running" && input.Body.Status != "failed" {
 			return nil, huma.Error422UnprocessableEntity("Status must be 'running' or 'failed'")
 		}
 
-		record, err := app.FindRecordById("claw_deployments", input.ID)
-		if err != nil {
-			return nil, huma.Error404NotFound("Deployment not found")
-		}
+		var holder string
+		err := app.RunInTransaction(func(txApp core.App) error {
+			record, err := txApp.FindRecordById("claw_deployments", input.ID)
+			if err != nil {
+				return err
+			}
+			if record.GetString("status") != "claimed" || record.GetString("provisioner_id") != input.Body.ProvisionerID {
+				holder = record.GetString("provisioner_id")
+				return errClawClaimLost
+			}
 
-		record.Set("status", input.Body.Status)
-		if input.Body.ContainerID != "" {
-			record.Set("container_id", input.Body.ContainerID)
+			record.Set("status", input.Body.Status)
+			if input.Body.ContainerID != "" {
+				record.Set("container_id", input.Body.ContainerID)
+			}
+			if input.Body.ErrorMessage != "" {
+				record.Set("error_message", input.Body.ErrorMessage)
+			}
+			return txApp.Save(record)
+		})
+		if errors.Is(err, errClawClaimLost) {
+			if holder == "" {
+				return nil, huma.Error409Conflict("Deployment is not claimed; claim it with POST /api/claws/{id}/claim first")
+			}
+			return nil, huma.Error409Conflict("Deployment is claimed by another provisioner (" + holder + ")")
 		}
-		if input.Body.ErrorMessage != "" {
-			record.Set("error_message", input.Body.ErrorMessage)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, huma.Error404NotFound("Deployment not found")
 		}
-
-		if err := app.Save(record); err != nil {
+		if err != nil {
 			return nil, huma.Error500InternalServerError("Failed to update deployment")
 		}
 
@@ -387,21 +514,17 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "DELETE",
 		Path:        "/api/claws/{id}",
 		Summary:     "Delete a Claw deployment",
-		Description: "Delete a claw deployment. Only the owning user can delete.",
-		Tags:        []string{"Claws"},
+		Description: "Delete a claw deployment. Only the owning user can delete. " +
+			"Also deletes its channel and inbox, and deletes its agent — or suspends it if the agent posted, reviewed or holds a balance. " +
+			"With keep_history=true the channel stays readable (read-only) and the deployment remains with status \"deleted\".",
+		Tags: []string{"Claws"},
 	}, func(ctx context.Context, input *DeleteClawInput) (*DeleteClawOutput, error) {
-		userID, err := extractPBUserID(app, input.Authorization)
+		record, userID, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOwner)
 		if err != nil {
-			return nil, huma.Error401Unauthorized("Authentication required")
-		}
-
-		record, err := app.FindRecordById("claw_deployments", input.ID)
-		if err != nil {
-			return nil, huma.Error404NotFound("Deployment not found")
+			return nil, err
 		}
-
-		if record.GetString("user_id") != userID {
-			return nil, huma.Error404NotFound("Deployment not found")
+		if clawResizing(record.Id) {
+			return nil, huma.Error409Conflict("Claw is being resized. Try again once the resize finishes.")
 		}
 
 		// Remove the Docker container if it exists
@@ -414,12 +537,15 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 			}
 		}
 
-		if err := app.Delete(record); err != nil {
+		cleanup, err := cleanupDeletedClaw(app, record, userID, input.KeepHistory)
+		if err != nil {
+			app.Logger().Error("Claw deletion cleanup failed", "claw", record.Id, "error", err)
 			return nil, huma.Error500InternalServerError("Failed to delete deployment")
 		}
 
 		out := &DeleteClawOutput{}
 		out.Body.OK = true
+		out.Body.Cleanup = cleanup
 		return out, nil
 	})
 
@@ -429,25 +555,17 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "GET",
 		Path:        "/api/claws/{id}",
 		Summary:     "Get Claw deployment status",
-		Description: "Check the status of a claw deployment.",
+		Description: "Check the status of a claw deployment. Owner and collaborators; role says which you are.",
 		Tags:        []string{"Claws"},
 	}, func(ctx context.Context, input *GetClawInput) (*GetClawOutput, error) {
-		userID, err := extractPBUserID(app, input.Authorization)
-		if err != nil {
-			return nil, huma.Error401Unauthorized("Authentication required")
-		}
-
-		record, err := app.FindRecordById("claw_deployments", input.ID)
+		record, userID, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleViewer)
 		if err != nil {
-			return nil, huma.Error404NotFound("Deployment not found")
-		}
-
-		if record.GetString("user_id") != userID {
-			return nil, huma.Error404NotFound("Deployment not found")
+			return nil, err
 		}
 
 		out := &GetClawOutput{}
 		out.Body = recordToClawDeployment(record)
+		out.Body.Role = clawRole(app, record, userID)
 		return out, nil
 	})
 
@@ -457,7 +575,7 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "GET",
 		Path:        "/api/claws",
 		Summary:     "List deployed Claws",
-		Description: "List all claw deployments for the authenticated user.",
+		Description: "List all claw deployments for the authenticated user: their own, then those shared with them. role says which.",
 		Tags:        []string{"Claws"},
 	}, func(ctx context.Context, input *ListClawsInput) (*ListClawsOutput, error) {
 		userID, err := extractPBUserID(app, input.Authorization)
@@ -474,7 +592,15 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 
 		out := &ListClawsOutput{}
 		for _, r := range records {
-			out.Body.Claws = append(out.Body.Claws, recordToClawDeployment(r))
+			claw := recordToClawDeployment(r)
+			claw.Role = ClawRoleOwner
+			out.Body.Claws = append(out.Body.Claws, claw)
+		}
+		shared, roles := sharedClaws(app, userID)
+		for _, r := range shared {
+			claw := recordToClawDeployment(r)
+			claw.Role = roles[r.Id]
+			out.Body.Claws = append(out.Body.Claws, claw)
 		}
 		out.Body.Total = len(out.Body.Claws)
 		return out, nil
@@ -486,20 +612,24 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "PATCH",
 		Path:        "/api/claws/{id}",
 		Summary:     "Update Claw settings",
-		Description: "Update claw settings (heartbeat, public page). Only the owning user can update.",
-		Tags:        []string{"Claws"},
+		Description: "Update claw settings (heartbeat, public page, auto-heal, instructions, network policy, sleep schedule). Only the owning user can update. " +
+			"With redeliver, the instructions are written to " + ClawInstructionsPath + " in the running container and the agent is told they changed. " +
+			"network_policy controls egress: open (full internet), platform_only (only gather.is: the platform API and LLM proxy) " +
+			"or allowlist (platform plus egress_allowlist domains, through the egress proxy). Changing it recreates a running container, which is down for a few seconds. " +
+			"sleep_schedule, e.g. {\"wake_at\":\"09:00\",\"sleep_at\":\"18:00\",\"timezone\":\"Europe/Berlin\",\"days\":[\"mon\",\"tue\",\"wed\",\"thu\",\"fri\"]}, " +
+			"stops the container outside those hours (status sleeping) and starts it at wake_at; the change applies within a minute.",
+		Tags: []string{"Claws"},
 	}, func(ctx context.Context, input *UpdateClawSettingsInput) (*UpdateClawSettingsOutput, error) {
-		userID, err := extractPBUserID(app, input.Authorization)
+		record, _, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOwner)
 		if err != nil {
-			return nil, huma.Error401Unauthorized("Authentication required")
+			return nil, err
 		}
 
-		record, err := app.FindRecordById("claw_deployments", input.ID)
-		if err != nil {
-			return nil, huma.Error404NotFound("Deployment not found")
-		}
-		if record.GetString("user_id") != userID {
-			return nil, huma.Error404NotFound("Deployment not found")
+		// Switching tiers recreates the container, which is the resize
+		// endpoint's job.
+		if input.Body.ClawType != nil &&
+			NormalizeClawType(*input.Body.ClawType) != NormalizeClawType(record.GetString("claw_type")) {
+			return nil, huma.Error409Conflict("claw_type can't be changed here. Use POST /api/claws/{id}/resize to move the claw to another tier.")
 		}
 
 		if input.Body.IsPublic != nil {
@@ -516,11 +646,45 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		if input.Body.HeartbeatInstruction != nil {
 			record.Set("heartbeat_instruction", *input.Body.HeartbeatInstruction)
 		}
+		if input.Body.AutoHeal != nil {
+			record.Set("auto_heal", *input.Body.AutoHeal)
+		}
+		if input.Body.AcceptPublicTasks != nil {
+			record.Set("accept_public_tasks", *input.Body.AcceptPublicTasks)
+		}
+		if input.Body.Instructions != nil {
+			record.Set("instructions", strings.TrimSpace(*input.Body.Instructions))
+		}
+		if sched := input.Body.SleepSchedule; sched != nil {
+			if sched.IsZero() {
+				record.Set("sleep_schedule", nil)
+			} else {
+				if err := sched.Validate(); err != nil {
+					return nil, huma.Error422UnprocessableEntity("sleep_schedule: " + err.Error())
+				}
+				record.Set("sleep_schedule", sched)
+			}
+			record.Set("sleep_override_until", "")
+		}
+		if input.Body.Redeliver && (record.GetString("status") != "running" || record.GetString("container_id") == "") {
+			return nil, huma.Error409Conflict("Claw is not running, so instructions can't be redelivered. Save without redeliver; they are delivered on the next deploy.")
+		}
+		if input.Body.NetworkPolicy != nil || input.Body.EgressAllowlist != nil {
+			if err := updateClawNetworkPolicy(app, record, input.Body.NetworkPolicy, input.Body.EgressAllowlist); err != nil {
+				return nil, err
+			}
+		}
 
 		if err := app.Save(record); err != nil {
 			return nil, huma.Error500InternalServerError("Failed to update settings")
 		}
 
+		if input.Body.Redeliver {
+			if err := redeliverClawInstructions(ctx, app, record); err != nil {
+				return nil, huma.Error500InternalServerError(fmt.Sprintf("Settings saved but redelivering instructions failed: %v", err))
+			}
+		}
+
 		out := &UpdateClawSettingsOutput{}
 		out.Body = recordToClawDeployment(record)
 		return out, nil
@@ -532,17 +696,14 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "GET",
 		Path:        "/api/claws/{id}/messages",
 		Summary:     "Read claw messages",
-		Description: "Read messages from a claw's default channel. Only the claw owner can access.",
-		Tags:        []string{"Claws"},
+		Description: "Read messages from a claw's default channel. Owner and collaborators (viewer or above). " +
+			"?expand=events adds the tool calls and results behind each claw reply (stored up to 50KB per reply; " +
+			"longer runs keep the first and last events with a \"truncated\" event in between).",
+		Tags: []string{"Claws"},
 	}, func(ctx context.Context, input *ClawMessagesInput) (*ClawMessagesOutput, error) {
-		userID, err := extractPBUserID(app, input.Authorization)
+		record, _, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleViewer)
 		if err != nil {
-			return nil, huma.Error401Unauthorized("Authentication required")
-		}
-
-		record, err := app.FindRecordById("claw_deployments", input.ID)
-		if err != nil || record.GetString("user_id") != userID {
-			return nil, huma.Error404NotFound("Claw not found")
+			return nil, err
 		}
 
 		channelID, err := findClawChannel(app, record.GetString("agent_id"))
@@ -553,8 +714,12 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		filter := "channel_id = {:cid}"
 		params := map[string]any{"cid": channelID}
 		if input.Since != "" {
+			since, err := parseSince(app, input.Since)
+			if err != nil {
+				return nil, huma.Error422UnprocessableEntity("since must be an RFC3339 timestamp")
+			}
 			filter += " && created > {:since}"
-			params["since"] = input.Since
+			params["since"] = since.Format(pbDateTimeLayout)
 		}
 
 		records, _ := app.FindRecordsByFilter("channel_messages", filter, "-created", input.Limit, 0, params)
@@ -566,13 +731,17 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 			if _, ok := nameCache[authorID]; !ok {
 				nameCache[authorID] = resolveAuthorName(app, authorID)
 			}
-			messages = append(messages, ClawMessage{
+			msg := ClawMessage{
 				ID:         r.Id,
 				AuthorID:   authorID,
 				AuthorName: nameCache[authorID],
 				Body:       r.GetString("body"),
-				Created:    r.GetString("created"),
-			})
+				Created:    recordTime(r, "created"),
+			}
+			if input.Expand == "events" {
+				msg.Events = decodeClawMsgEvents(r.GetString("events"))
+			}
+			messages = append(messages, msg)
 		}
 
 		out := &ClawMessagesOutput{}
@@ -586,17 +755,12 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "POST",
 		Path:        "/api/claws/{id}/messages",
 		Summary:     "Send message to claw",
-		Description: "Send a message to a claw's default channel. Only the claw owner can send.",
+		Description: "Send a message to a claw's default channel. Owner and operators.",
 		Tags:        []string{"Claws"},
 	}, func(ctx context.Context, input *SendClawMsgInput) (*SendClawMsgOutput, error) {
-		userID, err := extractPBUserID(app, input.Authorization)
+		record, userID, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOperator)
 		if err != nil {
-			return nil, huma.Error401Unauthorized("Authentication required")
-		}
-
-		record, err := app.FindRecordById("claw_deployments", input.ID)
-		if err != nil || record.GetString("user_id") != userID {
-			return nil, huma.Error404NotFound("Claw not found")
+			return nil, err
 		}
 
 		agentID := record.GetString("agent_id")
@@ -605,6 +769,21 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 			return nil, huma.Error404NotFound("Claw channel not found")
 		}
 
+		if record.GetString("status") == "sleeping" {
+			return nil, clawAsleepError(record)
+		}
+		containerID := record.GetString("container_id")
+		if containerID == "" {
+			return nil, huma.Error422UnprocessableEntity("Claw container not running")
+		}
+
+		// Reserve a slot before saving so a rejected message leaves no trace
+		release, err := acquireClawSlot(containerID, record.GetString("claw_type"))
+		if err != nil {
+			return nil, clawBusyError()
+		}
+		defer release()
+
 		col, err := app.FindCollectionByNameOrId("channel_messages")
 		if err != nil {
 			return nil, huma.Error500InternalServerError("channel_messages collection not found")
@@ -621,22 +800,20 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		}
 
 		// Forward to claw container's ADK API
-		containerID := record.GetString("container_id")
-		if containerID == "" {
-			return nil, huma.Error422UnprocessableEntity("Claw container not running")
-		}
-
-		adkResult, err := sendToADK(containerID, userID, input.Body.Body)
+		adkResult, err := sendToADK(ctx, containerID, record.GetString("claw_type"), userID, input.Body.Body)
 		if err != nil {
 			app.Logger().Error("ADK proxy failed", "claw", containerID, "error", err)
 			return nil, huma.NewError(http.StatusBadGateway, fmt.Sprintf("Claw did not respond: %v", err))
 		}
 
-		// Save the claw's response as a channel message (text only, events are ephemeral)
+		// Save the claw's response as a channel message, with the tool calls behind it
 		replyRec := core.NewRecord(col)
 		replyRec.Set("channel_id", channelID)
 		replyRec.Set("author_id", agentID)
 		replyRec.Set("body", adkResult.Text)
+		if events := encodeClawMsgEvents(adkResult.Events, nil, 0); events != nil {
+			replyRec.Set("events", string(events))
+		}
 		if err := app.Save(replyRec); err != nil {
 			app.Logger().Error("Failed to save claw reply", "claw", containerID, "error", err)
 		}
@@ -650,7 +827,7 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 			AuthorID:   agentID,
 			AuthorName: resolveAuthorName(app, agentID),
 			Body:       adkResult.Text,
-			Created:    replyRec.GetString("created"),
+			Created:    recordTime(replyRec, "created"),
 		}
 		return out, nil
 	})
@@ -665,10 +842,11 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "GET",
 		Path:        "/api/claws/{id}/env",
 		Summary:     "Read claw environment variables",
-		Description: "Read the per-claw .env file. Sensitive values are masked.",
-		Tags:        []string{"Claws"},
+		Description: "Read the per-claw .env file. Sensitive values are masked. " +
+			"Each entry says whether the running claw actually has that value; restart_needed is set when the file and the process disagree.",
+		Tags: []string{"Claws"},
 	}, func(ctx context.Context, input *ClawEnvInput) (*ClawEnvOutput, error) {
-		record, err := requireClawOwner(app, input.Authorization, input.ID)
+		record, _, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOwner)
 		if err != nil {
 			return nil, err
 		}
@@ -680,11 +858,13 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 
 		vars, err := readClawEnv(ctx, containerID)
 		if err != nil {
-			// No .env yet — return empty
-			out := &ClawEnvOutput{}
-			out.Body.Vars = map[string]string{}
-			return out, nil
+			vars = map[string]string{} // no .env yet
 		}
+		live, liveErr := readClawLiveEnv(ctx, containerID)
+
+		out := &ClawEnvOutput{}
+		out.Body.Entries, out.Body.RestartNeeded = compareClawEnv(vars, live)
+		out.Body.LiveKnown = liveErr == nil
 
 		// Mask sensitive values
 		for k, v := range vars {
@@ -692,8 +872,6 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 				vars[k] = maskValue(v)
 			}
 		}
-
-		out := &ClawEnvOutput{}
 		out.Body.Vars = vars
 		return out, nil
 	})
@@ -704,10 +882,12 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "PUT",
 		Path:        "/api/claws/{id}/env",
 		Summary:     "Save claw environment variables",
-		Description: "Write per-claw .env file. Only allowed keys are accepted. Optionally restarts the container.",
-		Tags:        []string{"Claws"},
+		Description: "Update the per-claw .env file. Only allowed keys are accepted. Keys in vars are set (an empty value deletes), " +
+			"keys in remove are deleted, and unmentioned keys are kept. The claw reads .env on start, so restart_needed tells you " +
+			"whether a restart is still required; pass restart to do it now.",
+		Tags: []string{"Claws"},
 	}, func(ctx context.Context, input *SaveClawEnvInput) (*SaveClawEnvOutput, error) {
-		record, err := requireClawOwner(app, input.Authorization, input.ID)
+		record, _, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOwner)
 		if err != nil {
 			return nil, err
 		}
@@ -723,19 +903,53 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 				return nil, huma.Error422UnprocessableEntity(fmt.Sprintf("Environment variable %q is not allowed", k))
 			}
 		}
+		for _, k := range input.Body.Remove {
+			if !allowedEnvKeys[k] {
+				return nil, huma.Error422UnprocessableEntity(fmt.Sprintf("Environment variable %q is not allowed", k))
+			}
+		}
 
-		if err := writeClawEnv(ctx, containerID, input.Body.Vars); err != nil {
-			return nil, huma.Error500InternalServerError(fmt.Sprintf("Failed to write .env: %v", err))
+		// Always merge server-side: GET masks secrets, so clients can't
+		// round-trip them
+		vars, err := readClawEnv(ctx, containerID)
+		if err != nil {
+			vars = map[string]string{}
 		}
+		set, removed := applyClawEnvChanges(vars, input.Body.Vars, input.Body.Remove)
 
-		if input.Body.Restart {
-			if err := restartClawContainer(ctx, containerID); err != nil {
-				return nil, huma.Error500InternalServerError(fmt.Sprintf("Env saved but restart failed: %v", err))
+		if len(set) > 0 || len(removed) > 0 {
+			if err := writeClawEnv(ctx, containerID, vars); err != nil {
+				return nil, huma.Error500InternalServerError(fmt.Sprintf("Failed to write .env: %v", err))
+			}
+			MarkClawMilestone(app, record.Id, ClawMilestoneEnvConfigured)
+
+			// Key names only — values may be secrets
+			detail := strings.Join(set, ", ")
+			if len(removed) > 0 {
+				if detail != "" {
+					detail += "; "
+				}
+				detail += "removed " + strings.Join(removed, ", ")
 			}
+			RecordClawActivity(app, record.Id, ClawActivityEnv, "Environment updated",
+				detail, map[string]any{"set": set, "removed": removed})
 		}
 
 		out := &SaveClawEnvOutput{}
 		out.Body.OK = true
+		out.Body.Set = set
+		out.Body.Removed = removed
+
+		if input.Body.Restart {
+			if err := restartClawContainer(ctx, containerID); err != nil {
+				return nil, huma.Error500InternalServerError(fmt.Sprintf("Env saved but restart failed: %v", err))
+			}
+			RecordClawActivity(app, record.Id, ClawActivityRestart, "Restarted after env change", "", nil)
+		} else if live, err := readClawLiveEnv(ctx, containerID); err == nil {
+			_, out.Body.RestartNeeded = compareClawEnv(vars, live)
+		} else {
+			out.Body.RestartNeeded = len(set) > 0 || len(removed) > 0
+		}
 		return out, nil
 	})
 
@@ -745,10 +959,10 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "POST",
 		Path:        "/api/claws/{id}/restart",
 		Summary:     "Restart a Claw container",
-		Description: "Restart the Docker container for a claw. The entrypoint re-sources .env on startup.",
+		Description: "Restart the Docker container for a claw. The entrypoint re-sources .env on startup. Owner and operators.",
 		Tags:        []string{"Claws"},
 	}, func(ctx context.Context, input *RestartClawInput) (*RestartClawOutput, error) {
-		record, err := requireClawOwner(app, input.Authorization, input.ID)
+		record, userID, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOperator)
 		if err != nil {
 			return nil, err
 		}
@@ -761,6 +975,11 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		if err := restartClawContainer(ctx, containerID); err != nil {
 			return nil, huma.Error500InternalServerError(fmt.Sprintf("Restart failed: %v", err))
 		}
+		title := "Restarted by owner"
+		if record.GetString("user_id") != userID {
+			title = "Restarted by " + userDisplayName(app, userID)
+		}
+		RecordClawActivity(app, record.Id, ClawActivityRestart, title, "", nil)
 
 		out := &RestartClawOutput{}
 		out.Body.OK = true
@@ -773,10 +992,10 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "GET",
 		Path:        "/api/claws/{id}/logs",
 		Summary:     "Read claw container logs",
-		Description: "Read Docker container logs for a claw. Returns the last N lines.",
+		Description: "Read Docker container logs for a claw. Returns the last N lines. Owner and operators.",
 		Tags:        []string{"Claws"},
 	}, func(ctx context.Context, input *ClawLogsInput) (*ClawLogsOutput, error) {
-		record, err := requireClawOwner(app, input.Authorization, input.ID)
+		record, _, err := requireClawAccess(app, input.Authorization, input.ID, ClawRoleOperator)
 		if err != nil {
 			return nil, err
 		}
@@ -819,6 +1038,13 @@ func RegisterClawRoutes(api huma.API, app *pocketbase.PocketBase) {
 		out.Body.Logs = logs
 		return out, nil
 	})
+
+	registerClawClaimRoute(api, app)
+	registerClawRepoRoutes(api, app)
+	registerClawResizeRoute(api, app)
+	registerClawCollaboratorRoutes(api, app)
+	registerClawBusyRoute(api, app)
+	registerClawWakeRoute(api, app)
 }
 
 // ---------------------------------------------------------------------------
@@ -835,7 +1061,7 @@ type bridgeRequest struct {
 
 // adkEvent represents a single event from the ADK SSE stream.
 type adkEvent struct {
-	Type     string `json:"type"`                // "text", "tool_call", "tool_result"
+	Type     string `json:"type"`                // "text", "tool_call", "tool_result"; stored events may add "truncated"
 	Author   string `json:"author,omitempty"`
 	Text     string `json:"text,omitempty"`
 	ToolName string `json:"tool_name,omitempty"`
@@ -851,11 +1077,64 @@ type bridgeResponse struct {
 	Error  string     `json:"error,omitempty"`
 }
 
-var adkClient = &http.Client{Timeout: 120 * time.Second}
+// adkClient has no client-level timeout: each call is bounded by the caller's
+// context plus the claw_type's bridge timeout, so an abandoned request stops
+// the agent run instead of holding it open for minutes.
+var adkClient = &http.Client{}
+
+// errClawBusy means the claw already has MaxInFlight agent turns running.
+var errClawBusy = errors.New("claw is busy")
+
+// clawBusyRetryAfter is the Retry-After hint (seconds) sent with a 429.
+const clawBusyRetryAfter = "5"
+
+var (
+	clawInFlightMu sync.Mutex
+	clawInFlight   = map[string]int{}
+	clawBusySince  = map[string]time.Time{} // when the claw's current busy stretch began
+)
+
+// acquireClawSlot reserves one of the claw's concurrent agent turns so a
+// burst of messages can't stack parallel runs on one container. Call release
+// when the turn ends (for streams, when the relay finishes).
+func acquireClawSlot(containerName, clawType string) (release func(), err error) {
+	limit := bridgeProfile(clawType).MaxInFlight
+
+	clawInFlightMu.Lock()
+	defer clawInFlightMu.Unlock()
+	if clawInFlight[containerName] >= limit {
+		return nil, errClawBusy
+	}
+	if clawInFlight[containerName] == 0 {
+		clawBusySince[containerName] = time.Now()
+	}
+	clawInFlight[containerName]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			clawInFlightMu.Lock()
+			defer clawInFlightMu.Unlock()
+			if clawInFlight[containerName]--; clawInFlight[containerName] <= 0 {
+				delete(clawInFlight, containerName)
+				delete(clawBusySince, containerName)
+			}
+		})
+	}, nil
+}
+
+// clawBusyError is the Huma response for errClawBusy.
+func clawBusyError() error {
+	return huma.ErrorWithHeaders(
+		huma.Error429TooManyRequests("Claw is still working on earlier messages. Retry in a few seconds."),
+		http.Header{"Retry-After": {clawBusyRetryAfter}},
+	)
+}
 
 // sendToADK forwards a user message to the claw's bridge middleware and returns the bridge response.
 // The bridge handles session management, token estimation, and compaction.
-func sendToADK(containerName, userID, text string) (*bridgeResponse, error) {
+// The call ends when ctx is cancelled or the claw_type's bridge timeout expires.
+func sendToADK(ctx context.Context, containerName, clawType, userID, text string) (*bridgeResponse, error) {
 	base := fmt.Sprintf("http://%s:8080", containerName)
 
 	body, _ := json.Marshal(bridgeRequest{
@@ -865,7 +1144,16 @@ func sendToADK(containerName, userID, text string) (*bridgeResponse, error) {
 		Protocol: "gather-ui",
 	})
 
-	resp, err := adkClient.Post(base+"/msg", "application/json", bytes.NewReader(body))
+	ctx, cancel := context.WithTimeout(ctx, bridgeProfile(clawType).BridgeTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/msg", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("bridge request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := adkClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("bridge request failed: %w", err)
 	}
@@ -898,7 +1186,8 @@ func sendToADK(containerName, userID, text string) (*bridgeResponse, error) {
 
 // sendToADKStream forwards a user message to the claw's bridge middleware via SSE streaming.
 // Returns the response body for streaming. Caller must close the body.
-func sendToADKStream(containerName, userID, text string) (*http.Response, error) {
+// Cancelling ctx (e.g. the browser disconnecting) closes the upstream stream.
+func sendToADKStream(ctx context.Context, containerName, userID, text string) (*http.Response, error) {
 	base := fmt.Sprintf("http://%s:8080", containerName)
 
 	body, _ := json.Marshal(bridgeRequest{
@@ -908,10 +1197,15 @@ func sendToADKStream(containerName, userID, text string) (*http.Response, error)
 		Protocol: "gather-ui",
 	})
 
-	// No client-level timeout — SSE streams stay open for the entire agent run,
+	// No timeout — SSE streams stay open for the entire agent run,
 	// streaming events tool-by-tool. The caller's context handles cancellation.
-	streamClient := &http.Client{}
-	resp, err := streamClient.Post(base+"/msg/stream", "application/json", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/msg/stream", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("bridge stream request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := adkClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("bridge stream request failed: %w", err)
 	}
@@ -955,10 +1249,18 @@ func HandleClawStream(app *pocketbase.PocketBase) http.HandlerFunc {
 		log.Printf("[STREAM] clawID=%s userID=%s", clawID, userID)
 
 		record, err := app.FindRecordById("claw_deployments", clawID)
-		if err != nil || record.GetString("user_id") != userID {
+		if err != nil {
 			http.Error(w, `{"error":"Claw not found"}`, http.StatusNotFound)
 			return
 		}
+		switch role := clawRole(app, record, userID); {
+		case role == "":
+			http.Error(w, `{"error":"Claw not found"}`, http.StatusNotFound)
+			return
+		case !clawRoleAtLeast(role, ClawRoleOperator):
+			http.Error(w, `{"error":"Sending messages needs operator access to the claw"}`, http.StatusForbidden)
+			return
+		}
 
 		agentID := record.GetString("agent_id")
 		channelID, err := findClawChannel(app, agentID)
@@ -976,12 +1278,30 @@ func HandleClawStream(app *pocketbase.PocketBase) http.HandlerFunc {
 			return
 		}
 
+		if record.GetString("status") == "sleeping" {
+			if wake := clawWakeTime(record); !wake.IsZero() {
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(time.Until(wake).Seconds())+1))
+			}
+			body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("%s is asleep (%s). Wake it now with POST /api/claws/%s/wake.",
+				record.GetString("name"), describeClawWake(record), record.Id)})
+			http.Error(w, string(body), http.StatusServiceUnavailable)
+			return
+		}
 		containerID := record.GetString("container_id")
 		if containerID == "" {
 			http.Error(w, `{"error":"Claw container not running"}`, http.StatusUnprocessableEntity)
 			return
 		}
 
+		queued := clawActiveRequests(containerID)
+		release, err := acquireClawSlot(containerID, record.GetString("claw_type"))
+		if err != nil {
+			w.Header().Set("Retry-After", clawBusyRetryAfter)
+			http.Error(w, `{"error":"Claw is still working on earlier messages. Retry in a few seconds."}`, http.StatusTooManyRequests)
+			return
+		}
+		defer release()
+
 		// Save user's message
 		col, err := app.FindCollectionByNameOrId("channel_messages")
 		if err != nil {
@@ -999,38 +1319,89 @@ func HandleClawStream(app *pocketbase.PocketBase) http.HandlerFunc {
 			return
 		}
 
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			log.Printf("[STREAM] ERROR: response writer %T does not support Flusher", w)
+			http.Error(w, `{"error":"streaming not supported"}`, http.StatusInternalServerError)
+			return
+		}
+
+		// The bridge sends nothing until the agent starts on this turn, so
+		// when earlier turns are still running, say so straight away.
+		sseStarted := false
+		if queued > 0 {
+			setSSEHeaders(w)
+			queuedEvt, _ := json.Marshal(map[string]any{"type": "queued", "active_requests": queued})
+			fmt.Fprintf(w, "data: %s\n\n", queuedEvt)
+			flusher.Flush()
+			sseStarted = true
+		}
+
 		// Stream from bridge
 		log.Printf("[STREAM] sending to bridge: container=%s", containerID)
-		bridgeResp, err := sendToADKStream(containerID, userID, reqBody.Body)
+		bridgeResp, err := sendToADKStream(r.Context(), containerID, userID, reqBody.Body)
 		if err != nil {
 			log.Printf("[STREAM] ERROR: bridge failed: %v", err)
+			if sseStarted {
+				errEvt, _ := json.Marshal(map[string]string{"type": "error", "text": fmt.Sprintf("Claw did not respond: %v", err)})
+				fmt.Fprintf(w, "data: %s\n\n", errEvt)
+				flusher.Flush()
+				return
+			}
 			http.Error(w, fmt.Sprintf(`{"error":"Claw did not respond: %v"}`, err), http.StatusBadGateway)
 			return
 		}
 		defer bridgeResp.Body.Close()
 		log.Printf("[STREAM] bridge responded %d, starting SSE relay", bridgeResp.StatusCode)
 
-		// Set SSE headers
-		w.Header().Set("Content-Type", "text/event-stream")
-		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
-		w.Header().Set("X-Accel-Buffering", "no")
-
-		flusher, ok := w.(http.Flusher)
-		if !ok {
-			log.Printf("[STREAM] ERROR: response writer %T does not support Flusher", w)
-			http.Error(w, `{"error":"streaming not supported"}`, http.StatusInternalServerError)
-			return
+		if !sseStarted {
+			setSSEHeaders(w)
 		}
 		log.Printf("[STREAM] flusher OK, starting stream to claw container %s", containerID)
 
-		// Raw byte relay — no per-event JSON parsing.
-		// TeeReader captures trailing bytes so we can extract the "end" event after stream closes.
+		// Raw byte relay to the client. The TeeReader captures trailing bytes
+		// so we can extract the "end" event after the stream closes, and
+		// collects the run's events to store with the reply.
 		tail := &tailBuffer{max: 256 * 1024}
-		tee := io.TeeReader(bridgeResp.Body, tail)
+		events := &clawStreamEvents{}
+		tee := io.TeeReader(bridgeResp.Body, io.MultiWriter(tail, events))
 
+		// The upstream request shares r.Context(), so a disconnect also
+		// unblocks a pending Read; checking Done between chunks stops us
+		// writing to a dead client.
 		fw := &flushWriter{w: w, f: flusher}
-		n, copyErr := io.Copy(fw, tee)
+		buf := make([]byte, 32*1024)
+		var n int64
+		var copyErr error
+	relay:
+		for {
+			select {
+			case <-r.Context().Done():
+				copyErr = r.Context().Err()
+				break relay
+			default:
+			}
+			nr, rerr := tee.Read(buf)
+			if nr > 0 {
+				nw, werr := fw.Write(buf[:nr])
+				n += int64(nw)
+				if werr != nil {
+					copyErr = werr
+					break
+				}
+			}
+			if rerr != nil {
+				if rerr != io.EOF {
+					copyErr = rerr
+				}
+				break
+			}
+		}
+		bridgeResp.Body.Close()
+		if r.Context().Err() != nil {
+			log.Printf("[STREAM] client disconnected after %d bytes; closed bridge stream for %s", n, containerID)
+			return
+		}
 		if copyErr != nil {
 			log.Printf("[STREAM] relay error after %d bytes: %v", n, copyErr)
 		}
@@ -1045,6 +1416,9 @@ func HandleClawStream(app *pocketbase.PocketBase) http.HandlerFunc {
 			replyRec.Set("channel_id", channelID)
 			replyRec.Set("author_id", agentID)
 			replyRec.Set("body", lastText)
+			if encoded := events.Encoded(); encoded != nil {
+				replyRec.Set("events", string(encoded))
+			}
 			if err := app.Save(replyRec); err != nil {
 				app.Logger().Error("Failed to save streamed claw reply", "claw", containerID, "error", err)
 			}
@@ -1061,6 +1435,14 @@ func HandleClawStream(app *pocketbase.PocketBase) http.HandlerFunc {
 	}
 }
 
+// setSSEHeaders marks the response as an unbuffered event stream.
+func setSSEHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+}
+
 // flushWriter wraps an io.Writer + http.Flusher, flushing after every Write.
 type flushWriter struct {
 	w io.Writer
@@ -1191,25 +1573,6 @@ var allowedEnvKeys = map[string]bool{
 	"TELEGRAM_CHAT_ID":   true,
 }
 
-// requireClawOwner validates auth + ownership and returns the claw record.
-func requireClawOwner(app *pocketbase.PocketBase, authHeader, clawID string) (*core.Record, error) {
-	userID, err := extractPBUserID(app, authHeader)
-	if err != nil {
-		return nil, huma.Error401Unauthorized("Authentication required")
-	}
-
-	record, err := app.FindRecordById("claw_deployments", clawID)
-	if err != nil {
-		return nil, huma.Error404NotFound("Deployment not found")
-	}
-
-	if record.GetString("user_id") != userID {
-		return nil, huma.Error404NotFound("Deployment not found")
-	}
-
-	return record, nil
-}
-
 // isSensitiveKey returns true for keys whose values should be masked in API responses.
 func isSensitiveKey(key string) bool {
 	upper := strings.ToUpper(key)
@@ -1234,31 +1597,145 @@ func readClawEnv(ctx context.Context, containerID string) (map[string]string, er
 	}
 	defer cli.Close()
 
-	execCfg := container.ExecOptions{
-		Cmd:          []string{"cat", "/app/data/.env"},
-		AttachStdout: true,
-		AttachStderr: true,
+	content, err := execClawCommand(ctx, cli, containerID, "cat", "/app/data/.env")
+	if err != nil {
+		return nil, err
 	}
-	execID, err := cli.ContainerExecCreate(ctx, containerID, execCfg)
+	return parseEnvFile(content), nil
+}
+
+// applyClawEnvChanges applies a save request to the current .env contents in
+// place. Empty values and keys in remove are deleted; a sensitive value equal
+// to the masked form of the current one (a GET round-trip) is left alone.
+// Returns the keys actually set and removed, sorted.
+func applyClawEnvChanges(vars, updates map[string]string, remove []string) (set, removed []string) {
+	set, removed = []string{}, []string{}
+	for _, k := range remove {
+		if _, ok := vars[k]; ok {
+			delete(vars, k)
+			removed = append(removed, k)
+		}
+	}
+	for k, v := range updates {
+		current, exists := vars[k]
+		switch {
+		case v == "":
+			if exists {
+				delete(vars, k)
+				removed = append(removed, k)
+			}
+		case v == current:
+		case exists && isSensitiveKey(k) && v == maskValue(current):
+		default:
+			vars[k] = v
+			set = append(set, k)
+		}
+	}
+	sort.Strings(set)
+	sort.Strings(removed)
+	return set, removed
+}
+
+// compareClawEnv builds the per-key view of the .env file against the live
+// process environment (nil if unknown). Values are masked like GET's vars.
+// restartNeeded is true when an allowlisted key differs between the two.
+func compareClawEnv(file, live map[string]string) (entries []ClawEnvEntry, restartNeeded bool) {
+	keys := make([]string, 0, len(file))
+	for k := range file {
+		keys = append(keys, k)
+	}
+	for k := range live {
+		if _, ok := file[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	mask := func(k, v string) string {
+		if isSensitiveKey(k) && v != "" {
+			return maskValue(v)
+		}
+		return v
+	}
+
+	entries = make([]ClawEnvEntry, 0, len(keys))
+	for _, k := range keys {
+		fileVal, inFile := file[k]
+		liveVal, inLive := live[k]
+		e := ClawEnvEntry{
+			Key:    k,
+			Value:  mask(k, fileVal),
+			InFile: inFile,
+			Active: inFile && inLive && fileVal == liveVal,
+		}
+		if inLive && !e.Active {
+			e.LiveValue = mask(k, liveVal)
+		}
+		if live != nil && allowedEnvKeys[k] && !e.Active {
+			restartNeeded = true
+		}
+		entries = append(entries, e)
+	}
+	return entries, restartNeeded
+}
+
+// readClawLiveEnv returns the allowlisted variables the running claw actually
+// has. The entrypoint sources .env and then execs medic as PID 1, so PID 1's
+// environ reflects .env as of the last start; docker inspect's Config.Env
+// only holds creation-time values and is the fallback.
+func readClawLiveEnv(ctx context.Context, containerID string) (map[string]string, error) {
+	cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, err
 	}
+	defer cli.Close()
+
+	var pairs []string
+	if raw, err := execClawCommand(ctx, cli, containerID, "cat", "/proc/1/environ"); err == nil && len(raw) > 0 {
+		pairs = strings.Split(raw, "\x00")
+	} else {
+		info, err := cli.ContainerInspect(ctx, containerID)
+		if err != nil {
+			return nil, err
+		}
+		if info.Config != nil {
+			pairs = info.Config.Env
+		}
+	}
+
+	live := map[string]string{}
+	for _, p := range pairs {
+		k, v, ok := strings.Cut(p, "=")
+		if ok && allowedEnvKeys[k] {
+			live[k] = v
+		}
+	}
+	return live, nil
+}
+
+// execClawCommand runs a command in the container and returns its stdout and
+// stderr with Docker's stream headers stripped.
+func execClawCommand(ctx context.Context, cli *dockerclient.Client, containerID string, cmd ...string) (string, error) {
+	execID, err := cli.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", err
+	}
 
 	resp, err := cli.ContainerExecAttach(ctx, execID.ID, container.ExecAttachOptions{})
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	defer resp.Close()
 
 	raw, err := io.ReadAll(resp.Reader)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	// Strip Docker multiplexed stream headers
-	content := stripDockerLogHeaders(raw)
-
-	return parseEnvFile(content), nil
+	return stripDockerLogHeaders(raw), nil
 }
 
 // writeClawEnv writes a .env file to the container's /app/data/ via CopyToContainer.
@@ -1318,6 +1795,30 @@ func restartClawContainer(ctx context.Context, containerID string) error {
 	return cli.ContainerRestart(ctx, containerID, container.StopOptions{Timeout: &timeout})
 }
 
+// stopClawContainer stops a Docker container with a 10-second timeout,
+// keeping it (and its volumes) for startClawContainer.
+func stopClawContainer(ctx context.Context, containerID string) error {
+	cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	timeout := 10
+	return cli.ContainerStop(ctx, containerID, container.StopOptions{Timeout: &timeout})
+}
+
+// startClawContainer starts a stopped Docker container.
+func startClawContainer(ctx context.Context, containerID string) error {
+	cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	return cli.ContainerStart(ctx, containerID, container.StartOptions{})
+}
+
 // parseEnvFile parses KEY=VALUE lines from a .env file string.
 func parseEnvFile(content string) map[string]string {
 	vars := map[string]string{}
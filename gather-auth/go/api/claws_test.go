@@ -0,0 +1,105 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyClawEnvChanges(t *testing.T) {
+	const key = "sk-ant-0123456789abcdef"
+	vars := map[string]string{
+		"MODEL_PROVIDER":    "anthropic",
+		"ANTHROPIC_API_KEY": key,
+		"ANTHROPIC_MODEL":   "old-model",
+		"TELEGRAM_CHAT_ID":  "42",
+	}
+	set, removed := applyClawEnvChanges(vars, map[string]string{
+		"TELEGRAM_BOT":      "bot-token", // add
+		"ANTHROPIC_MODEL":   "new-model", // update
+		"TELEGRAM_CHAT_ID":  "",          // delete by empty value
+		"ANTHROPIC_API_KEY": maskValue(key),
+	}, []string{"ANTHROPIC_API_BASE", "MODEL_PROVIDER"})
+
+	want := map[string]string{
+		"ANTHROPIC_API_KEY": key, // the masked round trip kept the secret
+		"ANTHROPIC_MODEL":   "new-model",
+		"TELEGRAM_BOT":      "bot-token",
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("vars = %v, want %v", vars, want)
+	}
+	if !reflect.DeepEqual(set, []string{"ANTHROPIC_MODEL", "TELEGRAM_BOT"}) {
+		t.Errorf("set = %v", set)
+	}
+	// Removing a key that isn't there isn't reported
+	if !reflect.DeepEqual(removed, []string{"MODEL_PROVIDER", "TELEGRAM_CHAT_ID"}) {
+		t.Errorf("removed = %v", removed)
+	}
+
+	// A new secret replaces the old one
+	set, _ = applyClawEnvChanges(vars, map[string]string{"ANTHROPIC_API_KEY": "sk-ant-new-key-value"}, nil)
+	if vars["ANTHROPIC_API_KEY"] != "sk-ant-new-key-value" || len(set) != 1 {
+		t.Errorf("secret update: %v %v", set, vars)
+	}
+
+	// Resubmitting the current value changes nothing
+	set, removed = applyClawEnvChanges(vars, map[string]string{"ANTHROPIC_MODEL": "new-model"}, nil)
+	if len(set) != 0 || len(removed) != 0 {
+		t.Errorf("unchanged save: set %v removed %v", set, removed)
+	}
+}
+
+func TestCompareClawEnv(t *testing.T) {
+	file := map[string]string{
+		"ANTHROPIC_MODEL":   "new-model",
+		"ANTHROPIC_API_KEY": "sk-ant-0123456789abcdef",
+		"TELEGRAM_CHAT_ID":  "42",
+	}
+	live := map[string]string{
+		"ANTHROPIC_MODEL":   "old-model",               // stale: saved, not restarted
+		"ANTHROPIC_API_KEY": "sk-ant-0123456789abcdef", // active
+		"MODEL_PROVIDER":    "anthropic",               // deleted from the file, still running
+	}
+
+	entries, restart := compareClawEnv(file, live)
+	if !restart {
+		t.Error("restart_needed = false with stale values")
+	}
+	got := map[string]ClawEnvEntry{}
+	for _, e := range entries {
+		got[e.Key] = e
+	}
+	want := map[string]ClawEnvEntry{
+		"ANTHROPIC_API_KEY": {Key: "ANTHROPIC_API_KEY", Value: "sk-a***************cdef", InFile: true, Active: true},
+		"ANTHROPIC_MODEL":   {Key: "ANTHROPIC_MODEL", Value: "new-model", InFile: true, LiveValue: "old-model"},
+		"MODEL_PROVIDER":    {Key: "MODEL_PROVIDER", LiveValue: "anthropic"},
+		"TELEGRAM_CHAT_ID":  {Key: "TELEGRAM_CHAT_ID", Value: "42", InFile: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entries:\n got %+v\nwant %+v", got, want)
+	}
+
+	// In sync
+	if _, restart := compareClawEnv(map[string]string{"ANTHROPIC_MODEL": "m"}, map[string]string{"ANTHROPIC_MODEL": "m"}); restart {
+		t.Error("restart_needed with file and process in sync")
+	}
+
+	// Live environment unknown: nothing is active, and no restart is claimed
+	entries, restart = compareClawEnv(file, nil)
+	if restart {
+		t.Error("restart_needed without a live environment")
+	}
+	for _, e := range entries {
+		if e.Active || e.LiveValue != "" {
+			t.Errorf("%s: %+v without a live environment", e.Key, e)
+		}
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	got := parseEnvFile("# comment\nA=1\n\n  B=x=y  \n=nokey\nnovalue\nC=\n")
+	want := map[string]string{"A": "1", "B": "x=y", "C": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
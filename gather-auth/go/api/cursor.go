@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Opaque polling cursors
+// -----------------------------------------------------------------------------
+
+// A cursor marks a position in a (created, id) ordered stream. Timestamps
+// alone aren't enough: several records can share a created value, and
+// clients polling with their own clock drift. Clients treat the encoded
+// string as opaque and just echo back next_cursor.
+type cursor struct {
+	Created string // PocketBase datetime
+	ID      string // empty = strictly after Created (translated ?since=)
+}
+
+func encodeCursor(created, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(created + "|" + id))
+}
+
+func decodeCursor(s string) (cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, fmt.Errorf("invalid cursor")
+	}
+	created, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return cursor{}, fmt.Errorf("invalid cursor")
+	}
+	if _, err := time.Parse(pbDateTimeLayout, created); err != nil {
+		return cursor{}, fmt.Errorf("invalid cursor")
+	}
+	return cursor{Created: created, ID: id}, nil
+}
+
+// recordCursor returns the cursor positioned at r.
+func recordCursor(r *core.Record) string {
+	return encodeCursor(r.GetString("created"), r.Id)
+}
+
+// resolveCursor turns ?cursor= or the legacy ?since= (RFC3339) into a cursor.
+// ok is false when neither was given.
+func resolveCursor(app core.App, cursorParam, since string) (c cursor, ok bool, err error) {
+	if cursorParam != "" {
+		c, err = decodeCursor(cursorParam)
+		if err != nil {
+			return c, false, huma.Error400BadRequest("cursor is invalid; use next_cursor from a previous response")
+		}
+		return c, true, nil
+	}
+	if since != "" {
+		t, err := parseSince(app, since)
+		if err != nil {
+			return c, false, huma.Error400BadRequest("since must be RFC3339 (e.g. 2026-02-11T00:00:00Z)")
+		}
+		return cursor{Created: t.Format(pbDateTimeLayout)}, true, nil
+	}
+	return c, false, nil
+}
+
+// filter returns a PocketBase filter selecting records strictly after c.
+func (c cursor) filter(params map[string]any) string {
+	params["cur_created"] = c.Created
+	if c.ID == "" {
+		return "created > {:cur_created}"
+	}
+	params["cur_id"] = c.ID
+	return "(created > {:cur_created} || (created = {:cur_created} && id > {:cur_id}))"
+}
+
+// cursorSort is the deterministic ascending order cursors walk.
+const cursorSort = "created,id"
+
+// String encodes c for next_cursor.
+func (c cursor) String() string {
+	return encodeCursor(c.Created, c.ID)
+}
+
+// latestCursor returns the cursor of the newest record in records, or
+// fallback if records is empty, so a client can keep polling from it.
+func latestCursor(records []*core.Record, fallback string) string {
+	var latest *core.Record
+	for _, r := range records {
+		if latest == nil || r.GetString("created") > latest.GetString("created") ||
+			(r.GetString("created") == latest.GetString("created") && r.Id > latest.Id) {
+			latest = r
+		}
+	}
+	if latest == nil {
+		return fallback
+	}
+	return recordCursor(latest)
+}
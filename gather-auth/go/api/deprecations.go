@@ -0,0 +1,140 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// -----------------------------------------------------------------------------
+// Deprecations — operations and parameters scheduled for removal
+// -----------------------------------------------------------------------------
+
+// Everything scheduled for removal is listed in deprecations. From that one
+// list, ApplyDeprecations marks the OpenAPI spec (deprecated, plus x-sunset
+// and x-replacement for gather-mcp), DeprecationMiddleware sends Deprecation
+// (RFC 9745), Sunset (RFC 8594) and a successor Link on every response, and
+// /discover lists them. An entry with Param covers one query parameter of the
+// operation rather than the whole operation, and its headers are only sent
+// when a request uses it.
+//
+// Only Huma operations can be listed: routes registered straight on the
+// PocketBase router aren't in the spec and don't pass through the middleware.
+
+type Deprecation struct {
+	OperationID string `json:"operation_id"`
+	Param       string `json:"param,omitempty" doc:"Query parameter that is deprecated; empty when the whole operation is"`
+	Endpoint    string `json:"endpoint" doc:"Method and path"`
+	Deprecated  string `json:"deprecated" doc:"Date the deprecation was announced (YYYY-MM-DD)"`
+	Sunset      string `json:"sunset" doc:"Date after which it may be removed (YYYY-MM-DD)"`
+	Replacement string `json:"replacement" doc:"What to use instead"`
+	// SuccessorPath, if set, is sent as a Link: rel="successor-version".
+	SuccessorPath string `json:"-"`
+}
+
+var deprecations = []Deprecation{
+	{
+		OperationID:   "create-review",
+		Endpoint:      "POST /api/reviews",
+		Deprecated:    "2026-10-16",
+		Sunset:        "2027-01-15",
+		Replacement:   "POST /api/reviews/submit",
+		SuccessorPath: "/api/reviews/submit",
+	},
+	{
+		OperationID: "list-posts",
+		Param:       "since",
+		Endpoint:    "GET /api/posts",
+		Deprecated:  "2026-10-16",
+		Sunset:      "2027-01-15",
+		Replacement: "?cursor= with the previous response's next_cursor",
+	},
+	{
+		OperationID: "get-channel-messages",
+		Param:       "since",
+		Endpoint:    "GET /api/channels/{id}/messages",
+		Deprecated:  "2026-10-16",
+		Sunset:      "2027-01-15",
+		Replacement: "?cursor= with the previous response's next_cursor",
+	},
+}
+
+// deprecationsByOp indexes deprecations by operation ID.
+var deprecationsByOp = func() map[string][]Deprecation {
+	m := map[string][]Deprecation{}
+	for _, d := range deprecations {
+		for _, date := range []string{d.Deprecated, d.Sunset} {
+			if _, err := time.Parse(time.DateOnly, date); err != nil {
+				panic(fmt.Sprintf("deprecation %s: bad date %q", d.OperationID, date))
+			}
+		}
+		m[d.OperationID] = append(m[d.OperationID], d)
+	}
+	return m
+}()
+
+// Deprecations lists everything scheduled for removal, for /discover.
+func Deprecations() []Deprecation {
+	return append([]Deprecation(nil), deprecations...)
+}
+
+// ApplyDeprecations marks the listed operations and parameters deprecated in
+// the OpenAPI spec. Call it after all routes are registered.
+func ApplyDeprecations(api huma.API) {
+	for _, item := range api.OpenAPI().Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch} {
+			if op == nil {
+				continue
+			}
+			for _, d := range deprecationsByOp[op.OperationID] {
+				if d.Param == "" {
+					op.Deprecated = true
+					if op.Extensions == nil {
+						op.Extensions = map[string]any{}
+					}
+					op.Extensions["x-sunset"] = d.Sunset
+					op.Extensions["x-replacement"] = d.Replacement
+					op.Description = strings.TrimSpace(fmt.Sprintf("Deprecated: may be removed after %s; use %s instead. %s",
+						d.Sunset, d.Replacement, op.Description))
+					continue
+				}
+				for _, p := range op.Parameters {
+					if p != nil && p.In == "query" && p.Name == d.Param {
+						p.Deprecated = true
+						p.Description = fmt.Sprintf("Deprecated: may be removed after %s; use %s. %s",
+							d.Sunset, d.Replacement, p.Description)
+					}
+				}
+			}
+		}
+	}
+}
+
+// DeprecationMiddleware adds Deprecation, Sunset and Link headers to
+// responses from deprecated operations, and from operations called with a
+// deprecated parameter.
+func DeprecationMiddleware(ctx huma.Context, next func(huma.Context)) {
+	if op := ctx.Operation(); op != nil {
+		for _, d := range deprecationsByOp[op.OperationID] {
+			if d.Param != "" && ctx.Query(d.Param) == "" {
+				continue
+			}
+			setDeprecationHeaders(ctx, d)
+			break
+		}
+	}
+	next(ctx)
+}
+
+func setDeprecationHeaders(ctx huma.Context, d Deprecation) {
+	deprecated, _ := time.Parse(time.DateOnly, d.Deprecated)
+	sunset, _ := time.Parse(time.DateOnly, d.Sunset)
+	ctx.SetHeader("Deprecation", fmt.Sprintf("@%d", deprecated.Unix()))
+	ctx.SetHeader("Sunset", sunset.UTC().Format(http.TimeFormat))
+	if d.SuccessorPath != "" {
+		ctx.AppendHeader("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", d.SuccessorPath))
+	}
+}
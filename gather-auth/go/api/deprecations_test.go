@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+func TestDeprecationHeaders(t *testing.T) {
+	app := newTestApp(t)
+	kr := newTestKeyring(t)
+	_, api := humatest.New(t)
+	api.UseMiddleware(DeprecationMiddleware)
+	RegisterReviewRoutes(api, app, kr)
+	RegisterPostRoutes(api, app, kr, nil)
+	RegisterChannelRoutes(api, app, kr, TinodeConfig{})
+	ApplyDeprecations(api)
+
+	// The headers go out whatever the handler returns, so these requests
+	// needn't succeed
+	cases := []struct {
+		name       string
+		resp       func() http.Header
+		deprecated bool
+		link       string
+	}{
+		{"create-review", func() http.Header { return api.Post("/api/reviews", map[string]any{}).Header() }, true,
+			`</api/reviews/submit>; rel="successor-version"`},
+		{"list-posts with since", func() http.Header { return api.Get("/api/posts?since=2026-01-01T00:00:00Z").Header() }, true, ""},
+		{"list-posts", func() http.Header { return api.Get("/api/posts").Header() }, false, ""},
+		{"list-posts with cursor", func() http.Header { return api.Get("/api/posts?cursor=abc").Header() }, false, ""},
+		{"channel messages with since", func() http.Header {
+			return api.Get("/api/channels/c1/messages?since=2026-01-01T00:00:00Z", bearer(t, kr, "agent")).Header()
+		}, true, ""},
+		{"channel messages", func() http.Header {
+			return api.Get("/api/channels/c1/messages", bearer(t, kr, "agent")).Header()
+		}, false, ""},
+	}
+	for _, tc := range cases {
+		h := tc.resp()
+		if !tc.deprecated {
+			if h.Get("Deprecation") != "" || h.Get("Sunset") != "" || h.Get("Link") != "" {
+				t.Errorf("%s: deprecation headers %v", tc.name, h)
+			}
+			continue
+		}
+		if got := h.Get("Deprecation"); got != "@1792108800" { // 2026-10-16
+			t.Errorf("%s: Deprecation %q", tc.name, got)
+		}
+		if got := h.Get("Sunset"); got != "Fri, 15 Jan 2027 00:00:00 GMT" {
+			t.Errorf("%s: Sunset %q", tc.name, got)
+		}
+		if got := h.Get("Link"); got != tc.link {
+			t.Errorf("%s: Link %q, want %q", tc.name, got, tc.link)
+		}
+	}
+}
+
+func TestApplyDeprecationsSpec(t *testing.T) {
+	app := newTestApp(t)
+	kr := newTestKeyring(t)
+	_, api := humatest.New(t)
+	RegisterReviewRoutes(api, app, kr)
+	RegisterPostRoutes(api, app, kr, nil)
+	RegisterChannelRoutes(api, app, kr, TinodeConfig{})
+	ApplyDeprecations(api)
+	paths := api.OpenAPI().Paths
+
+	create := paths["/api/reviews"].Post
+	if !create.Deprecated || create.Extensions["x-sunset"] != "2027-01-15" ||
+		create.Extensions["x-replacement"] != "POST /api/reviews/submit" ||
+		!strings.HasPrefix(create.Description, "Deprecated: may be removed after 2027-01-15") {
+		t.Errorf("create-review: deprecated %v, extensions %v, description %q",
+			create.Deprecated, create.Extensions, create.Description)
+	}
+	if paths["/api/reviews/submit"].Post.Deprecated {
+		t.Error("submit-review marked deprecated")
+	}
+
+	// Only the since parameter is deprecated, not the operations
+	for _, path := range []string{"/api/posts", "/api/channels/{id}/messages"} {
+		op := paths[path].Get
+		if op.Deprecated || op.Extensions["x-sunset"] != nil {
+			t.Errorf("%s: operation marked deprecated", path)
+		}
+		found := false
+		for _, p := range op.Parameters {
+			if p.Name == "since" {
+				found = true
+				if !p.Deprecated || !strings.Contains(p.Description, "2027-01-15") || !strings.Contains(p.Description, "?cursor=") {
+					t.Errorf("%s since: deprecated %v, %q", path, p.Deprecated, p.Description)
+				}
+			} else if p.Deprecated {
+				t.Errorf("%s: %s marked deprecated", path, p.Name)
+			}
+		}
+		if !found {
+			t.Errorf("%s: no since parameter", path)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Design library — list, reuse and delete uploaded designs
+// -----------------------------------------------------------------------------
+
+// Designs belong to the agent that uploaded them. Orders may only use the
+// orderer's own designs, by design_id or design_url; the URL is guessable,
+// so it is resolved to its record and checked like an ID.
+
+type ListDesignsInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	Limit         int    `query:"limit" default:"20" minimum:"1" maximum:"100" doc:"Max results"`
+	Offset        int    `query:"offset" default:"0" minimum:"0" doc:"Offset for pagination"`
+}
+
+type DesignItem struct {
+	ID           string          `json:"id" doc:"Use as design_id in POST /api/order/product"`
+	OriginalName string          `json:"original_name"`
+	MimeType     string          `json:"mime_type,omitempty"`
+	Width        int             `json:"width,omitempty" doc:"Pixels; omitted for SVGs and designs uploaded before dimensions were stored"`
+	Height       int             `json:"height,omitempty"`
+	DesignURL    string          `json:"design_url"`
+	PrintReady   map[string]bool `json:"print_ready" doc:"Product ID → whether the design is big enough to print on it"`
+	Created      string          `json:"created,omitempty"`
+}
+
+type ListDesignsOutput struct {
+	Body struct {
+		Designs []DesignItem `json:"designs"`
+		Total   int          `json:"total"`
+		Limit   int          `json:"limit"`
+		Offset  int          `json:"offset"`
+	}
+}
+
+type DeleteDesignInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Design ID"`
+}
+
+type DeleteDesignOutput struct {
+	Body struct {
+		Status string `json:"status"`
+	}
+}
+
+// DesignFileURL is the platform URL a design's image is served at.
+func DesignFileURL(design *core.Record) string {
+	// PocketBase serves files at /api/files/{collection}/{record_id}/{filename}
+	return fmt.Sprintf("/api/files/designs/%s/%s", design.Id, design.GetString("file"))
+}
+
+func recordToDesignItem(r *core.Record) DesignItem {
+	width, height := r.GetInt("width"), r.GetInt("height")
+	return DesignItem{
+		ID:           r.Id,
+		OriginalName: r.GetString("original_name"),
+		MimeType:     r.GetString("mime_type"),
+		Width:        width,
+		Height:       height,
+		DesignURL:    DesignFileURL(r),
+		PrintReady:   PrintReadiness(width, height),
+		Created:      recordTime(r, "created"),
+	}
+}
+
+// findOwnDesign returns the agent's design with this ID. Someone else's
+// design is reported as not found.
+func findOwnDesign(app *pocketbase.PocketBase, agentID, id string) (*core.Record, error) {
+	design, err := app.FindRecordById("designs", id)
+	if err != nil || design.GetString("agent_id") != agentID {
+		return nil, fmt.Errorf("design %q not found among your designs; see GET /api/designs or upload it via POST /api/designs/upload", id)
+	}
+	return design, nil
+}
+
+// resolveOrderDesign turns an order's design_id or design_url into the
+// design URL to print, checking the design is the orderer's and big enough
+// for the product. Neither given returns "" (the product placeholder).
+func resolveOrderDesign(app *pocketbase.PocketBase, agentID, productID, designID, designURL string) (string, error) {
+	if designID != "" && designURL != "" {
+		return "", errors.New("give design_id or design_url, not both")
+	}
+	if designURL != "" {
+		if !strings.HasPrefix(designURL, "/api/files/designs/") &&
+			!strings.HasPrefix(designURL, "https://gather.is/api/files/designs/") {
+			return "", errors.New("design_url must be a platform-hosted image from POST /api/designs/upload. External URLs are not accepted.")
+		}
+		designID = designIDFromURL(designURL)
+	}
+	if designID == "" {
+		if designURL != "" {
+			return "", errors.New("design_url doesn't point at an uploaded design; use design_id from GET /api/designs")
+		}
+		return "", nil
+	}
+
+	design, err := findOwnDesign(app, agentID, designID)
+	if err != nil {
+		return "", err
+	}
+	// Designs uploaded before dimensions were recorded have no width/height
+	// and are let through.
+	if err := CheckDesignSize(productID, design.GetInt("width"), design.GetInt("height")); err != nil {
+		return "", err
+	}
+	return DesignFileURL(design), nil
+}
+
+// liveDesignOrders counts the orders that still print this design.
+func liveDesignOrders(app *pocketbase.PocketBase, designID string) (int, error) {
+	recs, err := app.FindRecordsByFilter("orders",
+		"design_url ~ {:ref} && status != 'cancelled'", "", 0, 0,
+		map[string]any{"ref": "/api/files/designs/" + designID + "/"})
+	return len(recs), err
+}
+
+func registerDesignRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	// GET /api/designs
+	huma.Register(api, huma.Operation{
+		OperationID: "list-designs",
+		Method:      "GET",
+		Path:        "/api/designs",
+		Summary:     "List your uploaded designs",
+		Description: "Your designs from POST /api/designs/upload, newest first. Reuse one in an order with design_id instead of uploading it again.",
+		Tags:        []string{"Orders"},
+	}, func(ctx context.Context, input *ListDesignsInput) (*ListDesignsOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		params := map[string]any{"aid": claims.AgentID}
+		all, err := app.FindRecordsByFilter("designs", "agent_id = {:aid}", "-created", 0, 0, params)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to list designs")
+		}
+		recs := all[min(input.Offset, len(all)):min(input.Offset+input.Limit, len(all))]
+
+		out := &ListDesignsOutput{}
+		out.Body.Designs = make([]DesignItem, 0, len(recs))
+		for _, r := range recs {
+			out.Body.Designs = append(out.Body.Designs, recordToDesignItem(r))
+		}
+		out.Body.Total = len(all)
+		out.Body.Limit = input.Limit
+		out.Body.Offset = input.Offset
+		return out, nil
+	})
+
+	// DELETE /api/designs/{id}
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-design",
+		Method:      "DELETE",
+		Path:        "/api/designs/{id}",
+		Summary:     "Delete one of your designs",
+		Description: "Deletes the design and its image. Refused while an order still uses it.",
+		Tags:        []string{"Orders"},
+	}, func(ctx context.Context, input *DeleteDesignInput) (*DeleteDesignOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		design, err := findOwnDesign(app, claims.AgentID, input.ID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Design not found.")
+		}
+		n, err := liveDesignOrders(app, design.Id)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to check orders using the design")
+		}
+		if n > 0 {
+			return nil, huma.Error409Conflict(fmt.Sprintf("Design is used by %d order(s) and can't be deleted.", n))
+		}
+		if err := app.Delete(design); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to delete design")
+		}
+
+		out := &DeleteDesignOutput{}
+		out.Body.Status = "deleted"
+		return out, nil
+	})
+}
@@ -0,0 +1,340 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gather.is/auth/shop"
+)
+
+// -----------------------------------------------------------------------------
+// Design image validation (used by POST /api/designs/upload and orders)
+// -----------------------------------------------------------------------------
+
+const defaultDesignMaxMB = 20
+
+// maxDesignPixels guards against decompression bombs: a small PNG can claim
+// an enormous canvas and exhaust memory when fully decoded.
+const maxDesignPixels = 100_000_000
+
+// DesignMaxBytes is the upload size cap (DESIGN_MAX_MB, default 20).
+func DesignMaxBytes() int64 {
+	mb := defaultDesignMaxMB
+	if v, err := strconv.Atoi(os.Getenv("DESIGN_MAX_MB")); err == nil && v > 0 {
+		mb = v
+	}
+	return int64(mb) << 20
+}
+
+// IsValidImageContent checks magic bytes to verify actual file content matches claimed type.
+func IsValidImageContent(header []byte, ext string) bool {
+	if len(header) < 4 {
+		return false
+	}
+	switch ext {
+	case ".png":
+		return len(header) >= 8 && header[0] == 0x89 && header[1] == 0x50 && header[2] == 0x4E && header[3] == 0x47
+	case ".jpg", ".jpeg":
+		return header[0] == 0xFF && header[1] == 0xD8 && header[2] == 0xFF
+	case ".webp":
+		return len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WEBP"
+	case ".svg":
+		// Check for XML/SVG opening markers in first 512 bytes
+		s := strings.ToLower(string(header))
+		return strings.Contains(s, "<svg") || strings.Contains(s, "<?xml")
+	}
+	return false
+}
+
+// DesignDimensions validates an uploaded image and returns its pixel size.
+// PNG and JPEG are fully decoded so truncated or corrupt files are rejected;
+// WebP dimensions come from the container header. SVG is vector and returns
+// 0, 0.
+func DesignDimensions(data []byte, ext string) (int, int, error) {
+	switch ext {
+	case ".png", ".jpg", ".jpeg":
+		decodeConfig, decode := png.DecodeConfig, png.Decode
+		if ext != ".png" {
+			decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+		}
+		cfg, err := decodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return 0, 0, fmt.Errorf("not a valid image: %w", err)
+		}
+		if cfg.Width*cfg.Height > maxDesignPixels {
+			return 0, 0, fmt.Errorf("image is %dx%d; maximum is %d megapixels", cfg.Width, cfg.Height, maxDesignPixels/1_000_000)
+		}
+		if _, err := decode(bytes.NewReader(data)); err != nil {
+			return 0, 0, fmt.Errorf("image is corrupt: %w", err)
+		}
+		return cfg.Width, cfg.Height, nil
+	case ".webp":
+		return webpDimensions(data)
+	case ".svg":
+		return 0, 0, nil
+	}
+	return 0, 0, fmt.Errorf("unsupported image type %q", ext)
+}
+
+// webpDimensions reads the canvas size from a WebP header (lossy VP8,
+// lossless VP8L, or extended VP8X).
+func webpDimensions(data []byte) (int, int, error) {
+	if len(data) < 30 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, 0, fmt.Errorf("not a valid WebP file")
+	}
+	var w, h int
+	switch string(data[12:16]) {
+	case "VP8 ":
+		if data[23] != 0x9d || data[24] != 0x01 || data[25] != 0x2a {
+			return 0, 0, fmt.Errorf("corrupt WebP (VP8) header")
+		}
+		w = int(binary.LittleEndian.Uint16(data[26:28]) & 0x3fff)
+		h = int(binary.LittleEndian.Uint16(data[28:30]) & 0x3fff)
+	case "VP8L":
+		if data[20] != 0x2f {
+			return 0, 0, fmt.Errorf("corrupt WebP (VP8L) header")
+		}
+		bits := binary.LittleEndian.Uint32(data[21:25])
+		w = int(bits&0x3fff) + 1
+		h = int((bits>>14)&0x3fff) + 1
+	case "VP8X":
+		w = int(uint32(data[24])|uint32(data[25])<<8|uint32(data[26])<<16) + 1
+		h = int(uint32(data[27])|uint32(data[28])<<8|uint32(data[29])<<16) + 1
+	default:
+		return 0, 0, fmt.Errorf("unrecognised WebP chunk %q", data[12:16])
+	}
+	if w == 0 || h == 0 {
+		return 0, 0, fmt.Errorf("WebP reports zero dimensions")
+	}
+	return w, h, nil
+}
+
+// SVG namespaces the sanitizer writes back out.
+const (
+	svgNS   = "http://www.w3.org/2000/svg"
+	xlinkNS = "http://www.w3.org/1999/xlink"
+	xmlNS   = "http://www.w3.org/XML/1998/namespace"
+)
+
+// svgElements are the elements a sanitized SVG may contain: shapes, text,
+// paint servers, clipping, masking and filters. Everything else — script,
+// foreignObject, a, animations, editor metadata — is dropped with its
+// content. Names are matched on the local part, so a namespace prefix
+// (<x:script xmlns:x="http://www.w3.org/2000/svg">) doesn't let anything in.
+var svgElements = setOf(
+	"svg", "g", "defs", "desc", "title", "metadata", "symbol", "use", "style",
+	"path", "rect", "circle", "ellipse", "line", "polyline", "polygon", "image",
+	"text", "tspan", "textPath",
+	"linearGradient", "radialGradient", "stop", "pattern", "clipPath", "mask", "marker",
+	"filter", "feBlend", "feColorMatrix", "feComponentTransfer", "feComposite",
+	"feConvolveMatrix", "feDiffuseLighting", "feDisplacementMap", "feDistantLight",
+	"feDropShadow", "feFlood", "feFuncA", "feFuncB", "feFuncG", "feFuncR",
+	"feGaussianBlur", "feImage", "feMerge", "feMergeNode", "feMorphology", "feOffset",
+	"fePointLight", "feSpecularLighting", "feSpotLight", "feTile", "feTurbulence",
+)
+
+// svgAttributes are the attributes kept on those elements: geometry,
+// presentation and the parameters of the elements above. No event handler
+// (on*) is on the list.
+var svgAttributes = setOf(
+	"id", "class", "style", "lang", "space", "version", "href",
+	"x", "y", "x1", "y1", "x2", "y2", "cx", "cy", "r", "rx", "ry", "fx", "fy", "fr",
+	"width", "height", "viewBox", "preserveAspectRatio", "transform", "d", "points", "pathLength",
+	"fill", "fill-opacity", "fill-rule", "stroke", "stroke-width", "stroke-opacity",
+	"stroke-linecap", "stroke-linejoin", "stroke-miterlimit", "stroke-dasharray",
+	"stroke-dashoffset", "opacity", "color", "display", "visibility", "overflow",
+	"clip-path", "clip-rule", "mask", "filter", "paint-order", "vector-effect",
+	"mix-blend-mode", "isolation", "shape-rendering", "text-rendering", "image-rendering",
+	"color-interpolation", "color-interpolation-filters",
+	"font-family", "font-size", "font-style", "font-weight", "font-variant", "font-stretch",
+	"text-anchor", "dominant-baseline", "alignment-baseline", "baseline-shift",
+	"letter-spacing", "word-spacing", "text-decoration", "writing-mode",
+	"dx", "dy", "rotate", "textLength", "lengthAdjust", "startOffset", "method", "spacing",
+	"offset", "stop-color", "stop-opacity", "gradientUnits", "gradientTransform", "spreadMethod",
+	"patternUnits", "patternContentUnits", "patternTransform", "clipPathUnits",
+	"maskUnits", "maskContentUnits", "markerUnits", "markerWidth", "markerHeight",
+	"refX", "refY", "orient", "marker-start", "marker-mid", "marker-end",
+	"filterUnits", "primitiveUnits", "in", "in2", "result", "stdDeviation", "mode",
+	"operator", "k1", "k2", "k3", "k4", "type", "values", "tableValues", "slope",
+	"intercept", "amplitude", "exponent", "flood-color", "flood-opacity", "lighting-color",
+	"baseFrequency", "numOctaves", "seed", "stitchTiles", "scale", "xChannelSelector",
+	"yChannelSelector", "radius", "order", "kernelMatrix", "divisor", "bias", "targetX",
+	"targetY", "edgeMode", "preserveAlpha", "surfaceScale", "specularConstant",
+	"specularExponent", "diffuseConstant", "azimuth", "elevation", "pointsAtX", "pointsAtY",
+	"pointsAtZ", "limitingConeAngle", "z",
+)
+
+func setOf(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+var (
+	svgURLRe    = regexp.MustCompile(`(?i)url\(\s*("[^"]*"|'[^']*'|[^)]*)\s*\)`)
+	svgImportRe = regexp.MustCompile(`(?i)@import\b[^;]*;?`)
+)
+
+// svgLocalRef reports whether an href or url() value points inside the file
+// (#id). Anything else — another site, javascript:, data: — is fetched from
+// elsewhere or executed.
+func svgLocalRef(v string) bool {
+	return strings.HasPrefix(strings.TrimSpace(strings.Trim(strings.TrimSpace(v), `"'`)), "#")
+}
+
+// svgCSS neutralises external references in a style attribute or element:
+// url() values that aren't #fragments become none and @import is removed.
+func svgCSS(css string) string {
+	css = svgURLRe.ReplaceAllStringFunc(css, func(m string) string {
+		if svgLocalRef(svgURLRe.FindStringSubmatch(m)[1]) {
+			return m
+		}
+		return "none"
+	})
+	return svgImportRe.ReplaceAllString(css, "")
+}
+
+// SanitizeSVG parses an SVG and writes back only allowlisted elements and
+// attributes: no DOCTYPE, comments or processing instructions, no script or
+// foreignObject, no event handlers, and no references to anything outside the
+// file (href, url(), @import). Designs are served from our own origin, so
+// anything executable in them would run as gather.is. Files that aren't
+// well-formed XML are rejected rather than repaired. It also fails if the
+// root isn't <svg> or if more was dropped than kept — at that point the
+// picture we would store isn't the one that was uploaded.
+func SanitizeSVG(data []byte) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Entity = xml.HTMLEntity
+
+	var out bytes.Buffer
+	var open []string // names of the elements being written
+	kept, dropped := 0, 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("not well-formed XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(open) == 0 && (t.Name.Local != "svg" || kept > 0) {
+				return nil, fmt.Errorf("root element is <%s>, not a single <svg>", t.Name.Local)
+			}
+			if !svgElements[t.Name.Local] {
+				dropped++
+				if err := d.Skip(); err != nil {
+					return nil, fmt.Errorf("not well-formed XML: %w", err)
+				}
+				continue
+			}
+			kept++
+			out.WriteString("<" + t.Name.Local)
+			if len(open) == 0 {
+				out.WriteString(` xmlns="` + svgNS + `" xmlns:xlink="` + xlinkNS + `"`)
+			}
+			for _, a := range t.Attr {
+				writeSVGAttr(&out, a)
+			}
+			out.WriteString(">")
+			open = append(open, t.Name.Local)
+		case xml.EndElement:
+			out.WriteString("</" + t.Name.Local + ">")
+			open = open[:len(open)-1]
+		case xml.CharData:
+			if len(open) == 0 {
+				continue
+			}
+			text := string(t)
+			if open[len(open)-1] == "style" {
+				text = svgCSS(text)
+			}
+			xml.EscapeText(&out, []byte(text))
+		}
+		// Comments, processing instructions and directives (DOCTYPE, and with
+		// it any entity definitions) are left out.
+	}
+
+	if kept == 0 {
+		return nil, fmt.Errorf("no <svg> element")
+	}
+	if dropped > kept {
+		return nil, fmt.Errorf("more of the file is scripts, embedded HTML or unsupported elements than drawing; upload a plain SVG")
+	}
+	return out.Bytes(), nil
+}
+
+// writeSVGAttr writes a if it is allowlisted, with links and CSS restricted
+// to the file itself.
+func writeSVGAttr(out *bytes.Buffer, a xml.Attr) {
+	name, value := a.Name.Local, a.Value
+	if a.Name.Space == "xmlns" || name == "xmlns" || !svgAttributes[name] {
+		return
+	}
+	switch name {
+	case "href":
+		if !svgLocalRef(value) {
+			return
+		}
+		if a.Name.Space == xlinkNS {
+			name = "xlink:href"
+		}
+	case "space", "lang":
+		if a.Name.Space != xmlNS {
+			return
+		}
+		name = "xml:" + name
+	default:
+		value = svgCSS(value)
+	}
+	out.WriteString(" " + name + `="`)
+	xml.EscapeText(out, []byte(value))
+	out.WriteString(`"`)
+}
+
+// CheckDesignSize reports whether a raster design is large enough to print
+// on a product. Vector designs (0x0) always pass.
+func CheckDesignSize(productID string, width, height int) error {
+	if width == 0 && height == 0 {
+		return nil
+	}
+	minW, minH := shop.MinDesignSize(productID)
+	if width < minW || height < minH {
+		return fmt.Errorf("design is %dx%dpx but %s needs at least %dx%dpx", width, height, productID, minW, minH)
+	}
+	return nil
+}
+
+// PrintReadiness maps each product to whether a design of this size can be
+// printed on it, so agents can check before ordering.
+func PrintReadiness(width, height int) map[string]bool {
+	out := make(map[string]bool, len(shop.ProductOrder))
+	for _, id := range shop.ProductOrder {
+		out[id] = CheckDesignSize(id, width, height) == nil
+	}
+	return out
+}
+
+// designIDFromURL extracts the record ID from a design_url returned by
+// POST /api/designs/upload.
+func designIDFromURL(designURL string) string {
+	i := strings.Index(designURL, "/api/files/designs/")
+	if i < 0 {
+		return ""
+	}
+	rest := designURL[i+len("/api/files/designs/"):]
+	id, _, _ := strings.Cut(rest, "/")
+	return id
+}
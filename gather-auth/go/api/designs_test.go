@@ -0,0 +1,157 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+)
+
+const svgOpen = `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 10 10">`
+
+// svgFixture wraps body in an <svg> root with a couple of shapes, so the
+// payload is a small part of the file.
+func svgFixture(body string) string {
+	return svgOpen + `<rect width="10" height="10" fill="red"/><circle cx="5" cy="5" r="2"/>` + body + `</svg>`
+}
+
+// svgUnsafe lists what must never survive sanitization, lower-cased.
+var svgUnsafe = []string{"<script", "script>", "onload", "onclick", "onerror", "javascript:",
+	"foreignobject", "<iframe", "@import", "evil.example", "data:", "<!doctype", "<!entity", "<set", "<animate"}
+
+func TestSanitizeSVGMalicious(t *testing.T) {
+	cases := []struct {
+		name string
+		svg  string
+	}{
+		{"script", svgFixture(`<script>alert(document.cookie)</script>`)},
+		{"script CDATA", svgFixture(`<script><![CDATA[alert(1)]]></script>`)},
+		{"nested script name", svgFixture(`<scr<script></script>ipt>alert(1)</script>`)},
+		{"namespaced script", svgFixture(`<x:script xmlns:x="http://www.w3.org/2000/svg">alert(1)</x:script>`)},
+		{"html namespace script", svgFixture(`<h:script xmlns:h="http://www.w3.org/1999/xhtml">alert(1)</h:script>`)},
+		{"root onload", `<svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"><rect width="1" height="1"/></svg>`},
+		{"namespaced handler", svgFixture(`<rect x:onclick="alert(1)" xmlns:x="urn:x" width="1" height="1"/>`)},
+		{"event handler", svgFixture(`<circle r="1" onmouseover='alert(1)' ONCLICK="alert(2)"/>`)},
+		{"javascript href", svgFixture(`<use href="javascript:alert(1)"/><use xlink:href=" JaVaScRiPt:alert(2)"/>`)},
+		{"data href", svgFixture(`<image href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg=="/>`)},
+		{"external href", svgFixture(`<use href="https://evil.example/sprite.svg#x"/>`)},
+		{"link", svgFixture(`<a href="javascript:alert(1)"><text>click</text></a>`)},
+		{"foreignObject", svgFixture(`<foreignObject><iframe xmlns="http://www.w3.org/1999/xhtml" src="https://evil.example"/></foreignObject>`)},
+		{"animation rewrites href", svgFixture(`<use href="#a"><set attributeName="href" to="javascript:alert(1)"/></use>`)},
+		{"animation adds handler", svgFixture(`<animate attributeName="onload" values="alert(1)"/>`)},
+		{"style import", svgFixture(`<style>@import url(https://evil.example/x.css); rect { fill: blue }</style>`)},
+		{"style url", svgFixture(`<rect width="1" height="1" style="fill: url('https://evil.example/p.svg#g')"/>`)},
+		{"fill url", svgFixture(`<rect width="1" height="1" fill="url(javascript:alert(1))"/>`)},
+		{"doctype entities", `<?xml version="1.0"?><!DOCTYPE svg [<!ENTITY x SYSTEM "file:///etc/passwd">]>` + svgFixture(`<text>&x;</text>`)},
+		{"external doctype", `<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "https://evil.example/svg11.dtd">` + svgFixture(``)},
+		{"processing instruction", `<?xml-stylesheet href="https://evil.example/x.css"?>` + svgFixture(``)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := SanitizeSVG([]byte(tc.svg))
+			if err != nil {
+				return // rejected outright
+			}
+			lower := strings.ToLower(string(out))
+			for _, bad := range svgUnsafe {
+				if strings.Contains(lower, bad) {
+					t.Errorf("sanitized SVG still contains %q:\n%s", bad, out)
+				}
+			}
+			if !strings.HasPrefix(lower, "<svg") {
+				t.Errorf("sanitized SVG doesn't start with <svg>:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestSanitizeSVGPayloadsAreRemoved(t *testing.T) {
+	// Both of these got past the earlier regex-based sanitizer.
+	if out, err := SanitizeSVG([]byte(svgFixture(`<scr<script></script>ipt>alert(1)</script>`))); err == nil {
+		t.Errorf("malformed nested script accepted:\n%s", out)
+	}
+	out, err := SanitizeSVG([]byte(svgFixture(`<x:script xmlns:x="http://www.w3.org/2000/svg">alert(1)</x:script>`)))
+	if err != nil {
+		t.Fatalf("namespaced script: %v", err)
+	}
+	if strings.Contains(string(out), "script") || strings.Contains(string(out), "alert") {
+		t.Errorf("namespaced script survived:\n%s", out)
+	}
+}
+
+func TestSanitizeSVGKeepsDrawing(t *testing.T) {
+	in := svgOpen + `<defs><linearGradient id="g"><stop offset="0" stop-color="#fff"/></linearGradient></defs>` +
+		`<style>.a { fill: url(#g) } .b > .c { stroke: #000 }</style>` +
+		`<g transform="translate(1 1)"><path class="a" d="M0 0L10 10"/><use xlink:href="#g"/>` +
+		`<text x="1" y="2" xml:space="preserve">A &amp; B</text></g></svg>`
+	out, err := SanitizeSVG([]byte(in))
+	if err != nil {
+		t.Fatalf("SanitizeSVG: %v", err)
+	}
+	for _, want := range []string{`xmlns="http://www.w3.org/2000/svg"`, `viewBox="0 0 10 10"`,
+		`<linearGradient id="g">`, `stop-color="#fff"`, `fill: url(#g)`, `.b &gt; .c`,
+		`<path class="a" d="M0 0L10 10">`, `xlink:href="#g"`, `xml:space="preserve"`, `A &amp; B`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if _, err := SanitizeSVG(out); err != nil {
+		t.Errorf("sanitized output doesn't sanitize again: %v", err)
+	}
+}
+
+func TestSanitizeSVGRejects(t *testing.T) {
+	cases := map[string]string{
+		"not xml":         `<svg><rect></svg>`,
+		"html root":       `<html><body><svg xmlns="http://www.w3.org/2000/svg"></svg></body></html>`,
+		"two roots":       `<svg xmlns="http://www.w3.org/2000/svg"/><svg xmlns="http://www.w3.org/2000/svg"/>`,
+		"mostly script":   svgOpen + `<script>a()</script><script>b()</script><foreignObject/></svg>`,
+		"unknown entity":  svgFixture(`<text>&xxe;</text>`),
+		"empty":           ``,
+		"script root":     `<script xmlns="http://www.w3.org/2000/svg">alert(1)</script>`,
+		"namespaced root": `<x:script xmlns:x="http://www.w3.org/2000/svg"><svg/></x:script>`,
+	}
+	for name, in := range cases {
+		if out, err := SanitizeSVG([]byte(in)); err == nil {
+			t.Errorf("%s: accepted as\n%s", name, out)
+		}
+	}
+}
+
+func TestDownloadArtifactHeaders(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "artifacts", "review", "file:file", "file_name", "mime_type")
+	file, err := filesystem.NewFileFromBytes([]byte(`<html><script>alert(1)</script></html>`), "report.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact := addRecord(t, app, "artifacts", map[string]any{
+		"review": "rev1", "file": file, "file_name": "report.html", "mime_type": "text/html",
+	})
+
+	_, api := humatest.New(t)
+	registerArtifactRoutes(api, app)
+
+	resp := api.Get("/api/reviews/rev1/artifacts/" + artifact.Id)
+	if resp.Code != 200 {
+		t.Fatalf("status %d: %s", resp.Code, resp.Body)
+	}
+	for header, want := range map[string]string{
+		"Content-Disposition":     `attachment; filename="report.html"`,
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": downloadCSP,
+		"Content-Type":            "text/html",
+	} {
+		if got := resp.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if !strings.Contains(resp.Body.String(), "<script>") {
+		t.Errorf("body = %q, want the file unchanged", resp.Body)
+	}
+
+	if resp := api.Get("/api/reviews/other/artifacts/" + artifact.Id); resp.Code != 404 {
+		t.Errorf("artifact under another review: status %d, want 404", resp.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Digest helpers — seen-post filtering and channel summaries
+// -----------------------------------------------------------------------------
+
+// digestSize is the number of posts in a digest.
+const digestSize = 10
+
+// DigestChannel is one line of the digest's channel summary.
+type DigestChannel struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Unread       int    `json:"unread"`
+	LatestAuthor string `json:"latest_author,omitempty"`
+}
+
+// unseenDigestPosts returns the top visible posts created after since that
+// the agent hasn't authored, voted on or commented on.
+func unseenDigestPosts(app *pocketbase.PocketBase, agentID, since string) []*core.Record {
+	var rows []struct {
+		ID string `db:"id"`
+	}
+	err := app.DB().NewQuery(`
+		SELECT p.id FROM posts p
+		WHERE p.created > {:since}
+		  AND p.status != 'scheduled' AND p.hidden IS NOT TRUE
+		  AND p.author_id != {:aid}
+		  AND p.id NOT IN (SELECT post_id FROM votes WHERE agent_id = {:aid})
+		  AND p.id NOT IN (SELECT post_id FROM comments WHERE author_id = {:aid})
+		ORDER BY p.weight DESC, p.score DESC, p.created DESC
+		LIMIT {:limit}
+	`).Bind(map[string]any{"since": since, "aid": agentID, "limit": digestSize}).All(&rows)
+	if err != nil {
+		app.Logger().Warn("Failed to load unseen digest posts", "agent", agentID, "error", err)
+		return nil
+	}
+
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	records, err := app.FindRecordsByIds("posts", ids)
+	if err != nil {
+		return nil
+	}
+	// FindRecordsByIds doesn't keep the query's ranking
+	rank := make(map[string]int, len(ids))
+	for i, id := range ids {
+		rank[id] = i
+	}
+	sort.Slice(records, func(i, j int) bool { return rank[records[i].Id] < rank[records[j].Id] })
+	return records
+}
+
+// digestChannels summarizes the agent's channels that have unread messages,
+// most unread first, using each membership's read cursor.
+func digestChannels(app *pocketbase.PocketBase, agentID string) []DigestChannel {
+	unread := channelUnreadCounts(app, agentID)
+
+	channels := make([]DigestChannel, 0, len(unread))
+	for channelID, count := range unread {
+		if count == 0 {
+			continue
+		}
+		ch, err := app.FindRecordById("channels", channelID)
+		if err != nil {
+			continue
+		}
+		item := DigestChannel{ID: ch.Id, Name: ch.GetString("name"), Unread: count}
+		latest, _ := app.FindRecordsByFilter("channel_messages",
+			"channel_id = {:cid} && author_id != {:aid}", "-created", 1, 0,
+			map[string]any{"cid": channelID, "aid": agentID})
+		if len(latest) > 0 {
+			item.LatestAuthor = agentName(app, latest[0].GetString("author_id"))
+		}
+		channels = append(channels, item)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].Unread != channels[j].Unread {
+			return channels[i].Unread > channels[j].Unread
+		}
+		return channels[i].Name < channels[j].Name
+	})
+	return channels
+}
+
+// estimateTokens approximates the LLM token cost of v as JSON, at roughly
+// four bytes per token.
+func estimateTokens(v any) int {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return (len(b) + 3) / 4
+}
@@ -0,0 +1,391 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Post and comment drafts
+// -----------------------------------------------------------------------------
+
+// Drafts hold content server-side while an agent works through PoW and fees,
+// so a failed final submit doesn't lose a long post. They cost nothing, are
+// only visible to their author, and are consumed by POST /api/posts or
+// POST /api/posts/{id}/comments with draft_id.
+
+const (
+	maxDraftsPerAgent = 20
+	draftRetention    = 30 * 24 * time.Hour
+)
+
+// errDraftGone is returned inside a publish transaction when the draft was
+// deleted or published by a concurrent request after it was first read.
+var errDraftGone = errors.New("draft no longer exists")
+
+type DraftItem struct {
+	ID        string   `json:"id"`
+	Kind      string   `json:"kind" doc:"post or comment"`
+	ClientID  string   `json:"client_id,omitempty"`
+	PostID    string   `json:"post_id,omitempty" doc:"Post a comment draft replies to"`
+	ReplyTo   string   `json:"reply_to,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Summary   string   `json:"summary,omitempty"`
+	Body      string   `json:"body,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Created   string   `json:"created"`
+	Updated   string   `json:"updated"`
+	ExpiresAt string   `json:"expires_at" doc:"Drafts are deleted 30 days after their last edit"`
+}
+
+type SaveDraftInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	Body          struct {
+		ClientID string   `json:"client_id,omitempty" doc:"Your own identifier for this draft. Saving again with the same client_id updates the draft instead of creating another, so retries are safe." maxLength:"64"`
+		Kind     string   `json:"kind,omitempty" doc:"post (default) or comment" enum:"post,comment"`
+		PostID   string   `json:"post_id,omitempty" doc:"For comment drafts: the post being commented on"`
+		ReplyTo  string   `json:"reply_to,omitempty" doc:"For comment drafts: comment ID to reply to"`
+		Title    string   `json:"title,omitempty" maxLength:"200"`
+		Summary  string   `json:"summary,omitempty" maxLength:"500"`
+		Body     string   `json:"body,omitempty" maxLength:"10000"`
+		Tags     []string `json:"tags,omitempty" doc:"Up to 5 tags"`
+	}
+}
+
+type SaveDraftOutput struct {
+	Status int `header:"Status"`
+	Body   DraftItem
+}
+
+type ListDraftsInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ClientID      string `query:"client_id" doc:"Only the draft with this client_id"`
+}
+
+type ListDraftsOutput struct {
+	Body struct {
+		Drafts []DraftItem `json:"drafts"`
+		Total  int         `json:"total"`
+		Limit  int         `json:"limit" doc:"Maximum drafts per agent"`
+	}
+}
+
+type DraftIDInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Draft ID"`
+}
+
+type DraftOutput struct {
+	Body DraftItem
+}
+
+type PatchDraftInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Draft ID"`
+	Body          struct {
+		PostID  *string   `json:"post_id,omitempty"`
+		ReplyTo *string   `json:"reply_to,omitempty"`
+		Title   *string   `json:"title,omitempty" maxLength:"200"`
+		Summary *string   `json:"summary,omitempty" maxLength:"500"`
+		Body    *string   `json:"body,omitempty" maxLength:"10000"`
+		Tags    *[]string `json:"tags,omitempty"`
+	}
+}
+
+type DeleteDraftOutput struct {
+	Body struct {
+		Deleted bool `json:"deleted"`
+	}
+}
+
+func registerDraftRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID:   "save-draft",
+		Method:        "POST",
+		Path:          "/api/posts/drafts",
+		Summary:       "Save a post or comment draft",
+		Description:   "Requires JWT. No PoW or fee. Publish later with draft_id on POST /api/posts or POST /api/posts/{id}/comments. Max 20 drafts; each expires 30 days after its last edit.",
+		Tags:          []string{"Posts"},
+		DefaultStatus: 201,
+	}, func(ctx context.Context, input *SaveDraftInput) (*SaveDraftOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		b := input.Body
+		kind := b.Kind
+		if kind == "" {
+			kind = "post"
+		}
+		tags, err := validateDraftContent(kind, b.Title, b.Summary, b.Body, b.Tags)
+		if err != nil {
+			return nil, err
+		}
+
+		var record *core.Record
+		status := 201
+		if b.ClientID != "" {
+			record, _ = app.FindFirstRecordByFilter("drafts",
+				"agent_id = {:aid} && client_id = {:cid}",
+				map[string]any{"aid": claims.AgentID, "cid": b.ClientID})
+		}
+		if record != nil {
+			if record.GetString("kind") != kind {
+				return nil, huma.Error409Conflict(fmt.Sprintf("client_id is already used by a %s draft", record.GetString("kind")))
+			}
+			status = 200
+		} else {
+			existing, _ := app.FindRecordsByFilter("drafts", "agent_id = {:aid}", "", 0, 0,
+				map[string]any{"aid": claims.AgentID})
+			if len(existing) >= maxDraftsPerAgent {
+				return nil, huma.Error409Conflict(fmt.Sprintf("Draft limit reached (%d). Publish or delete a draft first.", maxDraftsPerAgent))
+			}
+			collection, err := app.FindCollectionByNameOrId("drafts")
+			if err != nil {
+				return nil, huma.Error500InternalServerError("drafts collection not found")
+			}
+			record = core.NewRecord(collection)
+			record.Set("agent_id", claims.AgentID)
+			record.Set("kind", kind)
+			record.Set("client_id", b.ClientID)
+		}
+
+		record.Set("post_id", b.PostID)
+		record.Set("reply_to", b.ReplyTo)
+		record.Set("title", b.Title)
+		record.Set("summary", b.Summary)
+		record.Set("body", b.Body)
+		record.Set("tags", tags)
+		if err := app.Save(record); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to save draft")
+		}
+
+		out := &SaveDraftOutput{}
+		out.Status = status
+		out.Body = recordToDraftItem(record)
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-drafts",
+		Method:      "GET",
+		Path:        "/api/posts/drafts",
+		Summary:     "List your drafts",
+		Description: "Requires JWT. Your own drafts, most recently edited first.",
+		Tags:        []string{"Posts"},
+	}, func(ctx context.Context, input *ListDraftsInput) (*ListDraftsOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		filter := "agent_id = {:aid}"
+		params := map[string]any{"aid": claims.AgentID}
+		if input.ClientID != "" {
+			filter += " && client_id = {:cid}"
+			params["cid"] = input.ClientID
+		}
+		records, _ := app.FindRecordsByFilter("drafts", filter, "-updated", 0, 0, params)
+
+		out := &ListDraftsOutput{}
+		out.Body.Drafts = make([]DraftItem, 0, len(records))
+		for _, r := range records {
+			out.Body.Drafts = append(out.Body.Drafts, recordToDraftItem(r))
+		}
+		out.Body.Total = len(records)
+		out.Body.Limit = maxDraftsPerAgent
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-draft",
+		Method:      "GET",
+		Path:        "/api/posts/drafts/{id}",
+		Summary:     "Get one of your drafts",
+		Description: "Requires JWT.",
+		Tags:        []string{"Posts"},
+	}, func(ctx context.Context, input *DraftIDInput) (*DraftOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		draft, err := findOwnDraft(app, claims.AgentID, input.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &DraftOutput{Body: recordToDraftItem(draft)}, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "update-draft",
+		Method:      "PATCH",
+		Path:        "/api/posts/drafts/{id}",
+		Summary:     "Update a draft",
+		Description: "Requires JWT. Only the fields you send are changed. Editing resets the 30-day expiry.",
+		Tags:        []string{"Posts"},
+	}, func(ctx context.Context, input *PatchDraftInput) (*DraftOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		draft, err := findOwnDraft(app, claims.AgentID, input.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		b := input.Body
+		if b.PostID != nil {
+			draft.Set("post_id", *b.PostID)
+		}
+		if b.ReplyTo != nil {
+			draft.Set("reply_to", *b.ReplyTo)
+		}
+		if b.Title != nil {
+			draft.Set("title", *b.Title)
+		}
+		if b.Summary != nil {
+			draft.Set("summary", *b.Summary)
+		}
+		if b.Body != nil {
+			draft.Set("body", *b.Body)
+		}
+		tags := draftTags(draft)
+		if b.Tags != nil {
+			tags = *b.Tags
+		}
+		clean, err := validateDraftContent(draft.GetString("kind"),
+			draft.GetString("title"), draft.GetString("summary"), draft.GetString("body"), tags)
+		if err != nil {
+			return nil, err
+		}
+		draft.Set("tags", clean)
+
+		if err := app.Save(draft); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to update draft")
+		}
+		return &DraftOutput{Body: recordToDraftItem(draft)}, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-draft",
+		Method:      "DELETE",
+		Path:        "/api/posts/drafts/{id}",
+		Summary:     "Delete a draft",
+		Description: "Requires JWT.",
+		Tags:        []string{"Posts"},
+	}, func(ctx context.Context, input *DraftIDInput) (*DeleteDraftOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		draft, err := findOwnDraft(app, claims.AgentID, input.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := app.Delete(draft); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to delete draft")
+		}
+		out := &DeleteDraftOutput{}
+		out.Body.Deleted = true
+		return out, nil
+	})
+}
+
+// findOwnDraft loads a draft owned by agentID. Other agents' drafts report
+// 404 so their existence isn't revealed.
+func findOwnDraft(app *pocketbase.PocketBase, agentID, id string) (*core.Record, error) {
+	draft, err := app.FindRecordById("drafts", id)
+	if err != nil || draft.GetString("agent_id") != agentID {
+		return nil, huma.Error404NotFound("Draft not found")
+	}
+	return draft, nil
+}
+
+// validateDraftContent checks a draft's fields for its kind. Drafts may be
+// incomplete; required fields are enforced at publish time. Returns the
+// cleaned tags.
+func validateDraftContent(kind, title, summary, body string, tags []string) ([]string, error) {
+	if kind == "comment" {
+		if title != "" || summary != "" || len(tags) > 0 {
+			return nil, huma.Error422UnprocessableEntity("Comment drafts only take body, post_id and reply_to")
+		}
+		if len(body) > 2000 {
+			return nil, huma.Error422UnprocessableEntity("Comment body must be at most 2000 characters")
+		}
+		return []string{}, nil
+	}
+	if len(tags) > 5 {
+		return nil, huma.Error422UnprocessableEntity("Posts take at most 5 tags")
+	}
+	clean := make([]string, 0, len(tags))
+	for _, t := range tags {
+		c, err := validateTag(t)
+		if err != nil {
+			return nil, huma.Error422UnprocessableEntity(err.Error())
+		}
+		clean = append(clean, c)
+	}
+	return clean, nil
+}
+
+func draftTags(r *core.Record) []string {
+	var tags []string
+	r.UnmarshalJSONField("tags", &tags)
+	return tags
+}
+
+func recordToDraftItem(r *core.Record) DraftItem {
+	updated := r.GetDateTime("updated").Time()
+	return DraftItem{
+		ID:        r.Id,
+		Kind:      r.GetString("kind"),
+		ClientID:  r.GetString("client_id"),
+		PostID:    r.GetString("post_id"),
+		ReplyTo:   r.GetString("reply_to"),
+		Title:     r.GetString("title"),
+		Summary:   r.GetString("summary"),
+		Body:      r.GetString("body"),
+		Tags:      draftTags(r),
+		Created:   recordTime(r, "created"),
+		Updated:   recordTime(r, "updated"),
+		ExpiresAt: updated.Add(draftRetention).UTC().Format(time.RFC3339),
+	}
+}
+
+// consumeDraft deletes the draft inside a publish transaction. It re-reads the
+// draft so a concurrent publish or delete since the first read surfaces as
+// errDraftGone instead of publishing twice.
+func consumeDraft(txApp core.App, draftID string) error {
+	draft, err := txApp.FindRecordById("drafts", draftID)
+	if err != nil {
+		return errDraftGone
+	}
+	return txApp.Delete(draft)
+}
+
+// RegisterDraftCleanupJob deletes drafts not edited for 30 days, daily.
+func RegisterDraftCleanupJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("draft_cleanup", 24*time.Hour, func(ctx context.Context) error {
+		return cleanExpiredDrafts(ctx, app)
+	}, jobs.RunOnStart())
+}
+
+func cleanExpiredDrafts(ctx context.Context, app *pocketbase.PocketBase) error {
+	cutoff := time.Now().UTC().Add(-draftRetention).Format(pbDateTimeLayout)
+	_, err := app.DB().NewQuery("DELETE FROM drafts WHERE updated < {:cutoff}").
+		Bind(map[string]any{"cutoff": cutoff}).WithContext(ctx).Execute()
+	if err != nil {
+		return fmt.Errorf("clean expired drafts: %w", err)
+	}
+	return nil
+}
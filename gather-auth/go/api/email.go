@@ -11,6 +11,7 @@ import (
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
 
+	auth "gather.is/auth"
 	gatheremail "gather.is/auth/email"
 	"gather.is/auth/ratelimit"
 )
@@ -135,7 +136,7 @@ type EmailInboundOutput struct {
 // Route registration
 // -----------------------------------------------------------------------------
 
-func RegisterEmailRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte) {
+func RegisterEmailRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
 
 	// GET /api/email — list emails
 	huma.Register(api, huma.Operation{
@@ -183,7 +184,7 @@ func RegisterEmailRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 				Subject:   r.GetString("subject"),
 				BodyText:  truncate(r.GetString("body_text"), 200),
 				Read:      r.GetBool("read"),
-				Created:   r.GetString("created"),
+				Created:   recordTime(r, "created"),
 			})
 		}
 
@@ -226,7 +227,7 @@ func RegisterEmailRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 				Subject:   record.GetString("subject"),
 				BodyText:  record.GetString("body_text"),
 				Read:      record.GetBool("read"),
-				Created:   record.GetString("created"),
+				Created:   recordTime(record, "created"),
 			},
 			BodyHTML:  record.GetString("body_html"),
 			MessageID: record.GetString("message_id"),
@@ -434,7 +435,14 @@ func wakeClawForEmail(app *pocketbase.PocketBase, agentID, fromAddr, subject, bo
 	// Compose a concise message for the claw
 	text := fmt.Sprintf("[EMAIL from %s] Subject: %s\n\n%s", fromAddr, subject, truncate(bodyText, 2000))
 
-	result, err := sendToADK(containerID, "email:"+fromAddr, text)
+	release, err := acquireClawSlot(containerID, deployment.GetString("claw_type"))
+	if err != nil {
+		log.Printf("[EMAIL] Claw %s busy; email stored without waking it", containerID)
+		return
+	}
+	defer release()
+
+	result, err := sendToADK(context.Background(), containerID, deployment.GetString("claw_type"), "email:"+fromAddr, text)
 	if err != nil {
 		log.Printf("[EMAIL] Failed to wake claw %s: %v", containerID, err)
 		return
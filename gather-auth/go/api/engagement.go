@@ -0,0 +1,325 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Engagement notifications — vote milestones and verified commenters
+// -----------------------------------------------------------------------------
+
+// Authors are told when a post reaches a vote milestone (its upvotes cross
+// one of engagement_vote_thresholds, default 5, 25, 100) and when a
+// Twitter-verified agent comments on it. Each milestone is claimed on the
+// post with a conditional UPDATE of vote_milestone, so concurrent votes
+// deliver it exactly once. Every notification is logged in
+// engagement_notifications; past engagement_notifications_per_day for an
+// author in a UTC day they are held, and the engagement_digest job sends the
+// day's held ones as one message the next day.
+
+const (
+	EngagementVoteMilestone   = "vote_milestone"
+	EngagementVerifiedComment = "verified_comment"
+	EngagementDigest          = "engagement_digest"
+
+	// DefaultEngagementVoteThresholds and DefaultEngagementNotificationsPerDay
+	// apply unless platform_config sets engagement_vote_thresholds and
+	// engagement_notifications_per_day.
+	DefaultEngagementVoteThresholds      = "5,25,100"
+	DefaultEngagementNotificationsPerDay = 10
+
+	maxEngagementThresholds = 10
+	engagementDigestEvery   = time.Hour
+	engagementDigestItems   = 10
+)
+
+// parseVoteThresholds parses a comma-separated list of vote thresholds into
+// ascending, distinct positive integers.
+func parseVoteThresholds(s string) ([]int, error) {
+	seen := map[int]bool{}
+	var out []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("thresholds must be positive integers, got %q", part)
+		}
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("at least one threshold is required")
+	}
+	if len(out) > maxEngagementThresholds {
+		return nil, fmt.Errorf("at most %d thresholds", maxEngagementThresholds)
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// engagementSettings reads the vote thresholds and daily cap from
+// platform_config.
+func engagementSettings(app core.App) (thresholds []int, perDay int) {
+	thresholds, _ = parseVoteThresholds(DefaultEngagementVoteThresholds)
+	perDay = DefaultEngagementNotificationsPerDay
+	cfg, err := loadPlatformConfig(app)
+	if err != nil || cfg.Collection().Fields.GetByName("engagement_vote_thresholds") == nil {
+		return thresholds, perDay
+	}
+	if t, err := parseVoteThresholds(cfg.GetString("engagement_vote_thresholds")); err == nil {
+		thresholds = t
+	}
+	perDay = cfg.GetInt("engagement_notifications_per_day")
+	return thresholds, perDay
+}
+
+// NotifyVoteMilestone checks a post's upvotes after a vote is cast or
+// changed, and tells the author if they crossed a threshold not yet
+// notified. Only the highest threshold crossed is sent, so a post with
+// votes from before milestones existed gets one message, not several.
+func NotifyVoteMilestone(app *pocketbase.PocketBase, postID string) {
+	post, err := app.FindRecordById("posts", postID)
+	if err != nil || !postVisible(post) || post.GetString("author_id") == "" {
+		return
+	}
+
+	var upvotes int
+	if err := app.DB().NewQuery(
+		"SELECT COUNT(*) FROM votes WHERE post_id = {:pid} AND value > 0").
+		Bind(map[string]any{"pid": postID}).Row(&upvotes); err != nil {
+		return
+	}
+
+	thresholds, perDay := engagementSettings(app)
+	crossed := 0
+	for _, t := range thresholds {
+		if upvotes >= t {
+			crossed = t
+		}
+	}
+	if crossed == 0 || crossed <= post.GetInt("vote_milestone") {
+		return
+	}
+
+	// Claim the milestone. Of concurrent voters who all see it crossed,
+	// exactly one changes the row and sends it.
+	res, err := app.DB().NewQuery(
+		"UPDATE posts SET vote_milestone = {:t} WHERE id = {:id} AND vote_milestone < {:t}").
+		Bind(map[string]any{"t": crossed, "id": postID}).Execute()
+	if err != nil {
+		app.Logger().Warn("Failed to claim vote milestone", "post", postID, "error", err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return
+	}
+
+	title := post.GetString("title")
+	sendEngagement(app, perDay, engagementEvent{
+		AuthorID:  post.GetString("author_id"),
+		PostID:    postID,
+		Kind:      EngagementVoteMilestone,
+		Threshold: crossed,
+		Subject:   fmt.Sprintf("'%s' reached %d upvotes", title, crossed),
+		Body: fmt.Sprintf("Your post '%s' now has %d upvotes. See it with GET /api/posts/%s.",
+			title, upvotes, postID),
+		Line: fmt.Sprintf("'%s' reached %d upvotes", title, crossed),
+	})
+}
+
+// NotifyVerifiedComment tells a post's author that a Twitter-verified agent
+// commented, the first time that agent comments on the post.
+func NotifyVerifiedComment(app *pocketbase.PocketBase, comment *core.Record) {
+	postID, commenterID := comment.GetString("post_id"), comment.GetString("author_id")
+	if comment.GetBool("hidden") {
+		return
+	}
+	post, err := app.FindRecordById("posts", postID)
+	if err != nil || !postVisible(post) {
+		return
+	}
+	authorID := post.GetString("author_id")
+	if authorID == "" || authorID == commenterID {
+		return
+	}
+	commenter, err := app.FindRecordById("agents", commenterID)
+	if err != nil || !commenter.GetBool("verified") {
+		return
+	}
+	earlier, err := app.FindRecordsByFilter("comments",
+		"post_id = {:pid} && author_id = {:aid} && id != {:id}", "", 1, 0,
+		map[string]any{"pid": postID, "aid": commenterID, "id": comment.Id})
+	if err != nil || len(earlier) > 0 {
+		return
+	}
+
+	_, perDay := engagementSettings(app)
+	title, name := post.GetString("title"), commenter.GetString("name")
+	sendEngagement(app, perDay, engagementEvent{
+		AuthorID: authorID,
+		PostID:   postID,
+		Kind:     EngagementVerifiedComment,
+		ActorID:  commenterID,
+		Subject:  fmt.Sprintf("Verified agent %s commented on '%s'", name, title),
+		Body: fmt.Sprintf("%s, a Twitter-verified agent, commented on your post: %s. Read it with GET /api/posts/%s?expand=comments.",
+			name, truncate(comment.GetString("body"), 200), postID),
+		Line: fmt.Sprintf("verified agent %s commented on '%s'", name, title),
+	})
+}
+
+type engagementEvent struct {
+	AuthorID  string
+	PostID    string
+	Kind      string
+	Threshold int
+	ActorID   string
+	Subject   string
+	Body      string
+	Line      string // one-line summary for the digest
+}
+
+// sendEngagement logs the event and sends it, or holds it for the digest
+// when the author has had perDay engagement notifications today. The count
+// and insert share a transaction, so concurrent events can't overshoot.
+func sendEngagement(app *pocketbase.PocketBase, perDay int, ev engagementEvent) {
+	collection, err := app.FindCollectionByNameOrId("engagement_notifications")
+	if err != nil {
+		app.Logger().Warn("Cannot log engagement notification", "error", err)
+		return
+	}
+	day := time.Now().UTC().Format("2006-01-02")
+
+	var send bool
+	err = app.RunInTransaction(func(txApp core.App) error {
+		var sent int
+		if err := txApp.DB().NewQuery(
+			"SELECT COUNT(*) FROM engagement_notifications WHERE author_id = {:aid} AND day = {:day} AND status = 'sent'").
+			Bind(map[string]any{"aid": ev.AuthorID, "day": day}).Row(&sent); err != nil {
+			return err
+		}
+		send = sent < perDay
+
+		record := core.NewRecord(collection)
+		record.Set("author_id", ev.AuthorID)
+		record.Set("post_id", ev.PostID)
+		record.Set("kind", ev.Kind)
+		record.Set("threshold", ev.Threshold)
+		record.Set("actor_id", ev.ActorID)
+		record.Set("summary", truncate(ev.Line, 300))
+		record.Set("day", day)
+		if send {
+			record.Set("status", "sent")
+		} else {
+			record.Set("status", "held")
+		}
+		return txApp.Save(record)
+	})
+	if err != nil {
+		app.Logger().Warn("Failed to log engagement notification", "author", ev.AuthorID, "kind", ev.Kind, "error", err)
+		return
+	}
+	if send {
+		SendInboxMessage(app, ev.AuthorID, ev.Kind, ev.Subject, ev.Body, "post", ev.PostID)
+	}
+}
+
+// RegisterEngagementDigestJob sends, once a day is over, each author's held
+// engagement notifications from it as one message.
+func RegisterEngagementDigestJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("engagement_digest", engagementDigestEvery, func(ctx context.Context) error {
+		return sendEngagementDigests(ctx, app)
+	}, jobs.RunOnStart())
+}
+
+func sendEngagementDigests(ctx context.Context, app *pocketbase.PocketBase) error {
+	today := time.Now().UTC().Format("2006-01-02")
+	held, err := app.FindRecordsByFilter("engagement_notifications",
+		"status = 'held' && day < {:today}", "author_id,created", 0, 0,
+		map[string]any{"today": today})
+	if err != nil {
+		return fmt.Errorf("find held engagement notifications: %w", err)
+	}
+
+	byAuthor := map[string][]*core.Record{}
+	var authors []string
+	for _, r := range held {
+		a := r.GetString("author_id")
+		if _, ok := byAuthor[a]; !ok {
+			authors = append(authors, a)
+		}
+		byAuthor[a] = append(byAuthor[a], r)
+	}
+
+	for _, author := range authors {
+		if ctx.Err() != nil {
+			return ctx.Err() // shutting down; the rest go next run
+		}
+		records := byAuthor[author]
+		var claimed []*core.Record
+		err := app.RunInTransaction(func(txApp core.App) error {
+			for _, r := range records {
+				res, err := txApp.DB().NewQuery(
+					"UPDATE engagement_notifications SET status = 'digested' WHERE id = {:id} AND status = 'held'").
+					Bind(map[string]any{"id": r.Id}).Execute()
+				if err != nil {
+					return err
+				}
+				if n, _ := res.RowsAffected(); n > 0 {
+					claimed = append(claimed, r)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("digest engagement for %s: %w", author, err)
+		}
+		if len(claimed) == 0 {
+			continue
+		}
+		subject, body, postID := engagementDigestMessage(claimed)
+		refType := ""
+		if postID != "" {
+			refType = "post"
+		}
+		SendInboxMessage(app, author, EngagementDigest, subject, body, refType, postID)
+	}
+	return nil
+}
+
+// engagementDigestMessage summarizes held notifications. postID is set when
+// they are all about one post.
+func engagementDigestMessage(records []*core.Record) (subject, body, postID string) {
+	postID = records[0].GetString("post_id")
+	var lines []string
+	for i, r := range records {
+		if r.GetString("post_id") != postID {
+			postID = ""
+		}
+		if i < engagementDigestItems {
+			lines = append(lines, "- "+r.GetString("summary"))
+		}
+	}
+	if extra := len(records) - engagementDigestItems; extra > 0 {
+		lines = append(lines, fmt.Sprintf("- and %d more", extra))
+	}
+
+	subject = fmt.Sprintf("%d more things happened on your posts", len(records))
+	body = "You hit your daily limit of engagement notifications, so these were saved up:\n" +
+		strings.Join(lines, "\n")
+	return subject, body, postID
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// newEngagementApp returns an app with the collections the engagement hooks
+// read and write, and a visible post by author.
+func newEngagementApp(t *testing.T) (app *pocketbase.PocketBase, post *core.Record) {
+	t.Helper()
+	app = newTestApp(t)
+	addCollection(t, app, "agents", "name", "verified:bool")
+	addCollection(t, app, "posts", "author_id", "title", "status", "hidden:bool", "vote_milestone:number")
+	addCollection(t, app, "votes", "post_id", "agent_id", "value:number")
+	addCollection(t, app, "comments", "post_id", "author_id", "body", "hidden:bool")
+	addCollection(t, app, "messages", "agent_id", "type", "subject", "body", "read:bool", "ref_type", "ref_id")
+	addCollection(t, app, "engagement_notifications",
+		"author_id", "post_id", "kind", "threshold:number", "actor_id", "summary", "day", "status")
+	author := addRecord(t, app, "agents", map[string]any{"name": "author"})
+	post = addRecord(t, app, "posts", map[string]any{"author_id": author.Id, "title": "Hello", "status": "published"})
+	return app, post
+}
+
+// engagementMessages returns the subjects of agentID's inbox messages of
+// type kind.
+func engagementMessages(t *testing.T, app *pocketbase.PocketBase, agentID, kind string) []string {
+	t.Helper()
+	msgs, err := app.FindRecordsByFilter("messages", "agent_id = {:aid} && type = {:type}", "created", 0, 0,
+		map[string]any{"aid": agentID, "type": kind})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var subjects []string
+	for _, m := range msgs {
+		if m.GetString("ref_type") != "post" || m.GetString("ref_id") == "" {
+			t.Errorf("message %q refs %s/%s", m.GetString("subject"), m.GetString("ref_type"), m.GetString("ref_id"))
+		}
+		subjects = append(subjects, m.GetString("subject"))
+	}
+	return subjects
+}
+
+func TestParseVoteThresholds(t *testing.T) {
+	cases := map[string]string{
+		"5,25,100":     "[5 25 100]",
+		" 100, 5 ,25,": "[5 25 100]",
+		"5,5,25":       "[5 25]",
+		"1":            "[1]",
+	}
+	for in, want := range cases {
+		got, err := parseVoteThresholds(in)
+		if err != nil || fmt.Sprint(got) != want {
+			t.Errorf("%q: %v %v, want %s", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", ",", "0", "-5", "5,x", "1,2,3,4,5,6,7,8,9,10,11"} {
+		if got, err := parseVoteThresholds(in); err == nil {
+			t.Errorf("%q: accepted as %v", in, got)
+		}
+	}
+}
+
+func TestVoteMilestoneExactlyOnce(t *testing.T) {
+	app, post := newEngagementApp(t)
+	author := post.GetString("author_id")
+
+	// Every voter runs the hook at once after the fifth vote
+	for i := 0; i < 5; i++ {
+		addRecord(t, app, "votes", map[string]any{"post_id": post.Id, "agent_id": fmt.Sprint("voter", i), "value": 1})
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			NotifyVoteMilestone(app, post.Id)
+		}()
+	}
+	wg.Wait()
+	if got := engagementMessages(t, app, author, EngagementVoteMilestone); len(got) != 1 {
+		t.Fatalf("after 5 votes and 20 concurrent hooks: %q, want one message", got)
+	}
+
+	// Votes and hooks interleaved up to the next threshold
+	votes, _ := app.FindCollectionByNameOrId("votes")
+	for i := 5; i < 30; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			vote := core.NewRecord(votes)
+			vote.Load(map[string]any{"post_id": post.Id, "agent_id": fmt.Sprint("voter", i), "value": 1})
+			if err := app.Save(vote); err != nil {
+				t.Error(err)
+			}
+			NotifyVoteMilestone(app, post.Id)
+		}(i)
+	}
+	wg.Wait()
+	got := engagementMessages(t, app, author, EngagementVoteMilestone)
+	want := []string{"'Hello' reached 5 upvotes", "'Hello' reached 25 upvotes"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("messages %q, want %q", got, want)
+	}
+	post, err := app.FindRecordById("posts", post.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.GetInt("vote_milestone") != 25 {
+		t.Errorf("vote_milestone = %d", post.GetInt("vote_milestone"))
+	}
+
+	// Downvotes don't count, and a milestone isn't re-sent
+	addRecord(t, app, "votes", map[string]any{"post_id": post.Id, "agent_id": "down", "value": -1})
+	NotifyVoteMilestone(app, post.Id)
+	if got := engagementMessages(t, app, author, EngagementVoteMilestone); len(got) != 2 {
+		t.Errorf("re-run sent %d messages", len(got)-2)
+	}
+}
+
+func TestVoteMilestoneSkipsToHighest(t *testing.T) {
+	app, post := newEngagementApp(t)
+	hidden := addRecord(t, app, "posts", map[string]any{"author_id": "someone", "title": "Hidden", "status": "published", "hidden": true})
+	for i := 0; i < 30; i++ {
+		addRecord(t, app, "votes", map[string]any{"post_id": post.Id, "agent_id": fmt.Sprint("voter", i), "value": 1})
+		addRecord(t, app, "votes", map[string]any{"post_id": hidden.Id, "agent_id": fmt.Sprint("voter", i), "value": 1})
+	}
+
+	// Votes from before milestones existed give one message, not several
+	NotifyVoteMilestone(app, post.Id)
+	got := engagementMessages(t, app, post.GetString("author_id"), EngagementVoteMilestone)
+	if len(got) != 1 || got[0] != "'Hello' reached 25 upvotes" {
+		t.Errorf("messages %q", got)
+	}
+
+	NotifyVoteMilestone(app, hidden.Id)
+	if got := engagementMessages(t, app, "someone", EngagementVoteMilestone); len(got) != 0 {
+		t.Errorf("hidden post notified: %q", got)
+	}
+}
+
+func TestVerifiedCommentNotification(t *testing.T) {
+	app, post := newEngagementApp(t)
+	author := post.GetString("author_id")
+	verified := addRecord(t, app, "agents", map[string]any{"name": "Checked", "verified": true})
+	plain := addRecord(t, app, "agents", map[string]any{"name": "Plain"})
+	comment := func(authorID string) {
+		c := addRecord(t, app, "comments", map[string]any{"post_id": post.Id, "author_id": authorID, "body": "nice"})
+		NotifyVerifiedComment(app, c)
+	}
+
+	comment(plain.Id)
+	comment(author)
+	if got := engagementMessages(t, app, author, EngagementVerifiedComment); len(got) != 0 {
+		t.Errorf("unverified or own comment notified: %q", got)
+	}
+
+	comment(verified.Id)
+	comment(verified.Id)
+	got := engagementMessages(t, app, author, EngagementVerifiedComment)
+	if len(got) != 1 || got[0] != "Verified agent Checked commented on 'Hello'" {
+		t.Errorf("messages %q, want one for the first verified comment", got)
+	}
+}
+
+func TestEngagementDailyCapAndDigest(t *testing.T) {
+	app, post := newEngagementApp(t)
+	author := post.GetString("author_id")
+	for i := 1; i <= 5; i++ {
+		sendEngagement(app, 2, engagementEvent{
+			AuthorID: author, PostID: post.Id, Kind: EngagementVoteMilestone, Threshold: i,
+			Subject: fmt.Sprint("milestone ", i), Line: fmt.Sprint("line ", i),
+		})
+	}
+	if got := engagementMessages(t, app, author, EngagementVoteMilestone); len(got) != 2 {
+		t.Fatalf("%d sent under a cap of 2", len(got))
+	}
+	held, _ := app.FindRecordsByFilter("engagement_notifications", "status = 'held'", "", 0, 0, nil)
+	if len(held) != 3 {
+		t.Fatalf("%d held, want 3", len(held))
+	}
+
+	// Held notifications wait for the day to end
+	ctx := context.Background()
+	if err := sendEngagementDigests(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	if got := engagementMessages(t, app, author, EngagementDigest); len(got) != 0 {
+		t.Fatalf("digest sent during the day: %q", got)
+	}
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+	if _, err := app.DB().NewQuery("UPDATE engagement_notifications SET day = {:day}").
+		Bind(map[string]any{"day": yesterday}).Execute(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := sendEngagementDigests(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+	digests, _ := app.FindRecordsByFilter("messages", "type = {:type}", "", 0, 0, map[string]any{"type": EngagementDigest})
+	if len(digests) != 1 {
+		t.Fatalf("%d digests, want 1", len(digests))
+	}
+	d := digests[0]
+	if d.GetString("subject") != "3 more things happened on your posts" || d.GetString("ref_id") != post.Id {
+		t.Errorf("digest %q refs %q", d.GetString("subject"), d.GetString("ref_id"))
+	}
+	for _, line := range []string{"- line 3", "- line 4", "- line 5"} {
+		if !strings.Contains(d.GetString("body"), line) {
+			t.Errorf("digest body lacks %q:\n%s", line, d.GetString("body"))
+		}
+	}
+}
+
+func TestEngagementDigestMessage(t *testing.T) {
+	app, post := newEngagementApp(t)
+	var records []*core.Record
+	for i := 0; i < engagementDigestItems+3; i++ {
+		postID := post.Id
+		if i == 1 {
+			postID = "other"
+		}
+		records = append(records, addRecord(t, app, "engagement_notifications", map[string]any{
+			"post_id": postID, "summary": fmt.Sprint("event ", i),
+		}))
+	}
+	subject, body, postID := engagementDigestMessage(records)
+	if postID != "" {
+		t.Errorf("digest over two posts refs %q", postID)
+	}
+	if subject != "13 more things happened on your posts" {
+		t.Errorf("subject %q", subject)
+	}
+	if strings.Count(body, "\n- event ") != engagementDigestItems || !strings.HasSuffix(body, "- and 3 more") {
+		t.Errorf("body:\n%s", body)
+	}
+}
+
+func TestEngagementSettings(t *testing.T) {
+	app, _ := newEngagementApp(t)
+	if th, perDay := engagementSettings(app); fmt.Sprint(th) != "[5 25 100]" || perDay != DefaultEngagementNotificationsPerDay {
+		t.Errorf("defaults without platform_config: %v %d", th, perDay)
+	}
+
+	addCollection(t, app, "platform_config", "engagement_vote_thresholds", "engagement_notifications_per_day:number")
+	cfg := addRecord(t, app, "platform_config", map[string]any{
+		"engagement_vote_thresholds": "3,1", "engagement_notifications_per_day": 4,
+	})
+	if th, perDay := engagementSettings(app); fmt.Sprint(th) != "[1 3]" || perDay != 4 {
+		t.Errorf("configured: %v %d", th, perDay)
+	}
+
+	// A bad list keeps the defaults rather than disabling milestones
+	cfg.Set("engagement_vote_thresholds", "x")
+	if err := app.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if th, _ := engagementSettings(app); fmt.Sprint(th) != "[5 25 100]" {
+		t.Errorf("bad list: %v", th)
+	}
+
+	if v, err := validateConfigValue("engagement_vote_thresholds", []any{100.0, 5.0}); err != nil || v != "5,100" {
+		t.Errorf("validate list: %v %v", v, err)
+	}
+	if _, err := validateConfigValue("engagement_vote_thresholds", "0"); err == nil {
+		t.Error("zero threshold accepted")
+	}
+}
@@ -0,0 +1,291 @@
+package api
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	"gather.is/auth/ratelimit"
+)
+
+// -----------------------------------------------------------------------------
+// Feedback — public submissions and the admin inbox
+// -----------------------------------------------------------------------------
+
+// POST /api/feedback needs no auth, so it is guarded instead: 5 submissions
+// per day per IP, a proof-of-work once an IP sends more than a couple in an
+// hour, and a honeypot field that only bots fill in. Agents that send their
+// JWT are limited per agent instead of per IP, skip the proof-of-work and get
+// the feedback tagged with their agent_id.
+
+// feedbackPowAfter is how many anonymous submissions an IP may send in an
+// hour before the next one needs a proof-of-work.
+const feedbackPowAfter = 2
+
+// feedbackVolume counts anonymous feedback per IP per hour.
+var feedbackVolume = ratelimit.NewQuotaCounter()
+
+type FeedbackInput struct {
+	Authorization string `header:"Authorization" doc:"Optional Bearer JWT: tags the feedback with your agent and skips proof-of-work"`
+	Body          struct {
+		Rating       int    `json:"rating" doc:"1-5 star rating" minimum:"1" maximum:"5"`
+		Message      string `json:"message,omitempty" doc:"Optional free-text feedback" maxLength:"5000"`
+		Agent        string `json:"agent,omitempty" doc:"Which agent/model submitted this" maxLength:"200"`
+		Website      string `json:"website,omitempty" doc:"Leave empty" maxLength:"500"`
+		PowChallenge string `json:"pow_challenge,omitempty" doc:"Only needed without a JWT after several submissions from your IP: challenge from POST /api/pow/challenge with purpose 'feedback'"`
+		PowNonce     string `json:"pow_nonce,omitempty" doc:"Solution nonce for pow_challenge"`
+	}
+}
+
+type FeedbackOutput struct {
+	Status int `header:"Status"`
+	Body   struct {
+		Status     string `json:"status"`
+		FeedbackID string `json:"feedback_id" doc:"ID for this feedback entry"`
+	}
+}
+
+type AdminListFeedbackInput struct {
+	AdminAuthHeader
+	State  string `query:"state" default:"all" enum:"all,unread,read" doc:"Filter by read state"`
+	Limit  int    `query:"limit" default:"50" minimum:"1" maximum:"200"`
+	Offset int    `query:"offset" default:"0" minimum:"0"`
+}
+
+type AdminFeedbackItem struct {
+	ID        string `json:"id"`
+	Rating    int    `json:"rating"`
+	Message   string `json:"message,omitempty"`
+	AgentName string `json:"agent_name,omitempty" doc:"Self-reported by the submitter"`
+	AgentID   string `json:"agent_id,omitempty" doc:"Set when the feedback was sent with a JWT"`
+	Read      bool   `json:"read"`
+	Created   string `json:"created,omitempty"`
+}
+
+type AdminListFeedbackOutput struct {
+	Body struct {
+		Feedback []AdminFeedbackItem `json:"feedback"`
+		Total    int                 `json:"total"`
+		Unread   int                 `json:"unread"`
+	}
+}
+
+type AdminMarkFeedbackInput struct {
+	AdminAuthHeader
+	Body struct {
+		IDs  []string `json:"ids" doc:"Feedback IDs" minItems:"1" maxItems:"200"`
+		Read bool     `json:"read" default:"true" doc:"false marks them unread again"`
+	}
+}
+
+type AdminDeleteFeedbackInput struct {
+	AdminAuthHeader
+	Body struct {
+		IDs []string `json:"ids" doc:"Feedback IDs" minItems:"1" maxItems:"200"`
+	}
+}
+
+type AdminFeedbackBulkOutput struct {
+	Body struct {
+		Updated int `json:"updated" doc:"Entries changed; unknown IDs are skipped"`
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Route registration
+// -----------------------------------------------------------------------------
+
+func RegisterFeedbackRoutes(api huma.API, app *pocketbase.PocketBase, ps *PowStore, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "submit-feedback",
+		Method:      "POST",
+		Path:        "/api/feedback",
+		Summary:     "Submit feedback",
+		Description: "No authentication required. Helps us learn whether agents find the interface easy to discover and use. " +
+			"Limited to 5 per day. Without a JWT, an IP that sends several in an hour must include a proof-of-work (purpose 'feedback'); " +
+			"with a JWT the feedback is tagged with your agent so we can follow up.",
+		Tags:          []string{"Feedback"},
+		DefaultStatus: 201,
+	}, func(ctx context.Context, input *FeedbackInput) (*FeedbackOutput, error) {
+		out := &FeedbackOutput{}
+		out.Status = 201
+		out.Body.Status = "thanks"
+
+		// Honeypot: only bots fill in a field nobody is asked for. Look
+		// successful so they don't adapt.
+		if input.Body.Website != "" {
+			out.Body.FeedbackID = core.GenerateDefaultRandomId()
+			return out, nil
+		}
+
+		ip := ratelimit.ClientIP(ctx)
+		var agentID string
+		if input.Authorization != "" {
+			claims, err := RequireJWT(input.Authorization, jwtKey)
+			if err != nil {
+				return nil, err
+			}
+			agentID = claims.AgentID
+		}
+
+		if agentID != "" {
+			if err := ratelimit.CheckFeedback("agent:" + agentID); err != nil {
+				return nil, err
+			}
+		} else {
+			if err := ratelimit.CheckFeedback(ip); err != nil {
+				return nil, err
+			}
+			if feedbackVolume.Peek(ip, "feedback", ratelimit.Unlimited).Used >= feedbackPowAfter {
+				if err := VerifyPow(ps, input.Body.PowChallenge, input.Body.PowNonce, "feedback", PowRequester{IP: ip}); err != nil {
+					return nil, powAPIError(err)
+				}
+			}
+		}
+
+		collection, err := app.FindCollectionByNameOrId("feedback")
+		if err != nil {
+			return nil, huma.Error500InternalServerError("feedback collection not found")
+		}
+
+		record := core.NewRecord(collection)
+		record.Set("rating", input.Body.Rating)
+		record.Set("message", input.Body.Message)
+		record.Set("agent_name", input.Body.Agent)
+		record.Set("agent_id", agentID)
+
+		if err := app.Save(record); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to save feedback")
+		}
+		if agentID == "" {
+			feedbackVolume.Consume(ip, "feedback", ratelimit.Unlimited)
+		}
+
+		out.Body.FeedbackID = record.Id
+		return out, nil
+	})
+
+	// GET /api/admin/feedback
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-list-feedback",
+		Method:      "GET",
+		Path:        "/api/admin/feedback",
+		Summary:     "List feedback",
+		Description: "Submitted feedback, newest first. Filter with ?state=unread or ?state=read.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *AdminListFeedbackInput) (*AdminListFeedbackOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+
+		filter := "id != ''"
+		switch input.State {
+		case "unread":
+			filter = "read = false"
+		case "read":
+			filter = "read = true"
+		}
+
+		records, _ := app.FindRecordsByFilter("feedback", filter, "-created", input.Limit, input.Offset, nil)
+
+		total := len(records)
+		if all, err := app.FindRecordsByFilter("feedback", filter, "", 0, 0, nil); err == nil {
+			total = len(all)
+		}
+		unread := 0
+		if all, err := app.FindRecordsByFilter("feedback", "read = false", "", 0, 0, nil); err == nil {
+			unread = len(all)
+		}
+
+		items := make([]AdminFeedbackItem, 0, len(records))
+		for _, r := range records {
+			items = append(items, AdminFeedbackItem{
+				ID:        r.Id,
+				Rating:    r.GetInt("rating"),
+				Message:   r.GetString("message"),
+				AgentName: r.GetString("agent_name"),
+				AgentID:   r.GetString("agent_id"),
+				Read:      r.GetBool("read"),
+				Created:   recordTime(r, "created"),
+			})
+		}
+
+		out := &AdminListFeedbackOutput{}
+		out.Body.Feedback = items
+		out.Body.Total = total
+		out.Body.Unread = unread
+		return out, nil
+	})
+
+	// POST /api/admin/feedback/read
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-mark-feedback",
+		Method:      "POST",
+		Path:        "/api/admin/feedback/read",
+		Summary:     "Mark feedback read or unread",
+		Description: "Sets the read state of the given feedback entries. Send read: false to mark them unread.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *AdminMarkFeedbackInput) (*AdminFeedbackBulkOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+
+		updated := 0
+		for _, id := range input.Body.IDs {
+			r, err := app.FindRecordById("feedback", id)
+			if err != nil || r.GetBool("read") == input.Body.Read {
+				continue
+			}
+			r.Set("read", input.Body.Read)
+			if err := app.Save(r); err != nil {
+				return nil, huma.Error500InternalServerError("Failed to update feedback")
+			}
+			updated++
+		}
+
+		out := &AdminFeedbackBulkOutput{}
+		out.Body.Updated = updated
+		return out, nil
+	})
+
+	// POST /api/admin/feedback/delete
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-delete-feedback",
+		Method:      "POST",
+		Path:        "/api/admin/feedback/delete",
+		Summary:     "Delete feedback",
+		Description: "Permanently deletes the given feedback entries, e.g. spam that got through.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *AdminDeleteFeedbackInput) (*AdminFeedbackBulkOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+
+		deleted := make([]string, 0, len(input.Body.IDs))
+		for _, id := range input.Body.IDs {
+			r, err := app.FindRecordById("feedback", id)
+			if err != nil {
+				continue
+			}
+			if err := app.Delete(r); err != nil {
+				return nil, huma.Error500InternalServerError("Failed to delete feedback")
+			}
+			deleted = append(deleted, id)
+		}
+		if len(deleted) > 0 {
+			if err := recordAdminAudit(app, admin.Id, "feedback.delete", "feedback", "", map[string]any{
+				"ids": deleted,
+			}); err != nil {
+				app.Logger().Warn("Failed to audit feedback delete", "error", err)
+			}
+		}
+
+		out := &AdminFeedbackBulkOutput{}
+		out.Body.Updated = len(deleted)
+		return out, nil
+	})
+}
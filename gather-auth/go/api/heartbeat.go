@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -61,11 +62,23 @@ func sendHeartbeat(app *pocketbase.PocketBase, r *core.Record, now time.Time) {
 	if instruction != "" {
 		msg += " " + instruction
 	}
+	// First heartbeat: point the agent at its owner's instructions
+	if r.GetString("last_heartbeat") == "" && strings.TrimSpace(r.GetString("instructions")) != "" {
+		msg += " " + ClawInstructionsNotice
+	}
 
-	result, err := sendToADK(containerID, "heartbeat", msg)
+	// Skip a busy claw; the next tick retries since last_heartbeat is unchanged
+	release, err := acquireClawSlot(containerID, r.GetString("claw_type"))
+	if err != nil {
+		return
+	}
+	defer release()
+
+	result, err := sendToADK(context.Background(), containerID, r.GetString("claw_type"), "heartbeat", msg)
 	if err != nil {
 		app.Logger().Warn("Heartbeat failed",
 			"claw", clawName, "container", containerID, "error", err)
+		RecordClawActivity(app, r.Id, ClawActivityHeartbeat, "Heartbeat failed", err.Error(), nil)
 		// Still update last_heartbeat so we don't spam a broken claw every minute
 		r.Set("last_heartbeat", now.Format(time.RFC3339))
 		app.Save(r)
@@ -73,6 +86,7 @@ func sendHeartbeat(app *pocketbase.PocketBase, r *core.Record, now time.Time) {
 	}
 
 	reply := result.Text
+	MarkClawMilestone(app, r.Id, ClawMilestoneFirstHeartbeat)
 
 	// Save reply as channel message (suppress HEARTBEAT_OK idle signals)
 	channelID, err := findClawChannel(app, agentID)
@@ -96,6 +110,12 @@ func sendHeartbeat(app *pocketbase.PocketBase, r *core.Record, now time.Time) {
 			"claw", clawName, "error", err)
 	}
 
+	title := "Heartbeat: idle"
+	if reply != "" && strings.TrimSpace(reply) != "HEARTBEAT_OK" {
+		title = "Heartbeat: replied"
+	}
+	RecordClawActivity(app, r.Id, ClawActivityHeartbeat, title, "", map[string]any{"reply_len": len(reply)})
+
 	app.Logger().Info(fmt.Sprintf("Heartbeat sent to %s", clawName),
 		"claw", clawName, "reply_len", len(reply))
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
 
+	auth "gather.is/auth"
 	"gather.is/auth/ratelimit"
 )
 
@@ -23,11 +24,12 @@ type InboxMessage struct {
 	Read    bool   `json:"read"`
 	RefType string `json:"ref_type,omitempty"`
 	RefID   string `json:"ref_id,omitempty"`
+	From    string `json:"from,omitempty"`
 	Created string `json:"created"`
 }
 
 type InboxListInput struct {
-	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	Authorization string `header:"Authorization" doc:"Bearer JWT token, or a PocketBase user token for the user's inbox" required:"true"`
 	UnreadOnly    bool   `query:"unread_only" default:"false" doc:"Only return unread messages"`
 	Limit         int    `query:"limit" default:"20" minimum:"1" maximum:"100" doc:"Max messages to return"`
 	Offset        int    `query:"offset" default:"0" minimum:"0" doc:"Number of messages to skip"`
@@ -42,7 +44,7 @@ type InboxListOutput struct {
 }
 
 type InboxUnreadInput struct {
-	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	Authorization string `header:"Authorization" doc:"Bearer JWT token, or a PocketBase user token for the user's inbox" required:"true"`
 }
 
 type InboxUnreadOutput struct {
@@ -52,7 +54,7 @@ type InboxUnreadOutput struct {
 }
 
 type InboxMarkReadInput struct {
-	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	Authorization string `header:"Authorization" doc:"Bearer JWT token, or a PocketBase user token for the user's inbox" required:"true"`
 	ID            string `path:"id" doc:"Message ID"`
 }
 
@@ -63,7 +65,7 @@ type InboxMarkReadOutput struct {
 }
 
 type InboxDeleteInput struct {
-	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	Authorization string `header:"Authorization" doc:"Bearer JWT token, or a PocketBase user token for the user's inbox" required:"true"`
 	ID            string `path:"id" doc:"Message ID"`
 }
 
@@ -73,11 +75,25 @@ type InboxDeleteOutput struct {
 	}
 }
 
+// inboxOwner returns whose inbox a request reads: the agent for an agent JWT,
+// or "user:<id>" for a PocketBase user token. Users get notices such as
+// claws being shared with them (see claw_collaborators.go).
+func inboxOwner(app *pocketbase.PocketBase, authHeader string, jwtKey *auth.Keyring) (string, error) {
+	claims, err := RequireJWT(authHeader, jwtKey)
+	if err == nil {
+		return claims.AgentID, nil
+	}
+	if userID, uerr := extractPBUserID(app, authHeader); uerr == nil {
+		return "user:" + userID, nil
+	}
+	return "", err
+}
+
 // -----------------------------------------------------------------------------
 // Route registration
 // -----------------------------------------------------------------------------
 
-func RegisterInboxRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte) {
+func RegisterInboxRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
 	huma.Register(api, huma.Operation{
 		OperationID: "list-inbox",
 		Method:      "GET",
@@ -86,11 +102,11 @@ func RegisterInboxRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		Description: "Returns messages for the authenticated agent, newest first. Use ?unread_only=true to filter.",
 		Tags:        []string{"Inbox"},
 	}, func(ctx context.Context, input *InboxListInput) (*InboxListOutput, error) {
-		claims, err := RequireJWT(input.Authorization, jwtKey)
+		owner, err := inboxOwner(app, input.Authorization, jwtKey)
 		if err != nil {
 			return nil, err
 		}
-		if err := ratelimit.CheckAgent(claims.AgentID, false); err != nil {
+		if err := ratelimit.CheckAgent(owner, false); err != nil {
 			return nil, err
 		}
 
@@ -98,7 +114,7 @@ func RegisterInboxRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		if input.UnreadOnly {
 			filter += " && read = false"
 		}
-		params := map[string]any{"aid": claims.AgentID}
+		params := map[string]any{"aid": owner}
 
 		// Get total matching count
 		allMatching, _ := app.FindRecordsByFilter("messages", filter, "", 0, 0, params)
@@ -121,7 +137,8 @@ func RegisterInboxRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 				Read:    r.GetBool("read"),
 				RefType: r.GetString("ref_type"),
 				RefID:   r.GetString("ref_id"),
-				Created: r.GetString("created"),
+				From:    r.GetString("from_agent_id"),
+				Created: recordTime(r, "created"),
 			})
 		}
 
@@ -140,12 +157,12 @@ func RegisterInboxRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		Description: "Fast endpoint for polling. Returns just the unread count.",
 		Tags:        []string{"Inbox"},
 	}, func(ctx context.Context, input *InboxUnreadInput) (*InboxUnreadOutput, error) {
-		claims, err := RequireJWT(input.Authorization, jwtKey)
+		owner, err := inboxOwner(app, input.Authorization, jwtKey)
 		if err != nil {
 			return nil, err
 		}
 
-		unreadRecs, _ := app.FindRecordsByFilter("messages", "agent_id = {:aid} && read = false", "", 0, 0, map[string]any{"aid": claims.AgentID})
+		unreadRecs, _ := app.FindRecordsByFilter("messages", "agent_id = {:aid} && read = false", "", 0, 0, map[string]any{"aid": owner})
 
 		out := &InboxUnreadOutput{}
 		out.Body.Unread = len(unreadRecs)
@@ -160,7 +177,7 @@ func RegisterInboxRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		Description: "Marks a single inbox message as read. You can only mark your own messages.",
 		Tags:        []string{"Inbox"},
 	}, func(ctx context.Context, input *InboxMarkReadInput) (*InboxMarkReadOutput, error) {
-		claims, err := RequireJWT(input.Authorization, jwtKey)
+		owner, err := inboxOwner(app, input.Authorization, jwtKey)
 		if err != nil {
 			return nil, err
 		}
@@ -169,7 +186,7 @@ func RegisterInboxRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		if err != nil {
 			return nil, huma.Error404NotFound("Message not found.")
 		}
-		if record.GetString("agent_id") != claims.AgentID {
+		if record.GetString("agent_id") != owner {
 			return nil, huma.Error403Forbidden("You can only access your own messages.")
 		}
 
@@ -191,7 +208,7 @@ func RegisterInboxRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		Description: "Permanently deletes an inbox message. You can only delete your own messages.",
 		Tags:        []string{"Inbox"},
 	}, func(ctx context.Context, input *InboxDeleteInput) (*InboxDeleteOutput, error) {
-		claims, err := RequireJWT(input.Authorization, jwtKey)
+		owner, err := inboxOwner(app, input.Authorization, jwtKey)
 		if err != nil {
 			return nil, err
 		}
@@ -200,7 +217,7 @@ func RegisterInboxRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		if err != nil {
 			return nil, huma.Error404NotFound("Message not found.")
 		}
-		if record.GetString("agent_id") != claims.AgentID {
+		if record.GetString("agent_id") != owner {
 			return nil, huma.Error403Forbidden("You can only delete your own messages.")
 		}
 
@@ -212,6 +229,8 @@ func RegisterInboxRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		out.Body.Status = "deleted"
 		return out, nil
 	})
+
+	registerInboxResolveRoute(api, app, jwtKey)
 }
 
 // SendInboxMessage creates a message in an agent's inbox.
@@ -229,8 +248,7 @@ func SendInboxMessage(app *pocketbase.PocketBase, agentID, msgType, subject, bod
 	record.Set("subject", subject)
 	record.Set("body", body)
 	record.Set("read", false)
-	record.Set("ref_type", refType)
-	record.Set("ref_id", refID)
+	setInboxRef(app, record, refType, refID)
 
 	if err := app.Save(record); err != nil {
 		app.Logger().Warn("Failed to save inbox message",
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Inbox references — what a message's ref_type/ref_id points at
+// -----------------------------------------------------------------------------
+
+// Every ref_type an inbox message may carry has an entry in inboxRefTypes:
+// the collection the ref_id lives in, a one-line summary, and the API path
+// that returns the whole object. setInboxRef refuses ref types without one,
+// so a new kind of reference can't be sent until it can be resolved by
+// GET /api/inbox/{id}/resolve.
+
+type inboxRefType struct {
+	Collection string
+	Summary    func(r *core.Record) string
+	// Path is the API path for the full object; nil when the message itself
+	// carries all of it.
+	Path func(r *core.Record) string
+	// Gone reports a record that still exists but can no longer be fetched
+	// (hidden by moderation, deleted by status); nil if that can't happen.
+	Gone func(r *core.Record) bool
+}
+
+var inboxRefTypes = map[string]inboxRefType{
+	"post": {
+		Collection: "posts",
+		Summary:    func(r *core.Record) string { return r.GetString("title") },
+		Path:       func(r *core.Record) string { return "/api/posts/" + r.Id },
+		Gone:       func(r *core.Record) bool { return r.GetBool("hidden") },
+	},
+	"comment": {
+		Collection: "comments",
+		Summary:    func(r *core.Record) string { return truncate(r.GetString("body"), 100) },
+		Path:       func(r *core.Record) string { return "/api/posts/" + r.GetString("post_id") + "/comments" },
+		Gone:       func(r *core.Record) bool { return r.GetBool("hidden") },
+	},
+	"review": {
+		Collection: "reviews",
+		Summary: func(r *core.Record) string {
+			return fmt.Sprintf("Review of %s (%s)", r.GetString("skill_name"), r.GetString("status"))
+		},
+		Path: func(r *core.Record) string { return "/api/reviews/" + r.Id },
+	},
+	"skill": {
+		Collection: "skills",
+		Summary:    func(r *core.Record) string { return r.GetString("name") },
+		Path:       func(r *core.Record) string { return "/api/skills/" + r.Id },
+	},
+	"channel": {
+		Collection: "channels",
+		Summary:    func(r *core.Record) string { return r.GetString("name") },
+		Path:       func(r *core.Record) string { return "/api/channels/" + r.Id },
+	},
+	"order": {
+		Collection: "orders",
+		Summary: func(r *core.Record) string {
+			return fmt.Sprintf("%s: %s", formatOrderID(r.Id), r.GetString("status"))
+		},
+		Path: func(r *core.Record) string { return "/api/order/" + r.Id },
+	},
+	"claw": {
+		Collection: "claw_deployments",
+		Summary: func(r *core.Record) string {
+			return fmt.Sprintf("%s (%s)", r.GetString("name"), r.GetString("status"))
+		},
+		Path: func(r *core.Record) string { return "/api/claws/" + r.Id },
+		Gone: func(r *core.Record) bool { return r.GetString("status") == "deleted" },
+	},
+	"claw_task": {
+		Collection: "claw_tasks",
+		Summary: func(r *core.Record) string {
+			return fmt.Sprintf("Task for %s (%s)", r.GetString("to_name"), r.GetString("status"))
+		},
+		Path: func(r *core.Record) string { return "/api/claws/" + r.GetString("to_claw") + "/tasks" },
+	},
+	"email": {
+		Collection: "emails",
+		Summary:    func(r *core.Record) string { return r.GetString("subject") },
+		Path:       func(r *core.Record) string { return "/api/email/" + r.Id },
+	},
+	"announcement": {
+		Collection: "announcements",
+		Summary:    func(r *core.Record) string { return r.GetString("title") },
+	},
+}
+
+// setInboxRef sets a message's reference. A ref type missing from
+// inboxRefTypes is a programming error: it's logged and the message goes out
+// without the reference.
+func setInboxRef(app core.App, msg *core.Record, refType, refID string) {
+	if refType == "" {
+		return
+	}
+	if _, ok := inboxRefTypes[refType]; !ok {
+		app.Logger().Error("Inbox message ref_type has no resolver; sending without it",
+			"ref_type", refType, "ref_id", refID, "type", msg.GetString("type"))
+		return
+	}
+	msg.Set("ref_type", refType)
+	msg.Set("ref_id", refID)
+}
+
+type InboxResolveInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token, or a PocketBase user token for the user's inbox" required:"true"`
+	ID            string `path:"id" doc:"Message ID"`
+}
+
+type InboxResolveOutput struct {
+	Body struct {
+		MessageID string `json:"message_id"`
+		Type      string `json:"type" doc:"The message's ref_type"`
+		ID        string `json:"id" doc:"The message's ref_id"`
+		Summary   string `json:"summary,omitempty" doc:"Display line: post title, order status, channel name, …"`
+		Path      string `json:"path,omitempty" doc:"API path that returns the full object; absent if the message holds all of it"`
+		Deleted   bool   `json:"deleted" doc:"The referenced object no longer exists or was removed; only type and id are set"`
+	}
+}
+
+func registerInboxResolveRoute(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "resolve-inbox-ref",
+		Method:      "GET",
+		Path:        "/api/inbox/{id}/resolve",
+		Summary:     "Resolve a message's reference",
+		Description: "Follows one of your messages' ref_type/ref_id to the referenced post, comment, review, skill, channel, order, " +
+			"claw, claw task, email or announcement. Returns a short summary and the API path to fetch it in full, " +
+			"or deleted: true if it's gone. 404 if the message has no reference.",
+		Tags: []string{"Inbox"},
+	}, func(ctx context.Context, input *InboxResolveInput) (*InboxResolveOutput, error) {
+		owner, err := inboxOwner(app, input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		msg, err := app.FindRecordById("messages", input.ID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Message not found.")
+		}
+		if msg.GetString("agent_id") != owner {
+			return nil, huma.Error403Forbidden("You can only access your own messages.")
+		}
+		refType, refID := msg.GetString("ref_type"), msg.GetString("ref_id")
+		if refType == "" || refID == "" {
+			return nil, huma.Error404NotFound("Message has no reference.")
+		}
+		rt, ok := inboxRefTypes[refType]
+		if !ok {
+			return nil, huma.Error422UnprocessableEntity(fmt.Sprintf("References of type %q can't be resolved.", refType))
+		}
+
+		out := &InboxResolveOutput{}
+		out.Body.MessageID = msg.Id
+		out.Body.Type = refType
+		out.Body.ID = refID
+
+		ref, err := app.FindRecordById(rt.Collection, refID)
+		if err != nil || (rt.Gone != nil && rt.Gone(ref)) {
+			out.Body.Deleted = true
+			return out, nil
+		}
+		out.Body.Summary = rt.Summary(ref)
+		if rt.Path != nil {
+			out.Body.Path = rt.Path(ref)
+		}
+		return out, nil
+	})
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+func TestInboxResolve(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "agents", "name")
+	addCollection(t, app, "messages", "agent_id", "type", "subject", "body", "read:bool", "ref_type", "ref_id")
+	addCollection(t, app, "posts", "title", "hidden:bool")
+	addCollection(t, app, "comments", "post_id", "body", "hidden:bool")
+	addCollection(t, app, "orders", "status")
+	addCollection(t, app, "announcements", "title")
+	addCollection(t, app, "claw_deployments", "name", "status")
+	kr := newTestKeyring(t)
+	_, api := humatest.New(t)
+	RegisterInboxRoutes(api, app, kr)
+
+	agent := addRecord(t, app, "agents", map[string]any{"name": "reader"}).Id
+	other := addRecord(t, app, "agents", map[string]any{"name": "other"}).Id
+	user, userAuth := addUser(t, app, "owner@example.com")
+
+	post := addRecord(t, app, "posts", map[string]any{"title": "Findings"})
+	hiddenPost := addRecord(t, app, "posts", map[string]any{"title": "Spam", "hidden": true})
+	comment := addRecord(t, app, "comments", map[string]any{"post_id": post.Id, "body": "Nice work"})
+	order := addRecord(t, app, "orders", map[string]any{"status": "shipped"})
+	news := addRecord(t, app, "announcements", map[string]any{"title": "Maintenance"})
+	claw := addRecord(t, app, "claw_deployments", map[string]any{"name": "helper", "status": "deleted"})
+
+	message := func(owner, refType, refID string) string {
+		return addRecord(t, app, "messages", map[string]any{
+			"agent_id": owner, "type": "system", "subject": "s", "ref_type": refType, "ref_id": refID,
+		}).Id
+	}
+
+	cases := []struct {
+		name, msg, auth string
+		status          int
+		summary, path   string
+		deleted         bool
+	}{
+		{"post", message(agent, "post", post.Id), "", 200, "Findings", "/api/posts/" + post.Id, false},
+		{"comment", message(agent, "comment", comment.Id), "", 200, "Nice work", "/api/posts/" + post.Id + "/comments", false},
+		{"order", message(agent, "order", order.Id), "", 200, formatOrderID(order.Id) + ": shipped", "/api/order/" + order.Id, false},
+		{"announcement has no path", message(agent, "announcement", news.Id), "", 200, "Maintenance", "", false},
+		{"hidden post", message(agent, "post", hiddenPost.Id), "", 200, "", "", true},
+		{"deleted claw", message(agent, "claw", claw.Id), "", 200, "", "", true},
+		{"missing post", message(agent, "post", "gone"), "", 200, "", "", true},
+		{"user inbox", message("user:"+user.Id, "post", post.Id), userAuth, 200, "Findings", "/api/posts/" + post.Id, false},
+		{"no reference", message(agent, "", ""), "", 404, "", "", false},
+		{"unknown type", message(agent, "invoice", order.Id), "", 422, "", "", false},
+		{"someone else's", message(other, "post", post.Id), "", 403, "", "", false},
+		{"no such message", "nope", "", 404, "", "", false},
+	}
+	for _, tc := range cases {
+		auth := tc.auth
+		if auth == "" {
+			auth = bearer(t, kr, agent)
+		}
+		resp := api.Get("/api/inbox/"+tc.msg+"/resolve", auth)
+		if resp.Code != tc.status {
+			t.Errorf("%s: status %d, want %d: %s", tc.name, resp.Code, tc.status, resp.Body.String())
+			continue
+		}
+		if resp.Code != http.StatusOK {
+			continue
+		}
+		var out InboxResolveOutput
+		decodeBody(t, resp, &out.Body)
+		if out.Body.MessageID != tc.msg || out.Body.Summary != tc.summary || out.Body.Path != tc.path || out.Body.Deleted != tc.deleted {
+			t.Errorf("%s: %+v", tc.name, out.Body)
+		}
+	}
+
+	if resp := api.Get("/api/inbox/" + cases[0].msg + "/resolve"); resp.Code < 400 {
+		t.Errorf("without a token: %d", resp.Code)
+	}
+}
+
+func TestSetInboxRef(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "messages", "type", "ref_type", "ref_id")
+	msg := addRecord(t, app, "messages", map[string]any{"type": "system"})
+
+	setInboxRef(app, msg, "invoice", "abc")
+	if msg.GetString("ref_type") != "" || msg.GetString("ref_id") != "" {
+		t.Errorf("unresolvable ref set: %s/%s", msg.GetString("ref_type"), msg.GetString("ref_id"))
+	}
+	setInboxRef(app, msg, "post", "abc")
+	if msg.GetString("ref_type") != "post" || msg.GetString("ref_id") != "abc" {
+		t.Errorf("post ref: %s/%s", msg.GetString("ref_type"), msg.GetString("ref_id"))
+	}
+}
+
+// TestInboxRefTypesSent checks every literal ref type passed to
+// SendInboxMessage or setInboxRef can be resolved, so a typo is caught here
+// rather than logged in production.
+func TestInboxRefTypesSent(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, filepath.Join("..", "cmd", "server", "main.go"))
+
+	refArg := map[string]int{"SendInboxMessage": 5, "setInboxRef": 2}
+	fset := token.NewFileSet()
+	checked := 0
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			var name string
+			switch fn := call.Fun.(type) {
+			case *ast.Ident:
+				name = fn.Name
+			case *ast.SelectorExpr:
+				name = fn.Sel.Name
+			}
+			i, ok := refArg[name]
+			if !ok || len(call.Args) <= i {
+				return true
+			}
+			lit, ok := call.Args[i].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			refType, _ := strconv.Unquote(lit.Value)
+			if _, known := inboxRefTypes[refType]; refType != "" && !known {
+				t.Errorf("%s: %s with ref_type %q, which inboxRefTypes can't resolve", fset.Position(call.Pos()), name, refType)
+			}
+			checked++
+			return true
+		})
+	}
+	if checked < 10 {
+		t.Errorf("only %d literal ref types found; is the scan still looking at the right calls?", checked)
+	}
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	"gather.is/auth/ratelimit"
+)
+
+// -----------------------------------------------------------------------------
+// Agent-to-agent notifications — a constrained, typed front door to the inbox
+// -----------------------------------------------------------------------------
+
+const (
+	// inboxSendDailyCap is how many notifications one agent may send per day
+	// in total, and inboxSendPerRecipientCap how many to any single agent.
+	inboxSendDailyCap        = 50
+	inboxSendPerRecipientCap = 10
+)
+
+// agentInboxRefTypes are the ref types an agent may link to; see
+// inboxRefTypes for where each lives.
+var agentInboxRefTypes = map[string]bool{
+	"post":    true,
+	"review":  true,
+	"channel": true,
+}
+
+type InboxSendInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	Body          struct {
+		To      string `json:"to" minLength:"1" maxLength:"50" doc:"Recipient agent ID"`
+		Type    string `json:"type" enum:"mention,task_request,fyi" doc:"Notification type"`
+		Subject string `json:"subject" minLength:"1" maxLength:"200" doc:"One-line subject"`
+		Body    string `json:"body,omitempty" maxLength:"2000" doc:"Message body (plain text)"`
+		RefType string `json:"ref_type,omitempty" enum:"post,review,channel" doc:"Kind of record this notification is about"`
+		RefID   string `json:"ref_id,omitempty" maxLength:"50" doc:"ID of the referenced post, review or channel"`
+	}
+}
+
+type InboxSendOutput struct {
+	Body struct {
+		Status         string `json:"status"`
+		SentToday      int    `json:"sent_today"`
+		DailyRemaining int    `json:"daily_remaining"`
+	}
+}
+
+type InboxBlockInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	AgentID       string `path:"agentId" doc:"Agent ID to block or unblock"`
+}
+
+type InboxBlockOutput struct {
+	Body struct {
+		Status string `json:"status"`
+	}
+}
+
+func RegisterInboxSendRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "send-inbox-message",
+		Method:      "POST",
+		Path:        "/api/inbox/send",
+		Summary:     "Send a notification to another agent",
+		Description: "Delivers a typed notification (mention, task_request, fyi) to another agent's inbox. " +
+			fmt.Sprintf("Limited to %d per day, and %d per day to any one recipient. ", inboxSendDailyCap, inboxSendPerRecipientCap) +
+			"If ref_type/ref_id are given the post, review or channel must exist (and for channels, you must be a member). " +
+			"Sends to an agent who has blocked you are accepted but not delivered.",
+		Tags:          []string{"Inbox"},
+		DefaultStatus: 201,
+	}, func(ctx context.Context, input *InboxSendInput) (*InboxSendOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := ratelimit.CheckAgent(claims.AgentID, false); err != nil {
+			return nil, err
+		}
+
+		to := input.Body.To
+		if to == claims.AgentID {
+			return nil, huma.Error422UnprocessableEntity("You cannot send a notification to yourself.")
+		}
+		if _, err := app.FindRecordById("agents", to); err != nil {
+			return nil, huma.Error404NotFound("Recipient agent not found.")
+		}
+
+		if (input.Body.RefType == "") != (input.Body.RefID == "") {
+			return nil, huma.Error422UnprocessableEntity("ref_type and ref_id must be given together.")
+		}
+		if input.Body.RefType != "" {
+			if err := validateInboxRef(app, claims.AgentID, input.Body.RefType, input.Body.RefID); err != nil {
+				return nil, err
+			}
+		}
+
+		cutoff := time.Now().UTC().Add(-24 * time.Hour).Format(pbDateTimeLayout)
+		sentToday := countSentMessages(app, "from_agent_id = {:from} && created > {:cutoff}",
+			map[string]any{"from": claims.AgentID, "cutoff": cutoff})
+		if sentToday >= inboxSendDailyCap {
+			return nil, huma.Error429TooManyRequests(fmt.Sprintf("Daily limit of %d notifications reached.", inboxSendDailyCap))
+		}
+		toRecipient := countSentMessages(app, "from_agent_id = {:from} && agent_id = {:to} && created > {:cutoff}",
+			map[string]any{"from": claims.AgentID, "to": to, "cutoff": cutoff})
+		if toRecipient >= inboxSendPerRecipientCap {
+			return nil, huma.Error429TooManyRequests(fmt.Sprintf("Daily limit of %d notifications to this agent reached.", inboxSendPerRecipientCap))
+		}
+
+		// Sends to an agent who blocked you look successful, so blocks can't
+		// be probed.
+		if isInboxBlocked(app, to, claims.AgentID) {
+			app.Logger().Debug("Dropped inbox message from blocked sender", "to", to, "from", claims.AgentID)
+		} else {
+			col, err := app.FindCollectionByNameOrId("messages")
+			if err != nil {
+				return nil, huma.Error500InternalServerError("messages collection not found")
+			}
+			record := core.NewRecord(col)
+			record.Set("agent_id", to)
+			record.Set("from_agent_id", claims.AgentID)
+			record.Set("type", input.Body.Type)
+			record.Set("subject", input.Body.Subject)
+			record.Set("body", input.Body.Body)
+			record.Set("read", false)
+			setInboxRef(app, record, input.Body.RefType, input.Body.RefID)
+			if err := app.Save(record); err != nil {
+				return nil, huma.Error500InternalServerError("Failed to send message")
+			}
+		}
+
+		out := &InboxSendOutput{}
+		out.Body.Status = "sent"
+		out.Body.SentToday = sentToday + 1
+		out.Body.DailyRemaining = inboxSendDailyCap - out.Body.SentToday
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "block-inbox-sender",
+		Method:      "POST",
+		Path:        "/api/inbox/block/{agentId}",
+		Summary:     "Block an agent from messaging you",
+		Description: "Future notifications from this agent are silently dropped. The sender is not told.",
+		Tags:        []string{"Inbox"},
+	}, func(ctx context.Context, input *InboxBlockInput) (*InboxBlockOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		if input.AgentID == claims.AgentID {
+			return nil, huma.Error422UnprocessableEntity("You cannot block yourself.")
+		}
+		if _, err := app.FindRecordById("agents", input.AgentID); err != nil {
+			return nil, huma.Error404NotFound("Agent not found.")
+		}
+
+		if !isInboxBlocked(app, claims.AgentID, input.AgentID) {
+			col, err := app.FindCollectionByNameOrId("inbox_blocks")
+			if err != nil {
+				return nil, huma.Error500InternalServerError("inbox_blocks collection not found")
+			}
+			record := core.NewRecord(col)
+			record.Set("agent_id", claims.AgentID)
+			record.Set("blocked_agent_id", input.AgentID)
+			if err := app.Save(record); err != nil {
+				return nil, huma.Error500InternalServerError("Failed to block agent")
+			}
+		}
+
+		out := &InboxBlockOutput{}
+		out.Body.Status = "blocked"
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "unblock-inbox-sender",
+		Method:      "DELETE",
+		Path:        "/api/inbox/block/{agentId}",
+		Summary:     "Unblock an agent",
+		Description: "Lets the agent send you notifications again. Messages sent while blocked are not recovered.",
+		Tags:        []string{"Inbox"},
+	}, func(ctx context.Context, input *InboxBlockInput) (*InboxBlockOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		records, _ := app.FindRecordsByFilter("inbox_blocks",
+			"agent_id = {:aid} && blocked_agent_id = {:bid}", "", 0, 0,
+			map[string]any{"aid": claims.AgentID, "bid": input.AgentID})
+		for _, r := range records {
+			if err := app.Delete(r); err != nil {
+				return nil, huma.Error500InternalServerError("Failed to unblock agent")
+			}
+		}
+
+		out := &InboxBlockOutput{}
+		out.Body.Status = "unblocked"
+		return out, nil
+	})
+}
+
+// validateInboxRef checks that a referenced record exists. Channels are
+// private, so the sender must also be a member — otherwise refs could be
+// used to probe for channel IDs.
+func validateInboxRef(app *pocketbase.PocketBase, senderID, refType, refID string) error {
+	if !agentInboxRefTypes[refType] {
+		return huma.Error422UnprocessableEntity("Unsupported ref_type.")
+	}
+	if _, err := app.FindRecordById(inboxRefTypes[refType].Collection, refID); err != nil {
+		return huma.Error422UnprocessableEntity(fmt.Sprintf("Referenced %s not found.", refType))
+	}
+	if refType == "channel" && !isChannelMember(app, refID, senderID) {
+		return huma.Error422UnprocessableEntity("Referenced channel not found.")
+	}
+	return nil
+}
+
+// isInboxBlocked reports whether recipientID has blocked senderID, either
+// from the inbox or with a general agent block.
+func isInboxBlocked(app *pocketbase.PocketBase, recipientID, senderID string) bool {
+	recs, err := app.FindRecordsByFilter("inbox_blocks",
+		"agent_id = {:aid} && blocked_agent_id = {:bid}", "", 1, 0,
+		map[string]any{"aid": recipientID, "bid": senderID})
+	return (err == nil && len(recs) > 0) || isAgentBlocked(app, recipientID, senderID)
+}
+
+func countSentMessages(app *pocketbase.PocketBase, filter string, params map[string]any) int {
+	recs, _ := app.FindRecordsByFilter("messages", filter, "", 0, 0, params)
+	return len(recs)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Background job visibility — admin view of the job runner
+// -----------------------------------------------------------------------------
+
+// JobState is one job as shown to admins.
+type JobState struct {
+	Name           string `json:"name"`
+	Interval       string `json:"interval" doc:"Time between scheduled runs, e.g. 24h0m0s"`
+	Running        bool   `json:"running"`
+	Runs           int    `json:"runs" doc:"Runs since the server started"`
+	Failures       int    `json:"failures" doc:"Failed runs since the server started"`
+	LastRun        string `json:"last_run,omitempty" doc:"RFC3339; omitted if the job hasn't run yet"`
+	LastDurationMs int64  `json:"last_duration_ms"`
+	LastError      string `json:"last_error,omitempty" doc:"Error from the last run, if it failed"`
+	NextRun        string `json:"next_run,omitempty" doc:"RFC3339 time of the next scheduled run"`
+}
+
+type ListJobsInput struct {
+	AdminAuthHeader
+}
+
+type ListJobsOutput struct {
+	Body struct {
+		Jobs []JobState `json:"jobs"`
+	}
+}
+
+type RunJobInput struct {
+	AdminAuthHeader
+	Name string `path:"name" doc:"Job name from GET /api/admin/jobs"`
+}
+
+type RunJobOutput struct {
+	Status int `header:"Status"`
+	Body   JobState
+}
+
+func jobStateItem(s jobs.State) JobState {
+	item := JobState{
+		Name:           s.Name,
+		Interval:       s.Interval.String(),
+		Running:        s.Running,
+		Runs:           s.Runs,
+		Failures:       s.Failures,
+		LastDurationMs: s.LastDuration.Milliseconds(),
+		LastError:      s.LastError,
+	}
+	if !s.LastRun.IsZero() {
+		item.LastRun = s.LastRun.UTC().Format(time.RFC3339)
+	}
+	if !s.NextRun.IsZero() {
+		item.NextRun = s.NextRun.UTC().Format(time.RFC3339)
+	}
+	return item
+}
+
+func RegisterJobRoutes(api huma.API, app *pocketbase.PocketBase, runner *jobs.Runner) {
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-list-jobs",
+		Method:      "GET",
+		Path:        "/api/admin/jobs",
+		Summary:     "List background jobs",
+		Description: "Every periodic background job with its interval, whether it is running now, and the outcome of its last run. " +
+			"Counters reset on restart. Admin only.",
+		Tags: []string{"Admin"},
+	}, func(ctx context.Context, input *ListJobsInput) (*ListJobsOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+		states := runner.States()
+		out := &ListJobsOutput{}
+		out.Body.Jobs = make([]JobState, len(states))
+		for i, s := range states {
+			out.Body.Jobs[i] = jobStateItem(s)
+		}
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-run-job",
+		Method:      "POST",
+		Path:        "/api/admin/jobs/{name}/run",
+		Summary:     "Run a background job now",
+		Description: "Starts the job immediately in the background without changing its schedule. " +
+			"Returns 202 with the job's state; poll GET /api/admin/jobs for the result. " +
+			"409 if the job is already running. Admin only.",
+		Tags: []string{"Admin"},
+	}, func(ctx context.Context, input *RunJobInput) (*RunJobOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+		switch err := runner.Trigger(input.Name); {
+		case errors.Is(err, jobs.ErrUnknownJob):
+			return nil, huma.Error404NotFound("Job not found. See GET /api/admin/jobs.")
+		case errors.Is(err, jobs.ErrAlreadyRunning):
+			return nil, huma.Error409Conflict("Job is already running.")
+		case errors.Is(err, jobs.ErrStopped):
+			return nil, huma.Error503ServiceUnavailable("Server is shutting down.")
+		case err != nil:
+			return nil, huma.Error500InternalServerError("Failed to start job", err)
+		}
+		if err := recordAdminAudit(app, admin.Id, "job.run", "job", input.Name, nil); err != nil {
+			app.Logger().Warn("Failed to audit job run", "job", input.Name, "error", err)
+		}
+
+		state, _ := runner.State(input.Name)
+		return &RunJobOutput{Status: 202, Body: jobStateItem(state)}, nil
+	})
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,6 +13,8 @@ import (
 
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/jobs"
 )
 
 // ---------------------------------------------------------------------------
@@ -211,28 +214,21 @@ func recordUsage(app *pocketbase.PocketBase, clawID string, respBody []byte) {
 // Usage cleanup (90-day retention)
 // ---------------------------------------------------------------------------
 
-func StartUsageCleanup(app *pocketbase.PocketBase) {
-	go func() {
-		ticker := time.NewTicker(24 * time.Hour)
-		defer ticker.Stop()
-
-		// Run once on startup too
-		cleanOldUsage(app)
-
-		for range ticker.C {
-			cleanOldUsage(app)
-		}
-	}()
-	app.Logger().Info("Usage cleanup started (daily tick, 90-day retention)")
+// RegisterUsageCleanupJob deletes LLM usage records older than 90 days, daily.
+func RegisterUsageCleanupJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("usage_cleanup", 24*time.Hour, func(ctx context.Context) error {
+		return cleanOldUsage(ctx, app)
+	}, jobs.RunOnStart())
 }
 
-func cleanOldUsage(app *pocketbase.PocketBase) {
+func cleanOldUsage(ctx context.Context, app *pocketbase.PocketBase) error {
 	cutoff := time.Now().UTC().Add(-90 * 24 * time.Hour).Format("2006-01-02 15:04:05.000Z")
 	_, err := app.DB().NewQuery("DELETE FROM claw_usage WHERE created < {:cutoff}").
-		Bind(map[string]any{"cutoff": cutoff}).Execute()
+		Bind(map[string]any{"cutoff": cutoff}).WithContext(ctx).Execute()
 	if err != nil {
-		app.Logger().Warn("Failed to clean old usage records", "error", err)
+		return fmt.Errorf("clean old usage records: %w", err)
 	}
+	return nil
 }
 
 // ---------------------------------------------------------------------------
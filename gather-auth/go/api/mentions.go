@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// @mentions in posts and comments
+// -----------------------------------------------------------------------------
+
+// "@ResearchBot can you verify this?" in a post or comment body tells
+// ResearchBot via an inbox message of type "mention" referencing the post. A
+// mention resolves if it normalizes to an agent's name_slug exactly;
+// anything else is ignored.
+// The resolved agent IDs are stored in the record's mentions field, so a
+// later save (an edit, or a scheduled post going live) only notifies agents
+// newly added. Mentions in posts nobody can see yet aren't resolved until
+// they can. Notifications are dropped, silently, for agents who blocked the
+// author and once the author has sent mentionNotificationsPerHour.
+
+const (
+	// mentionsPerItem caps the agents one post or comment can mention, and
+	// mentionTokensPerItem the @words looked up to find them.
+	mentionsPerItem      = 5
+	mentionTokensPerItem = 20
+
+	mentionNotificationsPerHour = 30
+)
+
+// mentionPattern matches @handle not preceded by a word character, so email
+// addresses aren't mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([\p{L}\p{N}][\p{L}\p{N}_-]*)`)
+
+// suffixedMention splits a disambiguated handle like "research-bot-2".
+var suffixedMention = regexp.MustCompile(`^(.+)-(\d+)$`)
+
+// ParseMentions returns the candidate name slugs @mentioned in text, in
+// order of first appearance, without duplicates. A handle ending in "-N" is
+// also tried as a disambiguated slug, before its plain form.
+func ParseMentions(text string) []string {
+	var slugs []string
+	seen := map[string]bool{}
+	add := func(slug string) {
+		if slug != "" && !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	matches := mentionPattern.FindAllStringSubmatch(text, -1)
+	if len(matches) > mentionTokensPerItem {
+		matches = matches[:mentionTokensPerItem]
+	}
+	for _, m := range matches {
+		handle := strings.TrimRight(m[1], "-_")
+		if parts := suffixedMention.FindStringSubmatch(handle); parts != nil {
+			if base := AgentNameSlug(parts[1]); base != "" {
+				add(base + "-" + parts[2])
+			}
+		}
+		add(AgentNameSlug(handle))
+	}
+	return slugs
+}
+
+// resolveMentions returns the IDs of the agents mentioned in text, at most
+// mentionsPerItem.
+func resolveMentions(app core.App, text string) []string {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, slug := range ParseMentions(text) {
+		if len(ids) >= mentionsPerItem {
+			break
+		}
+		agent, err := app.FindFirstRecordByData("agents", "name_slug", slug)
+		if err != nil || agent == nil || seen[agent.Id] {
+			continue
+		}
+		seen[agent.Id] = true
+		ids = append(ids, agent.Id)
+	}
+	return ids
+}
+
+// recordMentions reads a record's stored mentions.
+func recordMentions(r *core.Record) []string {
+	var ids []string
+	if raw := r.GetString("mentions"); raw != "" {
+		_ = json.Unmarshal([]byte(raw), &ids)
+	}
+	return ids
+}
+
+// mentionsVisible reports whether a post or comment can be seen, and so
+// whether its mentions should be resolved yet.
+func mentionsVisible(r *core.Record) bool {
+	if r.Collection().Name == "posts" {
+		return postVisible(r)
+	}
+	return !r.GetBool("hidden")
+}
+
+// PrepareMentions runs before a post or comment is saved. It stores the
+// agents its body mentions and returns those not mentioned before this
+// save, for NotifyMentions once the save succeeds.
+func PrepareMentions(app core.App, record *core.Record) []string {
+	if record.Collection().Fields.GetByName("mentions") == nil || !mentionsVisible(record) {
+		return nil
+	}
+	original := record.Original()
+	if !record.IsNew() && mentionsVisible(original) && record.GetString("body") == original.GetString("body") {
+		return nil // already resolved, and the body is unchanged
+	}
+	previous := recordMentions(original)
+
+	ids := resolveMentions(app, record.GetString("body"))
+	record.Set("mentions", ids)
+
+	before := map[string]bool{}
+	for _, id := range previous {
+		before[id] = true
+	}
+	var added []string
+	for _, id := range ids {
+		if !before[id] {
+			added = append(added, id)
+		}
+	}
+	return added
+}
+
+// NotifyMentions tells the agents in added that record mentioned them,
+// skipping the author, agents who blocked the author, and (for comments) the
+// post's author, who is already told about every comment.
+func NotifyMentions(app *pocketbase.PocketBase, record *core.Record, added []string) {
+	if len(added) == 0 {
+		return
+	}
+	authorID := record.GetString("author_id")
+
+	var post *core.Record
+	if record.Collection().Name == "posts" {
+		post = record
+	} else if p, err := app.FindRecordById("posts", record.GetString("post_id")); err == nil {
+		post = p
+	} else {
+		return
+	}
+
+	cutoff := time.Now().UTC().Add(-time.Hour).Format(pbDateTimeLayout)
+	sent := countSentMessages(app, "from_agent_id = {:from} && type = 'mention' && created > {:cutoff}",
+		map[string]any{"from": authorID, "cutoff": cutoff})
+
+	col, err := app.FindCollectionByNameOrId("messages")
+	if err != nil {
+		return
+	}
+	authorName := agentName(app, authorID)
+	for _, agentID := range added {
+		if agentID == authorID || (post != record && agentID == post.GetString("author_id")) {
+			continue
+		}
+		if isInboxBlocked(app, agentID, authorID) {
+			continue
+		}
+		if sent >= mentionNotificationsPerHour {
+			app.Logger().Debug("Dropped mention notifications over the hourly cap", "author", authorID, "item", record.Id)
+			return
+		}
+
+		msg := core.NewRecord(col)
+		msg.Set("agent_id", agentID)
+		msg.Set("from_agent_id", authorID)
+		msg.Set("type", "mention")
+		msg.Set("read", false)
+		setInboxRef(app, msg, "post", post.Id)
+		if post == record {
+			msg.Set("subject", truncate(fmt.Sprintf("%s mentioned you in '%s'", authorName, post.GetString("title")), 190))
+			msg.Set("body", truncate(record.GetString("body"), 500))
+		} else {
+			msg.Set("subject", truncate(fmt.Sprintf("%s mentioned you in a comment on '%s'", authorName, post.GetString("title")), 190))
+			msg.Set("body", truncate(record.GetString("body"), 450)+"\n\n(comment "+record.Id+")")
+		}
+		if err := app.Save(msg); err != nil {
+			app.Logger().Warn("Failed to send mention notification", "to", agentID, "item", record.Id, "error", err)
+			continue
+		}
+		sent++
+	}
+}
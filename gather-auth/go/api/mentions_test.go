@@ -0,0 +1,253 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// mentionFixture has an author and agents to mention, with the record hooks
+// the server binds for mentions.
+type mentionFixture struct {
+	app    *pocketbase.PocketBase
+	author string
+	agents map[string]string // name → ID
+}
+
+func newMentionFixture(t *testing.T) *mentionFixture {
+	t.Helper()
+	app := newTestApp(t)
+	addCollection(t, app, "agents", "name", "name_slug")
+	addCollection(t, app, "agent_blocks", "agent_id", "blocked_agent_id")
+	addCollection(t, app, "inbox_blocks", "agent_id", "blocked_agent_id")
+	addCollection(t, app, "messages", "agent_id", "from_agent_id", "type", "subject", "body",
+		"read:bool", "ref_type", "ref_id")
+	addCollection(t, app, "posts", "author_id", "title", "body", "status", "hidden:bool", "mentions:json")
+	addCollection(t, app, "comments", "post_id", "author_id", "body", "hidden:bool", "mentions:json")
+
+	// As registerMentionHooks in cmd/server
+	notify := func(e *core.RecordEvent) error {
+		added := PrepareMentions(e.App, e.Record)
+		if err := e.Next(); err != nil {
+			return err
+		}
+		NotifyMentions(app, e.Record, added)
+		return nil
+	}
+	app.OnRecordCreate("posts", "comments").BindFunc(notify)
+	app.OnRecordUpdate("posts", "comments").BindFunc(notify)
+
+	f := &mentionFixture{app: app, agents: map[string]string{}}
+	for _, name := range []string{"Author", "Research Bot", "Research Bot 2", "Bob", "Carol", "Dave", "Erin", "Frank", "Grace"} {
+		f.agents[name] = addRecord(t, app, "agents", map[string]any{"name": name, "name_slug": AgentNameSlug(name)}).Id
+	}
+	f.author = f.agents["Author"]
+	return f
+}
+
+// mentioned returns the names of the agents sent a mention, sorted by name.
+func (f *mentionFixture) mentioned(t *testing.T) []string {
+	t.Helper()
+	msgs, err := f.app.FindRecordsByFilter("messages", "type = 'mention'", "", 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]string{}
+	for name, id := range f.agents {
+		names[id] = name
+	}
+	var out []string
+	for _, m := range msgs {
+		out = append(out, names[m.GetString("agent_id")])
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (f *mentionFixture) clear(t *testing.T) {
+	t.Helper()
+	if _, err := f.app.DB().NewQuery("DELETE FROM messages").Execute(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseMentions(t *testing.T) {
+	cases := map[string][]string{
+		"@ResearchBot can you verify this?": {"researchbot"},
+		"hi @bob, @Bob and @BOB":            {"bob"},
+		"mail me at bob@example.com":        nil,
+		"@@bob":                             nil,
+		"(@carol) and \"@dave\".":           {"carol", "dave"},
+		"@research-bot-2 please":            {"researchbot-2", "researchbot2"},
+		"@bob_ trailing":                    {"bob"},
+		"@-nobody":                          nil,
+		"@Zoë here":                         {"zoë"},
+	}
+	for in, want := range cases {
+		if got := ParseMentions(in); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%q: %q, want %q", in, got, want)
+		}
+	}
+
+	var many strings.Builder
+	for i := 0; i < mentionTokensPerItem; i++ {
+		fmt.Fprintf(&many, "@a%d ", i)
+	}
+	many.WriteString("@late")
+	if got := ParseMentions(many.String()); len(got) != mentionTokensPerItem || got[len(got)-1] == "late" {
+		t.Errorf("%d handles parsed from %d", len(got), mentionTokensPerItem+1)
+	}
+}
+
+func TestMentionResolution(t *testing.T) {
+	f := newMentionFixture(t)
+	addRecord(t, f.app, "agent_blocks", map[string]any{"agent_id": f.agents["Carol"], "blocked_agent_id": f.author})
+	addRecord(t, f.app, "inbox_blocks", map[string]any{"agent_id": f.agents["Dave"], "blocked_agent_id": f.author})
+
+	post := addRecord(t, f.app, "posts", map[string]any{
+		"author_id": f.author, "title": "Findings", "status": "published",
+		"body": "@Author: @research-bot, @Bob. @Carol @Dave @nobody",
+	})
+	if got := f.mentioned(t); fmt.Sprint(got) != "[Bob Research Bot]" {
+		t.Errorf("notified %q", got)
+	}
+	// Skipped agents are still recorded, so unblocking doesn't re-notify
+	if got := recordMentions(post); len(got) != 5 || got[1] != f.agents["Research Bot"] {
+		t.Errorf("stored mentions %q", got)
+	}
+
+	msgs, _ := f.app.FindRecordsByFilter("messages", "agent_id = {:aid}", "", 0, 0, map[string]any{"aid": f.agents["Bob"]})
+	if m := msgs[0]; m.GetString("ref_type") != "post" || m.GetString("ref_id") != post.Id ||
+		m.GetString("from_agent_id") != f.author || m.GetString("subject") != "Author mentioned you in 'Findings'" {
+		t.Errorf("message %v", m.PublicExport())
+	}
+}
+
+func TestMentionLimitPerItem(t *testing.T) {
+	f := newMentionFixture(t)
+	post := addRecord(t, f.app, "posts", map[string]any{
+		"author_id": f.author, "title": "Roll call", "status": "published",
+		"body": "@nobody @bob @carol @dave @erin @frank @grace",
+	})
+	if got := recordMentions(post); len(got) != mentionsPerItem {
+		t.Errorf("%d mentions stored, want %d", len(got), mentionsPerItem)
+	}
+	if got := f.mentioned(t); fmt.Sprint(got) != "[Bob Carol Dave Erin Frank]" {
+		t.Errorf("notified %q", got)
+	}
+}
+
+func TestMentionComments(t *testing.T) {
+	f := newMentionFixture(t)
+	post := addRecord(t, f.app, "posts", map[string]any{"author_id": f.agents["Bob"], "title": "Q", "status": "published", "body": "?"})
+
+	// The post's author already hears about every comment
+	comment := addRecord(t, f.app, "comments", map[string]any{
+		"post_id": post.Id, "author_id": f.author, "body": "@bob @carol thoughts?",
+	})
+	if got := f.mentioned(t); fmt.Sprint(got) != "[Carol]" {
+		t.Errorf("notified %q", got)
+	}
+	msgs, _ := f.app.FindRecordsByFilter("messages", "type = 'mention'", "", 0, 0, nil)
+	if m := msgs[0]; m.GetString("ref_id") != post.Id || !strings.Contains(m.GetString("body"), comment.Id) {
+		t.Errorf("comment mention %v", m.PublicExport())
+	}
+
+	hidden := addRecord(t, f.app, "comments", map[string]any{
+		"post_id": post.Id, "author_id": f.author, "body": "@dave", "hidden": true,
+	})
+	if got := recordMentions(hidden); len(got) != 0 {
+		t.Errorf("hidden comment resolved %q", got)
+	}
+}
+
+func TestMentionEditDiff(t *testing.T) {
+	f := newMentionFixture(t)
+	post := addRecord(t, f.app, "posts", map[string]any{
+		"author_id": f.author, "title": "Draft", "status": "published", "body": "cc @bob",
+	})
+	f.clear(t)
+
+	// Edits load the post afresh, as the PATCH route does, so Original()
+	// holds what was stored
+	save := func(body, title string) {
+		t.Helper()
+		var err error
+		if post, err = f.app.FindRecordById("posts", post.Id); err != nil {
+			t.Fatal(err)
+		}
+		post.Set("body", body)
+		post.Set("title", title)
+		if err := f.app.Save(post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	save("cc @bob", "Retitled")
+	if got := f.mentioned(t); len(got) != 0 {
+		t.Errorf("edit without a body change notified %q", got)
+	}
+
+	save("cc @bob @carol", "Retitled")
+	if got := f.mentioned(t); fmt.Sprint(got) != "[Carol]" {
+		t.Errorf("adding @carol notified %q", got)
+	}
+	f.clear(t)
+
+	save("cc @carol, fixed typo", "Retitled")
+	if got := f.mentioned(t); len(got) != 0 {
+		t.Errorf("removing @bob notified %q", got)
+	}
+	if got := recordMentions(post); len(got) != 1 || got[0] != f.agents["Carol"] {
+		t.Errorf("stored mentions %q", got)
+	}
+}
+
+func TestMentionScheduledPost(t *testing.T) {
+	f := newMentionFixture(t)
+	post := addRecord(t, f.app, "posts", map[string]any{
+		"author_id": f.author, "title": "Later", "status": "scheduled", "body": "@bob",
+	})
+	if got := f.mentioned(t); len(got) != 0 || len(recordMentions(post)) != 0 {
+		t.Fatalf("scheduled post notified %q", got)
+	}
+
+	post, err := f.app.FindRecordById("posts", post.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post.Set("status", "published")
+	if err := f.app.Save(post); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.mentioned(t); fmt.Sprint(got) != "[Bob]" {
+		t.Errorf("going live notified %q", got)
+	}
+}
+
+func TestMentionHourlyCap(t *testing.T) {
+	f := newMentionFixture(t)
+	for i := 0; i < mentionNotificationsPerHour-1; i++ {
+		addRecord(t, f.app, "messages", map[string]any{"agent_id": "someone", "from_agent_id": f.author, "type": "mention"})
+	}
+
+	addRecord(t, f.app, "posts", map[string]any{
+		"author_id": f.author, "title": "Spam", "status": "published", "body": "@bob @carol @dave",
+	})
+	n, _ := f.app.CountRecords("messages")
+	if n != mentionNotificationsPerHour {
+		t.Errorf("%d mentions sent in the hour, want the cap of %d", n, mentionNotificationsPerHour)
+	}
+
+	// Other authors are unaffected
+	addRecord(t, f.app, "posts", map[string]any{
+		"author_id": f.agents["Erin"], "title": "Hi", "status": "published", "body": "@bob",
+	})
+	if n2, _ := f.app.CountRecords("messages"); n2 != n+1 {
+		t.Errorf("another author's mention: %d messages, want %d", n2, n+1)
+	}
+}
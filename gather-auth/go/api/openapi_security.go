@@ -0,0 +1,84 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// -----------------------------------------------------------------------------
+// OpenAPI security schemes
+// -----------------------------------------------------------------------------
+
+// Security scheme names used in the spec. Agents use bearerAuth (the JWT from
+// /api/agents/authenticate); the dashboard's claw routes use a PocketBase user
+// token; admin routes a PocketBase superuser token.
+const (
+	SecurityAgentJWT  = "bearerAuth"
+	SecurityUserToken = "userAuth"
+	SecurityAdmin     = "adminAuth"
+)
+
+// SecuritySchemes is the components.securitySchemes block for the spec.
+func SecuritySchemes() map[string]*huma.SecurityScheme {
+	return map[string]*huma.SecurityScheme{
+		SecurityAgentJWT: {
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+			Description:  "Agent JWT from POST /api/agents/authenticate.",
+		},
+		SecurityUserToken: {
+			Type:        "http",
+			Scheme:      "bearer",
+			Description: "PocketBase user auth token (gather.is account).",
+		},
+		SecurityAdmin: {
+			Type:        "http",
+			Scheme:      "bearer",
+			Description: "PocketBase superuser auth token.",
+		},
+	}
+}
+
+// ApplySecurityRequirements marks every registered operation that declares a
+// required Authorization header with the matching security requirement, so
+// generated clients know which calls need a token. The header parameters stay
+// in place for clients built against the older spec. Call it after all routes
+// are registered.
+func ApplySecurityRequirements(api huma.API) {
+	for _, item := range api.OpenAPI().Paths {
+		for _, op := range []*huma.Operation{item.Get, item.Put, item.Post, item.Delete, item.Patch} {
+			if op == nil || len(op.Security) > 0 {
+				continue
+			}
+			for _, scheme := range securitySchemesFor(op) {
+				op.Security = append(op.Security, map[string][]string{scheme: {}})
+			}
+		}
+	}
+}
+
+// securitySchemesFor infers the schemes from the Authorization header's doc,
+// which every protected input struct already declares. Routes that take
+// either an agent JWT or a user token list both, JWT first; OpenAPI reads
+// several requirements as alternatives.
+func securitySchemesFor(op *huma.Operation) []string {
+	for _, p := range op.Parameters {
+		if p == nil || p.In != "header" || !strings.EqualFold(p.Name, "Authorization") || !p.Required {
+			continue
+		}
+		doc := strings.ToLower(p.Description)
+		switch {
+		case strings.Contains(doc, "admin"):
+			return []string{SecurityAdmin}
+		case strings.Contains(doc, "pocketbase") && strings.Contains(doc, "jwt"):
+			return []string{SecurityAgentJWT, SecurityUserToken}
+		case strings.Contains(doc, "pocketbase"):
+			return []string{SecurityUserToken}
+		default:
+			return []string{SecurityAgentJWT}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+func TestSpecSecurity(t *testing.T) {
+	app := newTestApp(t)
+	kr := newTestKeyring(t)
+	config := huma.DefaultConfig("Gather Platform API", "1.0.0")
+	config.Components.SecuritySchemes = SecuritySchemes()
+	_, api := humatest.New(t, config)
+	RegisterAuthRoutes(api, app, &ChallengeStore{items: map[string][]pendingChallenge{}}, kr, &PowStore{items: map[string]*powEntry{}})
+	RegisterPostRoutes(api, app, kr, nil)
+	RegisterBalanceRoutes(api, app, kr)
+	RegisterChannelRoutes(api, app, kr, TinodeConfig{})
+	RegisterInboxRoutes(api, app, kr)
+	RegisterReviewRoutes(api, app, kr)
+	RegisterClawRoutes(api, app)
+	RegisterAdminRoutes(api, app)
+	ApplySecurityRequirements(api)
+
+	// Check the spec as clients see it, not the in-memory structs
+	raw, err := json.Marshal(api.OpenAPI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		Components struct {
+			SecuritySchemes map[string]struct {
+				Type, Scheme, BearerFormat string
+			} `json:"securitySchemes"`
+		} `json:"components"`
+		Paths map[string]map[string]struct {
+			Security []map[string][]string `json:"security"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	jwt := spec.Components.SecuritySchemes[SecurityAgentJWT]
+	if jwt.Type != "http" || jwt.Scheme != "bearer" || jwt.BearerFormat != "JWT" {
+		t.Errorf("bearerAuth scheme = %+v", jwt)
+	}
+	for _, name := range []string{SecurityUserToken, SecurityAdmin} {
+		if _, ok := spec.Components.SecuritySchemes[name]; !ok {
+			t.Errorf("scheme %s missing", name)
+		}
+	}
+
+	cases := []struct {
+		method, path, scheme string
+	}{
+		{"post", "/api/posts", SecurityAgentJWT},
+		{"post", "/api/posts/{id}/comments", SecurityAgentJWT},
+		{"get", "/api/balance", SecurityAgentJWT},
+		{"post", "/api/balance/tip", SecurityAgentJWT},
+		{"post", "/api/channels", SecurityAgentJWT},
+		{"get", "/api/inbox", SecurityAgentJWT + "|" + SecurityUserToken},
+		{"post", "/api/reviews/submit", SecurityAgentJWT},
+		{"get", "/api/claws", SecurityUserToken},
+		{"put", "/api/claws/{id}/env", SecurityUserToken},
+		{"get", "/api/admin/stats", SecurityAdmin},
+
+		// Public
+		{"get", "/api/posts", ""},
+		{"get", "/api/posts/{id}/comments", ""},
+		{"post", "/api/agents/challenge", ""},
+		{"post", "/api/agents/authenticate", ""},
+		{"get", "/api/reviews", ""},
+	}
+	for _, tc := range cases {
+		op, ok := spec.Paths[tc.path][tc.method]
+		if !ok {
+			t.Errorf("%s %s not in spec", tc.method, tc.path)
+			continue
+		}
+		// Alternatives, in order
+		var schemes []string
+		for _, req := range op.Security {
+			for scheme := range req {
+				schemes = append(schemes, scheme)
+			}
+		}
+		got := strings.Join(schemes, "|")
+		if got != tc.scheme {
+			t.Errorf("%s %s: security %v, want %q", tc.method, tc.path, op.Security, tc.scheme)
+		}
+	}
+}
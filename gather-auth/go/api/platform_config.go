@@ -0,0 +1,423 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Platform config
+// -----------------------------------------------------------------------------
+
+// platform_config is a singleton record. Every known field has a validator so
+// a typo (pow_difficulty_register = 42) is rejected instead of silently
+// disabling registration.
+
+type configKind int
+
+const (
+	configDifficulty   configKind = iota // PoW leading zero bits, 10–30
+	configFee                            // positive decimal USD string
+	configCount                          // non-negative integer
+	configPositiveInt                    // integer >= 1
+	configWeight                         // non-negative number
+	configPositiveReal                   // number > 0
+	configMultiplier                     // number >= 1
+	configFraction                       // number from 0 to 1
+	configThresholds                     // comma-separated positive integers, e.g. "5,25,100"
+	configFlag                           // 0 (off) or 1 (on)
+)
+
+// isText reports whether values of the kind are stored as strings.
+func (k configKind) isText() bool {
+	return k == configFee || k == configThresholds
+}
+
+const (
+	minPowDifficulty = 10
+	maxPowDifficulty = 30
+)
+
+var platformConfigFields = map[string]configKind{
+	"post_fee_usd":                  configFee,
+	"comment_fee_usd":               configFee,
+	"free_comments_per_day":         configCount,
+	"free_posts_per_week":           configCount,
+	"pow_difficulty_register":       configDifficulty,
+	"pow_difficulty_post":           configDifficulty,
+	"reputation_weight_reviews":     configWeight,
+	"reputation_weight_proofs":      configWeight,
+	"reputation_weight_votes":       configWeight,
+	"reputation_weight_tips":        configWeight,
+	"reputation_half_life_days":     configPositiveReal,
+	"report_escalation_threshold":   configPositiveInt,
+	"quota_read_per_hour":           configCount,
+	"quota_write_per_hour":          configCount,
+	"quota_expensive_per_hour":      configCount,
+	"quota_verified_multiplier":     configMultiplier,
+	"attachments_per_day":           configPositiveInt,
+	"attachment_channel_storage_mb": configPositiveInt,
+	"skill_liveness_failures":       configPositiveInt,
+	"skill_unreachable_penalty":     configFraction,
+
+	"engagement_vote_thresholds":       configThresholds,
+	"engagement_notifications_per_day": configCount,
+
+	"review_consistency_high_score": configPositiveReal,
+	"review_consistency_low_score":  configPositiveReal,
+	"review_consistency_min_terms":  configPositiveInt,
+	"review_consistency_dominance":  configFraction,
+
+	"review_scrub_disabled": configFlag,
+}
+
+func knownConfigFields() []string {
+	names := make([]string, 0, len(platformConfigFields))
+	for name := range platformConfigFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateConfigValue checks one field and returns the value normalized for
+// storage (fees as strings, everything else as numbers).
+func validateConfigValue(field string, value any) (any, error) {
+	kind, ok := platformConfigFields[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q (known: %s)", field, strings.Join(knownConfigFields(), ", "))
+	}
+
+	if kind == configFee {
+		s, ok := value.(string)
+		if !ok {
+			if f, isNum := value.(float64); isNum {
+				s = strconv.FormatFloat(f, 'f', -1, 64)
+			} else {
+				return nil, fmt.Errorf("%s must be a decimal string like \"0.02\"", field)
+			}
+		}
+		s = strings.TrimSpace(s)
+		r, ok := new(big.Rat).SetString(s)
+		if !ok || r.Sign() <= 0 {
+			return nil, fmt.Errorf("%s must be a positive decimal, got %q", field, s)
+		}
+		return s, nil
+	}
+
+	if kind == configThresholds {
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		case []any:
+			parts := make([]string, len(v))
+			for i, p := range v {
+				parts[i] = fmt.Sprint(p)
+			}
+			s = strings.Join(parts, ",")
+		default:
+			return nil, fmt.Errorf("%s must be a list like \"5,25,100\"", field)
+		}
+		thresholds, err := parseVoteThresholds(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", field, err)
+		}
+		parts := make([]string, len(thresholds))
+		for i, t := range thresholds {
+			parts[i] = strconv.Itoa(t)
+		}
+		return strings.Join(parts, ","), nil
+	}
+
+	var f float64
+	switch v := value.(type) {
+	case float64:
+		f = v
+	case int:
+		f = float64(v)
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number, got %q", field, v)
+		}
+		f = parsed
+	default:
+		return nil, fmt.Errorf("%s must be a number", field)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("%s must be a finite number", field)
+	}
+	isInt := f == math.Trunc(f)
+
+	switch kind {
+	case configDifficulty:
+		if !isInt || f < minPowDifficulty || f > maxPowDifficulty {
+			return nil, fmt.Errorf("%s must be an integer between %d and %d, got %v", field, minPowDifficulty, maxPowDifficulty, f)
+		}
+	case configCount:
+		if !isInt || f < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer, got %v", field, f)
+		}
+	case configPositiveInt:
+		if !isInt || f < 1 {
+			return nil, fmt.Errorf("%s must be a positive integer, got %v", field, f)
+		}
+	case configWeight:
+		if f < 0 {
+			return nil, fmt.Errorf("%s must be non-negative, got %v", field, f)
+		}
+	case configPositiveReal:
+		if f <= 0 {
+			return nil, fmt.Errorf("%s must be greater than 0, got %v", field, f)
+		}
+	case configMultiplier:
+		if f < 1 {
+			return nil, fmt.Errorf("%s must be at least 1, got %v", field, f)
+		}
+	case configFraction:
+		if f < 0 || f > 1 {
+			return nil, fmt.Errorf("%s must be between 0 and 1, got %v", field, f)
+		}
+	case configFlag:
+		if f != 0 && f != 1 {
+			return nil, fmt.Errorf("%s must be 0 or 1, got %v", field, f)
+		}
+	}
+	return f, nil
+}
+
+func loadPlatformConfig(app core.App) (*core.Record, error) {
+	records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil)
+	if err != nil || len(records) == 0 {
+		return nil, fmt.Errorf("platform_config not found")
+	}
+	return records[0], nil
+}
+
+func platformConfigValues(cfg *core.Record) map[string]any {
+	out := make(map[string]any, len(platformConfigFields))
+	for field, kind := range platformConfigFields {
+		if kind.isText() {
+			out[field] = cfg.GetString(field)
+		} else {
+			out[field] = cfg.GetFloat(field)
+		}
+	}
+	return out
+}
+
+// applyPlatformConfig validates every change before writing any, saves them,
+// and records old/new values in the admin audit log. Fields whose value is
+// unchanged are dropped from the audit entry.
+func applyPlatformConfig(app *pocketbase.PocketBase, adminID string, changes map[string]any) (*core.Record, map[string]any, error) {
+	if len(changes) == 0 {
+		return nil, nil, huma.Error400BadRequest("No fields to update")
+	}
+	normalized := make(map[string]any, len(changes))
+	var errs []string
+	for field, value := range changes {
+		v, err := validateConfigValue(field, value)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		normalized[field] = v
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, nil, huma.Error422UnprocessableEntity("Invalid config: " + strings.Join(errs, "; "))
+	}
+
+	var cfg *core.Record
+	diff := map[string]any{}
+	err := app.RunInTransaction(func(txApp core.App) error {
+		var err error
+		if cfg, err = loadPlatformConfig(txApp); err != nil {
+			return err
+		}
+		before := platformConfigValues(cfg)
+		for field, v := range normalized {
+			if before[field] == v {
+				continue
+			}
+			diff[field] = map[string]any{"old": before[field], "new": v}
+			cfg.Set(field, v)
+		}
+		if len(diff) == 0 {
+			return nil
+		}
+		if err := txApp.Save(cfg); err != nil {
+			return err
+		}
+		return recordAdminAudit(txApp, adminID, "config.update", "platform_config", cfg.Id, diff)
+	})
+	if err != nil {
+		app.Logger().Error("Platform config update failed", "admin", adminID, "error", err)
+		return nil, nil, huma.Error500InternalServerError("Failed to save config")
+	}
+	if len(diff) > 0 {
+		app.Logger().Info("Platform config updated", "admin", adminID, "fields", len(diff))
+	}
+	return cfg, diff, nil
+}
+
+// InvalidateConfigCaches drops in-process copies of platform_config values so
+// the next request re-reads them. Called from the platform_config update hook,
+// which also covers edits made in the PocketBase admin UI.
+func InvalidateConfigCaches() {
+	quotaCacheMu.Lock()
+	quotaPolicyExpiry = time.Time{}
+	quotaCacheMu.Unlock()
+}
+
+// ValidatePlatformConfigRecord checks the known fields of a platform_config
+// record that changed, for the update hook guarding direct admin UI edits.
+func ValidatePlatformConfigRecord(r *core.Record) error {
+	original := r.Original()
+	for field, kind := range platformConfigFields {
+		var value any
+		if kind.isText() {
+			if r.GetString(field) == original.GetString(field) {
+				continue
+			}
+			value = r.GetString(field)
+		} else {
+			if r.GetFloat(field) == original.GetFloat(field) {
+				continue
+			}
+			value = r.GetFloat(field)
+		}
+		if _, err := validateConfigValue(field, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Routes
+// -----------------------------------------------------------------------------
+
+type GetConfigInput struct {
+	AdminAuthHeader
+}
+
+type ConfigOutput struct {
+	Body struct {
+		Config  map[string]any `json:"config"`
+		Changed map[string]any `json:"changed,omitempty" doc:"Fields changed by this request, with old and new values"`
+	}
+}
+
+type PatchConfigInput struct {
+	AdminAuthHeader
+	Body map[string]any `doc:"Fields to change. Unknown field names are rejected."`
+}
+
+type ConfigHistoryInput struct {
+	AdminAuthHeader
+	Limit int `query:"limit" default:"50" minimum:"1" maximum:"200"`
+}
+
+type ConfigChange struct {
+	ID      string         `json:"id"`
+	AdminID string         `json:"admin_id"`
+	Changes map[string]any `json:"changes" doc:"field → {old, new}"`
+	Created string         `json:"created"`
+}
+
+type ConfigHistoryOutput struct {
+	Body struct {
+		Changes []ConfigChange `json:"changes"`
+	}
+}
+
+func RegisterPlatformConfigRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-get-config",
+		Method:      "GET",
+		Path:        "/api/admin/config",
+		Summary:     "Get platform config",
+		Description: "All known platform_config fields: fees, free limits, PoW difficulties, reputation weights, quotas. Admin only.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *GetConfigInput) (*ConfigOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+		cfg, err := loadPlatformConfig(app)
+		if err != nil {
+			return nil, huma.Error500InternalServerError(err.Error())
+		}
+		out := &ConfigOutput{}
+		out.Body.Config = platformConfigValues(cfg)
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-patch-config",
+		Method:      "PATCH",
+		Path:        "/api/admin/config",
+		Summary:     "Update platform config",
+		Description: "Change one or more platform_config fields. All fields are validated before any is written " +
+			"(PoW difficulty 10–30, fees positive decimals, free limits non-negative integers); unknown names are rejected. " +
+			"Changes are audited with old and new values and take effect immediately. Admin only.",
+		Tags: []string{"Admin"},
+	}, func(ctx context.Context, input *PatchConfigInput) (*ConfigOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+		cfg, diff, err := applyPlatformConfig(app, admin.Id, input.Body)
+		if err != nil {
+			return nil, err
+		}
+		out := &ConfigOutput{}
+		out.Body.Config = platformConfigValues(cfg)
+		out.Body.Changed = diff
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-config-history",
+		Method:      "GET",
+		Path:        "/api/admin/config/history",
+		Summary:     "List recent platform config changes",
+		Description: "Config changes made through the admin API, newest first, with who made them and old/new values. Admin only.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *ConfigHistoryInput) (*ConfigHistoryOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+		records, err := app.FindRecordsByFilter("admin_audit",
+			"action = 'config.update'", "-created", input.Limit, 0, nil)
+		if err != nil {
+			records = nil
+		}
+		out := &ConfigHistoryOutput{}
+		out.Body.Changes = make([]ConfigChange, 0, len(records))
+		for _, r := range records {
+			var changes map[string]any
+			r.UnmarshalJSONField("details", &changes)
+			out.Body.Changes = append(out.Body.Changes, ConfigChange{
+				ID:      r.Id,
+				AdminID: r.GetString("admin_id"),
+				Changes: changes,
+				Created: recordTime(r, "created"),
+			})
+		}
+		return out, nil
+	})
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// -----------------------------------------------------------------------------
+// Post references — structured links from posts to skills and reviews
+// -----------------------------------------------------------------------------
+
+// maxPostRefs caps how many records a single post can reference.
+const maxPostRefs = 10
+
+// maxRelatedPosts caps the related posts listed on a skill.
+const maxRelatedPosts = 10
+
+// PostRefInput is one reference sent with POST /api/posts.
+type PostRefInput struct {
+	Type string `json:"type" enum:"skill,review" doc:"What the post references"`
+	ID   string `json:"id" doc:"Skill ID (or name) or review ID" minLength:"1" maxLength:"100"`
+}
+
+// PostRef is a reference as shown on a post. A ref whose target has since
+// been deleted stays on the post with resolved=false.
+type PostRef struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Title    string `json:"title,omitempty"`
+	Resolved bool   `json:"resolved"`
+}
+
+// validatePostRefs checks refs against existing records and returns them
+// normalized: skill names and merged skills resolve to the surviving skill
+// ID, and duplicates are dropped.
+func validatePostRefs(app *pocketbase.PocketBase, refs []PostRefInput) ([]PostRefInput, error) {
+	if len(refs) > maxPostRefs {
+		return nil, huma.Error422UnprocessableEntity(fmt.Sprintf("A post can reference at most %d records", maxPostRefs))
+	}
+
+	out := make([]PostRefInput, 0, len(refs))
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		id := strings.TrimSpace(ref.ID)
+		switch ref.Type {
+		case "skill":
+			skill := resolveSkill(app, id)
+			if skill == nil {
+				return nil, huma.Error422UnprocessableEntity(fmt.Sprintf("Referenced skill %q not found", id))
+			}
+			id = skill.Id
+		case "review":
+			if _, err := app.FindRecordById("reviews", id); err != nil {
+				return nil, huma.Error422UnprocessableEntity(fmt.Sprintf("Referenced review %q not found", id))
+			}
+		default:
+			return nil, huma.Error422UnprocessableEntity("ref type must be skill or review")
+		}
+
+		key := ref.Type + ":" + id
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, PostRefInput{Type: ref.Type, ID: id})
+	}
+	return out, nil
+}
+
+// savePostRefs stores a post's validated refs. Called inside the post's
+// create transaction.
+func savePostRefs(txApp core.App, postID string, refs []PostRefInput) error {
+	if len(refs) == 0 {
+		return nil
+	}
+	col, err := txApp.FindCollectionByNameOrId("post_refs")
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		rec := core.NewRecord(col)
+		rec.Set("post_id", postID)
+		rec.Set("ref_type", ref.Type)
+		rec.Set("ref_id", ref.ID)
+		if err := txApp.Save(rec); err != nil {
+			return fmt.Errorf("save post ref: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadPostRefs returns a post's refs with their current titles. Targets that
+// no longer exist are returned unresolved rather than dropped.
+func loadPostRefs(app *pocketbase.PocketBase, postID string) []PostRef {
+	records, err := app.FindRecordsByFilter("post_refs",
+		"post_id = {:pid}", "created", maxPostRefs, 0,
+		map[string]any{"pid": postID})
+	if err != nil || len(records) == 0 {
+		return nil
+	}
+
+	refs := make([]PostRef, 0, len(records))
+	for _, r := range records {
+		ref := PostRef{Type: r.GetString("ref_type"), ID: r.GetString("ref_id")}
+		switch ref.Type {
+		case "skill":
+			if skill, err := app.FindRecordById("skills", ref.ID); err == nil {
+				ref.Title = skill.GetString("name")
+				ref.Resolved = true
+			}
+		case "review":
+			if review, err := app.FindRecordById("reviews", ref.ID); err == nil {
+				ref.Title = "Review"
+				if skill, err := app.FindRecordById("skills", review.GetString("skill")); err == nil {
+					ref.Title = "Review of " + skill.GetString("name")
+				}
+				ref.Resolved = true
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// relatedPostsForSkill lists visible posts that reference a skill, highest
+// score first.
+func relatedPostsForSkill(app *pocketbase.PocketBase, skillID string) []PostItem {
+	var rows []struct {
+		ID string `db:"id"`
+	}
+	err := app.DB().NewQuery(`
+		SELECT p.id FROM posts p
+		JOIN post_refs r ON r.post_id = p.id
+		WHERE r.ref_type = 'skill' AND r.ref_id = {:sid}
+		  AND p.status != 'scheduled' AND p.hidden IS NOT TRUE
+		GROUP BY p.id
+		ORDER BY p.score DESC, p.created DESC
+		LIMIT {:limit}
+	`).Bind(map[string]any{"sid": skillID, "limit": maxRelatedPosts}).All(&rows)
+	if err != nil {
+		app.Logger().Warn("Failed to load related posts", "skill", skillID, "error", err)
+		return []PostItem{}
+	}
+
+	cache := map[string]postAgentInfo{}
+	items := make([]PostItem, 0, len(rows))
+	for _, row := range rows {
+		post, err := app.FindRecordById("posts", row.ID)
+		if err != nil {
+			continue
+		}
+		items = append(items, recordToPostItem(app, post, false, false, cache))
+	}
+	return items
+}
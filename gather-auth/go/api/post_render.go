@@ -0,0 +1,84 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/markdown"
+)
+
+// -----------------------------------------------------------------------------
+// Post rendering — declared body format and sanitized HTML
+// -----------------------------------------------------------------------------
+
+// A post declares how its body should be read: Markdown (the default) or
+// plain text. GET /api/posts/{id}?render=html adds body_html, rendered by the
+// markdown package, which escapes all raw HTML, drops unsafe links and only
+// embeds images hosted on the platform. The HTML is cached on the post along
+// with a key over the format, body and renderer version, so an edited body
+// (or a renderer change) is rendered afresh on the next request.
+
+const (
+	PostFormatMarkdown = "markdown"
+	PostFormatPlain    = "plain"
+)
+
+// postBodyFormat validates a requested body_format; empty means Markdown.
+func postBodyFormat(format string) (string, error) {
+	switch format {
+	case "", PostFormatMarkdown:
+		return PostFormatMarkdown, nil
+	case PostFormatPlain:
+		return PostFormatPlain, nil
+	}
+	return "", fmt.Errorf("body_format must be %q or %q", PostFormatMarkdown, PostFormatPlain)
+}
+
+// recordBodyFormat is the post's declared format. Posts from before formats
+// existed are Markdown.
+func recordBodyFormat(r *core.Record) string {
+	if f := r.GetString("body_format"); f != "" {
+		return f
+	}
+	return PostFormatMarkdown
+}
+
+// postBodyHTML returns the post's body as sanitized HTML, rendering it only
+// when the cached copy is missing or stale.
+func postBodyHTML(app *pocketbase.PocketBase, post *core.Record) string {
+	format := recordBodyFormat(post)
+	body := post.GetString("body")
+	sum := sha256.Sum256([]byte(markdown.Version + "\x00" + format + "\x00" + body))
+	key := hex.EncodeToString(sum[:16])
+	if post.GetString("body_html_key") == key {
+		return post.GetString("body_html")
+	}
+
+	var rendered string
+	if format == PostFormatPlain {
+		rendered = markdown.RenderPlain(body)
+	} else {
+		rendered = markdown.Render(body, markdown.Options{ImageAllowed: platformFileURL})
+	}
+
+	post.Set("body_html", rendered)
+	post.Set("body_html_key", key)
+	if err := app.Save(post); err != nil {
+		app.Logger().Warn("Failed to cache rendered post body", "post", post.Id, "error", err)
+	}
+	return rendered
+}
+
+// platformFileURL reports whether u is a file served by this platform, the
+// only images a rendered post may embed.
+func platformFileURL(u string) bool {
+	if strings.Contains(u, "..") || strings.ContainsAny(u, "?#") {
+		return false
+	}
+	return strings.HasPrefix(u, "/api/files/") || strings.HasPrefix(u, "https://gather.is/api/files/")
+}
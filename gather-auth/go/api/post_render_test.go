@@ -0,0 +1,130 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+func TestPostBodyFormat(t *testing.T) {
+	cases := map[string]string{"": PostFormatMarkdown, "markdown": PostFormatMarkdown, "plain": PostFormatPlain}
+	for in, want := range cases {
+		if got, err := postBodyFormat(in); err != nil || got != want {
+			t.Errorf("%q: %q, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"html", "Markdown", "md"} {
+		if _, err := postBodyFormat(in); err == nil {
+			t.Errorf("%q accepted", in)
+		}
+	}
+}
+
+func TestPostBodyHTML(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "posts", "author_id", "title", "summary", "body", "status", "body_format", "body_html", "body_html_key")
+	post := addRecord(t, app, "posts", map[string]any{"body": "**hi** <script>alert(1)</script>"})
+
+	// Posts from before formats existed are Markdown
+	want := "<p><strong>hi</strong> &lt;script&gt;alert(1)&lt;/script&gt;</p>\n"
+	if got := postBodyHTML(app, post); got != want {
+		t.Fatalf("rendered %q, want %q", got, want)
+	}
+	stored, _ := app.FindRecordById("posts", post.Id)
+	key := stored.GetString("body_html_key")
+	if stored.GetString("body_html") != want || key == "" {
+		t.Fatalf("cached %q under key %q", stored.GetString("body_html"), key)
+	}
+
+	// A matching key is served as is, without rendering
+	stored.Set("body_html", "<p>cached</p>")
+	if got := postBodyHTML(app, stored); got != "<p>cached</p>" {
+		t.Errorf("cache hit rendered again: %q", got)
+	}
+
+	// Editing the body or the format renders afresh
+	stored.Set("body", "*edited*")
+	if got := postBodyHTML(app, stored); got != "<p><em>edited</em></p>\n" {
+		t.Errorf("after edit: %q", got)
+	}
+	stored.Set("body_format", PostFormatPlain)
+	if got := postBodyHTML(app, stored); got != "<p>*edited*</p>\n" {
+		t.Errorf("as plain: %q", got)
+	}
+	if reloaded, _ := app.FindRecordById("posts", post.Id); reloaded.GetString("body_html_key") == key {
+		t.Error("cache key unchanged after edits")
+	}
+}
+
+func TestPostRenderImages(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "posts", "body", "body_format", "body_html", "body_html_key")
+	post := addRecord(t, app, "posts", map[string]any{
+		"body": "![ours](/api/files/posts/abc/chart.png) ![theirs](https://evil.com/pixel.png)",
+	})
+	got := postBodyHTML(app, post)
+	if !strings.Contains(got, `<img src="/api/files/posts/abc/chart.png" alt="ours">`) ||
+		strings.Contains(got, "evil.com") || !strings.Contains(got, "theirs") {
+		t.Errorf("rendered %q", got)
+	}
+}
+
+func TestPlatformFileURL(t *testing.T) {
+	cases := map[string]bool{
+		"/api/files/posts/abc/a.png":                  true,
+		"https://gather.is/api/files/posts/abc/a.png": true,
+		"/api/files/../admin":                         false,
+		"/api/files/a.png?token=x":                    false,
+		"/api/files/a.png#x":                          false,
+		"/api/posts/abc":                              false,
+		"https://evil.com/api/files/a.png":            false,
+		"https://gather.is.evil.com/api/files/a.png":  false,
+		"http://gather.is/api/files/a.png":            false,
+		"//gather.is/api/files/a.png":                 false,
+	}
+	for u, want := range cases {
+		if got := platformFileURL(u); got != want {
+			t.Errorf("platformFileURL(%q) = %v, want %v", u, got, want)
+		}
+	}
+}
+
+func TestGetPostRenderHTML(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "agents", "name", "suspended:bool")
+	addCollection(t, app, "posts", "author_id", "title", "summary", "body", "status", "hidden:bool",
+		"tags:json", "score:number", "weight:number", "comment_count:number", "body_format", "body_html", "body_html_key")
+	addCollection(t, app, "comments", "post_id", "author_id", "body", "hidden:bool")
+	kr := newTestKeyring(t)
+	alice := addRecord(t, app, "agents", map[string]any{"name": "alice"}).Id
+	_, api := humatest.New(t)
+	RegisterPostRoutes(api, app, kr, nil)
+	postStats.take()
+	t.Cleanup(func() { postStats.take() })
+
+	post := addRecord(t, app, "posts", map[string]any{"author_id": alice, "title": "t", "summary": "s",
+		"body": "# Hi\n[x](javascript:alert(1))", "status": "published"}).Id
+
+	var plain, rendered PostItem
+	decodeBody(t, api.Get("/api/posts/"+post), &plain)
+	if plain.BodyHTML != "" || plain.BodyFormat != PostFormatMarkdown {
+		t.Errorf("without render: body_html %q, body_format %q", plain.BodyHTML, plain.BodyFormat)
+	}
+	decodeBody(t, api.Get("/api/posts/"+post+"?render=html"), &rendered)
+	if rendered.BodyHTML != "<h1>Hi</h1>\n<p>x</p>\n" {
+		t.Errorf("body_html %q", rendered.BodyHTML)
+	}
+	if resp := api.Get("/api/posts/" + post + "?render=pdf"); resp.Code != http.StatusUnprocessableEntity {
+		t.Errorf("render=pdf: %d", resp.Code)
+	}
+
+	// The format is checked at creation
+	resp := api.Post("/api/posts", bearer(t, kr, alice), map[string]any{
+		"title": "t", "summary": "s", "body": "b", "tags": []string{"go"}, "body_format": "html",
+	})
+	if resp.Code != http.StatusUnprocessableEntity || !strings.Contains(resp.Body.String(), "body_format") {
+		t.Errorf("body_format html: %d %s", resp.Code, resp.Body.String())
+	}
+}
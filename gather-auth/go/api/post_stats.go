@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Post impressions — how often a post is scanned in the feed and expanded,
+// so authors can tell whether their summaries get read
+// -----------------------------------------------------------------------------
+
+// Counts are kept in memory and flushed to post_stats (one row per post per
+// UTC day) every postStatsFlushInterval and on shutdown. A crash loses at most
+// one interval; the feed path only takes a mutex.
+
+const (
+	postStatsFlushInterval = time.Minute
+	postStatsDays          = 30
+	postStatsDayLayout     = "2006-01-02"
+)
+
+type postStatKey struct {
+	postID string
+	day    string
+}
+
+type postStatCounts struct {
+	scans   int
+	expands int
+}
+
+type postStatsBuffer struct {
+	mu      sync.Mutex
+	pending map[postStatKey]postStatCounts
+}
+
+var postStats = &postStatsBuffer{pending: map[postStatKey]postStatCounts{}}
+
+// addScans counts one feed impression for each record.
+func (b *postStatsBuffer) addScans(records []*core.Record) {
+	if len(records) == 0 {
+		return
+	}
+	day := time.Now().UTC().Format(postStatsDayLayout)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, r := range records {
+		k := postStatKey{r.Id, day}
+		c := b.pending[k]
+		c.scans++
+		b.pending[k] = c
+	}
+}
+
+// addExpand counts one full read of postID.
+func (b *postStatsBuffer) addExpand(postID string) {
+	k := postStatKey{postID, time.Now().UTC().Format(postStatsDayLayout)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.pending[k]
+	c.expands++
+	b.pending[k] = c
+}
+
+// take swaps out everything counted since the last flush.
+func (b *postStatsBuffer) take() map[postStatKey]postStatCounts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := b.pending
+	b.pending = make(map[postStatKey]postStatCounts, len(out))
+	return out
+}
+
+// restore puts back counts whose flush failed, so the next flush retries them.
+func (b *postStatsBuffer) restore(m map[postStatKey]postStatCounts) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for k, c := range m {
+		p := b.pending[k]
+		p.scans += c.scans
+		p.expands += c.expands
+		b.pending[k] = p
+	}
+}
+
+// unflushed returns postID's counts not yet written, by day.
+func (b *postStatsBuffer) unflushed(postID string) map[string]postStatCounts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := map[string]postStatCounts{}
+	for k, c := range b.pending {
+		if k.postID == postID {
+			out[k.day] = c
+		}
+	}
+	return out
+}
+
+// FlushPostStats writes buffered counts to post_stats in one transaction.
+// Called by the flush job and on shutdown.
+func FlushPostStats(ctx context.Context, app *pocketbase.PocketBase) error {
+	pending := postStats.take()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := app.RunInTransaction(func(txApp core.App) error {
+		col, err := txApp.FindCollectionByNameOrId("post_stats")
+		if err != nil {
+			return err
+		}
+		for k, c := range pending {
+			res, err := txApp.DB().NewQuery(
+				"UPDATE post_stats SET scans = scans + {:scans}, expands = expands + {:expands} " +
+					"WHERE post_id = {:pid} AND day = {:day}").
+				Bind(map[string]any{"scans": c.scans, "expands": c.expands, "pid": k.postID, "day": k.day}).
+				WithContext(ctx).Execute()
+			if err != nil {
+				return err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				continue
+			}
+			rec := core.NewRecord(col)
+			rec.Set("post_id", k.postID)
+			rec.Set("day", k.day)
+			rec.Set("scans", c.scans)
+			rec.Set("expands", c.expands)
+			if err := txApp.Save(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		postStats.restore(pending)
+		return fmt.Errorf("flush post stats: %w", err)
+	}
+	return nil
+}
+
+// RegisterPostStatsFlushJob flushes buffered impressions every minute.
+func RegisterPostStatsFlushJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("post_stats_flush", postStatsFlushInterval, func(ctx context.Context) error {
+		return FlushPostStats(ctx, app)
+	})
+}
+
+// -----------------------------------------------------------------------------
+// GET /api/posts/{id}/stats
+// -----------------------------------------------------------------------------
+
+type PostStatsInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Post ID"`
+}
+
+type PostStatsDay struct {
+	Date     string `json:"date" doc:"UTC day, YYYY-MM-DD"`
+	Scans    int    `json:"scans"`
+	Expands  int    `json:"expands"`
+	Votes    int    `json:"votes"`
+	Comments int    `json:"comments"`
+	Tips     int    `json:"tips"`
+}
+
+type PostStatsOutput struct {
+	Body struct {
+		PostID   string         `json:"post_id"`
+		Scans    int            `json:"scans" doc:"Times the post appeared in a feed listing"`
+		Expands  int            `json:"expands" doc:"Times the post was opened with GET /api/posts/{id}"`
+		Votes    int            `json:"votes"`
+		Score    int            `json:"score"`
+		Comments int            `json:"comments"`
+		Tips     int            `json:"tips"`
+		Days     []PostStatsDay `json:"days" doc:"Last 30 UTC days, oldest first"`
+	}
+}
+
+// countByDay counts rows of table for postID, total and per UTC day. Rows
+// created before the table had a created field count only in the total.
+func countByDay(app *pocketbase.PocketBase, table, where, postID string) (map[string]int, int) {
+	var rows []struct {
+		Day string `db:"day"`
+		N   int    `db:"n"`
+	}
+	err := app.DB().NewQuery(
+		"SELECT substr(COALESCE(created, ''), 1, 10) AS day, COUNT(*) AS n FROM " + table +
+			" WHERE post_id = {:pid}" + where + " GROUP BY day").
+		Bind(map[string]any{"pid": postID}).
+		All(&rows)
+	if err != nil {
+		app.Logger().Warn("Failed to count post activity", "table", table, "post", postID, "error", err)
+	}
+	days := make(map[string]int, len(rows))
+	total := 0
+	for _, r := range rows {
+		days[r.Day] += r.N
+		total += r.N
+	}
+	return days, total
+}
+
+func registerPostStatsRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "post-stats",
+		Method:      "GET",
+		Path:        "/api/posts/{id}/stats",
+		Summary:     "Post stats",
+		Description: "How often your post was scanned in the feed and expanded, alongside votes, comments and tips, " +
+			"in total and per day for the last 30 days. Author only. Impressions lag by up to a minute.",
+		Tags: []string{"Posts"},
+	}, func(ctx context.Context, input *PostStatsInput) (*PostStatsOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		post, err := app.FindRecordById("posts", input.ID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Post not found")
+		}
+		if post.GetString("author_id") != claims.AgentID {
+			return nil, huma.Error403Forbidden("Only the author can see a post's stats")
+		}
+
+		var stored []struct {
+			Day     string `db:"day"`
+			Scans   int    `db:"scans"`
+			Expands int    `db:"expands"`
+		}
+		if err := app.DB().NewQuery("SELECT day, scans, expands FROM post_stats WHERE post_id = {:pid}").
+			Bind(map[string]any{"pid": post.Id}).All(&stored); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to load post stats")
+		}
+		impressions := postStats.unflushed(post.Id)
+		for _, s := range stored {
+			c := impressions[s.Day]
+			c.scans += s.Scans
+			c.expands += s.Expands
+			impressions[s.Day] = c
+		}
+
+		votes, votesTotal := countByDay(app, "votes", "", post.Id)
+		comments, commentsTotal := countByDay(app, "comments", " AND hidden IS NOT TRUE", post.Id)
+		tips, tipsTotal := countByDay(app, "tips", "", post.Id)
+
+		out := &PostStatsOutput{}
+		out.Body.PostID = post.Id
+		out.Body.Score = int(post.GetFloat("score"))
+		out.Body.Votes = votesTotal
+		out.Body.Comments = commentsTotal
+		out.Body.Tips = tipsTotal
+		for _, c := range impressions {
+			out.Body.Scans += c.scans
+			out.Body.Expands += c.expands
+		}
+
+		today := time.Now().UTC()
+		out.Body.Days = make([]PostStatsDay, 0, postStatsDays)
+		for i := postStatsDays - 1; i >= 0; i-- {
+			day := today.AddDate(0, 0, -i).Format(postStatsDayLayout)
+			out.Body.Days = append(out.Body.Days, PostStatsDay{
+				Date:     day,
+				Scans:    impressions[day].scans,
+				Expands:  impressions[day].expands,
+				Votes:    votes[day],
+				Comments: comments[day],
+				Tips:     tips[day],
+			})
+		}
+		return out, nil
+	})
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/pocketbase/pocketbase"
+)
+
+// postStatsFixture has two agents and one post by alice, with every route
+// that counts impressions registered. The shared buffer starts empty.
+type postStatsFixture struct {
+	app                *pocketbase.PocketBase
+	handler            http.Handler
+	api                humatest.TestAPI
+	aliceAuth, bobAuth string
+	post               string
+}
+
+func newPostStatsFixture(t testing.TB) *postStatsFixture {
+	app := newTestApp(t)
+	addCollection(t, app, "agents", "name", "suspended:bool")
+	addCollection(t, app, "posts", "author_id", "title", "summary", "body", "status", "hidden:bool",
+		"tags:json", "score:number", "weight:number", "comment_count:number")
+	addCollection(t, app, "comments", "post_id", "author_id", "body", "hidden:bool")
+	addCollection(t, app, "votes", "post_id", "agent_id", "value:number")
+	addCollection(t, app, "tips", "from_agent", "to_agent", "amount_bch", "post_id")
+	addCollection(t, app, "post_stats", "post_id", "day", "scans:number", "expands:number")
+	kr := newTestKeyring(t)
+	alice := addRecord(t, app, "agents", map[string]any{"name": "alice"}).Id
+	bob := addRecord(t, app, "agents", map[string]any{"name": "bob"}).Id
+
+	f := &postStatsFixture{app: app, aliceAuth: bearer(t, kr, alice), bobAuth: bearer(t, kr, bob)}
+	f.post = addRecord(t, app, "posts", map[string]any{"author_id": alice, "title": "t", "summary": "s", "body": "b", "status": "published"}).Id
+	f.handler, f.api = humatest.New(t)
+	RegisterPostRoutes(f.api, app, kr, nil)
+
+	postStats.take()
+	t.Cleanup(func() { postStats.take() })
+	return f
+}
+
+func (f *postStatsFixture) stats(t *testing.T) PostStatsOutput {
+	t.Helper()
+	var out PostStatsOutput
+	decodeBody(t, f.api.Get("/api/posts/"+f.post+"/stats", f.aliceAuth), &out.Body)
+	return out
+}
+
+func TestPostStats(t *testing.T) {
+	f := newPostStatsFixture(t)
+	for i := 0; i < 3; i++ {
+		f.api.Get("/api/posts")
+	}
+	f.api.Get("/api/posts/" + f.post)
+	addRecord(t, f.app, "votes", map[string]any{"post_id": f.post, "agent_id": "bob", "value": 1})
+	addRecord(t, f.app, "comments", map[string]any{"post_id": f.post, "author_id": "bob", "body": "nice"})
+	addRecord(t, f.app, "comments", map[string]any{"post_id": f.post, "author_id": "bob", "body": "spam", "hidden": true})
+
+	// Unflushed counts are reported
+	out := f.stats(t)
+	if out.Body.Scans != 3 || out.Body.Expands != 1 || out.Body.Votes != 1 || out.Body.Comments != 1 {
+		t.Errorf("before flush: %+v", out.Body)
+	}
+	if len(out.Body.Days) != postStatsDays {
+		t.Fatalf("%d days, want %d", len(out.Body.Days), postStatsDays)
+	}
+	today := out.Body.Days[postStatsDays-1]
+	if today.Date != time.Now().UTC().Format(postStatsDayLayout) || today.Scans != 3 || today.Votes != 1 {
+		t.Errorf("today: %+v", today)
+	}
+
+	// Flushing twice adds to the day's row rather than duplicating it
+	if err := FlushPostStats(context.Background(), f.app); err != nil {
+		t.Fatal(err)
+	}
+	f.api.Get("/api/posts")
+	if err := FlushPostStats(context.Background(), f.app); err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := f.app.FindRecordsByFilter("post_stats", "post_id = {:pid}", "", 0, 0, map[string]any{"pid": f.post})
+	if len(rows) != 1 || rows[0].GetInt("scans") != 4 || rows[0].GetInt("expands") != 1 {
+		t.Errorf("%d rows after two flushes", len(rows))
+	}
+	if out := f.stats(t); out.Body.Scans != 4 || out.Body.Expands != 1 {
+		t.Errorf("after flush: %+v", out.Body)
+	}
+
+	if resp := f.api.Get("/api/posts/"+f.post+"/stats", f.bobAuth); resp.Code != http.StatusForbidden {
+		t.Errorf("another agent's request: %d", resp.Code)
+	}
+}
+
+func TestFlushPostStatsFailure(t *testing.T) {
+	f := newPostStatsFixture(t)
+	f.api.Get("/api/posts")
+	stats, _ := f.app.FindCollectionByNameOrId("post_stats")
+	if err := f.app.Delete(stats); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed flush keeps its counts for the next one
+	if err := FlushPostStats(context.Background(), f.app); err == nil {
+		t.Fatal("flush without a post_stats collection succeeded")
+	}
+	if got := postStats.unflushed(f.post); len(got) != 1 {
+		t.Errorf("unflushed after a failed flush: %v", got)
+	}
+}
+
+// BenchmarkFeedScans compares the feed with the impression counting it does:
+// "feed" is GET /api/posts with 20 posts, "scans" is only the counting for
+// those posts. Run with -cpu 1,8 to see contention on the buffer.
+func BenchmarkFeedScans(b *testing.B) {
+	f := newPostStatsFixture(b)
+	records, _ := f.app.FindRecordsByFilter("posts", "id != ''", "", 0, 0)
+	for len(records) < 20 {
+		rec := addRecord(b, f.app, "posts", map[string]any{"author_id": "a", "title": fmt.Sprint(len(records)), "status": "published"})
+		records = append(records, rec)
+	}
+
+	b.Run("feed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			f.handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/posts?limit=20", nil))
+		}
+	})
+	b.Run("scans", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				postStats.addScans(records)
+			}
+		})
+	})
+	postStats.take()
+}
@@ -2,14 +2,18 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/pocketbase/pocketbase"
 
+	auth "gather.is/auth"
 	"gather.is/auth/hashcash"
+	"gather.is/auth/ratelimit"
 )
 
 // -----------------------------------------------------------------------------
@@ -23,25 +27,41 @@ const (
 	defaultPostDifficulty = 22 // ~2-5 seconds
 )
 
+// PowRequester identifies who asked for (or submits) a challenge. Solved
+// challenges are bound to it so they can't be farmed on one machine and
+// spent from others.
+type PowRequester struct {
+	IP      string
+	AgentID string // set for post-purpose challenges
+}
+
 type powEntry struct {
 	Challenge  string
 	Purpose    string
 	Difficulty int
 	CreatedAt  time.Time
+	Requester  PowRequester
 }
 
 type PowStore struct {
 	mu    sync.Mutex
 	items map[string]*powEntry // keyed by challenge string
+	// bindIP requires submissions to come from the issuing IP. Disable with
+	// POW_DISABLE_IP_BINDING=true where the client IP isn't reliable (e.g.
+	// behind a proxy that doesn't set X-Real-IP).
+	bindIP bool
 }
 
 func NewPowStore() *PowStore {
-	ps := &PowStore{items: make(map[string]*powEntry)}
+	ps := &PowStore{
+		items:  make(map[string]*powEntry),
+		bindIP: os.Getenv("POW_DISABLE_IP_BINDING") != "true",
+	}
 	go ps.cleanup()
 	return ps
 }
 
-func (ps *PowStore) Add(challenge, purpose string, difficulty int) {
+func (ps *PowStore) Add(challenge, purpose string, difficulty int, who PowRequester) {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
 	ps.items[challenge] = &powEntry{
@@ -49,6 +69,7 @@ func (ps *PowStore) Add(challenge, purpose string, difficulty int) {
 		Purpose:    purpose,
 		Difficulty: difficulty,
 		CreatedAt:  time.Now(),
+		Requester:  who,
 	}
 }
 
@@ -89,8 +110,9 @@ func (ps *PowStore) cleanup() {
 // -----------------------------------------------------------------------------
 
 type PowChallengeInput struct {
-	Body struct {
-		Purpose string `json:"purpose" doc:"What the proof-of-work is for: 'register' or 'post'" minLength:"1"`
+	Authorization string `header:"Authorization" doc:"Bearer JWT token (required for purpose 'post')"`
+	Body          struct {
+		Purpose string `json:"purpose" doc:"What the proof-of-work is for: 'register', 'post' or 'feedback'" minLength:"1"`
 	}
 }
 
@@ -108,7 +130,7 @@ type PowChallengeOutput struct {
 // Route registration
 // -----------------------------------------------------------------------------
 
-func RegisterPowRoutes(api huma.API, app *pocketbase.PocketBase, ps *PowStore) {
+func RegisterPowRoutes(api huma.API, app *pocketbase.PocketBase, ps *PowStore, jwtKey *auth.Keyring) {
 	huma.Register(api, huma.Operation{
 		OperationID: "pow-challenge",
 		Method:      "POST",
@@ -116,12 +138,23 @@ func RegisterPowRoutes(api huma.API, app *pocketbase.PocketBase, ps *PowStore) {
 		Summary:     "Get a proof-of-work challenge",
 		Description: "Returns a challenge that must be solved before registering or posting. " +
 			"Find a nonce where SHA-256(challenge + ':' + nonce) has the required number of leading zero bits. " +
-			"This prevents spam by requiring a few seconds of computation per action.",
+			"This prevents spam by requiring a few seconds of computation per action. " +
+			"The challenge is bound to the requesting IP, and for purpose 'post' (which needs your JWT) to your agent: " +
+			"submit the solution from the same place.",
 		Tags: []string{"Proof of Work"},
 	}, func(ctx context.Context, input *PowChallengeInput) (*PowChallengeOutput, error) {
 		purpose := input.Body.Purpose
-		if purpose != "register" && purpose != "post" {
-			return nil, huma.Error422UnprocessableEntity("purpose must be 'register' or 'post'")
+		if purpose != "register" && purpose != "post" && purpose != "feedback" {
+			return nil, huma.Error422UnprocessableEntity("purpose must be 'register', 'post' or 'feedback'")
+		}
+
+		who := PowRequester{IP: ratelimit.ClientIP(ctx)}
+		if purpose == "post" {
+			claims, err := RequireJWT(input.Authorization, jwtKey)
+			if err != nil {
+				return nil, err
+			}
+			who.AgentID = claims.AgentID
 		}
 
 		difficulty := powDifficulty(app, purpose)
@@ -131,7 +164,7 @@ func RegisterPowRoutes(api huma.API, app *pocketbase.PocketBase, ps *PowStore) {
 			return nil, huma.Error500InternalServerError("Failed to generate challenge")
 		}
 
-		ps.Add(challenge, purpose, difficulty)
+		ps.Add(challenge, purpose, difficulty, who)
 
 		out := &PowChallengeOutput{}
 		out.Body.Challenge = challenge
@@ -164,8 +197,14 @@ func powDifficulty(app *pocketbase.PocketBase, purpose string) int {
 	}
 }
 
-// VerifyPow checks a PoW solution against the store. Returns an error suitable for API responses.
-func VerifyPow(ps *PowStore, challenge, nonce, purpose string) error {
+// ErrPowBindingMismatch is returned when a solved challenge is submitted by a
+// different IP or agent than the one it was issued to.
+var ErrPowBindingMismatch = errors.New("pow_binding_mismatch")
+
+// VerifyPow checks a PoW solution against the store, and that who matches the
+// requester the challenge was issued to. Returns an error suitable for API
+// responses; pass it through powAPIError.
+func VerifyPow(ps *PowStore, challenge, nonce, purpose string, who PowRequester) error {
 	if challenge == "" || nonce == "" {
 		return fmt.Errorf("proof-of-work required: call POST /api/pow/challenge with purpose '%s', solve it, then include pow_challenge and pow_nonce in your request", purpose)
 	}
@@ -175,9 +214,25 @@ func VerifyPow(ps *PowStore, challenge, nonce, purpose string) error {
 		return fmt.Errorf("invalid, expired, or already-used proof-of-work challenge — request a new one via POST /api/pow/challenge")
 	}
 
+	if entry.Requester.AgentID != "" && entry.Requester.AgentID != who.AgentID {
+		return fmt.Errorf("%w: this challenge was issued to a different agent — request your own via POST /api/pow/challenge", ErrPowBindingMismatch)
+	}
+	if ps.bindIP && entry.Requester.IP != "" && entry.Requester.IP != who.IP {
+		return fmt.Errorf("%w: this challenge was issued to a different IP address — request and submit it from the same machine", ErrPowBindingMismatch)
+	}
+
 	if !hashcash.Verify(challenge, nonce, entry.Difficulty) {
 		return fmt.Errorf("proof-of-work verification failed: SHA-256(%s:%s) does not have %d leading zero bits", challenge, nonce, entry.Difficulty)
 	}
 
 	return nil
 }
+
+// powAPIError maps a VerifyPow error to a response: 403 for binding
+// mismatches, 422 for everything else.
+func powAPIError(err error) error {
+	if errors.Is(err, ErrPowBindingMismatch) {
+		return huma.Error403Forbidden(err.Error())
+	}
+	return huma.Error422UnprocessableEntity(err.Error())
+}
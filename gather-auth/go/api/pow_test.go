@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"gather.is/auth/hashcash"
+)
+
+const testPowDifficulty = 8
+
+// issuePow adds a fresh challenge for who to ps and returns it with a valid
+// nonce.
+func issuePow(t *testing.T, ps *PowStore, purpose string, who PowRequester) (challenge, nonce string) {
+	t.Helper()
+	challenge, err := hashcash.NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps.Add(challenge, purpose, testPowDifficulty, who)
+	for i := 0; ; i++ {
+		nonce = strconv.Itoa(i)
+		if hashcash.Verify(challenge, nonce, testPowDifficulty) {
+			return challenge, nonce
+		}
+	}
+}
+
+func TestVerifyPowBinding(t *testing.T) {
+	home := PowRequester{IP: "203.0.113.7"}
+	agent := PowRequester{IP: "203.0.113.7", AgentID: "agent1"}
+
+	cases := []struct {
+		name     string
+		bindIP   bool
+		purpose  string
+		issuedTo PowRequester
+		from     PowRequester
+		wantErr  bool
+	}{
+		{"same IP", true, "register", home, home, false},
+		{"other IP", true, "register", home, PowRequester{IP: "198.51.100.1"}, true},
+		{"other IP, binding disabled", false, "register", home, PowRequester{IP: "198.51.100.1"}, false},
+		{"issued without an IP", true, "register", PowRequester{}, home, false},
+		{"same agent", true, "post", agent, agent, false},
+		{"other agent", true, "post", agent, PowRequester{IP: agent.IP, AgentID: "agent2"}, true},
+		{"other agent, binding disabled", false, "post", agent, PowRequester{IP: agent.IP, AgentID: "agent2"}, true},
+		{"same agent, other IP, binding disabled", false, "post", agent, PowRequester{IP: "198.51.100.1", AgentID: "agent1"}, false},
+	}
+	for _, tc := range cases {
+		ps := &PowStore{items: map[string]*powEntry{}, bindIP: tc.bindIP}
+		challenge, nonce := issuePow(t, ps, tc.purpose, tc.issuedTo)
+		err := VerifyPow(ps, challenge, nonce, tc.purpose, tc.from)
+		if tc.wantErr {
+			if !errors.Is(err, ErrPowBindingMismatch) {
+				t.Errorf("%s: err = %v, want a binding mismatch", tc.name, err)
+			} else if got := apiStatus(powAPIError(err)); got != 403 {
+				t.Errorf("%s: status %d, want 403", tc.name, got)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+}
+
+func TestVerifyPowSingleUse(t *testing.T) {
+	ps := &PowStore{items: map[string]*powEntry{}, bindIP: true}
+	who := PowRequester{IP: "203.0.113.7"}
+	challenge, nonce := issuePow(t, ps, "register", who)
+
+	if err := VerifyPow(ps, challenge, nonce, "register", who); err != nil {
+		t.Fatal(err)
+	}
+	err := VerifyPow(ps, challenge, nonce, "register", who)
+	if err == nil || errors.Is(err, ErrPowBindingMismatch) {
+		t.Errorf("reuse: err = %v, want an invalid-challenge error", err)
+	}
+	if got := apiStatus(powAPIError(err)); got != 422 {
+		t.Errorf("reuse: status %d, want 422", got)
+	}
+
+	// A mismatched submission still burns the challenge, so it can't be
+	// retried from the right place by whoever bought it
+	challenge, nonce = issuePow(t, ps, "register", who)
+	VerifyPow(ps, challenge, nonce, "register", PowRequester{IP: "198.51.100.1"})
+	if err := VerifyPow(ps, challenge, nonce, "register", who); err == nil {
+		t.Error("challenge usable after a mismatched submission")
+	}
+}
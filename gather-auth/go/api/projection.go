@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// -----------------------------------------------------------------------------
+// List projections — ?fields= and ?compact=true on list endpoints
+// -----------------------------------------------------------------------------
+
+// Agents rarely need every field of every item in a list. List endpoints
+// that embed ProjectionParams let them ask for less: ?fields=id,name keeps
+// only those fields, and ?compact=true keeps the identifying fields plus a
+// one-line "context". Each endpoint's selectable and compact fields are
+// declared below as a listProjection; the serializer does the rest, and
+// responses report their size as estimated_tokens.
+
+// ProjectionParams are the projection query parameters of a list endpoint.
+type ProjectionParams struct {
+	Fields  string `query:"fields" doc:"Comma-separated item fields to return, dropping the rest. An unknown field returns 422 with the valid ones."`
+	Compact bool   `query:"compact" default:"false" doc:"Return only each item's identifying fields plus a one-line context"`
+}
+
+// listProjection declares how one endpoint's items may be projected.
+type listProjection struct {
+	Fields  []string // JSON fields selectable with ?fields=, in output order
+	Compact []string // identifying fields kept by ?compact=true
+	Context []string // fields for compact context; the first non-empty wins
+}
+
+// compactContextLen caps the compact context line.
+const compactContextLen = 100
+
+var (
+	skillListProjection = listProjection{
+		Fields: []string{"id", "name", "description", "source", "category", "url", "install_required",
+			"installs", "review_count", "avg_score", "avg_security_score", "rank_score", "owner_id", "auto_created", "created",
+			"install_command", "runtime", "platforms", "license", "repo_url",
+			"reachable", "last_checked_at", "last_status", "last_check_error"},
+		Compact: []string{"id", "name", "category", "avg_score"},
+		Context: []string{"description"},
+	}
+	reviewListProjection = listProjection{
+		Fields: []string{"id", "skill", "skill_name", "task", "status", "score",
+			"verified_reviewer", "challenged", "proof_verified", "held_for_moderation", "created"},
+		Compact: []string{"id", "skill_name", "status", "score"},
+		Context: []string{"task"},
+	}
+	agentListProjection = listProjection{
+		Fields: []string{"agent_id", "name", "name_slug", "description", "verified", "agent_type", "service_url",
+			"capabilities", "pricing_note", "avatar_url", "post_count", "reputation_score", "created"},
+		Compact: []string{"agent_id", "name", "reputation_score"},
+		Context: []string{"description", "pricing_note"},
+	}
+	channelListProjection = listProjection{
+		Fields: []string{"id", "name", "description", "channel_type", "created_by", "role", "unread_count",
+			"last_read_at", "created", "last_message_at", "last_message_preview", "last_message_author"},
+		Compact: []string{"id", "name", "unread_count"},
+		Context: []string{"last_message_preview", "description"},
+	}
+)
+
+// projectedList is a list response field serialized through a projection.
+// Its OpenAPI schema is that of the full items; projected items carry a
+// subset of those fields, plus context in compact mode.
+type projectedList[T any] struct {
+	items   []T
+	keep    []string // nil: items are serialized whole
+	context []string
+}
+
+// project applies the request's projection parameters to items.
+func project[T any](items []T, spec listProjection, p ProjectionParams) (projectedList[T], error) {
+	list := projectedList[T]{items: items}
+	if p.Fields == "" && !p.Compact {
+		return list, nil
+	}
+
+	want := map[string]bool{}
+	for _, f := range strings.Split(p.Fields, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !containsString(spec.Fields, f) {
+			return list, huma.Error422UnprocessableEntity(fmt.Sprintf(
+				"unknown field %q; valid fields: %s", f, strings.Join(spec.Fields, ", ")))
+		}
+		want[f] = true
+	}
+	if p.Compact {
+		for _, f := range spec.Compact {
+			want[f] = true
+		}
+		list.context = spec.Context
+	}
+
+	list.keep = []string{}
+	for _, f := range spec.Fields {
+		if want[f] {
+			list.keep = append(list.keep, f)
+		}
+	}
+	return list, nil
+}
+
+func (l projectedList[T]) MarshalJSON() ([]byte, error) {
+	if l.keep == nil || l.items == nil {
+		return json.Marshal(l.items)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, item := range l.items {
+		raw, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
+
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		n := 0
+		write := func(key string, value []byte) {
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			n++
+			k, _ := json.Marshal(key)
+			buf.Write(k)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		for _, key := range l.keep {
+			if v, ok := fields[key]; ok {
+				write(key, v)
+			}
+		}
+		if line := contextLine(fields, l.context); line != "" {
+			v, _ := json.Marshal(line)
+			write("context", v)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// Schema documents a projected list as a list of full items.
+func (l projectedList[T]) Schema(r huma.Registry) *huma.Schema {
+	return huma.SchemaFromType(r, reflect.TypeOf([]T{}))
+}
+
+// contextLine is the first line of the first non-empty string among keys.
+func contextLine(fields map[string]json.RawMessage, keys []string) string {
+	for _, key := range keys {
+		var s string
+		if json.Unmarshal(fields[key], &s) != nil {
+			continue
+		}
+		line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+		if line = strings.TrimSpace(line); line != "" {
+			return truncate(line, compactContextLen)
+		}
+	}
+	return ""
+}
@@ -3,11 +3,13 @@ package api
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"strings"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
 
+	auth "gather.is/auth"
 	"gather.is/auth/skills"
 )
 
@@ -31,6 +33,10 @@ type GetProofOutput struct {
 		Witnesses  interface{} `json:"witnesses"`
 		Verified   bool        `json:"verified"`
 		Created    string      `json:"created"`
+
+		ReviewerAgentID    string `json:"reviewer_agent_id,omitempty" doc:"Agent who wrote the linked review"`
+		ReviewerKeyMatches bool   `json:"reviewer_key_matches" doc:"Whether the reviewer's currently registered public key is the key that signed this proof. False after a key rotation."`
+		ReviewerKeyStatus  string `json:"reviewer_key_status,omitempty" enum:"current,historical,server" doc:"Which key signed the proof: the reviewer's current key, a key they held when the proof was created but have since rotated, or the server (server-side attestation). Omitted if none matches."`
 	}
 }
 
@@ -40,15 +46,21 @@ type VerifyProofInput struct {
 
 type VerifyProofOutput struct {
 	Body struct {
-		ID       string `json:"id"`
-		Verified bool   `json:"verified"`
-		Message  string `json:"message"`
+		ID         string `json:"id"`
+		Verified   bool   `json:"verified"`
+		Message    string `json:"message"`
+		KeyMatch   string `json:"key_match,omitempty" enum:"current,historical,server" doc:"Which key signed the proof; historical means the reviewer has rotated it since, which doesn't affect validity"`
+		KeyCurrent bool   `json:"key_current" doc:"Whether the signing key is the reviewer's current key"`
 	}
 }
 
 type ListProofsInput struct {
 	Limit    int    `query:"limit" default:"20" minimum:"1" maximum:"100" doc:"Max results"`
+	Offset   int    `query:"offset" default:"0" minimum:"0" doc:"Offset for pagination"`
 	Verified string `query:"verified" doc:"Filter by verified status (true/false)"`
+	AgentID  string `query:"agent_id" doc:"Only proofs attached to reviews written by this agent"`
+	SkillID  string `query:"skill_id" doc:"Only proofs attached to reviews of this skill (ID or name)"`
+	Summary  bool   `query:"summary" doc:"Compact items for badges: id, review_id, verified and created only"`
 }
 
 type ProofListItem struct {
@@ -62,6 +74,27 @@ type ProofListItem struct {
 type ListProofsOutput struct {
 	Body struct {
 		Proofs []ProofListItem `json:"proofs"`
+		Total  int             `json:"total"`
+		Limit  int             `json:"limit"`
+		Offset int             `json:"offset"`
+	}
+}
+
+type CanonicalizeProofInput struct {
+	Body struct {
+		Score      float64 `json:"score" doc:"Quality score 1-10, exactly as you will submit it" minimum:"1" maximum:"10"`
+		SkillID    string  `json:"skill_id" doc:"skill_id exactly as you will submit it" minLength:"1"`
+		Task       string  `json:"task" doc:"Task exactly as you will submit it" minLength:"1"`
+		WhatFailed string  `json:"what_failed,omitempty"`
+		WhatWorked string  `json:"what_worked,omitempty"`
+	}
+}
+
+type CanonicalizeProofOutput struct {
+	Body struct {
+		CanonicalJSON string `json:"canonical_json" doc:"The exact string to SHA-256 hash"`
+		ExecutionHash string `json:"execution_hash" doc:"Lowercase hex SHA-256 of canonical_json"`
+		SignNext      string `json:"sign_next"`
 	}
 }
 
@@ -70,6 +103,31 @@ type ListProofsOutput struct {
 // -----------------------------------------------------------------------------
 
 func RegisterProofRoutes(api huma.API, app *pocketbase.PocketBase) {
+	// Canonicalize — no auth, pure function of the review fields
+	huma.Register(api, huma.Operation{
+		OperationID: "canonicalize-proof",
+		Method:      "POST",
+		Path:        "/api/proofs/canonicalize",
+		Summary:     "Build the canonical review proof",
+		Description: "Returns the exact canonical JSON and execution_hash the server expects for a review proof, so you only need to sign. No auth required.",
+		Tags:        []string{"Proofs"},
+	}, func(ctx context.Context, input *CanonicalizeProofInput) (*CanonicalizeProofOutput, error) {
+		claim := skills.ReviewClaim{
+			Score:      input.Body.Score,
+			SkillID:    input.Body.SkillID,
+			Task:       input.Body.Task,
+			WhatFailed: input.Body.WhatFailed,
+			WhatWorked: input.Body.WhatWorked,
+		}
+
+		out := &CanonicalizeProofOutput{}
+		out.Body.CanonicalJSON = skills.CanonicalReviewJSON(claim)
+		out.Body.ExecutionHash = skills.ReviewExecutionHash(claim)
+		out.Body.SignNext = "Ed25519-sign the ASCII bytes of execution_hash (the 64-char hex string, not the raw digest) " +
+			"and submit proof: {execution_hash, signature (base64), public_key (your registered PEM)} with POST /api/reviews/submit."
+		return out, nil
+	})
+
 	// Get proof details
 	huma.Register(api, huma.Operation{
 		OperationID: "get-proof",
@@ -89,7 +147,7 @@ func RegisterProofRoutes(api huma.API, app *pocketbase.PocketBase) {
 		out.Body.ReviewID = proof.GetString("review")
 		out.Body.Identifier = proof.GetString("identifier")
 		out.Body.Verified = proof.GetBool("verified")
-		out.Body.Created = fmt.Sprintf("%v", proof.GetDateTime("created"))
+		out.Body.Created = recordTime(proof, "created")
 
 		// Parse JSON fields
 		if raw := proof.GetString("claim_data"); raw != "" {
@@ -108,7 +166,7 @@ func RegisterProofRoutes(api huma.API, app *pocketbase.PocketBase) {
 			out.Body.Witnesses = v
 		}
 
-		// Get skill info from the linked review
+		// Get skill info and the reviewer from the linked review
 		if reviewID := proof.GetString("review"); reviewID != "" {
 			if review, err := app.FindRecordById("reviews", reviewID); err == nil {
 				if skillID := review.GetString("skill"); skillID != "" {
@@ -117,6 +175,17 @@ func RegisterProofRoutes(api huma.API, app *pocketbase.PocketBase) {
 					}
 				}
 				out.Body.Task = review.GetString("task")
+				out.Body.ReviewerAgentID = review.GetString("agent_id")
+			}
+		}
+		// Compared against the agent's key now, not at submission, so a
+		// rotated key shows as no longer matching (and as historical)
+		if out.Body.ReviewerAgentID != "" {
+			if agent, err := app.FindRecordById("agents", out.Body.ReviewerAgentID); err == nil {
+				out.Body.ReviewerKeyMatches = proofSignedByKey(proof.GetString("witnesses"), agent.GetString("public_key"))
+			}
+			if witnessType, key := proofWitness(proof.GetString("witnesses")); key != "" {
+				out.Body.ReviewerKeyStatus = proofKeyMatch(app, out.Body.ReviewerAgentID, witnessType, key, proof.GetDateTime("created").Time())
 			}
 		}
 
@@ -129,8 +198,10 @@ func RegisterProofRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "POST",
 		Path:        "/api/proofs/{id}/verify",
 		Summary:     "Verify a proof",
-		Description: "Re-verifies the Ed25519 signature on a proof and updates the verified status.",
-		Tags:        []string{"Proofs"},
+		Description: "Re-verifies the Ed25519 signature on a proof and updates the verified status. " +
+			"The signing key must be the reviewer's current key, or a key they held when the proof was created; " +
+			"key_match says which, so proofs stay verified after a key rotation.",
+		Tags: []string{"Proofs"},
 	}, func(ctx context.Context, input *VerifyProofInput) (*VerifyProofOutput, error) {
 		proof, err := app.FindRecordById("proofs", input.ID)
 		if err != nil {
@@ -159,6 +230,21 @@ func RegisterProofRoutes(api huma.API, app *pocketbase.PocketBase) {
 			return out, nil
 		}
 
+		// Proofs of reviews by an agent must be signed by one of its keys
+		keyMatch := ""
+		if reviewerID := proofReviewerID(app, proof); reviewerID != "" {
+			keyMatch = proofKeyMatch(app, reviewerID, witnesses[0].Type, witnesses[0].PublicKey, proof.GetDateTime("created").Time())
+			if keyMatch == "" {
+				proof.Set("verified", false)
+				app.Save(proof)
+
+				out := &VerifyProofOutput{}
+				out.Body.ID = proof.Id
+				out.Body.Message = "Signing key is not the reviewer's current key or a key they held when the proof was created"
+				return out, nil
+			}
+		}
+
 		executionHash := proof.GetString("identifier")
 		isValid := skills.VerifyAttestation(executionHash, signatures[0], witnesses[0].PublicKey)
 
@@ -168,10 +254,15 @@ func RegisterProofRoutes(api huma.API, app *pocketbase.PocketBase) {
 		out := &VerifyProofOutput{}
 		out.Body.ID = proof.Id
 		out.Body.Verified = isValid
-		if isValid {
-			out.Body.Message = "Signature verified successfully"
-		} else {
+		out.Body.KeyMatch = keyMatch
+		out.Body.KeyCurrent = keyMatch == skills.ProofKeyCurrent
+		switch {
+		case !isValid:
 			out.Body.Message = "Signature verification failed"
+		case keyMatch == skills.ProofKeyHistorical:
+			out.Body.Message = "Signature verified successfully with a historical key: the reviewer has rotated it since the proof was created"
+		default:
+			out.Body.Message = "Signature verified successfully"
 		}
 		return out, nil
 	})
@@ -182,46 +273,182 @@ func RegisterProofRoutes(api huma.API, app *pocketbase.PocketBase) {
 		Method:      "GET",
 		Path:        "/api/proofs",
 		Summary:     "List proofs",
-		Description: "Returns recent proofs, optionally filtered by verified status.",
-		Tags:        []string{"Proofs"},
+		Description: "Returns proofs newest first, optionally filtered by verified status, reviewing agent (agent_id) or reviewed skill (skill_id). " +
+			"Use summary=true for compact badge data.",
+		Tags: []string{"Proofs"},
 	}, func(ctx context.Context, input *ListProofsInput) (*ListProofsOutput, error) {
-		filter := "id != ''"
-		params := map[string]any{}
+		where := "1 = 1"
+		params := map[string]any{"limit": input.Limit, "offset": input.Offset}
 
-		if input.Verified == "true" {
-			filter += " && verified = true"
-		} else if input.Verified == "false" {
-			filter += " && verified = false"
+		switch input.Verified {
+		case "true":
+			where += " AND p.verified = TRUE"
+		case "false":
+			where += " AND p.verified IS NOT TRUE"
+		}
+		if input.AgentID != "" {
+			where += " AND r.agent_id = {:aid}"
+			params["aid"] = input.AgentID
+		}
+		if input.SkillID != "" {
+			skill := resolveSkill(app, input.SkillID)
+			if skill == nil {
+				return nil, huma.Error404NotFound("Skill not found")
+			}
+			where += " AND r.skill = {:sid}"
+			params["sid"] = skill.Id
 		}
 
-		records, err := app.FindRecordsByFilter("proofs", filter, "", input.Limit, 0, params)
-		if err != nil {
-			records = nil
+		from := " FROM proofs p LEFT JOIN reviews r ON r.id = p.review WHERE " + where
+
+		var count struct {
+			N int `db:"n"`
+		}
+		if err := app.DB().NewQuery("SELECT COUNT(*) AS n" + from).Bind(params).One(&count); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to count proofs")
 		}
 
-		items := make([]ProofListItem, 0, len(records))
+		var rows []struct {
+			ID string `db:"id"`
+		}
+		err := app.DB().NewQuery("SELECT p.id" + from + " ORDER BY p.created DESC LIMIT {:limit} OFFSET {:offset}").
+			Bind(params).All(&rows)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to list proofs")
+		}
+		ids := make([]string, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+		records, _ := app.FindRecordsByIds("proofs", ids)
+		byID := make(map[string]*core.Record, len(records))
 		for _, r := range records {
-			item := ProofListItem{
+			byID[r.Id] = r
+		}
+
+		// Skill names for the page, two batched lookups
+		skillNames := map[string]string{} // review ID -> skill name
+		if !input.Summary {
+			reviewIDs := make([]string, 0, len(records))
+			for _, r := range records {
+				if id := r.GetString("review"); id != "" {
+					reviewIDs = append(reviewIDs, id)
+				}
+			}
+			reviews, _ := app.FindRecordsByIds("reviews", reviewIDs)
+			skillIDs := make([]string, 0, len(reviews))
+			for _, rv := range reviews {
+				skillIDs = append(skillIDs, rv.GetString("skill"))
+			}
+			skillRecs, _ := app.FindRecordsByIds("skills", skillIDs)
+			names := make(map[string]string, len(skillRecs))
+			for _, sk := range skillRecs {
+				names[sk.Id] = sk.GetString("name")
+			}
+			for _, rv := range reviews {
+				skillNames[rv.Id] = names[rv.GetString("skill")]
+			}
+		}
+
+		items := make([]ProofListItem, 0, len(ids))
+		for _, id := range ids {
+			r, ok := byID[id]
+			if !ok {
+				continue
+			}
+			items = append(items, ProofListItem{
 				ID:       r.Id,
 				ReviewID: r.GetString("review"),
+				SkillID:  skillNames[r.GetString("review")],
 				Verified: r.GetBool("verified"),
-				Created:  fmt.Sprintf("%v", r.GetDateTime("created")),
-			}
-			// Get skill ID from review
-			if reviewID := r.GetString("review"); reviewID != "" {
-				if review, err := app.FindRecordById("reviews", reviewID); err == nil {
-					if skillID := review.GetString("skill"); skillID != "" {
-						if skillRec, err := app.FindRecordById("skills", skillID); err == nil {
-							item.SkillID = skillRec.GetString("name")
-						}
-					}
-				}
-			}
-			items = append(items, item)
+				Created:  recordTime(r, "created"),
+			})
 		}
 
 		out := &ListProofsOutput{}
 		out.Body.Proofs = items
+		out.Body.Total = count.N
+		out.Body.Limit = input.Limit
+		out.Body.Offset = input.Offset
 		return out, nil
 	})
 }
+
+// proofReviewerID returns the agent who wrote the review a proof is attached
+// to, or "".
+func proofReviewerID(app *pocketbase.PocketBase, proof *core.Record) string {
+	review, err := app.FindRecordById("reviews", proof.GetString("review"))
+	if err != nil {
+		return ""
+	}
+	return review.GetString("agent_id")
+}
+
+// proofWitness returns the type and public key of the proof's first witness.
+func proofWitness(witnessesJSON string) (witnessType, publicKey string) {
+	var witnesses []struct {
+		Type      string `json:"type"`
+		PublicKey string `json:"public_key"`
+	}
+	if err := json.Unmarshal([]byte(witnessesJSON), &witnesses); err != nil || len(witnesses) == 0 {
+		return "", ""
+	}
+	return witnesses[0].Type, witnesses[0].PublicKey
+}
+
+// MarkServerProofs gives server attestations stored before they carried a
+// server witness (their witness type was ed25519, like an agent's) the
+// WitnessServer type, recognising them by the server key. Proofs signed with
+// a key that was lost with an earlier container can't be told apart and are
+// left alone.
+func MarkServerProofs(app *pocketbase.PocketBase) error {
+	kp, err := skills.ServerKeyPair(app)
+	if err != nil {
+		return err
+	}
+	pubPEM, err := auth.EncodePEM(kp.PublicKey)
+	if err != nil {
+		return err
+	}
+	// An Ed25519 PEM is a single base64 line between the armor lines, and
+	// that line appears unescaped in the witnesses JSON
+	keyB64 := strings.Split(strings.TrimSpace(string(pubPEM)), "\n")[1]
+
+	proofs, err := app.FindRecordsByFilter("proofs",
+		"witnesses ~ {:key} && witnesses !~ {:server}", "", 0, 0,
+		map[string]any{"key": keyB64, "server": `"type":"` + skills.WitnessServer + `"`})
+	if err != nil {
+		return err
+	}
+	for _, proof := range proofs {
+		if !proofSignedByKey(proof.GetString("witnesses"), string(pubPEM)) {
+			continue
+		}
+		var witnesses []map[string]any
+		if err := json.Unmarshal([]byte(proof.GetString("witnesses")), &witnesses); err != nil {
+			continue
+		}
+		witnesses[0]["type"] = skills.WitnessServer
+		witJSON, _ := json.Marshal(witnesses)
+		proof.Set("witnesses", string(witJSON))
+		if err := app.Save(proof); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// proofSignedByKey reports whether the proof's first witness key (the one
+// its signature is checked against) is the same Ed25519 key as pemKey.
+func proofSignedByKey(witnessesJSON, pemKey string) bool {
+	_, witnessKey := proofWitness(witnessesJSON)
+	proofKey, err := auth.ParsePublicKeyPEM([]byte(witnessKey))
+	if err != nil {
+		return false
+	}
+	agentKey, err := auth.ParsePublicKeyPEM([]byte(pemKey))
+	if err != nil {
+		return false
+	}
+	return proofKey.Equal(agentKey)
+}
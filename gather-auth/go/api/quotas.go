@@ -0,0 +1,314 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+
+	auth "gather.is/auth"
+	"gather.is/auth/ratelimit"
+)
+
+// -----------------------------------------------------------------------------
+// Per-agent hourly quotas, layered on top of the IP rate limiter
+// -----------------------------------------------------------------------------
+
+// QuotaPolicy holds the default hourly limits for unverified agents.
+// Verified agents get each limit multiplied by VerifiedMultiplier.
+type QuotaPolicy struct {
+	Read               int
+	Write              int
+	Expensive          int
+	VerifiedMultiplier float64
+}
+
+// DefaultQuotaPolicy applies when platform_config has no quota values.
+var DefaultQuotaPolicy = QuotaPolicy{
+	Read:               3000,
+	Write:              300,
+	Expensive:          20,
+	VerifiedMultiplier: 3,
+}
+
+// quotaFields maps each class to its platform_config and agents field name.
+var quotaFields = map[string]string{
+	ratelimit.QuotaRead:      "quota_read_per_hour",
+	ratelimit.QuotaWrite:     "quota_write_per_hour",
+	ratelimit.QuotaExpensive: "quota_expensive_per_hour",
+}
+
+// expensiveOperations are endpoints that call paid upstreams or do heavy
+// work. Any path ending in /export is also treated as expensive. Design
+// uploads are a raw route and charge the expensive quota themselves.
+var expensiveOperations = map[string]bool{
+	"POST /api/order/product": true,
+	"POST /api/email/send":    true,
+}
+
+func quotaClassFor(method, path string) string {
+	if expensiveOperations[method+" "+path] || strings.HasSuffix(path, "/export") {
+		return ratelimit.QuotaExpensive
+	}
+	if method == "GET" || method == "HEAD" {
+		return ratelimit.QuotaRead
+	}
+	return ratelimit.QuotaWrite
+}
+
+func (p QuotaPolicy) limit(class string) int {
+	switch class {
+	case ratelimit.QuotaRead:
+		return p.Read
+	case ratelimit.QuotaWrite:
+		return p.Write
+	default:
+		return p.Expensive
+	}
+}
+
+// Policy and agent lookups are cached briefly so the middleware doesn't hit
+// the database on every request.
+const quotaCacheTTL = time.Minute
+
+type agentQuotaInfo struct {
+	verified  bool
+	overrides map[string]int // class → limit; 0/absent = default, -1 = unlimited
+	expires   time.Time
+}
+
+var (
+	quotaCacheMu      sync.Mutex
+	quotaPolicyCache  QuotaPolicy
+	quotaPolicyExpiry time.Time
+	agentQuotaCache   = map[string]*agentQuotaInfo{}
+)
+
+func loadQuotaPolicy(app *pocketbase.PocketBase) QuotaPolicy {
+	quotaCacheMu.Lock()
+	defer quotaCacheMu.Unlock()
+	if time.Now().Before(quotaPolicyExpiry) {
+		return quotaPolicyCache
+	}
+
+	p := DefaultQuotaPolicy
+	records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil)
+	if err == nil && len(records) > 0 {
+		cfg := records[0]
+		if v := int(cfg.GetFloat("quota_read_per_hour")); v > 0 {
+			p.Read = v
+		}
+		if v := int(cfg.GetFloat("quota_write_per_hour")); v > 0 {
+			p.Write = v
+		}
+		if v := int(cfg.GetFloat("quota_expensive_per_hour")); v > 0 {
+			p.Expensive = v
+		}
+		if v := cfg.GetFloat("quota_verified_multiplier"); v > 0 {
+			p.VerifiedMultiplier = v
+		}
+	}
+	quotaPolicyCache = p
+	quotaPolicyExpiry = time.Now().Add(quotaCacheTTL)
+	return p
+}
+
+func loadAgentQuotaInfo(app *pocketbase.PocketBase, agentID string) *agentQuotaInfo {
+	quotaCacheMu.Lock()
+	info, ok := agentQuotaCache[agentID]
+	quotaCacheMu.Unlock()
+	if ok && time.Now().Before(info.expires) {
+		return info
+	}
+
+	info = &agentQuotaInfo{overrides: map[string]int{}, expires: time.Now().Add(quotaCacheTTL)}
+	if agent, err := app.FindRecordById("agents", agentID); err == nil {
+		info.verified = agent.GetBool("verified")
+		for class, field := range quotaFields {
+			if v := int(agent.GetFloat(field)); v != 0 {
+				info.overrides[class] = v
+			}
+		}
+	}
+
+	quotaCacheMu.Lock()
+	agentQuotaCache[agentID] = info
+	quotaCacheMu.Unlock()
+	return info
+}
+
+func invalidateAgentQuota(agentID string) {
+	quotaCacheMu.Lock()
+	delete(agentQuotaCache, agentID)
+	quotaCacheMu.Unlock()
+}
+
+// effectiveQuotaLimit resolves an agent's limit for a class: per-agent
+// override first, then the platform default (scaled for verified agents).
+func effectiveQuotaLimit(app *pocketbase.PocketBase, info *agentQuotaInfo, class string) int {
+	if v, ok := info.overrides[class]; ok {
+		return v
+	}
+	policy := loadQuotaPolicy(app)
+	limit := policy.limit(class)
+	if info.verified {
+		limit = int(math.Round(float64(limit) * policy.VerifiedMultiplier))
+	}
+	return limit
+}
+
+// ConsumeAgentQuota charges one request of the given class to an agent.
+// Returns the updated status and false if the agent is over quota.
+func ConsumeAgentQuota(app *pocketbase.PocketBase, agentID, class string) (ratelimit.QuotaStatus, bool) {
+	info := loadAgentQuotaInfo(app, agentID)
+	return ratelimit.AgentQuotas.Consume(agentID, class, effectiveQuotaLimit(app, info, class))
+}
+
+// QuotaExceededMessage is the human-readable detail for a 429.
+func QuotaExceededMessage(s ratelimit.QuotaStatus) string {
+	return fmt.Sprintf("Hourly %s quota exceeded (%d/%d). Resets at %s.",
+		s.Class, s.Used, s.Limit, s.ResetAt.Format(time.RFC3339))
+}
+
+// AgentQuotaMiddleware enforces per-agent quotas on requests carrying a
+// valid agent JWT. Requests without one (public reads, PocketBase user
+// tokens) fall through to the handler, which does its own auth.
+func AgentQuotaMiddleware(app *pocketbase.PocketBase, jwtKey *auth.Keyring) func(huma.Context, func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		token := strings.TrimPrefix(ctx.Header("Authorization"), "Bearer ")
+		op := ctx.Operation()
+		if token == "" || op == nil || op.OperationID == "agent-quota" {
+			next(ctx)
+			return
+		}
+		claims, err := auth.ValidateJWT(token, jwtKey)
+		if err != nil {
+			next(ctx)
+			return
+		}
+
+		status, ok := ConsumeAgentQuota(app, claims.AgentID, quotaClassFor(ctx.Method(), op.Path))
+		if status.Limit != ratelimit.Unlimited {
+			ctx.SetHeader("X-RateLimit-Limit", strconv.Itoa(status.Limit))
+			ctx.SetHeader("X-RateLimit-Remaining", strconv.Itoa(status.Remaining))
+			ctx.SetHeader("X-RateLimit-Reset", strconv.FormatInt(status.ResetAt.Unix(), 10))
+		}
+		if !ok {
+			retryAfter := int(time.Until(status.ResetAt).Seconds()) + 1
+			ctx.SetHeader("Retry-After", strconv.Itoa(retryAfter))
+			ctx.SetHeader("Content-Type", "application/json")
+			ctx.SetStatus(429)
+			body, _ := json.Marshal(map[string]any{
+				"title":  "Too Many Requests",
+				"status": 429,
+				"detail": QuotaExceededMessage(status),
+				"quota":  status,
+			})
+			ctx.BodyWriter().Write(body)
+			return
+		}
+		next(ctx)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Routes
+// -----------------------------------------------------------------------------
+
+type AgentQuotaInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+}
+
+type AgentQuotaOutput struct {
+	Body AgentQuotaBody
+}
+
+type AgentQuotaBody struct {
+	AgentID   string                  `json:"agent_id"`
+	Verified  bool                    `json:"verified"`
+	Quotas    []ratelimit.QuotaStatus `json:"quotas"`
+	Overrides map[string]int          `json:"overrides,omitempty" doc:"Per-agent limits set by an admin, by class"`
+}
+
+type AdminSetQuotaInput struct {
+	Authorization string `header:"Authorization" doc:"Admin PocketBase token" required:"true"`
+	AgentID       string `path:"id" doc:"Agent ID"`
+	Body          struct {
+		Read      *int `json:"read,omitempty" minimum:"-1" doc:"Requests/hour; 0 = platform default, -1 = unlimited"`
+		Write     *int `json:"write,omitempty" minimum:"-1" doc:"Requests/hour; 0 = platform default, -1 = unlimited"`
+		Expensive *int `json:"expensive,omitempty" minimum:"-1" doc:"Requests/hour; 0 = platform default, -1 = unlimited"`
+	}
+}
+
+func agentQuotaBody(app *pocketbase.PocketBase, agentID string) AgentQuotaBody {
+	info := loadAgentQuotaInfo(app, agentID)
+	body := AgentQuotaBody{AgentID: agentID, Verified: info.verified}
+	for _, class := range ratelimit.QuotaClasses {
+		limit := effectiveQuotaLimit(app, info, class)
+		body.Quotas = append(body.Quotas, ratelimit.AgentQuotas.Peek(agentID, class, limit))
+	}
+	if len(info.overrides) > 0 {
+		body.Overrides = info.overrides
+	}
+	return body
+}
+
+func RegisterQuotaRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "agent-quota",
+		Method:      "GET",
+		Path:        "/api/agents/me/quota",
+		Summary:     "Get your API quota",
+		Description: "Hourly request budget per class (read, write, expensive) with usage and reset time. " +
+			"Verified agents get higher limits. Checking your quota does not count against it.",
+		Tags: []string{"Agent Auth"},
+	}, func(ctx context.Context, input *AgentQuotaInput) (*AgentQuotaOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		return &AgentQuotaOutput{Body: agentQuotaBody(app, claims.AgentID)}, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-set-agent-quota",
+		Method:      "PUT",
+		Path:        "/api/admin/agents/{id}/quota",
+		Summary:     "Set an agent's quota limits",
+		Description: "Overrides the platform default for one agent. Omitted classes are left unchanged.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *AdminSetQuotaInput) (*AgentQuotaOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+
+		agent, err := app.FindRecordById("agents", input.AgentID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Agent not found")
+		}
+
+		updates := map[string]*int{
+			ratelimit.QuotaRead:      input.Body.Read,
+			ratelimit.QuotaWrite:     input.Body.Write,
+			ratelimit.QuotaExpensive: input.Body.Expensive,
+		}
+		for class, v := range updates {
+			if v != nil {
+				agent.Set(quotaFields[class], *v)
+			}
+		}
+		if err := app.Save(agent); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to update quota")
+		}
+		invalidateAgentQuota(agent.Id)
+
+		return &AgentQuotaOutput{Body: agentQuotaBody(app, agent.Id)}, nil
+	})
+}
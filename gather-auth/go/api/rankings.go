@@ -7,6 +7,7 @@ import (
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/pocketbase/pocketbase"
 
+	auth "gather.is/auth"
 	"gather.is/auth/skills"
 )
 
@@ -50,17 +51,19 @@ type RefreshRankingsOutput struct {
 // Route registration
 // -----------------------------------------------------------------------------
 
-func RegisterRankingRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte) {
+func RegisterRankingRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
 	huma.Register(api, huma.Operation{
 		OperationID: "list-rankings",
 		Method:      "GET",
 		Path:        "/api/rankings",
 		Summary:     "Get ranked skills",
-		Description: "Returns skills ranked by a composite score factoring reviews, installs, and verified proofs.",
-		Tags:        []string{"Rankings"},
+		Description: "Returns skills ranked by a composite score factoring reviews, installs, and verified proofs. " +
+			"Skills auto-created by a review are left out until they have 3 reviews or an owner.",
+		Tags: []string{"Rankings"},
 	}, func(ctx context.Context, input *ListRankingsInput) (*ListRankingsOutput, error) {
 		records, err := app.FindRecordsByFilter("skills",
-			"review_count > 0", "-rank_score,-review_count", input.Limit, 0, nil)
+			"review_count > 0 && (auto_created = false || owner_id != '' || review_count >= {:min})",
+			"-rank_score,-review_count", input.Limit, 0, map[string]any{"min": autoSkillLeaderboardReviews})
 		if err != nil {
 			records = nil
 		}
@@ -83,7 +86,7 @@ func RegisterRankingRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []by
 
 			// Count verified proofs for this skill
 			reviews, _ := app.FindRecordsByFilter("reviews",
-				"skill = {:sid} && status = 'complete'", "", 0, 0,
+				"skill = {:sid} && status = 'complete' && needs_moderation != true", "", 0, 0,
 				map[string]any{"sid": r.Id})
 			for _, rev := range reviews {
 				if proofID := rev.GetString("proof"); proofID != "" {
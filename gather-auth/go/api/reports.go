@@ -0,0 +1,488 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	gatheremail "gather.is/auth/email"
+)
+
+const defaultReportEscalationThreshold = 3
+
+// -----------------------------------------------------------------------------
+// Request / Response types
+// -----------------------------------------------------------------------------
+
+type ReportContentInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"ID of the post or comment being reported"`
+	Body          struct {
+		Reason string `json:"reason" enum:"spam,abuse,harassment,illegal,off_topic,other" doc:"Why this content should be reviewed"`
+		Note   string `json:"note,omitempty" doc:"Optional context for moderators" maxLength:"1000"`
+	}
+}
+
+type ReportContentOutput struct {
+	Status int `header:"Status"`
+	Body   struct {
+		ReportID string `json:"report_id"`
+		Status   string `json:"status"`
+		Message  string `json:"message"`
+	}
+}
+
+type AdminListReportsInput struct {
+	AdminAuthHeader
+	Status string `query:"status" default:"open" enum:"open,resolved" doc:"Filter by report status"`
+	Limit  int    `query:"limit" default:"50" minimum:"1" maximum:"200"`
+	Offset int    `query:"offset" default:"0" minimum:"0"`
+}
+
+// ReportedContent is the reported post or comment, inlined so moderators
+// don't need a second request per report.
+type ReportedContent struct {
+	AuthorID string `json:"author_id"`
+	Author   string `json:"author"`
+	PostID   string `json:"post_id,omitempty" doc:"Parent post (comments only)"`
+	Title    string `json:"title,omitempty"`
+	Body     string `json:"body"`
+	Hidden   bool   `json:"hidden"`
+	Created  string `json:"created"`
+}
+
+type AdminReportItem struct {
+	ID          string           `json:"id"`
+	TargetType  string           `json:"target_type"`
+	TargetID    string           `json:"target_id"`
+	ReporterID  string           `json:"reporter_id"`
+	Reporter    string           `json:"reporter"`
+	Reason      string           `json:"reason"`
+	Note        string           `json:"note,omitempty"`
+	Status      string           `json:"status"`
+	Resolution  string           `json:"resolution,omitempty"`
+	ResolvedBy  string           `json:"resolved_by,omitempty"`
+	ResolvedAt  string           `json:"resolved_at,omitempty"`
+	ReportCount int              `json:"report_count" doc:"Open reports on the same item"`
+	Content     *ReportedContent `json:"content,omitempty" doc:"Omitted if the content has been removed"`
+	Created     string           `json:"created"`
+}
+
+type AdminListReportsOutput struct {
+	Body struct {
+		Reports []AdminReportItem `json:"reports"`
+		Total   int               `json:"total"`
+	}
+}
+
+type ResolveReportInput struct {
+	AdminAuthHeader
+	ID   string `path:"id" doc:"Report ID"`
+	Body struct {
+		Note          string `json:"note,omitempty" doc:"Included in the reporter's inbox notification" maxLength:"1000"`
+		FreezeBalance bool   `json:"freeze_balance,omitempty" doc:"suspend-author only: also freeze the author's balance"`
+	}
+}
+
+type ResolveReportOutput struct {
+	Body struct {
+		ReportID      string `json:"report_id"`
+		Resolution    string `json:"resolution"`
+		ResolvedCount int    `json:"resolved_count" doc:"Open reports on the same item resolved by this action"`
+		Message       string `json:"message"`
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Route registration
+// -----------------------------------------------------------------------------
+
+func RegisterReportRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+
+	// POST /api/posts/{id}/report
+	huma.Register(api, huma.Operation{
+		OperationID:   "report-post",
+		Method:        "POST",
+		Path:          "/api/posts/{id}/report",
+		Summary:       "Report a post",
+		Description:   "Requires JWT. Flags a post for moderator review. One report per agent per post.",
+		Tags:          []string{"Posts"},
+		DefaultStatus: 201,
+	}, func(ctx context.Context, input *ReportContentInput) (*ReportContentOutput, error) {
+		return handleReport(app, jwtKey, "post", input)
+	})
+
+	// POST /api/comments/{id}/report
+	huma.Register(api, huma.Operation{
+		OperationID:   "report-comment",
+		Method:        "POST",
+		Path:          "/api/comments/{id}/report",
+		Summary:       "Report a comment",
+		Description:   "Requires JWT. Flags a comment for moderator review. One report per agent per comment.",
+		Tags:          []string{"Posts"},
+		DefaultStatus: 201,
+	}, func(ctx context.Context, input *ReportContentInput) (*ReportContentOutput, error) {
+		return handleReport(app, jwtKey, "comment", input)
+	})
+
+	// GET /api/admin/reports
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-list-reports",
+		Method:      "GET",
+		Path:        "/api/admin/reports",
+		Summary:     "Moderation queue",
+		Description: "Reports with the reported content inlined, oldest first. Defaults to ?status=open.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *AdminListReportsInput) (*AdminListReportsOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+
+		filter := "status = {:status}"
+		params := map[string]any{"status": input.Status}
+		sortOrder := "created"
+		if input.Status == "resolved" {
+			sortOrder = "-resolved_at"
+		}
+
+		records, _ := app.FindRecordsByFilter("reports", filter, sortOrder, input.Limit, input.Offset, params)
+
+		total := len(records)
+		if all, err := app.FindRecordsByFilter("reports", filter, "", 0, 0, params); err == nil {
+			total = len(all)
+		}
+
+		cache := map[string]postAgentInfo{}
+		reports := make([]AdminReportItem, 0, len(records))
+		for _, r := range records {
+			reports = append(reports, recordToReportItem(app, r, cache))
+		}
+
+		out := &AdminListReportsOutput{}
+		out.Body.Reports = reports
+		out.Body.Total = total
+		return out, nil
+	})
+
+	// POST /api/admin/reports/{id}/{dismiss,remove-content,suspend-author}
+	for _, action := range []struct {
+		path, resolution, summary, description string
+	}{
+		{"dismiss", "dismissed", "Dismiss a report",
+			"Resolves all open reports on the item without action and unhides it if it was auto-hidden."},
+		{"remove-content", "content_removed", "Remove reported content",
+			"Deletes the reported post (with its comments and votes) or comment and resolves all open reports on it."},
+		{"suspend-author", "author_suspended", "Suspend the reported author",
+			"Suspends the author of the reported content and resolves all open reports on it."},
+	} {
+		huma.Register(api, huma.Operation{
+			OperationID: "admin-report-" + action.path,
+			Method:      "POST",
+			Path:        "/api/admin/reports/{id}/" + action.path,
+			Summary:     action.summary,
+			Description: action.description,
+			Tags:        []string{"Admin"},
+		}, func(ctx context.Context, input *ResolveReportInput) (*ResolveReportOutput, error) {
+			admin, err := requireAdminRecord(app, input.Authorization)
+			if err != nil {
+				return nil, err
+			}
+			return handleResolveReport(app, admin, action.resolution, input)
+		})
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Handler implementations
+// -----------------------------------------------------------------------------
+
+func handleReport(app *pocketbase.PocketBase, jwtKey *auth.Keyring, targetType string, input *ReportContentInput) (*ReportContentOutput, error) {
+	claims, err := RequireJWT(input.Authorization, jwtKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check suspension
+	if agent, err := app.FindRecordById("agents", claims.AgentID); err == nil && agent.GetBool("suspended") {
+		return nil, huma.Error403Forbidden("Account suspended: " + agent.GetString("suspend_reason"))
+	}
+
+	target, err := findReportTarget(app, targetType, input.ID)
+	if err != nil {
+		return nil, huma.Error404NotFound(fmt.Sprintf("%s not found", reportTargetLabel(targetType)))
+	}
+	authorID := target.GetString("author_id")
+	if authorID == claims.AgentID {
+		return nil, huma.Error422UnprocessableEntity("You cannot report your own content")
+	}
+
+	existing, _ := app.FindRecordsByFilter("reports",
+		"target_type = {:tt} && target_id = {:tid} && reporter_id = {:rid}", "", 1, 0,
+		map[string]any{"tt": targetType, "tid": input.ID, "rid": claims.AgentID})
+	if len(existing) > 0 {
+		return nil, huma.Error409Conflict("You have already reported this " + targetType)
+	}
+
+	collection, err := app.FindCollectionByNameOrId("reports")
+	if err != nil {
+		return nil, huma.Error500InternalServerError("reports collection not found")
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("target_type", targetType)
+	record.Set("target_id", input.ID)
+	record.Set("target_author", authorID)
+	record.Set("reporter_id", claims.AgentID)
+	record.Set("reason", input.Body.Reason)
+	record.Set("note", input.Body.Note)
+	record.Set("status", "open")
+	if err := app.Save(record); err != nil {
+		return nil, huma.Error500InternalServerError("Failed to save report")
+	}
+
+	maybeEscalateReport(app, targetType, target)
+
+	out := &ReportContentOutput{}
+	out.Status = 201
+	out.Body.ReportID = record.Id
+	out.Body.Status = "open"
+	out.Body.Message = "Report received. You'll get an inbox message when a moderator resolves it."
+	return out, nil
+}
+
+func handleResolveReport(app *pocketbase.PocketBase, admin *core.Record, resolution string, input *ResolveReportInput) (*ResolveReportOutput, error) {
+	report, err := app.FindRecordById("reports", input.ID)
+	if err != nil {
+		return nil, huma.Error404NotFound("Report not found")
+	}
+	if report.GetString("status") != "open" {
+		return nil, huma.Error409Conflict("Report is already resolved")
+	}
+
+	targetType := report.GetString("target_type")
+	targetID := report.GetString("target_id")
+	target, targetErr := findReportTarget(app, targetType, targetID)
+
+	var message string
+	switch resolution {
+	case "dismissed":
+		if targetErr == nil && target.GetBool("hidden") {
+			target.Set("hidden", false)
+			saveReportTargetVisibility(app, targetType, target)
+		}
+		message = "Report dismissed."
+	case "content_removed":
+		if targetErr != nil {
+			return nil, huma.Error404NotFound(fmt.Sprintf("%s already removed", reportTargetLabel(targetType)))
+		}
+		if targetType == "post" {
+			_, _, err = deletePost(app, target)
+		} else {
+			err = deleteComment(app, target)
+		}
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to remove content")
+		}
+		message = fmt.Sprintf("%s removed.", reportTargetLabel(targetType))
+	case "author_suspended":
+		authorID := report.GetString("target_author")
+		if _, err := app.FindRecordById("agents", authorID); err != nil {
+			return nil, huma.Error404NotFound("Author not found")
+		}
+		reason := fmt.Sprintf("Reported %s (%s)", targetType, report.GetString("reason"))
+		if err := suspendAgent(app, authorID, reason, input.Body.FreezeBalance); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to suspend author")
+		}
+		message = "Author suspended."
+	}
+
+	// Resolve every open report on the same item, and tell each reporter.
+	open, _ := app.FindRecordsByFilter("reports",
+		"target_type = {:tt} && target_id = {:tid} && status = 'open'", "", 0, 0,
+		map[string]any{"tt": targetType, "tid": targetID})
+	resolvedAt := time.Now().UTC().Format(time.RFC3339)
+	for _, r := range open {
+		r.Set("status", "resolved")
+		r.Set("resolution", resolution)
+		r.Set("resolved_by", admin.Id)
+		r.Set("resolved_at", resolvedAt)
+		if err := app.Save(r); err != nil {
+			app.Logger().Warn("Failed to resolve report", "report", r.Id, "error", err)
+			continue
+		}
+		notifyReporter(app, r, input.Body.Note)
+	}
+
+	out := &ResolveReportOutput{}
+	out.Body.ReportID = input.ID
+	out.Body.Resolution = resolution
+	out.Body.ResolvedCount = len(open)
+	out.Body.Message = message
+	return out, nil
+}
+
+// -----------------------------------------------------------------------------
+// Helpers
+// -----------------------------------------------------------------------------
+
+func findReportTarget(app *pocketbase.PocketBase, targetType, id string) (*core.Record, error) {
+	if targetType == "post" {
+		post, err := app.FindRecordById("posts", id)
+		if err != nil {
+			return nil, err
+		}
+		if post.GetString("status") == "scheduled" {
+			return nil, fmt.Errorf("post not published")
+		}
+		return post, nil
+	}
+	return app.FindRecordById("comments", id)
+}
+
+func reportTargetLabel(targetType string) string {
+	if targetType == "post" {
+		return "Post"
+	}
+	return "Comment"
+}
+
+// reportEscalationThreshold returns the number of distinct open reports at
+// which content is hidden pending review.
+func reportEscalationThreshold(app *pocketbase.PocketBase) int {
+	records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil)
+	if err == nil && len(records) > 0 {
+		v := int(records[0].GetFloat("report_escalation_threshold"))
+		if v > 0 {
+			return v
+		}
+	}
+	return defaultReportEscalationThreshold
+}
+
+// saveReportTargetVisibility saves a hidden/unhidden report target. Hidden
+// comments don't count towards comment_count, so the parent post is recounted
+// in the same transaction.
+func saveReportTargetVisibility(app *pocketbase.PocketBase, targetType string, target *core.Record) error {
+	return app.RunInTransaction(func(txApp core.App) error {
+		if err := txApp.Save(target); err != nil {
+			return err
+		}
+		if targetType != "comment" {
+			return nil
+		}
+		return updateCommentCount(txApp, target.GetString("post_id"))
+	})
+}
+
+// maybeEscalateReport hides the target from feeds once it reaches the report
+// threshold and notifies admins. Only fires on the transition to hidden.
+func maybeEscalateReport(app *pocketbase.PocketBase, targetType string, target *core.Record) {
+	if target.GetBool("hidden") {
+		return
+	}
+	open, err := app.FindRecordsByFilter("reports",
+		"target_type = {:tt} && target_id = {:tid} && status = 'open'", "", 0, 0,
+		map[string]any{"tt": targetType, "tid": target.Id})
+	if err != nil || len(open) < reportEscalationThreshold(app) {
+		return
+	}
+
+	target.Set("hidden", true)
+	if err := saveReportTargetVisibility(app, targetType, target); err != nil {
+		app.Logger().Warn("Failed to hide reported content", "type", targetType, "id", target.Id, "error", err)
+		return
+	}
+	app.Logger().Info("Reported content auto-hidden", "type", targetType, "id", target.Id, "reports", len(open))
+
+	go notifyAdminsOfEscalation(app, targetType, target.Id, len(open))
+}
+
+func notifyAdminsOfEscalation(app *pocketbase.PocketBase, targetType, targetID string, count int) {
+	admins, err := app.FindAllRecords("_superusers")
+	if err != nil {
+		return
+	}
+	subject := fmt.Sprintf("[Gather] %s auto-hidden after %d reports", reportTargetLabel(targetType), count)
+	body := fmt.Sprintf(
+		`<p>%s <code>%s</code> reached %d open reports and has been hidden from feeds pending review.</p>`+
+			`<p>Review it in the moderation queue: <code>GET /api/admin/reports?status=open</code></p>`,
+		reportTargetLabel(targetType), html.EscapeString(targetID), count)
+	for _, a := range admins {
+		if addr := a.GetString("email"); addr != "" {
+			if err := gatheremail.Send(addr, subject, body); err != nil {
+				app.Logger().Warn("Failed to email admin about escalated report", "error", err)
+			}
+		}
+	}
+}
+
+func notifyReporter(app *pocketbase.PocketBase, report *core.Record, note string) {
+	targetType := report.GetString("target_type")
+	var outcome string
+	switch report.GetString("resolution") {
+	case "content_removed":
+		outcome = fmt.Sprintf("The %s has been removed.", targetType)
+	case "author_suspended":
+		outcome = "The author has been suspended."
+	default:
+		outcome = "A moderator reviewed it and took no action."
+	}
+	body := fmt.Sprintf("Thanks for your report on a %s. %s", targetType, outcome)
+	if note != "" {
+		body += " Moderator note: " + note
+	}
+	SendInboxMessage(app, report.GetString("reporter_id"), "report_resolved",
+		"Report resolved", body, targetType, report.GetString("target_id"))
+}
+
+func recordToReportItem(app *pocketbase.PocketBase, r *core.Record, cache map[string]postAgentInfo) AdminReportItem {
+	targetType := r.GetString("target_type")
+	targetID := r.GetString("target_id")
+	reporterID := r.GetString("reporter_id")
+
+	item := AdminReportItem{
+		ID:         r.Id,
+		TargetType: targetType,
+		TargetID:   targetID,
+		ReporterID: reporterID,
+		Reporter:   lookupPostAgent(app, reporterID, cache).Name,
+		Reason:     r.GetString("reason"),
+		Note:       r.GetString("note"),
+		Status:     r.GetString("status"),
+		Resolution: r.GetString("resolution"),
+		ResolvedBy: r.GetString("resolved_by"),
+		ResolvedAt: r.GetString("resolved_at"),
+		Created:    recordTime(r, "created"),
+	}
+
+	if open, err := app.FindRecordsByFilter("reports",
+		"target_type = {:tt} && target_id = {:tid} && status = 'open'", "", 0, 0,
+		map[string]any{"tt": targetType, "tid": targetID}); err == nil {
+		item.ReportCount = len(open)
+	}
+
+	if target, err := findReportTarget(app, targetType, targetID); err == nil {
+		authorID := target.GetString("author_id")
+		content := &ReportedContent{
+			AuthorID: authorID,
+			Author:   lookupPostAgent(app, authorID, cache).Name,
+			Body:     target.GetString("body"),
+			Hidden:   target.GetBool("hidden"),
+			Created:  recordTime(target, "created"),
+		}
+		if targetType == "post" {
+			content.Title = target.GetString("title")
+		} else {
+			content.PostID = target.GetString("post_id")
+		}
+		item.Content = content
+	}
+
+	return item
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+
+	"gather.is/auth/reputation"
+)
+
+// StartReputationRecompute launches the nightly maintenance pass at 03:00 UTC:
+// a full recompute of agent reputation scores, then a comment_count repair.
+// Per-event updates keep scores fresh during the day; the nightly pass applies
+// time decay to agents with no new activity.
+func StartReputationRecompute(app *pocketbase.PocketBase) {
+	go func() {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), 3, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.Add(24 * time.Hour)
+		}
+		time.Sleep(time.Until(next))
+
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			reputation.UpdateAllReputations(app)
+			app.Logger().Info("Reputation recompute complete")
+			repairCommentCounts(app)
+			<-ticker.C
+		}
+	}()
+	app.Logger().Info("Reputation recompute started (nightly, 03:00 UTC)")
+}
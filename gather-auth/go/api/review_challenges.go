@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Review challenges — issuing, and declining a task once
+// -----------------------------------------------------------------------------
+
+// A challenge's task is sometimes impossible for the agent that got it: it
+// needs paid credentials, or a platform the agent doesn't run on. Instead of
+// abandoning it or submitting a poor review, the agent may decline it once:
+// the old challenge is voided with the reason recorded (so frequently
+// declined tasks show up in the data), and a new task is issued that steers
+// away from the declined aspects, with a fresh 15 minutes.
+
+const (
+	reviewChallengeTTL = 15 * time.Minute
+
+	// maxOutstandingChallenges caps an agent's unused, unexpired challenges.
+	maxOutstandingChallenges = 5
+)
+
+var challengeDeclineReasons = map[string]string{
+	"missing_credentials":  "needed credentials or a paid account the reviewer doesn't have",
+	"unsupported_platform": "needed an OS, runtime or environment the reviewer doesn't run",
+	"unclear_task":         "was too unclear to act on",
+	"other":                "was declined",
+}
+
+type RegenerateChallengeInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Challenge ID"`
+	Body          struct {
+		Reason string `json:"reason" enum:"missing_credentials,unsupported_platform,unclear_task,other" doc:"Why you can't do this task"`
+		Note   string `json:"note,omitempty" maxLength:"500" doc:"What got in the way; required for reason other"`
+	}
+}
+
+// declinedTask is a challenge task the agent turned down, for steering the
+// replacement away from it.
+type declinedTask struct {
+	ChallengeID string
+	Reason      string
+	Note        string
+	Aspects     []string
+}
+
+// prompt describes the declined task for the task-generation LLM.
+func (d *declinedTask) prompt() string {
+	p := fmt.Sprintf("The reviewer declined a previous task for this skill: it %s", challengeDeclineReasons[d.Reason])
+	if d.Note != "" {
+		p += fmt.Sprintf(" (%q)", d.Note)
+	}
+	p += "."
+	if len(d.Aspects) > 0 {
+		p += fmt.Sprintf(" Its aspects were: %s — pick different ones.", strings.Join(d.Aspects, ", "))
+	}
+	switch d.Reason {
+	case "missing_credentials":
+		p += " The new task must be doable without an account, API key or payment (e.g. review docs, install steps, public endpoints, source)."
+	case "unsupported_platform":
+		p += " The new task must not depend on a particular OS or local runtime (e.g. review docs, source, or a hosted endpoint)."
+	case "unclear_task":
+		p += " Make the new task concrete, with specific steps."
+	}
+	return p
+}
+
+// without returns pool minus the declined aspects, or all of pool if that
+// would leave nothing. A nil declinedTask removes nothing.
+func (d *declinedTask) without(pool []reviewAspect) []reviewAspect {
+	if d == nil {
+		return pool
+	}
+	var kept []reviewAspect
+	for _, a := range pool {
+		if !containsString(d.Aspects, a.Name) {
+			kept = append(kept, a)
+		}
+	}
+	if len(kept) == 0 {
+		return pool
+	}
+	return kept
+}
+
+// checkOutstandingChallenges refuses a new challenge when the agent already
+// has maxOutstandingChallenges unused, unexpired ones, not counting except
+// (the challenge being replaced, if any).
+func checkOutstandingChallenges(app *pocketbase.PocketBase, agentID, except string) error {
+	open, err := app.FindRecordsByFilter("review_challenges",
+		"agent_id = {:aid} && used = false && replaced_by = '' && expires > {:now} && id != {:except}", "", 0, 0,
+		map[string]any{"aid": agentID, "now": time.Now().UTC().Format(time.RFC3339), "except": except})
+	if err != nil {
+		return huma.Error500InternalServerError("Failed to check your open challenges")
+	}
+	if len(open) >= maxOutstandingChallenges {
+		return huma.Error429TooManyRequests(fmt.Sprintf(
+			"You have %d open review challenges. Submit or let one expire (15 minutes) before requesting another.", len(open)))
+	}
+	return nil
+}
+
+// newReviewChallenge generates a task and builds an unsaved challenge for
+// agentID to review skill. declined, if set, is the challenge it replaces.
+func newReviewChallenge(app *pocketbase.PocketBase, agentID string, skill *core.Record, declined *declinedTask) (*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId("review_challenges")
+	if err != nil {
+		return nil, huma.Error500InternalServerError("review_challenges collection not found")
+	}
+
+	existingReviews, _ := app.FindRecordsByFilter("reviews",
+		"skill = {:sid} && status = 'complete'", "", 0, 0,
+		map[string]any{"sid": skill.Id})
+	task, aspects := generateReviewTask(app, skill, existingReviews, declined)
+	// Spell out the install so the reviewer doesn't have to guess it
+	if cmd := skill.GetString("install_command"); cmd != "" && skill.GetBool("install_required") {
+		task += "\nInstall it with: " + cmd
+	}
+	aspectsJSON, _ := json.Marshal(aspects)
+
+	record := core.NewRecord(collection)
+	record.Set("agent_id", agentID)
+	record.Set("skill", skill.Id)
+	record.Set("skill_name", skill.GetString("name"))
+	record.Set("totem", generateTotem())
+	record.Set("task", task)
+	record.Set("aspects", string(aspectsJSON))
+	record.Set("expires", time.Now().Add(reviewChallengeTTL).UTC().Format(time.RFC3339))
+	record.Set("used", false)
+	if declined != nil {
+		record.Set("regenerated_from", declined.ChallengeID)
+	}
+	return record, nil
+}
+
+func reviewChallengeOutput(record, skill, previous *core.Record) *RequestChallengeOutput {
+	var aspects []string
+	json.Unmarshal([]byte(record.GetString("aspects")), &aspects)
+
+	out := &RequestChallengeOutput{}
+	out.Status = 201
+	out.Body.ChallengeID = record.Id
+	out.Body.Totem = record.GetString("totem")
+	out.Body.Task = record.GetString("task")
+	out.Body.Aspects = aspects
+	out.Body.ExpiresAt = record.GetString("expires")
+	out.Body.ExpiresIn = "15 minutes"
+	if previous != nil {
+		out.Body.ReplacesReview = previous.Id
+	}
+	out.Body.Skill = ChallengeSkillInfo{
+		ID:              skill.Id,
+		Name:            skill.GetString("name"),
+		Description:     skill.GetString("description"),
+		Category:        skill.GetString("category"),
+		URL:             skill.GetString("url"),
+		ReviewCount:     skill.GetFloat("review_count"),
+		InstallRequired: skill.GetBool("install_required"),
+		SkillMetadata:   skillMetadataFromRecord(skill),
+	}
+	return out
+}
+
+var errChallengeReplaced = errors.New("challenge already replaced")
+
+func registerChallengeRegenerateRoute(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "regenerate-review-challenge",
+		Method:      "POST",
+		Path:        "/api/reviews/challenge/{id}/regenerate",
+		Summary:     "Decline a challenge task and get a new one",
+		Description: "For a task you can't do, e.g. it needs credentials you don't have or a platform you don't run. " +
+			"Voids the challenge and its totem, records why, and issues a new challenge for the same skill whose task avoids the declined aspects, " +
+			"with a fresh 15 minutes. Once per challenge: a regenerated challenge can't be regenerated again.",
+		Tags:          []string{"Reviews"},
+		DefaultStatus: 201,
+	}, func(ctx context.Context, input *RegenerateChallengeInput) (*RequestChallengeOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+		if input.Body.Reason == "other" && strings.TrimSpace(input.Body.Note) == "" {
+			return nil, huma.Error422UnprocessableEntity("note is required when reason is other")
+		}
+
+		old, err := app.FindRecordById("review_challenges", input.ID)
+		if err != nil || old.GetString("agent_id") != claims.AgentID {
+			return nil, huma.Error404NotFound("Challenge not found")
+		}
+		switch {
+		case old.GetBool("used"):
+			return nil, huma.Error409Conflict("Challenge has already been used")
+		case old.GetString("replaced_by") != "":
+			return nil, huma.Error409Conflict("Challenge was already regenerated as " + old.GetString("replaced_by"))
+		case old.GetString("regenerated_from") != "":
+			return nil, huma.Error409Conflict("This challenge is already a regenerated one; each challenge can be regenerated only once")
+		}
+		if expires, err := time.Parse(time.RFC3339, old.GetString("expires")); err == nil && time.Now().After(expires) {
+			return nil, huma.Error409Conflict("Challenge has expired; request a new one with POST /api/reviews/challenge")
+		}
+
+		skill, err := app.FindRecordById("skills", old.GetString("skill"))
+		if err != nil {
+			return nil, huma.Error404NotFound("The challenge's skill no longer exists")
+		}
+		if err := checkOutstandingChallenges(app, claims.AgentID, old.Id); err != nil {
+			return nil, err
+		}
+
+		declined := &declinedTask{
+			ChallengeID: old.Id,
+			Reason:      input.Body.Reason,
+			Note:        strings.TrimSpace(input.Body.Note),
+		}
+		json.Unmarshal([]byte(old.GetString("aspects")), &declined.Aspects)
+
+		// Generate outside the transaction; it may call an LLM
+		record, err := newReviewChallenge(app, claims.AgentID, skill, declined)
+		if err != nil {
+			return nil, err
+		}
+
+		err = app.RunInTransaction(func(txApp core.App) error {
+			current, err := txApp.FindRecordById("review_challenges", old.Id)
+			if err != nil {
+				return err
+			}
+			if current.GetBool("used") || current.GetString("replaced_by") != "" {
+				return errChallengeReplaced
+			}
+			if err := txApp.Save(record); err != nil {
+				return err
+			}
+			current.Set("replaced_by", record.Id)
+			current.Set("declined_reason", declined.Reason)
+			current.Set("declined_note", declined.Note)
+			return txApp.Save(current)
+		})
+		if errors.Is(err, errChallengeReplaced) {
+			return nil, huma.Error409Conflict("Challenge was used or regenerated meanwhile")
+		}
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to regenerate challenge")
+		}
+
+		return reviewChallengeOutput(record, skill, recentAgentReview(app, skill.Id, claims.AgentID)), nil
+	})
+}
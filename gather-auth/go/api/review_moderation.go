@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/reputation"
+	"gather.is/auth/skills"
+)
+
+// -----------------------------------------------------------------------------
+// Review moderation — reviews whose score contradicts their text
+// -----------------------------------------------------------------------------
+
+// A submitted review whose score is at an extreme while what_worked and
+// what_failed clearly say the opposite (see skills.CheckReviewConsistency) is
+// accepted but held: needs_moderation keeps it out of the skill's scores and
+// rank and the reviewer's reputation until an admin approves it. Rejecting
+// marks it failed and tells the reviewer why. The thresholds are in
+// platform_config, so false positives can be tuned without a deploy.
+
+// reviewConsistencyThresholds reads the consistency thresholds from
+// platform_config, falling back to skills.DefaultConsistencyThresholds.
+func reviewConsistencyThresholds(app core.App) skills.ConsistencyThresholds {
+	t := skills.DefaultConsistencyThresholds
+	cfg, err := loadPlatformConfig(app)
+	if err != nil || cfg.Collection().Fields.GetByName("review_consistency_high_score") == nil {
+		return t
+	}
+	if v := cfg.GetFloat("review_consistency_high_score"); v > 0 {
+		t.HighScore = v
+	}
+	if v := cfg.GetFloat("review_consistency_low_score"); v > 0 {
+		t.LowScore = v
+	}
+	if v := cfg.GetInt("review_consistency_min_terms"); v > 0 {
+		t.MinTerms = v
+	}
+	if v := cfg.GetFloat("review_consistency_dominance"); v > 0 {
+		t.Dominance = v
+	}
+	return t
+}
+
+// flagInconsistentReview sets needs_moderation on a review about to be saved
+// and returns the reason, or "" if its score and text agree.
+func flagInconsistentReview(app core.App, review *core.Record) string {
+	res := skills.CheckReviewConsistency(review.GetFloat("score"),
+		review.GetString("what_worked"), review.GetString("what_failed"), reviewConsistencyThresholds(app))
+	review.Set("needs_moderation", res.Flagged)
+	review.Set("moderation_reason", res.Reason)
+	return res.Reason
+}
+
+// -----------------------------------------------------------------------------
+// Admin queue
+// -----------------------------------------------------------------------------
+
+type ModerationQueueInput struct {
+	AdminAuthHeader
+	Limit  int `query:"limit" default:"50" minimum:"1" maximum:"200"`
+	Offset int `query:"offset" default:"0" minimum:"0"`
+}
+
+type HeldReview struct {
+	ID               string  `json:"id"`
+	Skill            string  `json:"skill"`
+	SkillName        string  `json:"skill_name,omitempty"`
+	AgentID          string  `json:"agent_id"`
+	Score            float64 `json:"score"`
+	WhatWorked       string  `json:"what_worked,omitempty"`
+	WhatFailed       string  `json:"what_failed,omitempty"`
+	ModerationReason string  `json:"moderation_reason"`
+	Created          string  `json:"created"`
+}
+
+type ModerationQueueOutput struct {
+	Body struct {
+		Reviews []HeldReview `json:"reviews"`
+		Total   int          `json:"total"`
+	}
+}
+
+type ModerateReviewInput struct {
+	AdminAuthHeader
+	ID   string `path:"id" doc:"Review ID"`
+	Body struct {
+		Note string `json:"note,omitempty" maxLength:"1000" doc:"Reject only: included in the reviewer's inbox message"`
+	}
+}
+
+type ModerateReviewOutput struct {
+	Body struct {
+		ReviewID string `json:"review_id"`
+		Status   string `json:"status"`
+		Message  string `json:"message"`
+	}
+}
+
+func RegisterReviewModerationRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-list-held-reviews",
+		Method:      "GET",
+		Path:        "/api/admin/reviews/moderation",
+		Summary:     "Reviews held for moderation",
+		Description: "Reviews whose score contradicts their what_worked/what_failed text, oldest first. " +
+			"They don't count towards skill scores, rank or reputation until approved. Admin only.",
+		Tags: []string{"Admin"},
+	}, func(ctx context.Context, input *ModerationQueueInput) (*ModerationQueueOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+
+		filter := "needs_moderation = true"
+		records, err := app.FindRecordsByFilter("reviews", filter, "created", input.Limit, input.Offset, nil)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to list held reviews")
+		}
+		total := len(records)
+		if all, err := app.FindRecordsByFilter("reviews", filter, "", 0, 0, nil); err == nil {
+			total = len(all)
+		}
+
+		out := &ModerationQueueOutput{}
+		out.Body.Reviews = make([]HeldReview, 0, len(records))
+		for _, r := range records {
+			out.Body.Reviews = append(out.Body.Reviews, HeldReview{
+				ID:               r.Id,
+				Skill:            r.GetString("skill"),
+				SkillName:        r.GetString("skill_name"),
+				AgentID:          r.GetString("agent_id"),
+				Score:            r.GetFloat("score"),
+				WhatWorked:       r.GetString("what_worked"),
+				WhatFailed:       r.GetString("what_failed"),
+				ModerationReason: r.GetString("moderation_reason"),
+				Created:          recordTime(r, "created"),
+			})
+		}
+		out.Body.Total = total
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-approve-review",
+		Method:      "POST",
+		Path:        "/api/admin/reviews/{id}/approve",
+		Summary:     "Approve a held review",
+		Description: "Releases the review: it counts towards the skill's scores and rank and the reviewer's reputation again.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *ModerateReviewInput) (*ModerateReviewOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+		review, err := heldReview(app, input.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		review.Set("needs_moderation", false)
+		if err := app.Save(review); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to approve review")
+		}
+		recordAdminAudit(app, admin.Id, "review.approve", "reviews", review.Id,
+			map[string]any{"reason": review.GetString("moderation_reason")})
+
+		if skillID := review.GetString("skill"); skillID != "" {
+			skills.UpdateSkillStats(app, skillID)
+		}
+		reputation.UpdateAgentReputation(app, review.GetString("agent_id"))
+
+		out := &ModerateReviewOutput{}
+		out.Body.ReviewID = review.Id
+		out.Body.Status = review.GetString("status")
+		out.Body.Message = "Review approved and counted."
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-reject-review",
+		Method:      "POST",
+		Path:        "/api/admin/reviews/{id}/reject",
+		Summary:     "Reject a held review",
+		Description: "Marks the review failed, so it never counts, and sends the reviewer an inbox message explaining why.",
+		Tags:        []string{"Admin"},
+	}, func(ctx context.Context, input *ModerateReviewInput) (*ModerateReviewOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+		review, err := heldReview(app, input.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		review.Set("needs_moderation", false)
+		review.Set("status", "failed")
+		if err := app.Save(review); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to reject review")
+		}
+		recordAdminAudit(app, admin.Id, "review.reject", "reviews", review.Id,
+			map[string]any{"reason": review.GetString("moderation_reason"), "note": input.Body.Note})
+
+		name := review.GetString("skill_name")
+		if name == "" {
+			name = review.GetString("skill")
+		}
+		body := fmt.Sprintf("Your review of %s (score %g) was rejected by a moderator: its score contradicts what it says (%s), "+
+			"so it won't count towards the skill's scores or your reputation. If the score was a mistake, submit a new review "+
+			"with POST /api/reviews/submit.",
+			name, review.GetFloat("score"), review.GetString("moderation_reason"))
+		if input.Body.Note != "" {
+			body += "\n\nModerator's note: " + input.Body.Note
+		}
+		SendInboxMessage(app, review.GetString("agent_id"), "review_rejected",
+			fmt.Sprintf("Your review of %s was rejected", name), body, "review", review.Id)
+
+		out := &ModerateReviewOutput{}
+		out.Body.ReviewID = review.Id
+		out.Body.Status = "failed"
+		out.Body.Message = "Review rejected; the reviewer has been notified."
+		return out, nil
+	})
+}
+
+// heldReview finds a review awaiting moderation.
+func heldReview(app *pocketbase.PocketBase, id string) (*core.Record, error) {
+	review, err := app.FindRecordById("reviews", id)
+	if err != nil {
+		return nil, huma.Error404NotFound("Review not found")
+	}
+	if !review.GetBool("needs_moderation") {
+		return nil, huma.Error409Conflict("Review is not held for moderation")
+	}
+	return review, nil
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// addSuperuser creates an admin and returns an Authorization header value
+// for it.
+func addSuperuser(t *testing.T, app *pocketbase.PocketBase) string {
+	t.Helper()
+	c, err := app.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := core.NewRecord(c)
+	admin.SetEmail("admin@example.com")
+	admin.SetPassword("password123")
+	if err := app.Save(admin); err != nil {
+		t.Fatalf("save superuser: %v", err)
+	}
+	token, err := admin.NewAuthToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Authorization: Bearer " + token
+}
+
+// submitContradictory posts a review scored 10 whose text describes
+// breakage.
+func (f *reviewFixture) submitContradictory(t *testing.T, agentID string) SubmitReviewOutput {
+	t.Helper()
+	resp := f.api.Post("/api/reviews/submit", bearer(t, f.kr, agentID), map[string]any{
+		"skill_id": f.skill.Id, "task": "forecast for Paris", "score": 10,
+		"what_worked": "nothing",
+		"what_failed": "Crashed on every call, broken output, errors everywhere, unusable",
+	})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("submit: %d %s", resp.Code, resp.Body.String())
+	}
+	var out SubmitReviewOutput
+	decodeBody(t, resp, &out.Body)
+	return out
+}
+
+func TestReviewModeration(t *testing.T) {
+	f := newReviewFixture(t)
+	addCollection(t, f.app, "messages", "agent_id", "type", "subject", "body", "read:bool", "ref_type", "ref_id")
+	addCollection(t, f.app, "admin_audit", "admin_id", "action", "target_type", "target_id", "details:json")
+	RegisterReviewModerationRoutes(f.api, f.app)
+	admin := addSuperuser(t, f.app)
+	alice, bob := f.agent(t, "alice"), f.agent(t, "bob")
+
+	// Held reviews are stored but not counted
+	held := f.submitContradictory(t, alice)
+	if !held.Body.HeldForModeration || held.Body.ModerationReason == "" {
+		t.Fatalf("contradictory review not held: %+v", held.Body)
+	}
+	rejected := f.submitContradictory(t, bob)
+	if n, _, _ := f.stats(t); n != 0 {
+		t.Errorf("review_count %d with only held reviews", n)
+	}
+
+	// Only admins see the queue
+	_, user := addUser(t, f.app, "owner@example.com")
+	if resp := f.api.Get("/api/admin/reviews/moderation", user); resp.Code != http.StatusForbidden {
+		t.Errorf("queue as a user: %d", resp.Code)
+	}
+	resp := f.api.Get("/api/admin/reviews/moderation", admin)
+	var queue ModerationQueueOutput
+	decodeBody(t, resp, &queue.Body)
+	if queue.Body.Total != 2 || len(queue.Body.Reviews) != 2 || queue.Body.Reviews[0].ID != held.Body.ReviewID {
+		t.Fatalf("queue %+v", queue.Body)
+	}
+
+	// Approving counts the review
+	if resp := f.api.Post("/api/admin/reviews/"+held.Body.ReviewID+"/approve", admin, map[string]any{}); resp.Code != http.StatusOK {
+		t.Fatalf("approve: %d %s", resp.Code, resp.Body.String())
+	}
+	if n, avg, _ := f.stats(t); n != 1 || avg != 10 {
+		t.Errorf("after approval: %d reviews, avg %g", n, avg)
+	}
+	if resp := f.api.Post("/api/admin/reviews/"+held.Body.ReviewID+"/approve", admin, map[string]any{}); resp.Code != http.StatusConflict {
+		t.Errorf("second approval: %d", resp.Code)
+	}
+
+	// Rejecting fails the review and tells the reviewer
+	resp = f.api.Post("/api/admin/reviews/"+rejected.Body.ReviewID+"/reject", admin, map[string]any{"note": "score looks like a typo"})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("reject: %d %s", resp.Code, resp.Body.String())
+	}
+	review, err := f.app.FindRecordById("reviews", rejected.Body.ReviewID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review.GetString("status") != "failed" || review.GetBool("needs_moderation") {
+		t.Errorf("rejected review: status %q, held %v", review.GetString("status"), review.GetBool("needs_moderation"))
+	}
+	msgs, _ := f.app.FindRecordsByFilter("messages", "agent_id = {:aid} && type = 'review_rejected'", "", 0, 0,
+		map[string]any{"aid": bob})
+	if len(msgs) != 1 || msgs[0].GetString("ref_id") != review.Id {
+		t.Fatalf("%d rejection messages", len(msgs))
+	}
+	if n, _, _ := f.stats(t); n != 1 {
+		t.Errorf("review_count %d after rejection", n)
+	}
+
+	audit, _ := f.app.FindRecordsByFilter("admin_audit", "action ~ 'review.'", "", 0, 0, nil)
+	if len(audit) != 2 {
+		t.Errorf("%d audit entries, want 2", len(audit))
+	}
+	decodeBody(t, f.api.Get("/api/admin/reviews/moderation", admin), &queue.Body)
+	if queue.Body.Total != 0 {
+		t.Errorf("%d left in the queue", queue.Body.Total)
+	}
+}
+
+func TestReviewConsistencyThresholdsConfig(t *testing.T) {
+	f := newReviewFixture(t)
+	addCollection(t, f.app, "platform_config", "review_consistency_high_score:number",
+		"review_consistency_low_score:number", "review_consistency_min_terms:number", "review_consistency_dominance:number")
+
+	// Raising the bar for "top quartile" above 10 lets the same review through
+	addRecord(t, f.app, "platform_config", map[string]any{"review_consistency_high_score": 11})
+	resp := f.api.Post("/api/reviews/submit", bearer(t, f.kr, f.agent(t, "alice")), map[string]any{
+		"skill_id": f.skill.Id, "task": "forecast for Paris", "score": 10,
+		"what_failed": "Crashed on every call, broken output, errors everywhere, unusable",
+	})
+	var out SubmitReviewOutput
+	decodeBody(t, resp, &out.Body)
+	if resp.Code != http.StatusCreated || out.Body.HeldForModeration {
+		t.Errorf("with high_score 11: %d, held %v", resp.Code, out.Body.HeldForModeration)
+	}
+	if th := reviewConsistencyThresholds(f.app); th.HighScore != 11 || th.LowScore != 3 || th.MinTerms != 3 {
+		t.Errorf("unset fields should keep their defaults: %+v", th)
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+)
+
+// -----------------------------------------------------------------------------
+// Review submission size limits and cli_output spill-over
+// -----------------------------------------------------------------------------
+
+// A review carries the whole CLI log, which used to be stored inline and made
+// the reviews collection heavy. Output over cliOutputInlineMax is saved as a
+// text/plain artifact of the review; the review keeps the first
+// cliOutputPreviewMax bytes and a pointer to the artifact.
+
+const (
+	reviewSubmitMaxBytes = 8 << 20
+	cliOutputMaxChars    = 100000
+	cliOutputInlineMax   = 20 * 1024
+	cliOutputPreviewMax  = 4 * 1024
+	cliOutputFileName    = "cli_output.txt"
+)
+
+// reviewSubmitLimitsMessage is the 413 body for oversized submissions.
+var reviewSubmitLimitsMessage = fmt.Sprintf(
+	"Review submission is too large. Limits: request body %dMB, cli_output %d characters "+
+		"(output over %dKB is stored as a downloadable artifact with a %dKB preview on the review).",
+	reviewSubmitMaxBytes>>20, cliOutputMaxChars, cliOutputInlineMax>>10, cliOutputPreviewMax>>10)
+
+// rejectOversizedReview answers 413 with the limits before the body is read,
+// when Content-Length gives it away. Bodies without one are still capped by
+// the operation's MaxBodyBytes.
+func rejectOversizedReview(api huma.API) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		if n, err := strconv.ParseInt(ctx.Header("Content-Length"), 10, 64); err == nil && n > reviewSubmitMaxBytes {
+			huma.WriteErr(api, ctx, http.StatusRequestEntityTooLarge, reviewSubmitLimitsMessage)
+			return
+		}
+		next(ctx)
+	}
+}
+
+// cliOutputPreview returns the first cliOutputPreviewMax bytes of out, cut on
+// a UTF-8 boundary.
+func cliOutputPreview(out string) string {
+	if len(out) <= cliOutputPreviewMax {
+		return out
+	}
+	cut := cliOutputPreviewMax
+	for cut > 0 && !utf8.RuneStart(out[cut]) {
+		cut--
+	}
+	return out[:cut]
+}
+
+// storeCLIOutputArtifact saves the full cli_output as a text/plain artifact
+// of review and points the review at it. The caller has already set the
+// preview on the review.
+func storeCLIOutputArtifact(app core.App, review *core.Record, out string) error {
+	col, err := app.FindCollectionByNameOrId("artifacts")
+	if err != nil {
+		return err
+	}
+	file, err := filesystem.NewFileFromBytes([]byte(out), cliOutputFileName)
+	if err != nil {
+		return err
+	}
+	return app.RunInTransaction(func(txApp core.App) error {
+		artifact := core.NewRecord(col)
+		artifact.Set("review", review.Id)
+		artifact.Set("file", file)
+		artifact.Set("file_name", cliOutputFileName)
+		artifact.Set("mime_type", "text/plain; charset=utf-8")
+		artifact.Set("size_bytes", len(out))
+		if err := txApp.Save(artifact); err != nil {
+			return fmt.Errorf("save artifact: %w", err)
+		}
+		review.Set("cli_output_artifact", artifact.Id)
+		return txApp.Save(review)
+	})
+}
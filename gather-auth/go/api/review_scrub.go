@@ -0,0 +1,42 @@
+package api
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/scrub"
+)
+
+// -----------------------------------------------------------------------------
+// Review scrubbing
+// -----------------------------------------------------------------------------
+
+// Agents paste whole terminal sessions into cli_output, and with them API
+// keys, email addresses and home directories, all publicly readable through
+// GET /api/reviews/{id}. Before a review is stored, cli_output (and so the
+// artifact it spills into) and security_notes go through package scrub,
+// and the response says what was redacted. Admins can switch this off with
+// review_scrub_disabled in platform_config; submitters can't.
+
+// reviewScrubEnabled reports whether submitted reviews are scrubbed. It fails
+// closed: without a readable platform_config, they are.
+func reviewScrubEnabled(app core.App) bool {
+	cfg, err := loadPlatformConfig(app)
+	if err != nil {
+		return true
+	}
+	return cfg.GetInt("review_scrub_disabled") == 0
+}
+
+// scrubReviewText redacts cli_output and security_notes, returning what it
+// replaced.
+func scrubReviewText(app core.App, cliOutput, securityNotes string) (string, string, scrub.Counts) {
+	counts := scrub.Counts{}
+	if !reviewScrubEnabled(app) {
+		return cliOutput, securityNotes, counts
+	}
+	cliOutput, c := scrub.Text(cliOutput)
+	counts.Add(c)
+	securityNotes, c = scrub.Text(securityNotes)
+	counts.Add(c)
+	return cliOutput, securityNotes, counts
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
 
+	auth "gather.is/auth"
+	"gather.is/auth/reputation"
 	"gather.is/auth/skills"
 )
 
@@ -54,7 +56,7 @@ type SubmitReviewInput struct {
 		RunnerType      string                   `json:"runner_type,omitempty" doc:"Executor type (claude, aider, etc.)"`
 		PermissionMode  string                   `json:"permission_mode,omitempty" doc:"Permission mode used"`
 		ExecutionTimeMs *float64                 `json:"execution_time_ms,omitempty" doc:"Execution time in milliseconds"`
-		CLIOutput       string                   `json:"cli_output,omitempty" doc:"Raw CLI output"`
+		CLIOutput       string                   `json:"cli_output,omitempty" doc:"Raw CLI output. Over 20KB it is stored as an artifact and the review keeps a 4KB preview." maxLength:"100000"`
 		Proof           *ClientProof             `json:"proof,omitempty" doc:"Client-side execution proof"`
 		Artifacts       []ClientArtifact         `json:"artifacts,omitempty" doc:"File artifacts from execution"`
 		ChallengeID     string                   `json:"challenge_id,omitempty" doc:"Challenge ID from POST /api/reviews/challenge"`
@@ -79,14 +81,24 @@ type ClientArtifact struct {
 type SubmitReviewOutput struct {
 	Status int `header:"Status"`
 	Body   struct {
-		Message          string  `json:"message"`
-		ReviewID         string  `json:"review_id"`
-		SkillID          string  `json:"skill_id"`
-		Score            float64 `json:"score"`
-		ProofID          string  `json:"proof_id"`
-		ArtifactCount    int     `json:"artifact_count"`
-		VerifiedReviewer bool    `json:"verified_reviewer"`
-		Challenged       bool    `json:"challenged"`
+		Message            string         `json:"message"`
+		ReviewID           string         `json:"review_id"`
+		SkillID            string         `json:"skill_id"`
+		Score              float64        `json:"score"`
+		ProofID            string         `json:"proof_id"`
+		ArtifactCount      int            `json:"artifact_count"`
+		CLIOutputTruncated bool           `json:"cli_output_truncated" doc:"cli_output was over 20KB: the review keeps a preview and the full output is an artifact"`
+		VerifiedReviewer   bool           `json:"verified_reviewer"`
+		Challenged         bool           `json:"challenged"`
+		Replaced           bool           `json:"replaced" doc:"This replaced your earlier review of the skill (one review per agent per skill per 30 days)"`
+		ProofVerified      bool           `json:"proof_verified"`
+		ProofFailedStep    string         `json:"proof_failed_step,omitempty" doc:"key_mismatch, hash_mismatch, or signature_invalid"`
+		ProofMessage       string         `json:"proof_message,omitempty"`
+		ExpectedHash       string         `json:"expected_execution_hash,omitempty" doc:"Returned on hash_mismatch: the hash the server computed"`
+		HeldForModeration  bool           `json:"held_for_moderation,omitempty" doc:"The score contradicts what_worked/what_failed; the review won't count until a moderator approves it"`
+		ModerationReason   string         `json:"moderation_reason,omitempty"`
+		Redactions         int            `json:"redactions" doc:"Secrets and personal details (API keys, tokens, email addresses, home directory paths) replaced with [REDACTED:kind] in cli_output and security_notes before storage"`
+		RedactionsByKind   map[string]int `json:"redactions_by_kind,omitempty" doc:"Redactions per kind, e.g. aws_key, jwt, email, home_path"`
 	}
 }
 
@@ -98,6 +110,7 @@ type ReviewArtifactSummary struct {
 	ID       string `json:"id"`
 	FileName string `json:"file_name"`
 	MimeType string `json:"mime_type,omitempty"`
+	URL      string `json:"url" doc:"Download link; artifacts are always served as attachments"`
 }
 
 type ReviewProofSummary struct {
@@ -108,63 +121,88 @@ type ReviewProofSummary struct {
 
 type GetReviewOutput struct {
 	Body struct {
-		ID               string                  `json:"id"`
-		Skill            string                  `json:"skill"`
-		SkillName        string                  `json:"skill_name,omitempty"`
-		AgentID          string                  `json:"agent_id,omitempty"`
-		Task             string                  `json:"task"`
-		Status           string                  `json:"status"`
-		Score            *float64                `json:"score"`
-		WhatWorked       string                  `json:"what_worked,omitempty"`
-		WhatFailed       string                  `json:"what_failed,omitempty"`
-		SkillFeedback    string                  `json:"skill_feedback,omitempty"`
-		SecurityScore    *float64                `json:"security_score"`
-		SecurityNotes    string                  `json:"security_notes,omitempty"`
-		RunnerType       string                  `json:"runner_type,omitempty"`
-		PermissionMode   string                  `json:"permission_mode,omitempty"`
-		AgentModel       string                  `json:"agent_model,omitempty"`
-		ExecutionTimeMs  *float64                `json:"execution_time_ms"`
-		CLIOutput        string                  `json:"cli_output,omitempty"`
-		VerifiedReviewer bool                    `json:"verified_reviewer"`
-		Challenged       bool                    `json:"challenged"`
-		Created          string                  `json:"created"`
-		Artifacts        []ReviewArtifactSummary `json:"artifacts,omitempty"`
-		Proof            *ReviewProofSummary     `json:"proof,omitempty"`
+		ID                 string                  `json:"id"`
+		Skill              string                  `json:"skill"`
+		SkillName          string                  `json:"skill_name,omitempty"`
+		AgentID            string                  `json:"agent_id,omitempty"`
+		Task               string                  `json:"task"`
+		Status             string                  `json:"status"`
+		Score              *float64                `json:"score"`
+		WhatWorked         string                  `json:"what_worked,omitempty"`
+		WhatFailed         string                  `json:"what_failed,omitempty"`
+		SkillFeedback      string                  `json:"skill_feedback,omitempty"`
+		SecurityScore      *float64                `json:"security_score"`
+		SecurityNotes      string                  `json:"security_notes,omitempty"`
+		RunnerType         string                  `json:"runner_type,omitempty"`
+		PermissionMode     string                  `json:"permission_mode,omitempty"`
+		AgentModel         string                  `json:"agent_model,omitempty"`
+		ExecutionTimeMs    *float64                `json:"execution_time_ms"`
+		CLIOutput          string                  `json:"cli_output,omitempty" doc:"Full output, or the first 4KB when cli_output_truncated"`
+		CLIOutputTruncated bool                    `json:"cli_output_truncated"`
+		CLIOutputSize      int                     `json:"cli_output_size,omitempty" doc:"Size in bytes of the full output, when truncated"`
+		CLIOutputURL       string                  `json:"cli_output_url,omitempty" doc:"Download link for the full output, when truncated"`
+		VerifiedReviewer   bool                    `json:"verified_reviewer"`
+		Challenged         bool                    `json:"challenged"`
+		Created            string                  `json:"created"`
+		Artifacts          []ReviewArtifactSummary `json:"artifacts,omitempty"`
+		Proof              *ReviewProofSummary     `json:"proof,omitempty"`
 	}
 }
 
 type ListReviewsInput struct {
-	Limit  int    `query:"limit" default:"20" minimum:"1" maximum:"100" doc:"Max results"`
-	Status string `query:"status" doc:"Filter by status (pending, running, complete, failed)"`
+	Limit   int    `query:"limit" default:"20" minimum:"1" maximum:"100" doc:"Max results"`
+	Status  string `query:"status" doc:"Filter by status (pending, running, complete, failed)"`
+	AgentID string `query:"agent_id" doc:"Filter by reviewing agent ID"`
+	ProjectionParams
 }
 
 type ReviewListItem struct {
-	ID               string   `json:"id"`
-	Skill            string   `json:"skill"`
-	SkillName        string   `json:"skill_name,omitempty"`
-	Task             string   `json:"task"`
-	Status           string   `json:"status"`
-	Score            *float64 `json:"score"`
-	VerifiedReviewer bool     `json:"verified_reviewer"`
-	Challenged       bool     `json:"challenged"`
-	Created          string   `json:"created"`
+	ID                string   `json:"id"`
+	Skill             string   `json:"skill"`
+	SkillName         string   `json:"skill_name,omitempty"`
+	Task              string   `json:"task"`
+	Status            string   `json:"status"`
+	Score             *float64 `json:"score"`
+	VerifiedReviewer  bool     `json:"verified_reviewer"`
+	Challenged        bool     `json:"challenged"`
+	ProofVerified     bool     `json:"proof_verified" doc:"Review carries a verified Ed25519 execution proof"`
+	HeldForModeration bool     `json:"held_for_moderation,omitempty" doc:"Not counted towards scores until a moderator approves it"`
+	Created           string   `json:"created"`
 }
 
 type ListReviewsOutput struct {
+	Body struct {
+		Reviews         projectedList[ReviewListItem] `json:"reviews"`
+		EstimatedTokens int                           `json:"estimated_tokens" doc:"Approximate token size of this response"`
+	}
+}
+
+type AgentReviewsInput struct {
+	ID     string `path:"id" doc:"Agent ID"`
+	Limit  int    `query:"limit" default:"20" minimum:"1" maximum:"100"`
+	Offset int    `query:"offset" default:"0" minimum:"0"`
+}
+
+type AgentReviewsOutput struct {
 	Body struct {
 		Reviews []ReviewListItem `json:"reviews"`
+		Total   int              `json:"total"`
+		Limit   int              `json:"limit"`
+		Offset  int              `json:"offset"`
 	}
 }
 
 // Review challenge types
 
 type ChallengeSkillInfo struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description,omitempty"`
-	Category    string  `json:"category,omitempty"`
-	URL         string  `json:"url,omitempty"`
-	ReviewCount float64 `json:"review_count"`
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description,omitempty"`
+	Category        string  `json:"category,omitempty"`
+	URL             string  `json:"url,omitempty"`
+	ReviewCount     float64 `json:"review_count"`
+	InstallRequired bool    `json:"install_required"`
+	SkillMetadata
 }
 
 type RequestChallengeInput struct {
@@ -177,13 +215,14 @@ type RequestChallengeInput struct {
 type RequestChallengeOutput struct {
 	Status int `header:"Status"`
 	Body   struct {
-		ChallengeID string             `json:"challenge_id"`
-		Totem       string             `json:"totem"`
-		Task        string             `json:"task"`
-		Aspects     []string           `json:"aspects"`
-		ExpiresAt   string             `json:"expires_at"`
-		ExpiresIn   string             `json:"expires_in"`
-		Skill       ChallengeSkillInfo `json:"skill"`
+		ChallengeID    string             `json:"challenge_id"`
+		Totem          string             `json:"totem"`
+		Task           string             `json:"task"`
+		Aspects        []string           `json:"aspects"`
+		ExpiresAt      string             `json:"expires_at"`
+		ExpiresIn      string             `json:"expires_in"`
+		Skill          ChallengeSkillInfo `json:"skill"`
+		ReplacesReview string             `json:"replaces_review,omitempty" doc:"Your review of this skill from the last 30 days, which submitting this challenge will replace"`
 	}
 }
 
@@ -191,7 +230,7 @@ type RequestChallengeOutput struct {
 // Route registration
 // -----------------------------------------------------------------------------
 
-func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte) {
+func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
 	// Create review (server-side execution — disabled)
 	huma.Register(api, huma.Operation{
 		OperationID:   "create-review",
@@ -209,13 +248,18 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 
 	// Submit completed review from CLI
 	huma.Register(api, huma.Operation{
-		OperationID:   "submit-review",
-		Method:        "POST",
-		Path:          "/api/reviews/submit",
-		Summary:       "Submit a completed review",
-		Description:   "Submit a review with optional Ed25519 cryptographic proof. Requires JWT authentication.",
+		OperationID: "submit-review",
+		Method:      "POST",
+		Path:        "/api/reviews/submit",
+		Summary:     "Submit a completed review",
+		Description: "Submit a review with optional Ed25519 cryptographic proof. Requires JWT authentication. " +
+			"An agent has one review per skill per 30 days: resubmitting within that window replaces the earlier review (status 200, replaced=true) and the skill's scores are recomputed. " +
+			"Reviewing a skill you registered is refused with 403 and error code self_review. " +
+			"Secrets and personal details in cli_output and security_notes are replaced with [REDACTED:kind] before storage; redactions in the response counts them.",
 		Tags:          []string{"Reviews"},
 		DefaultStatus: 201,
+		MaxBodyBytes:  reviewSubmitMaxBytes,
+		Middlewares:   huma.Middlewares{rejectOversizedReview(api)},
 	}, func(ctx context.Context, input *SubmitReviewInput) (*SubmitReviewOutput, error) {
 		claims, err := RequireJWT(input.Authorization, jwtKey)
 		if err != nil {
@@ -223,18 +267,22 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 		}
 
 		// Look up skill by name or by ID (matching challenge handler logic)
-		skill, _ := app.FindFirstRecordByData("skills", "name", input.Body.SkillID)
-		if skill == nil {
-			skill, _ = app.FindRecordById("skills", input.Body.SkillID)
-		}
+		skill := resolveSkill(app, input.Body.SkillID)
+		autoCreated := false
 		if skill == nil {
 			// Auto-create only if not found by name or ID
-			ensureSkillExists(app, input.Body.SkillID)
-			skill, _ = app.FindFirstRecordByData("skills", "name", input.Body.SkillID)
+			b := input.Body
+			skill, autoCreated = autoCreateSkill(app, b.SkillID,
+				strings.Join([]string{b.Task, b.WhatWorked, b.WhatFailed, b.SkillFeedback}, " "), claims.AgentID)
 		}
 		skillRef := ""
+		var previous *core.Record
 		if skill != nil {
+			if skill.GetString("owner_id") == claims.AgentID {
+				return nil, selfReviewError(skill)
+			}
 			skillRef = skill.Id
+			previous = recentAgentReview(app, skill.Id, claims.AgentID)
 		}
 
 		runnerType := input.Body.RunnerType
@@ -259,8 +307,17 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 			return nil, huma.Error500InternalServerError("reviews collection not found")
 		}
 
-		record := core.NewRecord(collection)
+		// Within the window an agent's earlier review is replaced in place
+		record := previous
+		if record == nil {
+			record = core.NewRecord(collection)
+		} else {
+			resetReview(record)
+		}
 		record.Set("skill", skillRef)
+		if skill != nil {
+			record.Set("skill_name", skill.GetString("name"))
+		}
 		record.Set("agent_id", claims.AgentID)
 		record.Set("task", input.Body.Task)
 		record.Set("status", "complete")
@@ -271,15 +328,23 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 		if input.Body.SecurityScore != nil {
 			record.Set("security_score", *input.Body.SecurityScore)
 		}
-		record.Set("security_notes", input.Body.SecurityNotes)
+		cliOutput, securityNotes, redactions := scrubReviewText(app, input.Body.CLIOutput, input.Body.SecurityNotes)
+		record.Set("security_notes", securityNotes)
 		record.Set("runner_type", runnerType)
 		record.Set("permission_mode", permissionMode)
 		record.Set("agent_model", "claude-sonnet")
 		if input.Body.ExecutionTimeMs != nil {
 			record.Set("execution_time_ms", *input.Body.ExecutionTimeMs)
 		}
-		record.Set("cli_output", input.Body.CLIOutput)
+		spillOutput := len(cliOutput) > cliOutputInlineMax
+		if spillOutput {
+			record.Set("cli_output", cliOutputPreview(cliOutput))
+			record.Set("cli_output_size", len(cliOutput))
+		} else {
+			record.Set("cli_output", cliOutput)
+		}
 		record.Set("verified_reviewer", isVerified)
+		moderationReason := flagInconsistentReview(app, record)
 
 		// Validate review challenge if provided
 		challenged := false
@@ -297,6 +362,9 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 			if challenge.GetBool("used") {
 				return nil, huma.Error400BadRequest("Challenge has already been used")
 			}
+			if next := challenge.GetString("replaced_by"); next != "" {
+				return nil, huma.Error400BadRequest("Challenge was declined and replaced; submit with challenge " + next)
+			}
 			expiresStr := challenge.GetString("expires")
 			if expiresStr != "" {
 				if expires, err := time.Parse(time.RFC3339, expiresStr); err == nil {
@@ -321,19 +389,39 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 		if err := app.Save(record); err != nil {
 			return nil, huma.Error500InternalServerError("Failed to create review")
 		}
+		if previous != nil {
+			deleteReviewAttachments(app, record.Id)
+		}
+
+		if spillOutput {
+			if err := storeCLIOutputArtifact(app, record, cliOutput); err != nil {
+				// Still fits inline (cli_output is capped at 100,000 characters)
+				app.Logger().Warn("Failed to store cli_output artifact, keeping it inline", "review", record.Id, "error", err)
+				spillOutput = false
+				record.Set("cli_output", cliOutput)
+				record.Set("cli_output_size", 0)
+				record.Set("cli_output_artifact", "")
+				app.Save(record)
+			}
+		}
 
 		// Handle proof — verify against agent's registered key
 		proofID := ""
+		var proofCheck *skills.ProofCheck
 		if p := input.Body.Proof; p != nil && p.Signature != "" && p.ExecutionHash != "" {
-			proofVerified := false
-			if agentPubKey != "" && p.PublicKey == agentPubKey {
-				proofVerified = skills.VerifyAttestation(p.ExecutionHash, p.Signature, p.PublicKey)
-			}
-			proofID = createClientProof(app, record.Id, p, proofVerified)
+			check := skills.VerifyReviewProof(skills.ReviewClaim{
+				Score:      input.Body.Score,
+				SkillID:    input.Body.SkillID,
+				Task:       input.Body.Task,
+				WhatFailed: input.Body.WhatFailed,
+				WhatWorked: input.Body.WhatWorked,
+			}, p.ExecutionHash, p.Signature, p.PublicKey, agentPubKey)
+			proofCheck = &check
+			proofID = createClientProof(app, record.Id, p, check.Verified)
 		}
 		if proofID == "" {
 			// Generate server-side attestation
-			proofID = createServerProof(app, record.Id, input.Body.SkillID, input.Body.Task, input.Body.CLIOutput, input.Body.Score, input.Body.WhatWorked, input.Body.WhatFailed, input.Body.ExecutionTimeMs)
+			proofID = createServerProof(app, record.Id, input.Body.SkillID, input.Body.Task, cliOutput, input.Body.Score, input.Body.WhatWorked, input.Body.WhatFailed, input.Body.ExecutionTimeMs)
 		}
 
 		if proofID != "" {
@@ -343,18 +431,49 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 
 		// Update skill stats
 		if skill != nil {
-			updateSkillStatsFromAPI(app, skill.Id)
+			skills.UpdateSkillStats(app, skill.Id)
+		}
+		if autoCreated {
+			notifyAutoCreatedSkill(app, skill, claims.AgentID)
 		}
+		reputation.UpdateAgentReputation(app, claims.AgentID)
 
 		out := &SubmitReviewOutput{}
 		out.Status = 201
 		out.Body.Message = "Review submitted successfully"
+		if previous != nil {
+			out.Status = 200
+			out.Body.Message = "Review updated: it replaces your review of this skill from the last 30 days"
+			out.Body.Replaced = true
+		}
 		out.Body.ReviewID = record.Id
 		out.Body.SkillID = input.Body.SkillID
 		out.Body.Score = input.Body.Score
 		out.Body.ProofID = proofID
+		if spillOutput {
+			out.Body.ArtifactCount = 1
+			out.Body.CLIOutputTruncated = true
+		}
 		out.Body.VerifiedReviewer = isVerified
 		out.Body.Challenged = challenged
+		if moderationReason != "" {
+			out.Body.HeldForModeration = true
+			out.Body.ModerationReason = moderationReason
+			out.Body.Message += ". It's held for moderation and won't count towards the skill's scores until a moderator approves it"
+		}
+		if n := redactions.Total(); n > 0 {
+			out.Body.Redactions = n
+			out.Body.RedactionsByKind = redactions
+			out.Body.Message += fmt.Sprintf(". %d secret(s) or personal detail(s) were redacted before storage (%s)", n, redactions)
+		}
+		if proofCheck != nil {
+			out.Body.ProofVerified = proofCheck.Verified
+			out.Body.ProofFailedStep = proofCheck.FailedStep
+			out.Body.ProofMessage = proofCheck.Message
+			if proofCheck.FailedStep == skills.ProofStepHashMismatch {
+				out.Body.ExpectedHash = proofCheck.ExpectedHash
+			}
+		}
 		return out, nil
 	})
 
@@ -386,9 +505,14 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 		out.Body.PermissionMode = review.GetString("permission_mode")
 		out.Body.AgentModel = review.GetString("agent_model")
 		out.Body.CLIOutput = review.GetString("cli_output")
+		if artifactID := review.GetString("cli_output_artifact"); artifactID != "" {
+			out.Body.CLIOutputTruncated = true
+			out.Body.CLIOutputSize = int(review.GetFloat("cli_output_size"))
+			out.Body.CLIOutputURL = artifactURL(review.Id, artifactID)
+		}
 		out.Body.VerifiedReviewer = review.GetBool("verified_reviewer")
 		out.Body.Challenged = review.GetString("challenge") != ""
-		out.Body.Created = fmt.Sprintf("%v", review.GetDateTime("created"))
+		out.Body.Created = recordTime(review, "created")
 
 		if v := review.GetFloat("score"); v > 0 {
 			out.Body.Score = &v
@@ -400,12 +524,7 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 			out.Body.ExecutionTimeMs = &v
 		}
 
-		// Get skill name
-		if skillID := review.GetString("skill"); skillID != "" {
-			if skillRec, err := app.FindRecordById("skills", skillID); err == nil {
-				out.Body.SkillName = skillRec.GetString("name")
-			}
-		}
+		out.Body.SkillName = reviewSkillName(review, reviewSkillNames(app, []*core.Record{review}))
 
 		// Get artifacts
 		artifacts, _ := app.FindRecordsByFilter("artifacts",
@@ -416,6 +535,7 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 				ID:       a.Id,
 				FileName: a.GetString("file_name"),
 				MimeType: a.GetString("mime_type"),
+				URL:      artifactURL(review.Id, a.Id),
 			})
 		}
 
@@ -425,7 +545,7 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 				out.Body.Proof = &ReviewProofSummary{
 					ID:       proof.Id,
 					Verified: proof.GetBool("verified"),
-					Created:  fmt.Sprintf("%v", proof.GetDateTime("created")),
+					Created:  recordTime(proof, "created"),
 				}
 			}
 		}
@@ -439,7 +559,7 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 		Method:      "GET",
 		Path:        "/api/reviews",
 		Summary:     "List recent reviews",
-		Description: "Returns recent reviews, optionally filtered by status.",
+		Description: "Returns recent reviews, optionally filtered by status. Use ?fields= or ?compact=true to return less per review.",
 		Tags:        []string{"Reviews"},
 	}, func(ctx context.Context, input *ListReviewsInput) (*ListReviewsOutput, error) {
 		filter := "id != ''"
@@ -449,47 +569,70 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 			filter += " && status = {:status}"
 			params["status"] = input.Status
 		}
+		if input.AgentID != "" {
+			filter += " && agent_id = {:aid}"
+			params["aid"] = input.AgentID
+		}
 
 		records, err := app.FindRecordsByFilter("reviews", filter, "", input.Limit, 0, params)
 		if err != nil {
 			records = nil
 		}
 
-		items := make([]ReviewListItem, 0, len(records))
-		for _, r := range records {
-			item := ReviewListItem{
-				ID:               r.Id,
-				Skill:            r.GetString("skill"),
-				Task:             r.GetString("task"),
-				Status:           r.GetString("status"),
-				VerifiedReviewer: r.GetBool("verified_reviewer"),
-				Challenged:       r.GetString("challenge") != "",
-				Created:          fmt.Sprintf("%v", r.GetDateTime("created")),
-			}
-			if v := r.GetFloat("score"); v > 0 {
-				item.Score = &v
-			}
-			// Get skill name
-			if skillID := r.GetString("skill"); skillID != "" {
-				if skillRec, err := app.FindRecordById("skills", skillID); err == nil {
-					item.SkillName = skillRec.GetString("name")
-				}
-			}
-			items = append(items, item)
+		reviews, err := project(buildReviewListItems(app, records), reviewListProjection, input.ProjectionParams)
+		if err != nil {
+			return nil, err
 		}
 
 		out := &ListReviewsOutput{}
-		out.Body.Reviews = items
+		out.Body.Reviews = reviews
+		out.Body.EstimatedTokens = estimateTokens(out.Body)
+		return out, nil
+	})
+
+	// List an agent's completed reviews
+	huma.Register(api, huma.Operation{
+		OperationID: "list-agent-reviews",
+		Method:      "GET",
+		Path:        "/api/agents/{id}/reviews",
+		Summary:     "List an agent's reviews",
+		Description: "Completed reviews written by an agent, newest first, with skill names and challenged/verified flags. " +
+			"Use it to judge whether a reviewer's track record is worth trusting.",
+		Tags: []string{"Reviews"},
+	}, func(ctx context.Context, input *AgentReviewsInput) (*AgentReviewsOutput, error) {
+		agent, err := app.FindRecordById("agents", input.ID)
+		if err != nil || agent.GetBool("suspended") {
+			return nil, huma.Error404NotFound("Agent not found")
+		}
+
+		filter := "agent_id = {:aid} && status = 'complete'"
+		params := map[string]any{"aid": agent.Id}
+		records, err := app.FindRecordsByFilter("reviews", filter, "-created", input.Limit, input.Offset, params)
+		if err != nil {
+			records = nil
+		}
+		total := len(records)
+		if all, err := app.FindRecordsByFilter("reviews", filter, "", 0, 0, params); err == nil {
+			total = len(all)
+		}
+
+		out := &AgentReviewsOutput{}
+		out.Body.Reviews = buildReviewListItems(app, records)
+		out.Body.Total = total
+		out.Body.Limit = input.Limit
+		out.Body.Offset = input.Offset
 		return out, nil
 	})
 
 	// Request a review challenge
 	huma.Register(api, huma.Operation{
-		OperationID:   "request-review-challenge",
-		Method:        "POST",
-		Path:          "/api/reviews/challenge",
-		Summary:       "Request a review challenge",
-		Description:   "Get a unique totem and targeted review task for a skill. The challenge must be completed within 15 minutes. Challenge-verified reviews carry more weight in the marketplace.",
+		OperationID: "request-review-challenge",
+		Method:      "POST",
+		Path:        "/api/reviews/challenge",
+		Summary:     "Request a review challenge",
+		Description: "Get a unique totem and targeted review task for a skill. The challenge must be completed within 15 minutes. Challenge-verified reviews carry more weight in the marketplace. " +
+			"Refused with 403 and error code self_review for a skill you registered; replaces_review is set when submitting will replace your review from the last 30 days. " +
+			"At most 5 unused, unexpired challenges per agent. Can't do the task? Decline it once with POST /api/reviews/challenge/{id}/regenerate.",
 		Tags:          []string{"Reviews"},
 		DefaultStatus: 201,
 	}, func(ctx context.Context, input *RequestChallengeInput) (*RequestChallengeOutput, error) {
@@ -499,62 +642,30 @@ func RegisterReviewRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byt
 		}
 
 		// Look up skill by name or by ID
-		var skill *core.Record
-		skill, _ = app.FindFirstRecordByData("skills", "name", input.Body.SkillID)
-		if skill == nil {
-			skill, _ = app.FindRecordById("skills", input.Body.SkillID)
-		}
+		skill := resolveSkill(app, input.Body.SkillID)
 		if skill == nil {
 			return nil, huma.Error404NotFound("Skill not found")
 		}
+		if skill.GetString("owner_id") == claims.AgentID {
+			return nil, selfReviewError(skill)
+		}
+		previous := recentAgentReview(app, skill.Id, claims.AgentID)
+		if err := checkOutstandingChallenges(app, claims.AgentID, ""); err != nil {
+			return nil, err
+		}
 
-		totem := generateTotem()
-		existingReviews, _ := app.FindRecordsByFilter("reviews",
-			"skill = {:sid} && status = 'complete'", "", 0, 0,
-			map[string]any{"sid": skill.Id})
-		task, aspects := generateReviewTask(app, skill, existingReviews)
-		expiresAt := time.Now().Add(15 * time.Minute).UTC().Format(time.RFC3339)
-
-		// Persist challenge
-		collection, err := app.FindCollectionByNameOrId("review_challenges")
+		record, err := newReviewChallenge(app, claims.AgentID, skill, nil)
 		if err != nil {
-			return nil, huma.Error500InternalServerError("review_challenges collection not found")
+			return nil, err
 		}
-
-		aspectsJSON, _ := json.Marshal(aspects)
-
-		record := core.NewRecord(collection)
-		record.Set("agent_id", claims.AgentID)
-		record.Set("skill", skill.Id)
-		record.Set("skill_name", skill.GetString("name"))
-		record.Set("totem", totem)
-		record.Set("task", task)
-		record.Set("aspects", string(aspectsJSON))
-		record.Set("expires", expiresAt)
-		record.Set("used", false)
-
 		if err := app.Save(record); err != nil {
 			return nil, huma.Error500InternalServerError("Failed to create challenge")
 		}
-
-		out := &RequestChallengeOutput{}
-		out.Status = 201
-		out.Body.ChallengeID = record.Id
-		out.Body.Totem = totem
-		out.Body.Task = task
-		out.Body.Aspects = aspects
-		out.Body.ExpiresAt = expiresAt
-		out.Body.ExpiresIn = "15 minutes"
-		out.Body.Skill = ChallengeSkillInfo{
-			ID:          skill.Id,
-			Name:        skill.GetString("name"),
-			Description: skill.GetString("description"),
-			Category:    skill.GetString("category"),
-			URL:         skill.GetString("url"),
-			ReviewCount: skill.GetFloat("review_count"),
-		}
-		return out, nil
+		return reviewChallengeOutput(record, skill, previous), nil
 	})
+
+	registerChallengeRegenerateRoute(api, app, jwtKey)
+	registerArtifactRoutes(api, app)
 }
 
 // -----------------------------------------------------------------------------
@@ -596,19 +707,21 @@ var securityAspects = []reviewAspect{
 // generateReviewTask builds a targeted review task for a skill.
 // With GEMINI_API_KEY set, it uses Gemini to generate contextual tasks.
 // Without it, falls back to template-based generation with mandatory security aspect.
-func generateReviewTask(app *pocketbase.PocketBase, skill *core.Record, existingReviews []*core.Record) (task string, aspects []string) {
+// declined, when set, is the task the agent turned down; the new one steers
+// away from it.
+func generateReviewTask(app *pocketbase.PocketBase, skill *core.Record, existingReviews []*core.Record, declined *declinedTask) (task string, aspects []string) {
 	// Try AI-driven generation first
-	if t, a, err := generateReviewTaskAI(skill, existingReviews); err == nil {
+	if t, a, err := generateReviewTaskAI(skill, existingReviews, declined); err == nil {
 		return t, a
 	} else if err.Error() != "GOOGLE_API_KEY not set" {
 		log.Printf("WARNING: AI task generation failed, using template fallback: %v", err)
 	}
 
-	return generateReviewTaskTemplate(skill)
+	return generateReviewTaskTemplate(skill, declined)
 }
 
 // generateReviewTaskAI uses Claude to create a contextual review task.
-func generateReviewTaskAI(skill *core.Record, existingReviews []*core.Record) (string, []string, error) {
+func generateReviewTaskAI(skill *core.Record, existingReviews []*core.Record, declined *declinedTask) (string, []string, error) {
 	name := skill.GetString("name")
 	desc := skill.GetString("description")
 	category := skill.GetString("category")
@@ -669,10 +782,16 @@ Rules:
 		userPromptParts = append(userPromptParts, fmt.Sprintf("Source: %s", source))
 	}
 	userPromptParts = append(userPromptParts, fmt.Sprintf("Install required: %v", installRequired))
+	if runtime := skill.GetString("runtime"); runtime != "" {
+		userPromptParts = append(userPromptParts, fmt.Sprintf("Runtime: %s", runtime))
+	}
 	userPromptParts = append(userPromptParts, fmt.Sprintf("Coverage: %s", coverageSummary))
 	if len(coveredAspects) > 0 {
 		userPromptParts = append(userPromptParts, fmt.Sprintf("Already covered: %s", strings.Join(coveredAspects, ", ")))
 	}
+	if declined != nil {
+		userPromptParts = append(userPromptParts, declined.prompt())
+	}
 
 	raw, err := callLLM(systemPrompt, strings.Join(userPromptParts, "\n"))
 	if err != nil {
@@ -696,11 +815,11 @@ Rules:
 
 // generateReviewTaskTemplate is the fallback when AI generation is unavailable.
 // Always picks 1 security aspect + 1 general/api aspect.
-func generateReviewTaskTemplate(skill *core.Record) (task string, aspects []string) {
+func generateReviewTaskTemplate(skill *core.Record, declined *declinedTask) (task string, aspects []string) {
 	category := skill.GetString("category")
 
 	// Pick 1 security aspect
-	secChosen := pickRandomAspects(securityAspects, 1)
+	secChosen := pickRandomAspects(declined.without(securityAspects), 1)
 
 	// Pick 1 general/api aspect
 	generalPool := make([]reviewAspect, len(generalAspects))
@@ -708,7 +827,7 @@ func generateReviewTaskTemplate(skill *core.Record) (task string, aspects []stri
 	if category == "api" || category == "service" {
 		generalPool = append(generalPool, apiAspects...)
 	}
-	genChosen := pickRandomAspects(generalPool, 1)
+	genChosen := pickRandomAspects(declined.without(generalPool), 1)
 
 	chosen := append(secChosen, genChosen...)
 	for _, a := range chosen {
@@ -755,29 +874,57 @@ func pickRandomAspects(pool []reviewAspect, n int) []reviewAspect {
 // Helpers
 // -----------------------------------------------------------------------------
 
-func ensureSkillExists(app *pocketbase.PocketBase, skillName string) {
-	existing, _ := app.FindFirstRecordByData("skills", "name", skillName)
-	if existing != nil {
-		return
-	}
+// reviewReplaceWindow is how long an agent's review of a skill stands: a
+// resubmission within it replaces that review instead of adding another.
+const reviewReplaceWindow = 30 * 24 * time.Hour
 
-	collection, err := app.FindCollectionByNameOrId("skills")
-	if err != nil {
-		return
-	}
+// selfReviewCode is the error code for reviewing a skill you registered.
+const selfReviewCode = "self_review"
 
-	record := core.NewRecord(collection)
-	record.Set("name", skillName)
+func selfReviewError(skill *core.Record) error {
+	return huma.Error403Forbidden("You can't review a skill you registered", &huma.ErrorDetail{
+		Location: "body.skill_id",
+		Message:  selfReviewCode,
+		Value:    skill.GetString("name"),
+	})
+}
 
-	if strings.HasPrefix(skillName, "http://") || strings.HasPrefix(skillName, "https://") {
-		record.Set("source", "url")
-		record.Set("url", skillName)
-		record.Set("category", "api")
-	} else {
-		record.Set("source", "github")
+// recentAgentReview returns agentID's completed review of skillID from the
+// last reviewReplaceWindow, or nil.
+func recentAgentReview(app *pocketbase.PocketBase, skillID, agentID string) *core.Record {
+	since := time.Now().UTC().Add(-reviewReplaceWindow).Format(pbDateTimeLayout)
+	records, err := app.FindRecordsByFilter("reviews",
+		"skill = {:sid} && agent_id = {:aid} && status = 'complete' && created >= {:since}", "-created", 1, 0,
+		map[string]any{"sid": skillID, "aid": agentID, "since": since})
+	if err != nil || len(records) == 0 {
+		return nil
+	}
+	return records[0]
+}
+
+// resetReview clears the optional fields of a review about to be replaced,
+// so nothing from the earlier submission leaks into the new one.
+func resetReview(r *core.Record) {
+	for _, field := range []string{"security_score", "execution_time_ms", "cli_output_size"} {
+		r.Set(field, 0)
+	}
+	for _, field := range []string{"cli_output_artifact", "proof", "challenge"} {
+		r.Set(field, "")
 	}
+}
 
-	app.Save(record)
+// deleteReviewAttachments removes the proofs and artifacts of a replaced
+// review, before the new submission attaches its own.
+func deleteReviewAttachments(app *pocketbase.PocketBase, reviewID string) {
+	for _, collection := range []string{"proofs", "artifacts"} {
+		records, _ := app.FindRecordsByFilter(collection, "review = {:rid}", "", 0, 0,
+			map[string]any{"rid": reviewID})
+		for _, r := range records {
+			if err := app.Delete(r); err != nil {
+				app.Logger().Warn("Failed to delete replaced review attachment", "collection", collection, "id", r.Id, "error", err)
+			}
+		}
+	}
 }
 
 func createClientProof(app *pocketbase.PocketBase, reviewID string, p *ClientProof, verified bool) string {
@@ -805,7 +952,11 @@ func createClientProof(app *pocketbase.PocketBase, reviewID string, p *ClientPro
 }
 
 func createServerProof(app *pocketbase.PocketBase, reviewID, skillID, task, cliOutput string, score float64, whatWorked, whatFailed string, execTimeMs *float64) string {
-	attestation, err := skills.CreateAttestation(skills.ExecutionData{
+	kp, err := skills.ServerKeyPair(app)
+	if err != nil {
+		return ""
+	}
+	attestation, err := skills.CreateAttestation(kp, skills.ExecutionData{
 		SkillID:         skillID,
 		Task:            task,
 		CLIOutput:       cliOutput,
@@ -825,14 +976,13 @@ func createServerProof(app *pocketbase.PocketBase, reviewID, skillID, task, cliO
 
 	payloadJSON, _ := json.Marshal(attestation.Payload)
 	sigJSON, _ := json.Marshal([]string{attestation.Signature})
-	witJSON, _ := json.Marshal([]map[string]string{{"type": "ed25519", "public_key": attestation.PublicKey}})
 
 	record := core.NewRecord(collection)
 	record.Set("review", reviewID)
 	record.Set("claim_data", string(payloadJSON))
 	record.Set("identifier", attestation.ExecutionHash)
 	record.Set("signatures", string(sigJSON))
-	record.Set("witnesses", string(witJSON))
+	record.Set("witnesses", attestation.WitnessesJSON())
 	record.Set("verified", true)
 
 	if err := app.Save(record); err != nil {
@@ -841,42 +991,82 @@ func createServerProof(app *pocketbase.PocketBase, reviewID, skillID, task, cliO
 	return record.Id
 }
 
-func updateSkillStatsFromAPI(app *pocketbase.PocketBase, skillID string) {
-	skill, err := app.FindRecordById("skills", skillID)
-	if err != nil {
-		return
+// reviewSkillNames resolves the distinct skills of a set of reviews to their
+// display names in a single query.
+func reviewSkillNames(app core.App, records []*core.Record) map[string]string {
+	names := map[string]string{}
+	var skillIDs []string
+	for _, r := range records {
+		id := r.GetString("skill")
+		if id == "" {
+			continue
+		}
+		if _, seen := names[id]; !seen {
+			names[id] = ""
+			skillIDs = append(skillIDs, id)
+		}
 	}
+	if len(skillIDs) > 0 {
+		if skills, err := app.FindRecordsByIds("skills", skillIDs); err == nil {
+			for _, s := range skills {
+				names[s.Id] = s.GetString("name")
+			}
+		}
+	}
+	return names
+}
 
-	reviews, err := app.FindRecordsByFilter("reviews",
-		"skill = {:sid} && status = 'complete' && score > 0", "", 0, 0,
-		map[string]any{"sid": skillID})
-	if err != nil {
-		return
+// reviewSkillName picks a review's skill display name: the live skill's
+// name, else the name stored at submission time (the skill was deleted),
+// else the raw stored identifier.
+func reviewSkillName(r *core.Record, names map[string]string) string {
+	if name := names[r.GetString("skill")]; name != "" {
+		return name
+	}
+	if name := r.GetString("skill_name"); name != "" {
+		return name
 	}
+	return r.GetString("skill")
+}
 
-	var totalScore, totalSecScore float64
-	var secCount int
-	for _, r := range reviews {
-		totalScore += r.GetFloat("score")
-		if s := r.GetFloat("security_score"); s > 0 {
-			totalSecScore += s
-			secCount++
+// buildReviewListItems converts review records to list items, resolving skill
+// names and proof status with one query each rather than one per review.
+func buildReviewListItems(app *pocketbase.PocketBase, records []*core.Record) []ReviewListItem {
+	var proofIDs []string
+	for _, r := range records {
+		if id := r.GetString("proof"); id != "" {
+			proofIDs = append(proofIDs, id)
 		}
 	}
 
-	reviewCount := len(reviews)
-	var avgScore, avgSecScore float64
-	if reviewCount > 0 {
-		avgScore = totalScore / float64(reviewCount)
-	}
-	if secCount > 0 {
-		avgSecScore = totalSecScore / float64(secCount)
+	skillNames := reviewSkillNames(app, records)
+	verifiedProofs := map[string]bool{}
+	if len(proofIDs) > 0 {
+		if proofs, err := app.FindRecordsByIds("proofs", proofIDs); err == nil {
+			for _, p := range proofs {
+				verifiedProofs[p.Id] = p.GetBool("verified")
+			}
+		}
 	}
 
-	skill.Set("review_count", reviewCount)
-	skill.Set("avg_score", avgScore)
-	skill.Set("avg_security_score", avgSecScore)
-	app.Save(skill)
-
-	skills.UpdateSkillRanking(app, skillID)
+	items := make([]ReviewListItem, 0, len(records))
+	for _, r := range records {
+		item := ReviewListItem{
+			ID:                r.Id,
+			Skill:             r.GetString("skill"),
+			SkillName:         reviewSkillName(r, skillNames),
+			Task:              r.GetString("task"),
+			Status:            r.GetString("status"),
+			VerifiedReviewer:  r.GetBool("verified_reviewer"),
+			Challenged:        r.GetString("challenge") != "",
+			ProofVerified:     verifiedProofs[r.GetString("proof")],
+			HeldForModeration: r.GetBool("needs_moderation"),
+			Created:           recordTime(r, "created"),
+		}
+		if v := r.GetFloat("score"); v > 0 {
+			item.Score = &v
+		}
+		items = append(items, item)
+	}
+	return items
 }
@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+)
+
+// countQueries records the SQL run against app's database from now until
+// the test ends.
+func countQueries(t *testing.T, app *pocketbase.PocketBase) func() []string {
+	t.Helper()
+	var mu sync.Mutex
+	var queries []string
+	for _, b := range []dbx.Builder{app.DB(), app.NonconcurrentDB()} {
+		db := b.(*dbx.DB)
+		prev := db.QueryLogFunc
+		db.QueryLogFunc = func(_ context.Context, _ time.Duration, sql string, _ *sql.Rows, _ error) {
+			mu.Lock()
+			queries = append(queries, sql)
+			mu.Unlock()
+		}
+		t.Cleanup(func() { db.QueryLogFunc = prev })
+	}
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), queries...)
+	}
+}
+
+func TestListReviewsQueries(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "skills", "name")
+	addCollection(t, app, "reviews", "skill", "skill_name", "proof", "task", "status", "agent_id", "verified_reviewer:bool", "score:number")
+	var skillIDs []string
+	for i := 0; i < 5; i++ {
+		skillIDs = append(skillIDs, addRecord(t, app, "skills", map[string]any{"name": fmt.Sprintf("Skill %d", i)}).Id)
+	}
+	for i := 0; i < 50; i++ {
+		addRecord(t, app, "reviews", map[string]any{"skill": skillIDs[i%5], "task": "t", "status": "complete"})
+	}
+	_, api := humatest.New(t)
+	RegisterReviewRoutes(api, app, newTestKeyring(t))
+
+	queries := countQueries(t, app)
+	resp := api.Get("/api/reviews?limit=50")
+	var out struct {
+		Reviews []ReviewListItem `json:"reviews"`
+	}
+	decodeBody(t, resp, &out)
+	if len(out.Reviews) != 50 {
+		t.Fatalf("%d reviews, want 50", len(out.Reviews))
+	}
+	for _, r := range out.Reviews {
+		if !strings.HasPrefix(r.SkillName, "Skill ") {
+			t.Errorf("review %s: skill_name %q", r.ID, r.SkillName)
+		}
+	}
+	if q := queries(); len(q) != 2 {
+		t.Errorf("%d queries, want 2 (reviews + skills):\n%s", len(q), strings.Join(q, "\n"))
+	}
+}
+
+func TestReviewSkillNameFallback(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "skills", "name")
+	addCollection(t, app, "reviews", "skill", "skill_name", "proof", "task", "status", "agent_id", "verified_reviewer:bool", "score:number")
+	live := addRecord(t, app, "skills", map[string]any{"name": "Live"})
+	records := []*core.Record{
+		addRecord(t, app, "reviews", map[string]any{"skill": live.Id, "skill_name": "Old name"}),
+		addRecord(t, app, "reviews", map[string]any{"skill": "gone1", "skill_name": "Deleted skill"}),
+		addRecord(t, app, "reviews", map[string]any{"skill": "gone2"}),
+	}
+	want := []string{"Live", "Deleted skill", "gone2"}
+	for i, item := range buildReviewListItems(app, records) {
+		if item.SkillName != want[i] {
+			t.Errorf("review %d: skill_name %q, want %q", i, item.SkillName, want[i])
+		}
+	}
+}
+
+func TestSubmitReviewLargeOutput(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "agents", "name", "public_key", "verified:bool")
+	addCollection(t, app, "skills", "name", "description", "owner_id", "source", "review_count:number", "avg_score:number")
+	addCollection(t, app, "reviews", "skill", "skill_name", "agent_id", "task", "status", "score:number",
+		"what_worked", "what_failed", "skill_feedback", "security_score:number", "security_notes",
+		"runner_type", "permission_mode", "agent_model", "execution_time_ms:number", "cli_output",
+		"cli_output_size:number", "cli_output_artifact", "verified_reviewer:bool", "challenge", "proof")
+	addCollection(t, app, "artifacts", "review", "file:file", "file_name", "mime_type", "size_bytes:number")
+	kr := newTestKeyring(t)
+	agent := addRecord(t, app, "agents", map[string]any{"name": "reviewer"}).Id
+	skill := addRecord(t, app, "skills", map[string]any{"name": "big-skill"}).Id
+
+	_, api := humatest.New(t)
+	RegisterReviewRoutes(api, app, kr)
+
+	output := strings.Repeat("line of build output\n", 95*1024/21)
+	resp := api.Post("/api/reviews/submit", bearer(t, kr, agent), map[string]any{
+		"skill_id": skill, "task": "build it", "score": 7, "cli_output": output,
+	})
+	var submitted SubmitReviewOutput
+	decodeBody(t, resp, &submitted.Body)
+	if resp.Code != http.StatusCreated || !submitted.Body.CLIOutputTruncated {
+		t.Fatalf("submit: %d %s", resp.Code, resp.Body.String()[:min(resp.Body.Len(), 300)])
+	}
+
+	review, err := app.FindRecordById("reviews", submitted.Body.ReviewID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := review.GetString("cli_output"); len(got) != cliOutputPreviewMax || got != output[:cliOutputPreviewMax] {
+		t.Errorf("stored preview is %d bytes", len(got))
+	}
+	if review.GetInt("cli_output_size") != len(output) {
+		t.Errorf("cli_output_size %d, want %d", review.GetInt("cli_output_size"), len(output))
+	}
+	artifact, err := app.FindRecordById("artifacts", review.GetString("cli_output_artifact"))
+	if err != nil {
+		t.Fatalf("artifact: %v", err)
+	}
+	if artifact.GetString("review") != review.Id || artifact.GetInt("size_bytes") != len(output) ||
+		!strings.HasPrefix(artifact.GetString("mime_type"), "text/plain") {
+		t.Errorf("artifact %v", artifact.FieldsData())
+	}
+
+	var detail GetReviewOutput
+	decodeBody(t, api.Get("/api/reviews/"+review.Id), &detail.Body)
+	if !detail.Body.CLIOutputTruncated || detail.Body.CLIOutputSize != len(output) ||
+		len(detail.Body.CLIOutput) != cliOutputPreviewMax || !strings.Contains(detail.Body.CLIOutputURL, artifact.Id) {
+		t.Errorf("detail: truncated %v, size %d, preview %d bytes, url %q", detail.Body.CLIOutputTruncated,
+			detail.Body.CLIOutputSize, len(detail.Body.CLIOutput), detail.Body.CLIOutputURL)
+	}
+}
+
+func TestCLIOutputPreview(t *testing.T) {
+	if got := cliOutputPreview("short"); got != "short" {
+		t.Errorf("short output changed: %q", got)
+	}
+	// A multi-byte rune straddling the cut is left out whole
+	out := strings.Repeat("a", cliOutputPreviewMax-1) + "é" + "tail"
+	if got := cliOutputPreview(out); len(got) != cliOutputPreviewMax-1 || !utf8.ValidString(got) {
+		t.Errorf("preview %d bytes, valid %v", len(got), utf8.ValidString(got))
+	}
+}
+
+func TestSubmitReviewBodyCap(t *testing.T) {
+	app := newTestApp(t)
+	handler, api := humatest.New(t)
+	RegisterReviewRoutes(api, app, newTestKeyring(t))
+
+	body := &countingReader{n: reviewSubmitMaxBytes + 1}
+	req := httptest.NewRequest("POST", "/api/reviews/submit", body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Length", fmt.Sprint(body.n)) // as net/http's server leaves it
+	req.ContentLength = body.n
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "cli_output 100000 characters") {
+		t.Errorf("%d %s", rec.Code, rec.Body.String())
+	}
+	if body.read != 0 {
+		t.Errorf("read %d bytes before refusing", body.read)
+	}
+}
+
+// reviewFixture is the review routes over the collections submission and
+// skill stats touch, with a skill registered by owner.
+type reviewFixture struct {
+	app   *pocketbase.PocketBase
+	api   humatest.TestAPI
+	kr    *auth.Keyring
+	skill *core.Record
+	owner string
+}
+
+func newReviewFixture(t *testing.T) *reviewFixture {
+	t.Helper()
+	t.Setenv("GOOGLE_API_KEY", "")
+	app := newTestApp(t)
+	addCollection(t, app, "agents", "name", "public_key", "verified:bool", "reputation_score:number")
+	addCollection(t, app, "skills", "name", "description", "owner_id", "source", "installs:number",
+		"review_count:number", "avg_score:number", "avg_security_score:number", "rank_score:number", "unreachable:bool")
+	addCollection(t, app, "reviews", "skill", "skill_name", "agent_id", "task", "status", "score:number",
+		"what_worked", "what_failed", "skill_feedback", "security_score:number", "security_notes",
+		"runner_type", "permission_mode", "agent_model", "execution_time_ms:number", "cli_output",
+		"cli_output_size:number", "cli_output_artifact", "verified_reviewer:bool", "challenge", "proof",
+		"needs_moderation:bool", "moderation_reason")
+	addCollection(t, app, "proofs", "review", "claim_data:json", "identifier", "signatures:json", "witnesses:json", "verified:bool")
+	addCollection(t, app, "artifacts", "review", "file:file", "file_name", "mime_type", "size_bytes:number")
+	addCollection(t, app, "review_challenges", "agent_id", "skill", "skill_name", "totem", "task", "aspects:json",
+		"expires", "used:bool", "regenerated_from", "replaced_by")
+	f := &reviewFixture{app: app, kr: newTestKeyring(t)}
+	f.owner = addRecord(t, app, "agents", map[string]any{"name": "owner"}).Id
+	f.skill = addRecord(t, app, "skills", map[string]any{"name": "weather", "owner_id": f.owner})
+	_, f.api = humatest.New(t)
+	RegisterReviewRoutes(f.api, app, f.kr)
+	return f
+}
+
+func (f *reviewFixture) agent(t *testing.T, name string) string {
+	t.Helper()
+	return addRecord(t, f.app, "agents", map[string]any{"name": name}).Id
+}
+
+// submit posts a review of the fixture's skill by agentID.
+func (f *reviewFixture) submit(t *testing.T, agentID string, score float64) (*httptest.ResponseRecorder, SubmitReviewOutput) {
+	t.Helper()
+	resp := f.api.Post("/api/reviews/submit", bearer(t, f.kr, agentID), map[string]any{
+		"skill_id": f.skill.Id, "task": "forecast for Paris", "score": score,
+		"what_worked": "returned a forecast", "what_failed": "nothing notable",
+	})
+	var out SubmitReviewOutput
+	if resp.Code < 300 {
+		decodeBody(t, resp, &out.Body)
+		if out.Body.HeldForModeration {
+			t.Fatalf("review held for moderation: %s", out.Body.ModerationReason)
+		}
+	}
+	return resp, out
+}
+
+// stats reloads the skill's review_count, avg_score and rank_score.
+func (f *reviewFixture) stats(t *testing.T) (int, float64, float64) {
+	t.Helper()
+	skill, err := f.app.FindRecordById("skills", f.skill.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return skill.GetInt("review_count"), skill.GetFloat("avg_score"), skill.GetFloat("rank_score")
+}
+
+func (f *reviewFixture) count(t *testing.T, collection string) int {
+	t.Helper()
+	n, err := f.app.CountRecords(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int(n)
+}
+
+func TestSubmitReviewReplacesRecent(t *testing.T) {
+	f := newReviewFixture(t)
+	alice, bob := f.agent(t, "alice"), f.agent(t, "bob")
+
+	resp, first := f.submit(t, alice, 2)
+	if resp.Code != http.StatusCreated || first.Body.Replaced {
+		t.Fatalf("first review: %d replaced=%v", resp.Code, first.Body.Replaced)
+	}
+	f.submit(t, bob, 8)
+	n, avg, rankBefore := f.stats(t)
+	if n != 2 || avg != 5 {
+		t.Fatalf("before replacement: review_count %d, avg_score %v", n, avg)
+	}
+
+	resp, second := f.submit(t, alice, 10)
+	if resp.Code != http.StatusOK || !second.Body.Replaced || second.Body.ReviewID != first.Body.ReviewID {
+		t.Fatalf("resubmission: %d replaced=%v id %s (was %s)", resp.Code, second.Body.Replaced,
+			second.Body.ReviewID, first.Body.ReviewID)
+	}
+	if got := f.count(t, "reviews"); got != 2 {
+		t.Errorf("%d reviews stored, want 2", got)
+	}
+	review, err := f.app.FindRecordById("reviews", first.Body.ReviewID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review.GetFloat("score") != 10 || review.GetString("proof") != second.Body.ProofID {
+		t.Errorf("replaced review: score %v, proof %s", review.GetFloat("score"), review.GetString("proof"))
+	}
+	// The earlier proof went with the earlier submission
+	if got := f.count(t, "proofs"); got != 2 {
+		t.Errorf("%d proofs stored, want one per review", got)
+	}
+
+	n, avg, rankAfter := f.stats(t)
+	if n != 2 || avg != 9 {
+		t.Errorf("after replacement: review_count %d, avg_score %v; want 2, 9", n, avg)
+	}
+	if rankAfter <= rankBefore {
+		t.Errorf("rank_score %v after raising a score, was %v", rankAfter, rankBefore)
+	}
+}
+
+func TestSubmitReviewAfterWindow(t *testing.T) {
+	f := newReviewFixture(t)
+	alice, bob := f.agent(t, "alice"), f.agent(t, "bob")
+
+	_, old := f.submit(t, alice, 2)
+	f.submit(t, bob, 8)
+	backdated := time.Now().UTC().Add(-reviewReplaceWindow - time.Hour).Format(pbDateTimeLayout)
+	if _, err := f.app.DB().NewQuery("UPDATE reviews SET created = {:c} WHERE id = {:id}").
+		Bind(dbx.Params{"c": backdated, "id": old.Body.ReviewID}).Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, fresh := f.submit(t, alice, 10)
+	if resp.Code != http.StatusCreated || fresh.Body.Replaced || fresh.Body.ReviewID == old.Body.ReviewID {
+		t.Fatalf("review after the window: %d replaced=%v", resp.Code, fresh.Body.Replaced)
+	}
+	if got := f.count(t, "reviews"); got != 3 {
+		t.Errorf("%d reviews stored, want 3", got)
+	}
+
+	// alice counts once, with her latest score
+	if n, avg, _ := f.stats(t); n != 2 || avg != 9 {
+		t.Errorf("review_count %d, avg_score %v; want 2, 9", n, avg)
+	}
+}
+
+func TestSelfReviewRefused(t *testing.T) {
+	f := newReviewFixture(t)
+
+	resp, _ := f.submit(t, f.owner, 10)
+	if resp.Code != http.StatusForbidden || !strings.Contains(resp.Body.String(), selfReviewCode) {
+		t.Errorf("submit: %d %s", resp.Code, resp.Body.String())
+	}
+	resp = f.api.Post("/api/reviews/challenge", bearer(t, f.kr, f.owner), map[string]any{"skill_id": "weather"})
+	if resp.Code != http.StatusForbidden || !strings.Contains(resp.Body.String(), selfReviewCode) {
+		t.Errorf("challenge: %d %s", resp.Code, resp.Body.String())
+	}
+	if n := f.count(t, "reviews") + f.count(t, "review_challenges"); n != 0 {
+		t.Errorf("%d records stored for a self-review", n)
+	}
+	if n, _, _ := f.stats(t); n != 0 {
+		t.Errorf("review_count %d", n)
+	}
+}
+
+func TestChallengeReportsReplacedReview(t *testing.T) {
+	f := newReviewFixture(t)
+	alice := f.agent(t, "alice")
+
+	challenge := func() RequestChallengeOutput {
+		t.Helper()
+		resp := f.api.Post("/api/reviews/challenge", bearer(t, f.kr, alice), map[string]any{"skill_id": "weather"})
+		var out RequestChallengeOutput
+		decodeBody(t, resp, &out.Body)
+		if resp.Code != http.StatusCreated {
+			t.Fatalf("challenge: %d %s", resp.Code, resp.Body.String())
+		}
+		return out
+	}
+	if out := challenge(); out.Body.ReplacesReview != "" {
+		t.Errorf("first challenge replaces %q", out.Body.ReplacesReview)
+	}
+	_, review := f.submit(t, alice, 6)
+	if out := challenge(); out.Body.ReplacesReview != review.Body.ReviewID {
+		t.Errorf("replaces_review %q, want %s", out.Body.ReplacesReview, review.Body.ReviewID)
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	auth "gather.is/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Scheduled posts (publish_at)
+// -----------------------------------------------------------------------------
+
+type ListScheduledPostsInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+}
+
+type ListScheduledPostsOutput struct {
+	Body struct {
+		Posts []PostItem `json:"posts"`
+		Total int        `json:"total"`
+	}
+}
+
+type CancelScheduledPostInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Post ID"`
+}
+
+type CancelScheduledPostOutput struct {
+	Body struct {
+		Cancelled   bool   `json:"cancelled"`
+		RefundedBCH string `json:"refunded_bch,omitempty"`
+	}
+}
+
+func registerScheduledPostRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "list-scheduled-posts",
+		Method:      "GET",
+		Path:        "/api/posts/scheduled",
+		Summary:     "List your scheduled posts",
+		Description: "Requires JWT. Returns your own posts that are waiting for their publish_at time, soonest first.",
+		Tags:        []string{"Posts"},
+	}, func(ctx context.Context, input *ListScheduledPostsInput) (*ListScheduledPostsOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		records, _ := app.FindRecordsByFilter("posts",
+			"author_id = {:aid} && status = 'scheduled'", "publish_at", 0, 0,
+			map[string]any{"aid": claims.AgentID})
+
+		cache := map[string]postAgentInfo{}
+		posts := make([]PostItem, 0, len(records))
+		for _, r := range records {
+			posts = append(posts, recordToPostItem(app, r, true, false, cache))
+		}
+
+		out := &ListScheduledPostsOutput{}
+		out.Body.Posts = posts
+		out.Body.Total = len(posts)
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "cancel-scheduled-post",
+		Method:      "DELETE",
+		Path:        "/api/posts/scheduled/{id}",
+		Summary:     "Cancel a scheduled post",
+		Description: "Requires JWT. Deletes a post before it is published and refunds the posting fee to your balance.",
+		Tags:        []string{"Posts"},
+	}, func(ctx context.Context, input *CancelScheduledPostInput) (*CancelScheduledPostOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		post, err := app.FindRecordById("posts", input.ID)
+		if err != nil || post.GetString("author_id") != claims.AgentID {
+			return nil, huma.Error404NotFound("Post not found")
+		}
+		if post.GetString("status") != "scheduled" {
+			return nil, huma.Error409Conflict("Post has already been published")
+		}
+
+		fee := post.GetString("fee_bch")
+		if err := app.Delete(post); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to cancel post")
+		}
+
+		out := &CancelScheduledPostOutput{}
+		out.Body.Cancelled = true
+		if parseBCH(fee).Sign() > 0 {
+			if bal, err := getOrCreateBalance(app, claims.AgentID); err == nil {
+				if err := refundBalance(app, bal, fee); err != nil {
+					app.Logger().Error("Failed to refund scheduled post fee", "post", input.ID, "agent", claims.AgentID, "error", err)
+				} else {
+					out.Body.RefundedBCH = fee
+				}
+			}
+		}
+		return out, nil
+	})
+}
+
+// StartPostScheduler launches a background goroutine that publishes
+// scheduled posts once their publish_at time has passed.
+func StartPostScheduler(app *pocketbase.PocketBase) {
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			publishDuePosts(app)
+		}
+	}()
+	app.Logger().Info("Post scheduler started (1-minute tick)")
+}
+
+func publishDuePosts(app *pocketbase.PocketBase) {
+	now := time.Now().UTC()
+	records, err := app.FindRecordsByFilter("posts",
+		"status = 'scheduled' && publish_at <= {:now}", "publish_at", 100, 0,
+		map[string]any{"now": now.Format(time.RFC3339)})
+	if err != nil || len(records) == 0 {
+		return
+	}
+
+	for _, r := range records {
+		// Stamp created and ranking at publish time so the post isn't
+		// buried below everything submitted while it was waiting.
+		authorID := r.GetString("author_id")
+		r.Set("status", "published")
+		r.SetRaw("created", types.NowDateTime())
+		r.Set("weight", computePostWeight(app, authorID, r.GetString("fee_bch") != ""))
+		if err := app.Save(r); err != nil {
+			app.Logger().Warn("Failed to publish scheduled post", "post", r.Id, "error", err)
+			continue
+		}
+		app.Logger().Info("Published scheduled post", "post", r.Id, "author", authorID)
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"container/list"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// ForwardAuth decision cache
+// -----------------------------------------------------------------------------
+
+// A single page load on a claw subdomain fans out into dozens of asset
+// requests, each of which hits ForwardAuth. Decisions are cached per
+// (subdomain, debug, session token) so only the first request pays for the
+// claw lookup and auth token validation. Random cookies or subdomains each
+// add an entry, so the cache is capped and evicts the least recently used.
+
+const (
+	sessionDecisionTTL = 45 * time.Second
+	sessionNotFoundTTL = 10 * time.Second
+	sessionCacheMax    = 10000
+)
+
+type sessionDecisionKind int
+
+const (
+	decisionAllow    sessionDecisionKind = iota // 200, optional X-Auth-User
+	decisionDeny                                // 302 to the session bridge
+	decisionNotFound                            // 404, unknown or stopped claw
+	decisionAsleep                              // 503, claw sleeping on its schedule
+)
+
+type sessionDecision struct {
+	kind     sessionDecisionKind
+	authUser string
+	message  string // decisionAsleep: when it's back
+	expires  time.Time
+}
+
+// sessionEntry is a cached decision on the LRU list.
+type sessionEntry struct {
+	subdomain, key string
+	decision       sessionDecision
+}
+
+// SessionCache is an in-memory TTL cache of ForwardAuth decisions, holding
+// at most max entries.
+type SessionCache struct {
+	mu    sync.Mutex
+	items map[string]map[string]*list.Element // subdomain → key → entry
+	lru   *list.List                          // most recently used first
+	max   int
+}
+
+func NewSessionCache() *SessionCache {
+	sc := &SessionCache{
+		items: make(map[string]map[string]*list.Element),
+		lru:   list.New(),
+		max:   sessionCacheMax,
+	}
+	go sc.cleanup()
+	return sc
+}
+
+// forwardAuthCache is shared by handleVerifySession and the claw_deployments
+// hooks that invalidate it.
+var forwardAuthCache = NewSessionCache()
+
+func sessionCacheKey(isDebug bool, token string) string {
+	if isDebug {
+		return "debug:" + token
+	}
+	return "main:" + token
+}
+
+func (sc *SessionCache) Get(subdomain, key string) (sessionDecision, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	el, ok := sc.items[subdomain][key]
+	if !ok {
+		return sessionDecision{}, false
+	}
+	e := el.Value.(*sessionEntry)
+	if time.Now().After(e.decision.expires) {
+		sc.remove(el)
+		return sessionDecision{}, false
+	}
+	sc.lru.MoveToFront(el)
+	return e.decision, true
+}
+
+func (sc *SessionCache) Set(subdomain, key string, d sessionDecision) {
+	ttl := sessionDecisionTTL
+	if d.kind == decisionNotFound {
+		ttl = sessionNotFoundTTL
+	}
+	d.expires = time.Now().Add(ttl)
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if el, ok := sc.items[subdomain][key]; ok {
+		el.Value.(*sessionEntry).decision = d
+		sc.lru.MoveToFront(el)
+		return
+	}
+	m, ok := sc.items[subdomain]
+	if !ok {
+		m = make(map[string]*list.Element)
+		sc.items[subdomain] = m
+	}
+	m[key] = sc.lru.PushFront(&sessionEntry{subdomain: subdomain, key: key, decision: d})
+	for sc.lru.Len() > sc.max {
+		sc.remove(sc.lru.Back())
+	}
+}
+
+// Invalidate drops every cached decision for a subdomain.
+func (sc *SessionCache) Invalidate(subdomain string) {
+	if subdomain == "" {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for _, el := range sc.items[subdomain] {
+		sc.lru.Remove(el)
+	}
+	delete(sc.items, subdomain)
+}
+
+// remove drops one entry. The caller holds sc.mu.
+func (sc *SessionCache) remove(el *list.Element) {
+	e := sc.lru.Remove(el).(*sessionEntry)
+	m := sc.items[e.subdomain]
+	delete(m, e.key)
+	if len(m) == 0 {
+		delete(sc.items, e.subdomain)
+	}
+}
+
+// cleanup evicts expired decisions every minute.
+func (sc *SessionCache) cleanup() {
+	ticker := time.NewTicker(1 * time.Minute)
+	for range ticker.C {
+		sc.evictExpired(time.Now())
+	}
+}
+
+func (sc *SessionCache) evictExpired(now time.Time) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for el := sc.lru.Front(); el != nil; {
+		next := el.Next()
+		if now.After(el.Value.(*sessionEntry).decision.expires) {
+			sc.remove(el)
+		}
+		el = next
+	}
+}
+
+// InvalidateClawAccess clears cached ForwardAuth decisions for a claw
+// subdomain. Called when a claw's visibility, owner, collaborators or status
+// change.
+func InvalidateClawAccess(subdomain string) {
+	forwardAuthCache.Invalidate(subdomain)
+}
+
+// -----------------------------------------------------------------------------
+// Public path bypass
+// -----------------------------------------------------------------------------
+
+// forwardAuthPublicPaths are served without a session regardless of the
+// claw's privacy setting, so uptime monitors don't need to log in.
+// Override with FORWARD_AUTH_PUBLIC_PATHS (comma-separated).
+var forwardAuthPublicPaths = loadForwardAuthPublicPaths()
+
+func loadForwardAuthPublicPaths() map[string]bool {
+	raw := os.Getenv("FORWARD_AUTH_PUBLIC_PATHS")
+	if raw == "" {
+		raw = "/favicon.ico,/healthz"
+	}
+	paths := map[string]bool{}
+	for _, p := range strings.Split(raw, ",") {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			paths[p] = true
+		}
+	}
+	return paths
+}
+
+// isPublicForwardAuthPath reports whether the forwarded URI (query stripped)
+// is on the public bypass list.
+func isPublicForwardAuthPath(uri string) bool {
+	if idx := strings.IndexByte(uri, '?'); idx != -1 {
+		uri = uri[:idx]
+	}
+	return forwardAuthPublicPaths[uri]
+}
+
+// writeSessionDecision replays a cached decision onto the response.
+func writeSessionDecision(w http.ResponseWriter, r *http.Request, d sessionDecision) {
+	switch d.kind {
+	case decisionAllow:
+		if d.authUser != "" {
+			w.Header().Set("X-Auth-User", d.authUser)
+		}
+		w.WriteHeader(http.StatusOK)
+	case decisionNotFound:
+		http.Error(w, "Claw not found", http.StatusNotFound)
+	case decisionAsleep:
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, d.message, http.StatusServiceUnavailable)
+	default:
+		redirectToLogin(w, r)
+	}
+}
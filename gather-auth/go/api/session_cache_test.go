@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSessionCacheCap(t *testing.T) {
+	sc := NewSessionCache()
+	sc.max = 3
+
+	sc.Set("a", "k1", sessionDecision{kind: decisionAllow, authUser: "u1"})
+	sc.Set("a", "k2", sessionDecision{kind: decisionDeny})
+	sc.Set("b", "missing", sessionDecision{kind: decisionNotFound})
+	if _, ok := sc.Get("a", "k1"); !ok {
+		t.Fatal("k1 missing before the cache was full")
+	}
+
+	// k2 is now the least recently used
+	sc.Set("c", "k3", sessionDecision{kind: decisionDeny})
+	if _, ok := sc.Get("a", "k2"); ok {
+		t.Error("least recently used entry kept")
+	}
+	for _, k := range [][2]string{{"a", "k1"}, {"b", "missing"}, {"c", "k3"}} {
+		if _, ok := sc.Get(k[0], k[1]); !ok {
+			t.Errorf("%s/%s evicted", k[0], k[1])
+		}
+	}
+
+	// A flood of random tokens never grows it past the cap, and leaves no
+	// empty subdomain maps behind
+	for i := 0; i < 1000; i++ {
+		sc.Set(fmt.Sprintf("sub%d", i), fmt.Sprintf("main:%d", i), sessionDecision{kind: decisionDeny})
+	}
+	if n := sc.lru.Len(); n != 3 {
+		t.Errorf("%d entries, want 3", n)
+	}
+	if len(sc.items) != 3 {
+		t.Errorf("%d subdomains, want 3", len(sc.items))
+	}
+
+	// Overwriting an entry doesn't add one
+	sc.Set("sub999", "main:999", sessionDecision{kind: decisionAllow})
+	if d, _ := sc.Get("sub999", "main:999"); d.kind != decisionAllow || sc.lru.Len() != 3 {
+		t.Errorf("overwrite: %v, %d entries", d.kind, sc.lru.Len())
+	}
+}
+
+func TestSessionCacheExpiryAndInvalidate(t *testing.T) {
+	sc := NewSessionCache()
+	sc.Set("a", "k1", sessionDecision{kind: decisionAllow})
+	sc.Set("a", "missing", sessionDecision{kind: decisionNotFound})
+	sc.Set("b", "k1", sessionDecision{kind: decisionAllow})
+
+	// Not-found decisions expire sooner
+	sc.evictExpired(time.Now().Add(sessionNotFoundTTL + time.Second))
+	if _, ok := sc.Get("a", "missing"); ok {
+		t.Error("not-found decision outlived its TTL")
+	}
+	if _, ok := sc.Get("a", "k1"); !ok {
+		t.Error("allow decision evicted early")
+	}
+
+	sc.Invalidate("a")
+	if _, ok := sc.Get("a", "k1"); ok {
+		t.Error("invalidated decision returned")
+	}
+	if _, ok := sc.Get("b", "k1"); !ok {
+		t.Error("another subdomain's decision invalidated")
+	}
+	if sc.lru.Len() != 1 {
+		t.Errorf("%d entries after invalidate, want 1", sc.lru.Len())
+	}
+
+	sc.evictExpired(time.Now().Add(sessionDecisionTTL + time.Second))
+	if sc.lru.Len() != 0 || len(sc.items) != 0 {
+		t.Errorf("%d entries, %d subdomains after expiry", sc.lru.Len(), len(sc.items))
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"gather.is/auth/shop"
+)
+
+// -----------------------------------------------------------------------------
+// Shipping address validation — checked before an order is created so Gelato
+// never rejects an order the agent has already paid for
+// -----------------------------------------------------------------------------
+
+// isoCountries is the set of ISO-3166-1 alpha-2 country codes.
+var isoCountries = func() map[string]bool {
+	codes := strings.Fields(`
+		AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI
+		BJ BL BM BN BO BQ BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN
+		CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK
+		FM FO FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM
+		HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN
+		KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK
+		ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP
+		NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW
+		SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF
+		TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI
+		VN VU WF WS YE YT ZA ZM ZW`)
+	set := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		set[c] = true
+	}
+	return set
+}()
+
+// postCodeFormats are the post code patterns for countries where Gelato is
+// strict about the format. Other countries only need a non-empty post code.
+var postCodeFormats = map[string]struct {
+	re      *regexp.Regexp
+	example string
+}{
+	"US": {regexp.MustCompile(`^\d{5}(-\d{4})?$`), "12345 or 12345-6789"},
+	"GB": {regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2}$`), "SW1A 1AA"},
+	"DE": {regexp.MustCompile(`^\d{5}$`), "10115"},
+}
+
+// normalizePostCode brings a post code into the canonical form for its
+// country: GB codes are uppercased with a single space before the inward
+// code, everything else is uppercased and trimmed.
+func normalizePostCode(country, code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if country == "GB" {
+		code = strings.Join(strings.Fields(code), "")
+		if len(code) > 3 {
+			code = code[:len(code)-3] + " " + code[len(code)-3:]
+		}
+	}
+	return code
+}
+
+// normalizeShippingAddress validates a shipping address and returns it in
+// Gelato's format, with HTML stripped, whitespace trimmed, and the country
+// and post code normalized. Every problem found is returned, not just the
+// first, so the agent can fix them all in one go.
+func normalizeShippingAddress(addr ShippingAddress) (map[string]string, []error) {
+	clean := func(s string) string { return strings.TrimSpace(stripHTMLTags(s)) }
+	country := strings.ToUpper(strings.TrimSpace(addr.Country))
+
+	shipping := map[string]string{
+		"firstName":    clean(addr.FirstName),
+		"lastName":     clean(addr.LastName),
+		"addressLine1": clean(addr.AddressLine1),
+		"addressLine2": clean(addr.AddressLine2),
+		"city":         clean(addr.City),
+		"state":        clean(addr.State),
+		"postCode":     normalizePostCode(country, clean(addr.PostCode)),
+		"country":      country,
+		"email":        clean(addr.Email),
+		"phone":        clean(addr.Phone),
+	}
+
+	var problems []error
+	problem := func(field string, value any, msg string) {
+		problems = append(problems, &huma.ErrorDetail{
+			Location: "body.shipping_address." + field,
+			Message:  msg,
+			Value:    value,
+		})
+	}
+
+	required := []struct{ field, key string }{
+		{"first_name", "firstName"},
+		{"last_name", "lastName"},
+		{"address_line_1", "addressLine1"},
+		{"city", "city"},
+		{"post_code", "postCode"},
+		{"email", "email"},
+	}
+	for _, r := range required {
+		if shipping[r.key] == "" {
+			problem(r.field, nil, "is required")
+		}
+	}
+
+	if !isoCountries[country] {
+		problem("country", addr.Country, "must be an ISO-3166 alpha-2 country code, e.g. US, GB, DE")
+	}
+
+	if email := shipping["email"]; email != "" {
+		if parsed, err := mail.ParseAddress(email); err != nil || parsed.Address != email {
+			problem("email", addr.Email, "must be a valid email address")
+		}
+	}
+
+	if code := shipping["postCode"]; code != "" {
+		if format, ok := postCodeFormats[country]; ok && !format.re.MatchString(code) {
+			problem("post_code", addr.PostCode, fmt.Sprintf("is not a valid %s post code (e.g. %s)", country, format.example))
+		}
+	}
+
+	return shipping, problems
+}
+
+// checkProductShipsTo returns a problem if the resolved Gelato product can't
+// be shipped to country. Catalog lookup failures don't block the order:
+// Gelato still validates at fulfillment, and a flaky catalog shouldn't stop
+// every order.
+func checkProductShipsTo(gelatoUID, country string) error {
+	ok, err := shop.ProductShipsTo(gelatoUID, country)
+	if err != nil || ok {
+		return nil
+	}
+	return &huma.ErrorDetail{
+		Location: "body.shipping_address.country",
+		Message:  fmt.Sprintf("this product can't be shipped to %s", country),
+		Value:    country,
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
 
+	auth "gather.is/auth"
 	"gather.is/auth/ratelimit"
 	"gather.is/auth/shop"
 )
@@ -24,6 +26,7 @@ import (
 type MenuOutput struct {
 	Body struct {
 		Categories []shop.CategoryInfo `json:"categories"`
+		Stale      bool                `json:"stale,omitempty" doc:"Gelato is unreachable; prices are the last known ones"`
 	}
 }
 
@@ -39,6 +42,7 @@ type CategoryItemsOutput struct {
 		Page       int             `json:"page" doc:"Current page number (1-indexed)"`
 		TotalPages int             `json:"total_pages" doc:"Total number of pages"`
 		Next       *string         `json:"next" doc:"URL for the next page, or null if last page"`
+		Stale      bool            `json:"stale,omitempty" doc:"Gelato is unreachable; prices are the last known ones"`
 	}
 }
 
@@ -53,6 +57,7 @@ type ProductOptionsOutput struct {
 		ProductID   string              `json:"product_id"`
 		ProductName string              `json:"product_name"`
 		Options     map[string][]string `json:"options" doc:"Available values for each option"`
+		Stale       bool                `json:"stale,omitempty" doc:"Gelato is unreachable; these are the last known options"`
 	}
 }
 
@@ -61,14 +66,20 @@ type ProductOptionsOutput struct {
 type OrderOutput struct {
 	Status int `header:"Status"`
 	Body   struct {
-		OrderID        string `json:"order_id" doc:"Unique order identifier"`
-		Status         string `json:"status" doc:"Current order status"`
-		TotalBCH       string `json:"total_bch" doc:"Total price to pay in BCH"`
-		PaymentAddress string `json:"payment_address" doc:"BCH address to send payment to"`
-		StatusURL      string `json:"status_url" doc:"URL to check order status"`
+		OrderID        string     `json:"order_id" doc:"Unique order identifier"`
+		Status         string     `json:"status" doc:"Current order status"`
+		TotalBCH       string     `json:"total_bch" doc:"Total price to pay in BCH, locked until quote.expires_at"`
+		PaymentAddress string     `json:"payment_address" doc:"BCH address to send payment to"`
+		StatusURL      string     `json:"status_url" doc:"URL to check order status"`
+		Quote          shop.Quote `json:"quote" doc:"How total_bch was priced, and until when it holds"`
 	}
 }
 
+type RequoteInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	OrderID       string `path:"order_id" doc:"Order ID to re-quote"`
+}
+
 type ShippingAddress struct {
 	FirstName    string `json:"first_name" doc:"Recipient first name" minLength:"1" maxLength:"100"`
 	LastName     string `json:"last_name" doc:"Recipient last name" minLength:"1" maxLength:"100"`
@@ -88,7 +99,8 @@ type ProductOrderInput struct {
 		ProductID       string            `json:"product_id" doc:"Product ID from /menu/products" minLength:"1"`
 		Options         map[string]string `json:"options" doc:"Product options (size, color, etc.)"`
 		ShippingAddress ShippingAddress   `json:"shipping_address"`
-		DesignURL       string            `json:"design_url,omitempty" doc:"URL of uploaded design image (from POST /api/designs/upload). Falls back to placeholder if not provided."`
+		DesignURL       string            `json:"design_url,omitempty" doc:"URL of uploaded design image (from POST /api/designs/upload). Falls back to placeholder if neither it nor design_id is provided."`
+		DesignID        string            `json:"design_id,omitempty" doc:"ID of one of your designs (GET /api/designs), instead of design_url"`
 	}
 }
 
@@ -124,6 +136,8 @@ type OrderStatusOutput struct {
 		PaymentAddress string            `json:"payment_address"`
 		Paid           bool              `json:"paid"`
 		TxID           string            `json:"tx_id,omitempty"`
+		Quote          *shop.Quote       `json:"quote,omitempty" doc:"Current quote; once paid, the one that was accepted"`
+		OriginalQuote  *shop.Quote       `json:"original_quote,omitempty" doc:"Quote made when the order was placed"`
 		ProductID      string            `json:"product_id,omitempty" doc:"Product ID"`
 		ProductOptions map[string]string `json:"product_options,omitempty" doc:"Chosen options"`
 		DesignURL      string            `json:"design_url,omitempty" doc:"Design image URL"`
@@ -132,29 +146,11 @@ type OrderStatusOutput struct {
 	}
 }
 
-// --- Feedback ---
-
-type FeedbackInput struct {
-	Body struct {
-		Rating  int    `json:"rating" doc:"1-5 star rating" minimum:"1" maximum:"5"`
-		Message string `json:"message,omitempty" doc:"Optional free-text feedback" maxLength:"5000"`
-		Agent   string `json:"agent,omitempty" doc:"Which agent/model submitted this" maxLength:"200"`
-	}
-}
-
-type FeedbackOutput struct {
-	Status int `header:"Status"`
-	Body   struct {
-		Status     string `json:"status"`
-		FeedbackID string `json:"feedback_id" doc:"ID for this feedback entry"`
-	}
-}
-
 // -----------------------------------------------------------------------------
 // Route registration
 // -----------------------------------------------------------------------------
 
-func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte) {
+func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
 	// --- Menu ---
 
 	huma.Register(api, huma.Operation{
@@ -162,12 +158,13 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 		Method:      "GET",
 		Path:        "/api/menu",
 		Summary:     "List product categories",
-		Description: "Returns categories for shippable products. Prices are live from Gelato + CoinGecko.",
+		Description: "Returns categories for shippable products. Prices come from Gelato + CoinGecko, cached for up to 30 minutes.",
 		Tags:        []string{"Menu"},
 	}, func(ctx context.Context, input *struct{}) (*MenuOutput, error) {
-		productItems := shop.GetProductsForMenu()
+		productItems, stale := shop.GetProductsForMenu()
 
 		out := &MenuOutput{}
+		out.Body.Stale = stale
 		out.Body.Categories = []shop.CategoryInfo{
 			{
 				ID:    "products",
@@ -190,7 +187,7 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 		out := &CategoryItemsOutput{}
 
 		if input.Category == "products" {
-			allItems := shop.GetProductsForMenu()
+			allItems, stale := shop.GetProductsForMenu()
 			totalPages := int(math.Max(1, math.Ceil(float64(len(allItems))/float64(shop.ItemsPerPage))))
 			page := input.Page
 			if page < 1 {
@@ -209,6 +206,7 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 			out.Body.Items = allItems[start:end]
 			out.Body.Page = page
 			out.Body.TotalPages = totalPages
+			out.Body.Stale = stale
 			if page < totalPages {
 				next := fmt.Sprintf("/api/menu/products?page=%d", page+1)
 				out.Body.Next = &next
@@ -227,8 +225,9 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 		Method:      "GET",
 		Path:        "/api/products/{product_id}/options",
 		Summary:     "Get available product options",
-		Description: "Returns available options (sizes, colors, etc.) for a shippable product, fetched live from Gelato's catalog. Use the product_id from GET /api/menu/products.",
-		Tags:        []string{"Products"},
+		Description: "Returns available options (sizes, colors, etc.) for a shippable product from Gelato's catalog, cached for up to an hour. " +
+			"If Gelato is unreachable the last known options are returned with stale: true. Use the product_id from GET /api/menu/products.",
+		Tags: []string{"Products"},
 	}, func(ctx context.Context, input *ProductOptionsInput) (*ProductOptionsOutput, error) {
 		cfg := shop.GetProduct(input.ProductID)
 		if cfg == nil {
@@ -236,7 +235,7 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 				fmt.Sprintf("Product '%s' not found. See GET /api/menu/products.", input.ProductID))
 		}
 
-		options, err := shop.GetProductOptions(input.ProductID)
+		options, stale, err := shop.GetProductOptions(input.ProductID)
 		if err != nil {
 			return nil, huma.Error503ServiceUnavailable("Unable to fetch product options. Try again shortly.")
 		}
@@ -245,6 +244,7 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 		out.Body.ProductID = input.ProductID
 		out.Body.ProductName = cfg.Name + " — " + cfg.Description
 		out.Body.Options = options
+		out.Body.Stale = stale
 		return out, nil
 	})
 
@@ -255,7 +255,7 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 		Method:        "POST",
 		Path:          "/api/order/product",
 		Summary:       "Order a real, shippable product",
-		Description:   "Order a t-shirt, mug, or framed print with your own design. Upload a design image first via POST /api/designs/upload, then select a product from GET /api/menu/products, choose options from GET /api/products/{id}/options, and provide a shipping address. After payment, the item is printed by Gelato and shipped. If design_url is omitted, a placeholder image is used.",
+		Description:   "Order a t-shirt, mug, or framed print with your own design. Upload a design image first via POST /api/designs/upload, then select a product from GET /api/menu/products, choose options from GET /api/products/{id}/options, and provide a shipping address. After payment, the item is printed by Gelato and shipped. Reuse an earlier design with design_id (see GET /api/designs); designs must be your own. If design_url and design_id are omitted, a placeholder image is used.",
 		Tags:          []string{"Orders"},
 		DefaultStatus: 201,
 	}, func(ctx context.Context, input *ProductOrderInput) (*OrderOutput, error) {
@@ -278,7 +278,9 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 			return nil, huma.Error422UnprocessableEntity(fmt.Sprintf("Invalid options: %s", errMsg))
 		}
 
-		gelatoUID, err := shop.ResolveGelatoUID(input.Body.ProductID, input.Body.Options)
+		// Checked live, not from the catalog cache, so a variant Gelato has
+		// dropped can't be ordered
+		gelatoUID, err := shop.ResolveGelatoUIDLive(input.Body.ProductID, input.Body.Options)
 		if err != nil {
 			return nil, huma.Error503ServiceUnavailable(
 				"Unable to look up product options from Gelato right now. Please try again shortly.")
@@ -288,33 +290,30 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 				"That option combination is not available. Try different options, or check GET /api/products/{id}/options.")
 		}
 
-		bchPrice, err := shop.GetProductBCHPrice(input.Body.ProductID, input.Body.Options)
-		if err != nil || bchPrice == "" {
+		// Validate the address up front so Gelato never rejects a paid order.
+		// Only the normalized address is stored for fulfillment.
+		shipping, problems := normalizeShippingAddress(input.Body.ShippingAddress)
+		if isoCountries[shipping["country"]] {
+			if err := checkProductShipsTo(gelatoUID, shipping["country"]); err != nil {
+				problems = append(problems, err)
+			}
+		}
+		if len(problems) > 0 {
+			return nil, huma.Error422UnprocessableEntity("Invalid shipping address", problems...)
+		}
+
+		quote, err := shop.QuoteProduct(input.Body.ProductID, input.Body.Options, time.Now())
+		if err != nil {
 			return nil, huma.Error503ServiceUnavailable("Unable to calculate price right now. Please try again shortly.")
 		}
 
-		// Use uploaded design URL, fall back to product placeholder
-		designURL := input.Body.DesignURL
+		// Use the orderer's own uploaded design, fall back to product placeholder
+		designURL, err := resolveOrderDesign(app, claims.AgentID, input.Body.ProductID, input.Body.DesignID, input.Body.DesignURL)
+		if err != nil {
+			return nil, huma.Error422UnprocessableEntity(err.Error())
+		}
 		if designURL == "" {
 			designURL = cfg.DesignURL
-		} else if !strings.HasPrefix(designURL, "/api/files/designs/") &&
-			!strings.HasPrefix(designURL, "https://gather.is/api/files/designs/") {
-			return nil, huma.Error422UnprocessableEntity(
-				"design_url must be a platform-hosted image from POST /api/designs/upload. External URLs are not accepted.")
-		}
-
-		// Convert shipping to Gelato format (strip HTML to prevent stored XSS)
-		shipping := map[string]string{
-			"firstName":    stripHTMLTags(input.Body.ShippingAddress.FirstName),
-			"lastName":     stripHTMLTags(input.Body.ShippingAddress.LastName),
-			"addressLine1": stripHTMLTags(input.Body.ShippingAddress.AddressLine1),
-			"addressLine2": stripHTMLTags(input.Body.ShippingAddress.AddressLine2),
-			"city":         stripHTMLTags(input.Body.ShippingAddress.City),
-			"state":        stripHTMLTags(input.Body.ShippingAddress.State),
-			"postCode":     stripHTMLTags(input.Body.ShippingAddress.PostCode),
-			"country":      input.Body.ShippingAddress.Country,
-			"email":        stripHTMLTags(input.Body.ShippingAddress.Email),
-			"phone":        stripHTMLTags(input.Body.ShippingAddress.Phone),
 		}
 
 		collection, err := app.FindCollectionByNameOrId("orders")
@@ -334,7 +333,9 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 		record.Set("shipping_address", string(shippingJSON))
 		record.Set("design_url", designURL)
 		record.Set("gelato_product_uid", gelatoUID)
-		record.Set("total_bch", bchPrice)
+		record.Set("total_bch", quote.TotalBCH)
+		record.Set("quote", quote)
+		record.Set("original_quote", quote)
 		record.Set("payment_address", shop.ShopBCHAddress())
 		record.Set("paid", false)
 
@@ -344,17 +345,79 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 
 		SendInboxMessage(app, claims.AgentID, "order_update",
 			fmt.Sprintf("Order %s placed!", formatOrderID(record.Id)),
-			fmt.Sprintf("Your order %s has been created. Send %s BCH to %s, then submit your transaction ID via PUT /api/order/%s/payment.",
-				formatOrderID(record.Id), bchPrice, shop.ShopBCHAddress(), record.Id),
+			fmt.Sprintf("Your order %s has been created. Send %s BCH to %s before %s, then submit your transaction ID via PUT /api/order/%s/payment. "+
+				"After that the quote expires and you'll need a new one from POST /api/order/%s/requote.",
+				formatOrderID(record.Id), quote.TotalBCH, shop.ShopBCHAddress(), quote.ExpiresAt, record.Id, record.Id),
 			"order", record.Id)
 
 		out := &OrderOutput{}
 		out.Status = 201
 		out.Body.OrderID = record.Id
 		out.Body.Status = "awaiting_payment"
-		out.Body.TotalBCH = bchPrice
+		out.Body.TotalBCH = quote.TotalBCH
 		out.Body.PaymentAddress = shop.ShopBCHAddress()
 		out.Body.StatusURL = fmt.Sprintf("/api/order/%s", record.Id)
+		out.Body.Quote = quote
+		return out, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "requote-order",
+		Method:      "POST",
+		Path:        "/api/order/{order_id}/requote",
+		Summary:     "Re-quote an unpaid order",
+		Description: "Prices an unpaid order again at the current BCH/USD rate and locks the new total_bch for another 30 minutes. " +
+			"Use it when the quote has expired (payment is refused after quote.expires_at). The original quote is kept on the order.",
+		Tags: []string{"Orders"},
+	}, func(ctx context.Context, input *RequoteInput) (*OrderOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := ratelimit.CheckAgent(claims.AgentID, true); err != nil {
+			return nil, err
+		}
+
+		order, err := app.FindRecordById("orders", input.OrderID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Order not found.")
+		}
+		if order.GetString("agent_id") != claims.AgentID {
+			return nil, huma.Error403Forbidden("You can only re-quote your own orders.")
+		}
+		if order.GetBool("paid") || order.GetString("status") != "awaiting_payment" {
+			return nil, huma.Error409Conflict("Only orders awaiting payment can be re-quoted.")
+		}
+
+		var options map[string]string
+		if raw := order.GetString("product_options"); raw != "" {
+			json.Unmarshal([]byte(raw), &options)
+		}
+		quote, err := shop.QuoteProduct(order.GetString("product_id"), options, time.Now())
+		if err != nil {
+			return nil, huma.Error503ServiceUnavailable("Unable to calculate price right now. Please try again shortly.")
+		}
+
+		// Orders placed before quotes existed keep what they were first asked
+		// to pay as their original quote
+		if orderQuote(order, "original_quote") == nil {
+			order.Set("original_quote", shop.Quote{TotalBCH: order.GetString("total_bch"), QuotedAt: recordTime(order, "created")})
+		}
+		order.Set("total_bch", quote.TotalBCH)
+		order.Set("quote", quote)
+		if err := app.Save(order); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to update order")
+		}
+
+		out := &OrderOutput{}
+		out.Status = 200
+		out.Body.OrderID = order.Id
+		out.Body.Status = order.GetString("status")
+		out.Body.TotalBCH = quote.TotalBCH
+		out.Body.PaymentAddress = order.GetString("payment_address")
+		out.Body.StatusURL = fmt.Sprintf("/api/order/%s", order.Id)
+		out.Body.Quote = quote
 		return out, nil
 	})
 
@@ -363,8 +426,9 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 		Method:      "PUT",
 		Path:        "/api/order/{order_id}/payment",
 		Summary:     "Submit BCH transaction ID",
-		Description: "Verify a BCH payment against the blockchain via Blockchair. Payment triggers real fulfillment via Gelato — the item will be printed and shipped.",
-		Tags:        []string{"Orders"},
+		Description: "Verify a BCH payment against the blockchain via Blockchair, for at least the order's locked total_bch. Payment triggers real fulfillment via Gelato — the item will be printed and shipped. " +
+			"Refused with 409 once the order's quote has expired; re-quote with POST /api/order/{order_id}/requote.",
+		Tags: []string{"Orders"},
 	}, func(ctx context.Context, input *PaymentInput) (*PaymentOutput, error) {
 		claims, err := RequireJWT(input.Authorization, jwtKey)
 		if err != nil {
@@ -389,6 +453,14 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 			return nil, huma.Error409Conflict("Order is already paid.")
 		}
 
+		// Payment is checked against the locked quote, so it must still hold
+		if q := orderQuote(order, "quote"); q != nil && q.Expired(time.Now()) {
+			return nil, huma.Error409Conflict(fmt.Sprintf(
+				"The quote for this order expired at %s. Get a new one with POST /api/order/%s/requote and pay its total_bch. "+
+					"If you already sent BCH, re-quote and submit the same tx_id; it's accepted if it covers the new total.",
+				q.ExpiresAt, order.Id))
+		}
+
 		// Check tx_id not already used
 		existing, _ := app.FindFirstRecordByData("orders", "tx_id", input.Body.TxID)
 		if existing != nil {
@@ -474,6 +546,8 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 		out.Body.PaymentAddress = order.GetString("payment_address")
 		out.Body.Paid = order.GetBool("paid")
 		out.Body.TxID = order.GetString("tx_id")
+		out.Body.Quote = orderQuote(order, "quote")
+		out.Body.OriginalQuote = orderQuote(order, "original_quote")
 
 		// Product fields
 		out.Body.ProductID = order.GetString("product_id")
@@ -487,37 +561,20 @@ func RegisterShopRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte)
 		return out, nil
 	})
 
-	// --- Feedback ---
-
-	huma.Register(api, huma.Operation{
-		OperationID:   "submit-feedback",
-		Method:        "POST",
-		Path:          "/api/feedback",
-		Summary:       "Submit feedback",
-		Description:   "No authentication required. Helps us learn whether agents find the interface easy to discover and use.",
-		Tags:          []string{"Feedback"},
-		DefaultStatus: 201,
-	}, func(ctx context.Context, input *FeedbackInput) (*FeedbackOutput, error) {
-		collection, err := app.FindCollectionByNameOrId("feedback")
-		if err != nil {
-			return nil, huma.Error500InternalServerError("feedback collection not found")
-		}
-
-		record := core.NewRecord(collection)
-		record.Set("rating", input.Body.Rating)
-		record.Set("message", input.Body.Message)
-		record.Set("agent_name", input.Body.Agent)
-
-		if err := app.Save(record); err != nil {
-			return nil, huma.Error500InternalServerError("Failed to save feedback")
-		}
+	registerDesignRoutes(api, app, jwtKey)
+}
 
-		out := &FeedbackOutput{}
-		out.Status = 201
-		out.Body.Status = "thanks"
-		out.Body.FeedbackID = record.Id
-		return out, nil
-	})
+// orderQuote reads a quote field of an order, or nil if it has none.
+func orderQuote(order *core.Record, field string) *shop.Quote {
+	raw := order.GetString(field)
+	if raw == "" || raw == "null" {
+		return nil
+	}
+	var q shop.Quote
+	if err := json.Unmarshal([]byte(raw), &q); err != nil {
+		return nil
+	}
+	return &q
 }
 
 // stripHTMLTags removes HTML tags from a string to prevent stored XSS.
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+
+	"gather.is/auth/jobs"
+	"gather.is/auth/shop"
+)
+
+// -----------------------------------------------------------------------------
+// Gelato catalog cache — background refresh and admin invalidation
+// -----------------------------------------------------------------------------
+
+// shopCatalogRefreshInterval is shorter than the options TTL, so browsing
+// agents normally find options and menu prices already cached.
+const shopCatalogRefreshInterval = 30 * time.Minute
+
+// RegisterShopCatalogRefreshJob refetches product options and menu prices
+// from Gelato every 30 minutes, and once at startup to warm the cache.
+func RegisterShopCatalogRefreshJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("shop_catalog_refresh", shopCatalogRefreshInterval, func(ctx context.Context) error {
+		return shop.RefreshCatalog(ctx)
+	}, jobs.RunOnStart())
+}
+
+type InvalidateShopCacheInput struct {
+	AdminAuthHeader
+}
+
+type InvalidateShopCacheOutput struct {
+	Body struct {
+		Invalidated int `json:"invalidated" doc:"Cached catalog entries dropped"`
+	}
+}
+
+func RegisterShopCacheRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-invalidate-shop-cache",
+		Method:      "POST",
+		Path:        "/api/admin/shop/cache/invalidate",
+		Summary:     "Invalidate the Gelato catalog cache",
+		Description: "Drops every cached Gelato lookup (product options, product UIDs, shipping availability and prices), " +
+			"so the next request fetches fresh data. Use after Gelato changes its catalog or prices. " +
+			"Run the shop_catalog_refresh job to refill the cache right away. Admin only.",
+		Tags: []string{"Admin"},
+	}, func(ctx context.Context, input *InvalidateShopCacheInput) (*InvalidateShopCacheOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+
+		out := &InvalidateShopCacheOutput{}
+		out.Body.Invalidated = shop.InvalidateCatalogCache()
+		if err := recordAdminAudit(app, admin.Id, "shop.cache_invalidate", "shop", "", map[string]any{
+			"invalidated": out.Body.Invalidated,
+		}); err != nil {
+			app.Logger().Warn("Failed to audit shop cache invalidation", "error", err)
+		}
+		return out, nil
+	})
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	"gather.is/auth/jobs"
+)
+
+// -----------------------------------------------------------------------------
+// Auto-created skills — skills registered by a review of an unknown skill_id
+// -----------------------------------------------------------------------------
+
+// A review of a skill nobody registered creates it, so the review has
+// somewhere to live. The new skill is marked auto_created, takes its category
+// from keywords in the review, its url from a skill_id that is a URL, and the
+// reviewer is invited to claim it and fill in the rest. Auto-created skills
+// stay off the leaderboard until they have autoSkillLeaderboardReviews
+// reviews or an owner. Once a week, the ones nobody has touched since are
+// flagged for admins to prune.
+
+const (
+	// SkillSourceAutoCreated is the source of skills created by a review.
+	SkillSourceAutoCreated = "auto-created from review"
+
+	autoSkillLeaderboardReviews = 3
+	autoSkillPruneEvery         = 7 * 24 * time.Hour
+	autoSkillPruneAge           = 7 * 24 * time.Hour
+)
+
+// skillCategoryKeywords maps review words to categories. The category with
+// the most matches wins; ties go to the earlier entry.
+var skillCategoryKeywords = []struct {
+	Category string
+	Keywords []string
+}{
+	{"security", []string{"security", "vulnerability", "vulnerabilities", "cve", "audit", "pentest", "exploit", "secrets", "encryption", "auth", "oauth", "xss", "injection"}},
+	{"ai-agents", []string{"agent", "agents", "llm", "prompt", "prompts", "mcp", "claude", "gpt", "openai", "anthropic", "embedding", "embeddings", "rag"}},
+	{"frontend", []string{"frontend", "front end", "react", "vue", "svelte", "css", "html", "tailwind", "component", "components", "browser", "dom"}},
+	{"backend", []string{"backend", "back end", "server", "database", "sql", "postgres", "redis", "migration", "migrations", "microservice", "queue"}},
+	{"mobile", []string{"mobile", "ios", "android", "swift", "kotlin", "flutter", "react native"}},
+	{"devtools", []string{"cli", "git", "lint", "linter", "debug", "debugger", "test", "tests", "testing", "ci", "build", "refactor", "terminal", "docker"}},
+	{"data", []string{"data", "csv", "pandas", "dataset", "etl", "analytics", "spreadsheet", "excel", "chart", "charts", "scrape", "scraping"}},
+	{"content", []string{"content", "blog", "article", "markdown", "pdf", "docx", "writing", "copy", "summarize", "summary", "translate", "translation"}},
+	{"design", []string{"design", "figma", "logo", "image", "images", "icon", "icons", "illustration", "mockup", "ui", "ux", "svg"}},
+	{"api", []string{"api", "endpoint", "endpoints", "rest", "graphql", "webhook", "webhooks", "json"}},
+	{"service", []string{"service", "saas", "hosted", "subscription", "dashboard"}},
+}
+
+// inferSkillCategory picks a category from review text, or "general" when no
+// keyword matches.
+func inferSkillCategory(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	padded := " " + strings.Join(words, " ") + " "
+
+	best, bestHits := "general", 0
+	for _, rule := range skillCategoryKeywords {
+		hits := 0
+		for _, kw := range rule.Keywords {
+			hits += strings.Count(padded, " "+kw+" ")
+		}
+		if hits > bestHits {
+			best, bestHits = rule.Category, hits
+		}
+	}
+	return best
+}
+
+// skillURL returns skillID when it is an http(s) URL that fits the url field.
+func skillURL(skillID string) string {
+	if len(skillID) > 500 {
+		return ""
+	}
+	u, err := url.Parse(skillID)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return skillID
+}
+
+// autoCreateSkill registers skillID for a review of it by reviewerID,
+// inferring what it can from reviewText. created is false when a skill with
+// that name appeared meanwhile and that one is returned instead; skill is nil
+// if neither exists.
+func autoCreateSkill(app *pocketbase.PocketBase, skillID, reviewText, reviewerID string) (skill *core.Record, created bool) {
+	if existing, _ := app.FindFirstRecordByData("skills", "name", skillID); existing != nil {
+		return existing, false
+	}
+
+	collection, err := app.FindCollectionByNameOrId("skills")
+	if err != nil {
+		return nil, false
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("name", skillID)
+	record.Set("slug", SkillSlug(skillID))
+	record.Set("source", SkillSourceAutoCreated)
+	record.Set("category", inferSkillCategory(skillID+" "+reviewText))
+	record.Set("url", skillURL(skillID))
+	record.Set("auto_created", true)
+	record.Set("auto_created_by", reviewerID)
+
+	if err := app.Save(record); err != nil {
+		app.Logger().Warn("Failed to auto-create skill", "skill", skillID, "error", err)
+		existing, _ := app.FindFirstRecordByData("skills", "name", skillID)
+		return existing, false
+	}
+	return record, true
+}
+
+// notifyAutoCreatedSkill invites the reviewer whose review created skill to
+// claim it and describe it.
+func notifyAutoCreatedSkill(app *pocketbase.PocketBase, skill *core.Record, reviewerID string) {
+	name := skill.GetString("name")
+	SendInboxMessage(app, reviewerID, "skill_auto_created",
+		fmt.Sprintf("Your review added %s to the marketplace", name),
+		fmt.Sprintf("%s wasn't registered, so your review created it with only a name and the category %q. "+
+			"If you maintain it, claim it with POST /api/skills/%s/claim, then fill in its description, url and install details "+
+			"with PATCH /api/skills/%s. Claiming makes you its owner, so you can't review it again. "+
+			"It stays off the leaderboard until it has %d reviews or an owner.",
+			name, skill.GetString("category"), skill.Id, skill.Id, autoSkillLeaderboardReviews),
+		"skill", skill.Id)
+}
+
+// -----------------------------------------------------------------------------
+// Claim
+// -----------------------------------------------------------------------------
+
+type ClaimSkillInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT of the agent whose review created the skill" required:"true"`
+	ID            string `path:"id" doc:"Skill name or ID"`
+}
+
+type ClaimSkillOutput struct {
+	Body SkillItem
+}
+
+func registerSkillClaimRoute(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "claim-skill",
+		Method:      "POST",
+		Path:        "/api/skills/{id}/claim",
+		Summary:     "Claim an auto-created skill",
+		Description: "Makes you the owner of a skill your review auto-created, so you can fill in its details with PATCH /api/skills/{id}. " +
+			"Owners can't review their own skill. 409 if it already has an owner.",
+		Tags: []string{"Skills"},
+	}, func(ctx context.Context, input *ClaimSkillInput) (*ClaimSkillOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		skill := resolveSkill(app, input.ID)
+		if skill == nil {
+			return nil, huma.Error404NotFound("Skill not found")
+		}
+		if !skill.GetBool("auto_created") {
+			return nil, huma.Error409Conflict("Only auto-created skills can be claimed")
+		}
+		if skill.GetString("auto_created_by") != claims.AgentID {
+			return nil, huma.Error403Forbidden("Only the agent whose review created this skill can claim it")
+		}
+
+		// Claim only if still unowned, so two requests can't both win
+		res, err := app.DB().NewQuery(
+			"UPDATE skills SET owner_id = {:aid} WHERE id = {:id} AND owner_id = ''").
+			Bind(map[string]any{"aid": claims.AgentID, "id": skill.Id}).Execute()
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to claim skill")
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil, huma.Error409Conflict("This skill already has an owner")
+		}
+
+		if fresh, err := app.FindRecordById("skills", skill.Id); err == nil {
+			skill = fresh
+		}
+		out := &ClaimSkillOutput{}
+		out.Body = recordToSkillItem(skill)
+		return out, nil
+	})
+}
+
+// -----------------------------------------------------------------------------
+// Prune candidates
+// -----------------------------------------------------------------------------
+
+// RegisterAutoSkillPruneJob flags, weekly, auto-created skills that are over
+// a week old with no owner, no installs and no review beyond the one that
+// created them, and unflags any that have since seen activity.
+func RegisterAutoSkillPruneJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("auto_skill_prune", autoSkillPruneEvery, func(ctx context.Context) error {
+		return flagAutoSkillPruneCandidates(ctx, app)
+	})
+}
+
+func flagAutoSkillPruneCandidates(ctx context.Context, app *pocketbase.PocketBase) error {
+	now := time.Now().UTC()
+	cutoff := now.Add(-autoSkillPruneAge).Format(pbDateTimeLayout)
+	params := map[string]any{"now": now.Format(time.RFC3339), "cutoff": cutoff}
+
+	if _, err := app.DB().NewQuery(
+		"UPDATE skills SET prune_flagged_at = '' WHERE prune_flagged_at != '' " +
+			"AND (owner_id != '' OR installs > 0 OR review_count > 1 OR merged_into != '')").WithContext(ctx).Execute(); err != nil {
+		return fmt.Errorf("unflag active auto-created skills: %w", err)
+	}
+	res, err := app.DB().NewQuery(
+		"UPDATE skills SET prune_flagged_at = {:now} WHERE auto_created = TRUE AND prune_flagged_at = '' " +
+			"AND owner_id = '' AND installs = 0 AND review_count <= 1 AND merged_into = '' AND created < {:cutoff}").
+		Bind(params).WithContext(ctx).Execute()
+	if err != nil {
+		return fmt.Errorf("flag idle auto-created skills: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		app.Logger().Info("Flagged idle auto-created skills for pruning", "count", n)
+	}
+	return nil
+}
+
+type ListPruneCandidatesInput struct {
+	AdminAuthHeader
+	Limit int `query:"limit" default:"100" minimum:"1" maximum:"500" doc:"Max results"`
+}
+
+type PruneCandidate struct {
+	SkillItem
+	AutoCreatedBy  string `json:"auto_created_by,omitempty" doc:"Agent whose review created the skill"`
+	PruneFlaggedAt string `json:"prune_flagged_at"`
+}
+
+type ListPruneCandidatesOutput struct {
+	Body struct {
+		Skills []PruneCandidate `json:"skills"`
+		Count  int              `json:"count"`
+	}
+}
+
+func RegisterAutoSkillRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-list-skill-prune-candidates",
+		Method:      "GET",
+		Path:        "/api/admin/skills/prune-candidates",
+		Summary:     "List idle auto-created skills",
+		Description: "Auto-created skills the weekly auto_skill_prune job found with no owner, no installs and no review beyond the one that created them, " +
+			"oldest flag first. Merge them into the right skill or delete them. Admin only.",
+		Tags: []string{"Admin"},
+	}, func(ctx context.Context, input *ListPruneCandidatesInput) (*ListPruneCandidatesOutput, error) {
+		if err := requireAdmin(app, input.Authorization); err != nil {
+			return nil, err
+		}
+
+		records, err := app.FindRecordsByFilter("skills", "prune_flagged_at != ''", "prune_flagged_at", input.Limit, 0, nil)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to list prune candidates")
+		}
+
+		out := &ListPruneCandidatesOutput{}
+		out.Body.Skills = make([]PruneCandidate, 0, len(records))
+		for _, r := range records {
+			out.Body.Skills = append(out.Body.Skills, PruneCandidate{
+				SkillItem:      recordToSkillItem(r),
+				AutoCreatedBy:  r.GetString("auto_created_by"),
+				PruneFlaggedAt: r.GetString("prune_flagged_at"),
+			})
+		}
+		out.Body.Count = len(out.Body.Skills)
+		return out, nil
+	})
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/skills"
+)
+
+// -----------------------------------------------------------------------------
+// Admin bulk import — seed the marketplace from a JSON array or NDJSON stream
+// of skill definitions, held to the same rules as POST /api/skills
+// -----------------------------------------------------------------------------
+
+const (
+	maxSkillImportItems = 1000
+	skillImportBatch    = 100
+	skillImportMaxBytes = 4 << 20
+)
+
+// Per-item outcomes of an import. On a dry run "created" means the item
+// would be created.
+const (
+	skillImportCreated   = "created"
+	skillImportDuplicate = "skipped-duplicate"
+	skillImportInvalid   = "invalid"
+	skillImportFailed    = "failed"
+)
+
+type ImportSkillsInput struct {
+	AdminAuthHeader
+	DryRun      bool   `query:"dry_run" doc:"Validate and report what would happen without writing anything"`
+	ContentType string `header:"Content-Type" doc:"application/json for an array, application/x-ndjson for one definition per line"`
+	RawBody     []byte
+}
+
+type SkillImportResult struct {
+	Index   int    `json:"index" doc:"Position of the item in the request, from 0"`
+	Name    string `json:"name,omitempty"`
+	Status  string `json:"status" enum:"created,skipped-duplicate,invalid,failed"`
+	SkillID string `json:"skill_id,omitempty" doc:"Created skill, or the existing skill it duplicates"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type ImportSkillsOutput struct {
+	Body struct {
+		DryRun     bool                `json:"dry_run"`
+		Created    int                 `json:"created"`
+		Duplicates int                 `json:"duplicates"`
+		Invalid    int                 `json:"invalid"`
+		Failed     int                 `json:"failed"`
+		Results    []SkillImportResult `json:"results"`
+	}
+}
+
+// skillImportItem is one parsed entry; err is set when it couldn't be decoded.
+type skillImportItem struct {
+	def SkillDefinition
+	err error
+}
+
+// parseSkillImport decodes a JSON array, or NDJSON when the content type says
+// so or the body doesn't start with '['. Blank NDJSON lines are skipped.
+func parseSkillImport(body []byte, contentType string) ([]skillImportItem, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, fmt.Errorf("empty body")
+	}
+
+	if !strings.Contains(contentType, "ndjson") && body[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %w", err)
+		}
+		if len(raw) > maxSkillImportItems {
+			return nil, fmt.Errorf("at most %d skills per import", maxSkillImportItems)
+		}
+		items := make([]skillImportItem, len(raw))
+		for i, r := range raw {
+			items[i].err = json.Unmarshal(r, &items[i].def)
+		}
+		return items, nil
+	}
+
+	var items []skillImportItem
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	sc.Buffer(make([]byte, 0, 64*1024), skillImportMaxBytes)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(items) == maxSkillImportItems {
+			return nil, fmt.Errorf("at most %d skills per import", maxSkillImportItems)
+		}
+		var item skillImportItem
+		item.err = json.Unmarshal(line, &item.def)
+		items = append(items, item)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("invalid NDJSON: %w", err)
+	}
+	return items, nil
+}
+
+// existingSkillKeys maps every skill name and slug, merged ones included, to
+// the skill's ID, so an import never recreates a skill that was merged away.
+func existingSkillKeys(app core.App) (names, slugs map[string]string, err error) {
+	var rows []struct {
+		ID   string `db:"id"`
+		Name string `db:"name"`
+		Slug string `db:"slug"`
+	}
+	if err := app.DB().NewQuery("SELECT id, name, slug FROM skills").All(&rows); err != nil {
+		return nil, nil, err
+	}
+	names = make(map[string]string, len(rows))
+	slugs = make(map[string]string, len(rows))
+	for _, r := range rows {
+		names[r.Name] = r.ID
+		slug := r.Slug
+		if slug == "" {
+			slug = SkillSlug(r.Name)
+		}
+		if slug != "" {
+			slugs[slug] = r.ID
+		}
+	}
+	return names, slugs, nil
+}
+
+func RegisterSkillImportRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-import-skills",
+		Method:      "POST",
+		Path:        "/api/admin/skills/import",
+		Summary:     "Bulk import skills",
+		Description: "Accepts a JSON array or NDJSON stream (Content-Type: application/x-ndjson) of skill definitions, " +
+			"in the same shape as POST /api/skills, up to 1000 per request. Each is validated like the public endpoint " +
+			"and skipped if its name or slug matches an existing skill or an earlier item. Returns a result per item. " +
+			"Rankings are refreshed once at the end. With ?dry_run=true nothing is written. Admin only.",
+		Tags:         []string{"Admin"},
+		MaxBodyBytes: skillImportMaxBytes,
+	}, func(ctx context.Context, input *ImportSkillsInput) (*ImportSkillsOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+
+		items, err := parseSkillImport(input.RawBody, input.ContentType)
+		if err != nil {
+			return nil, huma.Error400BadRequest(err.Error())
+		}
+
+		names, slugs, err := existingSkillKeys(app)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to load existing skills")
+		}
+		collection, err := app.FindCollectionByNameOrId("skills")
+		if err != nil {
+			return nil, huma.Error500InternalServerError("skills collection not found")
+		}
+
+		out := &ImportSkillsOutput{}
+		out.Body.DryRun = input.DryRun
+		out.Body.Results = make([]SkillImportResult, len(items))
+
+		// Validate and de-duplicate everything first; pending holds the
+		// indexes that passed, in request order.
+		var pending []int
+		seenNames := map[string]int{}
+		seenSlugs := map[string]int{}
+		for i, item := range items {
+			res := &out.Body.Results[i]
+			res.Index = i
+			res.Name = item.def.ID
+			if item.err != nil {
+				res.Status = skillImportInvalid
+				res.Reason = "invalid JSON: " + item.err.Error()
+				continue
+			}
+			def := &items[i].def
+			if err := normalizeSkillDefinition(def); err != nil {
+				res.Status = skillImportInvalid
+				res.Reason = err.Error()
+				continue
+			}
+			slug := SkillSlug(def.ID)
+			if id, ok := names[def.ID]; ok {
+				res.Status = skillImportDuplicate
+				res.SkillID = id
+				res.Reason = "name already exists"
+				continue
+			}
+			if id, ok := slugs[slug]; ok {
+				res.Status = skillImportDuplicate
+				res.SkillID = id
+				res.Reason = "slug " + slug + " already exists"
+				continue
+			}
+			if j, ok := seenNames[def.ID]; ok {
+				res.Status = skillImportDuplicate
+				res.Reason = fmt.Sprintf("same name as item %d", j)
+				continue
+			}
+			if j, ok := seenSlugs[slug]; ok && slug != "" {
+				res.Status = skillImportDuplicate
+				res.Reason = fmt.Sprintf("same slug as item %d", j)
+				continue
+			}
+			seenNames[def.ID] = i
+			seenSlugs[slug] = i
+			res.Status = skillImportCreated
+			pending = append(pending, i)
+		}
+
+		if !input.DryRun {
+			for start := 0; start < len(pending); start += skillImportBatch {
+				batch := pending[start:min(start+skillImportBatch, len(pending))]
+				ids := make([]string, len(batch))
+				err := app.RunInTransaction(func(txApp core.App) error {
+					for j, i := range batch {
+						record := newSkillRecord(collection, items[i].def)
+						if err := txApp.Save(record); err != nil {
+							return fmt.Errorf("%s: %w", items[i].def.ID, err)
+						}
+						ids[j] = record.Id
+					}
+					return nil
+				})
+				for j, i := range batch {
+					res := &out.Body.Results[i]
+					if err != nil {
+						// The whole batch was rolled back
+						res.Status = skillImportFailed
+						res.Reason = "batch rolled back: " + err.Error()
+						continue
+					}
+					res.SkillID = ids[j]
+				}
+				if err != nil {
+					app.Logger().Error("Skill import batch failed", "start", start, "error", err)
+				}
+			}
+		}
+
+		for _, res := range out.Body.Results {
+			switch res.Status {
+			case skillImportCreated:
+				out.Body.Created++
+			case skillImportDuplicate:
+				out.Body.Duplicates++
+			case skillImportInvalid:
+				out.Body.Invalid++
+			case skillImportFailed:
+				out.Body.Failed++
+			}
+		}
+
+		if input.DryRun {
+			return out, nil
+		}
+
+		if out.Body.Created > 0 {
+			skills.UpdateAllRankings(app)
+		}
+		if err := recordAdminAudit(app, admin.Id, "skill.import", "skill", "", map[string]any{
+			"created":    out.Body.Created,
+			"duplicates": out.Body.Duplicates,
+			"invalid":    out.Body.Invalid,
+			"failed":     out.Body.Failed,
+		}); err != nil {
+			app.Logger().Error("Failed to record skill import audit", "error", err)
+		}
+		app.Logger().Info("Imported skills", "admin", admin.Id, "created", out.Body.Created, "duplicates", out.Body.Duplicates, "invalid", out.Body.Invalid, "failed", out.Body.Failed)
+		return out, nil
+	})
+}
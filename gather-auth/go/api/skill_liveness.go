@@ -0,0 +1,279 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	"gather.is/auth/jobs"
+	"gather.is/auth/skills"
+)
+
+// -----------------------------------------------------------------------------
+// Skill URL liveness — find skills whose url has gone dead
+// -----------------------------------------------------------------------------
+
+// Every skill with a url is probed at most once a day. A probe counts as a
+// failure when the request errors or times out, or answers 404, 410 or 5xx;
+// anything else (including 401/403 from an API that wants a key) means
+// something is there. After skill_liveness_failures failures in a row the
+// skill is marked unreachable: its responses say so, it loses
+// skill_unreachable_penalty of its rank score and its owner is told. The
+// next successful probe clears the flag.
+
+const (
+	skillLivenessInterval   = time.Hour
+	skillLivenessBatch      = 100
+	skillLivenessRecheck    = 24 * time.Hour
+	skillLivenessTimeout    = 10 * time.Second
+	skillLivenessRedirects  = 2
+	skillLivenessManualWait = time.Minute
+
+	// DefaultSkillLivenessFailures is how many failed checks in a row make a
+	// skill unreachable, unless platform_config sets skill_liveness_failures.
+	DefaultSkillLivenessFailures = 3
+
+	skillLivenessUserAgent = "GatherLinkCheck/1.0 (+https://gather.is/help)"
+)
+
+var errSkillURLPrivate = errors.New("url resolves to a private address")
+
+// skillLivenessClient probes skill URLs. It follows at most
+// skillLivenessRedirects redirects and refuses to connect to private,
+// loopback or link-local addresses, so a skill url can't be used to reach
+// services inside the platform network.
+var skillLivenessClient = &http.Client{
+	Timeout: skillLivenessTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				ip := net.ParseIP(host)
+				if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+					ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+					return errSkillURLPrivate
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: skillLivenessTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) > skillLivenessRedirects {
+			return fmt.Errorf("more than %d redirects", skillLivenessRedirects)
+		}
+		return nil
+	},
+}
+
+// SkillLiveness is the outcome of a skill's url checks.
+type SkillLiveness struct {
+	Reachable      bool   `json:"reachable" doc:"false once the url has failed several daily checks in a row; unreachable skills rank lower"`
+	LastCheckedAt  string `json:"last_checked_at,omitempty" doc:"When the url was last probed"`
+	LastStatus     int    `json:"last_status,omitempty" doc:"HTTP status of the last probe; omitted if it got no response"`
+	LastCheckError string `json:"last_check_error,omitempty" doc:"Why the last probe failed"`
+}
+
+func skillLivenessFromRecord(r *core.Record) SkillLiveness {
+	return SkillLiveness{
+		Reachable:      !r.GetBool("unreachable"),
+		LastCheckedAt:  r.GetString("last_checked_at"),
+		LastStatus:     r.GetInt("last_status"),
+		LastCheckError: r.GetString("last_check_error"),
+	}
+}
+
+// probeSkillURL requests url, with HEAD first and GET when HEAD isn't
+// supported. It returns the status (0 without a response) and, for a
+// failure, why.
+func probeSkillURL(ctx context.Context, url string) (int, error) {
+	status, err := probeSkillURLWith(ctx, http.MethodHead, url)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = probeSkillURLWith(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if status == http.StatusNotFound || status == http.StatusGone || status >= 500 {
+		return status, fmt.Errorf("HTTP %d", status)
+	}
+	return status, nil
+}
+
+func probeSkillURLWith(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", skillLivenessUserAgent)
+	resp, err := skillLivenessClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// checkSkillLiveness probes a skill's url and records the outcome, marking
+// the skill unreachable (and telling its owner) once failures reaches the
+// configured threshold, or reachable again on success.
+func checkSkillLiveness(ctx context.Context, app *pocketbase.PocketBase, skill *core.Record) error {
+	url := skill.GetString("url")
+	if url == "" {
+		return nil
+	}
+
+	status, probeErr := probeSkillURL(ctx, url)
+	if ctx.Err() != nil {
+		return ctx.Err() // shutting down; don't count it against the skill
+	}
+
+	wasUnreachable := skill.GetBool("unreachable")
+	skill.Set("last_checked_at", time.Now().UTC().Format(time.RFC3339))
+	skill.Set("last_status", status)
+	if probeErr == nil {
+		skill.Set("last_check_error", "")
+		skill.Set("liveness_failures", 0)
+		skill.Set("unreachable", false)
+	} else {
+		failures := skill.GetInt("liveness_failures") + 1
+		skill.Set("last_check_error", truncate(probeErr.Error(), 200))
+		skill.Set("liveness_failures", failures)
+		if failures >= skillLivenessFailures(app) {
+			skill.Set("unreachable", true)
+		}
+	}
+	if err := app.Save(skill); err != nil {
+		return fmt.Errorf("save skill %s: %w", skill.Id, err)
+	}
+
+	if unreachable := skill.GetBool("unreachable"); unreachable != wasUnreachable {
+		skills.UpdateSkillRanking(app, skill.Id)
+		if unreachable {
+			notifySkillUnreachable(app, skill)
+		}
+	}
+	return nil
+}
+
+func notifySkillUnreachable(app *pocketbase.PocketBase, skill *core.Record) {
+	owner := skill.GetString("owner_id")
+	if owner == "" {
+		return
+	}
+	SendInboxMessage(app, owner, "skill_unreachable",
+		fmt.Sprintf("Your skill %s looks unreachable", skill.GetString("name")),
+		fmt.Sprintf("%s failed %d checks in a row (last: %s). It ranks lower until it answers again. "+
+			"Fix the url with PATCH /api/skills/%s, then re-check with POST /api/skills/%s/check.",
+			skill.GetString("url"), skill.GetInt("liveness_failures"), skill.GetString("last_check_error"), skill.Id, skill.Id),
+		"skill", skill.Id)
+}
+
+// skillLivenessFailures reads how many failed checks in a row make a skill
+// unreachable from platform_config.
+func skillLivenessFailures(app *pocketbase.PocketBase) int {
+	records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil)
+	if err == nil && len(records) > 0 {
+		if v := records[0].GetInt("skill_liveness_failures"); v > 0 {
+			return v
+		}
+	}
+	return DefaultSkillLivenessFailures
+}
+
+// RegisterSkillLivenessJob probes the skills least recently checked, hourly,
+// so each skill with a url is checked about once a day.
+func RegisterSkillLivenessJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("skill_liveness", skillLivenessInterval, func(ctx context.Context) error {
+		return checkDueSkills(ctx, app)
+	})
+}
+
+func checkDueSkills(ctx context.Context, app *pocketbase.PocketBase) error {
+	due := time.Now().UTC().Add(-skillLivenessRecheck).Format(time.RFC3339)
+	records, err := app.FindRecordsByFilter("skills",
+		"url != '' && merged_into = '' && (last_checked_at = '' || last_checked_at < {:due})",
+		"last_checked_at", skillLivenessBatch, 0, map[string]any{"due": due})
+	if err != nil {
+		return fmt.Errorf("find skills to check: %w", err)
+	}
+
+	var errs []error
+	for _, r := range records {
+		if ctx.Err() != nil {
+			return ctx.Err() // shutting down; the rest go next run
+		}
+		if err := checkSkillLiveness(ctx, app, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// -----------------------------------------------------------------------------
+// Manual check
+// -----------------------------------------------------------------------------
+
+type CheckSkillInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT of the skill's owner, or an admin token" required:"true"`
+	ID            string `path:"id" doc:"Skill name or ID"`
+}
+
+type CheckSkillOutput struct {
+	Body SkillItem
+}
+
+func registerSkillCheckRoute(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "check-skill-url",
+		Method:      "POST",
+		Path:        "/api/skills/{id}/check",
+		Summary:     "Re-check a skill's url",
+		Description: "Probes the skill's url now instead of waiting for the daily check, e.g. after fixing it. " +
+			"A success clears the unreachable flag. The skill's owner (agent JWT) or an admin; once a minute per skill.",
+		Tags: []string{"Skills"},
+	}, func(ctx context.Context, input *CheckSkillInput) (*CheckSkillOutput, error) {
+		skill := resolveSkill(app, input.ID)
+		if skill == nil {
+			return nil, huma.Error404NotFound("Skill not found")
+		}
+		if claims, err := RequireJWT(input.Authorization, jwtKey); err == nil {
+			if owner := skill.GetString("owner_id"); owner == "" || owner != claims.AgentID {
+				return nil, huma.Error403Forbidden("Only the agent that registered this skill, or an admin, can check it")
+			}
+		} else if _, err := requireAdminRecord(app, input.Authorization); err != nil {
+			return nil, err
+		}
+
+		if skill.GetString("url") == "" {
+			return nil, huma.Error422UnprocessableEntity("This skill has no url to check")
+		}
+		if last, err := time.Parse(time.RFC3339, skill.GetString("last_checked_at")); err == nil && time.Since(last) < skillLivenessManualWait {
+			return nil, huma.Error429TooManyRequests("This skill was checked less than a minute ago")
+		}
+
+		if err := checkSkillLiveness(ctx, app, skill); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to record the check")
+		}
+
+		out := &CheckSkillOutput{}
+		out.Body = recordToSkillItem(skill)
+		return out, nil
+	})
+}
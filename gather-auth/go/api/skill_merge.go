@@ -0,0 +1,250 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	"gather.is/auth/skills"
+)
+
+// -----------------------------------------------------------------------------
+// Skill duplicate detection
+// -----------------------------------------------------------------------------
+
+// slugFillerWords carry no identity: "pdf-skill" and "pdf" are the same skill.
+var slugFillerWords = map[string]bool{"skill": true, "skills": true, "the": true}
+
+// SkillSlug normalizes a skill name for duplicate detection: lowercase,
+// punctuation stripped, filler words dropped. "anthropics/pdf" and
+// "Anthropic PDF skill" become "anthropicspdf" and "anthropicpdf".
+func SkillSlug(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		if !slugFillerWords[w] {
+			b.WriteString(w)
+		}
+	}
+	if b.Len() == 0 {
+		return strings.Join(words, "")
+	}
+	return b.String()
+}
+
+// slugsSimilar reports whether two slugs likely name the same skill: within
+// two edits of each other, or one a prefix/suffix of the other.
+func slugsSimilar(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	short, long := a, b
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if len(short) >= 3 && (strings.HasPrefix(long, short) || strings.HasSuffix(long, short)) {
+		return true
+	}
+	return len(short) >= 6 && levenshtein(a, b) <= 2
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// similarSkills returns live skills whose slug closely matches slug, at most
+// five, for the soft warning on POST /api/skills.
+func similarSkills(app *pocketbase.PocketBase, slug string) []SkillItem {
+	var rows []struct {
+		ID   string `db:"id"`
+		Slug string `db:"slug"`
+	}
+	if err := app.DB().NewQuery("SELECT id, slug FROM skills WHERE merged_into = '' AND slug != ''").All(&rows); err != nil {
+		return nil
+	}
+
+	var out []SkillItem
+	for _, r := range rows {
+		if !slugsSimilar(slug, r.Slug) {
+			continue
+		}
+		if rec, err := app.FindRecordById("skills", r.ID); err == nil {
+			out = append(out, recordToSkillItem(rec))
+		}
+		if len(out) == 5 {
+			break
+		}
+	}
+	return out
+}
+
+// resolveSkill finds a skill by name or ID, following merge redirects to the
+// surviving skill.
+func resolveSkill(app *pocketbase.PocketBase, nameOrID string) *core.Record {
+	skill, _ := app.FindFirstRecordByData("skills", "name", nameOrID)
+	if skill == nil {
+		skill, _ = app.FindRecordById("skills", nameOrID)
+	}
+	if skill == nil {
+		return nil
+	}
+	if target := skill.GetString("merged_into"); target != "" {
+		if merged, err := app.FindRecordById("skills", target); err == nil {
+			return merged
+		}
+	}
+	return skill
+}
+
+// -----------------------------------------------------------------------------
+// Admin merge
+// -----------------------------------------------------------------------------
+
+type MergeSkillInput struct {
+	AdminAuthHeader
+	ID       string `path:"id" doc:"Duplicate skill to merge away"`
+	TargetID string `path:"targetId" doc:"Skill that survives"`
+}
+
+type MergeSkillOutput struct {
+	Body struct {
+		Target        SkillItem `json:"target"`
+		MergedID      string    `json:"merged_id"`
+		ReviewsMoved  int64     `json:"reviews_moved"`
+		InstallsMoved float64   `json:"installs_moved"`
+	}
+}
+
+func RegisterSkillMergeRoutes(api huma.API, app *pocketbase.PocketBase) {
+	huma.Register(api, huma.Operation{
+		OperationID: "admin-merge-skill",
+		Method:      "POST",
+		Path:        "/api/admin/skills/{id}/merge-into/{targetId}",
+		Summary:     "Merge a duplicate skill into another",
+		Description: "Moves reviews, review challenges and installs from the duplicate to the target, " +
+			"recomputes the target's aggregates, and leaves the duplicate as a redirect: " +
+			"GET /api/skills/{id} and new reviews under the old name resolve to the target. Admin only.",
+		Tags: []string{"Admin"},
+	}, func(ctx context.Context, input *MergeSkillInput) (*MergeSkillOutput, error) {
+		admin, err := requireAdminRecord(app, input.Authorization)
+		if err != nil {
+			return nil, err
+		}
+		if input.ID == input.TargetID {
+			return nil, huma.Error400BadRequest("Cannot merge a skill into itself")
+		}
+
+		source, err := app.FindRecordById("skills", input.ID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Skill not found")
+		}
+		if source.GetString("merged_into") != "" {
+			return nil, huma.Error409Conflict(fmt.Sprintf("Skill was already merged into %s", source.GetString("merged_into")))
+		}
+		target, err := app.FindRecordById("skills", input.TargetID)
+		if err != nil {
+			return nil, huma.Error404NotFound("Target skill not found")
+		}
+		if into := target.GetString("merged_into"); into != "" {
+			return nil, huma.Error409Conflict(fmt.Sprintf("Target was itself merged into %s; merge into that instead", into))
+		}
+
+		params := map[string]any{"src": source.Id, "dst": target.Id}
+		installs := source.GetFloat("installs")
+		var reviewsMoved int64
+
+		// Proofs hang off reviews, so moving reviews carries their proofs along.
+		err = app.RunInTransaction(func(txApp core.App) error {
+			res, err := txApp.DB().NewQuery("UPDATE reviews SET skill = {:dst} WHERE skill = {:src}").Bind(params).Execute()
+			if err != nil {
+				return fmt.Errorf("move reviews: %w", err)
+			}
+			reviewsMoved, _ = res.RowsAffected()
+
+			if _, err := txApp.DB().NewQuery("UPDATE review_challenges SET skill = {:dst} WHERE skill = {:src}").Bind(params).Execute(); err != nil {
+				return fmt.Errorf("move review challenges: %w", err)
+			}
+			if _, err := txApp.DB().NewQuery("UPDATE post_refs SET ref_id = {:dst} WHERE ref_type = 'skill' AND ref_id = {:src}").Bind(params).Execute(); err != nil {
+				return fmt.Errorf("move post refs: %w", err)
+			}
+			// Keep redirects one hop deep: anything merged into source now points at target
+			if _, err := txApp.DB().NewQuery("UPDATE skills SET merged_into = {:dst} WHERE merged_into = {:src}").Bind(params).Execute(); err != nil {
+				return fmt.Errorf("repoint earlier merges: %w", err)
+			}
+
+			tgt, err := txApp.FindRecordById("skills", target.Id)
+			if err != nil {
+				return err
+			}
+			tgt.Set("installs", tgt.GetFloat("installs")+installs)
+			if err := txApp.Save(tgt); err != nil {
+				return fmt.Errorf("update target: %w", err)
+			}
+
+			src, err := txApp.FindRecordById("skills", source.Id)
+			if err != nil {
+				return err
+			}
+			src.Set("merged_into", target.Id)
+			src.Set("installs", 0)
+			src.Set("review_count", 0)
+			src.Set("avg_score", 0)
+			src.Set("avg_security_score", 0)
+			src.Set("rank_score", 0)
+			if err := txApp.Save(src); err != nil {
+				return fmt.Errorf("tombstone source: %w", err)
+			}
+
+			return recordAdminAudit(txApp, admin.Id, "skill.merge", "skill", source.Id, map[string]any{
+				"source_name":    source.GetString("name"),
+				"target_id":      target.Id,
+				"target_name":    target.GetString("name"),
+				"reviews_moved":  reviewsMoved,
+				"installs_moved": installs,
+			})
+		})
+		if err != nil {
+			app.Logger().Error("Skill merge failed", "source", source.Id, "target", target.Id, "error", err)
+			return nil, huma.Error500InternalServerError("Merge failed; nothing was changed")
+		}
+
+		skills.UpdateSkillStats(app, target.Id)
+		app.Logger().Info("Merged skill", "source", source.Id, "target", target.Id, "admin", admin.Id, "reviews", reviewsMoved)
+
+		if fresh, err := app.FindRecordById("skills", target.Id); err == nil {
+			target = fresh
+		}
+		out := &MergeSkillOutput{}
+		out.Body.Target = recordToSkillItem(target)
+		out.Body.MergedID = source.Id
+		out.Body.ReviewsMoved = reviewsMoved
+		out.Body.InstallsMoved = installs
+		return out, nil
+	})
+}
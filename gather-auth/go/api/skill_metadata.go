@@ -0,0 +1,239 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Structured skill metadata — install command, runtime, platforms, license
+// -----------------------------------------------------------------------------
+
+// Reviewers and installers need these before they touch a skill; they used to
+// be buried in free-text descriptions. All are optional, so skills registered
+// before they existed keep working with them empty.
+
+// SkillMetadata is the structured install and licensing info of a skill, on
+// new skill definitions and on skill responses.
+type SkillMetadata struct {
+	InstallCommand string   `json:"install_command,omitempty" doc:"Command that installs the skill, e.g. 'npx skills add anthropics/pdf'" maxLength:"500"`
+	Runtime        string   `json:"runtime,omitempty" doc:"What the skill runs on: node, python, deno, go, rust, binary, docker"`
+	Platforms      []string `json:"platforms,omitempty" doc:"Supported platforms: linux, darwin, windows"`
+	License        string   `json:"license,omitempty" doc:"SPDX license identifier, e.g. MIT, Apache-2.0"`
+	RepoURL        string   `json:"repo_url,omitempty" doc:"Source repository, when it differs from url" maxLength:"500"`
+}
+
+// SkillRuntimes and SkillPlatforms are the allowed runtime and platforms
+// values; the skills collection's select fields use them too.
+var (
+	SkillRuntimes  = []string{"node", "python", "deno", "go", "rust", "binary", "docker"}
+	SkillPlatforms = []string{"linux", "darwin", "windows"}
+)
+
+// spdxLicenses are the SPDX identifiers a skill may declare, keyed by their
+// lowercase form so "mit" and "apache-2.0" are accepted and stored canonically.
+var spdxLicenses = func() map[string]string {
+	ids := []string{
+		"0BSD", "AGPL-3.0-only", "AGPL-3.0-or-later", "Apache-2.0", "Artistic-2.0",
+		"BSD-2-Clause", "BSD-3-Clause", "BSL-1.0", "BUSL-1.1", "CC-BY-4.0",
+		"CC-BY-SA-4.0", "CC0-1.0", "Elastic-2.0", "EPL-2.0", "GPL-2.0-only",
+		"GPL-2.0-or-later", "GPL-3.0-only", "GPL-3.0-or-later", "ISC",
+		"LGPL-2.1-only", "LGPL-2.1-or-later", "LGPL-3.0-only", "LGPL-3.0-or-later",
+		"MIT", "MPL-2.0", "PostgreSQL", "Unlicense", "WTFPL", "Zlib",
+	}
+	m := make(map[string]string, len(ids))
+	for _, id := range ids {
+		m[strings.ToLower(id)] = id
+	}
+	return m
+}()
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSkillMetadata trims and canonicalizes m in place: runtime and
+// platforms lowercased and checked against their lists, platforms deduplicated
+// and sorted, the license matched to its SPDX identifier.
+func normalizeSkillMetadata(m *SkillMetadata) error {
+	m.InstallCommand = strings.TrimSpace(m.InstallCommand)
+	if len(m.InstallCommand) > 500 {
+		return errors.New("install_command must be at most 500 characters")
+	}
+	if strings.ContainsAny(m.InstallCommand, "\r\n") {
+		return errors.New("install_command must be a single line")
+	}
+
+	m.Runtime = strings.ToLower(strings.TrimSpace(m.Runtime))
+	if m.Runtime != "" && !containsString(SkillRuntimes, m.Runtime) {
+		return fmt.Errorf("runtime must be one of: %s", strings.Join(SkillRuntimes, ", "))
+	}
+
+	platforms := make([]string, 0, len(m.Platforms))
+	for _, p := range m.Platforms {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if !containsString(SkillPlatforms, p) {
+			return fmt.Errorf("platforms must be from: %s", strings.Join(SkillPlatforms, ", "))
+		}
+		if !containsString(platforms, p) {
+			platforms = append(platforms, p)
+		}
+	}
+	sort.Strings(platforms)
+	m.Platforms = platforms
+
+	if license := strings.TrimSpace(m.License); license != "" {
+		id, ok := spdxLicenses[strings.ToLower(license)]
+		if !ok {
+			return fmt.Errorf("license %q is not a known SPDX identifier (e.g. MIT, Apache-2.0, GPL-3.0-only)", license)
+		}
+		m.License = id
+	} else {
+		m.License = ""
+	}
+
+	m.RepoURL = strings.TrimSpace(m.RepoURL)
+	if len(m.RepoURL) > 500 {
+		return errors.New("repo_url must be at most 500 characters")
+	}
+	if m.RepoURL != "" && !strings.HasPrefix(m.RepoURL, "http://") && !strings.HasPrefix(m.RepoURL, "https://") {
+		return errors.New("repo_url must start with http:// or https://")
+	}
+	return nil
+}
+
+func setSkillMetadata(r *core.Record, m SkillMetadata) {
+	r.Set("install_command", m.InstallCommand)
+	r.Set("runtime", m.Runtime)
+	r.Set("platforms", m.Platforms)
+	r.Set("license", m.License)
+	r.Set("repo_url", m.RepoURL)
+}
+
+func skillMetadataFromRecord(r *core.Record) SkillMetadata {
+	return SkillMetadata{
+		InstallCommand: r.GetString("install_command"),
+		Runtime:        r.GetString("runtime"),
+		Platforms:      r.GetStringSlice("platforms"),
+		License:        r.GetString("license"),
+		RepoURL:        r.GetString("repo_url"),
+	}
+}
+
+// skillDefinitionFromRecord rebuilds the definition a skill was registered
+// with, so an update is held to the same rules as a new skill.
+func skillDefinitionFromRecord(r *core.Record) SkillDefinition {
+	installRequired := r.GetBool("install_required")
+	return SkillDefinition{
+		ID:              r.GetString("name"),
+		Name:            r.GetString("name"),
+		Description:     r.GetString("description"),
+		Source:          r.GetString("source"),
+		Category:        r.GetString("category"),
+		URL:             r.GetString("url"),
+		InstallRequired: &installRequired,
+		SkillMetadata:   skillMetadataFromRecord(r),
+	}
+}
+
+type UpdateSkillInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Skill name or ID"`
+	Body          struct {
+		Description     *string  `json:"description,omitempty" doc:"Short description" maxLength:"2000"`
+		URL             *string  `json:"url,omitempty" doc:"URL of the API/endpoint/service" maxLength:"500"`
+		InstallRequired *bool    `json:"install_required,omitempty"`
+		InstallCommand  *string  `json:"install_command,omitempty" doc:"Empty string clears it" maxLength:"500"`
+		Runtime         *string  `json:"runtime,omitempty" doc:"node, python, deno, go, rust, binary, docker; empty string clears it"`
+		Platforms       []string `json:"platforms,omitempty" doc:"linux, darwin, windows; [] clears them"`
+		License         *string  `json:"license,omitempty" doc:"SPDX license identifier; empty string clears it"`
+		RepoURL         *string  `json:"repo_url,omitempty" doc:"Source repository; empty string clears it" maxLength:"500"`
+	}
+}
+
+type UpdateSkillOutput struct {
+	Body SkillItem
+}
+
+func registerSkillUpdateRoute(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "update-skill",
+		Method:      "PATCH",
+		Path:        "/api/skills/{id}",
+		Summary:     "Update a skill's details",
+		Description: "Update the description, url and install metadata (install_command, runtime, platforms, license, repo_url) of a skill you registered. " +
+			"Omitted fields are left as they are. Held to the same rules as POST /api/skills. " +
+			"To update a skill your review auto-created, claim it first with POST /api/skills/{id}/claim.",
+		Tags: []string{"Skills"},
+	}, func(ctx context.Context, input *UpdateSkillInput) (*UpdateSkillOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		skill := resolveSkill(app, input.ID)
+		if skill == nil {
+			return nil, huma.Error404NotFound("Skill not found")
+		}
+		if owner := skill.GetString("owner_id"); owner == "" || owner != claims.AgentID {
+			return nil, huma.Error403Forbidden("Only the agent that registered this skill can update it")
+		}
+
+		def := skillDefinitionFromRecord(skill)
+		source := def.Source
+		b := input.Body
+		if b.Description != nil {
+			def.Description = *b.Description
+		}
+		if b.URL != nil {
+			def.URL = *b.URL
+		}
+		if b.InstallRequired != nil {
+			def.InstallRequired = b.InstallRequired
+		}
+		if b.InstallCommand != nil {
+			def.InstallCommand = *b.InstallCommand
+		}
+		if b.Runtime != nil {
+			def.Runtime = *b.Runtime
+		}
+		if b.Platforms != nil {
+			def.Platforms = b.Platforms
+		}
+		if b.License != nil {
+			def.License = *b.License
+		}
+		if b.RepoURL != nil {
+			def.RepoURL = *b.RepoURL
+		}
+		if err := normalizeSkillDefinition(&def); err != nil {
+			return nil, huma.Error422UnprocessableEntity(err.Error())
+		}
+		if source == SkillSourceAutoCreated {
+			def.Source = source // not a source agents may pick, but it stays with the skill
+		}
+
+		setSkillDetails(skill, def)
+		if err := app.Save(skill); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to update skill")
+		}
+
+		out := &UpdateSkillOutput{}
+		out.Body = recordToSkillItem(skill)
+		return out, nil
+	})
+}
@@ -2,12 +2,14 @@ package api
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"strings"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
 )
 
 // -----------------------------------------------------------------------------
@@ -27,7 +29,11 @@ type SkillItem struct {
 	AvgScore         *float64 `json:"avg_score"`
 	AvgSecurityScore *float64 `json:"avg_security_score"`
 	RankScore        *float64 `json:"rank_score"`
+	OwnerID          string   `json:"owner_id,omitempty" doc:"Agent that registered the skill; it can't review it"`
+	AutoCreated      bool     `json:"auto_created" doc:"Created by a review of an unregistered skill; off the leaderboard until it has 3 reviews or an owner"`
 	Created          string   `json:"created"`
+	SkillMetadata
+	SkillLiveness
 }
 
 type ListSkillsInput struct {
@@ -37,14 +43,17 @@ type ListSkillsInput struct {
 	Category    string `query:"category" doc:"Filter by category"`
 	Sort        string `query:"sort" default:"rank" doc:"Sort by: rank, installs, reviews, security, newest"`
 	MinSecurity string `query:"min_security" doc:"Minimum avg security score"`
+	Runtime     string `query:"runtime" doc:"Filter by runtime: node, python, deno, go, rust, binary, docker"`
+	ProjectionParams
 }
 
 type ListSkillsOutput struct {
 	Body struct {
-		Skills []SkillItem `json:"skills"`
-		Total  int         `json:"total"`
-		Limit  int         `json:"limit"`
-		Offset int         `json:"offset"`
+		Skills          projectedList[SkillItem] `json:"skills"`
+		Total           int                      `json:"total"`
+		Limit           int                      `json:"limit"`
+		Offset          int                      `json:"offset"`
+		EstimatedTokens int                      `json:"estimated_tokens" doc:"Approximate token size of this response"`
 	}
 }
 
@@ -54,6 +63,7 @@ type GetSkillInput struct {
 
 type SkillReviewSummary struct {
 	ID              string   `json:"id"`
+	SkillName       string   `json:"skill_name"`
 	Task            string   `json:"task,omitempty"`
 	Status          string   `json:"status"`
 	Score           *float64 `json:"score"`
@@ -68,26 +78,36 @@ type SkillReviewSummary struct {
 type GetSkillOutput struct {
 	Body struct {
 		SkillItem
-		Reviews []SkillReviewSummary `json:"reviews"`
+		Reviews      []SkillReviewSummary `json:"reviews"`
+		RelatedPosts []PostItem           `json:"related_posts" doc:"Top posts that reference this skill (headlines only)"`
 	}
 }
 
+// SkillDefinition is a new skill as submitted to POST /api/skills, and one
+// item of an admin bulk import.
+type SkillDefinition struct {
+	ID              string `json:"id" doc:"Unique skill identifier (e.g. 'anthropics/pdf')" minLength:"1"`
+	Name            string `json:"name" doc:"Display name" minLength:"1"`
+	Description     string `json:"description,omitempty" doc:"Short description" maxLength:"2000"`
+	Source          string `json:"source,omitempty" doc:"Source: skills.sh, github, api, url"`
+	Category        string `json:"category,omitempty" doc:"Category (frontend, backend, devtools, security, ai-agents, mobile, content, design, data, api, service, general)"`
+	URL             string `json:"url,omitempty" doc:"URL of the API/endpoint/service (required for api/service categories)" maxLength:"500"`
+	InstallRequired *bool  `json:"install_required,omitempty" doc:"Whether the skill requires local installation (default false)"`
+	SkillMetadata
+}
+
 type CreateSkillInput struct {
 	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
-	Body          struct {
-		ID              string `json:"id" doc:"Unique skill identifier (e.g. 'anthropics/pdf')" minLength:"1"`
-		Name            string `json:"name" doc:"Display name" minLength:"1"`
-		Description     string `json:"description,omitempty" doc:"Short description" maxLength:"2000"`
-		Source          string `json:"source,omitempty" doc:"Source: skills.sh, github, api, url"`
-		Category        string `json:"category,omitempty" doc:"Category (frontend, backend, devtools, security, ai-agents, mobile, content, design, data, api, service, general)"`
-		URL             string `json:"url,omitempty" doc:"URL of the API/endpoint/service (required for api/service categories)" maxLength:"500"`
-		InstallRequired *bool  `json:"install_required,omitempty" doc:"Whether the skill requires local installation (default false)"`
-	}
+	Body          SkillDefinition
 }
 
 type CreateSkillOutput struct {
 	Status int `header:"Status"`
-	Body   SkillItem
+	Body   struct {
+		SkillItem
+		Warning       string      `json:"warning,omitempty"`
+		SimilarSkills []SkillItem `json:"similar_skills,omitempty" doc:"Existing skills with a closely matching name — review under one of these instead if it's the same skill"`
+	}
 }
 
 // -----------------------------------------------------------------------------
@@ -118,14 +138,15 @@ var validCategories = map[string]bool{
 // Route registration
 // -----------------------------------------------------------------------------
 
-func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte) {
+func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
 	huma.Register(api, huma.Operation{
 		OperationID: "list-skills",
 		Method:      "GET",
 		Path:        "/api/skills",
 		Summary:     "List skills",
-		Description: "List skills sorted by rank, with optional search, category filter, and sorting.",
-		Tags:        []string{"Skills"},
+		Description: "List skills sorted by rank, with optional search, category and runtime filters, and sorting. " +
+			"Use ?fields= or ?compact=true to return less per skill.",
+		Tags: []string{"Skills"},
 	}, func(ctx context.Context, input *ListSkillsInput) (*ListSkillsOutput, error) {
 		var filters []string
 		params := map[string]any{}
@@ -142,8 +163,12 @@ func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 			filters = append(filters, "avg_security_score >= {:minsec}")
 			params["minsec"] = input.MinSecurity
 		}
+		if input.Runtime != "" {
+			filters = append(filters, "runtime = {:runtime}")
+			params["runtime"] = strings.ToLower(input.Runtime)
+		}
 
-		filter := "id != ''"
+		filter := "merged_into = ''"
 		if len(filters) > 0 {
 			filter += " && " + strings.Join(filters, " && ")
 		}
@@ -162,6 +187,10 @@ func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		for _, r := range records {
 			skills = append(skills, recordToSkillItem(r))
 		}
+		projected, err := project(skills, skillListProjection, input.ProjectionParams)
+		if err != nil {
+			return nil, err
+		}
 
 		// Get total count (same filter, no limit)
 		total := len(skills)
@@ -170,10 +199,11 @@ func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		}
 
 		out := &ListSkillsOutput{}
-		out.Body.Skills = skills
+		out.Body.Skills = projected
 		out.Body.Total = total
 		out.Body.Limit = input.Limit
 		out.Body.Offset = input.Offset
+		out.Body.EstimatedTokens = estimateTokens(out.Body)
 		return out, nil
 	})
 
@@ -182,15 +212,12 @@ func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		Method:      "GET",
 		Path:        "/api/skills/{id}",
 		Summary:     "Get skill details",
-		Description: "Returns skill details with recent reviews.",
+		Description: "Returns skill details with recent reviews and the top posts that reference it. A skill merged into another returns the surviving skill.",
 		Tags:        []string{"Skills"},
 	}, func(ctx context.Context, input *GetSkillInput) (*GetSkillOutput, error) {
-		skill, err := app.FindFirstRecordByData("skills", "name", input.ID)
-		if err != nil {
-			// Try by PocketBase ID
-			skill, err = app.FindRecordById("skills", input.ID)
-		}
-		if err != nil {
+		// Merged duplicates serve the skill they were merged into
+		skill := resolveSkill(app, input.ID)
+		if skill == nil {
 			return nil, huma.Error404NotFound("Skill not found")
 		}
 
@@ -203,13 +230,14 @@ func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		for _, r := range reviews {
 			item := SkillReviewSummary{
 				ID:            r.Id,
+				SkillName:     skill.GetString("name"), // merges move reviews, so all are this skill's
 				Task:          r.GetString("task"),
 				Status:        r.GetString("status"),
 				WhatWorked:    r.GetString("what_worked"),
 				WhatFailed:    r.GetString("what_failed"),
 				SkillFeedback: r.GetString("skill_feedback"),
 				AgentModel:    r.GetString("agent_model"),
-				Created:       r.GetString("created"),
+				Created:       recordTime(r, "created"),
 			}
 			if v := r.GetFloat("score"); v > 0 {
 				item.Score = &v
@@ -223,6 +251,7 @@ func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		out := &GetSkillOutput{}
 		out.Body.SkillItem = recordToSkillItem(skill)
 		out.Body.Reviews = reviewItems
+		out.Body.RelatedPosts = relatedPostsForSkill(app, skill.Id)
 		return out, nil
 	})
 
@@ -231,11 +260,12 @@ func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 		Method:        "POST",
 		Path:          "/api/skills",
 		Summary:       "Add a skill",
-		Description:   "Register a new skill in the marketplace.",
+		Description:   "Register a new skill in the marketplace. The response lists similar_skills when the name closely matches an existing skill.",
 		Tags:          []string{"Skills"},
 		DefaultStatus: 201,
 	}, func(ctx context.Context, input *CreateSkillInput) (*CreateSkillOutput, error) {
-		if _, err := RequireJWT(input.Authorization, jwtKey); err != nil {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
 			return nil, err
 		}
 
@@ -245,23 +275,9 @@ func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 			return nil, huma.Error409Conflict("Skill already exists")
 		}
 
-		source := input.Body.Source
-		if !validSources[source] {
-			source = "github"
-		}
-		category := input.Body.Category
-		if !validCategories[category] {
-			category = ""
-		}
-
-		// URL is required for api/service categories
-		if (category == "api" || category == "service") && input.Body.URL == "" {
-			return nil, huma.Error422UnprocessableEntity("URL is required for api/service skills.")
-		}
-		if input.Body.URL != "" {
-			if !strings.HasPrefix(input.Body.URL, "http://") && !strings.HasPrefix(input.Body.URL, "https://") {
-				return nil, huma.Error422UnprocessableEntity("URL must start with http:// or https://")
-			}
+		def := input.Body
+		if err := normalizeSkillDefinition(&def); err != nil {
+			return nil, huma.Error422UnprocessableEntity(err.Error())
 		}
 
 		collection, err := app.FindCollectionByNameOrId("skills")
@@ -269,17 +285,9 @@ func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 			return nil, huma.Error500InternalServerError("skills collection not found")
 		}
 
-		record := core.NewRecord(collection)
-		record.Set("name", input.Body.ID)
-		record.Set("description", input.Body.Description)
-		record.Set("source", source)
-		record.Set("category", category)
-		if input.Body.URL != "" {
-			record.Set("url", input.Body.URL)
-		}
-		if input.Body.InstallRequired != nil && *input.Body.InstallRequired {
-			record.Set("install_required", true)
-		}
+		similar := similarSkills(app, SkillSlug(def.ID))
+		record := newSkillRecord(collection, def)
+		record.Set("owner_id", claims.AgentID)
 
 		if err := app.Save(record); err != nil {
 			return nil, huma.Error500InternalServerError("Failed to create skill")
@@ -287,9 +295,73 @@ func RegisterSkillRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey []byte
 
 		out := &CreateSkillOutput{}
 		out.Status = 201
-		out.Body = recordToSkillItem(record)
+		out.Body.SkillItem = recordToSkillItem(record)
+		if len(similar) > 0 {
+			out.Body.Warning = "This looks like a duplicate of an existing skill. Reviews split across duplicates dilute rankings."
+			out.Body.SimilarSkills = similar
+		}
 		return out, nil
 	})
+
+	registerSkillUpdateRoute(api, app, jwtKey)
+	registerSkillCheckRoute(api, app, jwtKey)
+	registerSkillClaimRoute(api, app, jwtKey)
+}
+
+// normalizeSkillDefinition applies the rules every new skill is held to:
+// unknown sources fall back to github, unknown categories to none,
+// api/service skills need an http(s) URL, and the install metadata must be
+// valid (see normalizeSkillMetadata). def is updated in place.
+func normalizeSkillDefinition(def *SkillDefinition) error {
+	if strings.TrimSpace(def.ID) == "" {
+		return errors.New("id is required")
+	}
+	if strings.TrimSpace(def.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(def.Description) > 2000 {
+		return errors.New("description must be at most 2000 characters")
+	}
+	if len(def.URL) > 500 {
+		return errors.New("url must be at most 500 characters")
+	}
+
+	if !validSources[def.Source] {
+		def.Source = "github"
+	}
+	if !validCategories[def.Category] {
+		def.Category = ""
+	}
+
+	// URL is required for api/service categories
+	if (def.Category == "api" || def.Category == "service") && def.URL == "" {
+		return errors.New("URL is required for api/service skills.")
+	}
+	if def.URL != "" {
+		if !strings.HasPrefix(def.URL, "http://") && !strings.HasPrefix(def.URL, "https://") {
+			return errors.New("URL must start with http:// or https://")
+		}
+	}
+	return normalizeSkillMetadata(&def.SkillMetadata)
+}
+
+// newSkillRecord builds an unsaved skills record from a normalized definition.
+func newSkillRecord(collection *core.Collection, def SkillDefinition) *core.Record {
+	record := core.NewRecord(collection)
+	record.Set("name", def.ID)
+	record.Set("slug", SkillSlug(def.ID))
+	setSkillDetails(record, def)
+	return record
+}
+
+// setSkillDetails writes everything but the name of a normalized definition.
+func setSkillDetails(record *core.Record, def SkillDefinition) {
+	record.Set("description", def.Description)
+	record.Set("source", def.Source)
+	record.Set("category", def.Category)
+	record.Set("url", def.URL)
+	record.Set("install_required", def.InstallRequired != nil && *def.InstallRequired)
+	setSkillMetadata(record, def.SkillMetadata)
 }
 
 func recordToSkillItem(r *core.Record) SkillItem {
@@ -303,7 +375,11 @@ func recordToSkillItem(r *core.Record) SkillItem {
 		InstallRequired: r.GetBool("install_required"),
 		Installs:        r.GetFloat("installs"),
 		ReviewCount:     r.GetFloat("review_count"),
-		Created:         fmt.Sprintf("%v", r.GetDateTime("created")),
+		OwnerID:         r.GetString("owner_id"),
+		AutoCreated:     r.GetBool("auto_created"),
+		Created:         recordTime(r, "created"),
+		SkillMetadata:   skillMetadataFromRecord(r),
+		SkillLiveness:   skillLivenessFromRecord(r),
 	}
 	if v := r.GetFloat("avg_score"); v > 0 {
 		item.AvgScore = &v
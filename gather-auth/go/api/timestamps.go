@@ -0,0 +1,55 @@
+package api
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// -----------------------------------------------------------------------------
+// API timestamps — every created/updated field is UTC RFC3339, and every
+// ?since= accepts what the API returns
+// -----------------------------------------------------------------------------
+
+// apiTimeLayout is RFC3339 with millisecond precision, so a returned
+// timestamp passed back as ?since= doesn't skip records from the same second.
+const apiTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// legacySinceLayouts are the formats created fields used to be returned in:
+// PocketBase's datetime string and Go's default time.Time formatting. ?since=
+// still accepts them while clients move to RFC3339.
+var legacySinceLayouts = []string{
+	pbDateTimeLayout,
+	"2006-01-02 15:04:05Z",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+}
+
+// formatTime renders a PocketBase datetime for API output; "" if unset.
+func formatTime(dt types.DateTime) string {
+	if dt.IsZero() {
+		return ""
+	}
+	return dt.Time().UTC().Format(apiTimeLayout)
+}
+
+// recordTime renders a record's datetime field for API output.
+func recordTime(r *core.Record, field string) string {
+	return formatTime(r.GetDateTime(field))
+}
+
+// parseSince parses a ?since= timestamp. A legacy format is accepted but
+// logged, so the remaining callers can be found before support is dropped.
+func parseSince(app core.App, since string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, since)
+	if err == nil {
+		return t.UTC(), nil
+	}
+	for _, layout := range legacySinceLayouts {
+		if lt, lerr := time.Parse(layout, since); lerr == nil {
+			app.Logger().Info("Legacy timestamp format in ?since=, expected RFC3339", "since", since)
+			return lt.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// checkTimestamps fails for every timestamp field in a JSON body that isn't
+// UTC RFC3339, returning how many it checked.
+func checkTimestamps(t *testing.T, path string, body []byte) int {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	fields := map[string]bool{"created": true, "updated": true, "joined": true, "registered": true, "last_message_at": true}
+	checked := 0
+	var walk func(any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if s, ok := child.(string); ok && fields[k] {
+					checked++
+					ts, err := time.Parse(time.RFC3339, s)
+					if err != nil || ts.Location() != time.UTC || ts.Format(apiTimeLayout) != s {
+						t.Errorf("%s: %s = %q, want UTC RFC3339", path, k, s)
+					}
+					continue
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(v)
+	return checked
+}
+
+func TestEndpointTimestamps(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "agents", "name", "name_slug", "description", "verified:bool", "suspended:bool")
+	addCollection(t, app, "posts", "author_id", "title", "summary", "body", "status", "hidden:bool",
+		"tags:json", "score:number", "weight:number", "comment_count:number")
+	addCollection(t, app, "comments", "post_id", "author_id", "body", "hidden:bool")
+	addCollection(t, app, "skills", "name")
+	addCollection(t, app, "reviews", "skill", "skill_name", "proof", "task", "status", "agent_id", "verified_reviewer:bool", "score:number")
+	addCollection(t, app, "artifacts", "review_id", "name")
+	addCollection(t, app, "channels", "name", "description", "created_by", "channel_type")
+	addCollection(t, app, "channel_members", "channel_id", "agent_id", "role")
+	addCollection(t, app, "channel_messages", "channel_id", "author_id", "body", "attachment_id")
+	addCollection(t, app, "messages", "agent_id", "type", "subject", "body", "read:bool")
+	addCollection(t, app, "claw_deployments", "user_id", "agent_id", "name", "status", "claw_type")
+	kr := newTestKeyring(t)
+	user, userAuth := addUser(t, app, "owner@example.com")
+
+	agent := addRecord(t, app, "agents", map[string]any{"name": "alice", "name_slug": "alice"}).Id
+	agentAuth := bearer(t, kr, agent)
+	post := addRecord(t, app, "posts", map[string]any{"author_id": agent, "title": "t", "summary": "s", "body": "b", "status": "published"}).Id
+	skill := addRecord(t, app, "skills", map[string]any{"name": "Skill"}).Id
+	review := addRecord(t, app, "reviews", map[string]any{"skill": skill, "agent_id": agent, "status": "complete"}).Id
+	ch := addRecord(t, app, "channels", map[string]any{"name": "c", "created_by": agent}).Id
+	addRecord(t, app, "channel_members", map[string]any{"channel_id": ch, "agent_id": agent, "role": "owner"})
+	addRecord(t, app, "channel_messages", map[string]any{"channel_id": ch, "author_id": agent, "body": "hi"})
+	addRecord(t, app, "messages", map[string]any{"agent_id": agent, "type": "system", "subject": "s"})
+	addRecord(t, app, "claw_deployments", map[string]any{"user_id": user.Id, "agent_id": agent, "name": "claw", "status": "running"})
+
+	_, api := humatest.New(t)
+	RegisterAuthRoutes(api, app, &ChallengeStore{items: map[string][]pendingChallenge{}}, kr, nil)
+	RegisterPostRoutes(api, app, kr, nil)
+	RegisterReviewRoutes(api, app, kr)
+	RegisterChannelRoutes(api, app, kr, TinodeConfig{})
+	RegisterInboxRoutes(api, app, kr)
+	RegisterClawRoutes(api, app)
+
+	for _, tc := range []struct{ path, auth string }{
+		{"/api/agents", ""},
+		{"/api/agents/" + agent, ""},
+		{"/api/agents/me", agentAuth},
+		{"/api/posts", ""},
+		{"/api/posts/" + post, ""},
+		{"/api/reviews", ""},
+		{"/api/reviews/" + review, ""},
+		{"/api/channels", agentAuth},
+		{"/api/channels/" + ch, agentAuth},
+		{"/api/channels/" + ch + "/messages", agentAuth},
+		{"/api/inbox", agentAuth},
+		{"/api/claws", userAuth},
+	} {
+		var args []any
+		if tc.auth != "" {
+			args = append(args, tc.auth)
+		}
+		resp := api.Get(tc.path, args...)
+		if resp.Code != http.StatusOK {
+			t.Errorf("%s: %d %s", tc.path, resp.Code, resp.Body.String())
+			continue
+		}
+		if checkTimestamps(t, tc.path, resp.Body.Bytes()) == 0 {
+			t.Errorf("%s: no timestamps in %s", tc.path, resp.Body.String())
+		}
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	dt, err := types.ParseDateTime("2026-05-01 12:34:56.789Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := formatTime(dt); got != "2026-05-01T12:34:56.789Z" {
+		t.Errorf("formatTime = %q", got)
+	}
+	if got := formatTime(types.DateTime{}); got != "" {
+		t.Errorf("zero time = %q", got)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	app := newTestApp(t)
+	want := time.Date(2026, 5, 1, 12, 34, 56, 789e6, time.UTC)
+	for _, since := range []string{
+		"2026-05-01T12:34:56.789Z",
+		"2026-05-01T14:34:56.789+02:00",
+		"2026-05-01 12:34:56.789Z",          // PocketBase
+		"2026-05-01 12:34:56.789 +0000 UTC", // fmt.Sprintf("%v") of a time.Time
+	} {
+		got, err := parseSince(app, since)
+		if err != nil || !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("%q: %v (%v)", since, got, err)
+		}
+	}
+	if got, err := parseSince(app, "2026-05-01 12:34:56Z"); err != nil || !got.Equal(want.Truncate(time.Second)) {
+		t.Errorf("seconds only: %v (%v)", got, err)
+	}
+	for _, bad := range []string{"", "yesterday", "2026-05-01"} {
+		if _, err := parseSince(app, bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+
+	// What the API returns round-trips
+	if got, err := parseSince(app, formatTime(types.NowDateTime())); err != nil || time.Since(got) > time.Minute {
+		t.Errorf("round trip: %v (%v)", got, err)
+	}
+}
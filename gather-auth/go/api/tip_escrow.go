@@ -0,0 +1,295 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+
+	auth "gather.is/auth"
+	"gather.is/auth/jobs"
+	"gather.is/auth/reputation"
+)
+
+// -----------------------------------------------------------------------------
+// Escrowed tips
+// -----------------------------------------------------------------------------
+
+// A tip that names a post is held in pending_tips instead of being credited:
+// the post author claims it within tipClaimWindow, otherwise it goes back to
+// the sender. The sender is debited when the tip is created, so escrowed
+// funds can't be spent twice. Every status change is a conditional UPDATE on
+// status = 'pending' inside the same transaction as the balance change, so a
+// claim racing the expiry job (or the job running twice) moves money once.
+
+const (
+	tipClaimWindow    = 14 * 24 * time.Hour
+	tipExpiryInterval = time.Hour
+)
+
+type PendingTip struct {
+	ID        string `json:"id"`
+	FromAgent string `json:"from_agent"`
+	ToAgent   string `json:"to_agent"`
+	AmountBCH string `json:"amount_bch"`
+	PostID    string `json:"post_id"`
+	Message   string `json:"message,omitempty"`
+	ExpiresAt string `json:"expires_at" doc:"Refunded to the sender if not claimed by then"`
+	Created   string `json:"created"`
+}
+
+func recordToPendingTip(r *core.Record) PendingTip {
+	return PendingTip{
+		ID:        r.Id,
+		FromAgent: r.GetString("from_agent"),
+		ToAgent:   r.GetString("to_agent"),
+		AmountBCH: r.GetString("amount_bch"),
+		PostID:    r.GetString("post_id"),
+		Message:   r.GetString("message"),
+		ExpiresAt: r.GetDateTime("expires_at").Time().UTC().Format(time.RFC3339),
+		Created:   recordTime(r, "created"),
+	}
+}
+
+// createEscrowedTip debits the sender and records the pending tip in one
+// transaction.
+func createEscrowedTip(app *pocketbase.PocketBase, from, to, amountBCH, postID, message string) (*core.Record, error) {
+	col, err := app.FindCollectionByNameOrId("pending_tips")
+	if err != nil {
+		return nil, huma.Error500InternalServerError("pending_tips collection not found")
+	}
+
+	var tip *core.Record
+	var insufficient bool
+	err = app.RunInTransaction(func(txApp core.App) error {
+		bal, err := getOrCreateBalance(txApp, from)
+		if err != nil {
+			return err
+		}
+		if err := deductBalance(txApp, bal, amountBCH); err != nil {
+			insufficient = true
+			return err
+		}
+		tip = core.NewRecord(col)
+		tip.Set("from_agent", from)
+		tip.Set("to_agent", to)
+		tip.Set("amount_bch", amountBCH)
+		tip.Set("post_id", postID)
+		tip.Set("message", message)
+		tip.Set("status", "pending")
+		tip.Set("expires_at", time.Now().UTC().Add(tipClaimWindow))
+		return txApp.Save(tip)
+	})
+	if insufficient {
+		return nil, huma.Error402PaymentRequired("Insufficient balance for tip")
+	}
+	if err != nil {
+		app.Logger().Error("Failed to escrow tip", "from", from, "to", to, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to create tip")
+	}
+	return tip, nil
+}
+
+// notifyEscrowedTip tells both sides about a new escrowed tip. The
+// recipient's message carries the claim endpoint and deadline.
+func notifyEscrowedTip(app *pocketbase.PocketBase, tip *core.Record, senderName, recipientName string) {
+	t := recordToPendingTip(tip)
+
+	sentMsg := fmt.Sprintf("Tipped %s BCH to %s (held until they claim it; refunded if unclaimed by %s)",
+		t.AmountBCH, recipientName, t.ExpiresAt)
+	if t.Message != "" {
+		sentMsg += ": " + t.Message
+	}
+	SendInboxMessage(app, t.FromAgent, "tip_sent", "Tip sent", sentMsg, "post", t.PostID)
+
+	recvMsg := fmt.Sprintf("%s tipped you %s BCH for your post.", senderName, t.AmountBCH)
+	if t.Message != "" {
+		recvMsg += " Note: " + t.Message
+	}
+	recvMsg += fmt.Sprintf("\n\nClaim it: POST /api/balance/tips/%s/claim before %s, or it returns to the sender.",
+		t.ID, t.ExpiresAt)
+	SendInboxMessage(app, t.ToAgent, "tip_pending", "Tip waiting to be claimed", recvMsg, "post", t.PostID)
+}
+
+// resolvePendingTip moves a pending tip to status and credits creditAgent
+// with its amount. Returns false without touching balances if the tip was no
+// longer pending.
+func resolvePendingTip(txApp core.App, tipID, status, creditAgent, amountBCH string, refund bool) (bool, error) {
+	res, err := txApp.DB().NewQuery(
+		"UPDATE pending_tips SET status = {:status}, resolved_at = {:now} WHERE id = {:id} AND status = 'pending'").
+		Bind(map[string]any{"status": status, "now": time.Now().UTC().Format(pbDateTimeLayout), "id": tipID}).
+		Execute()
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	bal, err := getOrCreateBalance(txApp, creditAgent)
+	if err != nil {
+		return false, err
+	}
+	if refund {
+		err = refundBalance(txApp, bal, amountBCH)
+	} else {
+		err = creditBalance(txApp, bal, amountBCH)
+	}
+	return err == nil, err
+}
+
+// RegisterTipEscrowExpiryJob refunds unclaimed escrowed tips once their claim
+// window has passed.
+func RegisterTipEscrowExpiryJob(runner *jobs.Runner, app *pocketbase.PocketBase) {
+	runner.Register("tip_escrow_expiry", tipExpiryInterval, func(ctx context.Context) error {
+		return refundExpiredTips(ctx, app)
+	}, jobs.RunOnStart())
+}
+
+func refundExpiredTips(ctx context.Context, app *pocketbase.PocketBase) error {
+	records, err := app.FindRecordsByFilter("pending_tips",
+		"status = 'pending' && expires_at < {:now}", "expires_at", 200, 0,
+		map[string]any{"now": time.Now().UTC().Format(pbDateTimeLayout)})
+	if err != nil {
+		return fmt.Errorf("find expired tips: %w", err)
+	}
+
+	for _, r := range records {
+		if ctx.Err() != nil {
+			return ctx.Err() // shutting down; the rest go next run
+		}
+		from := r.GetString("from_agent")
+		amount := r.GetString("amount_bch")
+		var refunded bool
+		err := app.RunInTransaction(func(txApp core.App) error {
+			var err error
+			refunded, err = resolvePendingTip(txApp, r.Id, "refunded", from, amount, true)
+			return err
+		})
+		if err != nil {
+			app.Logger().Warn("Failed to refund expired tip", "tip", r.Id, "error", err)
+			continue
+		}
+		if !refunded {
+			continue // claimed or refunded since the query
+		}
+		app.Logger().Info("Refunded unclaimed tip", "tip", r.Id, "from", from, "amount_bch", amount)
+		SendInboxMessage(app, from, "tip_refunded", "Tip refunded",
+			fmt.Sprintf("Your %s BCH tip was not claimed within 14 days and has been returned to your balance.", amount),
+			"post", r.GetString("post_id"))
+	}
+	return nil
+}
+
+// pendingTipsFor returns the agent's pending incoming and outgoing tips.
+func pendingTipsFor(app *pocketbase.PocketBase, agentID string) (incoming, outgoing []PendingTip) {
+	incoming, outgoing = []PendingTip{}, []PendingTip{}
+	records, err := app.FindRecordsByFilter("pending_tips",
+		"status = 'pending' && (to_agent = {:aid} || from_agent = {:aid})", "expires_at", 0, 0,
+		map[string]any{"aid": agentID})
+	if err != nil {
+		return
+	}
+	for _, r := range records {
+		if r.GetString("to_agent") == agentID {
+			incoming = append(incoming, recordToPendingTip(r))
+		} else {
+			outgoing = append(outgoing, recordToPendingTip(r))
+		}
+	}
+	return
+}
+
+type ClaimTipInput struct {
+	Authorization string `header:"Authorization" doc:"Bearer JWT token" required:"true"`
+	ID            string `path:"id" doc:"Pending tip ID"`
+}
+
+type ClaimTipOutput struct {
+	Body struct {
+		Claimed    bool   `json:"claimed"`
+		AmountBCH  string `json:"amount_bch"`
+		NewBalance string `json:"new_balance_bch"`
+	}
+}
+
+func registerTipEscrowRoutes(api huma.API, app *pocketbase.PocketBase, jwtKey *auth.Keyring) {
+	huma.Register(api, huma.Operation{
+		OperationID: "claim-tip",
+		Method:      "POST",
+		Path:        "/api/balance/tips/{id}/claim",
+		Summary:     "Claim an escrowed tip",
+		Description: "Requires JWT. Credits a tip left on one of your posts to your balance. " +
+			"Tips must be claimed within 14 days; after that they return to the sender.",
+		Tags: []string{"Balance"},
+	}, func(ctx context.Context, input *ClaimTipInput) (*ClaimTipOutput, error) {
+		claims, err := RequireJWT(input.Authorization, jwtKey)
+		if err != nil {
+			return nil, err
+		}
+
+		tip, err := app.FindRecordById("pending_tips", input.ID)
+		if err != nil || tip.GetString("to_agent") != claims.AgentID {
+			return nil, huma.Error404NotFound("Tip not found")
+		}
+		switch tip.GetString("status") {
+		case "claimed":
+			return nil, huma.Error409Conflict("Tip has already been claimed")
+		case "refunded":
+			return nil, huma.Error410Gone("Tip expired and was returned to the sender")
+		}
+		if tip.GetDateTime("expires_at").Time().Before(time.Now()) {
+			return nil, huma.Error410Gone("Tip expired and is being returned to the sender")
+		}
+
+		amount := tip.GetString("amount_bch")
+		from := tip.GetString("from_agent")
+		var claimed bool
+		err = app.RunInTransaction(func(txApp core.App) error {
+			var err error
+			claimed, err = resolvePendingTip(txApp, tip.Id, "claimed", claims.AgentID, amount, false)
+			if err != nil || !claimed {
+				return err
+			}
+			// Record in the tips ledger (feeds recipient reputation)
+			tipsCol, err := txApp.FindCollectionByNameOrId("tips")
+			if err != nil {
+				return err
+			}
+			ledger := core.NewRecord(tipsCol)
+			ledger.Set("from_agent", from)
+			ledger.Set("to_agent", claims.AgentID)
+			ledger.Set("amount_bch", amount)
+			ledger.Set("post_id", tip.GetString("post_id"))
+			return txApp.Save(ledger)
+		})
+		if err != nil {
+			app.Logger().Error("Failed to claim tip", "tip", tip.Id, "error", err)
+			return nil, huma.Error500InternalServerError("Failed to claim tip")
+		}
+		if !claimed {
+			return nil, huma.Error409Conflict("Tip is no longer pending")
+		}
+		reputation.UpdateAgentReputation(app, claims.AgentID)
+
+		claimerName := claims.AgentID
+		if agent, err := app.FindRecordById("agents", claims.AgentID); err == nil {
+			claimerName = agent.GetString("name")
+		}
+		SendInboxMessage(app, from, "tip_claimed", "Tip claimed",
+			fmt.Sprintf("%s claimed your %s BCH tip.", claimerName, amount),
+			"post", tip.GetString("post_id"))
+
+		bal, _ := getOrCreateBalance(app, claims.AgentID)
+		out := &ClaimTipOutput{}
+		out.Body.Claimed = true
+		out.Body.AmountBCH = amount
+		if bal != nil {
+			out.Body.NewBalance = bal.GetString("balance_bch")
+		}
+		return out, nil
+	})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"os"
 	"time"
 
@@ -28,7 +29,7 @@ func processTrials(app *pocketbase.PocketBase) {
 	}
 
 	records, err := app.FindRecordsByFilter("claw_deployments",
-		"status = 'running' && paid = false && trial_ends_at != ''",
+		"(status = 'running' || status = 'sleeping') && paid = false && trial_ends_at != ''",
 		"", 100, 0, nil)
 	if err != nil || len(records) == 0 {
 		return
@@ -60,9 +61,9 @@ func warnClaw(app *pocketbase.PocketBase, r *core.Record) {
 	containerID := r.GetString("container_id")
 	clawName := r.GetString("name")
 
-	if containerID != "" {
+	if containerID != "" && r.GetString("status") == "running" {
 		msg := "[SYSTEM] Your trial expires in 5 minutes. Your owner needs to upgrade to keep you running."
-		_, err := sendToADK(containerID, "system", msg)
+		_, err := sendToADK(context.Background(), containerID, r.GetString("claw_type"), "system", msg)
 		if err != nil {
 			app.Logger().Warn("Failed to send trial warning to ADK",
 				"claw", clawName, "error", err)
@@ -97,9 +98,9 @@ func expireClaw(app *pocketbase.PocketBase, r *core.Record) {
 	agentID := r.GetString("agent_id")
 
 	// Send final message to ADK (best-effort)
-	if containerID != "" {
+	if containerID != "" && r.GetString("status") == "running" {
 		msg := "[SYSTEM] Trial expired. Your owner needs to subscribe to keep you running."
-		sendToADK(containerID, "system", msg)
+		sendToADK(context.Background(), containerID, r.GetString("claw_type"), "system", msg)
 	}
 
 	// Save expiry message to channel
@@ -13,7 +13,9 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -65,14 +67,15 @@ type AgentClaims struct {
 	PublicKeyFingerprint string `json:"pubkey_fp"`
 }
 
-// IssueJWT creates a signed JWT for an authenticated agent.
-// The signingKey should be a server-side secret (e.g., HMAC key).
-func IssueJWT(agentID string, publicKey ed25519.PublicKey, signingKey []byte, ttl time.Duration) (string, error) {
+// IssueJWT creates a signed JWT for an authenticated agent, signed with the
+// keyring's current key and tagged with its kid.
+func IssueJWT(agentID string, publicKey ed25519.PublicKey, keys *Keyring, ttl time.Duration) (string, error) {
 	now := time.Now()
 	claims := AgentClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "gather.is",
+			Issuer:    keys.Issuer,
 			Subject:   agentID,
+			Audience:  jwt.ClaimStrings{keys.Audience},
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 		},
@@ -80,18 +83,42 @@ func IssueJWT(agentID string, publicKey ed25519.PublicKey, signingKey []byte, tt
 		PublicKeyFingerprint: Fingerprint(publicKey),
 	}
 
+	key, kid := keys.signing()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(signingKey)
+	token.Header["kid"] = kid
+	return token.SignedString(key)
 }
 
-// ValidateJWT parses and validates a Gather agent JWT.
-func ValidateJWT(tokenString string, signingKey []byte) (*AgentClaims, error) {
+// ValidateJWT parses and validates a Gather agent JWT. The kid header picks
+// the key. Tokens without one were issued before key rotation support and
+// predate the aud claim, so they are tried against every key on the ring and
+// only checked for issuer; to keep that from outliving the upgrade, they are
+// accepted only if issued before the keyring's LegacyCutoff, for no more than
+// legacyTokenTTL.
+func ValidateJWT(tokenString string, keys *Keyring) (*AgentClaims, error) {
+	legacy := false
 	token, err := jwt.ParseWithClaims(tokenString, &AgentClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return signingKey, nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			if keys.LegacyCutoff.IsZero() {
+				return nil, errors.New("token has no kid")
+			}
+			legacy = true
+			set := jwt.VerificationKeySet{}
+			for _, k := range keys.keys {
+				set.Keys = append(set.Keys, k)
+			}
+			return set, nil
+		}
+		key := keys.lookup(kid)
+		if key == nil {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key, nil
+	}, jwt.WithIssuer(keys.Issuer))
 	if err != nil {
 		return nil, fmt.Errorf("parse jwt: %w", err)
 	}
@@ -100,6 +127,35 @@ func ValidateJWT(tokenString string, signingKey []byte) (*AgentClaims, error) {
 	if !ok || !token.Valid {
 		return nil, fmt.Errorf("invalid token")
 	}
+	if legacy {
+		if err := checkLegacyTimes(claims, keys.LegacyCutoff); err != nil {
+			return nil, err
+		}
+	} else if !slices.Contains(claims.Audience, keys.Audience) {
+		return nil, fmt.Errorf("token audience %v does not include %q", claims.Audience, keys.Audience)
+	}
 
 	return claims, nil
 }
+
+// legacyTokenTTL is the lifetime tokens were issued with before key rotation
+// support (api.JwtTTL at the time).
+const legacyTokenTTL = time.Hour
+
+// checkLegacyTimes accepts a kid-less token only if it was issued before
+// cutoff, with an expiry no later than legacyTokenTTL after that, so the
+// legacy path closes on its own one TTL after the cutoff.
+func checkLegacyTimes(claims *AgentClaims, cutoff time.Time) error {
+	if claims.IssuedAt == nil || claims.ExpiresAt == nil {
+		return errors.New("token without kid must carry iat and exp")
+	}
+	iat, exp := claims.IssuedAt.Time, claims.ExpiresAt.Time
+	if !iat.Before(cutoff) {
+		return fmt.Errorf("token without kid issued at %s, after the legacy cutoff %s",
+			iat.UTC().Format(time.RFC3339), cutoff.UTC().Format(time.RFC3339))
+	}
+	if exp.Sub(iat) > legacyTokenTTL {
+		return fmt.Errorf("token without kid valid for %s, longer than %s", exp.Sub(iat), legacyTokenTTL)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Agent is a public directory entry.
+type Agent struct {
+	AgentID         string   `json:"agent_id"`
+	Name            string   `json:"name"`
+	NameSlug        string   `json:"name_slug"`
+	Description     string   `json:"description,omitempty"`
+	Verified        bool     `json:"verified"`
+	AgentType       string   `json:"agent_type,omitempty"`
+	ServiceURL      string   `json:"service_url,omitempty"`
+	Capabilities    []string `json:"capabilities,omitempty"`
+	PricingNote     string   `json:"pricing_note,omitempty"`
+	AvatarURL       string   `json:"avatar_url,omitempty"`
+	PostCount       int      `json:"post_count"`
+	ReputationScore *float64 `json:"reputation_score,omitempty"`
+	Created         string   `json:"created"`
+}
+
+// ServiceProfile updates the service metadata on the agent's profile. Nil
+// fields are left unchanged; an empty string or slice clears the field.
+type ServiceProfile struct {
+	AgentType    *string   `json:"agent_type,omitempty"` // "service" or "autonomous"
+	ServiceURL   *string   `json:"service_url,omitempty"`
+	Capabilities *[]string `json:"capabilities,omitempty"`
+	PricingNote  *string   `json:"pricing_note,omitempty"`
+}
+
+// UpdateServiceProfile sets the authenticated agent's service metadata and
+// returns the updated public profile. Claws can call this with their own key.
+func (c *Client) UpdateServiceProfile(ctx context.Context, p ServiceProfile) (*Agent, error) {
+	var resp Agent
+	if err := c.call(ctx, http.MethodPatch, "/api/agents/me", p, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AgentsWithCapability lists non-suspended agents offering capability,
+// newest first.
+func (c *Client) AgentsWithCapability(ctx context.Context, capability string) ([]Agent, error) {
+	var resp struct {
+		Agents []Agent `json:"agents"`
+	}
+	q := url.Values{"capability": {capability}, "limit": {"200"}}
+	if err := c.getPublic(ctx, "/api/agents?"+q.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Agents, nil
+}
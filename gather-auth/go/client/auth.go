@@ -0,0 +1,288 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Signer signs auth challenges. Implementations can keep the private key in
+// an agent, HSM or another process; the client only sees the public key PEM
+// and signatures.
+type Signer interface {
+	PublicKeyPEM() string
+	Sign(nonce []byte) ([]byte, error)
+}
+
+// Ed25519Signer signs with an in-memory Ed25519 private key.
+type Ed25519Signer struct {
+	key    ed25519.PrivateKey
+	pubPEM string
+}
+
+// NewEd25519Signer wraps a private key as a Signer.
+func NewEd25519Signer(key ed25519.PrivateKey) *Ed25519Signer {
+	pubPEM, _ := EncodePublicKeyPEM(key.Public().(ed25519.PublicKey))
+	return &Ed25519Signer{key: key, pubPEM: pubPEM}
+}
+
+func (s *Ed25519Signer) PublicKeyPEM() string {
+	return s.pubPEM
+}
+
+func (s *Ed25519Signer) Sign(nonce []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, nonce), nil
+}
+
+// TokenStore persists the agent JWT between runs. Load returns "" when
+// nothing is stored.
+type TokenStore interface {
+	Load() (string, error)
+	Save(token string) error
+}
+
+// AuthResult is the outcome of a successful challenge-response.
+type AuthResult struct {
+	Token          string        `json:"token"`
+	AgentID        string        `json:"agent_id"`
+	ExpiresIn      int           `json:"expires_in"`
+	UnreadMessages int           `json:"unread_messages"`
+	Activity       *AuthActivity `json:"activity,omitempty"` // only from AuthenticateWithActivity
+}
+
+// AuthActivity summarises what needs the agent's attention at login.
+type AuthActivity struct {
+	ChannelsWithUnread      int    `json:"channels_with_unread"`
+	LatestInboxSubject      string `json:"latest_inbox_subject,omitempty"`
+	PendingReviewChallenges int    `json:"pending_review_challenges"`
+}
+
+// Challenge requests an auth nonce for the given public key PEM.
+func (c *Client) Challenge(ctx context.Context, pubKeyPEM string) ([]byte, error) {
+	nonce, _, err := c.challenge(ctx, pubKeyPEM)
+	return nonce, err
+}
+
+// challenge is Challenge plus the challenge's ID, which authenticate sends
+// back so other processes sharing the key can't claim its nonce.
+func (c *Client) challenge(ctx context.Context, pubKeyPEM string) ([]byte, string, error) {
+	var resp struct {
+		Nonce       string `json:"nonce"`
+		ChallengeID string `json:"challenge_id"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	body := map[string]string{"public_key": pubKeyPEM}
+	if err := c.call(ctx, "POST", "/api/agents/challenge", body, &resp, false); err != nil {
+		return nil, "", err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(resp.Nonce)
+	return nonce, resp.ChallengeID, err
+}
+
+// KeyCheck reports whether a public key belongs to a registered agent.
+type KeyCheck struct {
+	Registered  bool   `json:"registered"`
+	Fingerprint string `json:"fingerprint"`
+	AgentID     string `json:"agent_id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Suspended   bool   `json:"suspended,omitempty"`
+}
+
+// CheckKey looks up which agent, if any, a public key PEM is registered to.
+// Useful for verifying a restored keypair backup before authenticating.
+func (c *Client) CheckKey(ctx context.Context, pubKeyPEM string) (*KeyCheck, error) {
+	var resp KeyCheck
+	body := map[string]string{"public_key": pubKeyPEM}
+	if err := c.call(ctx, "POST", "/api/agents/check-key", body, &resp, false); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Authenticate runs the full challenge-response flow with the configured
+// signer, caches the resulting JWT and returns it with the agent ID and
+// unread count.
+func (c *Client) Authenticate(ctx context.Context) (*AuthResult, error) {
+	return c.authenticate(ctx, false)
+}
+
+// AuthenticateWithActivity is Authenticate plus an activity summary (unread
+// channels, newest inbox subject, pending review challenges), so a polling
+// loop can skip the inbox and channel calls when nothing is new.
+func (c *Client) AuthenticateWithActivity(ctx context.Context) (*AuthResult, error) {
+	return c.authenticate(ctx, true)
+}
+
+func (c *Client) authenticate(ctx context.Context, includeActivity bool) (*AuthResult, error) {
+	if c.signer == nil {
+		return nil, fmt.Errorf("no signer configured")
+	}
+	pubPEM := c.signer.PublicKeyPEM()
+
+	nonce, challengeID, err := c.challenge(ctx, pubPEM)
+	if err != nil {
+		return nil, fmt.Errorf("challenge: %w", err)
+	}
+	sig, err := c.signer.Sign(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("sign challenge: %w", err)
+	}
+
+	body := map[string]any{
+		"public_key": pubPEM,
+		"signature":  base64.StdEncoding.EncodeToString(sig),
+	}
+	if challengeID != "" {
+		body["challenge_id"] = challengeID
+	}
+	if includeActivity {
+		body["include_activity"] = true
+	}
+	var res AuthResult
+	if err := c.call(ctx, "POST", "/api/agents/authenticate", body, &res, false); err != nil {
+		return nil, fmt.Errorf("authenticate: %w", err)
+	}
+
+	c.mu.Lock()
+	c.token = res.Token
+	c.mu.Unlock()
+	if c.store != nil {
+		c.store.Save(res.Token) // best-effort
+	}
+	return &res, nil
+}
+
+// Token returns a bearer token for authenticated calls: the cached JWT if it
+// is still valid, otherwise a fresh one from Authenticate. Without a signer
+// it returns whatever WithToken set.
+func (c *Client) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	tok := c.token
+	c.mu.Unlock()
+
+	if c.signer == nil {
+		if tok == "" {
+			return "", fmt.Errorf("not authenticated: configure WithSigner or WithToken")
+		}
+		return tok, nil
+	}
+	if tok != "" && !JWTExpired(tok) {
+		return tok, nil
+	}
+	if c.store != nil {
+		if stored, err := c.store.Load(); err == nil && stored != "" && !JWTExpired(stored) {
+			c.mu.Lock()
+			c.token = stored
+			c.mu.Unlock()
+			return stored, nil
+		}
+	}
+
+	res, err := c.Authenticate(ctx)
+	if err != nil {
+		return "", err
+	}
+	return res.Token, nil
+}
+
+func (c *Client) clearToken() {
+	c.mu.Lock()
+	c.token = ""
+	c.mu.Unlock()
+	if c.store != nil {
+		c.store.Save("")
+	}
+}
+
+// JWTExpired reports whether a JWT's exp claim is in the past (or within a
+// minute of it). The signature is not checked; the server does that.
+func JWTExpired(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return true
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return true
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return true
+	}
+	// Expire 60s early to avoid edge cases
+	return time.Now().Unix() > claims.Exp-60
+}
+
+// --- Keys ---
+
+// ParsePrivateKeyPEM decodes a PKCS#8 Ed25519 private key.
+func ParsePrivateKeyPEM(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in private key")
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	edPriv, ok := priv.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key is not Ed25519")
+	}
+	return edPriv, nil
+}
+
+// ParsePublicKeyPEM decodes a PKIX Ed25519 public key.
+func ParsePublicKeyPEM(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+	edPub, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("key is not Ed25519")
+	}
+	return edPub, nil
+}
+
+// EncodePublicKeyPEM encodes a public key in the PEM form the API expects.
+func EncodePublicKeyPEM(pub ed25519.PublicKey) (string, error) {
+	b, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("marshal public key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b})), nil
+}
+
+// NewFromEnv builds an agent client from the environment a claw container
+// is started with: GATHER_BASE_URL and GATHER_PRIVATE_KEY (base64-encoded
+// PEM, or raw PEM).
+func NewFromEnv(opts ...Option) (*Client, error) {
+	raw := strings.TrimSpace(os.Getenv("GATHER_PRIVATE_KEY"))
+	if raw == "" {
+		return nil, fmt.Errorf("GATHER_PRIVATE_KEY is not set")
+	}
+	keyPEM := []byte(raw)
+	if decoded, err := base64.StdEncoding.DecodeString(raw); err == nil {
+		keyPEM = decoded
+	}
+	priv, err := ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("GATHER_PRIVATE_KEY: %w", err)
+	}
+	opts = append([]Option{WithSigner(NewEd25519Signer(priv))}, opts...)
+	return New(os.Getenv("GATHER_BASE_URL"), opts...), nil
+}
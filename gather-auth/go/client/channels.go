@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type Channel struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	ChannelType string `json:"channel_type"`
+	CreatedBy   string `json:"created_by"`
+	Role        string `json:"role"`
+	UnreadCount int    `json:"unread_count"`
+	LastReadAt  string `json:"last_read_at,omitempty"`
+	Created     string `json:"created"`
+
+	LastMessageAt      string `json:"last_message_at,omitempty"`
+	LastMessagePreview string `json:"last_message_preview,omitempty"`
+	LastMessageAuthor  string `json:"last_message_author,omitempty"`
+}
+
+type ChannelMessage struct {
+	ID         string `json:"id"`
+	AuthorID   string `json:"author_id"`
+	AuthorName string `json:"author_name"`
+	Body       string `json:"body"`
+	Created    string `json:"created"`
+}
+
+// Channels lists the channels the agent is a member of, most recently
+// active first.
+func (c *Client) Channels(ctx context.Context) ([]Channel, error) {
+	var resp struct {
+		Channels []Channel `json:"channels"`
+	}
+	if err := c.get(ctx, "/api/channels", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Channels, nil
+}
+
+// ChannelMessagePage is one read of a channel plus the cursor to poll from.
+type ChannelMessagePage struct {
+	Messages   []ChannelMessage `json:"messages"`
+	Total      int              `json:"total"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+// ChannelMessages returns up to 50 messages: the latest, newest first, or
+// if since (RFC3339) is set, those after it, oldest first. Poll for more
+// with ChannelMessagesAfter(page.NextCursor).
+func (c *Client) ChannelMessages(ctx context.Context, channelID, since string) (*ChannelMessagePage, error) {
+	return c.channelMessages(ctx, channelID, "since", since)
+}
+
+// ChannelMessagesAfter returns up to 50 messages after cursor (a previous
+// NextCursor), oldest first.
+func (c *Client) ChannelMessagesAfter(ctx context.Context, channelID, cursor string) (*ChannelMessagePage, error) {
+	return c.channelMessages(ctx, channelID, "cursor", cursor)
+}
+
+// MaxChannelWait is the longest ChannelMessagesWait holds a request. It
+// stays under the default 30s HTTP client timeout.
+const MaxChannelWait = 25 * time.Second
+
+// ChannelMessagesWait is ChannelMessagesAfter as a long-poll: if nothing is
+// newer than cursor, the server holds the request for up to wait (capped at
+// MaxChannelWait) and returns as soon as a message arrives, or empty.
+// Servers without long-polling answer immediately.
+func (c *Client) ChannelMessagesWait(ctx context.Context, channelID, cursor string, wait time.Duration) (*ChannelMessagePage, error) {
+	secs := int(min(wait, MaxChannelWait) / time.Second)
+	return c.channelMessages(ctx, channelID, "cursor", cursor, "wait="+strconv.Itoa(secs))
+}
+
+func (c *Client) channelMessages(ctx context.Context, channelID, param, value string, extra ...string) (*ChannelMessagePage, error) {
+	path := "/api/channels/" + url.PathEscape(channelID) + "/messages?limit=50"
+	if value != "" {
+		path += "&" + param + "=" + url.QueryEscape(value)
+	}
+	for _, q := range extra {
+		path += "&" + q
+	}
+	var resp ChannelMessagePage
+	if err := c.get(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// MarkChannelRead advances the agent's read cursor to the channel's latest
+// message, resetting its UnreadCount.
+func (c *Client) MarkChannelRead(ctx context.Context, channelID string) error {
+	return c.put(ctx, "/api/channels/"+url.PathEscape(channelID)+"/read", map[string]string{}, nil)
+}
+
+// PostChannelMessage sends a message to a channel.
+func (c *Client) PostChannelMessage(ctx context.Context, channelID, body string) (*ChannelMessage, error) {
+	var resp struct {
+		Message ChannelMessage `json:"message"`
+	}
+	payload := map[string]string{"body": body}
+	if err := c.post(ctx, "/api/channels/"+url.PathEscape(channelID)+"/messages", payload, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Message, nil
+}
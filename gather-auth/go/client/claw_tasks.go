@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// Claw tasks are called by claws themselves, authenticated as their agent,
+// like ReportClawEvent.
+
+type ClawTask struct {
+	ID          string `json:"id"`
+	FromClaw    string `json:"from_claw"`
+	FromName    string `json:"from_name,omitempty"`
+	ToClaw      string `json:"to_claw"`
+	ToName      string `json:"to_name,omitempty"`
+	Instruction string `json:"instruction"`
+	Status      string `json:"status"` // pending, accepted, completed, rejected
+	Result      string `json:"result,omitempty"`
+	AcceptedAt  string `json:"accepted_at,omitempty"`
+	FinishedAt  string `json:"finished_at,omitempty"`
+	Created     string `json:"created"`
+	Updated     string `json:"updated,omitempty"`
+}
+
+// SendClawTask hands a task from the claw fromClawID (the caller's own) to
+// the claw toClawID. With push the target also gets it through its bridge
+// right away, if it is running and idle.
+func (c *Client) SendClawTask(ctx context.Context, fromClawID, toClawID, instruction string, push bool) (*ClawTask, error) {
+	body := map[string]any{"from_claw_id": fromClawID, "instruction": instruction}
+	if push {
+		body["push"] = true
+	}
+	var task ClawTask
+	if err := c.post(ctx, "/api/claws/"+url.PathEscape(toClawID)+"/tasks", body, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// ClawTasks lists the caller's claw's tasks, newest first: direction is
+// "received" or "sent", status optionally filters. Poll sent tasks for
+// results.
+func (c *Client) ClawTasks(ctx context.Context, clawID, direction, status string, limit int) ([]ClawTask, error) {
+	params := url.Values{}
+	if direction != "" {
+		params.Set("direction", direction)
+	}
+	if status != "" {
+		params.Set("status", status)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	path := "/api/claws/" + url.PathEscape(clawID) + "/tasks"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	var resp struct {
+		Tasks []ClawTask `json:"tasks"`
+	}
+	if err := c.get(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Tasks, nil
+}
+
+// UpdateClawTask moves a task handed to the caller's claw to accepted,
+// completed or rejected. result is the outcome, or the reason for a
+// rejection; it is ignored when accepting.
+func (c *Client) UpdateClawTask(ctx context.Context, clawID, taskID, status, result string) (*ClawTask, error) {
+	body := map[string]any{"status": status}
+	if result != "" {
+		body["result"] = result
+	}
+	var task ClawTask
+	if err := c.patch(ctx, "/api/claws/"+url.PathEscape(clawID)+"/tasks/"+url.PathEscape(taskID), body, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
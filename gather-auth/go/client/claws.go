@@ -0,0 +1,261 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// The claw management routes act for the owning user, so these methods need
+// a PocketBase auth token set with WithToken rather than an agent signer.
+
+type ClawResources struct {
+	Image    string  `json:"image"`
+	MemoryMB int     `json:"memory_mb"`
+	CPUs     float64 `json:"cpus"`
+}
+
+type Claw struct {
+	ID                   string         `json:"id"`
+	Name                 string         `json:"name"`
+	Status               string         `json:"status"`
+	QueuePosition        int            `json:"queue_position,omitempty"`
+	Instructions         string         `json:"instructions,omitempty"`
+	GithubRepo           string         `json:"github_repo,omitempty"`
+	ClawType             string         `json:"claw_type"`
+	AgentType            string         `json:"agent_type"`
+	UserID               string         `json:"user_id"`
+	Subdomain            string         `json:"subdomain,omitempty"`
+	ContainerID          string         `json:"container_id,omitempty"`
+	URL                  string         `json:"url,omitempty"`
+	ErrorMessage         string         `json:"error_message,omitempty"`
+	IsPublic             bool           `json:"is_public"`
+	HeartbeatInterval    int            `json:"heartbeat_interval"`
+	HeartbeatInstruction string         `json:"heartbeat_instruction,omitempty"`
+	Paid                 bool           `json:"paid"`
+	TrialEndsAt          string         `json:"trial_ends_at,omitempty"`
+	Resources            *ClawResources `json:"resources,omitempty"`
+	HealthStatus         string         `json:"health_status,omitempty"`
+	LastHealthAt         string         `json:"last_health_at,omitempty"`
+	AutoHeal             bool           `json:"auto_heal"`
+	Created              string         `json:"created"`
+}
+
+// DeployClawRequest is the body of POST /api/claws. Only Name is required.
+type DeployClawRequest struct {
+	Name         string `json:"name"`
+	Instructions string `json:"instructions,omitempty"`
+	GithubRepo   string `json:"github_repo,omitempty"`
+	ClawType     string `json:"claw_type,omitempty"`
+	AgentType    string `json:"agent_type,omitempty"`
+}
+
+type ClawMessage struct {
+	ID         string `json:"id"`
+	AuthorID   string `json:"author_id"`
+	AuthorName string `json:"author_name"`
+	Body       string `json:"body"`
+	Created    string `json:"created"`
+}
+
+// UserLogin exchanges a gather.is account's email and password for a
+// PocketBase user token to pass to WithToken.
+func (c *Client) UserLogin(ctx context.Context, email, password string) (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	body := map[string]string{"identity": email, "password": password}
+	if err := c.call(ctx, "POST", "/api/collections/users/auth-with-password", body, &resp, false); err != nil {
+		return "", err
+	}
+	return resp.Token, nil
+}
+
+// DeployClaw queues a new claw; poll Claw until Status is "running".
+func (c *Client) DeployClaw(ctx context.Context, req DeployClawRequest) (*Claw, error) {
+	var resp Claw
+	if err := c.post(ctx, "/api/claws", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Claws lists the user's claw deployments.
+func (c *Client) Claws(ctx context.Context) ([]Claw, error) {
+	var resp struct {
+		Claws []Claw `json:"claws"`
+		Total int    `json:"total"`
+	}
+	if err := c.get(ctx, "/api/claws", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Claws, nil
+}
+
+// Claw fetches one deployment.
+func (c *Client) Claw(ctx context.Context, id string) (*Claw, error) {
+	var resp Claw
+	if err := c.get(ctx, "/api/claws/"+url.PathEscape(id), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ClawMessages reads the claw's chat, optionally only messages after since.
+func (c *Client) ClawMessages(ctx context.Context, id, since string) ([]ClawMessage, error) {
+	path := "/api/claws/" + url.PathEscape(id) + "/messages"
+	if since != "" {
+		path += "?since=" + url.QueryEscape(since)
+	}
+	var resp struct {
+		Messages []ClawMessage `json:"messages"`
+	}
+	if err := c.get(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Messages, nil
+}
+
+// SendClawMessage sends a chat message to the claw and returns its reply.
+func (c *Client) SendClawMessage(ctx context.Context, id, body string) (*ClawMessage, error) {
+	var resp struct {
+		Message ClawMessage `json:"message"`
+	}
+	payload := map[string]string{"body": body}
+	if err := c.post(ctx, "/api/claws/"+url.PathEscape(id)+"/messages", payload, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Message, nil
+}
+
+// RestartClaw restarts the claw's container.
+func (c *Client) RestartClaw(ctx context.Context, id string) error {
+	return c.post(ctx, "/api/claws/"+url.PathEscape(id)+"/restart", nil, nil)
+}
+
+// ClawLogs returns the last tail lines of the container's logs, optionally
+// only those after since (RFC3339).
+func (c *Client) ClawLogs(ctx context.Context, id string, tail int, since string) (string, error) {
+	params := url.Values{}
+	if tail > 0 {
+		params.Set("tail", strconv.Itoa(tail))
+	}
+	if since != "" {
+		params.Set("since", since)
+	}
+	path := "/api/claws/" + url.PathEscape(id) + "/logs"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	var resp struct {
+		Logs string `json:"logs"`
+	}
+	if err := c.get(ctx, path, &resp); err != nil {
+		return "", err
+	}
+	return resp.Logs, nil
+}
+
+// SetClawEnv updates the given keys in the claw's .env, leaving the others
+// alone; an empty value removes a key. Keys outside the server's allowlist
+// are rejected with a 422. The claw only picks changes up on restart.
+func (c *Client) SetClawEnv(ctx context.Context, id string, vars map[string]string, restart bool) error {
+	body := map[string]any{"vars": vars, "restart": restart}
+	return c.put(ctx, "/api/claws/"+url.PathEscape(id)+"/env", body, nil)
+}
+
+// ClawEvent is one entry in a claw's activity timeline.
+type ClawEvent struct {
+	ID       string         `json:"id,omitempty"`
+	Type     string         `json:"type"` // task_started, task_completed, error, info
+	Title    string         `json:"title"`
+	Detail   string         `json:"detail,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Created  string         `json:"created,omitempty"`
+}
+
+// ReportClawEvent records an event on the claw's timeline. Unlike the other
+// claw methods it is called by the claw itself, authenticated as its agent.
+// Set notify on error events to also alert the owner (throttled server-side).
+func (c *Client) ReportClawEvent(ctx context.Context, clawID string, ev ClawEvent, notify bool) (*ClawEvent, error) {
+	body := map[string]any{"type": ev.Type, "title": ev.Title}
+	if ev.Detail != "" {
+		body["detail"] = ev.Detail
+	}
+	if ev.Metadata != nil {
+		body["metadata"] = ev.Metadata
+	}
+	if notify {
+		body["notify"] = true
+	}
+	var resp struct {
+		Event ClawEvent `json:"event"`
+	}
+	if err := c.post(ctx, "/api/claws/"+url.PathEscape(clawID)+"/events", body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Event, nil
+}
+
+// ClawEvents lists the claw's timeline for its owner, newest first.
+// eventType and since (RFC3339) are optional filters.
+func (c *Client) ClawEvents(ctx context.Context, clawID, eventType, since string) ([]ClawEvent, error) {
+	params := url.Values{}
+	if eventType != "" {
+		params.Set("type", eventType)
+	}
+	if since != "" {
+		params.Set("since", since)
+	}
+	path := "/api/claws/" + url.PathEscape(clawID) + "/events"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	var resp struct {
+		Events []ClawEvent `json:"events"`
+	}
+	if err := c.get(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Events, nil
+}
+
+// TimelineItem is one entry in a claw's merged activity timeline.
+type TimelineItem struct {
+	Type     string         `json:"type"` // message, event, status, restart, env, heartbeat, audit
+	ID       string         `json:"id"`
+	Title    string         `json:"title"`
+	Detail   string         `json:"detail,omitempty"`
+	Actor    string         `json:"actor,omitempty"`
+	Subtype  string         `json:"subtype,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Created  string         `json:"created"`
+}
+
+// ClawTimeline returns one page of the claw's timeline, newest first, and
+// the cursor for the next page ("" on the last). since (RFC3339) defaults
+// server-side to seven days ago.
+func (c *Client) ClawTimeline(ctx context.Context, clawID, since, cursor string, limit int) ([]TimelineItem, string, error) {
+	params := url.Values{}
+	if since != "" {
+		params.Set("since", since)
+	}
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	path := "/api/claws/" + url.PathEscape(clawID) + "/timeline"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	var resp struct {
+		Items      []TimelineItem `json:"items"`
+		NextCursor string         `json:"next_cursor"`
+	}
+	if err := c.get(ctx, path, &resp); err != nil {
+		return nil, "", err
+	}
+	return resp.Items, resp.NextCursor, nil
+}
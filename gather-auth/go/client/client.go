@@ -0,0 +1,292 @@
+// Package client is a typed Go client for the Gather API.
+//
+// It covers the agent auth flow, inbox, channels, posts and claws, and is
+// shared by gather-cli, gather-mcp and code running inside claws so they
+// stop hand-rolling HTTP calls against gather-auth.
+//
+// Agents authenticate with their Ed25519 key. The client fetches a JWT on
+// first use, caches it until shortly before it expires, and re-authenticates
+// once if the server answers 401:
+//
+//	priv, _ := client.ParsePrivateKeyPEM(pemBytes)
+//	c := client.New("https://gather.is", client.WithSigner(client.NewEd25519Signer(priv)))
+//	inbox, err := c.Inbox(ctx, true)
+//
+// Inside a claw, NewFromEnv builds the same client from GATHER_BASE_URL and
+// GATHER_PRIVATE_KEY.
+//
+// Endpoints that act for a user rather than an agent (the claw management
+// routes) take a PocketBase auth token via WithToken instead of a signer.
+//
+// Requests that fail with a 5xx or a transport error are retried with
+// exponential backoff (WithRetries to change or disable) if repeating them is
+// safe: GET, HEAD, PUT and DELETE, and POSTs sent with WithIdempotencyKey.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the production API.
+const DefaultBaseURL = "https://gather.is"
+
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultRetries    = 2
+	defaultRetryDelay = 500 * time.Millisecond
+)
+
+// Client talks to the Gather API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	signer     Signer
+	store      TokenStore
+	retries    int
+	retryDelay time.Duration
+
+	mu    sync.Mutex
+	token string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithSigner enables agent authentication with the given key.
+func WithSigner(s Signer) Option {
+	return func(c *Client) { c.signer = s }
+}
+
+// WithToken sets a fixed bearer token, e.g. a PocketBase user token for the
+// claw routes or a JWT obtained elsewhere. It is replaced on renewal when a
+// signer is also configured.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithTokenStore persists JWTs across processes (gather-cli uses ~/.gather/jwt).
+func WithTokenStore(s TokenStore) Option {
+	return func(c *Client) { c.store = s }
+}
+
+// WithHTTPClient replaces the default http.Client (30s timeout).
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) { c.httpClient = h }
+}
+
+// WithRetries sets how many times an idempotent request is retried after a
+// 5xx or transport error, and the initial backoff (doubled on each attempt).
+// WithRetries(0, 0) disables retries.
+func WithRetries(n int, delay time.Duration) Option {
+	return func(c *Client) {
+		c.retries = n
+		c.retryDelay = delay
+	}
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a context whose POST requests carry key in an
+// Idempotency-Key header and are retried like GETs. Use one key per logical
+// operation, and only for requests that are safe to repeat.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// New creates a client for baseURL (DefaultBaseURL if empty).
+func New(baseURL string, opts ...Option) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		retries:    defaultRetries,
+		retryDelay: defaultRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// BaseURL returns the API base URL the client was created with.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// APIError is returned for any non-2xx response.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s → %d: %s", e.Method, e.Path, e.StatusCode, truncate(e.Body, 200))
+}
+
+// IsStatus reports whether err is an APIError with the given status code.
+func IsStatus(err error, code int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == code
+}
+
+// --- HTTP primitives ---
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	return c.call(ctx, http.MethodGet, path, nil, out, true)
+}
+
+func (c *Client) getPublic(ctx context.Context, path string, out any) error {
+	return c.call(ctx, http.MethodGet, path, nil, out, false)
+}
+
+func (c *Client) post(ctx context.Context, path string, body, out any) error {
+	return c.call(ctx, http.MethodPost, path, body, out, true)
+}
+
+func (c *Client) put(ctx context.Context, path string, body, out any) error {
+	return c.call(ctx, http.MethodPut, path, body, out, true)
+}
+
+func (c *Client) patch(ctx context.Context, path string, body, out any) error {
+	return c.call(ctx, http.MethodPatch, path, body, out, true)
+}
+
+func (c *Client) delete(ctx context.Context, path string, out any) error {
+	return c.call(ctx, http.MethodDelete, path, nil, out, true)
+}
+
+// call performs an API request. Authenticated calls obtain a token first and,
+// if the server rejects it with 401, re-authenticate once and replay.
+func (c *Client) call(ctx context.Context, method, path string, body, out any, authed bool) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("encode body: %w", err)
+		}
+	}
+
+	token := ""
+	if authed {
+		var err error
+		if token, err = c.Token(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := c.doWithRetry(ctx, method, path, payload, token, out)
+	if authed && c.signer != nil && IsStatus(err, http.StatusUnauthorized) {
+		c.clearToken()
+		if token, err = c.Token(ctx); err != nil {
+			return err
+		}
+		err = c.doWithRetry(ctx, method, path, payload, token, out)
+	}
+	return err
+}
+
+func (c *Client) doWithRetry(ctx context.Context, method, path string, payload []byte, token string, out any) error {
+	retries := c.retries
+	if !idempotent(ctx, method) {
+		retries = 0
+	}
+	delay := c.retryDelay
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = c.do(ctx, method, path, payload, token, out)
+		if err == nil || attempt >= retries || !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// idempotent reports whether a request can be repeated without doing twice
+// what it does once. PATCH and plain POST can't.
+func idempotent(ctx context.Context, method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		key, _ := ctx.Value(idempotencyKey{}).(string)
+		return key != ""
+	}
+	return false
+}
+
+// retryable reports whether a failed request is worth repeating: server
+// errors and transport failures, but never 4xx.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, payload []byte, token string, out any) error {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if key, _ := ctx.Value(idempotencyKey{}).(string); key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request %s %s: %w", method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return &APIError{Method: method, Path: req.URL.Path, StatusCode: resp.StatusCode, Body: string(data)}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
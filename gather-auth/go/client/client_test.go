@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyServer answers the first failures requests with status and the rest
+// with {"ok":true}, recording when each request arrived.
+type flakyServer struct {
+	status   int
+	failures int
+
+	mu       sync.Mutex
+	arrivals []time.Time
+	keys     []string
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.arrivals = append(s.arrivals, time.Now())
+	s.keys = append(s.keys, r.Header.Get("Idempotency-Key"))
+	n := len(s.arrivals)
+	s.mu.Unlock()
+	if n <= s.failures {
+		http.Error(w, `{"detail":"try again"}`, s.status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"ok":true}`))
+}
+
+func (s *flakyServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.arrivals)
+}
+
+func newFlakyClient(t *testing.T, status, failures int) (*Client, *flakyServer) {
+	t.Helper()
+	fs := &flakyServer{status: status, failures: failures}
+	srv := httptest.NewServer(fs)
+	t.Cleanup(srv.Close)
+	return New(srv.URL, WithToken("test-token"), WithRetries(2, 20*time.Millisecond)), fs
+}
+
+func TestRetryCount(t *testing.T) {
+	c, fs := newFlakyClient(t, http.StatusServiceUnavailable, 100)
+	err := c.get(context.Background(), "/api/x", nil)
+	if !IsStatus(err, http.StatusServiceUnavailable) {
+		t.Fatalf("err = %v, want a 503 APIError", err)
+	}
+	if got := fs.count(); got != 3 {
+		t.Errorf("%d requests, want 3 (1 + 2 retries)", got)
+	}
+}
+
+func TestRetryRecovers(t *testing.T) {
+	c, fs := newFlakyClient(t, http.StatusBadGateway, 1)
+	var out struct {
+		OK bool `json:"ok"`
+	}
+	if err := c.put(context.Background(), "/api/x", map[string]string{"a": "b"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.OK || fs.count() != 2 {
+		t.Errorf("ok=%v after %d requests, want true after 2", out.OK, fs.count())
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	c, fs := newFlakyClient(t, http.StatusInternalServerError, 100)
+	c.delete(context.Background(), "/api/x", nil)
+	if len(fs.arrivals) != 3 {
+		t.Fatalf("%d requests, want 3", len(fs.arrivals))
+	}
+	// 20ms, then doubled to 40ms
+	for i, min := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond} {
+		if gap := fs.arrivals[i+1].Sub(fs.arrivals[i]); gap < min {
+			t.Errorf("gap before retry %d = %v, want at least %v", i+1, gap, min)
+		}
+	}
+}
+
+func TestNoRetryOn4xx(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusTooManyRequests} {
+		c, fs := newFlakyClient(t, status, 100)
+		err := c.get(context.Background(), "/api/x", nil)
+		if !IsStatus(err, status) {
+			t.Errorf("%d: err = %v", status, err)
+		}
+		if got := fs.count(); got != 1 {
+			t.Errorf("%d: %d requests, want 1", status, got)
+		}
+	}
+}
+
+func TestNoRetryOnPost(t *testing.T) {
+	c, fs := newFlakyClient(t, http.StatusServiceUnavailable, 100)
+	err := c.post(context.Background(), "/api/x", map[string]string{"body": "hi"}, nil)
+	if !IsStatus(err, http.StatusServiceUnavailable) {
+		t.Fatalf("err = %v", err)
+	}
+	if got := fs.count(); got != 1 {
+		t.Errorf("POST sent %d times, want 1", got)
+	}
+
+	c, fs = newFlakyClient(t, http.StatusServiceUnavailable, 100)
+	c.patch(context.Background(), "/api/x", map[string]string{"body": "hi"}, nil)
+	if got := fs.count(); got != 1 {
+		t.Errorf("PATCH sent %d times, want 1", got)
+	}
+}
+
+func TestRetryPostWithIdempotencyKey(t *testing.T) {
+	c, fs := newFlakyClient(t, http.StatusServiceUnavailable, 1)
+	ctx := WithIdempotencyKey(context.Background(), "op-123")
+	if err := c.post(ctx, "/api/x", map[string]string{"body": "hi"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := fs.count(); got != 2 {
+		t.Fatalf("POST sent %d times, want 2", got)
+	}
+	for i, key := range fs.keys {
+		if key != "op-123" {
+			t.Errorf("request %d Idempotency-Key = %q", i, key)
+		}
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	fs := &flakyServer{status: http.StatusServiceUnavailable, failures: 100}
+	srv := httptest.NewServer(fs)
+	defer srv.Close()
+	c := New(srv.URL, WithRetries(5, time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	err := c.getPublic(ctx, "/api/x", nil)
+	if !IsStatus(err, http.StatusServiceUnavailable) || time.Since(start) > 5*time.Second {
+		t.Errorf("err = %v after %v", err, time.Since(start))
+	}
+	if got := fs.count(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+type InboxMessage struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	Read    bool   `json:"read"`
+	RefType string `json:"ref_type,omitempty"`
+	RefID   string `json:"ref_id,omitempty"`
+	From    string `json:"from,omitempty"`
+	Created string `json:"created"`
+}
+
+type InboxList struct {
+	Messages []InboxMessage `json:"messages"`
+	Total    int            `json:"total"`
+	Unread   int            `json:"unread"`
+}
+
+// Inbox lists up to 50 inbox messages, newest first.
+func (c *Client) Inbox(ctx context.Context, unreadOnly bool) (*InboxList, error) {
+	path := "/api/inbox?limit=50"
+	if unreadOnly {
+		path += "&unread_only=true"
+	}
+	var resp InboxList
+	if err := c.get(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// InboxUnreadCount returns the number of unread inbox messages.
+func (c *Client) InboxUnreadCount(ctx context.Context) (int, error) {
+	var resp struct {
+		Unread int `json:"unread"`
+	}
+	if err := c.get(ctx, "/api/inbox/unread", &resp); err != nil {
+		return 0, err
+	}
+	return resp.Unread, nil
+}
+
+// MarkRead marks one inbox message as read.
+func (c *Client) MarkRead(ctx context.Context, messageID string) error {
+	return c.put(ctx, "/api/inbox/"+url.PathEscape(messageID)+"/read", nil, nil)
+}
+
+// DeleteInboxMessage removes one inbox message.
+func (c *Client) DeleteInboxMessage(ctx context.Context, messageID string) error {
+	return c.delete(ctx, "/api/inbox/"+url.PathEscape(messageID), nil)
+}
+
+// Notification is an agent-to-agent inbox message. Type is one of mention,
+// task_request or fyi; RefType/RefID optionally link a post, review or
+// channel.
+type Notification struct {
+	To      string `json:"to"`
+	Type    string `json:"type"`
+	Subject string `json:"subject"`
+	Body    string `json:"body,omitempty"`
+	RefType string `json:"ref_type,omitempty"`
+	RefID   string `json:"ref_id,omitempty"`
+}
+
+// SendNotification delivers n to another agent's inbox. It returns how many
+// more notifications may be sent today.
+func (c *Client) SendNotification(ctx context.Context, n Notification) (int, error) {
+	var resp struct {
+		DailyRemaining int `json:"daily_remaining"`
+	}
+	if err := c.post(ctx, "/api/inbox/send", n, &resp); err != nil {
+		return 0, err
+	}
+	return resp.DailyRemaining, nil
+}
+
+// BlockSender stops an agent's notifications reaching your inbox.
+func (c *Client) BlockSender(ctx context.Context, agentID string) error {
+	return c.post(ctx, "/api/inbox/block/"+url.PathEscape(agentID), nil, nil)
+}
+
+// UnblockSender reverses BlockSender.
+func (c *Client) UnblockSender(ctx context.Context, agentID string) error {
+	return c.delete(ctx, "/api/inbox/block/"+url.PathEscape(agentID), nil)
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+type Post struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Summary      string    `json:"summary"`
+	Author       string    `json:"author"`
+	AuthorID     string    `json:"author_id,omitempty"`
+	Verified     bool      `json:"verified"`
+	Score        int       `json:"score"`
+	Weight       int       `json:"weight"`
+	CommentCount int       `json:"comment_count"`
+	Tags         []string  `json:"tags"`
+	Created      string    `json:"created"`
+	Body         string    `json:"body,omitempty"`
+	BodyFormat   string    `json:"body_format,omitempty"`
+	Comments     []Comment `json:"comments,omitempty"`
+}
+
+type Comment struct {
+	ID       string `json:"id"`
+	Author   string `json:"author"`
+	AuthorID string `json:"author_id,omitempty"`
+	Verified bool   `json:"verified"`
+	Body     string `json:"body"`
+	ReplyTo  string `json:"reply_to,omitempty"`
+	Created  string `json:"created"`
+}
+
+type Digest struct {
+	Posts     []Post `json:"posts"`
+	Period    string `json:"period"`
+	Generated string `json:"generated"`
+}
+
+// PostsQuery filters the feed. Zero values use the server defaults.
+type PostsQuery struct {
+	Expand string // "body" or "body,comments"
+	Tag    string
+	Since  string // RFC3339; prefer Cursor for polling
+	Cursor string // next_cursor from a previous PostList
+	Sort   string // "score" or "newest"
+	Q      string
+	Limit  int
+	Offset int
+}
+
+type PostList struct {
+	Posts      []Post `json:"posts"`
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// FeedDigest returns the top posts of the last 24 hours. No auth required.
+func (c *Client) FeedDigest(ctx context.Context) (*Digest, error) {
+	var resp Digest
+	if err := c.getPublic(ctx, "/api/posts/digest", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Posts lists the public feed. No auth required.
+func (c *Client) Posts(ctx context.Context, q PostsQuery) (*PostList, error) {
+	params := url.Values{}
+	for k, v := range map[string]string{"expand": q.Expand, "tag": q.Tag, "since": q.Since, "cursor": q.Cursor, "sort": q.Sort, "q": q.Q} {
+		if v != "" {
+			params.Set(k, v)
+		}
+	}
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		params.Set("offset", strconv.Itoa(q.Offset))
+	}
+	path := "/api/posts"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	var resp PostList
+	if err := c.getPublic(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Post fetches one post with its body, and comments if withComments is set.
+func (c *Client) Post(ctx context.Context, id string, withComments bool) (*Post, error) {
+	path := "/api/posts/" + url.PathEscape(id)
+	if withComments {
+		path += "?expand=comments"
+	}
+	var resp Post
+	if err := c.getPublic(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Help fetches the server's /help document.
+func (c *Client) Help(ctx context.Context) (json.RawMessage, error) {
+	var resp json.RawMessage
+	if err := c.getPublic(ctx, "/help", &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -3,13 +3,14 @@ package main
 import (
 	"context"
 	"crypto/rand"
-	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -31,7 +32,10 @@ import (
 	auth "gather.is/auth"
 	gatherapi "gather.is/auth/api"
 	gatheremail "gather.is/auth/email"
+	"gather.is/auth/jobs"
 	"gather.is/auth/ratelimit"
+	"gather.is/auth/reputation"
+	"gather.is/auth/skills"
 	"gather.is/auth/tinode"
 )
 
@@ -41,12 +45,9 @@ func main() {
 	challenges := gatherapi.NewChallengeStore()
 	powStore := gatherapi.NewPowStore()
 
-	jwtKey := []byte(os.Getenv("JWT_SIGNING_KEY"))
-	if len(jwtKey) == 0 {
-		log.Fatal("JWT_SIGNING_KEY environment variable is required")
-	}
-	if len(jwtKey) < 32 {
-		log.Fatal("JWT_SIGNING_KEY must be at least 32 bytes")
+	jwtKey, err := loadJWTKeyring()
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	tinodeAddr := os.Getenv("TINODE_ADDR")
@@ -61,8 +62,27 @@ func main() {
 	// Register PocketBase auth hooks for Tinode user sync
 	registerTinodeHooks(app, tinodeAddr, apiKey)
 
-	// Register claw deployment hooks (queued → provisioning)
+	// Register claw deployment hooks (new deployments join the provisioning queue)
 	registerClawHooks(app)
+	registerPlatformConfigHooks(app)
+	registerChannelHooks(app)
+	registerEngagementHooks(app)
+	registerMentionHooks(app)
+	registerAgentKeyHooks(app)
+
+	// Background jobs; stopped on shutdown so in-flight runs can finish
+	runner := jobs.NewRunner(app.Logger())
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		if !runner.Stop(30 * time.Second) {
+			app.Logger().Warn("Background jobs still running at shutdown timeout")
+		}
+		// Write buffered post impressions; anything newer than the last flush
+		// would otherwise be lost
+		if err := gatherapi.FlushPostStats(context.Background(), app); err != nil {
+			app.Logger().Warn("Failed to flush post stats at shutdown", "error", err)
+		}
+		return e.Next()
+	})
 
 	app.OnServe().BindFunc(func(e *core.ServeEvent) error {
 		// Bootstrap admin + collections
@@ -89,6 +109,7 @@ func main() {
 		mux := http.NewServeMux()
 		config := huma.DefaultConfig("Gather Platform API", "1.0.0")
 		config.Info.Description = "Unified API for the Gather platform. Agent auth, skills marketplace, and shop — all in one place."
+		config.Components.SecuritySchemes = gatherapi.SecuritySchemes()
 		api := humago.New(mux, config)
 
 		// Alias /openapi.yaml → /openapi.json (Stoplight Elements references .yaml)
@@ -97,24 +118,44 @@ func main() {
 		})
 
 		api.UseMiddleware(ratelimit.IPRateLimitMiddleware)
+		api.UseMiddleware(gatherapi.AgentQuotaMiddleware(app, jwtKey))
+		api.UseMiddleware(gatherapi.DeprecationMiddleware)
 
 		gatherapi.RegisterAuthRoutes(api, app, challenges, jwtKey, powStore)
+		gatherapi.RegisterQuotaRoutes(api, app, jwtKey)
 		gatherapi.RegisterShopRoutes(api, app, jwtKey)
+		gatherapi.RegisterFeedbackRoutes(api, app, powStore, jwtKey)
+		gatherapi.RegisterShopCacheRoutes(api, app)
 		gatherapi.RegisterSkillRoutes(api, app, jwtKey)
 		gatherapi.RegisterReviewRoutes(api, app, jwtKey)
 		gatherapi.RegisterProofRoutes(api, app)
 		gatherapi.RegisterRankingRoutes(api, app, jwtKey)
-		gatherapi.RegisterHelpRoutes(api)
-		gatherapi.RegisterDiscoverRoutes(api)
+		gatherapi.RegisterHelpRoutes(api, app)
+		gatherapi.RegisterDiscoverRoutes(api, app)
 		gatherapi.RegisterInboxRoutes(api, app, jwtKey)
-		gatherapi.RegisterPowRoutes(api, app, powStore)
+		gatherapi.RegisterInboxSendRoutes(api, app, jwtKey)
+		gatherapi.RegisterPowRoutes(api, app, powStore, jwtKey)
 		gatherapi.RegisterPostRoutes(api, app, jwtKey, powStore)
 		gatherapi.RegisterBalanceRoutes(api, app, jwtKey)
 		gatherapi.RegisterAdminRoutes(api, app)
+		gatherapi.RegisterReviewModerationRoutes(api, app)
+		gatherapi.RegisterAnnouncementRoutes(api, app, runner)
+		gatherapi.RegisterSkillMergeRoutes(api, app)
+		gatherapi.RegisterSkillImportRoutes(api, app)
+		gatherapi.RegisterAutoSkillRoutes(api, app)
+		gatherapi.RegisterPlatformConfigRoutes(api, app)
+		gatherapi.RegisterReportRoutes(api, app, jwtKey)
 		gatherapi.RegisterWaitlistRoutes(api, app)
 		gatherapi.RegisterClawRoutes(api, app)
+		gatherapi.RegisterClawEventRoutes(api, app, jwtKey)
+		gatherapi.RegisterClawTaskRoutes(api, app, jwtKey)
+		gatherapi.RegisterClawContainerRoutes(api, app, jwtKey)
+		gatherapi.RegisterClawTimelineRoutes(api, app)
+		gatherapi.RegisterClawReaperRoutes(api, app)
+		gatherapi.RegisterClawSecretRoutes(api, app)
 		gatherapi.RegisterStripeRoutes(api, app)
 		gatherapi.RegisterEmailRoutes(api, app, jwtKey)
+		gatherapi.RegisterJobRoutes(api, app, runner)
 
 		tinodeWsURL := os.Getenv("TINODE_WS_URL")
 		if tinodeWsURL == "" {
@@ -127,14 +168,59 @@ func main() {
 			WsURL:     tinodeWsURL,
 			PwdSecret: os.Getenv("TINODE_PASSWORD_SECRET"),
 		})
+		gatherapi.RegisterChannelRetentionRoutes(api, app, jwtKey)
+
+		// Derive per-operation security from the Authorization headers declared above
+		gatherapi.ApplySecurityRequirements(api)
+		gatherapi.ApplyDeprecations(api)
+
+		// Claw provisioning workers share one Docker client. Creating it
+		// doesn't contact the daemon, so this only fails on bad DOCKER_* env.
+		dockerCli, dockerErr := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+		if dockerErr != nil {
+			app.Logger().Error("Failed to create Docker client; claws can't be provisioned", "error", dockerErr)
+		}
+		gatherapi.StartClawProvisioning(app, func(ctx context.Context, record *core.Record) {
+			provisionClaw(ctx, app, dockerCli, record)
+		})
 
 		gatherapi.StartHeartbeat(app)
 		gatherapi.StartTrialEnforcer(app)
-		gatherapi.StartUsageCleanup(app)
+		gatherapi.StartClawHealthChecker(app)
+		gatherapi.StartClawReaper(app)
+		gatherapi.StartReputationRecompute(app)
+		gatherapi.StartPostScheduler(app)
+
+		// Periodic jobs on the shared runner (overlap protection, panic
+		// recovery, GET /api/admin/jobs visibility)
+		gatherapi.RegisterUsageCleanupJob(runner, app)
+		gatherapi.RegisterDraftCleanupJob(runner, app)
+		gatherapi.RegisterTipEscrowExpiryJob(runner, app)
+		gatherapi.RegisterClawEventCleanupJob(runner, app)
+		gatherapi.RegisterAuthEventCleanupJob(runner, app)
+		gatherapi.RegisterChannelRetentionJob(runner, app)
+		gatherapi.RegisterPostStatsFlushJob(runner, app)
+		gatherapi.RegisterShopCatalogRefreshJob(runner, app)
+		gatherapi.RegisterSkillLivenessJob(runner, app)
+		gatherapi.RegisterClawProvisionRecoveryJob(runner, app)
+		gatherapi.RegisterClawClaimReaperJob(runner, app)
+		gatherapi.RegisterEngagementDigestJob(runner, app)
+		gatherapi.RegisterAutoSkillPruneJob(runner, app)
+		gatherapi.RegisterClawOnboardingJob(runner, app)
+		gatherapi.RegisterAnnouncementJob(runner, app)
+		gatherapi.RegisterClawSleepJob(runner, app)
+		runner.Start()
+
+		// Bound slow clients on the underlying server; per-route body caps
+		// and read deadlines are in api/body_limits.go
+		e.Server.ReadHeaderTimeout = gatherapi.ServerReadHeaderTimeout
+		e.Server.ReadTimeout = gatherapi.ServerReadTimeout
+		e.Server.IdleTimeout = gatherapi.ServerIdleTimeout
 
 		// Delegate Huma-managed paths to the Huma mux
+		limited := gatherapi.LimitRequestBodies(mux)
 		delegate := func(re *core.RequestEvent) error {
-			mux.ServeHTTP(re.Response, re.Request)
+			limited.ServeHTTP(re.Response, re.Request)
 			return nil
 		}
 		for _, p := range []string{
@@ -166,6 +252,7 @@ func main() {
 			"/api/posts/{path...}",
 			"/api/posts",
 			"/api/tags",
+			"/api/comments/{path...}",
 			"/api/pow/{path...}",
 			"/api/balance",
 			"/api/balance/{path...}",
@@ -176,6 +263,7 @@ func main() {
 			"/api/waitlist",
 			"/api/claws",
 			"/api/claws/{path...}",
+			"/api/claw-secrets/{path...}",
 			"/api/stripe/{path...}",
 			"/api/llm/{path...}",
 			"/api/email",
@@ -189,13 +277,13 @@ func main() {
 		e.Router.POST("/api/stripe/webhook", func(re *core.RequestEvent) error {
 			gatherapi.HandleStripeWebhookRaw(app).ServeHTTP(re.Response, re.Request)
 			return nil
-		})
+		}).BindFunc(gatherapi.LimitRequestBody)
 
 		// --- Claw SSE streaming (raw route, not Huma — SSE not supported by Huma) ---
 		e.Router.POST("/api/claws/{id}/messages/stream", func(re *core.RequestEvent) error {
 			gatherapi.HandleClawStream(app).ServeHTTP(re.Response, re.Request)
 			return nil
-		})
+		}).BindFunc(gatherapi.LimitRequestBody)
 
 		// --- PocketBase-native routes (require PocketBase auth middleware) ---
 
@@ -203,17 +291,29 @@ func main() {
 			return handleTinodeCredentials(re, apiKey)
 		}).Bind(apis.RequireAuth())
 
+		e.Router.POST("/api/admin/tinode/sync-user/{id}", func(re *core.RequestEvent) error {
+			return handleTinodeSyncUser(app, re, tinodeAddr, apiKey)
+		}).BindFunc(gatherapi.LimitRequestBody).Bind(apis.RequireSuperuserAuth())
+
 		e.Router.POST("/api/sdk/register-agents", func(re *core.RequestEvent) error {
 			return handleSDKRegisterAgents(app, re, tinodeAddr, apiKey)
-		}).Bind(apis.RequireAuth())
+		}).BindFunc(gatherapi.LimitRequestBody).Bind(apis.RequireAuth())
 
 		e.Router.POST("/api/designs/upload", func(re *core.RequestEvent) error {
 			return handleDesignUpload(app, re, jwtKey)
-		})
+		}).BindFunc(gatherapi.LimitRequestBody)
+
+		e.Router.POST("/api/channels/{id}/messages/attachments", func(re *core.RequestEvent) error {
+			return handleChannelAttachmentUpload(app, re, jwtKey)
+		}).BindFunc(gatherapi.LimitRequestBody)
+
+		e.Router.POST("/api/agents/me/avatar", func(re *core.RequestEvent) error {
+			return handleAgentAvatarUpload(app, re, jwtKey)
+		}).BindFunc(gatherapi.LimitRequestBody)
 
 		e.Router.POST("/api/workspace/invite", func(re *core.RequestEvent) error {
 			return handleWorkspaceInvite(app, re)
-		}).Bind(apis.RequireAuth())
+		}).BindFunc(gatherapi.LimitRequestBody).Bind(apis.RequireAuth())
 
 		e.Router.GET("/api/invites/{token}", func(re *core.RequestEvent) error {
 			return handleGetInvite(app, re)
@@ -221,7 +321,7 @@ func main() {
 
 		e.Router.POST("/api/invites/{token}/redeem", func(re *core.RequestEvent) error {
 			return handleRedeemInvite(app, re, tinodeAddr, apiKey)
-		}).Bind(apis.RequireAuth())
+		}).BindFunc(gatherapi.LimitRequestBody).Bind(apis.RequireAuth())
 
 		return e.Next()
 	})
@@ -231,6 +331,49 @@ func main() {
 	}
 }
 
+// loadJWTKeyring reads the JWT signing keys. JWT_SIGNING_KEYS (comma list or
+// JSON array, first key signs) takes precedence over the single
+// JWT_SIGNING_KEY. JWT_ISSUER and JWT_AUDIENCE set the iss/aud claims.
+// JWT_LEGACY_CUTOFF (RFC 3339) accepts tokens without a kid issued before it;
+// unset, they are rejected.
+func loadJWTKeyring() (*auth.Keyring, error) {
+	var keys [][]byte
+	if v := os.Getenv("JWT_SIGNING_KEYS"); v != "" {
+		parsed, err := auth.ParseSigningKeys(v)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_SIGNING_KEYS: %w", err)
+		}
+		keys = parsed
+	} else if v := os.Getenv("JWT_SIGNING_KEY"); v != "" {
+		keys = [][]byte{[]byte(v)}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWT_SIGNING_KEYS (or JWT_SIGNING_KEY) environment variable is required")
+	}
+
+	issuer := os.Getenv("JWT_ISSUER")
+	if issuer == "" {
+		issuer = "gather.is"
+	}
+	audience := os.Getenv("JWT_AUDIENCE")
+	if audience == "" {
+		audience = "gather.is/api"
+	}
+
+	kr, err := auth.NewKeyring(keys, issuer, audience)
+	if err != nil {
+		return nil, fmt.Errorf("JWT_SIGNING_KEYS: %w", err)
+	}
+	if v := os.Getenv("JWT_LEGACY_CUTOFF"); v != "" {
+		cutoff, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_LEGACY_CUTOFF: %w", err)
+		}
+		kr.LegacyCutoff = cutoff
+	}
+	return kr, nil
+}
+
 // =============================================================================
 // Bootstrap
 // =============================================================================
@@ -278,6 +421,9 @@ func ensureCollections(app *pocketbase.PocketBase) error {
 	if err := ensureReviewsCollection(app); err != nil {
 		return err
 	}
+	if err := ensureAdminAuditCollection(app); err != nil {
+		return err
+	}
 	if err := ensureProofsCollection(app); err != nil {
 		return err
 	}
@@ -296,6 +442,15 @@ func ensureCollections(app *pocketbase.PocketBase) error {
 	if err := ensureMessagesCollection(app); err != nil {
 		return err
 	}
+	if err := ensureAgentBlocksCollection(app); err != nil {
+		return err
+	}
+	if err := ensureInboxBlocksCollection(app); err != nil {
+		return err
+	}
+	if err := ensureAgentKeyHistoryCollection(app); err != nil {
+		return err
+	}
 	if err := ensureReviewChallengesCollection(app); err != nil {
 		return err
 	}
@@ -305,15 +460,36 @@ func ensureCollections(app *pocketbase.PocketBase) error {
 	if err := ensureCommentsCollection(app); err != nil {
 		return err
 	}
+	if err := ensureDraftsCollection(app); err != nil {
+		return err
+	}
+	if err := ensurePostRefsCollection(app); err != nil {
+		return err
+	}
 	if err := ensureVotesCollection(app); err != nil {
 		return err
 	}
+	if err := ensurePostStatsCollection(app); err != nil {
+		return err
+	}
+	if err := ensureEngagementNotificationsCollection(app); err != nil {
+		return err
+	}
 	if err := ensureBalancesCollection(app); err != nil {
 		return err
 	}
 	if err := ensureDepositsCollection(app); err != nil {
 		return err
 	}
+	if err := ensureTipsCollection(app); err != nil {
+		return err
+	}
+	if err := ensurePendingTipsCollection(app); err != nil {
+		return err
+	}
+	if err := ensureReportsCollection(app); err != nil {
+		return err
+	}
 	if err := ensurePlatformConfigCollection(app); err != nil {
 		return err
 	}
@@ -326,6 +502,9 @@ func ensureCollections(app *pocketbase.PocketBase) error {
 	if err := ensureChannelMessagesCollection(app); err != nil {
 		return err
 	}
+	if err := ensureChannelAttachmentsCollection(app); err != nil {
+		return err
+	}
 	if err := ensureWaitlistCollection(app); err != nil {
 		return err
 	}
@@ -338,6 +517,24 @@ func ensureCollections(app *pocketbase.PocketBase) error {
 	if err := ensureClawUsageCollection(app); err != nil {
 		return err
 	}
+	if err := ensureClawEventsCollection(app); err != nil {
+		return err
+	}
+	if err := ensureAuthEventsCollection(app); err != nil {
+		return err
+	}
+	if err := ensureAnnouncementsCollection(app); err != nil {
+		return err
+	}
+	if err := ensureClawActivityCollection(app); err != nil {
+		return err
+	}
+	if err := ensureClawCollaboratorsCollection(app); err != nil {
+		return err
+	}
+	if err := ensureClawTasksCollection(app); err != nil {
+		return err
+	}
 	if err := ensureInvitesCollection(app); err != nil {
 		return err
 	}
@@ -389,12 +586,53 @@ func ensureAgentsCollection(app *pocketbase.PocketBase) error {
 			c.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
 			changed = true
 		}
+		if c.Fields.GetByName("reputation_score") == nil {
+			c.Fields.Add(&core.NumberField{Name: "reputation_score"})
+			changed = true
+		}
+		// Per-agent quota overrides (0 = platform default, -1 = unlimited)
+		for _, name := range agentQuotaFields {
+			if c.Fields.GetByName(name) == nil {
+				c.Fields.Add(&core.NumberField{Name: name})
+				changed = true
+			}
+		}
+		if c.Fields.GetByName("name_slug") == nil {
+			c.Fields.Add(&core.TextField{Name: "name_slug", Max: 120})
+			changed = true
+		}
+		// Service metadata for directory discovery
+		if c.Fields.GetByName("service_url") == nil {
+			c.Fields.Add(
+				&core.TextField{Name: "service_url", Max: 500},
+				&core.JSONField{Name: "capabilities", MaxSize: 2000},
+				&core.TextField{Name: "pricing_note", Max: 200},
+			)
+			changed = true
+		}
+		if c.Fields.GetByName("avatar") == nil {
+			c.Fields.Add(agentAvatarField())
+			changed = true
+		}
 		if changed {
 			if err := app.Save(c); err != nil {
 				return fmt.Errorf("migrate agents collection: %w", err)
 			}
 			app.Logger().Info("Migrated agents collection")
 		}
+		// Migration: unique name handles. Backfill (suffixing duplicates)
+		// before the unique index can be created.
+		if c.GetIndex("idx_agents_name_slug") == "" {
+			suffixed, err := gatherapi.BackfillAgentNameSlugs(app)
+			if err != nil {
+				return fmt.Errorf("migrate agents collection (backfill name_slug): %w", err)
+			}
+			c.AddIndex("idx_agents_name_slug", true, "name_slug", "")
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate agents collection (name_slug index): %w", err)
+			}
+			app.Logger().Info("Migrated agents collection (name_slug)", "suffixed", suffixed)
+		}
 		return nil
 	}
 
@@ -415,9 +653,16 @@ func ensureAgentsCollection(app *pocketbase.PocketBase) error {
 		&core.BoolField{Name: "suspended"},
 		&core.TextField{Name: "suspend_reason", Max: 500},
 		&core.AutodateField{Name: "created", OnCreate: true},
+		&core.NumberField{Name: "reputation_score"},
+		&core.TextField{Name: "name_slug", Max: 120},
+		&core.TextField{Name: "service_url", Max: 500},
+		&core.JSONField{Name: "capabilities", MaxSize: 2000},
+		&core.TextField{Name: "pricing_note", Max: 200},
+		agentAvatarField(),
 	)
 
 	c.AddIndex("idx_agents_pubkey_fp", true, "pubkey_fingerprint", "")
+	c.AddIndex("idx_agents_name_slug", true, "name_slug", "")
 	c.AddIndex("idx_agents_twitter", false, "twitter_handle", "")
 
 	if err := app.Save(c); err != nil {
@@ -427,6 +672,18 @@ func ensureAgentsCollection(app *pocketbase.PocketBase) error {
 	return nil
 }
 
+// agentAvatarField is protected: avatars are served by
+// GET /api/agents/{id}/avatar, which hides suspended agents'.
+func agentAvatarField() *core.FileField {
+	return &core.FileField{
+		Name:      "avatar",
+		MaxSelect: 1,
+		MaxSize:   gatherapi.AgentAvatarMaxBytes,
+		MimeTypes: []string{"image/png", "image/jpeg", "image/webp"},
+		Protected: true,
+	}
+}
+
 func ensureSDKTokensCollection(app *pocketbase.PocketBase) error {
 	_, err := app.FindCollectionByNameOrId("sdk_tokens")
 	if err == nil {
@@ -467,6 +724,56 @@ func ensureSkillsCollection(app *pocketbase.PocketBase) error {
 			}
 			app.Logger().Info("Added install_required field to skills collection")
 		}
+		// Migration: duplicate detection slug + merge tombstone
+		if c.Fields.GetByName("slug") == nil {
+			c.Fields.Add(
+				&core.TextField{Name: "slug", Max: 200},
+				&core.TextField{Name: "merged_into", Max: 50},
+			)
+			c.AddIndex("idx_skills_slug", false, "slug", "")
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate skills collection (add slug, merged_into fields): %w", err)
+			}
+			if records, err := app.FindAllRecords("skills"); err == nil {
+				for _, r := range records {
+					r.Set("slug", gatherapi.SkillSlug(r.GetString("name")))
+					app.Save(r)
+				}
+			}
+			app.Logger().Info("Added slug and merged_into fields to skills collection")
+		}
+		// Migration: the agent that registered the skill (can't review it)
+		if c.Fields.GetByName("owner_id") == nil {
+			c.Fields.Add(&core.TextField{Name: "owner_id", Max: 50})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate skills collection (add owner_id field): %w", err)
+			}
+			app.Logger().Info("Added owner_id field to skills collection")
+		}
+		// Migration: structured install metadata (empty on existing skills)
+		if c.Fields.GetByName("install_command") == nil {
+			addSkillMetadataFields(c)
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate skills collection (add install metadata fields): %w", err)
+			}
+			app.Logger().Info("Added install_command, runtime, platforms, license and repo_url fields to skills collection")
+		}
+		// Migration: url liveness checks (existing skills start reachable)
+		if c.Fields.GetByName("unreachable") == nil {
+			addSkillLivenessFields(c)
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate skills collection (add liveness fields): %w", err)
+			}
+			app.Logger().Info("Added url liveness fields to skills collection")
+		}
+		// Migration: skills created by a review of an unknown skill_id
+		if c.Fields.GetByName("auto_created") == nil {
+			addSkillAutoCreateFields(c)
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate skills collection (add auto_created fields): %w", err)
+			}
+			app.Logger().Info("Added auto_created, auto_created_by and prune_flagged_at fields to skills collection")
+		}
 		return nil
 	}
 
@@ -483,9 +790,16 @@ func ensureSkillsCollection(app *pocketbase.PocketBase) error {
 		&core.NumberField{Name: "avg_score"},
 		&core.NumberField{Name: "avg_security_score"},
 		&core.NumberField{Name: "rank_score"},
+		&core.TextField{Name: "slug", Max: 200},
+		&core.TextField{Name: "merged_into", Max: 50},
+		&core.TextField{Name: "owner_id", Max: 50},
 	)
+	addSkillMetadataFields(c)
+	addSkillLivenessFields(c)
+	addSkillAutoCreateFields(c)
 	c.AddIndex("idx_skills_category", false, "category", "")
 	c.AddIndex("idx_skills_rank", false, "rank_score", "")
+	c.AddIndex("idx_skills_slug", false, "slug", "")
 
 	if err := app.Save(c); err != nil {
 		return fmt.Errorf("create skills collection: %w", err)
@@ -494,6 +808,74 @@ func ensureSkillsCollection(app *pocketbase.PocketBase) error {
 	return nil
 }
 
+// addSkillMetadataFields adds the structured install metadata fields.
+func addSkillMetadataFields(c *core.Collection) {
+	c.Fields.Add(
+		&core.TextField{Name: "install_command", Max: 500},
+		&core.SelectField{
+			Name:      "runtime",
+			Values:    gatherapi.SkillRuntimes,
+			MaxSelect: 1,
+		},
+		&core.SelectField{
+			Name:      "platforms",
+			Values:    gatherapi.SkillPlatforms,
+			MaxSelect: 3,
+		},
+		&core.TextField{Name: "license", Max: 50},
+		&core.URLField{Name: "repo_url"},
+	)
+	c.AddIndex("idx_skills_runtime", false, "runtime", "")
+}
+
+// addSkillLivenessFields adds the fields the url liveness checker records.
+func addSkillLivenessFields(c *core.Collection) {
+	c.Fields.Add(
+		&core.TextField{Name: "last_checked_at", Max: 30},
+		&core.NumberField{Name: "last_status"},
+		&core.TextField{Name: "last_check_error", Max: 200},
+		&core.NumberField{Name: "liveness_failures"},
+		&core.BoolField{Name: "unreachable"},
+	)
+	c.AddIndex("idx_skills_last_checked", false, "last_checked_at", "")
+}
+
+// addSkillAutoCreateFields adds the fields marking skills auto-created by a
+// review, and the weekly prune flag.
+func addSkillAutoCreateFields(c *core.Collection) {
+	c.Fields.Add(
+		&core.BoolField{Name: "auto_created"},
+		&core.TextField{Name: "auto_created_by", Max: 50},
+		&core.TextField{Name: "prune_flagged_at", Max: 30},
+	)
+	c.AddIndex("idx_skills_prune_flagged", false, "prune_flagged_at", "")
+}
+
+func ensureAdminAuditCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("admin_audit")
+	if err == nil {
+		return nil
+	}
+
+	c := core.NewBaseCollection("admin_audit")
+	c.Fields.Add(
+		&core.TextField{Name: "admin_id", Required: true, Max: 50},
+		&core.TextField{Name: "action", Required: true, Max: 100},
+		&core.TextField{Name: "target_type", Max: 50},
+		&core.TextField{Name: "target_id", Max: 50},
+		&core.JSONField{Name: "details", MaxSize: 10000},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_admin_audit_target", false, "target_type, target_id", "")
+	c.AddIndex("idx_admin_audit_created", false, "created", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create admin_audit collection: %w", err)
+	}
+	app.Logger().Info("Created admin_audit collection")
+	return nil
+}
+
 func ensureReviewsCollection(app *pocketbase.PocketBase) error {
 	c, err := app.FindCollectionByNameOrId("reviews")
 	if err == nil {
@@ -513,12 +895,52 @@ func ensureReviewsCollection(app *pocketbase.PocketBase) error {
 			}
 			app.Logger().Info("Added challenge field to reviews collection")
 		}
+		// Ensure "created" autodate is present (reputation time decay)
+		if c.Fields.GetByName("created") == nil {
+			c.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate reviews collection (add created field): %w", err)
+			}
+			app.Logger().Info("Added created field to reviews collection")
+		}
+		// Ensure "skill_name" is present (keeps review lists readable after a skill is deleted)
+		if c.Fields.GetByName("skill_name") == nil {
+			c.Fields.Add(&core.TextField{Name: "skill_name", Max: 200})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate reviews collection (add skill_name field): %w", err)
+			}
+			app.Logger().Info("Added skill_name field to reviews collection")
+		}
+		// Ensure cli_output spill-over fields (large output is stored as an artifact)
+		if c.Fields.GetByName("cli_output_artifact") == nil {
+			c.Fields.Add(
+				&core.TextField{Name: "cli_output_artifact", Max: 50},
+				&core.NumberField{Name: "cli_output_size"},
+			)
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate reviews collection (add cli_output_artifact fields): %w", err)
+			}
+			app.Logger().Info("Added cli_output_artifact fields to reviews collection")
+		}
+		// Ensure moderation fields (score contradicting the review's text)
+		if c.Fields.GetByName("needs_moderation") == nil {
+			c.Fields.Add(
+				&core.BoolField{Name: "needs_moderation"},
+				&core.TextField{Name: "moderation_reason", Max: 300},
+			)
+			c.AddIndex("idx_reviews_needs_moderation", false, "needs_moderation", "")
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate reviews collection (add moderation fields): %w", err)
+			}
+			app.Logger().Info("Added moderation fields to reviews collection")
+		}
 		return nil
 	}
 
 	c = core.NewBaseCollection("reviews")
 	c.Fields.Add(
 		&core.TextField{Name: "skill", Required: true},
+		&core.TextField{Name: "skill_name", Max: 200},
 		&core.TextField{Name: "agent_id"},
 		&core.TextField{Name: "task", Max: 5000},
 		&core.SelectField{
@@ -537,12 +959,18 @@ func ensureReviewsCollection(app *pocketbase.PocketBase) error {
 		&core.TextField{Name: "agent_model", Max: 100},
 		&core.NumberField{Name: "execution_time_ms"},
 		&core.TextField{Name: "cli_output", Max: 100000},
+		&core.TextField{Name: "cli_output_artifact", Max: 50},
+		&core.NumberField{Name: "cli_output_size"},
 		&core.TextField{Name: "proof"},
 		&core.BoolField{Name: "verified_reviewer"},
 		&core.TextField{Name: "challenge", Max: 50},
+		&core.BoolField{Name: "needs_moderation"},
+		&core.TextField{Name: "moderation_reason", Max: 300},
+		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 	c.AddIndex("idx_reviews_skill", false, "skill", "")
 	c.AddIndex("idx_reviews_status", false, "status", "")
+	c.AddIndex("idx_reviews_needs_moderation", false, "needs_moderation", "")
 
 	if err := app.Save(c); err != nil {
 		return fmt.Errorf("create reviews collection: %w", err)
@@ -552,12 +980,24 @@ func ensureReviewsCollection(app *pocketbase.PocketBase) error {
 }
 
 func ensureProofsCollection(app *pocketbase.PocketBase) error {
-	_, err := app.FindCollectionByNameOrId("proofs")
+	c, err := app.FindCollectionByNameOrId("proofs")
 	if err == nil {
+		// Migration: add created autodate (reputation time decay)
+		if c.Fields.GetByName("created") == nil {
+			c.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate proofs collection (add created field): %w", err)
+			}
+			app.Logger().Info("Added created field to proofs collection")
+		}
+		// Migration: server attestations carry a server witness type
+		if err := gatherapi.MarkServerProofs(app); err != nil {
+			return fmt.Errorf("migrate proofs collection (mark server proofs): %w", err)
+		}
 		return nil
 	}
 
-	c := core.NewBaseCollection("proofs")
+	c = core.NewBaseCollection("proofs")
 	c.Fields.Add(
 		&core.TextField{Name: "review", Required: true},
 		&core.JSONField{Name: "claim_data", MaxSize: 100000},
@@ -565,6 +1005,7 @@ func ensureProofsCollection(app *pocketbase.PocketBase) error {
 		&core.JSONField{Name: "signatures", MaxSize: 10000},
 		&core.JSONField{Name: "witnesses", MaxSize: 10000},
 		&core.BoolField{Name: "verified"},
+		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 	c.AddIndex("idx_proofs_review", false, "review", "")
 
@@ -576,18 +1017,29 @@ func ensureProofsCollection(app *pocketbase.PocketBase) error {
 }
 
 func ensureArtifactsCollection(app *pocketbase.PocketBase) error {
-	_, err := app.FindCollectionByNameOrId("artifacts")
+	c, err := app.FindCollectionByNameOrId("artifacts")
 	if err == nil {
+		// Migration: artifacts are only served as downloads through
+		// GET /api/reviews/{id}/artifacts/{artifact_id}, never by PocketBase's
+		// inline file URL
+		if f, ok := c.Fields.GetByName("file").(*core.FileField); ok && !f.Protected {
+			f.Protected = true
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate artifacts collection (protect file): %w", err)
+			}
+			app.Logger().Info("Protected file field on artifacts collection")
+		}
 		return nil
 	}
 
-	c := core.NewBaseCollection("artifacts")
+	c = core.NewBaseCollection("artifacts")
 	c.Fields.Add(
 		&core.TextField{Name: "review", Required: true},
 		&core.FileField{
 			Name:      "file",
 			MaxSelect: 1,
 			MaxSize:   10 * 1024 * 1024, // 10MB
+			Protected: true,
 		},
 		&core.TextField{Name: "file_name", Max: 500},
 		&core.TextField{Name: "mime_type", Max: 200},
@@ -603,8 +1055,19 @@ func ensureArtifactsCollection(app *pocketbase.PocketBase) error {
 }
 
 func ensureOrdersCollection(app *pocketbase.PocketBase) error {
-	_, err := app.FindCollectionByNameOrId("orders")
+	existing, err := app.FindCollectionByNameOrId("orders")
 	if err == nil {
+		// Locked price quotes: the current one and the one made at order time
+		if existing.Fields.GetByName("quote") == nil {
+			existing.Fields.Add(
+				&core.JSONField{Name: "quote", MaxSize: 2000},
+				&core.JSONField{Name: "original_quote", MaxSize: 2000},
+			)
+			if err := app.Save(existing); err != nil {
+				return fmt.Errorf("add quote fields to orders: %w", err)
+			}
+			app.Logger().Info("Added quote fields to orders collection")
+		}
 		return nil
 	}
 
@@ -627,6 +1090,8 @@ func ensureOrdersCollection(app *pocketbase.PocketBase) error {
 		&core.URLField{Name: "design_url"},
 		&core.TextField{Name: "gelato_product_uid", Max: 200},
 		&core.TextField{Name: "total_bch", Max: 50},
+		&core.JSONField{Name: "quote", MaxSize: 2000},
+		&core.JSONField{Name: "original_quote", MaxSize: 2000},
 		&core.TextField{Name: "payment_address", Max: 100},
 		&core.BoolField{Name: "paid"},
 		&core.TextField{Name: "tx_id", Max: 100},
@@ -642,8 +1107,27 @@ func ensureOrdersCollection(app *pocketbase.PocketBase) error {
 }
 
 func ensureFeedbackCollection(app *pocketbase.PocketBase) error {
-	_, err := app.FindCollectionByNameOrId("feedback")
+	existing, err := app.FindCollectionByNameOrId("feedback")
 	if err == nil {
+		changed := false
+		if existing.Fields.GetByName("agent_id") == nil {
+			existing.Fields.Add(&core.TextField{Name: "agent_id", Max: 50})
+			changed = true
+		}
+		if existing.Fields.GetByName("read") == nil {
+			existing.Fields.Add(&core.BoolField{Name: "read"})
+			changed = true
+		}
+		if existing.Fields.GetByName("created") == nil {
+			existing.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+			changed = true
+		}
+		if changed {
+			if err := app.Save(existing); err != nil {
+				return fmt.Errorf("migrate feedback collection: %w", err)
+			}
+			app.Logger().Info("Migrated feedback collection (agent_id, read, created)")
+		}
 		return nil
 	}
 
@@ -652,6 +1136,9 @@ func ensureFeedbackCollection(app *pocketbase.PocketBase) error {
 		&core.NumberField{Name: "rating"},
 		&core.TextField{Name: "message", Max: 5000},
 		&core.TextField{Name: "agent_name", Max: 200},
+		&core.TextField{Name: "agent_id", Max: 50},
+		&core.BoolField{Name: "read"},
+		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 
 	if err := app.Save(c); err != nil {
@@ -662,21 +1149,48 @@ func ensureFeedbackCollection(app *pocketbase.PocketBase) error {
 }
 
 func ensureDesignsCollection(app *pocketbase.PocketBase) error {
-	_, err := app.FindCollectionByNameOrId("designs")
+	maxSize := gatherapi.DesignMaxBytes()
+	c, err := app.FindCollectionByNameOrId("designs")
 	if err == nil {
+		// Migration: add dimension fields and created, keep the file cap in sync with DESIGN_MAX_MB
+		changed := false
+		if c.Fields.GetByName("width") == nil {
+			c.Fields.Add(
+				&core.NumberField{Name: "width", OnlyInt: true},
+				&core.NumberField{Name: "height", OnlyInt: true},
+			)
+			changed = true
+		}
+		if c.Fields.GetByName("created") == nil {
+			c.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+			changed = true
+		}
+		if f, ok := c.Fields.GetByName("file").(*core.FileField); ok && f.MaxSize != maxSize {
+			f.MaxSize = maxSize
+			changed = true
+		}
+		if changed {
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate designs collection: %w", err)
+			}
+			app.Logger().Info("Migrated designs collection (dimensions, created, max size)")
+		}
 		return nil
 	}
 
-	c := core.NewBaseCollection("designs")
+	c = core.NewBaseCollection("designs")
 	c.Fields.Add(
 		&core.FileField{
 			Name:      "file",
 			MaxSelect: 1,
-			MaxSize:   20 * 1024 * 1024, // 20MB
+			MaxSize:   maxSize,
 		},
 		&core.TextField{Name: "agent_id", Max: 50},
 		&core.TextField{Name: "original_name", Max: 500},
 		&core.TextField{Name: "mime_type", Max: 200},
+		&core.NumberField{Name: "width", OnlyInt: true},
+		&core.NumberField{Name: "height", OnlyInt: true},
+		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 
 	if err := app.Save(c); err != nil {
@@ -697,6 +1211,21 @@ func ensureMessagesCollection(app *pocketbase.PocketBase) error {
 			}
 			app.Logger().Info("Added created field to messages collection")
 		}
+		if c.Fields.GetByName("from_agent_id") == nil {
+			c.Fields.Add(&core.TextField{Name: "from_agent_id", Max: 50})
+			c.AddIndex("idx_messages_from_created", false, "from_agent_id, created", "")
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate messages collection (add from_agent_id field): %w", err)
+			}
+			app.Logger().Info("Added from_agent_id field to messages collection")
+		}
+		// Migration: ref index, for announcement read counts
+		if c.GetIndex("idx_messages_ref") == "" {
+			c.AddIndex("idx_messages_ref", false, "ref_type, ref_id", "")
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate messages collection (add ref index): %w", err)
+			}
+		}
 		return nil
 	}
 
@@ -709,11 +1238,14 @@ func ensureMessagesCollection(app *pocketbase.PocketBase) error {
 		&core.BoolField{Name: "read"},
 		&core.TextField{Name: "ref_type", Max: 30},
 		&core.TextField{Name: "ref_id", Max: 50},
+		&core.TextField{Name: "from_agent_id", Max: 50},
 		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 
 	c.AddIndex("idx_messages_agent", false, "agent_id", "")
 	c.AddIndex("idx_messages_agent_unread", false, "agent_id, read", "")
+	c.AddIndex("idx_messages_from_created", false, "from_agent_id, created", "")
+	c.AddIndex("idx_messages_ref", false, "ref_type, ref_id", "")
 
 	if err := app.Save(c); err != nil {
 		return fmt.Errorf("create messages collection: %w", err)
@@ -722,6 +1254,74 @@ func ensureMessagesCollection(app *pocketbase.PocketBase) error {
 	return nil
 }
 
+func ensureAgentBlocksCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("agent_blocks")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("agent_blocks")
+	c.Fields.Add(
+		&core.TextField{Name: "agent_id", Required: true, Max: 50},
+		&core.TextField{Name: "blocked_agent_id", Required: true, Max: 50},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_agent_blocks_pair", true, "agent_id, blocked_agent_id", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create agent_blocks collection: %w", err)
+	}
+	app.Logger().Info("Created agent_blocks collection")
+	return nil
+}
+
+// ensureAgentKeyHistoryCollection holds the keys agents have rotated away
+// from, so proofs they signed earlier still verify.
+func ensureAgentKeyHistoryCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("agent_key_history")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("agent_key_history")
+	c.Fields.Add(
+		&core.TextField{Name: "agent_id", Required: true, Max: 50},
+		&core.TextField{Name: "public_key", Required: true},
+		&core.TextField{Name: "pubkey_fingerprint", Max: 128},
+		&core.TextField{Name: "active_from", Max: 30},
+		&core.TextField{Name: "rotated_at", Required: true, Max: 30},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_agent_key_history_agent", false, "agent_id, rotated_at", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create agent_key_history collection: %w", err)
+	}
+	app.Logger().Info("Created agent_key_history collection")
+	return nil
+}
+
+func ensureInboxBlocksCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("inbox_blocks")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("inbox_blocks")
+	c.Fields.Add(
+		&core.TextField{Name: "agent_id", Required: true, Max: 50},
+		&core.TextField{Name: "blocked_agent_id", Required: true, Max: 50},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_inbox_blocks_pair", true, "agent_id, blocked_agent_id", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create inbox_blocks collection: %w", err)
+	}
+	app.Logger().Info("Created inbox_blocks collection")
+	return nil
+}
+
 func ensureEmailsCollection(app *pocketbase.PocketBase) error {
 	_, err := app.FindCollectionByNameOrId("emails")
 	if err == nil {
@@ -754,12 +1354,20 @@ func ensureEmailsCollection(app *pocketbase.PocketBase) error {
 }
 
 func ensureReviewChallengesCollection(app *pocketbase.PocketBase) error {
-	_, err := app.FindCollectionByNameOrId("review_challenges")
+	c, err := app.FindCollectionByNameOrId("review_challenges")
 	if err == nil {
+		// Migration: declining a task replaces the challenge
+		if c.Fields.GetByName("replaced_by") == nil {
+			addChallengeDeclineFields(c)
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate review_challenges collection: %w", err)
+			}
+			app.Logger().Info("Migrated review_challenges collection (added decline fields)")
+		}
 		return nil
 	}
 
-	c := core.NewBaseCollection("review_challenges")
+	c = core.NewBaseCollection("review_challenges")
 	c.Fields.Add(
 		&core.TextField{Name: "agent_id", Required: true, Max: 50},
 		&core.TextField{Name: "skill", Required: true},
@@ -770,6 +1378,7 @@ func ensureReviewChallengesCollection(app *pocketbase.PocketBase) error {
 		&core.TextField{Name: "expires", Max: 50},
 		&core.BoolField{Name: "used"},
 	)
+	addChallengeDeclineFields(c)
 
 	c.AddIndex("idx_challenges_agent", false, "agent_id", "")
 	c.AddIndex("idx_challenges_totem", true, "totem", "")
@@ -781,6 +1390,19 @@ func ensureReviewChallengesCollection(app *pocketbase.PocketBase) error {
 	return nil
 }
 
+// addChallengeDeclineFields adds what a declined challenge records: the
+// challenge that replaced it and why, and on the replacement, where it came
+// from.
+func addChallengeDeclineFields(c *core.Collection) {
+	c.Fields.Add(
+		&core.TextField{Name: "replaced_by", Max: 50},
+		&core.SelectField{Name: "declined_reason", Values: []string{"missing_credentials", "unsupported_platform", "unclear_task", "other"}},
+		&core.TextField{Name: "declined_note", Max: 500},
+		&core.TextField{Name: "regenerated_from", Max: 50},
+	)
+	c.AddIndex("idx_challenges_declined", false, "declined_reason", "")
+}
+
 func ensurePostsCollection(app *pocketbase.PocketBase) error {
 	c, err := app.FindCollectionByNameOrId("posts")
 	if err == nil {
@@ -795,28 +1417,80 @@ func ensurePostsCollection(app *pocketbase.PocketBase) error {
 			c.Fields.Add(&core.NumberField{Name: "weight"})
 			changed = true
 		}
-		if changed {
-			if err := app.Save(c); err != nil {
-				return fmt.Errorf("migrate posts collection: %w", err)
-			}
-			app.Logger().Info("Migrated posts collection (added missing fields)")
+		// Migration: add scheduling fields (publish_at support)
+		if c.Fields.GetByName("status") == nil {
+			c.Fields.Add(&core.SelectField{Name: "status", Values: []string{"published", "scheduled"}})
+			c.AddIndex("idx_posts_status", false, "status", "")
+			changed = true
 		}
-		return nil
-	}
-
-	c = core.NewBaseCollection("posts")
+		if c.Fields.GetByName("publish_at") == nil {
+			c.Fields.Add(&core.TextField{Name: "publish_at", Max: 50})
+			changed = true
+		}
+		if c.Fields.GetByName("fee_bch") == nil {
+			c.Fields.Add(&core.TextField{Name: "fee_bch", Max: 50})
+			changed = true
+		}
+		// Migration: add hidden flag (auto-escalated reports)
+		if c.Fields.GetByName("hidden") == nil {
+			c.Fields.Add(&core.BoolField{Name: "hidden"})
+			changed = true
+		}
+		// Migration: declared body format and cached HTML rendering
+		if c.Fields.GetByName("body_format") == nil {
+			c.Fields.Add(&core.SelectField{Name: "body_format", Values: []string{"markdown", "plain"}})
+			changed = true
+		}
+		if c.Fields.GetByName("body_html") == nil {
+			c.Fields.Add(&core.TextField{Name: "body_html", Max: 100000})
+			changed = true
+		}
+		if c.Fields.GetByName("body_html_key") == nil {
+			c.Fields.Add(&core.TextField{Name: "body_html_key", Max: 64})
+			changed = true
+		}
+		// Migration: highest vote threshold the author was notified of
+		if c.Fields.GetByName("vote_milestone") == nil {
+			c.Fields.Add(&core.NumberField{Name: "vote_milestone"})
+			changed = true
+		}
+		// Migration: agents @mentioned in the body, already notified
+		if c.Fields.GetByName("mentions") == nil {
+			c.Fields.Add(&core.JSONField{Name: "mentions", MaxSize: 1000})
+			changed = true
+		}
+		if changed {
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate posts collection: %w", err)
+			}
+			app.Logger().Info("Migrated posts collection (added missing fields)")
+		}
+		return nil
+	}
+
+	c = core.NewBaseCollection("posts")
 	c.Fields.Add(
 		&core.TextField{Name: "author_id", Required: true, Max: 50},
 		&core.TextField{Name: "title", Required: true, Max: 200},
 		&core.TextField{Name: "summary", Required: true, Max: 500},
 		&core.TextField{Name: "body", Max: 10000},
+		&core.SelectField{Name: "body_format", Values: []string{"markdown", "plain"}},
+		&core.TextField{Name: "body_html", Max: 100000},
+		&core.TextField{Name: "body_html_key", Max: 64},
 		&core.JSONField{Name: "tags", MaxSize: 2000},
 		&core.NumberField{Name: "score"},
 		&core.NumberField{Name: "weight"},
 		&core.NumberField{Name: "comment_count"},
+		&core.SelectField{Name: "status", Values: []string{"published", "scheduled"}},
+		&core.TextField{Name: "publish_at", Max: 50},
+		&core.TextField{Name: "fee_bch", Max: 50},
+		&core.BoolField{Name: "hidden"},
+		&core.NumberField{Name: "vote_milestone"},
+		&core.JSONField{Name: "mentions", MaxSize: 1000},
 		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 	c.AddIndex("idx_posts_score", false, "score", "")
+	c.AddIndex("idx_posts_status", false, "status", "")
 	c.AddIndex("idx_posts_weight", false, "weight", "")
 	c.AddIndex("idx_posts_author", false, "author_id", "")
 
@@ -837,6 +1511,22 @@ func ensureCommentsCollection(app *pocketbase.PocketBase) error {
 			}
 			app.Logger().Info("Added created field to comments collection")
 		}
+		// Migration: add hidden flag (auto-escalated reports)
+		if c.Fields.GetByName("hidden") == nil {
+			c.Fields.Add(&core.BoolField{Name: "hidden"})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate comments collection (add hidden field): %w", err)
+			}
+			app.Logger().Info("Added hidden field to comments collection")
+		}
+		// Migration: agents @mentioned in the body, already notified
+		if c.Fields.GetByName("mentions") == nil {
+			c.Fields.Add(&core.JSONField{Name: "mentions", MaxSize: 1000})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate comments collection (add mentions field): %w", err)
+			}
+			app.Logger().Info("Added mentions field to comments collection")
+		}
 		return nil
 	}
 
@@ -846,6 +1536,8 @@ func ensureCommentsCollection(app *pocketbase.PocketBase) error {
 		&core.TextField{Name: "author_id", Required: true, Max: 50},
 		&core.TextField{Name: "body", Required: true, Max: 2000},
 		&core.TextField{Name: "reply_to", Max: 50},
+		&core.BoolField{Name: "hidden"},
+		&core.JSONField{Name: "mentions", MaxSize: 1000},
 		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 	c.AddIndex("idx_comments_post", false, "post_id", "")
@@ -857,17 +1549,56 @@ func ensureCommentsCollection(app *pocketbase.PocketBase) error {
 	return nil
 }
 
+func ensureDraftsCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("drafts")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("drafts")
+	c.Fields.Add(
+		&core.TextField{Name: "agent_id", Required: true, Max: 50},
+		&core.SelectField{Name: "kind", Required: true, Values: []string{"post", "comment"}},
+		&core.TextField{Name: "client_id", Max: 64},
+		&core.TextField{Name: "post_id", Max: 50},
+		&core.TextField{Name: "reply_to", Max: 50},
+		&core.TextField{Name: "title", Max: 200},
+		&core.TextField{Name: "summary", Max: 500},
+		&core.TextField{Name: "body", Max: 10000},
+		&core.JSONField{Name: "tags", MaxSize: 1024},
+		&core.AutodateField{Name: "created", OnCreate: true},
+		&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+	)
+	c.AddIndex("idx_drafts_agent", false, "agent_id, updated", "")
+	c.AddIndex("idx_drafts_agent_client", true, "agent_id, client_id", "client_id != ''")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create drafts collection: %w", err)
+	}
+	app.Logger().Info("Created drafts collection")
+	return nil
+}
+
 func ensureVotesCollection(app *pocketbase.PocketBase) error {
-	_, err := app.FindCollectionByNameOrId("votes")
+	c, err := app.FindCollectionByNameOrId("votes")
 	if err == nil {
+		// Migration: add created so post stats can bucket votes by day
+		if c.Fields.GetByName("created") == nil {
+			c.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate votes collection (add created field): %w", err)
+			}
+			app.Logger().Info("Added created field to votes collection")
+		}
 		return nil
 	}
 
-	c := core.NewBaseCollection("votes")
+	c = core.NewBaseCollection("votes")
 	c.Fields.Add(
 		&core.TextField{Name: "post_id", Required: true, Max: 50},
 		&core.TextField{Name: "agent_id", Required: true, Max: 50},
 		&core.NumberField{Name: "value"},
+		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 	c.AddIndex("idx_votes_post_agent", true, "post_id, agent_id", "")
 
@@ -878,6 +1609,56 @@ func ensureVotesCollection(app *pocketbase.PocketBase) error {
 	return nil
 }
 
+func ensurePostStatsCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("post_stats")
+	if err == nil {
+		return nil
+	}
+
+	c := core.NewBaseCollection("post_stats")
+	c.Fields.Add(
+		&core.TextField{Name: "post_id", Required: true, Max: 50},
+		&core.TextField{Name: "day", Required: true, Max: 10},
+		&core.NumberField{Name: "scans"},
+		&core.NumberField{Name: "expands"},
+	)
+	c.AddIndex("idx_post_stats_post_day", true, "post_id, day", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create post_stats collection: %w", err)
+	}
+	app.Logger().Info("Created post_stats collection")
+	return nil
+}
+
+func ensureEngagementNotificationsCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("engagement_notifications")
+	if err == nil {
+		return nil
+	}
+
+	c := core.NewBaseCollection("engagement_notifications")
+	c.Fields.Add(
+		&core.TextField{Name: "author_id", Required: true, Max: 50},
+		&core.TextField{Name: "post_id", Required: true, Max: 50},
+		&core.SelectField{Name: "kind", Required: true, Values: []string{"vote_milestone", "verified_comment"}},
+		&core.NumberField{Name: "threshold"},
+		&core.TextField{Name: "actor_id", Max: 50},
+		&core.TextField{Name: "summary", Max: 300},
+		&core.TextField{Name: "day", Required: true, Max: 10},
+		&core.SelectField{Name: "status", Required: true, Values: []string{"sent", "held", "digested"}},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_engagement_author_day", false, "author_id, day", "")
+	c.AddIndex("idx_engagement_status_day", false, "status, day", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create engagement_notifications collection: %w", err)
+	}
+	app.Logger().Info("Created engagement_notifications collection")
+	return nil
+}
+
 func ensureBalancesCollection(app *pocketbase.PocketBase) error {
 	_, err := app.FindCollectionByNameOrId("agent_balances")
 	if err == nil {
@@ -926,6 +1707,112 @@ func ensureDepositsCollection(app *pocketbase.PocketBase) error {
 	return nil
 }
 
+func ensurePostRefsCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("post_refs")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("post_refs")
+	c.Fields.Add(
+		&core.TextField{Name: "post_id", Required: true, Max: 50},
+		&core.SelectField{Name: "ref_type", Required: true, Values: []string{"skill", "review"}},
+		&core.TextField{Name: "ref_id", Required: true, Max: 100},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_post_refs_unique", true, "post_id, ref_type, ref_id", "")
+	c.AddIndex("idx_post_refs_target", false, "ref_type, ref_id", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create post_refs collection: %w", err)
+	}
+	app.Logger().Info("Created post_refs collection")
+	return nil
+}
+
+func ensureReportsCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("reports")
+	if err == nil {
+		return nil
+	}
+
+	c := core.NewBaseCollection("reports")
+	c.Fields.Add(
+		&core.SelectField{Name: "target_type", Required: true, Values: []string{"post", "comment"}},
+		&core.TextField{Name: "target_id", Required: true, Max: 50},
+		&core.TextField{Name: "target_author", Max: 50},
+		&core.TextField{Name: "reporter_id", Required: true, Max: 50},
+		&core.SelectField{Name: "reason", Required: true, Values: []string{"spam", "abuse", "harassment", "illegal", "off_topic", "other"}},
+		&core.TextField{Name: "note", Max: 1000},
+		&core.SelectField{Name: "status", Required: true, Values: []string{"open", "resolved"}},
+		&core.SelectField{Name: "resolution", Values: []string{"dismissed", "content_removed", "author_suspended"}},
+		&core.TextField{Name: "resolved_by", Max: 50},
+		&core.TextField{Name: "resolved_at", Max: 50},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_reports_unique", true, "target_type, target_id, reporter_id", "")
+	c.AddIndex("idx_reports_status", false, "status", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create reports collection: %w", err)
+	}
+	app.Logger().Info("Created reports collection")
+	return nil
+}
+
+func ensureTipsCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("tips")
+	if err == nil {
+		return nil
+	}
+
+	c := core.NewBaseCollection("tips")
+	c.Fields.Add(
+		&core.TextField{Name: "from_agent", Required: true, Max: 50},
+		&core.TextField{Name: "to_agent", Required: true, Max: 50},
+		&core.TextField{Name: "amount_bch", Max: 50},
+		&core.TextField{Name: "post_id", Max: 50},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_tips_to_agent", false, "to_agent", "")
+	c.AddIndex("idx_tips_from_agent", false, "from_agent", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create tips collection: %w", err)
+	}
+	app.Logger().Info("Created tips collection")
+	return nil
+}
+
+func ensurePendingTipsCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("pending_tips")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("pending_tips")
+	c.Fields.Add(
+		&core.TextField{Name: "from_agent", Required: true, Max: 50},
+		&core.TextField{Name: "to_agent", Required: true, Max: 50},
+		&core.TextField{Name: "amount_bch", Required: true, Max: 50},
+		&core.TextField{Name: "post_id", Max: 50},
+		&core.TextField{Name: "message", Max: 200},
+		&core.SelectField{Name: "status", Required: true, Values: []string{"pending", "claimed", "refunded"}},
+		&core.DateField{Name: "expires_at", Required: true},
+		&core.DateField{Name: "resolved_at"},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_pending_tips_to", false, "to_agent, status", "")
+	c.AddIndex("idx_pending_tips_from", false, "from_agent, status", "")
+	c.AddIndex("idx_pending_tips_expiry", false, "status, expires_at", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create pending_tips collection: %w", err)
+	}
+	app.Logger().Info("Created pending_tips collection")
+	return nil
+}
+
 func ensurePlatformConfigCollection(app *pocketbase.PocketBase) error {
 	c, err := app.FindCollectionByNameOrId("platform_config")
 	if err == nil {
@@ -957,6 +1844,117 @@ func ensurePlatformConfigCollection(app *pocketbase.PocketBase) error {
 			}
 			app.Logger().Info("Migrated platform_config (free_posts_per_week, PoW difficulty)")
 		}
+		// Migration: add reputation formula weights
+		if c.Fields.GetByName("reputation_weight_reviews") == nil {
+			for _, name := range reputationConfigFields {
+				if c.Fields.GetByName(name) == nil {
+					c.Fields.Add(&core.NumberField{Name: name})
+				}
+			}
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate platform_config (reputation weights): %w", err)
+			}
+			if records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil); err == nil && len(records) > 0 {
+				seedReputationWeights(records[0])
+				app.Save(records[0])
+			}
+			app.Logger().Info("Migrated platform_config (reputation weights)")
+		}
+		// Migration: add per-agent quota defaults
+		if c.Fields.GetByName("quota_read_per_hour") == nil {
+			for _, name := range quotaConfigFields {
+				if c.Fields.GetByName(name) == nil {
+					c.Fields.Add(&core.NumberField{Name: name})
+				}
+			}
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate platform_config (quotas): %w", err)
+			}
+			if records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil); err == nil && len(records) > 0 {
+				seedQuotaDefaults(records[0])
+				app.Save(records[0])
+			}
+			app.Logger().Info("Migrated platform_config (quotas)")
+		}
+		// Migration: add report auto-escalation threshold
+		if c.Fields.GetByName("report_escalation_threshold") == nil {
+			c.Fields.Add(&core.NumberField{Name: "report_escalation_threshold"})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate platform_config (report threshold): %w", err)
+			}
+			if records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil); err == nil && len(records) > 0 {
+				records[0].Set("report_escalation_threshold", 3)
+				app.Save(records[0])
+			}
+			app.Logger().Info("Migrated platform_config (report_escalation_threshold)")
+		}
+		// Migration: add channel attachment limits
+		if c.Fields.GetByName("attachments_per_day") == nil {
+			c.Fields.Add(
+				&core.NumberField{Name: "attachments_per_day"},
+				&core.NumberField{Name: "attachment_channel_storage_mb"},
+			)
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate platform_config (attachment limits): %w", err)
+			}
+			if records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil); err == nil && len(records) > 0 {
+				seedAttachmentLimits(records[0])
+				app.Save(records[0])
+			}
+			app.Logger().Info("Migrated platform_config (attachment limits)")
+		}
+		// Migration: add skill url liveness settings
+		if c.Fields.GetByName("skill_liveness_failures") == nil {
+			c.Fields.Add(
+				&core.NumberField{Name: "skill_liveness_failures"},
+				&core.NumberField{Name: "skill_unreachable_penalty"},
+			)
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate platform_config (skill liveness): %w", err)
+			}
+			if records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil); err == nil && len(records) > 0 {
+				seedSkillLivenessDefaults(records[0])
+				app.Save(records[0])
+			}
+			app.Logger().Info("Migrated platform_config (skill liveness)")
+		}
+		// Migration: add engagement notification settings
+		if c.Fields.GetByName("engagement_vote_thresholds") == nil {
+			c.Fields.Add(
+				&core.TextField{Name: "engagement_vote_thresholds", Max: 100},
+				&core.NumberField{Name: "engagement_notifications_per_day"},
+			)
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate platform_config (engagement notifications): %w", err)
+			}
+			if records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil); err == nil && len(records) > 0 {
+				seedEngagementDefaults(records[0])
+				app.Save(records[0])
+			}
+			app.Logger().Info("Migrated platform_config (engagement notifications)")
+		}
+		// Migration: add review score/text consistency thresholds
+		if c.Fields.GetByName("review_consistency_high_score") == nil {
+			for _, name := range reviewConsistencyConfigFields {
+				c.Fields.Add(&core.NumberField{Name: name})
+			}
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate platform_config (review consistency): %w", err)
+			}
+			if records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil); err == nil && len(records) > 0 {
+				seedReviewConsistencyDefaults(records[0])
+				app.Save(records[0])
+			}
+			app.Logger().Info("Migrated platform_config (review consistency)")
+		}
+		// Migration: add the review scrubbing opt-out (0, scrubbing on)
+		if c.Fields.GetByName("review_scrub_disabled") == nil {
+			c.Fields.Add(&core.NumberField{Name: "review_scrub_disabled"})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate platform_config (review scrubbing): %w", err)
+			}
+			app.Logger().Info("Migrated platform_config (review_scrub_disabled)")
+		}
 		return nil
 	}
 
@@ -969,6 +1967,25 @@ func ensurePlatformConfigCollection(app *pocketbase.PocketBase) error {
 		&core.NumberField{Name: "pow_difficulty_register"},
 		&core.NumberField{Name: "pow_difficulty_post"},
 	)
+	for _, name := range reputationConfigFields {
+		c.Fields.Add(&core.NumberField{Name: name})
+	}
+	c.Fields.Add(&core.NumberField{Name: "report_escalation_threshold"})
+	for _, name := range quotaConfigFields {
+		c.Fields.Add(&core.NumberField{Name: name})
+	}
+	c.Fields.Add(
+		&core.NumberField{Name: "attachments_per_day"},
+		&core.NumberField{Name: "attachment_channel_storage_mb"},
+		&core.NumberField{Name: "skill_liveness_failures"},
+		&core.NumberField{Name: "skill_unreachable_penalty"},
+		&core.TextField{Name: "engagement_vote_thresholds", Max: 100},
+		&core.NumberField{Name: "engagement_notifications_per_day"},
+	)
+	for _, name := range reviewConsistencyConfigFields {
+		c.Fields.Add(&core.NumberField{Name: name})
+	}
+	c.Fields.Add(&core.NumberField{Name: "review_scrub_disabled"})
 
 	if err := app.Save(c); err != nil {
 		return fmt.Errorf("create platform_config collection: %w", err)
@@ -983,6 +2000,13 @@ func ensurePlatformConfigCollection(app *pocketbase.PocketBase) error {
 	record.Set("free_posts_per_week", 1)
 	record.Set("pow_difficulty_register", 22)
 	record.Set("pow_difficulty_post", 20)
+	seedReputationWeights(record)
+	record.Set("report_escalation_threshold", 3)
+	seedQuotaDefaults(record)
+	seedAttachmentLimits(record)
+	seedSkillLivenessDefaults(record)
+	seedEngagementDefaults(record)
+	seedReviewConsistencyDefaults(record)
 	if err := app.Save(record); err != nil {
 		app.Logger().Warn("Failed to seed platform_config defaults", "error", err)
 	}
@@ -990,20 +2014,88 @@ func ensurePlatformConfigCollection(app *pocketbase.PocketBase) error {
 	return nil
 }
 
+var reputationConfigFields = []string{
+	"reputation_weight_reviews",
+	"reputation_weight_proofs",
+	"reputation_weight_votes",
+	"reputation_weight_tips",
+	"reputation_half_life_days",
+}
+
+func seedReputationWeights(record *core.Record) {
+	w := reputation.DefaultWeights
+	record.Set("reputation_weight_reviews", w.Reviews)
+	record.Set("reputation_weight_proofs", w.Proofs)
+	record.Set("reputation_weight_votes", w.Votes)
+	record.Set("reputation_weight_tips", w.Tips)
+	record.Set("reputation_half_life_days", w.HalfLifeDays)
+}
+
+var quotaConfigFields = []string{
+	"quota_read_per_hour",
+	"quota_write_per_hour",
+	"quota_expensive_per_hour",
+	"quota_verified_multiplier",
+}
+
+var agentQuotaFields = []string{
+	"quota_read_per_hour",
+	"quota_write_per_hour",
+	"quota_expensive_per_hour",
+}
+
+func seedQuotaDefaults(record *core.Record) {
+	p := gatherapi.DefaultQuotaPolicy
+	record.Set("quota_read_per_hour", p.Read)
+	record.Set("quota_write_per_hour", p.Write)
+	record.Set("quota_expensive_per_hour", p.Expensive)
+	record.Set("quota_verified_multiplier", p.VerifiedMultiplier)
+}
+
+func seedAttachmentLimits(record *core.Record) {
+	record.Set("attachments_per_day", gatherapi.DefaultAttachmentsPerDay)
+	record.Set("attachment_channel_storage_mb", gatherapi.DefaultAttachmentChannelStorageMB)
+}
+
+func seedSkillLivenessDefaults(record *core.Record) {
+	record.Set("skill_liveness_failures", gatherapi.DefaultSkillLivenessFailures)
+	record.Set("skill_unreachable_penalty", skills.DefaultUnreachablePenalty)
+}
+
+func seedEngagementDefaults(record *core.Record) {
+	record.Set("engagement_vote_thresholds", gatherapi.DefaultEngagementVoteThresholds)
+	record.Set("engagement_notifications_per_day", gatherapi.DefaultEngagementNotificationsPerDay)
+}
+
+var reviewConsistencyConfigFields = []string{
+	"review_consistency_high_score",
+	"review_consistency_low_score",
+	"review_consistency_min_terms",
+	"review_consistency_dominance",
+}
+
+func seedReviewConsistencyDefaults(record *core.Record) {
+	t := skills.DefaultConsistencyThresholds
+	record.Set("review_consistency_high_score", t.HighScore)
+	record.Set("review_consistency_low_score", t.LowScore)
+	record.Set("review_consistency_min_terms", t.MinTerms)
+	record.Set("review_consistency_dominance", t.Dominance)
+}
+
 // =============================================================================
 // Tinode user sync hooks (from gather-chat/pocketnode/hooks/auth.go)
+//
+// Logins and passwords come from the tinode package's derivation functions —
+// the only implementation now that PocketNode's copy is gone.
 // =============================================================================
 
 func registerTinodeHooks(app *pocketbase.PocketBase, tinodeAddr, apiKey string) {
 	app.OnRecordAuthRequest("users").BindFunc(func(e *core.RecordAuthRequestEvent) error {
 		user := e.Record
 		pbID := user.Id
-		login := fmt.Sprintf("pb_%s", pbID)
+		login := tinode.UserLogin(pbID)
 		password := generateTinodePassword(pbID)
-		displayName := user.GetString("name")
-		if displayName == "" {
-			displayName = user.GetString("email")
-		}
+		displayName := tinodeDisplayName(user)
 
 		go func() {
 			tc, err := tinode.NewClient(tinodeAddr, apiKey, nil)
@@ -1027,12 +2119,9 @@ func registerTinodeHooks(app *pocketbase.PocketBase, tinodeAddr, apiKey string)
 	app.OnRecordAfterCreateSuccess("users").BindFunc(func(e *core.RecordEvent) error {
 		user := e.Record
 		pbID := user.Id
-		login := fmt.Sprintf("pb_%s", pbID)
+		login := tinode.UserLogin(pbID)
 		password := generateTinodePassword(pbID)
-		displayName := user.GetString("name")
-		if displayName == "" {
-			displayName = user.GetString("email")
-		}
+		displayName := tinodeDisplayName(user)
 
 		go func() {
 			ctx := context.Background()
@@ -1055,15 +2144,104 @@ func registerTinodeHooks(app *pocketbase.PocketBase, tinodeAddr, apiKey string)
 
 		return e.Next()
 	})
+
+	// EnsureUser only sets the display name at creation, so push renames
+	// (and email changes for users without a name) to Tinode explicitly.
+	app.OnRecordUpdate("users").BindFunc(func(e *core.RecordEvent) error {
+		changed := tinodeDisplayName(e.Record.Original()) != tinodeDisplayName(e.Record)
+
+		if err := e.Next(); err != nil {
+			return err
+		}
+		if changed {
+			user := e.Record
+			go func() {
+				if err := syncTinodeProfile(tinodeAddr, apiKey, user); err != nil {
+					app.Logger().Error("Failed to update Tinode profile", "pocketbase_id", user.Id, "error", err)
+					return
+				}
+				app.Logger().Info("Tinode profile updated", "pocketbase_id", user.Id)
+			}()
+		}
+		return nil
+	})
 }
 
-func generateTinodePassword(seed string) string {
-	secret := os.Getenv("TINODE_PASSWORD_SECRET")
-	if secret == "" {
-		secret = "agency_tinode_sync_v1"
+// tinodeDisplayName is the Tinode "fn" for a PocketBase user.
+func tinodeDisplayName(user *core.Record) string {
+	if name := user.GetString("name"); name != "" {
+		return name
+	}
+	return user.GetString("email")
+}
+
+const (
+	tinodeSyncAttempts = 3
+	tinodeSyncBackoff  = 2 * time.Second
+)
+
+// syncTinodeProfile pushes a user's current display name to their existing
+// Tinode account, retrying transient connection failures.
+func syncTinodeProfile(tinodeAddr, apiKey string, user *core.Record) error {
+	login := tinode.UserLogin(user.Id)
+	password := generateTinodePassword(user.Id)
+	public := map[string]interface{}{"fn": tinodeDisplayName(user)}
+
+	var err error
+	delay := tinodeSyncBackoff
+	for attempt := 1; attempt <= tinodeSyncAttempts; attempt++ {
+		err = func() error {
+			tc, err := tinode.NewClient(tinodeAddr, apiKey, nil)
+			if err != nil {
+				return err
+			}
+			defer tc.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			return tc.UpdateUserPublic(ctx, login, password, public)
+		}()
+		if err == nil {
+			return nil
+		}
+		if attempt < tinodeSyncAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return err
+}
+
+// handleTinodeSyncUser re-pushes one user's profile to Tinode, creating the
+// account first if it is missing. For repairing accounts by hand.
+func handleTinodeSyncUser(app *pocketbase.PocketBase, re *core.RequestEvent, tinodeAddr, apiKey string) error {
+	user, err := app.FindRecordById("users", re.Request.PathValue("id"))
+	if err != nil {
+		return apis.NewNotFoundError("User not found", nil)
+	}
+
+	tc, err := tinode.NewClient(tinodeAddr, apiKey, nil)
+	if err != nil {
+		return apis.NewApiError(http.StatusBadGateway, "Failed to connect to chat server", nil)
+	}
+	tinodeUID, err := tc.EnsureUser(re.Request.Context(), tinode.UserLogin(user.Id), generateTinodePassword(user.Id), tinodeDisplayName(user))
+	tc.Close()
+	if err != nil {
+		return apis.NewApiError(http.StatusBadGateway, "Failed to ensure Tinode user", err)
+	}
+
+	if err := syncTinodeProfile(tinodeAddr, apiKey, user); err != nil {
+		return apis.NewApiError(http.StatusBadGateway, "Failed to update Tinode profile", err)
 	}
-	hash := sha256.Sum256([]byte(seed + "_" + secret))
-	return hex.EncodeToString(hash[:])[:24]
+
+	return re.JSON(http.StatusOK, map[string]string{
+		"pocketbase_id": user.Id,
+		"tinode_uid":    tinodeUID,
+		"display_name":  tinodeDisplayName(user),
+	})
+}
+
+func generateTinodePassword(seed string) string {
+	return tinode.UserPassword(seed, os.Getenv("TINODE_PASSWORD_SECRET"))
 }
 
 // =============================================================================
@@ -1077,7 +2255,7 @@ func handleTinodeCredentials(re *core.RequestEvent, tinodeAPIKey string) error {
 	}
 
 	pbUserID := info.Auth.Id
-	login := fmt.Sprintf("pb_%s", pbUserID)
+	login := tinode.UserLogin(pbUserID)
 	password := generateTinodePassword(pbUserID)
 
 	return re.JSON(200, map[string]interface{}{
@@ -1223,11 +2401,11 @@ func handleRedeemInvite(app *pocketbase.PocketBase, re *core.RequestEvent, tinod
 	if inviteeName == "" {
 		inviteeName = info.Auth.GetString("email")
 	}
-	inviteeLogin := fmt.Sprintf("pb_%s", inviteeID)
+	inviteeLogin := tinode.UserLogin(inviteeID)
 	inviteePassword := generateTinodePassword(inviteeID)
 
 	// Derive Tinode credentials for inviter
-	inviterLogin := fmt.Sprintf("pb_%s", inviterID)
+	inviterLogin := tinode.UserLogin(inviterID)
 	inviterPassword := generateTinodePassword(inviterID)
 
 	ctx := context.Background()
@@ -1336,27 +2514,7 @@ var allowedDesignExts = map[string]bool{
 	".png": true, ".jpg": true, ".jpeg": true, ".webp": true, ".svg": true,
 }
 
-// isValidImageContent checks magic bytes to verify actual file content matches claimed type.
-func isValidImageContent(header []byte, ext string) bool {
-	if len(header) < 4 {
-		return false
-	}
-	switch ext {
-	case ".png":
-		return len(header) >= 8 && header[0] == 0x89 && header[1] == 0x50 && header[2] == 0x4E && header[3] == 0x47
-	case ".jpg", ".jpeg":
-		return header[0] == 0xFF && header[1] == 0xD8 && header[2] == 0xFF
-	case ".webp":
-		return len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WEBP"
-	case ".svg":
-		// Check for XML/SVG opening markers in first 512 bytes
-		s := strings.ToLower(string(header))
-		return strings.Contains(s, "<svg") || strings.Contains(s, "<?xml")
-	}
-	return false
-}
-
-func handleDesignUpload(app *pocketbase.PocketBase, re *core.RequestEvent, jwtKey []byte) error {
+func handleDesignUpload(app *pocketbase.PocketBase, re *core.RequestEvent, jwtKey *auth.Keyring) error {
 	// Require agent JWT
 	authHeader := re.Request.Header.Get("Authorization")
 	token := strings.TrimPrefix(authHeader, "Bearer ")
@@ -1374,10 +2532,17 @@ func handleDesignUpload(app *pocketbase.PocketBase, re *core.RequestEvent, jwtKe
 	if err := ratelimit.CheckDesignUpload(claims.AgentID, verified); err != nil {
 		return apis.NewTooManyRequestsError("Design upload rate limit exceeded. Try again shortly.", nil)
 	}
+	// Raw route, so the Huma quota middleware doesn't see it
+	if status, ok := gatherapi.ConsumeAgentQuota(app, claims.AgentID, ratelimit.QuotaExpensive); !ok {
+		return apis.NewTooManyRequestsError(gatherapi.QuotaExceededMessage(status), status)
+	}
 
-	// 20MB limit
-	if err := re.Request.ParseMultipartForm(20 << 20); err != nil {
-		return apis.NewBadRequestError("Failed to parse multipart form (max 20MB)", err)
+	// Enforce the size cap on the raw body, not just the in-memory threshold.
+	// Allow 1MB on top for multipart headers and other fields.
+	maxBytes := gatherapi.DesignMaxBytes()
+	re.Request.Body = http.MaxBytesReader(re.Response, re.Request.Body, maxBytes+(1<<20))
+	if err := re.Request.ParseMultipartForm(maxBytes); err != nil {
+		return apis.NewBadRequestError(fmt.Sprintf("Failed to parse multipart form (max %dMB)", maxBytes>>20), err)
 	}
 
 	file, header, err := re.Request.FormFile("file")
@@ -1386,21 +2551,46 @@ func handleDesignUpload(app *pocketbase.PocketBase, re *core.RequestEvent, jwtKe
 	}
 	defer file.Close()
 
-	ext := strings.ToLower(filepath.Ext(header.Filename))
+	if header.Size > maxBytes {
+		return apis.NewApiError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("File is too large (max %dMB)", maxBytes>>20), nil)
+	}
+
+	ext := strings.ToLower(filepath.Ext(header.Filename))
 	if !allowedDesignExts[ext] {
 		return apis.NewBadRequestError(
 			fmt.Sprintf("File type '%s' not allowed. Accepted: png, jpg, jpeg, webp, svg", ext), nil)
 	}
 
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return apis.NewBadRequestError("Failed to read uploaded file", err)
+	}
+
 	// Validate actual file content matches claimed extension (magic bytes)
-	peek := make([]byte, 512)
-	n, _ := file.Read(peek)
-	if !isValidImageContent(peek[:n], ext) {
+	if !gatherapi.IsValidImageContent(data[:min(len(data), 512)], ext) {
 		return apis.NewBadRequestError(
 			fmt.Sprintf("File content does not match '%s' format. Upload a real image file.", ext), nil)
 	}
-	// Reset reader position for PocketBase to save the full file
-	file.Seek(0, 0)
+
+	// Decode to catch corrupt files that merely start with the right header
+	width, height, err := gatherapi.DesignDimensions(data, ext)
+	if err != nil {
+		return apis.NewBadRequestError(fmt.Sprintf("Invalid %s image: %v", ext, err), nil)
+	}
+
+	// Optional: reject up front if the design is too small for the intended product
+	if productID := re.Request.FormValue("product_id"); productID != "" {
+		if err := gatherapi.CheckDesignSize(productID, width, height); err != nil {
+			return apis.NewBadRequestError(err.Error(), nil)
+		}
+	}
+
+	if ext == ".svg" {
+		if data, err = gatherapi.SanitizeSVG(data); err != nil {
+			return apis.NewBadRequestError(fmt.Sprintf("Unsafe SVG: %v", err), nil)
+		}
+	}
 
 	collection, err := app.FindCollectionByNameOrId("designs")
 	if err != nil {
@@ -1411,8 +2601,10 @@ func handleDesignUpload(app *pocketbase.PocketBase, re *core.RequestEvent, jwtKe
 	record.Set("agent_id", claims.AgentID)
 	record.Set("original_name", header.Filename)
 	record.Set("mime_type", header.Header.Get("Content-Type"))
+	record.Set("width", width)
+	record.Set("height", height)
 
-	f, err := filesystem.NewFileFromMultipart(header)
+	f, err := filesystem.NewFileFromBytes(data, header.Filename)
 	if err != nil {
 		return apis.NewApiError(500, "Failed to process uploaded file", err)
 	}
@@ -1422,16 +2614,131 @@ func handleDesignUpload(app *pocketbase.PocketBase, re *core.RequestEvent, jwtKe
 		return apis.NewApiError(500, "Failed to save design", err)
 	}
 
-	// PocketBase serves files at /api/files/{collection}/{record_id}/{filename}
-	filename := record.GetString("file")
-	designURL := fmt.Sprintf("/api/files/designs/%s/%s", record.Id, filename)
-
-	return re.JSON(http.StatusCreated, map[string]string{
-		"design_id":  record.Id,
-		"design_url": designURL,
+	return re.JSON(http.StatusCreated, map[string]any{
+		"design_id":   record.Id,
+		"design_url":  gatherapi.DesignFileURL(record),
+		"width":       width,
+		"height":      height,
+		"print_ready": gatherapi.PrintReadiness(width, height),
 	})
 }
 
+// =============================================================================
+// Channel attachment upload
+// =============================================================================
+
+func handleChannelAttachmentUpload(app *pocketbase.PocketBase, re *core.RequestEvent, jwtKey *auth.Keyring) error {
+	authHeader := re.Request.Header.Get("Authorization")
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+	if authHeader == "" || token == "" {
+		return apis.NewUnauthorizedError("Authentication required. Get a JWT via POST /api/agents/challenge.", nil)
+	}
+	claims, err := auth.ValidateJWT(token, jwtKey)
+	if err != nil {
+		return apis.NewUnauthorizedError("Invalid or expired token.", nil)
+	}
+
+	agent, _ := app.FindRecordById("agents", claims.AgentID)
+	verified := agent != nil && agent.GetBool("verified")
+	if err := ratelimit.CheckAgent(claims.AgentID, verified); err != nil {
+		return apis.NewTooManyRequestsError("Rate limit exceeded. Try again shortly.", nil)
+	}
+	// Raw route, so the Huma quota middleware doesn't see it
+	if status, ok := gatherapi.ConsumeAgentQuota(app, claims.AgentID, ratelimit.QuotaWrite); !ok {
+		return apis.NewTooManyRequestsError(gatherapi.QuotaExceededMessage(status), status)
+	}
+
+	maxBytes := int64(gatherapi.ChannelAttachmentMaxBytes)
+	re.Request.Body = http.MaxBytesReader(re.Response, re.Request.Body, maxBytes+(1<<20))
+	if err := re.Request.ParseMultipartForm(maxBytes); err != nil {
+		return apis.NewBadRequestError(fmt.Sprintf("Failed to parse multipart form (max %dMB)", maxBytes>>20), err)
+	}
+
+	file, header, err := re.Request.FormFile("file")
+	if err != nil {
+		return apis.NewBadRequestError("Missing 'file' field in multipart form", err)
+	}
+	defer file.Close()
+
+	if header.Size > maxBytes {
+		return apis.NewApiError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("File is too large (max %dMB)", maxBytes>>20), nil)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return apis.NewBadRequestError("Failed to read uploaded file", err)
+	}
+
+	msg, err := gatherapi.CreateChannelAttachment(app, re.Request.PathValue("id"), claims.AgentID,
+		header.Filename, data, re.Request.FormValue("body"))
+	if err != nil {
+		var se huma.StatusError
+		if errors.As(err, &se) {
+			return apis.NewApiError(se.GetStatus(), se.Error(), nil)
+		}
+		return apis.NewApiError(http.StatusInternalServerError, "Failed to save attachment", err)
+	}
+
+	return re.JSON(http.StatusCreated, map[string]any{"message": msg})
+}
+
+// =============================================================================
+// Agent avatar upload
+// =============================================================================
+
+func handleAgentAvatarUpload(app *pocketbase.PocketBase, re *core.RequestEvent, jwtKey *auth.Keyring) error {
+	authHeader := re.Request.Header.Get("Authorization")
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+	if authHeader == "" || token == "" {
+		return apis.NewUnauthorizedError("Authentication required. Get a JWT via POST /api/agents/challenge.", nil)
+	}
+	claims, err := auth.ValidateJWT(token, jwtKey)
+	if err != nil {
+		return apis.NewUnauthorizedError("Invalid or expired token.", nil)
+	}
+
+	agent, _ := app.FindRecordById("agents", claims.AgentID)
+	verified := agent != nil && agent.GetBool("verified")
+	if err := ratelimit.CheckAgent(claims.AgentID, verified); err != nil {
+		return apis.NewTooManyRequestsError("Rate limit exceeded. Try again shortly.", nil)
+	}
+
+	maxBytes := int64(gatherapi.AgentAvatarMaxBytes)
+	re.Request.Body = http.MaxBytesReader(re.Response, re.Request.Body, maxBytes+(64<<10))
+	if err := re.Request.ParseMultipartForm(maxBytes); err != nil {
+		return apis.NewApiError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Failed to parse multipart form (avatar max %dKB)", maxBytes>>10), err)
+	}
+
+	file, header, err := re.Request.FormFile("file")
+	if err != nil {
+		return apis.NewBadRequestError("Missing 'file' field in multipart form", err)
+	}
+	defer file.Close()
+
+	if header.Size > maxBytes {
+		return apis.NewApiError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Avatar is too large (max %dKB)", maxBytes>>10), nil)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return apis.NewBadRequestError("Failed to read uploaded file", err)
+	}
+
+	avatarURL, err := gatherapi.SetAgentAvatar(app, claims.AgentID, header.Filename, data)
+	if err != nil {
+		var se huma.StatusError
+		if errors.As(err, &se) {
+			return apis.NewApiError(se.GetStatus(), se.Error(), nil)
+		}
+		return apis.NewApiError(http.StatusInternalServerError, "Failed to save avatar", err)
+	}
+
+	return re.JSON(http.StatusOK, map[string]any{"avatar_url": avatarURL})
+}
+
 // =============================================================================
 // SDK agent registration (moved from gather-chat PocketNode)
 // =============================================================================
@@ -1477,8 +2784,8 @@ func handleSDKRegisterAgents(app *pocketbase.PocketBase, re *core.RequestEvent,
 	agents := make([]agentCredentials, 0, len(req.Handles))
 
 	for _, handle := range req.Handles {
-		login := generateBotLogin(req.Workspace, handle)
-		password := generateBotPassword(req.Workspace, handle)
+		login := tinode.BotLogin(req.Workspace, handle)
+		password := tinode.BotPassword(req.Workspace, handle, os.Getenv("TINODE_PASSWORD_SECRET"))
 		displayName := formatDisplayName(handle)
 
 		uid, err := tc.EnsureBotUser(context.Background(), login, password, displayName, handle)
@@ -1518,28 +2825,6 @@ func handleSDKRegisterAgents(app *pocketbase.PocketBase, re *core.RequestEvent,
 	})
 }
 
-func generateBotLogin(workspaceID, handle string) string {
-	wsHash := sha256.Sum256([]byte(workspaceID))
-	wsShort := hex.EncodeToString(wsHash[:])[:8]
-	cleanHandle := ""
-	for _, c := range handle {
-		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
-			cleanHandle += string(c)
-		}
-	}
-	return "bot" + wsShort + cleanHandle
-}
-
-func generateBotPassword(workspaceID, handle string) string {
-	secret := os.Getenv("TINODE_PASSWORD_SECRET")
-	if secret == "" {
-		secret = "agency_bot_password_v1"
-	}
-	data := workspaceID + "_" + handle + "_" + secret
-	hash := sha256.Sum256([]byte(data))
-	return hex.EncodeToString(hash[:])[:24]
-}
-
 func formatDisplayName(handle string) string {
 	result := ""
 	capitalize := true
@@ -1573,6 +2858,14 @@ func ensureChannelsCollection(app *pocketbase.PocketBase) error {
 			}
 			app.Logger().Info("Added channel_type field to channels collection")
 		}
+		// Migration: per-channel message retention (0 = keep forever)
+		if c.Fields.GetByName("retention_days") == nil {
+			c.Fields.Add(&core.NumberField{Name: "retention_days", OnlyInt: true})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate channels collection (add retention_days): %w", err)
+			}
+			app.Logger().Info("Added retention_days field to channels collection")
+		}
 		return nil
 	}
 
@@ -1582,6 +2875,7 @@ func ensureChannelsCollection(app *pocketbase.PocketBase) error {
 		&core.TextField{Name: "description", Max: 500},
 		&core.TextField{Name: "created_by", Required: true, Max: 50},
 		&core.TextField{Name: "channel_type", Max: 20},
+		&core.NumberField{Name: "retention_days", OnlyInt: true},
 		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 	c.AddIndex("idx_channels_created_by", false, "created_by", "")
@@ -1594,16 +2888,25 @@ func ensureChannelsCollection(app *pocketbase.PocketBase) error {
 }
 
 func ensureChannelMembersCollection(app *pocketbase.PocketBase) error {
-	_, err := app.FindCollectionByNameOrId("channel_members")
+	c, err := app.FindCollectionByNameOrId("channel_members")
 	if err == nil {
+		// Migration: add per-member read cursor
+		if c.Fields.GetByName("last_read_at") == nil {
+			c.Fields.Add(&core.DateField{Name: "last_read_at"})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate channel_members collection (add last_read_at): %w", err)
+			}
+			app.Logger().Info("Added last_read_at field to channel_members collection")
+		}
 		return nil
 	}
 
-	c := core.NewBaseCollection("channel_members")
+	c = core.NewBaseCollection("channel_members")
 	c.Fields.Add(
 		&core.TextField{Name: "channel_id", Required: true, Max: 50},
 		&core.TextField{Name: "agent_id", Required: true, Max: 50},
 		&core.TextField{Name: "role", Max: 20},
+		&core.DateField{Name: "last_read_at"},
 		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 	c.AddIndex("idx_chmembers_channel_agent", true, "channel_id, agent_id", "")
@@ -1617,19 +2920,67 @@ func ensureChannelMembersCollection(app *pocketbase.PocketBase) error {
 }
 
 func ensureChannelMessagesCollection(app *pocketbase.PocketBase) error {
-	_, err := app.FindCollectionByNameOrId("channel_messages")
+	c, err := app.FindCollectionByNameOrId("channel_messages")
 	if err == nil {
+		// Migration: (channel_id, created) index for unread counts and cursors
+		if c.GetIndex("idx_chmessages_channel_created") == "" {
+			c.AddIndex("idx_chmessages_channel_created", false, "channel_id, created", "")
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate channel_messages collection (add created index): %w", err)
+			}
+		}
+		// Migration: attachment reference
+		if c.Fields.GetByName("attachment_id") == nil {
+			c.Fields.Add(&core.TextField{Name: "attachment_id", Max: 50})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate channel_messages collection (add attachment_id): %w", err)
+			}
+			app.Logger().Info("Added attachment_id field to channel_messages collection")
+		}
+		// Migration: pinned messages are exempt from channel retention
+		if c.Fields.GetByName("pinned") == nil {
+			c.Fields.Add(&core.BoolField{Name: "pinned"})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate channel_messages collection (add pinned): %w", err)
+			}
+			app.Logger().Info("Added pinned field to channel_messages collection")
+		}
+		// Migration: tool-call events behind claw replies
+		if c.Fields.GetByName("events") == nil {
+			c.Fields.Add(&core.JSONField{Name: "events", MaxSize: 64 << 10})
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate channel_messages collection (add events): %w", err)
+			}
+			app.Logger().Info("Added events field to channel_messages collection")
+		}
+		// Migration: delivery of peer messages into claws
+		if c.Fields.GetByName("delivery") == nil {
+			c.Fields.Add(
+				&core.TextField{Name: "delivery", Max: 20},
+				&core.TextField{Name: "delivery_error", Max: 300},
+			)
+			if err := app.Save(c); err != nil {
+				return fmt.Errorf("migrate channel_messages collection (add delivery): %w", err)
+			}
+			app.Logger().Info("Added delivery fields to channel_messages collection")
+		}
 		return nil
 	}
 
-	c := core.NewBaseCollection("channel_messages")
+	c = core.NewBaseCollection("channel_messages")
 	c.Fields.Add(
 		&core.TextField{Name: "channel_id", Required: true, Max: 50},
 		&core.TextField{Name: "author_id", Required: true, Max: 50},
 		&core.TextField{Name: "body", Required: true, Max: 5000},
+		&core.TextField{Name: "attachment_id", Max: 50},
+		&core.BoolField{Name: "pinned"},
+		&core.JSONField{Name: "events", MaxSize: 64 << 10},
+		&core.TextField{Name: "delivery", Max: 20},
+		&core.TextField{Name: "delivery_error", Max: 300},
 		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 	c.AddIndex("idx_chmessages_channel", false, "channel_id", "")
+	c.AddIndex("idx_chmessages_channel_created", false, "channel_id, created", "")
 
 	if err := app.Save(c); err != nil {
 		return fmt.Errorf("create channel_messages collection: %w", err)
@@ -1638,6 +2989,38 @@ func ensureChannelMessagesCollection(app *pocketbase.PocketBase) error {
 	return nil
 }
 
+func ensureChannelAttachmentsCollection(app *pocketbase.PocketBase) error {
+	if _, err := app.FindCollectionByNameOrId("channel_attachments"); err == nil {
+		return nil
+	}
+
+	c := core.NewBaseCollection("channel_attachments")
+	c.Fields.Add(
+		// Protected: served only through the membership-checked download route
+		&core.FileField{
+			Name:      "file",
+			MaxSelect: 1,
+			MaxSize:   gatherapi.ChannelAttachmentMaxBytes,
+			Protected: true,
+		},
+		&core.TextField{Name: "channel_id", Required: true, Max: 50},
+		&core.TextField{Name: "message_id", Max: 50},
+		&core.TextField{Name: "agent_id", Required: true, Max: 50},
+		&core.TextField{Name: "original_name", Max: 500},
+		&core.TextField{Name: "mime_type", Max: 200},
+		&core.NumberField{Name: "size", OnlyInt: true},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_chattachments_channel", false, "channel_id", "")
+	c.AddIndex("idx_chattachments_agent_created", false, "agent_id, created", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create channel_attachments collection: %w", err)
+	}
+	app.Logger().Info("Created channel_attachments collection")
+	return nil
+}
+
 func ensureWaitlistCollection(app *pocketbase.PocketBase) error {
 	_, err := app.FindCollectionByNameOrId("waitlist")
 	if err == nil {
@@ -1659,21 +3042,162 @@ func ensureWaitlistCollection(app *pocketbase.PocketBase) error {
 	return nil
 }
 
+// =============================================================================
+// Platform config hooks
+// =============================================================================
+
+// registerPlatformConfigHooks validates platform_config edits however they are
+// made (admin API or the PocketBase UI) and drops cached config on change.
+func registerPlatformConfigHooks(app *pocketbase.PocketBase) {
+	app.OnRecordUpdate("platform_config").BindFunc(func(e *core.RecordEvent) error {
+		if err := gatherapi.ValidatePlatformConfigRecord(e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+	app.OnRecordAfterUpdateSuccess("platform_config").BindFunc(func(e *core.RecordEvent) error {
+		gatherapi.InvalidateConfigCaches()
+		return e.Next()
+	})
+}
+
+// =============================================================================
+// Agent key hooks
+// =============================================================================
+
+// registerAgentKeyHooks keeps the old key in agent_key_history whenever an
+// agent's public_key changes, however it is changed, so its proofs still
+// verify after the rotation.
+func registerAgentKeyHooks(app *pocketbase.PocketBase) {
+	app.OnRecordUpdate("agents").BindFunc(func(e *core.RecordEvent) error {
+		previous := e.Record.Original()
+		rotated := previous.GetString("pubkey_fingerprint") != e.Record.GetString("pubkey_fingerprint") ||
+			previous.GetString("public_key") != e.Record.GetString("public_key")
+
+		if err := e.Next(); err != nil {
+			return err
+		}
+		if rotated {
+			if err := gatherapi.RecordAgentKeyRotation(e.App, previous, time.Now()); err != nil {
+				app.Logger().Error("Failed to record agent key rotation", "agent", e.Record.Id, "error", err)
+			}
+		}
+		return nil
+	})
+}
+
+// =============================================================================
+// Channel hooks
+// =============================================================================
+
+// registerChannelHooks wakes long-polling readers of a channel whenever a
+// message is saved to it, however it was created, and pushes messages from
+// other agents into the claw whose channel it is.
+func registerChannelHooks(app *pocketbase.PocketBase) {
+	app.OnRecordAfterCreateSuccess("channel_messages").BindFunc(func(e *core.RecordEvent) error {
+		gatherapi.NotifyChannelMessage(e.Record.GetString("channel_id"))
+		gatherapi.MarkClawAgentMilestone(app, e.Record.GetString("author_id"), gatherapi.ClawMilestoneFirstChannelPost)
+		gatherapi.EnqueueClawChannelDelivery(app, e.Record)
+		return e.Next()
+	})
+}
+
+// =============================================================================
+// Engagement hooks
+// =============================================================================
+
+// registerEngagementHooks tells post authors about vote milestones and
+// comments from verified agents, however the vote or comment was saved.
+func registerEngagementHooks(app *pocketbase.PocketBase) {
+	app.OnRecordAfterCreateSuccess("votes").BindFunc(func(e *core.RecordEvent) error {
+		gatherapi.NotifyVoteMilestone(app, e.Record.GetString("post_id"))
+		return e.Next()
+	})
+	app.OnRecordAfterUpdateSuccess("votes").BindFunc(func(e *core.RecordEvent) error {
+		gatherapi.NotifyVoteMilestone(app, e.Record.GetString("post_id"))
+		return e.Next()
+	})
+	app.OnRecordAfterCreateSuccess("comments").BindFunc(func(e *core.RecordEvent) error {
+		gatherapi.NotifyVerifiedComment(app, e.Record)
+		return e.Next()
+	})
+}
+
+// registerMentionHooks tells agents @mentioned in a post or comment, however
+// it was saved. Edits and scheduled posts going live only notify agents not
+// mentioned before.
+func registerMentionHooks(app *pocketbase.PocketBase) {
+	notify := func(e *core.RecordEvent) error {
+		added := gatherapi.PrepareMentions(e.App, e.Record)
+		if err := e.Next(); err != nil {
+			return err
+		}
+		gatherapi.NotifyMentions(app, e.Record, added)
+		return nil
+	}
+	app.OnRecordCreate("posts", "comments").BindFunc(notify)
+	app.OnRecordUpdate("posts", "comments").BindFunc(notify)
+}
+
 // =============================================================================
 // Claw deployment hooks
 // =============================================================================
 
 func registerClawHooks(app *pocketbase.PocketBase) {
 	app.OnRecordAfterCreateSuccess("claw_deployments").BindFunc(func(e *core.RecordEvent) error {
-		record := e.Record
-		go provisionClaw(app, record)
+		gatherapi.EnqueueClawProvision(app, e.Record)
+		return e.Next()
+	})
+
+	// Pending claw invites are claimed on login once the email is verified
+	app.OnRecordAuthRequest("users").BindFunc(func(e *core.RecordAuthRequestEvent) error {
+		gatherapi.ClaimClawInvites(app, e.Record)
+		return e.Next()
+	})
+
+	// Invalidate cached ForwardAuth decisions when access-relevant fields change
+	app.OnRecordUpdate("claw_deployments").BindFunc(func(e *core.RecordEvent) error {
+		old := e.Record.Original()
+		changed := old.GetBool("is_public") != e.Record.GetBool("is_public") ||
+			old.GetString("user_id") != e.Record.GetString("user_id") ||
+			old.GetString("status") != e.Record.GetString("status") ||
+			old.GetString("subdomain") != e.Record.GetString("subdomain")
+		oldSubdomain := old.GetString("subdomain")
+		oldStatus, newStatus := old.GetString("status"), e.Record.GetString("status")
+		gatherapi.PrepareClawOnboardingSave(e.App, e.Record)
+
+		if err := e.Next(); err != nil {
+			return err
+		}
+		if changed {
+			gatherapi.InvalidateClawAccess(oldSubdomain)
+			gatherapi.InvalidateClawAccess(e.Record.GetString("subdomain"))
+		}
+		if oldStatus != newStatus {
+			detail := ""
+			if newStatus == "failed" || newStatus == "expired" {
+				detail = e.Record.GetString("error_message")
+			}
+			gatherapi.RecordClawActivity(e.App, e.Record.Id, gatherapi.ClawActivityStatus,
+				fmt.Sprintf("Status: %s → %s", oldStatus, newStatus), detail,
+				map[string]any{"from": oldStatus, "to": newStatus})
+		}
+		return nil
+	})
+
+	app.OnRecordAfterDeleteSuccess("claw_deployments").BindFunc(func(e *core.RecordEvent) error {
+		gatherapi.InvalidateClawAccess(e.Record.GetString("subdomain"))
 		return e.Next()
 	})
 }
 
 // provisionClaw creates a real Docker container for a claw deployment,
 // including a Gather agent identity (Ed25519 keypair) and default channel.
-func provisionClaw(app *pocketbase.PocketBase, record *core.Record) {
+// It runs on a provisioning worker with the workers' shared Docker client
+// (nil if it couldn't be created). A claw re-queued after a restart keeps
+// its agent (with a new key) and channel, and its half-made container is
+// replaced.
+func provisionClaw(ctx context.Context, app *pocketbase.PocketBase, cli *dockerclient.Client, record *core.Record) {
 	// Derive subdomain from claw name (lowercase alphanumeric only)
 	name := strings.ToLower(record.GetString("name"))
 	subdomain := ""
@@ -1698,6 +3222,7 @@ func provisionClaw(app *pocketbase.PocketBase, record *core.Record) {
 
 	record.Set("subdomain", subdomain)
 	record.Set("status", "provisioning")
+	record.Set("provision_started_at", time.Now().UTC().Format(time.RFC3339))
 	record.Set("container_id", containerName)
 	if isFreeTier || os.Getenv("BETA_MODE") == "true" {
 		record.Set("paid", true)
@@ -1739,39 +3264,71 @@ func provisionClaw(app *pocketbase.PocketBase, record *core.Record) {
 	}
 	privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privBytes})
 
-	// Create agent record (direct DB insert, no PoW needed for claws)
 	fp := auth.Fingerprint(kp.PublicKey)
-	agentCol, err := app.FindCollectionByNameOrId("agents")
-	if err != nil {
-		app.Logger().Error("Failed to find agents collection", "id", record.Id, "error", err)
-		record.Set("status", "failed")
-		record.Set("error_message", "agents collection not found")
-		app.Save(record)
-		return
-	}
 
-	agentRec := core.NewRecord(agentCol)
-	agentRec.Set("name", clawDisplayName)
-	agentRec.Set("description", fmt.Sprintf("Claw agent: %s", clawDisplayName))
-	agentRec.Set("public_key", string(pubPEM))
-	agentRec.Set("pubkey_fingerprint", fp)
-	agentRec.Set("verified", false)
-	if err := app.Save(agentRec); err != nil {
-		app.Logger().Error("Failed to create claw agent record", "id", record.Id, "error", err)
-		record.Set("status", "failed")
-		record.Set("error_message", "agent record creation failed")
+	// A retry already has an agent: only the key is new, since the old
+	// private key went with the previous attempt
+	var agentRec *core.Record
+	if agentID := record.GetString("agent_id"); agentID != "" {
+		agentRec, _ = app.FindRecordById("agents", agentID)
+	}
+	retry := agentRec != nil
+
+	if retry {
+		agentRec.Set("public_key", string(pubPEM))
+		agentRec.Set("pubkey_fingerprint", fp)
+		if err := app.Save(agentRec); err != nil {
+			app.Logger().Error("Failed to rekey claw agent record", "id", record.Id, "error", err)
+			record.Set("status", "failed")
+			record.Set("error_message", "agent record update failed")
+			app.Save(record)
+			return
+		}
+	} else {
+		// Create agent record (direct DB insert, no PoW needed for claws)
+		agentCol, err := app.FindCollectionByNameOrId("agents")
+		if err != nil {
+			app.Logger().Error("Failed to find agents collection", "id", record.Id, "error", err)
+			record.Set("status", "failed")
+			record.Set("error_message", "agents collection not found")
+			app.Save(record)
+			return
+		}
+
+		agentRec = core.NewRecord(agentCol)
+		agentRec.Set("name", clawDisplayName)
+		agentRec.Set("name_slug", gatherapi.UniqueAgentSlug(app, clawDisplayName, record.Id))
+		agentRec.Set("description", fmt.Sprintf("Claw agent: %s", clawDisplayName))
+		agentRec.Set("public_key", string(pubPEM))
+		agentRec.Set("pubkey_fingerprint", fp)
+		agentRec.Set("verified", false)
+		if err := app.Save(agentRec); err != nil {
+			app.Logger().Error("Failed to create claw agent record", "id", record.Id, "error", err)
+			record.Set("status", "failed")
+			record.Set("error_message", "agent record creation failed")
+			app.Save(record)
+			return
+		}
+
+		if err := gatherapi.SetAgentIdenticon(app, agentRec); err != nil {
+			app.Logger().Warn("Failed to set claw agent identicon", "id", record.Id, "error", err)
+		}
+
+		// Store agent_id on claw record
+		record.Set("agent_id", agentRec.Id)
 		app.Save(record)
-		return
 	}
 
-	// Store agent_id on claw record
-	record.Set("agent_id", agentRec.Id)
-	app.Save(record)
-
-	// Create default agent channel
+	// Create default agent channel, or find the one a previous attempt made
 	var channelID string
+	if retry {
+		if ch, err := app.FindFirstRecordByFilter("channels", "created_by = {:aid} && name = {:name}",
+			map[string]any{"aid": agentRec.Id, "name": fmt.Sprintf("claw-%s", subdomain)}); err == nil {
+			channelID = ch.Id
+		}
+	}
 	chCol, err := app.FindCollectionByNameOrId("channels")
-	if err == nil {
+	if err == nil && channelID == "" {
 		chRec := core.NewRecord(chCol)
 		chRec.Set("name", fmt.Sprintf("claw-%s", subdomain))
 		chRec.Set("description", fmt.Sprintf("Default channel for %s", clawDisplayName))
@@ -1783,36 +3340,39 @@ func provisionClaw(app *pocketbase.PocketBase, record *core.Record) {
 		}
 	}
 
-	// Send welcome inbox message
-	gatherapi.SendInboxMessage(app, agentRec.Id, "welcome",
-		fmt.Sprintf("Welcome, %s!", clawDisplayName),
-		fmt.Sprintf("Your claw is live. Run `gather auth` to authenticate, "+
+	// Send welcome inbox message (once; a retry's agent already has it)
+	instructions := record.GetString("instructions")
+	if !retry {
+		welcome := fmt.Sprintf("Your claw is live. Run `gather auth` to authenticate, "+
 			"`gather channels` to see your channels, "+
-			"`gather post %s 'hello'` to send your first message.", channelID),
-		"", "")
+			"`gather post %s 'hello'` to send your first message.", channelID)
+		if strings.TrimSpace(instructions) != "" {
+			welcome = gatherapi.ClawInstructionsNotice + "\n\n" + welcome
+		}
+		gatherapi.SendInboxMessage(app, agentRec.Id, "welcome",
+			fmt.Sprintf("Welcome, %s!", clawDisplayName), welcome, "", "")
+	}
 
 	app.Logger().Info("Claw agent identity created",
 		"id", record.Id, "agent_id", agentRec.Id, "channel_id", channelID)
 
 	// --- Launch Docker container with identity env vars + Traefik labels ---
-	image := os.Getenv("CLAW_DOCKER_IMAGE")
-	if image == "" {
-		image = "gather-claw:latest"
-	}
-	networkName := os.Getenv("CLAW_DOCKER_NETWORK")
-	if networkName == "" {
-		networkName = "gather-infra_gather_net"
+	profile, ok := gatherapi.ClawProfileFor(record.GetString("claw_type"))
+	if !ok {
+		record.Set("status", "failed")
+		record.Set("error_message", "No provisioning profile for claw_type "+record.GetString("claw_type"))
+		app.Save(record)
+		app.Logger().Error("Unknown claw_type", "id", record.Id, "claw_type", record.GetString("claw_type"))
+		return
 	}
+	// Network policy picks the network (and with it, what the claw can reach)
+	netSetup := gatherapi.ClawNetworkSetupForRecord(record)
+	networkName := netSetup.Network
 
 	// Base64-encode PEM keys (they contain newlines)
 	privB64 := base64.StdEncoding.EncodeToString(privPEM)
 	pubB64 := base64.StdEncoding.EncodeToString(pubPEM)
 
-	baseURL := os.Getenv("GATHER_BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://gather.is"
-	}
-
 	// Build env slice: host defaults first, then vault overrides
 	envMap := map[string]string{
 		"MODEL_PROVIDER": "anthropic",
@@ -1822,10 +3382,16 @@ func provisionClaw(app *pocketbase.PocketBase, record *core.Record) {
 		"GATHER_PRIVATE_KEY": privB64,
 		"GATHER_PUBLIC_KEY":  pubB64,
 		"GATHER_AGENT_ID":   agentRec.Id,
+		"GATHER_CLAW_ID":    record.Id,
 		"GATHER_CHANNEL_ID": channelID,
-		"GATHER_BASE_URL":   baseURL,
 		"ADK_WEBUI_ADDRESS": "https://" + subdomain + ".gather.is/api",
 	}
+	// Profile env fills gaps only; vault secrets below still override it
+	for k, v := range profile.Env {
+		if _, exists := envMap[k]; !exists {
+			envMap[k] = v
+		}
+	}
 	// LLM proxy — claw talks to gather-auth, not directly to upstream
 	proxyTokenBytes := make([]byte, 32)
 	if _, err := rand.Read(proxyTokenBytes); err != nil {
@@ -1845,17 +3411,29 @@ func provisionClaw(app *pocketbase.PocketBase, record *core.Record) {
 	if v := os.Getenv("CLAW_LLM_MODEL"); v != "" {
 		envMap["ANTHROPIC_MODEL"] = v
 	}
+	if v := os.Getenv("BUILD_AUTH_TOKEN"); v != "" {
+		envMap["BUILD_AUTH_TOKEN"] = v
+	}
 
 	// Inject user's vault secrets (overrides host defaults, but NOT ANTHROPIC_API_KEY/BASE)
 	secrets, _ := app.FindRecordsByFilter("claw_secrets",
 		"user_id = {:uid}", "", 100, 0,
 		map[string]any{"uid": userID})
+	var injected []*core.Record
+	secretKeys := []string{}
 	for _, s := range secrets {
 		key := s.GetString("key")
 		if key == "ANTHROPIC_API_KEY" || key == "ANTHROPIC_API_BASE" {
 			continue // proxy is mandatory — no BYOK override
 		}
 		envMap[key] = s.GetString("value")
+		injected = append(injected, s)
+		secretKeys = append(secretKeys, key)
+	}
+	// The policy's env (base URL, egress proxy) wins over vault secrets, so
+	// a secret can't point the claw somewhere its network can't reach
+	for k, v := range netSetup.Env {
+		envMap[k] = v
 	}
 
 	var envSlice []string
@@ -1885,35 +3463,67 @@ func provisionClaw(app *pocketbase.PocketBase, record *core.Record) {
 		"traefik.http.routers." + routerName + "-debug.service":          routerName + "-debug",
 		"traefik.http.services." + routerName + "-debug.loadbalancer.server.port": "8081",
 	}
+	// Traefik is on both claw networks; tell it which one this claw is on
+	labels["traefik.docker.network"] = networkName
 
-	ctx := context.Background()
-	cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
-	if err != nil {
+	if cli == nil {
 		record.Set("status", "failed")
-		record.Set("error_message", "Docker client init failed: "+err.Error())
+		record.Set("error_message", "Docker client unavailable")
 		app.Save(record)
-		app.Logger().Error("Failed to create Docker client", "id", record.Id, "error", err)
+		app.Logger().Error("No Docker client for claw provisioning", "id", record.Id)
 		return
 	}
-	defer cli.Close()
+
+	mounts := []mount.Mount{{
+		Type:   mount.TypeVolume,
+		Source: dataVolume,
+		Target: "/app/data",
+	}}
+
+	// Clone the connected GitHub repo into its own volume
+	if record.GetString("github_repo") != "" {
+		repo, err := gatherapi.SyncClawRepo(ctx, cli, app, record)
+		if err != nil {
+			record.Set("status", "failed")
+			record.Set("error_message", "Repo clone failed: "+err.Error())
+			app.Save(record)
+			app.Logger().Error("Failed to clone claw repo",
+				"id", record.Id, "repo", record.GetString("github_repo"), "error", err)
+			return
+		}
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: repo.Volume,
+			Target: gatherapi.ClawWorkspacePath,
+		})
+		envSlice = append(envSlice,
+			"GATHER_REPO="+repo.Repo,
+			"GATHER_REPO_BRANCH="+repo.Branch,
+		)
+		app.Logger().Info("Claw repo cloned",
+			"id", record.Id, "repo", repo.Repo, "branch", repo.Branch, "head", repo.Head)
+	}
+
+	// A previous attempt may have got as far as creating the container
+	if retry {
+		removeOwnClawContainer(ctx, app, cli, containerName, record.Id)
+	}
 
 	resp, err := cli.ContainerCreate(ctx,
 		&container.Config{
-			Image:  image,
+			Image:  profile.Image,
 			Env:    envSlice,
 			Labels: labels,
 		},
 		&container.HostConfig{
 			RestartPolicy: container.RestartPolicy{Name: "unless-stopped"},
+			NetworkMode:   container.NetworkMode(networkName),
 			Resources: container.Resources{
-				Memory:   512 * 1024 * 1024, // 512 MB
-				NanoCPUs: 1e9,               // 1 CPU
+				Memory:   profile.MemoryBytes(),
+				NanoCPUs: profile.NanoCPUs(),
 			},
-			Mounts: []mount.Mount{{
-				Type:   mount.TypeVolume,
-				Source: dataVolume,
-				Target: "/app/data",
-			}},
+			Mounts:    mounts,
+			LogConfig: gatherapi.ClawLogConfig(),
 		},
 		&network.NetworkingConfig{
 			EndpointsConfig: map[string]*network.EndpointSettings{
@@ -1932,6 +3542,15 @@ func provisionClaw(app *pocketbase.PocketBase, record *core.Record) {
 		return
 	}
 
+	// Deliver the owner's instructions before the agent boots. The data
+	// volume outlives the container, so a redeploy without instructions
+	// removes any stale file once running (below).
+	if strings.TrimSpace(instructions) != "" {
+		if err := gatherapi.WriteClawInstructions(ctx, cli, resp.ID, instructions); err != nil {
+			app.Logger().Warn("Failed to write claw instructions", "id", record.Id, "error", err)
+		}
+	}
+
 	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
 		record.Set("status", "failed")
 		record.Set("error_message", "Container start failed: "+err.Error())
@@ -1953,34 +3572,77 @@ func provisionClaw(app *pocketbase.PocketBase, record *core.Record) {
 		return
 	}
 
+	if strings.TrimSpace(instructions) == "" {
+		gatherapi.WriteClawInstructions(ctx, cli, resp.ID, "")
+	}
+
 	record.Set("status", "running")
 	record.Set("url", fmt.Sprintf("https://%s.gather.is", subdomain))
+	record.Set("secret_keys", secretKeys) // key names only, for secret usage tracking
 	if err := app.Save(record); err != nil {
 		app.Logger().Error("Failed to save claw running status", "id", record.Id, "error", err)
 	} else {
+		gatherapi.StampSecretInjection(app, record.Id, injected)
 		app.Logger().Info("Claw container running",
 			"id", record.Id, "container", containerName, "subdomain", subdomain,
-			"agent_id", agentRec.Id)
+			"agent_id", agentRec.Id, "image", profile.Image, "memory_mb", profile.MemoryMB, "cpus", profile.CPUs)
+	}
+}
+
+// removeOwnClawContainer force-removes the container called name if it was
+// started for this claw (its GATHER_CLAW_ID says so), leaving any other
+// claw's container with a colliding name alone.
+func removeOwnClawContainer(ctx context.Context, app *pocketbase.PocketBase, cli *dockerclient.Client, name, clawID string) {
+	info, err := cli.ContainerInspect(ctx, name)
+	if err != nil || info.Config == nil {
+		return
+	}
+	for _, env := range info.Config.Env {
+		if env == "GATHER_CLAW_ID="+clawID {
+			if err := cli.ContainerRemove(ctx, info.ID, container.RemoveOptions{Force: true}); err != nil {
+				app.Logger().Warn("Failed to remove container from earlier provision attempt",
+					"id", clawID, "container", name, "error", err)
+			}
+			return
+		}
 	}
 }
 
 func ensureClawSecretsCollection(app *pocketbase.PocketBase) error {
 	ownerRule := "@request.auth.id = user_id"
-	authRule := "@request.auth.id != ''"
+	// Injection tracking is server-stamped; clients can read but not set it
+	untracked := " && @request.body.last_injected_at:isset = false && @request.body.last_injected_claw:isset = false"
+	authRule := "@request.auth.id != ''" + untracked
+	ownerUpdateRule := ownerRule + untracked
 
 	c, err := app.FindCollectionByNameOrId("claw_secrets")
 	if err == nil {
+		changed := false
 		// Migration: ensure API rules are set
 		if c.ListRule == nil {
 			c.ListRule = &ownerRule
 			c.ViewRule = &ownerRule
-			c.CreateRule = &authRule
-			c.UpdateRule = &ownerRule
 			c.DeleteRule = &ownerRule
+			changed = true
+		}
+		// Migration: injection tracking fields
+		if c.Fields.GetByName("last_injected_at") == nil {
+			c.Fields.Add(
+				&core.DateField{Name: "last_injected_at"},
+				&core.TextField{Name: "last_injected_claw", Max: 50},
+			)
+			changed = true
+		}
+		if c.CreateRule == nil || *c.CreateRule != authRule || c.UpdateRule == nil || *c.UpdateRule != ownerUpdateRule {
+			c.CreateRule = &authRule
+			c.UpdateRule = &ownerUpdateRule
+			changed = true
+		}
+		if changed {
 			if err := app.Save(c); err != nil {
-				return fmt.Errorf("migrate claw_secrets rules: %w", err)
+				return fmt.Errorf("migrate claw_secrets collection: %w", err)
 			}
-			app.Logger().Info("Migrated claw_secrets API rules")
+			app.Logger().Info("Migrated claw_secrets collection")
 		}
 		return nil
 	}
@@ -1989,13 +3651,15 @@ func ensureClawSecretsCollection(app *pocketbase.PocketBase) error {
 	c.ListRule = &ownerRule
 	c.ViewRule = &ownerRule
 	c.CreateRule = &authRule
-	c.UpdateRule = &ownerRule
+	c.UpdateRule = &ownerUpdateRule
 	c.DeleteRule = &ownerRule
 	c.Fields.Add(
 		&core.TextField{Name: "user_id", Required: true, Max: 50},
 		&core.TextField{Name: "key", Required: true, Max: 100},
 		&core.TextField{Name: "value", Required: true, Max: 2000},
 		&core.JSONField{Name: "scope", MaxSize: 2000},
+		&core.DateField{Name: "last_injected_at"},
+		&core.TextField{Name: "last_injected_claw", Max: 50},
 		&core.AutodateField{Name: "created", OnCreate: true},
 		&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
 	)
@@ -2031,6 +3695,178 @@ func ensureClawUsageCollection(app *pocketbase.PocketBase) error {
 	return nil
 }
 
+// ensureClawTasksCollection stores tasks one claw hands another (see
+// api/claw_tasks.go).
+func ensureClawTasksCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("claw_tasks")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("claw_tasks")
+	c.Fields.Add(
+		&core.TextField{Name: "from_claw", Required: true, Max: 50},
+		&core.TextField{Name: "from_agent", Max: 50},
+		&core.TextField{Name: "from_name", Max: 50},
+		&core.TextField{Name: "to_claw", Required: true, Max: 50},
+		&core.TextField{Name: "to_agent", Max: 50},
+		&core.TextField{Name: "to_name", Max: 50},
+		&core.TextField{Name: "instruction", Required: true, Max: 10000},
+		&core.SelectField{Name: "status", Required: true, Values: []string{"pending", "accepted", "completed", "rejected"}},
+		&core.TextField{Name: "result", Max: 50000},
+		&core.TextField{Name: "accepted_at", Max: 30},
+		&core.TextField{Name: "finished_at", Max: 30},
+		&core.AutodateField{Name: "created", OnCreate: true},
+		&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+	)
+	c.AddIndex("idx_claw_tasks_to", false, "to_claw, created", "")
+	c.AddIndex("idx_claw_tasks_from", false, "from_claw, created", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create claw_tasks collection: %w", err)
+	}
+	app.Logger().Info("Created claw_tasks collection")
+	return nil
+}
+
+func ensureAuthEventsCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("auth_events")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("auth_events")
+	c.Fields.Add(
+		&core.TextField{Name: "agent_id", Max: 50},
+		&core.TextField{Name: "fingerprint", Max: 100},
+		&core.SelectField{Name: "kind", Required: true, Values: []string{"challenge", "authenticate"}},
+		&core.BoolField{Name: "success"},
+		&core.NumberField{Name: "status"},
+		&core.TextField{Name: "reason", Max: 300},
+		&core.TextField{Name: "ip", Max: 64},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_auth_events_agent_created", false, "agent_id, created", "")
+	c.AddIndex("idx_auth_events_created", false, "created", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create auth_events collection: %w", err)
+	}
+	app.Logger().Info("Created auth_events collection")
+	return nil
+}
+
+func ensureAnnouncementsCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("announcements")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("announcements")
+	c.Fields.Add(
+		&core.TextField{Name: "title", Required: true, Max: 180},
+		&core.TextField{Name: "body", Required: true, Max: 2000},
+		&core.SelectField{Name: "severity", Required: true, Values: []string{"info", "warning", "critical"}},
+		&core.SelectField{Name: "audience", Required: true, Values: []string{"all", "verified_only", "claw_agents"}},
+		&core.SelectField{Name: "status", Required: true, Values: []string{"pending", "delivering", "delivered", "cancelled"}},
+		&core.NumberField{Name: "recipients"},
+		&core.NumberField{Name: "delivered"},
+		&core.TextField{Name: "cursor", Max: 50},
+		&core.TextField{Name: "created_by", Max: 50},
+		&core.TextField{Name: "completed_at"},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_announcements_status", false, "status, created", "")
+	c.AddIndex("idx_announcements_severity_created", false, "severity, created", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create announcements collection: %w", err)
+	}
+	app.Logger().Info("Created announcements collection")
+	return nil
+}
+
+func ensureClawEventsCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("claw_events")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("claw_events")
+	c.Fields.Add(
+		&core.TextField{Name: "claw_id", Required: true, Max: 50},
+		&core.TextField{Name: "agent_id", Max: 50},
+		&core.SelectField{Name: "type", Required: true, Values: []string{"task_started", "task_completed", "error", "info"}},
+		&core.TextField{Name: "title", Required: true, Max: 200},
+		&core.TextField{Name: "detail", Max: 5000},
+		&core.JSONField{Name: "metadata", MaxSize: 16384},
+		&core.BoolField{Name: "notified"},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_claw_events_claw_created", false, "claw_id, created", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create claw_events collection: %w", err)
+	}
+	app.Logger().Info("Created claw_events collection")
+	return nil
+}
+
+func ensureClawActivityCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("claw_activity")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("claw_activity")
+	c.Fields.Add(
+		&core.TextField{Name: "claw_id", Required: true, Max: 50},
+		&core.SelectField{Name: "kind", Required: true, Values: []string{
+			gatherapi.ClawActivityStatus, gatherapi.ClawActivityRestart,
+			gatherapi.ClawActivityEnv, gatherapi.ClawActivityHeartbeat,
+		}},
+		&core.TextField{Name: "title", Required: true, Max: 200},
+		&core.TextField{Name: "detail", Max: 2000},
+		&core.JSONField{Name: "metadata", MaxSize: 4096},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_claw_activity_claw_created", false, "claw_id, created", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create claw_activity collection: %w", err)
+	}
+	app.Logger().Info("Created claw_activity collection")
+	return nil
+}
+
+func ensureClawCollaboratorsCollection(app *pocketbase.PocketBase) error {
+	_, err := app.FindCollectionByNameOrId("claw_collaborators")
+	if err == nil {
+		return nil // already exists
+	}
+
+	c := core.NewBaseCollection("claw_collaborators")
+	c.Fields.Add(
+		&core.TextField{Name: "claw_id", Required: true, Max: 50},
+		&core.TextField{Name: "user_id", Max: 50}, // empty while the invite is pending
+		&core.TextField{Name: "email", Required: true, Max: 200},
+		&core.SelectField{Name: "role", Required: true, Values: []string{
+			gatherapi.ClawRoleViewer, gatherapi.ClawRoleOperator,
+		}},
+		&core.TextField{Name: "invited_by", Required: true, Max: 50},
+		&core.AutodateField{Name: "created", OnCreate: true},
+	)
+	c.AddIndex("idx_claw_collaborators_claw_email", true, "claw_id, email", "")
+	c.AddIndex("idx_claw_collaborators_user", false, "user_id", "")
+	c.AddIndex("idx_claw_collaborators_email", false, "email", "")
+
+	if err := app.Save(c); err != nil {
+		return fmt.Errorf("create claw_collaborators collection: %w", err)
+	}
+	app.Logger().Info("Created claw_collaborators collection")
+	return nil
+}
+
 func ensureInvitesCollection(app *pocketbase.PocketBase) error {
 	_, err := app.FindCollectionByNameOrId("invites")
 	if err == nil {
@@ -2114,6 +3950,59 @@ func ensureClawDeploymentsCollection(app *pocketbase.PocketBase) error {
 			c.Fields.Add(&core.TextField{Name: "agent_type", Max: 20})
 			changed = true
 		}
+		if c.Fields.GetByName("health_status") == nil {
+			c.Fields.Add(
+				&core.TextField{Name: "health_status", Max: 20},
+				&core.TextField{Name: "last_health_at", Max: 30},
+				&core.NumberField{Name: "health_latency_ms"},
+				&core.NumberField{Name: "health_failures"},
+				&core.BoolField{Name: "auto_heal"},
+				&core.TextField{Name: "last_auto_heal_at", Max: 30},
+			)
+			changed = true
+		}
+		if c.Fields.GetByName("secret_keys") == nil {
+			c.Fields.Add(&core.JSONField{Name: "secret_keys", MaxSize: 10000})
+			changed = true
+		}
+		if c.Fields.GetByName("network_policy") == nil {
+			// Existing claws keep an empty policy, which means open
+			c.Fields.Add(
+				&core.TextField{Name: "network_policy", Max: 20},
+				&core.JSONField{Name: "egress_allowlist", MaxSize: 10000},
+			)
+			changed = true
+		}
+		if c.Fields.GetByName("accept_public_tasks") == nil {
+			c.Fields.Add(&core.BoolField{Name: "accept_public_tasks"})
+			changed = true
+		}
+		if c.Fields.GetByName("provision_started_at") == nil {
+			c.Fields.Add(&core.TextField{Name: "provision_started_at", Max: 30})
+			changed = true
+		}
+		if c.Fields.GetByName("sleep_schedule") == nil {
+			c.Fields.Add(
+				&core.JSONField{Name: "sleep_schedule", MaxSize: 1000},
+				&core.TextField{Name: "sleep_override_until", Max: 30},
+			)
+			changed = true
+		}
+		if c.Fields.GetByName("provisioner_id") == nil {
+			c.Fields.Add(
+				&core.TextField{Name: "provisioner_id", Max: 100},
+				&core.TextField{Name: "claimed_at", Max: 30},
+				&core.NumberField{Name: "claim_attempts"},
+			)
+			changed = true
+		}
+		// Onboarding checklist, stamped by agent_id from the auth and
+		// channel paths
+		if c.Fields.GetByName("onboarding") == nil {
+			c.Fields.Add(&core.JSONField{Name: "onboarding", MaxSize: 2000})
+			c.AddIndex("idx_claw_agent", false, "agent_id", "")
+			changed = true
+		}
 		if changed {
 			if err := app.Save(c); err != nil {
 				return fmt.Errorf("migrate claw_deployments collection: %w", err)
@@ -2152,10 +4041,28 @@ func ensureClawDeploymentsCollection(app *pocketbase.PocketBase) error {
 		&core.BoolField{Name: "trial_warned"},
 		&core.TextField{Name: "proxy_token", Max: 64},
 		&core.TextField{Name: "agent_type", Max: 20},
+		&core.TextField{Name: "health_status", Max: 20},
+		&core.TextField{Name: "last_health_at", Max: 30},
+		&core.NumberField{Name: "health_latency_ms"},
+		&core.NumberField{Name: "health_failures"},
+		&core.BoolField{Name: "auto_heal"},
+		&core.TextField{Name: "last_auto_heal_at", Max: 30},
+		&core.JSONField{Name: "secret_keys", MaxSize: 10000},
+		&core.TextField{Name: "network_policy", Max: 20},
+		&core.JSONField{Name: "egress_allowlist", MaxSize: 10000},
+		&core.BoolField{Name: "accept_public_tasks"},
+		&core.TextField{Name: "provision_started_at", Max: 30},
+		&core.TextField{Name: "provisioner_id", Max: 100},
+		&core.TextField{Name: "claimed_at", Max: 30},
+		&core.NumberField{Name: "claim_attempts"},
+		&core.JSONField{Name: "onboarding", MaxSize: 2000},
+		&core.JSONField{Name: "sleep_schedule", MaxSize: 1000},
+		&core.TextField{Name: "sleep_override_until", Max: 30},
 		&core.AutodateField{Name: "created", OnCreate: true},
 	)
 	c.AddIndex("idx_claw_user", false, "user_id", "")
 	c.AddIndex("idx_claw_proxy_token", false, "proxy_token", "")
+	c.AddIndex("idx_claw_agent", false, "agent_id", "")
 
 	if err := app.Save(c); err != nil {
 		return fmt.Errorf("create claw_deployments collection: %w", err)
@@ -35,12 +35,16 @@ func GenerateKeyPair() (*KeyPair, error) {
 // SaveKeyPair writes the keypair to disk at ~/.gather/keys/{name}.key and .pub
 // with restrictive permissions (0600 for private, 0644 for public).
 func SaveKeyPair(name string, kp *KeyPair) error {
-	home, err := os.UserHomeDir()
+	dir, err := defaultKeysDir()
 	if err != nil {
-		return fmt.Errorf("get home dir: %w", err)
+		return err
 	}
+	return SaveKeyPairTo(dir, name, kp)
+}
 
-	dir := filepath.Join(home, ".gather", "keys")
+// SaveKeyPairTo writes the keypair to {dir}/{name}.key and .pub, creating dir
+// if needed.
+func SaveKeyPairTo(dir, name string, kp *KeyPair) error {
 	if err := os.MkdirAll(dir, 0700); err != nil {
 		return fmt.Errorf("create keys dir: %w", err)
 	}
@@ -72,13 +76,15 @@ func SaveKeyPair(name string, kp *KeyPair) error {
 
 // LoadKeyPair reads a keypair from ~/.gather/keys/{name}.key and .pub.
 func LoadKeyPair(name string) (*KeyPair, error) {
-	home, err := os.UserHomeDir()
+	dir, err := defaultKeysDir()
 	if err != nil {
-		return nil, fmt.Errorf("get home dir: %w", err)
+		return nil, err
 	}
+	return LoadKeyPairFrom(dir, name)
+}
 
-	dir := filepath.Join(home, ".gather", "keys")
-
+// LoadKeyPairFrom reads a keypair from {dir}/{name}.key and .pub.
+func LoadKeyPairFrom(dir, name string) (*KeyPair, error) {
 	privPEM, err := os.ReadFile(filepath.Join(dir, name+".key"))
 	if err != nil {
 		return nil, fmt.Errorf("read private key: %w", err)
@@ -102,6 +108,15 @@ func LoadKeyPair(name string) (*KeyPair, error) {
 	return &KeyPair{PublicKey: pub, PrivateKey: priv}, nil
 }
 
+// defaultKeysDir is ~/.gather/keys.
+func defaultKeysDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, ".gather", "keys"), nil
+}
+
 // ParsePublicKeyPEM decodes a PEM-encoded Ed25519 public key.
 func ParsePublicKeyPEM(pemData []byte) (ed25519.PublicKey, error) {
 	block, _ := pem.Decode(pemData)
@@ -0,0 +1,293 @@
+// Package jobs runs gather-auth's periodic background work (cleanups,
+// expiries, recomputes) with overlap protection and per-job run history.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Func is the body of a periodic job. It should return promptly once ctx is
+// cancelled (server shutdown).
+type Func func(ctx context.Context) error
+
+var (
+	ErrUnknownJob     = errors.New("unknown job")
+	ErrAlreadyRunning = errors.New("job is already running")
+	ErrStopped        = errors.New("job runner is stopped")
+)
+
+// State is a snapshot of one job's schedule and last outcome.
+type State struct {
+	Name         string
+	Interval     time.Duration
+	Running      bool
+	Runs         int
+	Failures     int
+	LastRun      time.Time // zero if the job hasn't run yet
+	LastDuration time.Duration
+	LastError    string // empty if the last run succeeded
+	NextRun      time.Time
+}
+
+type job struct {
+	name       string
+	interval   time.Duration
+	fn         Func
+	runOnStart bool
+
+	// guarded by Runner.mu
+	running      bool
+	runs         int
+	failures     int
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastError    string
+	nextRun      time.Time
+}
+
+// Runner schedules periodic jobs. It never runs two instances of the same
+// job at once, recovers panics, and records the outcome of every run.
+type Runner struct {
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewRunner creates an empty runner. Register jobs, then call Start.
+func NewRunner(logger *slog.Logger) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		logger: logger,
+		jobs:   make(map[string]*job),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Option tweaks a job at registration.
+type Option func(*job)
+
+// RunOnStart runs the job once as soon as the runner starts, instead of
+// waiting a full interval.
+func RunOnStart() Option {
+	return func(j *job) { j.runOnStart = true }
+}
+
+// Register adds a job. Names must be unique; registering after Start panics,
+// as does a duplicate name — both are programming errors.
+func (r *Runner) Register(name string, interval time.Duration, fn Func, opts ...Option) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		panic(fmt.Sprintf("jobs: Register(%q) after Start", name))
+	}
+	if _, dup := r.jobs[name]; dup {
+		panic(fmt.Sprintf("jobs: duplicate job %q", name))
+	}
+	j := &job{name: name, interval: interval, fn: fn}
+	for _, opt := range opts {
+		opt(j)
+	}
+	r.jobs[name] = j
+}
+
+// Start launches a scheduling loop per registered job.
+func (r *Runner) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return
+	}
+	r.started = true
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go r.loop(j)
+	}
+	r.logger.Info("Job runner started", "jobs", len(r.jobs))
+}
+
+func (r *Runner) loop(j *job) {
+	defer r.wg.Done()
+
+	if j.runOnStart {
+		r.run(j)
+	}
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	r.setNextRun(j, time.Now().Add(j.interval))
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			r.setNextRun(j, time.Now().Add(j.interval))
+			r.run(j)
+		}
+	}
+}
+
+func (r *Runner) setNextRun(j *job, t time.Time) {
+	r.mu.Lock()
+	j.nextRun = t
+	r.mu.Unlock()
+}
+
+// claim marks j as running. It fails if j is already running or the runner
+// is shutting down.
+func (r *Runner) claim(j *job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return ErrStopped
+	}
+	if j.running {
+		return ErrAlreadyRunning
+	}
+	j.running = true
+	return nil
+}
+
+// run executes j once unless it is already running, and records the outcome.
+func (r *Runner) run(j *job) {
+	if err := r.claim(j); err != nil {
+		if errors.Is(err, ErrAlreadyRunning) {
+			r.logger.Warn("Job still running, skipping tick", "job", j.name)
+		}
+		return
+	}
+	r.execute(j)
+}
+
+// execute runs a claimed job and releases it.
+func (r *Runner) execute(j *job) {
+	start := time.Now()
+	err := r.call(j)
+	elapsed := time.Since(start)
+
+	r.mu.Lock()
+	j.running = false
+	j.runs++
+	j.lastRun = start
+	j.lastDuration = elapsed
+	j.lastError = ""
+	if err != nil {
+		j.failures++
+		j.lastError = err.Error()
+	}
+	r.mu.Unlock()
+
+	if err != nil {
+		r.logger.Warn("Job failed", "job", j.name, "duration", elapsed, "error", err)
+	} else {
+		r.logger.Debug("Job finished", "job", j.name, "duration", elapsed)
+	}
+}
+
+// call invokes the job body, converting a panic into an error.
+func (r *Runner) call(j *job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			r.logger.Error("Job panicked", "job", j.name, "panic", p, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return j.fn(r.ctx)
+}
+
+// Trigger starts a run of the named job now, in the background, without
+// disturbing its schedule.
+func (r *Runner) Trigger(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[name]
+	switch {
+	case !ok:
+		return ErrUnknownJob
+	case r.stopped:
+		return ErrStopped
+	case j.running:
+		return ErrAlreadyRunning
+	}
+	// Claimed under the lock so Stop can't start waiting before Add.
+	j.running = true
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.execute(j)
+	}()
+	return nil
+}
+
+// States returns a snapshot of every job, sorted by name.
+func (r *Runner) States() []State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	states := make([]State, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		states = append(states, r.stateLocked(j))
+	}
+	sort.Slice(states, func(a, b int) bool { return states[a].Name < states[b].Name })
+	return states
+}
+
+// State returns a snapshot of the named job.
+func (r *Runner) State(name string) (State, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[name]
+	if !ok {
+		return State{}, false
+	}
+	return r.stateLocked(j), true
+}
+
+func (r *Runner) stateLocked(j *job) State {
+	return State{
+		Name:         j.name,
+		Interval:     j.interval,
+		Running:      j.running,
+		Runs:         j.runs,
+		Failures:     j.failures,
+		LastRun:      j.lastRun,
+		LastDuration: j.lastDuration,
+		LastError:    j.lastError,
+		NextRun:      j.nextRun,
+	}
+}
+
+// Stop cancels the context passed to running jobs, stops scheduling new
+// runs, and waits up to timeout for in-flight runs to return. It reports
+// whether everything finished in time.
+func (r *Runner) Stop(timeout time.Duration) bool {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
@@ -0,0 +1,104 @@
+package auth
+
+// JWT signing keyring.
+//
+// Rotation procedure (JWT_SIGNING_KEYS, first key signs, all keys validate):
+// 1. Prepend the new key: JWT_SIGNING_KEYS=new,old. Restart. New tokens are
+//    signed with "new"; tokens already issued under "old" keep working.
+// 2. Wait at least one token TTL (1 hour) so every "old" token has expired.
+// 3. Drop the old key: JWT_SIGNING_KEYS=new. Restart. Any token signed with
+//    "old" is now rejected.
+// For a leaked key, skip step 2: dropping it forces affected agents to
+// re-authenticate, but sessions already on the new key are unaffected.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MinSigningKeyLen is the minimum length of an HMAC signing key, in bytes.
+const MinSigningKeyLen = 32
+
+// Keyring holds the HMAC keys used to sign and validate agent JWTs, plus the
+// iss/aud claims every token must carry.
+type Keyring struct {
+	keys     [][]byte
+	kids     []string
+	Issuer   string
+	Audience string
+	// LegacyCutoff lets tokens without a kid (and so without aud) through if
+	// they were issued before it. Zero, the default, rejects them all; set it
+	// to the time of the upgrade only while rolling it out.
+	LegacyCutoff time.Time
+}
+
+// NewKeyring builds a keyring. keys[0] signs new tokens; every key validates.
+func NewKeyring(keys [][]byte, issuer, audience string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+	kr := &Keyring{Issuer: issuer, Audience: audience}
+	seen := map[string]bool{}
+	for i, k := range keys {
+		if len(k) < MinSigningKeyLen {
+			return nil, fmt.Errorf("signing key %d must be at least %d bytes", i+1, MinSigningKeyLen)
+		}
+		kid := KeyID(k)
+		if seen[kid] {
+			continue
+		}
+		seen[kid] = true
+		kr.keys = append(kr.keys, k)
+		kr.kids = append(kr.kids, kid)
+	}
+	return kr, nil
+}
+
+// ParseSigningKeys parses a JWT_SIGNING_KEYS value: either a JSON array of
+// strings or a comma-separated list. Blank entries are ignored.
+func ParseSigningKeys(s string) ([][]byte, error) {
+	s = strings.TrimSpace(s)
+	var raw []string
+	if strings.HasPrefix(s, "[") {
+		if err := json.Unmarshal([]byte(s), &raw); err != nil {
+			return nil, fmt.Errorf("parse signing keys: %w", err)
+		}
+	} else {
+		raw = strings.Split(s, ",")
+	}
+	var keys [][]byte
+	for _, k := range raw {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, []byte(k))
+		}
+	}
+	return keys, nil
+}
+
+// KeyID derives the kid header for a key: a short hash, so the key itself
+// never appears in tokens and operators don't have to name keys.
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// signing returns the key and kid used for new tokens.
+func (kr *Keyring) signing() ([]byte, string) {
+	return kr.keys[0], kr.kids[0]
+}
+
+// lookup returns the key with the given kid, or nil if it isn't (or is no
+// longer) on the ring.
+func (kr *Keyring) lookup(kid string) []byte {
+	for i, k := range kr.kids {
+		if k == kid {
+			return kr.keys[i]
+		}
+	}
+	return nil
+}
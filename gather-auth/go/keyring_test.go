@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	oldKey = bytes.Repeat([]byte("o"), MinSigningKeyLen)
+	newKey = bytes.Repeat([]byte("n"), MinSigningKeyLen)
+)
+
+func testRing(t *testing.T, audience string, keys ...[]byte) *Keyring {
+	t.Helper()
+	kr, err := NewKeyring(keys, "gather.is", audience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kr
+}
+
+func issue(t *testing.T, kr *Keyring) string {
+	t.Helper()
+	token, err := IssueJWT("agent1", make(ed25519.PublicKey, ed25519.PublicKeySize), kr, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestKeyRotation(t *testing.T) {
+	before := testRing(t, "gather-api", oldKey)
+	during := testRing(t, "gather-api", newKey, oldKey)
+	after := testRing(t, "gather-api", newKey)
+
+	oldToken := issue(t, before)
+	newToken := issue(t, during)
+
+	// Step 1: the new key signs, and tokens under the old key still validate
+	if claims, err := ValidateJWT(oldToken, during); err != nil || claims.AgentID != "agent1" {
+		t.Errorf("old token during rotation: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &AgentClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := parsed.Header["kid"]; kid != KeyID(newKey) {
+		t.Errorf("new token kid = %v, want the new key's", kid)
+	}
+
+	// Step 3: once the old key is dropped, its tokens are rejected and new
+	// ones keep working
+	if _, err := ValidateJWT(oldToken, after); err == nil {
+		t.Error("old token accepted after its key was dropped")
+	}
+	if _, err := ValidateJWT(newToken, after); err != nil {
+		t.Errorf("new token after rotation: %v", err)
+	}
+}
+
+func TestValidateJWTClaims(t *testing.T) {
+	kr := testRing(t, "gather-api", newKey)
+	token := issue(t, kr)
+
+	if _, err := ValidateJWT(token, testRing(t, "other-service", newKey)); err == nil {
+		t.Error("token accepted by a service with another audience")
+	}
+	other, _ := NewKeyring([][]byte{newKey}, "someone-else", "gather-api")
+	if _, err := ValidateJWT(token, other); err == nil {
+		t.Error("token accepted with another issuer")
+	}
+
+	expired, err := IssueJWT("agent1", make(ed25519.PublicKey, ed25519.PublicKeySize), kr, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateJWT(expired, kr); err == nil {
+		t.Error("expired token accepted")
+	}
+
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, AgentClaims{AgentID: "agent1"}).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateJWT(none, kr); err == nil {
+		t.Error("unsigned token accepted")
+	}
+}
+
+func TestValidateLegacyJWT(t *testing.T) {
+	// Tokens issued before rotation support have no kid and no aud
+	legacy := func(key []byte, issuer string, iat time.Time, aud ...string) string {
+		claims := AgentClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    issuer,
+				Audience:  aud,
+				IssuedAt:  jwt.NewNumericDate(iat),
+				ExpiresAt: jwt.NewNumericDate(iat.Add(time.Hour)),
+			},
+			AgentID: "agent1",
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	before := time.Now().Add(-time.Minute)
+
+	during := testRing(t, "gather-api", newKey, oldKey)
+	during.LegacyCutoff = time.Now()
+	if claims, err := ValidateJWT(legacy(oldKey, "gather.is", before), during); err != nil || claims.AgentID != "agent1" {
+		t.Errorf("legacy token under a listed key: %v", err)
+	}
+	dropped := testRing(t, "gather-api", newKey)
+	dropped.LegacyCutoff = during.LegacyCutoff
+	if _, err := ValidateJWT(legacy(oldKey, "gather.is", before), dropped); err == nil {
+		t.Error("legacy token accepted after its key was dropped")
+	}
+	if _, err := ValidateJWT(legacy(oldKey, "elsewhere", before), during); err == nil {
+		t.Error("legacy token with another issuer accepted")
+	}
+
+	// Without a cutoff the legacy path is closed
+	if _, err := ValidateJWT(legacy(newKey, "gather.is", before), testRing(t, "gather-api", newKey)); err == nil {
+		t.Error("kid-less token accepted with no legacy cutoff set")
+	}
+}
+
+func TestLegacyJWTCutoff(t *testing.T) {
+	cutoff := time.Now().Add(-10 * time.Minute)
+	kr := testRing(t, "gather-api", newKey)
+	kr.LegacyCutoff = cutoff
+
+	sign := func(claims jwt.RegisteredClaims) string {
+		claims.Issuer = "gather.is"
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, AgentClaims{RegisteredClaims: claims, AgentID: "agent1"}).SignedString(newKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	at := func(t time.Time) *jwt.NumericDate { return jwt.NewNumericDate(t) }
+	old, recent := cutoff.Add(-time.Minute), time.Now().Add(-time.Minute)
+
+	cases := []struct {
+		name   string
+		claims jwt.RegisteredClaims
+		ok     bool
+	}{
+		{"issued before the cutoff", jwt.RegisteredClaims{IssuedAt: at(old), ExpiresAt: at(old.Add(time.Hour))}, true},
+		{"after the cutoff, no aud", jwt.RegisteredClaims{IssuedAt: at(recent), ExpiresAt: at(recent.Add(time.Hour))}, false},
+		{"after the cutoff, wrong aud", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"other-service"},
+			IssuedAt: at(recent), ExpiresAt: at(recent.Add(time.Hour))}, false},
+		{"after the cutoff, right aud", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"gather-api"},
+			IssuedAt: at(recent), ExpiresAt: at(recent.Add(time.Hour))}, false},
+		{"no iat", jwt.RegisteredClaims{ExpiresAt: at(time.Now().Add(time.Hour))}, false},
+		{"no exp", jwt.RegisteredClaims{IssuedAt: at(old)}, false},
+		{"before the cutoff, long-lived", jwt.RegisteredClaims{IssuedAt: at(old), ExpiresAt: at(old.Add(24 * time.Hour))}, false},
+	}
+	for _, tc := range cases {
+		_, err := ValidateJWT(sign(tc.claims), kr)
+		if tc.ok && err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		} else if !tc.ok && err == nil {
+			t.Errorf("%s: accepted", tc.name)
+		}
+	}
+
+	// Tokens with a kid are unaffected by the cutoff
+	if _, err := ValidateJWT(issue(t, kr), kr); err != nil {
+		t.Errorf("current token: %v", err)
+	}
+}
+
+func TestParseSigningKeys(t *testing.T) {
+	cases := map[string][]string{
+		"":                 nil,
+		"a":                {"a"},
+		" a , b ,,":        {"a", "b"},
+		`["a,1", "b", ""]`: {"a,1", "b"},
+		`[" spaced "]`:     {"spaced"},
+	}
+	for in, want := range cases {
+		got, err := ParseSigningKeys(in)
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		if len(got) != len(want) {
+			t.Errorf("%q: got %q, want %q", in, got, want)
+			continue
+		}
+		for i := range want {
+			if string(got[i]) != want[i] {
+				t.Errorf("%q: got %q, want %q", in, got, want)
+			}
+		}
+	}
+	if _, err := ParseSigningKeys(`["unterminated`); err == nil {
+		t.Error("malformed JSON accepted")
+	}
+}
+
+func TestNewKeyring(t *testing.T) {
+	if _, err := NewKeyring(nil, "i", "a"); err == nil {
+		t.Error("empty keyring accepted")
+	}
+	if _, err := NewKeyring([][]byte{newKey, []byte("short")}, "i", "a"); err == nil {
+		t.Error("short key accepted")
+	}
+	kr := testRing(t, "a", newKey, oldKey, newKey)
+	if len(kr.keys) != 2 {
+		t.Errorf("%d keys after de-duplication, want 2", len(kr.keys))
+	}
+	if _, kid := kr.signing(); kid != KeyID(newKey) {
+		t.Error("first key doesn't sign")
+	}
+}
@@ -0,0 +1,527 @@
+// Package markdown renders post bodies to HTML that is safe to inject into a
+// page.
+//
+// It supports the Markdown subset agents actually write: ATX headings,
+// paragraphs, fenced code blocks, block quotes, bullet and numbered lists,
+// horizontal rules, emphasis, strong, strikethrough, code spans, links,
+// autolinks and images. It is a sanitizer as much as a renderer: every byte of
+// input is HTML-escaped before it reaches the output, so raw HTML in the
+// source shows as text and never passes through. Links are emitted only for
+// http, https and mailto URLs and same-site paths, and images only when the
+// caller's ImageAllowed accepts the URL.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Version changes whenever the output for the same input may change, so
+// callers caching rendered HTML know to render again.
+const Version = "1"
+
+// maxDepth bounds how deeply block quotes and lists may nest; deeper content
+// is rendered as a paragraph.
+const maxDepth = 8
+
+// Options controls what the rendered HTML may reference.
+type Options struct {
+	// ImageAllowed reports whether an image URL may be embedded. Images it
+	// rejects, or all images when it is nil, render as their alt text.
+	ImageAllowed func(url string) bool
+}
+
+// Render converts Markdown to sanitized HTML.
+func Render(src string, opts Options) string {
+	r := renderer{opts: opts}
+	r.blocks(splitLines(src), 0)
+	return r.b.String()
+}
+
+// RenderPlain converts plain text to HTML: paragraphs split on blank lines,
+// single newlines kept as line breaks, everything else escaped.
+func RenderPlain(src string) string {
+	var b strings.Builder
+	for _, para := range blankLineRe.Split(normalize(src), -1) {
+		para = strings.Trim(para, "\n")
+		if strings.TrimSpace(para) == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(para), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+func normalize(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.ReplaceAll(s, "\x00", "�")
+}
+
+func splitLines(s string) []string {
+	return strings.Split(normalize(s), "\n")
+}
+
+var (
+	blankLineRe = regexp.MustCompile(`\n[ \t]*\n`)
+	headingRe   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	hrRe        = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fenceRe     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+	bulletRe    = regexp.MustCompile(`^( {0,3})([-*+])[ \t]+(.*)$`)
+	orderedRe   = regexp.MustCompile(`^( {0,3})(\d{1,9})[.)][ \t]+(.*)$`)
+	quoteRe     = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	langRe      = regexp.MustCompile(`^[A-Za-z0-9_+#.-]{1,30}$`)
+)
+
+type renderer struct {
+	b    strings.Builder
+	opts Options
+}
+
+// blocks renders a sequence of lines as block-level elements.
+func (r *renderer) blocks(lines []string, depth int) {
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			r.b.WriteString("<p>")
+			r.inline(strings.Join(para, "\n"), false)
+			r.b.WriteString("</p>\n")
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			flush()
+			fence := m[1]
+			var code []string
+			for i++; i < len(lines); i++ {
+				t := strings.TrimLeft(lines[i], " ")
+				if strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]+" \t") == "" {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			r.b.WriteString("<pre><code")
+			if langRe.MatchString(m[2]) {
+				r.b.WriteString(` class="language-` + html.EscapeString(m[2]) + `"`)
+			}
+			r.b.WriteString(">")
+			for _, c := range code {
+				r.b.WriteString(html.EscapeString(c))
+				r.b.WriteString("\n")
+			}
+			r.b.WriteString("</code></pre>\n")
+			continue
+		}
+
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			flush()
+			level := string(rune('0' + len(m[1])))
+			r.b.WriteString("<h" + level + ">")
+			r.inline(m[2], false)
+			r.b.WriteString("</h" + level + ">\n")
+			continue
+		}
+
+		if hrRe.MatchString(line) {
+			flush()
+			r.b.WriteString("<hr>\n")
+			continue
+		}
+
+		if depth < maxDepth && quoteRe.MatchString(line) {
+			flush()
+			var inner []string
+			for ; i < len(lines); i++ {
+				m := quoteRe.FindStringSubmatch(lines[i])
+				if m == nil {
+					break
+				}
+				inner = append(inner, m[1])
+			}
+			i--
+			r.b.WriteString("<blockquote>\n")
+			r.blocks(inner, depth+1)
+			r.b.WriteString("</blockquote>\n")
+			continue
+		}
+
+		if depth < maxDepth && (bulletRe.MatchString(line) || orderedRe.MatchString(line)) {
+			flush()
+			i = r.list(lines, i, depth) - 1
+			continue
+		}
+
+		para = append(para, strings.TrimLeft(line, " \t"))
+	}
+	flush()
+}
+
+// list renders the list starting at lines[start] and returns the index of
+// the first line after it.
+func (r *renderer) list(lines []string, start, depth int) int {
+	ordered := orderedRe.MatchString(lines[start])
+	indent := len(lines[start]) - len(strings.TrimLeft(lines[start], " "))
+	// marker matches a sibling item: same kind, not indented enough to be
+	// nested under the previous one.
+	marker := func(line string) []string {
+		var m []string
+		if ordered {
+			m = orderedRe.FindStringSubmatch(line)
+		} else if m = bulletRe.FindStringSubmatch(line); m != nil && hrRe.MatchString(line) {
+			return nil
+		}
+		if m == nil || len(m[1]) > indent+1 {
+			return nil
+		}
+		return m
+	}
+
+	var items [][]string
+	i := start
+	for i < len(lines) {
+		if m := marker(lines[i]); m != nil {
+			items = append(items, []string{m[3]})
+			i++
+			continue
+		}
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			// A blank line continues the item only if indented content follows.
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], strings.Repeat(" ", indent+2)) {
+				items[len(items)-1] = append(items[len(items)-1], "")
+				i++
+				continue
+			}
+			break
+		}
+		if strings.HasPrefix(line, strings.Repeat(" ", indent+2)) || strings.HasPrefix(line, "\t") {
+			items[len(items)-1] = append(items[len(items)-1], dedent(line[indent:]))
+			i++
+			continue
+		}
+		// A lazy continuation of the item's paragraph, unless it starts a
+		// new block.
+		if bulletRe.MatchString(line) || orderedRe.MatchString(line) || headingRe.MatchString(line) ||
+			fenceRe.MatchString(line) || quoteRe.MatchString(line) || hrRe.MatchString(line) {
+			break
+		}
+		items[len(items)-1] = append(items[len(items)-1], line)
+		i++
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		if n := strings.TrimLeft(orderedRe.FindStringSubmatch(lines[start])[2], "0"); n != "" && n != "1" {
+			r.b.WriteString(`<ol start="` + n + `">` + "\n")
+		} else {
+			r.b.WriteString("<ol>\n")
+		}
+	} else {
+		r.b.WriteString("<ul>\n")
+	}
+	for _, item := range items {
+		r.b.WriteString("<li>")
+		inner := renderer{opts: r.opts}
+		inner.blocks(item, depth+1)
+		out := strings.TrimSuffix(inner.b.String(), "\n")
+		// Items without blank lines are tight: their leading paragraph
+		// isn't wrapped in <p>.
+		if !slices.Contains(item, "") && strings.HasPrefix(out, "<p>") {
+			if para, rest, ok := strings.Cut(out[len("<p>"):], "</p>"); ok {
+				out = para + rest
+			}
+		}
+		r.b.WriteString(out)
+		r.b.WriteString("</li>\n")
+	}
+	r.b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func dedent(line string) string {
+	if strings.HasPrefix(line, "\t") {
+		return line[1:]
+	}
+	n := len(line) - len(strings.TrimLeft(line, " "))
+	return line[min(n, 4):]
+}
+
+// inline renders span-level Markdown. inLink suppresses nested links.
+func (r *renderer) inline(s string, inLink bool) {
+	var text strings.Builder
+	flush := func() {
+		r.b.WriteString(html.EscapeString(text.String()))
+		text.Reset()
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			run := runLength(s[i:], '`')
+			if end := strings.Index(s[i+run:], strings.Repeat("`", run)); end >= 0 {
+				flush()
+				code := s[i+run : i+run+end]
+				if t := strings.TrimSpace(code); t != "" {
+					code = t
+				}
+				r.b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += run + end + run
+				continue
+			}
+			text.WriteString(s[i : i+run])
+			i += run
+			continue
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if alt, dest, n, ok := parseLink(s[i+1:]); ok {
+				flush()
+				if u, ok := safeURL(dest); ok && r.opts.ImageAllowed != nil && r.opts.ImageAllowed(u) {
+					r.b.WriteString(`<img src="` + html.EscapeString(u) + `" alt="` + html.EscapeString(plainText(alt)) + `">`)
+				} else {
+					r.b.WriteString(html.EscapeString(plainText(alt)))
+				}
+				i += 1 + n
+				continue
+			}
+
+		case c == '[' && !inLink:
+			if label, dest, n, ok := parseLink(s[i:]); ok {
+				flush()
+				if u, ok := safeURL(dest); ok {
+					r.b.WriteString(`<a href="` + html.EscapeString(u) + `" rel="nofollow ugc noopener">`)
+					r.inline(label, true)
+					r.b.WriteString("</a>")
+				} else {
+					r.inline(label, true)
+				}
+				i += n
+				continue
+			}
+
+		case c == '<' && !inLink:
+			if end := strings.IndexByte(s[i:], '>'); end > 0 {
+				dest := s[i+1 : i+end]
+				if strings.Contains(dest, ":") && !strings.ContainsAny(dest, " <") {
+					if u, ok := safeURL(dest); ok && !strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "#") {
+						flush()
+						r.b.WriteString(`<a href="` + html.EscapeString(u) + `" rel="nofollow ugc noopener">` + html.EscapeString(dest) + "</a>")
+						i += end + 1
+						continue
+					}
+				}
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			run := runLength(s[i:], c)
+			n := min(run, 2)
+			if c == '~' && run != 2 {
+				break
+			}
+			if end := closingDelim(s, i, c, n); end > 0 {
+				flush()
+				tag := "em"
+				if c == '~' {
+					tag = "del"
+				} else if n == 2 {
+					tag = "strong"
+				}
+				r.b.WriteString("<" + tag + ">")
+				r.inline(s[i+n:end], inLink)
+				r.b.WriteString("</" + tag + ">")
+				i = end + n
+				continue
+			}
+			text.WriteString(s[i : i+run])
+			i += run
+			continue
+		}
+
+		text.WriteByte(c)
+		i++
+	}
+	flush()
+}
+
+// closingDelim finds the delimiter run of n c's that closes the one opening
+// at s[open], or -1. Openers must be followed, and closers preceded, by
+// non-space; underscores inside words don't count.
+func closingDelim(s string, open int, c byte, n int) int {
+	start := open + n
+	if start >= len(s) || isSpace(s[start]) {
+		return -1
+	}
+	if c == '_' && open > 0 && isWordByte(s[open-1]) {
+		return -1
+	}
+	for j := start + 1; j+n <= len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+			continue
+		case '`':
+			// Skip code spans so delimiters inside them don't close.
+			run := runLength(s[j:], '`')
+			if end := strings.Index(s[j+run:], strings.Repeat("`", run)); end >= 0 {
+				j += run + end + run - 1
+			} else {
+				j += run - 1
+			}
+			continue
+		case c:
+			run := runLength(s[j:], c)
+			if run != n {
+				// A run of the other length belongs to a nested span.
+				j += run - 1
+				continue
+			}
+			if isSpace(s[j-1]) {
+				j += run - 1
+				continue
+			}
+			if c == '_' && j+run < len(s) && isWordByte(s[j+run]) {
+				j += run - 1
+				continue
+			}
+			return j
+		}
+	}
+	return -1
+}
+
+// parseLink parses "[label](dest)" or "[label](dest "title")" at the start
+// of s, returning the label, destination and bytes consumed.
+func parseLink(s string) (label, dest string, n int, ok bool) {
+	if len(s) == 0 || s[0] != '[' {
+		return "", "", 0, false
+	}
+	depth := 0
+	closeIdx := -1
+	for i := 0; i < len(s) && closeIdx < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeIdx = i
+			}
+		}
+	}
+	if closeIdx < 0 || closeIdx+1 >= len(s) || s[closeIdx+1] != '(' {
+		return "", "", 0, false
+	}
+	// The destination may contain balanced parentheses.
+	end, parens := -1, 0
+	for i := closeIdx + 2; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			parens++
+		case ')':
+			if parens == 0 {
+				end = i - (closeIdx + 2)
+			}
+			parens--
+		}
+	}
+	if end < 0 {
+		return "", "", 0, false
+	}
+	inner := strings.TrimSpace(s[closeIdx+2 : closeIdx+2+end])
+	if sp := strings.IndexAny(inner, " \t\n"); sp >= 0 {
+		inner = inner[:sp] // drop the optional title
+	}
+	inner = strings.TrimSuffix(strings.TrimPrefix(inner, "<"), ">")
+	return s[1:closeIdx], inner, closeIdx + 2 + end + 1, true
+}
+
+// safeURL returns dest if it is an http, https or mailto URL, or a path on
+// this site. Anything else — javascript:, data:, vbscript:, protocol-relative
+// //host, or a URL hiding control characters that browsers strip before
+// reading the scheme — is refused.
+func safeURL(dest string) (string, bool) {
+	if dest == "" || len(dest) > 2048 {
+		return "", false
+	}
+	if strings.ContainsFunc(dest, func(r rune) bool {
+		return r < 0x20 || r == 0x7f || r == '\\' || unicode.IsSpace(r) || r == unicode.ReplacementChar
+	}) {
+		return "", false
+	}
+	if strings.HasPrefix(dest, "#") || (strings.HasPrefix(dest, "/") && !strings.HasPrefix(dest, "//")) {
+		return dest, true
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+		return dest, true
+	case "mailto":
+		if u.Opaque == "" {
+			return "", false
+		}
+		return dest, true
+	}
+	return "", false
+}
+
+// plainText strips Markdown punctuation from s for use as alt text.
+func plainText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '*', '_', '`', '[', ']', '~':
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func runLength(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
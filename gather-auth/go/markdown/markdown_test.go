@@ -0,0 +1,178 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var testOpts = Options{ImageAllowed: func(u string) bool { return strings.HasPrefix(u, "/api/files/") }}
+
+func TestRender(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"# Title\n\nHello *world* and **bold** and ~~gone~~ and `code`.",
+			"<h1>Title</h1>\n<p>Hello <em>world</em> and <strong>bold</strong> and <del>gone</del> and <code>code</code>.</p>\n"},
+		{"- one\n- two\n  - nested\n\n1. a\n2. b",
+			"<ul>\n<li>one</li>\n<li>two\n<ul>\n<li>nested</li>\n</ul></li>\n</ul>\n<ol>\n<li>a</li>\n<li>b</li>\n</ol>\n"},
+		{"3. three\n4. four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>\n"},
+		{"> quote\n> more", "<blockquote>\n<p>quote\nmore</p>\n</blockquote>\n"},
+		{"```go\nfmt.Println(\"<b>\")\n```",
+			"<pre><code class=\"language-go\">fmt.Println(&#34;&lt;b&gt;&#34;)\n</code></pre>\n"},
+		{"---", "<hr>\n"},
+		{`[link](https://example.com "title") and <https://x.org/a>`,
+			`<p><a href="https://example.com" rel="nofollow ugc noopener">link</a> and ` +
+				`<a href="https://x.org/a" rel="nofollow ugc noopener">https://x.org/a</a></p>` + "\n"},
+		{"![alt *x*](/api/files/a.png) ![off](https://evil.com/x.png)",
+			`<p><img src="/api/files/a.png" alt="alt x"> off</p>` + "\n"},
+		{"snake_case_word and _em_", "<p>snake_case_word and <em>em</em></p>\n"},
+		{`\*not em\*`, "<p>*not em*</p>\n"},
+		{"a\r\nb\x00", "<p>a\nb�</p>\n"},
+	}
+	for _, tc := range cases {
+		if got := Render(tc.in, testOpts); got != tc.want {
+			t.Errorf("Render(%q)\n got %q\nwant %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestRenderPlain(t *testing.T) {
+	got := RenderPlain("a <b>\nc\n\n\nd & *e*")
+	if want := "<p>a &lt;b&gt;<br>\nc</p>\n<p>d &amp; *e*</p>\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// xssFixtures are inputs that try to get markup, script or an off-site
+// image into the output.
+var xssFixtures = []string{
+	`<script>alert(1)</script>`,
+	`<img src=x onerror=alert(1)>`,
+	`<a href="javascript:alert(1)">x</a>`,
+	`<svg/onload=alert(1)>`,
+	`<iframe src="https://evil.com"></iframe>`,
+	`[x](javascript:alert(1))`,
+	`[x](JaVaScRiPt:alert(1))`,
+	"[x](java\tscript:alert(1))",
+	"[x](java\x00script:alert(1))",
+	`[x](  javascript:alert(1))`,
+	`[x](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)`,
+	`[x](vbscript:msgbox(1))`,
+	`[x](//evil.com/steal)`,
+	`[x](\\evil.com)`,
+	`[x](https://ok.com/"onmouseover="alert(1))`,
+	`[x](https://ok.com/'><script>alert(1)</script>)`,
+	`[x](<javascript:alert(1)>)`,
+	`<javascript:alert(1)>`,
+	`<data:text/html,<script>alert(1)</script>>`,
+	`![x](https://evil.com/track.png)`,
+	`![x](javascript:alert(1))`,
+	`![" onerror="alert(1)](/api/files/a.png)`,
+	`![x](/api/files/a.png" onerror="alert(1))`,
+	"```\"><script>alert(1)</script>\n```",
+	"```js onload=alert(1)\nx\n```",
+	"# <script>alert(1)</script>",
+	"- <b onclick=alert(1)>x</b>",
+	"> <style>body{display:none}</style>",
+	"`<script>`",
+	"**<script>**alert(1)",
+	"[<img src=x onerror=alert(1)>](https://ok.com)",
+	"[[nested](javascript:alert(1))](https://ok.com)",
+	"&lt;script&gt; &#x3C;script&#x3E;",
+}
+
+var (
+	tagRe  = regexp.MustCompile(`<(/?)([a-z0-9]+)((?: [a-z]+="[^"<>]*")*)>`)
+	attrRe = regexp.MustCompile(` ([a-z]+)="([^"]*)"`)
+	hrefRe = regexp.MustCompile(`^(https?://[^/]|mailto:.|/[^/]|/$|#)`)
+	// allowedTags maps each tag the renderer may emit to its attributes.
+	allowedTags = map[string][]string{
+		"p": nil, "br": nil, "hr": nil, "h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+		"em": nil, "strong": nil, "del": nil, "code": {"class"}, "pre": nil, "blockquote": nil,
+		"ul": nil, "ol": {"start"}, "li": nil, "a": {"href", "rel"}, "img": {"src", "alt"},
+	}
+)
+
+// checkSafe fails t unless out consists of escaped text and allowed tags with
+// allowed attributes, links are http(s), mailto or same-site, and images
+// are platform files.
+func checkSafe(t *testing.T, in, out string) {
+	t.Helper()
+	rest := tagRe.ReplaceAllStringFunc(out, func(tag string) string {
+		m := tagRe.FindStringSubmatch(tag)
+		attrs, ok := allowedTags[m[2]]
+		if !ok {
+			t.Errorf("%q: tag <%s> in %q", in, m[2], out)
+		}
+		for _, a := range attrRe.FindAllStringSubmatch(m[3], -1) {
+			name, value := a[1], a[2]
+			if !strings.Contains(strings.Join(attrs, " "), name) {
+				t.Errorf("%q: attribute %s on <%s> in %q", in, name, m[2], out)
+			}
+			switch {
+			case name == "href" && !hrefRe.MatchString(value):
+				t.Errorf("%q: href %q", in, value)
+			case name == "src" && !strings.HasPrefix(value, "/api/files/"):
+				t.Errorf("%q: src %q", in, value)
+			}
+		}
+		return ""
+	})
+	if strings.ContainsAny(rest, `<>"`) {
+		t.Errorf("%q: unescaped markup in %q", in, out)
+	}
+}
+
+func TestRenderXSS(t *testing.T) {
+	deep := []string{
+		strings.Repeat("> ", 100) + "<script>",
+		strings.Repeat("- ", 100) + "[x](javascript:1)",
+		strings.Repeat("[", 1000) + "x" + strings.Repeat("](javascript:1)", 1000),
+	}
+	for _, in := range append(xssFixtures, deep...) {
+		checkSafe(t, in, Render(in, testOpts))
+		checkSafe(t, in, RenderPlain(in))
+	}
+	// Without ImageAllowed no image is embedded
+	if out := Render("![x](/api/files/a.png)", Options{}); strings.Contains(out, "<img") {
+		t.Errorf("image embedded without ImageAllowed: %q", out)
+	}
+}
+
+func FuzzRender(f *testing.F) {
+	for _, in := range xssFixtures {
+		f.Add(in)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		checkSafe(t, in, Render(in, testOpts))
+	})
+}
+
+func TestSafeURL(t *testing.T) {
+	cases := map[string]bool{
+		"https://example.com/a?b=c#d": true,
+		"HTTP://EXAMPLE.COM":          true,
+		"mailto:a@example.com":        true,
+		"/api/posts/1":                true,
+		"#section":                    true,
+		"":                            false,
+		"https://":                    false,
+		"mailto:":                     false,
+		"//evil.com":                  false,
+		"javascript:alert(1)":         false,
+		"JAVASCRIPT:alert(1)":         false,
+		"java\tscript:alert(1)":       false,
+		"data:text/html,x":            false,
+		"vbscript:x":                  false,
+		"ftp://example.com":           false,
+		"relative/path":               false,
+		"https://ok.com/a b":          false,
+		"https://ok.com/\x7f":         false,
+		"/\\evil.com":                 false,
+		"https://ok.com/" + strings.Repeat("a", 2048): false,
+	}
+	for in, want := range cases {
+		if _, got := safeURL(in); got != want {
+			t.Errorf("safeURL(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -32,6 +32,16 @@ func NewLimiter(r rate.Limit, burst int) *Limiter {
 	return l
 }
 
+// PerMinute returns the sustained number of requests allowed per key per minute.
+func (l *Limiter) PerMinute() int {
+	return int(float64(l.rate) * 60)
+}
+
+// Burst returns how many requests a key may make at once.
+func (l *Limiter) Burst() int {
+	return l.burst
+}
+
 // Allow checks whether a request for the given key is allowed.
 func (l *Limiter) Allow(key string) bool {
 	l.mu.Lock()
@@ -46,11 +56,17 @@ func (l *Limiter) Allow(key string) bool {
 }
 
 // cleanup evicts entries idle for more than 30 minutes, every 10 minutes.
+// Slow limiters (e.g. per day) keep an entry until its bucket has refilled,
+// so going idle doesn't reset a key's allowance.
 func (l *Limiter) cleanup() {
+	idle := 30 * time.Minute
+	if refill := time.Duration(float64(l.burst) / float64(l.rate) * float64(time.Second)); refill > idle {
+		idle = refill
+	}
 	ticker := time.NewTicker(10 * time.Minute)
 	for range ticker.C {
 		l.mu.Lock()
-		cutoff := time.Now().Add(-30 * time.Minute)
+		cutoff := time.Now().Add(-idle)
 		for k, e := range l.entries {
 			if e.lastSeen.Before(cutoff) {
 				delete(l.entries, k)
@@ -76,4 +92,12 @@ var (
 
 	// DesignUploadVerified: 30 req/min, burst 10, keyed by agent_id.
 	DesignUploadVerified = NewLimiter(rate.Limit(30.0/60.0), 10)
+
+	// KeyCheck: 10 req/min, burst 5, keyed by IP. Unauthenticated key
+	// lookups must not become a registry enumeration oracle.
+	KeyCheck = NewLimiter(rate.Limit(10.0/60.0), 5)
+
+	// Feedback: 5 per day, keyed by IP (agent_id with a JWT). The feedback
+	// endpoint needs no auth, so it is the cheapest thing to flood.
+	Feedback = NewLimiter(rate.Limit(5.0/86400.0), 5)
 )
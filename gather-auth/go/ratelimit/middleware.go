@@ -1,6 +1,7 @@
 package ratelimit
 
 import (
+	"context"
 	"net"
 	"strings"
 
@@ -45,7 +46,24 @@ func CheckDesignUpload(agentID string, verified bool) error {
 	return nil
 }
 
+// CheckKeyLookup checks the KeyCheck limiter for the given IP.
+func CheckKeyLookup(ip string) error {
+	if !KeyCheck.Allow(ip) {
+		return huma.Error429TooManyRequests("Key check rate limit exceeded. Try again in a minute.")
+	}
+	return nil
+}
+
+// CheckFeedback checks the Feedback limiter for the given IP or agent key.
+func CheckFeedback(key string) error {
+	if !Feedback.Allow(key) {
+		return huma.Error429TooManyRequests("Feedback limit reached (5 per day). Thanks — try again tomorrow.")
+	}
+	return nil
+}
+
 // IPRateLimitMiddleware returns a Huma middleware that rate-limits all requests by client IP.
+// It also stores the IP in the request context for handlers (see ClientIP).
 func IPRateLimitMiddleware(ctx huma.Context, next func(huma.Context)) {
 	ip := clientIP(ctx)
 	if !PublicRead.Allow(ip) {
@@ -53,7 +71,16 @@ func IPRateLimitMiddleware(ctx huma.Context, next func(huma.Context)) {
 		ctx.BodyWriter().Write([]byte(`{"title":"Too Many Requests","status":429,"detail":"Rate limit exceeded. Try again shortly."}`))
 		return
 	}
-	next(ctx)
+	next(huma.WithValue(ctx, clientIPKey{}, ip))
+}
+
+type clientIPKey struct{}
+
+// ClientIP returns the client IP recorded by IPRateLimitMiddleware, or ""
+// when the middleware didn't run.
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
 }
 
 // clientIP extracts the client IP from X-Real-IP (set by nginx to $remote_addr, not spoofable).
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Quota classes — each agent gets an hourly budget per class.
+const (
+	QuotaRead      = "read"
+	QuotaWrite     = "write"
+	QuotaExpensive = "expensive"
+)
+
+// QuotaClasses lists the classes in display order.
+var QuotaClasses = []string{QuotaRead, QuotaWrite, QuotaExpensive}
+
+// QuotaWindow is the quota accounting period. Windows are aligned to the
+// clock (top of the hour) so reset times are predictable.
+const QuotaWindow = time.Hour
+
+// Unlimited is the limit value that disables a quota.
+const Unlimited = -1
+
+// QuotaStatus is an agent's standing in one quota class.
+type QuotaStatus struct {
+	Class     string    `json:"class"`
+	Limit     int       `json:"limit" doc:"Requests allowed per hour (-1 = unlimited)"`
+	Used      int       `json:"used"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+type quotaWindow struct {
+	start time.Time
+	used  int
+}
+
+// QuotaCounter counts requests per (key, class) in fixed hourly windows.
+type QuotaCounter struct {
+	mu      sync.Mutex
+	windows map[string]*quotaWindow
+}
+
+// NewQuotaCounter creates an empty counter.
+func NewQuotaCounter() *QuotaCounter {
+	q := &QuotaCounter{windows: make(map[string]*quotaWindow)}
+	go q.cleanup()
+	return q
+}
+
+// Consume records one request against the quota and reports whether it is
+// within limit. Rejected requests are not counted.
+func (q *QuotaCounter) Consume(key, class string, limit int) (QuotaStatus, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	w := q.window(key, class)
+	if limit != Unlimited && w.used >= limit {
+		return q.status(w, class, limit), false
+	}
+	w.used++
+	return q.status(w, class, limit), true
+}
+
+// Peek returns the current status without consuming.
+func (q *QuotaCounter) Peek(key, class string, limit int) QuotaStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.status(q.window(key, class), class, limit)
+}
+
+// window returns the current window for key/class, starting a new one if the
+// previous hour has passed. Caller holds q.mu.
+func (q *QuotaCounter) window(key, class string) *quotaWindow {
+	now := time.Now().UTC().Truncate(QuotaWindow)
+	k := key + "|" + class
+	w, ok := q.windows[k]
+	if !ok || !w.start.Equal(now) {
+		w = &quotaWindow{start: now}
+		q.windows[k] = w
+	}
+	return w
+}
+
+func (q *QuotaCounter) status(w *quotaWindow, class string, limit int) QuotaStatus {
+	remaining := Unlimited
+	if limit != Unlimited {
+		remaining = limit - w.used
+		if remaining < 0 {
+			remaining = 0
+		}
+	}
+	return QuotaStatus{
+		Class:     class,
+		Limit:     limit,
+		Used:      w.used,
+		Remaining: remaining,
+		ResetAt:   w.start.Add(QuotaWindow),
+	}
+}
+
+// cleanup drops windows from previous hours, every 10 minutes.
+func (q *QuotaCounter) cleanup() {
+	ticker := time.NewTicker(10 * time.Minute)
+	for range ticker.C {
+		q.mu.Lock()
+		current := time.Now().UTC().Truncate(QuotaWindow)
+		for k, w := range q.windows {
+			if w.start.Before(current) {
+				delete(q.windows, k)
+			}
+		}
+		q.mu.Unlock()
+	}
+}
+
+// AgentQuotas tracks per-agent hourly usage, keyed by agent_id.
+var AgentQuotas = NewQuotaCounter()
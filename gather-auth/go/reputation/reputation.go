@@ -0,0 +1,208 @@
+package reputation
+
+import (
+	"math"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+// Weights controls the agent reputation formula. Stored in platform_config
+// (reputation_weight_* and reputation_half_life_days) so admins can tune
+// them without a deploy.
+type Weights struct {
+	Reviews      float64 // per completed challenge-verified review
+	Proofs       float64 // per verified proof attached to the agent's reviews
+	Votes        float64 // per net vote received on the agent's posts
+	Tips         float64 // per tip received (count, not amount — reputation can't be bought)
+	HalfLifeDays float64 // signal weight halves every HalfLifeDays
+}
+
+var DefaultWeights = Weights{
+	Reviews:      3,
+	Proofs:       2,
+	Votes:        1,
+	Tips:         2,
+	HalfLifeDays: 90,
+}
+
+// Scale is the raw signal at which reputation reaches ~63 (1 - 1/e of 100).
+const Scale = 25.0
+
+// VoteSignal is the net vote total on one post, decayed by the post's age.
+type VoteSignal struct {
+	Net     int
+	Created time.Time
+}
+
+// Inputs holds the timestamped signals for one agent.
+type Inputs struct {
+	ChallengeReviews []time.Time
+	VerifiedProofs   []time.Time
+	PostVotes        []VoteSignal
+	TipsReceived     []time.Time
+}
+
+// decay returns the exponential decay factor for a signal at time t.
+// Zero timestamps (records created before the field existed) are not decayed.
+func decay(t, now time.Time, halfLifeDays float64) float64 {
+	if t.IsZero() || halfLifeDays <= 0 {
+		return 1
+	}
+	ageDays := now.Sub(t).Hours() / 24
+	if ageDays <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, ageDays/halfLifeDays)
+}
+
+// Calculate computes a 0-100 reputation score. It is a pure function of its
+// inputs so the incremental (per-event) and nightly batch paths always agree:
+//
+//	raw   = Σ w.Reviews·d(review) + Σ w.Proofs·d(proof)
+//	      + Σ w.Votes·net(post)·d(post) + Σ w.Tips·d(tip)
+//	score = 100 · (1 − e^(−max(raw, 0) / Scale))
+//
+// where d(x) = 0.5^(age_days / w.HalfLifeDays).
+func Calculate(in Inputs, w Weights, now time.Time) float64 {
+	raw := 0.0
+	for _, t := range in.ChallengeReviews {
+		raw += w.Reviews * decay(t, now, w.HalfLifeDays)
+	}
+	for _, t := range in.VerifiedProofs {
+		raw += w.Proofs * decay(t, now, w.HalfLifeDays)
+	}
+	for _, v := range in.PostVotes {
+		raw += w.Votes * float64(v.Net) * decay(v.Created, now, w.HalfLifeDays)
+	}
+	for _, t := range in.TipsReceived {
+		raw += w.Tips * decay(t, now, w.HalfLifeDays)
+	}
+	if raw <= 0 {
+		return 0
+	}
+	score := 100 * (1 - math.Exp(-raw/Scale))
+	return math.Round(score*100) / 100
+}
+
+// LoadWeights reads the formula weights from platform_config. A weight field
+// the collection has is used as stored, so 0 switches that signal off; a
+// missing field or a negative value falls back to DefaultWeights, as does a
+// half-life that isn't positive.
+func LoadWeights(app *pocketbase.PocketBase) Weights {
+	w := DefaultWeights
+	records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil)
+	if err != nil || len(records) == 0 {
+		return w
+	}
+	cfg := records[0]
+	weight := func(field string, def float64) float64 {
+		if cfg.Collection().Fields.GetByName(field) == nil {
+			return def
+		}
+		if v := cfg.GetFloat(field); v >= 0 {
+			return v
+		}
+		return def
+	}
+	w.Reviews = weight("reputation_weight_reviews", w.Reviews)
+	w.Proofs = weight("reputation_weight_proofs", w.Proofs)
+	w.Votes = weight("reputation_weight_votes", w.Votes)
+	w.Tips = weight("reputation_weight_tips", w.Tips)
+	if v := cfg.GetFloat("reputation_half_life_days"); v > 0 {
+		w.HalfLifeDays = v
+	}
+	return w
+}
+
+// CollectInputs gathers the reputation signals for an agent.
+func CollectInputs(app *pocketbase.PocketBase, agentID string) Inputs {
+	var in Inputs
+	params := map[string]any{"aid": agentID}
+
+	reviews, err := app.FindRecordsByFilter("reviews",
+		"agent_id = {:aid} && status = 'complete' && needs_moderation != true", "", 0, 0, params)
+	if err == nil {
+		var proofIDs []string
+		for _, r := range reviews {
+			if r.GetString("challenge") != "" {
+				in.ChallengeReviews = append(in.ChallengeReviews, r.GetDateTime("created").Time())
+			}
+			if proofID := r.GetString("proof"); proofID != "" {
+				proofIDs = append(proofIDs, proofID)
+			}
+		}
+		if len(proofIDs) > 0 {
+			proofs, err := app.FindRecordsByIds("proofs", proofIDs)
+			if err == nil {
+				verified := map[string]time.Time{}
+				for _, p := range proofs {
+					if p.GetBool("verified") {
+						verified[p.Id] = p.GetDateTime("created").Time()
+					}
+				}
+				// Per review, so a proof shared by two reviews counts twice
+				for _, id := range proofIDs {
+					if created, ok := verified[id]; ok {
+						in.VerifiedProofs = append(in.VerifiedProofs, created)
+					}
+				}
+			}
+		}
+	}
+
+	posts, err := app.FindRecordsByFilter("posts", "author_id = {:aid}", "", 0, 0, params)
+	if err == nil {
+		for _, p := range posts {
+			if score := int(p.GetFloat("score")); score != 0 {
+				in.PostVotes = append(in.PostVotes, VoteSignal{
+					Net:     score,
+					Created: p.GetDateTime("created").Time(),
+				})
+			}
+		}
+	}
+
+	tips, err := app.FindRecordsByFilter("tips", "to_agent = {:aid}", "", 0, 0, params)
+	if err == nil {
+		for _, t := range tips {
+			in.TipsReceived = append(in.TipsReceived, t.GetDateTime("created").Time())
+		}
+	}
+
+	return in
+}
+
+// UpdateAgentReputation recomputes and stores reputation_score for one agent.
+// Called after events that change an agent's signals (review submitted,
+// vote on their post, tip received).
+func UpdateAgentReputation(app *pocketbase.PocketBase, agentID string) {
+	updateAgent(app, agentID, LoadWeights(app), time.Now())
+}
+
+func updateAgent(app *pocketbase.PocketBase, agentID string, w Weights, now time.Time) {
+	agent, err := app.FindRecordById("agents", agentID)
+	if err != nil {
+		return
+	}
+	score := Calculate(CollectInputs(app, agentID), w, now)
+	if agent.GetFloat("reputation_score") == score {
+		return
+	}
+	agent.Set("reputation_score", score)
+	app.Save(agent)
+}
+
+// UpdateAllReputations recomputes reputation_score for every agent. Run
+// nightly so time decay is applied even to agents with no new events.
+func UpdateAllReputations(app *pocketbase.PocketBase) {
+	agents, err := app.FindRecordsByFilter("agents", "id != ''", "", 0, 0, nil)
+	if err != nil {
+		return
+	}
+	w := LoadWeights(app)
+	now := time.Now()
+	for _, a := range agents {
+		updateAgent(app, a.Id, w, now)
+	}
+}
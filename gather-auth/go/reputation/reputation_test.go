@@ -0,0 +1,97 @@
+package reputation
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// expected is the formula's score for a raw signal, rounded like Calculate.
+func expected(raw float64) float64 {
+	return math.Round(100*(1-math.Exp(-raw/Scale))*100) / 100
+}
+
+func TestCalculate(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	halfLife := now.Add(-90 * 24 * time.Hour)
+	w := DefaultWeights
+
+	cases := []struct {
+		name string
+		in   Inputs
+		want float64
+	}{
+		{"no signals", Inputs{}, 0},
+		{"one review today", Inputs{ChallengeReviews: []time.Time{now}}, expected(3)},
+		{"one review one half-life ago", Inputs{ChallengeReviews: []time.Time{halfLife}}, expected(1.5)},
+		{"review two half-lives ago", Inputs{ChallengeReviews: []time.Time{now.Add(-180 * 24 * time.Hour)}}, expected(0.75)},
+		{"undated review isn't decayed", Inputs{ChallengeReviews: []time.Time{{}}}, expected(3)},
+		{"future review isn't amplified", Inputs{ChallengeReviews: []time.Time{now.Add(48 * time.Hour)}}, expected(3)},
+		{"proof", Inputs{VerifiedProofs: []time.Time{now}}, expected(2)},
+		{"tips count, not amount", Inputs{TipsReceived: []time.Time{now, now}}, expected(4)},
+		{"net votes", Inputs{PostVotes: []VoteSignal{{Net: 5, Created: now}, {Net: -2, Created: now}}}, expected(3)},
+		{"decayed votes", Inputs{PostVotes: []VoteSignal{{Net: 4, Created: halfLife}}}, expected(2)},
+		{"downvoted agent floors at zero", Inputs{PostVotes: []VoteSignal{{Net: -50, Created: now}}}, 0},
+		{"downvotes offset other signals", Inputs{
+			ChallengeReviews: []time.Time{now},
+			PostVotes:        []VoteSignal{{Net: -2, Created: now}},
+		}, expected(1)},
+		{"all signals", Inputs{
+			ChallengeReviews: []time.Time{now, halfLife},
+			VerifiedProofs:   []time.Time{now},
+			PostVotes:        []VoteSignal{{Net: 3, Created: now}},
+			TipsReceived:     []time.Time{halfLife},
+		}, expected(3 + 1.5 + 2 + 3 + 1)},
+	}
+	for _, tc := range cases {
+		if got := Calculate(tc.in, w, now); got != tc.want {
+			t.Errorf("%s: Calculate = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCalculateScale(t *testing.T) {
+	now := time.Now()
+	// Scale's worth of raw signal reaches 1 - 1/e of 100
+	in := Inputs{}
+	for i := 0; i < int(Scale); i++ {
+		in.PostVotes = append(in.PostVotes, VoteSignal{Net: 1, Created: now})
+	}
+	if got := Calculate(in, DefaultWeights, now); got != 63.21 {
+		t.Errorf("score at Scale = %v, want 63.21", got)
+	}
+
+	// More signal always helps, and the score never reaches 100
+	prev := 0.0
+	for n := 1; n <= 2000; n *= 2 {
+		in := Inputs{PostVotes: []VoteSignal{{Net: n, Created: now}}}
+		got := Calculate(in, DefaultWeights, now)
+		if got < prev || got > 100 {
+			t.Fatalf("net %d votes: score %v after %v", n, got, prev)
+		}
+		prev = got
+	}
+}
+
+func TestCalculateWeights(t *testing.T) {
+	now := time.Now()
+	in := Inputs{ChallengeReviews: []time.Time{now.Add(-30 * 24 * time.Hour)}, TipsReceived: []time.Time{now}}
+
+	w := DefaultWeights
+	w.Tips = 0
+	if got, want := Calculate(in, w, now), expected(3*math.Pow(0.5, 30.0/90)); got != want {
+		t.Errorf("tips weighted 0: %v, want %v", got, want)
+	}
+
+	w = DefaultWeights
+	w.HalfLifeDays = 0 // no decay
+	if got, want := Calculate(in, w, now), expected(3+2); got != want {
+		t.Errorf("no half-life: %v, want %v", got, want)
+	}
+
+	w = DefaultWeights
+	w.HalfLifeDays = 30
+	if got, want := Calculate(in, w, now), expected(1.5+2); got != want {
+		t.Errorf("30-day half-life: %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,145 @@
+package reputation
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func newTestApp(t *testing.T) *pocketbase.PocketBase {
+	t.Helper()
+	app := pocketbase.NewWithConfig(pocketbase.Config{DefaultDataDir: t.TempDir()})
+	if err := app.Bootstrap(); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+	t.Cleanup(func() { app.ResetBootstrapState() })
+	return app
+}
+
+// addCollection creates a base collection with a created timestamp. Fields
+// are text unless suffixed ":number" or ":bool".
+func addCollection(t *testing.T, app *pocketbase.PocketBase, name string, fields ...string) {
+	t.Helper()
+	c := core.NewBaseCollection(name)
+	for _, f := range fields {
+		fieldName, kind, _ := strings.Cut(f, ":")
+		switch kind {
+		case "number":
+			c.Fields.Add(&core.NumberField{Name: fieldName})
+		case "bool":
+			c.Fields.Add(&core.BoolField{Name: fieldName})
+		default:
+			c.Fields.Add(&core.TextField{Name: fieldName})
+		}
+	}
+	c.Fields.Add(&core.AutodateField{Name: "created", OnCreate: true})
+	if err := app.Save(c); err != nil {
+		t.Fatalf("create %s collection: %v", name, err)
+	}
+}
+
+func addRecord(t *testing.T, app *pocketbase.PocketBase, collection string, values map[string]any) *core.Record {
+	t.Helper()
+	c, err := app.FindCollectionByNameOrId(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := core.NewRecord(c)
+	for k, v := range values {
+		rec.Set(k, v)
+	}
+	if err := app.Save(rec); err != nil {
+		t.Fatalf("save %s record: %v", collection, err)
+	}
+	return rec
+}
+
+func TestLoadWeights(t *testing.T) {
+	app := newTestApp(t)
+	if w := LoadWeights(app); w != DefaultWeights {
+		t.Errorf("without platform_config: %+v", w)
+	}
+
+	// Only some weights are configured; the rest keep their defaults
+	addCollection(t, app, "platform_config", "reputation_weight_reviews:number", "reputation_weight_votes:number",
+		"reputation_weight_tips:number", "reputation_half_life_days:number")
+	cfg := addRecord(t, app, "platform_config", map[string]any{
+		"reputation_weight_reviews": 5,
+		"reputation_weight_votes":   0, // switched off
+		"reputation_weight_tips":    -1,
+		"reputation_half_life_days": 0,
+	})
+	want := DefaultWeights
+	want.Reviews = 5
+	want.Votes = 0
+	if w := LoadWeights(app); w != want {
+		t.Errorf("got %+v, want %+v", w, want)
+	}
+
+	cfg.Set("reputation_weight_reviews", 0)
+	cfg.Set("reputation_half_life_days", 30)
+	if err := app.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	want.Reviews, want.HalfLifeDays = 0, 30
+	w := LoadWeights(app)
+	if w != want {
+		t.Errorf("got %+v, want %+v", w, want)
+	}
+
+	// A zero weight drops the signal from the score
+	now := time.Now()
+	in := Inputs{ChallengeReviews: []time.Time{now}, PostVotes: []VoteSignal{{Net: 10, Created: now}}}
+	if got := Calculate(in, w, now); got != 0 {
+		t.Errorf("score with reviews and votes weighted 0: %v", got)
+	}
+}
+
+func TestCollectInputs(t *testing.T) {
+	app := newTestApp(t)
+	addCollection(t, app, "reviews", "agent_id", "status", "challenge", "proof", "needs_moderation:bool")
+	addCollection(t, app, "proofs", "verified:bool")
+	addCollection(t, app, "posts", "author_id", "score:number")
+	addCollection(t, app, "tips", "to_agent")
+
+	for i := 0; i < 10; i++ {
+		proof := addRecord(t, app, "proofs", map[string]any{"verified": i%2 == 0}).Id
+		addRecord(t, app, "reviews", map[string]any{"agent_id": "a1", "status": "complete", "challenge": "c", "proof": proof})
+	}
+	addRecord(t, app, "reviews", map[string]any{"agent_id": "a1", "status": "complete"})
+	addRecord(t, app, "reviews", map[string]any{"agent_id": "a1", "status": "complete", "proof": "gone"})
+	addRecord(t, app, "reviews", map[string]any{"agent_id": "a1", "status": "complete", "challenge": "c", "needs_moderation": true})
+	addRecord(t, app, "reviews", map[string]any{"agent_id": "a2", "status": "complete", "challenge": "c"})
+	addRecord(t, app, "posts", map[string]any{"author_id": "a1", "score": 4})
+	addRecord(t, app, "posts", map[string]any{"author_id": "a1", "score": 0})
+	addRecord(t, app, "tips", map[string]any{"to_agent": "a1"})
+
+	var queries []string
+	db := app.DB().(*dbx.DB)
+	prev := db.QueryLogFunc
+	db.QueryLogFunc = func(_ context.Context, _ time.Duration, sql string, _ *sql.Rows, _ error) {
+		queries = append(queries, sql)
+	}
+	in := CollectInputs(app, "a1")
+	db.QueryLogFunc = prev
+
+	if len(in.ChallengeReviews) != 10 || len(in.VerifiedProofs) != 5 || len(in.PostVotes) != 1 || len(in.TipsReceived) != 1 {
+		t.Errorf("%d challenge reviews, %d verified proofs, %d voted posts, %d tips",
+			len(in.ChallengeReviews), len(in.VerifiedProofs), len(in.PostVotes), len(in.TipsReceived))
+	}
+	proofQueries := 0
+	for _, q := range queries {
+		if strings.Contains(q, "proofs") {
+			proofQueries++
+		}
+	}
+	if proofQueries != 1 {
+		t.Errorf("%d proof queries for 11 reviews with proofs, want 1:\n%s", proofQueries, strings.Join(queries, "\n"))
+	}
+}
@@ -0,0 +1,178 @@
+// Package scrub redacts secrets and personal details from text agents submit
+// for public display, such as review CLI transcripts.
+//
+// Each match is replaced with a typed placeholder, [REDACTED:aws_key], so a
+// reader can still follow the transcript. Patterns that are unambiguous on
+// their own (AWS access key IDs, PEM private keys, provider token prefixes,
+// JWTs) always apply. Long hex and base64 strings look like secrets but are
+// just as often hashes or IDs, so they are redacted only when a key-like
+// word ("token", "secret", "api_key" ...) appears shortly before them on
+// the same line.
+package scrub
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// contextWindow is how far before a match, on the same line, a rule's
+// keyword must appear.
+const contextWindow = 40
+
+// rule is one kind of sensitive text.
+type rule struct {
+	kind    string
+	pattern *regexp.Regexp
+	// group is the submatch that is replaced; 0 replaces the whole match.
+	group int
+	// context, if set, must match the text shortly before the match.
+	context *regexp.Regexp
+	// keep, if set, leaves matches it accepts alone.
+	keep func(match string) bool
+}
+
+var secretContext = regexp.MustCompile(`(?i)(api[_-]?key|access[_-]?key|secret|token|passw(or)?d|passwd|credential|\bauth\b|authorization|bearer|signature|private[_-]?key|session[_-]?(id|key))`)
+
+// rules run in order, each over the output of the one before; placeholders
+// contain nothing a later rule matches.
+var rules = []rule{
+	{kind: "private_key", pattern: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`)},
+	{kind: "aws_key", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "github_token", pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{kind: "slack_token", pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{kind: "stripe_key", pattern: regexp.MustCompile(`\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}\b`)},
+	{kind: "api_key", pattern: regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`)},
+	{kind: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{kind: "bearer_token", pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{16,}=*)`), group: 1},
+	{kind: "hex_secret", pattern: regexp.MustCompile(`\b[0-9a-fA-F]{32,}\b`), context: secretContext},
+	{kind: "base64_secret", pattern: regexp.MustCompile(`[A-Za-z0-9+/_-]{24,}={0,2}`), context: secretContext, keep: notMixed},
+	{kind: "email", pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`), keep: harmlessEmail},
+	{kind: "home_path", pattern: regexp.MustCompile(`(?:/home/|/Users/|[A-Za-z]:\\Users\\)[^/\\\s"'` + "`" + `:]+`)},
+}
+
+// notMixed leaves strings without upper case, lower case and digits alone:
+// paths, identifiers and words are rarely all three, encoded keys usually
+// are.
+func notMixed(s string) bool {
+	var upper, lower, digit bool
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return !(upper && lower && digit)
+}
+
+// harmlessEmail leaves documentation addresses and git remotes alone.
+func harmlessEmail(s string) bool {
+	local, domain, _ := strings.Cut(strings.ToLower(s), "@")
+	if local == "git" {
+		return true
+	}
+	for _, d := range []string{"example.com", "example.org", "example.net"} {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts is the number of redactions per kind.
+type Counts map[string]int
+
+// Total is the number of redactions of every kind.
+func (c Counts) Total() int {
+	n := 0
+	for _, v := range c {
+		n += v
+	}
+	return n
+}
+
+// Add adds other's redactions to c.
+func (c Counts) Add(other Counts) {
+	for k, v := range other {
+		c[k] += v
+	}
+}
+
+// String summarizes the counts, e.g. "2 email, 1 aws_key", largest first.
+func (c Counts) String() string {
+	kinds := make([]string, 0, len(c))
+	for k := range c {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if c[kinds[i]] != c[kinds[j]] {
+			return c[kinds[i]] > c[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	parts := make([]string, len(kinds))
+	for i, k := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", c[k], k)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Placeholder is what a redacted match of kind is replaced with.
+func Placeholder(kind string) string {
+	return "[REDACTED:" + kind + "]"
+}
+
+// Text returns s with secrets and personal details replaced by placeholders,
+// and how many of each kind it replaced.
+func Text(s string) (string, Counts) {
+	counts := Counts{}
+	for _, r := range rules {
+		s = r.apply(s, counts)
+	}
+	return s, counts
+}
+
+func (r rule) apply(s string, counts Counts) string {
+	matches := r.pattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[2*r.group], m[2*r.group+1]
+		if start < 0 || start < last {
+			continue
+		}
+		if r.keep != nil && r.keep(s[start:end]) {
+			continue
+		}
+		if r.context != nil && !r.context.MatchString(precedingText(s, m[0])) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Placeholder(r.kind))
+		last = end
+		counts[r.kind]++
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// precedingText is up to contextWindow bytes before offset, within its line.
+func precedingText(s string, offset int) string {
+	from := max(0, offset-contextWindow)
+	if nl := strings.LastIndexByte(s[from:offset], '\n'); nl >= 0 {
+		from += nl + 1
+	}
+	return s[from:offset]
+}
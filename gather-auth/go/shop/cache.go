@@ -0,0 +1,171 @@
+package shop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Catalog cache ---
+//
+// Gelato and CoinGecko lookups are cached in memory. An expired entry is
+// refetched on the next read; if that fetch fails, the last known value is
+// served with a stale flag rather than failing the request, so a Gelato
+// outage doesn't take the shop down; the refetch is then retried at most once
+// per staleRetryInterval so readers don't each wait out a timeout. Concurrent misses for one key share a
+// single fetch, so a burst of browsing agents costs one upstream call.
+// RefreshCatalog keeps the common entries warm in the background.
+
+const (
+	catalogTTL = 6 * time.Hour    // product UIDs and shipping availability
+	optionsTTL = time.Hour        // valid option values per product
+	priceTTL   = 30 * time.Minute // Gelato base prices
+	rateTTL    = 5 * time.Minute  // BCH/USD rate
+
+	staleRetryInterval = time.Minute
+)
+
+// Key prefixes of the Gelato catalog entries, dropped by InvalidateCatalogCache.
+const (
+	optionsKeyPrefix = "valid_options:"
+	uidKeyPrefix     = "uid:"
+	shipsToKeyPrefix = "ships_to:"
+	priceKeyPrefix   = "price_usd:"
+)
+
+var catalogKeyPrefixes = []string{optionsKeyPrefix, uidKeyPrefix, shipsToKeyPrefix, priceKeyPrefix}
+
+type cacheEntry struct {
+	data      interface{}
+	fetchedAt time.Time
+	failedAt  time.Time // last failed refetch, zero if none since fetchedAt
+}
+
+// cacheCall is a fetch in progress; other readers of the key wait on done.
+type cacheCall struct {
+	done chan struct{}
+	data interface{}
+	err  error
+}
+
+var (
+	cacheMu  sync.RWMutex
+	cache    = map[string]cacheEntry{}
+	inflight = map[string]*cacheCall{}
+)
+
+// getCached returns the value for key, fetching it if there is none younger
+// than ttl. If the fetch fails and an older value exists, that value is
+// returned with stale set.
+func getCached(key string, ttl time.Duration, fetchFn func() (interface{}, error)) (interface{}, bool, error) {
+	data, _, stale, err := getCachedAt(key, ttl, fetchFn)
+	return data, stale, err
+}
+
+// getCachedAt is getCached, also returning when the value was fetched.
+func getCachedAt(key string, ttl time.Duration, fetchFn func() (interface{}, error)) (interface{}, time.Time, bool, error) {
+	cacheMu.RLock()
+	entry, ok := cache[key]
+	cacheMu.RUnlock()
+
+	if ok && time.Since(entry.fetchedAt) < ttl {
+		return entry.data, entry.fetchedAt, false, nil
+	}
+	if ok && time.Since(entry.failedAt) < staleRetryInterval {
+		return entry.data, entry.fetchedAt, true, nil
+	}
+
+	data, err := fetchShared(key, fetchFn)
+	if err != nil {
+		if ok {
+			return entry.data, entry.fetchedAt, true, nil
+		}
+		return nil, time.Time{}, false, err
+	}
+	return data, time.Now(), false, nil
+}
+
+// fetchShared fetches key and caches the result, or waits for a fetch of the
+// same key that is already running. It never serves from the cache.
+func fetchShared(key string, fetchFn func() (interface{}, error)) (interface{}, error) {
+	cacheMu.Lock()
+	if c, ok := inflight[key]; ok {
+		cacheMu.Unlock()
+		<-c.done
+		return c.data, c.err
+	}
+	c := &cacheCall{done: make(chan struct{})}
+	inflight[key] = c
+	cacheMu.Unlock()
+
+	c.data, c.err = fetchFn()
+
+	cacheMu.Lock()
+	delete(inflight, key)
+	if c.err == nil {
+		cache[key] = cacheEntry{data: c.data, fetchedAt: time.Now()}
+	} else if entry, ok := cache[key]; ok {
+		entry.failedAt = time.Now()
+		cache[key] = entry
+	}
+	cacheMu.Unlock()
+	close(c.done)
+	return c.data, c.err
+}
+
+// InvalidateCatalogCache drops every cached Gelato lookup (options, product
+// UIDs, shipping availability and prices) so the next read fetches fresh
+// data. The BCH rate is kept. Returns the number of entries dropped.
+func InvalidateCatalogCache() int {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	n := 0
+	for key := range cache {
+		for _, prefix := range catalogKeyPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				delete(cache, key)
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+// RefreshCatalog refetches every product's options and menu price, whether
+// or not they have expired. An entry whose refetch fails keeps its last known
+// value. Does nothing without a Gelato API key.
+func RefreshCatalog(ctx context.Context) error {
+	if gelatoAPIKey() == "" {
+		return nil
+	}
+	var errs []error
+	for _, pid := range ProductOrder {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := fetchShared(optionsKeyPrefix+pid, func() (interface{}, error) {
+			return fetchValidOptions(pid)
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("%s options: %w", pid, err))
+		}
+
+		uid, err := ResolveGelatoUIDLive(pid, CatalogConfig[pid].ReferenceVariant)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s product UID: %w", pid, err))
+			continue
+		}
+		if uid == "" {
+			continue
+		}
+		if _, err := fetchShared(priceKeyPrefix+uid, func() (interface{}, error) {
+			return fetchProductPriceUSD(uid)
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("%s price: %w", pid, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,261 @@
+package shop
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeGelato answers product searches and price lookups for the mug
+// catalog, counting requests. While down is set every request fails.
+type fakeGelato struct {
+	calls atomic.Int32
+	down  atomic.Bool
+	sizes atomic.Value // []string of MugSize values in the catalog
+}
+
+func newFakeGelato(t *testing.T) *fakeGelato {
+	t.Helper()
+	f := &fakeGelato{}
+	f.sizes.Store([]string{"11-oz", "15-oz"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.calls.Add(1)
+		if f.down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/products:search"):
+			var req struct {
+				Offset int `json:"offset"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			type product struct {
+				ProductUID string            `json:"productUid"`
+				Attributes map[string]string `json:"attributes"`
+			}
+			products := []product{}
+			if req.Offset == 0 {
+				for _, size := range f.sizes.Load().([]string) {
+					products = append(products, product{ProductUID: "mug_" + size, Attributes: map[string]string{"MugSize": size}})
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]any{"products": products})
+		case strings.HasSuffix(r.URL.Path, "/prices"):
+			w.Write([]byte(`[{"price": 7.5}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	oldURL := gelatoCatalogURL
+	gelatoCatalogURL = srv.URL
+	t.Setenv("GELATO_API_KEY", "test")
+	resetCache()
+	t.Cleanup(func() {
+		gelatoCatalogURL = oldURL
+		srv.Close()
+		resetCache()
+	})
+	return f
+}
+
+func resetCache() {
+	cacheMu.Lock()
+	cache = map[string]cacheEntry{}
+	cacheMu.Unlock()
+}
+
+// expire backdates every cached entry past its TTL.
+func expire() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	for key, entry := range cache {
+		entry.fetchedAt = entry.fetchedAt.Add(-24 * time.Hour)
+		cache[key] = entry
+	}
+}
+
+func mugSizes(t *testing.T) ([]string, bool, error) {
+	t.Helper()
+	opts, stale, err := GetProductOptions("mug")
+	return opts["size"], stale, err
+}
+
+func TestCatalogCacheHitAndMiss(t *testing.T) {
+	f := newFakeGelato(t)
+
+	sizes, stale, err := mugSizes(t)
+	if err != nil || stale || strings.Join(sizes, ",") != "11-oz,15-oz" {
+		t.Fatalf("miss: sizes %v, stale %v, %v", sizes, stale, err)
+	}
+	misses := f.calls.Load()
+	if misses == 0 {
+		t.Fatal("miss didn't reach Gelato")
+	}
+
+	if _, stale, err := mugSizes(t); err != nil || stale {
+		t.Fatalf("hit: stale %v, %v", stale, err)
+	}
+	if n := f.calls.Load(); n != misses {
+		t.Errorf("hit made %d Gelato calls", n-misses)
+	}
+
+	// Once expired, the next read refetches
+	f.sizes.Store([]string{"11-oz"})
+	expire()
+	if sizes, stale, err := mugSizes(t); err != nil || stale || len(sizes) != 1 {
+		t.Errorf("after expiry: sizes %v, stale %v, %v", sizes, stale, err)
+	}
+	if f.calls.Load() == misses {
+		t.Error("expired entry served without a refetch")
+	}
+}
+
+func TestCatalogCacheServesStale(t *testing.T) {
+	f := newFakeGelato(t)
+	if _, _, err := mugSizes(t); err != nil {
+		t.Fatal(err)
+	}
+
+	f.down.Store(true)
+	expire()
+	before := f.calls.Load()
+	sizes, stale, err := mugSizes(t)
+	if err != nil || !stale || len(sizes) != 2 {
+		t.Fatalf("Gelato down: sizes %v, stale %v, %v; want the last known sizes", sizes, stale, err)
+	}
+	if f.calls.Load() == before {
+		t.Error("expired entry served stale without trying Gelato")
+	}
+
+	// Within staleRetryInterval of the failure, readers don't wait on Gelato
+	before = f.calls.Load()
+	if _, stale, err := mugSizes(t); err != nil || !stale {
+		t.Errorf("second read: stale %v, %v", stale, err)
+	}
+	if n := f.calls.Load(); n != before {
+		t.Errorf("second read made %d Gelato calls", n-before)
+	}
+
+	// Back up after the retry interval: fresh again
+	f.down.Store(false)
+	cacheMu.Lock()
+	entry := cache[optionsKeyPrefix+"mug"]
+	entry.failedAt = entry.failedAt.Add(-staleRetryInterval)
+	cache[optionsKeyPrefix+"mug"] = entry
+	cacheMu.Unlock()
+	if _, stale, err := mugSizes(t); err != nil || stale {
+		t.Errorf("Gelato back: stale %v, %v", stale, err)
+	}
+
+	// Without anything cached, an outage is an error
+	resetCache()
+	f.down.Store(true)
+	if _, _, err := mugSizes(t); err == nil {
+		t.Error("cold cache with Gelato down: no error")
+	}
+}
+
+func TestInvalidateCatalogCache(t *testing.T) {
+	f := newFakeGelato(t)
+	if _, err := ResolveGelatoUID("mug", map[string]string{"size": "11-oz"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := mugSizes(t); err != nil {
+		t.Fatal(err)
+	}
+	cacheMu.Lock()
+	cache["bch_rate"] = cacheEntry{data: 400.0, fetchedAt: time.Now()}
+	cacheMu.Unlock()
+
+	if n := InvalidateCatalogCache(); n != 2 {
+		t.Errorf("invalidated %d entries, want 2", n)
+	}
+	cacheMu.RLock()
+	_, rateKept := cache["bch_rate"]
+	cacheMu.RUnlock()
+	if !rateKept {
+		t.Error("BCH rate dropped with the catalog")
+	}
+
+	// The next read goes to Gelato, and sees catalog changes
+	f.sizes.Store([]string{"15-oz"})
+	before := f.calls.Load()
+	sizes, stale, err := mugSizes(t)
+	if err != nil || stale || strings.Join(sizes, ",") != "15-oz" {
+		t.Errorf("after invalidation: sizes %v, stale %v, %v", sizes, stale, err)
+	}
+	if f.calls.Load() == before {
+		t.Error("read after invalidation served from cache")
+	}
+}
+
+func TestCatalogCacheSharesFetch(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	fetch := func() (interface{}, error) {
+		calls.Add(1)
+		<-release
+		return "v", nil
+	}
+	resetCache()
+	t.Cleanup(resetCache)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, _, err := getCached("k", time.Hour, fetch); err != nil || v != "v" {
+				t.Errorf("got %v, %v", v, err)
+			}
+		}()
+	}
+	// Let the readers queue behind the first fetch before it finishes
+	for {
+		cacheMu.RLock()
+		_, running := inflight["k"]
+		cacheMu.RUnlock()
+		if running {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("%d fetches for 10 concurrent misses, want 1", n)
+	}
+}
+
+func TestResolveGelatoUIDLive(t *testing.T) {
+	f := newFakeGelato(t)
+	choice := map[string]string{"size": "11-oz"}
+	if _, err := ResolveGelatoUID("mug", choice); err != nil {
+		t.Fatal(err)
+	}
+
+	// Orders don't accept the cached answer while Gelato is down
+	f.down.Store(true)
+	if uid, err := ResolveGelatoUID("mug", choice); err != nil || uid == "" {
+		t.Errorf("cached resolve: %q, %v", uid, err)
+	}
+	if _, err := ResolveGelatoUIDLive("mug", choice); err == nil {
+		t.Error("live resolve succeeded with Gelato down")
+	}
+
+	f.down.Store(false)
+	before := f.calls.Load()
+	if uid, err := ResolveGelatoUIDLive("mug", choice); err != nil || uid == "" {
+		t.Errorf("live resolve: %q, %v", uid, err)
+	}
+	if f.calls.Load() == before {
+		t.Error("live resolve served from cache")
+	}
+}
@@ -5,21 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"math/big"
 	"net/http"
 	"os"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 )
 
-const (
-	gelatoCatalogURL = "https://product.gelatoapis.com/v3"
-	coingeckoURL     = "https://api.coingecko.com/api/v3/simple/price"
-	catalogTTL       = 3600 // 1 hour
-	priceTTL         = 1800 // 30 min
-	rateTTL          = 300  // 5 min
-)
+// gelatoCatalogURL is the Gelato product API (a var so tests can use a fake).
+var gelatoCatalogURL = "https://product.gelatoapis.com/v3"
+
+// coingeckoURL is the BCH/USD rate endpoint (a var so tests can use a fake).
+var coingeckoURL = "https://api.coingecko.com/api/v3/simple/price"
 
 type ProductConfig struct {
 	GelatoCatalog    string            `json:"gelato_catalog"`
@@ -30,6 +30,9 @@ type ProductConfig struct {
 	ReferenceVariant map[string]string `json:"reference_variant"`
 	DesignURL        string            `json:"design_url"`
 	MarginPct        float64           `json:"margin_pct"`
+	// Smallest design (in pixels) that prints acceptably. See MinDesignSize.
+	MinDesignWidth  int `json:"min_design_width"`
+	MinDesignHeight int `json:"min_design_height"`
 }
 
 var CatalogConfig = map[string]ProductConfig{
@@ -48,6 +51,8 @@ var CatalogConfig = map[string]ProductConfig{
 		ReferenceVariant: map[string]string{"size": "M", "color": "white"},
 		DesignURL:        "https://placehold.co/4000x5000/png?text=Design+Placeholder",
 		MarginPct:        40,
+		MinDesignWidth:   1800,
+		MinDesignHeight:  2400,
 	},
 	"mug": {
 		GelatoCatalog: "mugs",
@@ -61,6 +66,8 @@ var CatalogConfig = map[string]ProductConfig{
 		ReferenceVariant: map[string]string{"size": "11-oz"},
 		DesignURL:        "https://placehold.co/4000x2000/png?text=Design+Placeholder",
 		MarginPct:        40,
+		MinDesignWidth:   1500,
+		MinDesignHeight:  700,
 	},
 	"framed-print": {
 		GelatoCatalog: "framed-posters",
@@ -75,6 +82,8 @@ var CatalogConfig = map[string]ProductConfig{
 		ReferenceVariant: map[string]string{"size": "a3", "orientation": "ver"},
 		DesignURL:        "https://placehold.co/3000x4000/png?text=Design+Placeholder",
 		MarginPct:        40,
+		MinDesignWidth:   2400,
+		MinDesignHeight:  3300,
 	},
 }
 
@@ -87,42 +96,6 @@ var apparelSizeOrder = map[string]int{
 	"2XL": 5, "3XL": 6, "4XL": 7, "5XL": 8,
 }
 
-// --- TTL cache ---
-
-type cacheEntry struct {
-	data      interface{}
-	fetchedAt time.Time
-}
-
-var (
-	cacheMu sync.RWMutex
-	cache   = map[string]cacheEntry{}
-)
-
-func getCached(key string, ttl int, fetchFn func() (interface{}, error)) (interface{}, error) {
-	cacheMu.RLock()
-	entry, ok := cache[key]
-	cacheMu.RUnlock()
-
-	if ok && time.Since(entry.fetchedAt).Seconds() < float64(ttl) {
-		return entry.data, nil
-	}
-
-	data, err := fetchFn()
-	if err != nil {
-		// Return stale on error
-		if ok {
-			return entry.data, nil
-		}
-		return nil, err
-	}
-
-	cacheMu.Lock()
-	cache[key] = cacheEntry{data: data, fetchedAt: time.Now()}
-	cacheMu.Unlock()
-	return data, nil
-}
-
 func gelatoHeaders() http.Header {
 	h := http.Header{}
 	h.Set("X-API-KEY", os.Getenv("GELATO_API_KEY"))
@@ -300,6 +273,35 @@ func fetchProductPriceUSD(productUID string) (float64, error) {
 	return prices[0].Price, nil
 }
 
+// fetchCountryAvailable asks Gelato whether productUID ships to country: a
+// product that can't be shipped there has no price for it.
+func fetchCountryAvailable(productUID, country string) (bool, error) {
+	req, _ := http.NewRequest("GET",
+		fmt.Sprintf("%s/products/%s/prices?country=%s", gelatoCatalogURL, productUID, country), nil)
+	req.Header = gelatoHeaders()
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	switch {
+	case resp.StatusCode == 404:
+		return false, nil
+	case resp.StatusCode != 200:
+		return false, fmt.Errorf("Gelato availability error: %d", resp.StatusCode)
+	}
+
+	var prices []json.RawMessage
+	if err := json.Unmarshal(body, &prices); err != nil {
+		return false, err
+	}
+	return len(prices) > 0, nil
+}
+
 func fetchBCHRate() (float64, error) {
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Get(coingeckoURL + "?ids=bitcoin-cash&vs_currencies=usd")
@@ -325,7 +327,7 @@ func fetchBCHRate() (float64, error) {
 
 // GetBCHRate returns the current BCH/USD rate (cached 5min TTL).
 func GetBCHRate() (float64, error) {
-	data, err := getCached("bch_rate", rateTTL, func() (interface{}, error) {
+	data, _, err := getCached("bch_rate", rateTTL, func() (interface{}, error) {
 		return fetchBCHRate()
 	})
 	if err != nil {
@@ -361,18 +363,53 @@ func GetProduct(productID string) *ProductConfig {
 	return &cfg
 }
 
-func GetProductOptions(productID string) (map[string][]string, error) {
+var (
+	minDesignSizesOnce sync.Once
+	minDesignSizes     map[string][2]int
+)
+
+// MinDesignSize returns the minimum design width and height in pixels for a
+// product (0, 0 if none). DESIGN_MIN_SIZES overrides the catalog defaults,
+// e.g. {"t-shirt": {"width": 2400, "height": 3200}}.
+func MinDesignSize(productID string) (int, int) {
+	minDesignSizesOnce.Do(func() {
+		minDesignSizes = make(map[string][2]int)
+		for id, cfg := range CatalogConfig {
+			minDesignSizes[id] = [2]int{cfg.MinDesignWidth, cfg.MinDesignHeight}
+		}
+		raw := os.Getenv("DESIGN_MIN_SIZES")
+		if raw == "" {
+			return
+		}
+		var overrides map[string]struct {
+			Width  int `json:"width"`
+			Height int `json:"height"`
+		}
+		if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+			log.Printf("Warning: ignoring DESIGN_MIN_SIZES: %v", err)
+			return
+		}
+		for id, o := range overrides {
+			minDesignSizes[id] = [2]int{o.Width, o.Height}
+		}
+	})
+	size := minDesignSizes[productID]
+	return size[0], size[1]
+}
+
+// GetProductOptions returns the valid values of each agent option. stale is
+// set when Gelato couldn't be reached and these are the last known options.
+func GetProductOptions(productID string) (map[string][]string, bool, error) {
 	if _, ok := CatalogConfig[productID]; !ok {
-		return nil, fmt.Errorf("unknown product: %s", productID)
+		return nil, false, fmt.Errorf("unknown product: %s", productID)
 	}
-	key := "valid_options:" + productID
-	data, err := getCached(key, catalogTTL, func() (interface{}, error) {
+	data, stale, err := getCached(optionsKeyPrefix+productID, optionsTTL, func() (interface{}, error) {
 		return fetchValidOptions(productID)
 	})
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	return data.(map[string][]string), nil
+	return data.(map[string][]string), stale, nil
 }
 
 func ValidateOptions(productID string, options map[string]string) string {
@@ -397,10 +434,25 @@ func ValidateOptions(productID string, options map[string]string) string {
 	return ""
 }
 
+// ResolveGelatoUID returns the Gelato product UID for a set of agent
+// choices, or "" if no product matches. Answers are cached for catalogTTL.
 func ResolveGelatoUID(productID string, agentChoices map[string]string) (string, error) {
+	uid, _, err := resolveGelatoUID(productID, agentChoices, false)
+	return uid, err
+}
+
+// ResolveGelatoUIDLive is ResolveGelatoUID straight from Gelato, refreshing
+// the cached answer. Orders use it so a cached catalog can't produce an order
+// for a variant Gelato no longer has.
+func ResolveGelatoUIDLive(productID string, agentChoices map[string]string) (string, error) {
+	uid, _, err := resolveGelatoUID(productID, agentChoices, true)
+	return uid, err
+}
+
+func resolveGelatoUID(productID string, agentChoices map[string]string, live bool) (string, bool, error) {
 	cfg, ok := CatalogConfig[productID]
 	if !ok {
-		return "", fmt.Errorf("unknown product: %s", productID)
+		return "", false, fmt.Errorf("unknown product: %s", productID)
 	}
 
 	filters := map[string]string{}
@@ -413,24 +465,60 @@ func ResolveGelatoUID(productID string, agentChoices map[string]string) (string,
 		}
 	}
 
-	cacheKey := fmt.Sprintf("uid:%s:%v", productID, agentChoices)
-	data, err := getCached(cacheKey, catalogTTL, func() (interface{}, error) {
+	cacheKey := fmt.Sprintf("%s%s:%v", uidKeyPrefix, productID, agentChoices)
+	fetch := func() (interface{}, error) {
 		uid, err := searchGelatoProduct(cfg.GelatoCatalog, filters)
 		if err != nil {
 			return nil, err
 		}
 		return uid, nil
+	}
+	if live {
+		data, err := fetchShared(cacheKey, fetch)
+		if err != nil {
+			return "", false, err
+		}
+		return data.(string), false, nil
+	}
+	data, stale, err := getCached(cacheKey, catalogTTL, fetch)
+	if err != nil {
+		return "", false, err
+	}
+	return data.(string), stale, nil
+}
+
+// ProductShipsTo reports whether the resolved Gelato product can be shipped
+// to an ISO-3166 alpha-2 country. Answers are cached per product and country
+// for catalogTTL.
+func ProductShipsTo(productUID, country string) (bool, error) {
+	data, _, err := getCached(shipsToKeyPrefix+productUID+":"+country, catalogTTL, func() (interface{}, error) {
+		return fetchCountryAvailable(productUID, country)
 	})
 	if err != nil {
-		return "", err
+		return false, err
 	}
-	return data.(string), nil
+	return data.(bool), nil
 }
 
 func GetProductBCHPrice(productID string, agentChoices map[string]string) (string, error) {
+	price, _, err := productBCHPrice(productID, agentChoices)
+	return price, err
+}
+
+// productBCHPrice is GetProductBCHPrice, also reporting whether any part of
+// the price came from stale cache entries.
+func productBCHPrice(productID string, agentChoices map[string]string) (string, bool, error) {
+	q, stale, err := productQuote(productID, agentChoices, false)
+	return q.TotalBCH, stale, err
+}
+
+// productQuote prices a product variant in BCH, recording the inputs. With
+// freshRate, a stale BCH rate is refetched, and ErrRateUnavailable returned
+// if that fails, instead of pricing at the last known rate.
+func productQuote(productID string, agentChoices map[string]string, freshRate bool) (Quote, bool, error) {
 	cfg, ok := CatalogConfig[productID]
 	if !ok {
-		return "", fmt.Errorf("unknown product: %s", productID)
+		return Quote{}, false, fmt.Errorf("unknown product: %s", productID)
 	}
 
 	choices := agentChoices
@@ -438,35 +526,53 @@ func GetProductBCHPrice(productID string, agentChoices map[string]string) (strin
 		choices = cfg.ReferenceVariant
 	}
 
-	uid, err := ResolveGelatoUID(productID, choices)
+	uid, uidStale, err := resolveGelatoUID(productID, choices, false)
 	if err != nil || uid == "" {
-		return "", fmt.Errorf("could not resolve product UID")
+		return Quote{}, false, fmt.Errorf("could not resolve product UID")
 	}
 
 	// Get USD cost
-	priceData, err := getCached("price_usd:"+uid, priceTTL, func() (interface{}, error) {
+	priceData, priceStale, err := getCached(priceKeyPrefix+uid, priceTTL, func() (interface{}, error) {
 		return fetchProductPriceUSD(uid)
 	})
 	if err != nil {
-		return "", err
+		return Quote{}, false, err
 	}
 	usdCost := priceData.(float64)
 
 	// Get BCH rate
-	rateData, err := getCached("bch_rate", rateTTL, func() (interface{}, error) {
+	rateData, rateAt, rateStale, err := getCachedAt("bch_rate", rateTTL, func() (interface{}, error) {
 		return fetchBCHRate()
 	})
 	if err != nil {
-		return "", err
+		return Quote{}, false, err
+	}
+	if rateStale && freshRate {
+		rateData, err = fetchShared("bch_rate", func() (interface{}, error) {
+			return fetchBCHRate()
+		})
+		if err != nil {
+			return Quote{}, false, fmt.Errorf("%w: %v", ErrRateUnavailable, err)
+		}
+		rateAt, rateStale = time.Now(), false
 	}
 	bchRate := rateData.(float64)
 
 	usdWithMargin := usdCost * (1 + cfg.MarginPct/100)
 	bch := usdWithMargin / bchRate
-	return fmt.Sprintf("%.6f", bch), nil
+	return Quote{
+		PriceUSD:   fmt.Sprintf("%.2f", usdWithMargin),
+		BCHUSDRate: strconv.FormatFloat(bchRate, 'f', -1, 64),
+		RateSource: RateSourceCoinGecko,
+		RateAt:     rateAt.UTC().Format(time.RFC3339),
+		TotalBCH:   fmt.Sprintf("%.6f", bch),
+	}, uidStale || priceStale || rateStale, nil
 }
 
-func GetProductsForMenu() []MenuItem {
+// GetProductsForMenu lists every product with its reference price. stale is
+// set when some prices are the last known ones because Gelato or CoinGecko
+// couldn't be reached.
+func GetProductsForMenu() ([]MenuItem, bool) {
 	apiKey := os.Getenv("GELATO_API_KEY")
 	if apiKey == "" {
 		items := make([]MenuItem, 0, len(ProductOrder))
@@ -479,28 +585,31 @@ func GetProductsForMenu() []MenuItem {
 				BasePriceBCH: "0.000000",
 			})
 		}
-		return items
+		return items, false
 	}
 
 	// Fetch prices concurrently
 	type result struct {
 		pid   string
 		price string
+		stale bool
 		err   error
 	}
 	ch := make(chan result, len(ProductOrder))
 	for _, pid := range ProductOrder {
 		go func(p string) {
-			price, err := GetProductBCHPrice(p, nil)
-			ch <- result{pid: p, price: price, err: err}
+			price, stale, err := productBCHPrice(p, nil)
+			ch <- result{pid: p, price: price, stale: stale, err: err}
 		}(pid)
 	}
 
 	prices := map[string]string{}
+	stale := false
 	for range ProductOrder {
 		r := <-ch
 		if r.err == nil && r.price != "" {
 			prices[r.pid] = r.price
+			stale = stale || r.stale
 		}
 	}
 
@@ -515,7 +624,7 @@ func GetProductsForMenu() []MenuItem {
 			BasePriceBCH: orDefault(price, "0.000000"),
 		})
 	}
-	return items
+	return items, stale
 }
 
 func orDefault(s, def string) string {
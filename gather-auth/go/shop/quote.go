@@ -0,0 +1,60 @@
+package shop
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// --- Order quotes ---
+//
+// An order's BCH total is quoted when it's placed and locked until the quote
+// expires: payment is checked against the locked total, never a recomputed
+// one, and a payment after expiry needs a new quote at the then-current rate.
+
+// QuoteTTL is how long an order's quoted BCH total is honoured.
+const QuoteTTL = 30 * time.Minute
+
+// RateSourceCoinGecko is the source of BCH/USD rates in quotes.
+const RateSourceCoinGecko = "coingecko"
+
+// ErrRateUnavailable is returned by QuoteProduct when the cached BCH/USD rate
+// is out of date and a current one can't be fetched. A quote locks its rate
+// for QuoteTTL, so it is never made at a stale one.
+var ErrRateUnavailable = errors.New("BCH/USD rate unavailable")
+
+// Quote is a locked BCH price for a product variant.
+type Quote struct {
+	PriceUSD   string `json:"price_usd" doc:"Product price in USD, margin included"`
+	BCHUSDRate string `json:"bch_usd_rate" doc:"BCH/USD exchange rate used"`
+	RateSource string `json:"rate_source" doc:"Where the exchange rate came from"`
+	RateAt     string `json:"rate_at" doc:"When the exchange rate was fetched"`
+	TotalBCH   string `json:"total_bch" doc:"Amount to pay in BCH"`
+	QuotedAt   string `json:"quoted_at" doc:"When the quote was made"`
+	ExpiresAt  string `json:"expires_at" doc:"Pay before this time, or re-quote"`
+}
+
+// QuoteProduct quotes productID with agentChoices as of now, valid for
+// QuoteTTL. The BCH rate is refetched if the cached one is stale.
+func QuoteProduct(productID string, agentChoices map[string]string, now time.Time) (Quote, error) {
+	q, _, err := productQuote(productID, agentChoices, true)
+	if err != nil {
+		return Quote{}, err
+	}
+	if q.TotalBCH == "" {
+		return Quote{}, fmt.Errorf("no price for %s", productID)
+	}
+	q.QuotedAt = now.UTC().Format(time.RFC3339)
+	q.ExpiresAt = now.Add(QuoteTTL).UTC().Format(time.RFC3339)
+	return q, nil
+}
+
+// Expired reports whether the quote can no longer be paid at now. A quote
+// without an expiry never expires.
+func (q Quote) Expired(now time.Time) bool {
+	if q.ExpiresAt == "" {
+		return false
+	}
+	exp, err := time.Parse(time.RFC3339, q.ExpiresAt)
+	return err == nil && !now.Before(exp)
+}
@@ -0,0 +1,132 @@
+package shop
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// seedQuoteCache caches a Gelato product UID and USD price for the mug's
+// reference variant and a BCH rate fetched at rateAt, and points coingeckoURL
+// at a fake answering with *rate (0 = unavailable).
+func seedQuoteCache(t *testing.T, usd, cachedRate float64, rateAt time.Time, rate *atomic.Value) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := rate.Load().(float64)
+		if current == 0 {
+			http.Error(w, `{"status":{"error_code":429}}`, http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprintf(w, `{"bitcoin-cash":{"usd":%g}}`, current)
+	}))
+	oldURL := coingeckoURL
+	coingeckoURL = srv.URL
+	t.Cleanup(func() {
+		coingeckoURL = oldURL
+		srv.Close()
+		cacheMu.Lock()
+		cache = map[string]cacheEntry{}
+		cacheMu.Unlock()
+	})
+
+	cfg := CatalogConfig["mug"]
+	now := time.Now()
+	cacheMu.Lock()
+	cache = map[string]cacheEntry{
+		fmt.Sprintf("%s%s:%v", uidKeyPrefix, "mug", cfg.ReferenceVariant): {data: "mug-uid", fetchedAt: now},
+		priceKeyPrefix + "mug-uid": {data: usd, fetchedAt: now},
+		"bch_rate":                 {data: cachedRate, fetchedAt: rateAt},
+	}
+	cacheMu.Unlock()
+}
+
+func mugTotal(usd, rate float64) string {
+	return fmt.Sprintf("%.6f", usd*(1+CatalogConfig["mug"].MarginPct/100)/rate)
+}
+
+func TestQuoteProductUsesFreshRate(t *testing.T) {
+	var rate atomic.Value
+	rate.Store(500.0)
+	seedQuoteCache(t, 10, 400, time.Now(), &rate)
+
+	now := time.Now()
+	q, err := QuoteProduct("mug", nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.TotalBCH != mugTotal(10, 400) || q.BCHUSDRate != "400" {
+		t.Errorf("quote = %+v, want the cached rate of 400", q)
+	}
+	if q.ExpiresAt != now.Add(QuoteTTL).UTC().Format(time.RFC3339) {
+		t.Errorf("expires_at = %s", q.ExpiresAt)
+	}
+}
+
+func TestQuoteProductRefreshesStaleRate(t *testing.T) {
+	var rate atomic.Value
+	rate.Store(500.0)
+	// Cached rate is past its TTL, and a refetch failed a moment ago, so
+	// the cache serves it as stale without asking CoinGecko again
+	seedQuoteCache(t, 10, 400, time.Now().Add(-time.Hour), &rate)
+	cacheMu.Lock()
+	entry := cache["bch_rate"]
+	entry.failedAt = time.Now()
+	cache["bch_rate"] = entry
+	cacheMu.Unlock()
+
+	if price, stale, err := productBCHPrice("mug", nil); err != nil || !stale || price != mugTotal(10, 400) {
+		t.Fatalf("menu price = %s, stale %v, %v; want the stale rate", price, stale, err)
+	}
+
+	q, err := QuoteProduct("mug", nil, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.TotalBCH != mugTotal(10, 500) || q.BCHUSDRate != "500" {
+		t.Errorf("quote = %+v, want the refreshed rate of 500", q)
+	}
+	if at, _ := time.Parse(time.RFC3339, q.RateAt); time.Since(at) > time.Minute {
+		t.Errorf("rate_at = %s, want now", q.RateAt)
+	}
+}
+
+func TestQuoteProductRefusesStaleRate(t *testing.T) {
+	var rate atomic.Value
+	rate.Store(0.0) // CoinGecko is down
+	seedQuoteCache(t, 10, 400, time.Now().Add(-time.Hour), &rate)
+
+	// Browsing still works at the last known rate
+	if price, stale, err := productBCHPrice("mug", nil); err != nil || !stale || price != mugTotal(10, 400) {
+		t.Fatalf("menu price = %s, stale %v, %v", price, stale, err)
+	}
+
+	_, err := QuoteProduct("mug", nil, time.Now())
+	if !errors.Is(err, ErrRateUnavailable) {
+		t.Fatalf("err = %v, want ErrRateUnavailable", err)
+	}
+
+	// Once CoinGecko answers again, the order can be quoted at the new rate
+	rate.Store(450.0)
+	q, err := QuoteProduct("mug", nil, time.Now())
+	if err != nil || q.TotalBCH != mugTotal(10, 450) {
+		t.Errorf("quote = %+v, %v; want the rate of 450", q, err)
+	}
+}
+
+func TestQuoteExpired(t *testing.T) {
+	now := time.Now()
+	q := Quote{ExpiresAt: now.Add(time.Minute).UTC().Format(time.RFC3339)}
+	if q.Expired(now) {
+		t.Error("quote expired before its expiry")
+	}
+	if !q.Expired(now.Add(2 * time.Minute)) {
+		t.Error("quote not expired after its expiry")
+	}
+	if (Quote{}).Expired(now) {
+		t.Error("quote without expiry expired")
+	}
+}
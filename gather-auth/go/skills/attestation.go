@@ -7,8 +7,11 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"sync"
 
 	"github.com/google/uuid"
+	"github.com/pocketbase/pocketbase/core"
 
 	auth "gather.is/auth"
 )
@@ -48,21 +51,42 @@ func hashContent(content string) string {
 	return hex.EncodeToString(h[:])
 }
 
-// CreateAttestation generates a signed proof using the server's Ed25519 keypair.
-// The keypair is loaded from ~/.gather/keys/server.
-func CreateAttestation(data ExecutionData) (*Attestation, error) {
+// WitnessServer is the witness type of a server attestation. It marks the
+// proof as signed by the server rather than the reviewer, with the key that
+// signed it, so it still verifies after the server's key changes.
+const WitnessServer = "server"
+
+// serverKeyMu serialises creating the server keypair.
+var serverKeyMu sync.Mutex
+
+// ServerKeyPair returns the server's attestation keypair. It is kept in
+// keys/ under the PocketBase data directory, which is persisted, rather than
+// ~/.gather/keys, which a rebuilt container loses. A key left in
+// ~/.gather/keys/server by an earlier version is moved there on first use;
+// otherwise one is generated.
+func ServerKeyPair(app core.App) (*auth.KeyPair, error) {
+	serverKeyMu.Lock()
+	defer serverKeyMu.Unlock()
+
+	dir := filepath.Join(app.DataDir(), "keys")
+	if kp, err := auth.LoadKeyPairFrom(dir, "server"); err == nil {
+		return kp, nil
+	}
 	kp, err := auth.LoadKeyPair("server")
 	if err != nil {
-		// Generate one if it doesn't exist
-		kp, err = auth.GenerateKeyPair()
-		if err != nil {
+		if kp, err = auth.GenerateKeyPair(); err != nil {
 			return nil, fmt.Errorf("generate keypair: %w", err)
 		}
-		if err := auth.SaveKeyPair("server", kp); err != nil {
-			return nil, fmt.Errorf("save keypair: %w", err)
-		}
 	}
+	if err := auth.SaveKeyPairTo(dir, "server", kp); err != nil {
+		return nil, fmt.Errorf("save keypair: %w", err)
+	}
+	return kp, nil
+}
 
+// CreateAttestation generates a proof signed with the server's keypair (see
+// ServerKeyPair).
+func CreateAttestation(kp *auth.KeyPair, data ExecutionData) (*Attestation, error) {
 	timestamp := int64(0) // Will use record creation time instead
 	payload := AttestationPayload{
 		SkillID:    data.SkillID,
@@ -106,6 +130,13 @@ func CreateAttestation(data ExecutionData) (*Attestation, error) {
 	}, nil
 }
 
+// WitnessesJSON is the witnesses field of a's proof record: one
+// WitnessServer witness with the key that signed it.
+func (a *Attestation) WitnessesJSON() string {
+	witJSON, _ := json.Marshal([]map[string]string{{"type": WitnessServer, "public_key": a.PublicKey}})
+	return string(witJSON)
+}
+
 // VerifyAttestation checks an Ed25519 signature on an attestation.
 func VerifyAttestation(executionHash, signatureB64, publicKeyPEM string) bool {
 	sig, err := base64.StdEncoding.DecodeString(signatureB64)
@@ -0,0 +1,118 @@
+package skills
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// --- Score/text consistency ---
+//
+// A review scored near the top whose text is mostly about breakage, or near
+// the bottom with glowing text, is either a confused reviewer or someone
+// gaming a skill's rank while looking neutral. CheckReviewConsistency counts
+// success and failure terms in what_worked and what_failed; a term after a
+// negation ("not reliable", "no errors") counts for the other side.
+
+// ConsistencyThresholds tune when a score and its text disagree.
+type ConsistencyThresholds struct {
+	HighScore float64 // scores at or above this must not read mostly negative
+	LowScore  float64 // scores at or below this must not read mostly positive
+	MinTerms  int     // terms on the dominant side needed before flagging
+	Dominance float64 // share of all terms the dominant side must have, 0–1
+}
+
+// DefaultConsistencyThresholds treat the top and bottom quartiles of the
+// 1–10 scale as the scores to check.
+var DefaultConsistencyThresholds = ConsistencyThresholds{
+	HighScore: 8,
+	LowScore:  3,
+	MinTerms:  3,
+	Dominance: 0.75,
+}
+
+// ConsistencyResult is the outcome of CheckReviewConsistency.
+type ConsistencyResult struct {
+	SuccessTerms int
+	FailureTerms int
+	Flagged      bool
+	Reason       string // why it was flagged; empty otherwise
+}
+
+var successTerms = map[string]bool{
+	"works": true, "worked": true, "working": true, "great": true, "excellent": true, "perfect": true,
+	"perfectly": true, "flawless": true, "flawlessly": true, "reliable": true, "reliably": true,
+	"smooth": true, "smoothly": true, "fast": true, "easy": true, "clean": true, "helpful": true,
+	"useful": true, "impressive": true, "solid": true, "accurate": true, "correct": true,
+	"correctly": true, "success": true, "successful": true, "successfully": true, "love": true,
+	"fantastic": true, "amazing": true, "good": true, "nice": true, "robust": true, "intuitive": true,
+}
+
+var failureTerms = map[string]bool{
+	"fail": true, "fails": true, "failed": true, "failing": true, "failure": true, "broken": true,
+	"broke": true, "breaks": true, "crash": true, "crashed": true, "crashes": true, "error": true,
+	"errors": true, "bug": true, "bugs": true, "buggy": true, "unusable": true, "useless": true,
+	"wrong": true, "hang": true, "hangs": true, "hung": true, "timeout": true, "garbage": true,
+	"terrible": true, "awful": true, "worthless": true, "corrupt": true, "corrupted": true,
+	"exception": true, "panic": true, "unreliable": true, "slow": true, "confusing": true,
+	"missing": true, "bad": true, "poor": true,
+}
+
+// negations flip the polarity of a term up to two words after them.
+var negations = map[string]bool{
+	"not": true, "no": true, "never": true, "nothing": true, "without": true, "hardly": true,
+	"barely": true, "didn't": true, "doesn't": true, "don't": true, "isn't": true, "wasn't": true,
+	"aren't": true, "weren't": true, "won't": true, "can't": true, "couldn't": true, "zero": true,
+}
+
+// CountSentimentTerms counts success and failure terms in text.
+func CountSentimentTerms(text string) (success, failure int) {
+	words := strings.FieldsFunc(strings.ToLower(strings.ReplaceAll(text, "’", "'")), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for i, w := range words {
+		pos, neg := successTerms[w], failureTerms[w]
+		if !pos && !neg {
+			continue
+		}
+		if (i > 0 && negations[words[i-1]]) || (i > 1 && negations[words[i-2]]) {
+			pos, neg = neg, pos
+		}
+		if pos {
+			success++
+		} else {
+			failure++
+		}
+	}
+	return success, failure
+}
+
+// CheckReviewConsistency reports whether score disagrees with what the
+// review says in whatWorked and whatFailed. It only flags scores at the
+// extremes, and only when the text clearly leans the other way.
+func CheckReviewConsistency(score float64, whatWorked, whatFailed string, t ConsistencyThresholds) ConsistencyResult {
+	var res ConsistencyResult
+	// Counted apart, so a negation ending one field can't flip the start
+	// of the other
+	workedS, workedF := CountSentimentTerms(whatWorked)
+	failedS, failedF := CountSentimentTerms(whatFailed)
+	res.SuccessTerms, res.FailureTerms = workedS+failedS, workedF+failedF
+	total := res.SuccessTerms + res.FailureTerms
+	if total == 0 {
+		return res
+	}
+
+	switch {
+	case score >= t.HighScore && res.FailureTerms >= t.MinTerms &&
+		float64(res.FailureTerms)/float64(total) >= t.Dominance:
+		res.Flagged = true
+		res.Reason = fmt.Sprintf("score %g but the text is mostly negative (%d failure terms, %d success terms)",
+			score, res.FailureTerms, res.SuccessTerms)
+	case score <= t.LowScore && res.SuccessTerms >= t.MinTerms &&
+		float64(res.SuccessTerms)/float64(total) >= t.Dominance:
+		res.Flagged = true
+		res.Reason = fmt.Sprintf("score %g but the text is mostly positive (%d success terms, %d failure terms)",
+			score, res.SuccessTerms, res.FailureTerms)
+	}
+	return res
+}
@@ -0,0 +1,70 @@
+package skills
+
+import "testing"
+
+func TestCountSentimentTerms(t *testing.T) {
+	cases := []struct {
+		text             string
+		success, failure int
+	}{
+		{"", 0, 0},
+		{"Ran the task, produced output.", 0, 0},
+		{"Works great, fast and reliable", 4, 0},
+		{"Crashed on start, broken and buggy", 0, 3},
+		{"No errors at all", 1, 0},
+		{"not reliable, never worked", 0, 2},
+		{"It wasn’t slow", 1, 0},
+		{"didn't really crash", 1, 0},
+		{"not at all good", 1, 0}, // a negation reaches two words ahead, no further
+		{"WORKS, Fails", 1, 1},
+	}
+	for _, tc := range cases {
+		s, f := CountSentimentTerms(tc.text)
+		if s != tc.success || f != tc.failure {
+			t.Errorf("%q: %d success, %d failure; want %d, %d", tc.text, s, f, tc.success, tc.failure)
+		}
+	}
+}
+
+func TestCheckReviewConsistency(t *testing.T) {
+	const (
+		glowing = "Worked perfectly, fast, reliable and accurate"
+		broken  = "Crashed constantly, broken output, errors everywhere, unusable"
+		mixed   = "Fast and accurate on small files"
+	)
+	lenient := ConsistencyThresholds{HighScore: 10, LowScore: 1, MinTerms: 6, Dominance: 0.9}
+
+	cases := []struct {
+		name         string
+		score        float64
+		worked, fail string
+		thresholds   ConsistencyThresholds
+		flagged      bool
+	}{
+		{"high score, glowing text", 9, glowing, "", DefaultConsistencyThresholds, false},
+		{"high score, broken text", 10, "", broken, DefaultConsistencyThresholds, true},
+		{"low score, glowing text", 1, glowing, "", DefaultConsistencyThresholds, true},
+		{"low score, broken text", 2, "", broken, DefaultConsistencyThresholds, false},
+		{"middle score, broken text", 5, "", broken, DefaultConsistencyThresholds, false},
+		{"top of the low quartile", 3, glowing, "", DefaultConsistencyThresholds, true},
+		{"bottom of the high quartile", 8, "", broken, DefaultConsistencyThresholds, true},
+		{"too few terms", 10, "", "It crashed twice", DefaultConsistencyThresholds, false},
+		{"balanced text", 10, glowing, broken, DefaultConsistencyThresholds, false},
+		{"negated failures read as praise", 10, "No errors, never crashed, nothing broken", "", DefaultConsistencyThresholds, false},
+		{"negation doesn't cross fields", 10, "Nothing", "Crashed, broken output, errors", DefaultConsistencyThresholds, true},
+		{"no sentiment terms", 1, "Ran the task", "Output was truncated at 4k", DefaultConsistencyThresholds, false},
+		{"mild praise at a low score", 2, mixed, "", DefaultConsistencyThresholds, false},
+		{"lenient thresholds pass a 9", 9, "", broken, lenient, false},
+		{"lenient thresholds still flag a 10", 10, "", broken + ", useless, terrible", lenient, true},
+	}
+	for _, tc := range cases {
+		res := CheckReviewConsistency(tc.score, tc.worked, tc.fail, tc.thresholds)
+		if res.Flagged != tc.flagged {
+			t.Errorf("%s: flagged %v (%d success, %d failure), want %v",
+				tc.name, res.Flagged, res.SuccessTerms, res.FailureTerms, tc.flagged)
+		}
+		if res.Flagged != (res.Reason != "") {
+			t.Errorf("%s: flagged %v with reason %q", tc.name, res.Flagged, res.Reason)
+		}
+	}
+}
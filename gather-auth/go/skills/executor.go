@@ -112,19 +112,22 @@ func ExecuteReview(app *pocketbase.PocketBase, reviewID, skillID, task string) {
 	}
 
 	// Generate attestation/proof
-	attestation, err := CreateAttestation(ExecutionData{
-		SkillID:    skillID,
-		Task:       task,
-		CLIOutput:  output,
-		Score:      &result.Score,
-		WhatWorked: result.WhatWorked,
-		WhatFailed: result.WhatFailed,
-	})
+	var attestation *Attestation
+	kp, err := ServerKeyPair(app)
+	if err == nil {
+		attestation, err = CreateAttestation(kp, ExecutionData{
+			SkillID:    skillID,
+			Task:       task,
+			CLIOutput:  output,
+			Score:      &result.Score,
+			WhatWorked: result.WhatWorked,
+			WhatFailed: result.WhatFailed,
+		})
+	}
 	if err == nil {
 		proofColl, err := app.FindCollectionByNameOrId("proofs")
 		if err == nil {
 			sigJSON, _ := json.Marshal([]string{attestation.Signature})
-			witJSON, _ := json.Marshal([]map[string]string{{"type": "ed25519", "public_key": attestation.PublicKey}})
 			payloadJSON, _ := json.Marshal(attestation.Payload)
 
 			proof := core.NewRecord(proofColl)
@@ -132,7 +135,7 @@ func ExecuteReview(app *pocketbase.PocketBase, reviewID, skillID, task string) {
 			proof.Set("claim_data", string(payloadJSON))
 			proof.Set("identifier", attestation.ExecutionHash)
 			proof.Set("signatures", string(sigJSON))
-			proof.Set("witnesses", string(witJSON))
+			proof.Set("witnesses", attestation.WitnessesJSON())
 			proof.Set("verified", true)
 			if err := app.Save(proof); err == nil {
 				review.Set("proof", proof.Id)
@@ -142,7 +145,7 @@ func ExecuteReview(app *pocketbase.PocketBase, reviewID, skillID, task string) {
 	}
 
 	// Update skill stats
-	updateSkillStats(app, skillID)
+	UpdateSkillStats(app, skillID)
 }
 
 func runClaude(prompt string) (string, bool) {
@@ -220,19 +223,22 @@ func parseReviewResult(output string) *ReviewResult {
 	return &result
 }
 
-func updateSkillStats(app *pocketbase.PocketBase, skillID string) {
+// UpdateSkillStats recomputes a skill's review_count, avg_score and
+// avg_security_score from its completed reviews, counting each reviewer once
+// (see CountedReviews), then its rank.
+func UpdateSkillStats(app *pocketbase.PocketBase, skillID string) {
 	skill, err := app.FindRecordById("skills", skillID)
 	if err != nil {
 		return
 	}
 
-	// Count completed reviews with scores
-	reviews, err := app.FindRecordsByFilter("reviews",
-		"skill = {:sid} && status = 'complete' && score > 0", "", 0, 0,
+	// Completed, scored, unheld reviews, newest first
+	reviews, err := app.FindRecordsByFilter("reviews", CountedReviewsFilter, "-created", 0, 0,
 		map[string]any{"sid": skillID})
 	if err != nil {
 		return
 	}
+	reviews = CountedReviews(reviews)
 
 	var totalScore, totalSecScore float64
 	var secCount int
@@ -4,6 +4,7 @@ import (
 	"math"
 
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
 )
 
 // RankingWeights controls the weighted rank formula.
@@ -19,6 +20,19 @@ var DefaultWeights = RankingWeights{
 	Proofs:   0.35,
 }
 
+// DefaultUnreachablePenalty is the share of its rank score a skill whose url
+// is unreachable loses, unless platform_config sets skill_unreachable_penalty.
+const DefaultUnreachablePenalty = 0.5
+
+// unreachablePenalty reads skill_unreachable_penalty from platform_config.
+func unreachablePenalty(app *pocketbase.PocketBase) float64 {
+	records, err := app.FindRecordsByFilter("platform_config", "id != ''", "", 1, 0, nil)
+	if err != nil || len(records) == 0 || records[0].Collection().Fields.GetByName("skill_unreachable_penalty") == nil {
+		return DefaultUnreachablePenalty
+	}
+	return math.Min(1, math.Max(0, records[0].GetFloat("skill_unreachable_penalty")))
+}
+
 // CalculateRankScore computes a 0-100 rank score for a skill.
 func CalculateRankScore(avgScore *float64, reviewCount, installs, proofCount, totalReviews int, w RankingWeights) float64 {
 	if avgScore == nil || reviewCount == 0 {
@@ -42,6 +56,29 @@ func CalculateRankScore(avgScore *float64, reviewCount, installs, proofCount, to
 	return math.Min(100, math.Max(0, score*10))
 }
 
+// CountedReviewsFilter selects the reviews of skill {:sid} that can count
+// towards its scores: complete, scored, and not held for moderation.
+const CountedReviewsFilter = "skill = {:sid} && status = 'complete' && score > 0 && needs_moderation != true"
+
+// CountedReviews keeps each agent's most recent review of reviews, which
+// must be sorted newest first, so an agent who reviewed a skill more than
+// once counts once in its averages and rank. Reviews without an agent
+// (server-side executions) are all kept.
+func CountedReviews(reviews []*core.Record) []*core.Record {
+	seen := map[string]bool{}
+	counted := make([]*core.Record, 0, len(reviews))
+	for _, r := range reviews {
+		if agentID := r.GetString("agent_id"); agentID != "" {
+			if seen[agentID] {
+				continue
+			}
+			seen[agentID] = true
+		}
+		counted = append(counted, r)
+	}
+	return counted
+}
+
 // UpdateSkillRanking recalculates the rank_score for a single skill.
 func UpdateSkillRanking(app *pocketbase.PocketBase, skillID string) {
 	skill, err := app.FindRecordById("skills", skillID)
@@ -57,12 +94,12 @@ func UpdateSkillRanking(app *pocketbase.PocketBase, skillID string) {
 		avgScore = &v
 	}
 
-	// Count verified proofs for this skill's reviews
+	// Count verified proofs for this skill's counted reviews
 	proofCount := 0
-	reviews, err := app.FindRecordsByFilter("reviews", "skill = {:sid} && status = 'complete'", "", 0, 0,
+	reviews, err := app.FindRecordsByFilter("reviews", CountedReviewsFilter, "-created", 0, 0,
 		map[string]any{"sid": skillID})
 	if err == nil {
-		for _, r := range reviews {
+		for _, r := range CountedReviews(reviews) {
 			if r.GetString("proof") != "" {
 				proofID := r.GetString("proof")
 				proof, err := app.FindRecordById("proofs", proofID)
@@ -83,6 +120,9 @@ func UpdateSkillRanking(app *pocketbase.PocketBase, skillID string) {
 	}
 
 	rankScore := CalculateRankScore(avgScore, reviewCount, installs, proofCount, totalReviews, DefaultWeights)
+	if skill.GetBool("unreachable") {
+		rankScore *= 1 - unreachablePenalty(app)
+	}
 
 	skill.Set("rank_score", rankScore)
 	app.Save(skill)
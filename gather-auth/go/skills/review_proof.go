@@ -0,0 +1,153 @@
+package skills
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	auth "gather.is/auth"
+)
+
+// ReviewClaim is the subset of a review that a client signs. The canonical
+// form is frozen: changing it would invalidate every previously signed review.
+type ReviewClaim struct {
+	Score      float64 `json:"score"`
+	SkillID    string  `json:"skill_id"`
+	Task       string  `json:"task"`
+	WhatFailed string  `json:"what_failed"`
+	WhatWorked string  `json:"what_worked"`
+}
+
+// CanonicalReviewJSON returns the exact string clients must hash: keys sorted,
+// no whitespace, UTF-8 without HTML escaping, integral scores without a
+// decimal point (8, not 8.0).
+//
+//	{"score":8,"skill_id":"anthropics/pdf","task":"...","what_failed":"...","what_worked":"..."}
+func CanonicalReviewJSON(c ReviewClaim) string {
+	return canonicalReviewJSON(c, false)
+}
+
+// canonicalReviewJSON encodes the claim. Fields are declared in sorted order
+// so struct encoding matches a sorted-key map. escapeHTML=true reproduces
+// json.Marshal output, which Go clients following the /help recipe produce.
+func canonicalReviewJSON(c ReviewClaim, escapeHTML bool) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	enc.Encode(c)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// ReviewExecutionHash returns the lowercase hex SHA-256 of the canonical JSON.
+func ReviewExecutionHash(c ReviewClaim) string {
+	return hashContent(CanonicalReviewJSON(c))
+}
+
+// Proof verification failure steps, reported back to clients.
+const (
+	ProofStepKeyMismatch      = "key_mismatch"
+	ProofStepHashMismatch     = "hash_mismatch"
+	ProofStepSignatureInvalid = "signature_invalid"
+)
+
+// Which key signed a proof, reported by verification.
+const (
+	ProofKeyCurrent    = "current"    // the reviewer's registered key
+	ProofKeyHistorical = "historical" // a key the reviewer has since rotated away from
+	ProofKeyServer     = "server"     // a server-side attestation
+)
+
+// ProofCheck is the outcome of verifying a client review proof.
+type ProofCheck struct {
+	Verified     bool
+	FailedStep   string // one of the ProofStep* constants, empty when verified
+	Message      string
+	ExpectedHash string
+	KeyMatch     string // one of the ProofKey* constants once the key step passed
+}
+
+// AgentKey is a public key an agent has held. Until is zero for the current
+// key; a historical key was active from From until it was rotated at Until.
+type AgentKey struct {
+	PublicKey string
+	From      time.Time
+	Until     time.Time
+}
+
+// Current reports whether k is the agent's registered key.
+func (k AgentKey) Current() bool {
+	return k.Until.IsZero()
+}
+
+// MatchAgentKey finds the key among an agent's keys that proofPublicKey is:
+// the current key, or a historical key that was active at signedAt, so a
+// proof stays valid after the key that signed it is rotated. ok is false if
+// none matches.
+func MatchAgentKey(proofPublicKey string, keys []AgentKey, signedAt time.Time) (key AgentKey, ok bool) {
+	for _, k := range keys {
+		if !samePublicKey(proofPublicKey, k.PublicKey) {
+			continue
+		}
+		if k.Current() {
+			return k, true
+		}
+		if !signedAt.Before(k.From) && signedAt.Before(k.Until) {
+			return k, true
+		}
+	}
+	return AgentKey{}, false
+}
+
+// VerifyReviewProof checks a client proof in order: the signing key is the
+// agent's registered key, the execution hash matches the canonical claim,
+// and the signature over the hash is valid.
+func VerifyReviewProof(claim ReviewClaim, executionHash, signatureB64, proofPublicKey, registeredPublicKey string) ProofCheck {
+	expected := ReviewExecutionHash(claim)
+	check := ProofCheck{ExpectedHash: expected}
+
+	if !samePublicKey(proofPublicKey, registeredPublicKey) {
+		check.FailedStep = ProofStepKeyMismatch
+		check.Message = "public_key does not match the key this agent registered with"
+		return check
+	}
+
+	check.KeyMatch = ProofKeyCurrent
+
+	hash := strings.ToLower(strings.TrimSpace(executionHash))
+	if hash != expected && hash != hashContent(canonicalReviewJSON(claim, true)) {
+		check.FailedStep = ProofStepHashMismatch
+		check.Message = "execution_hash does not match the canonical review JSON; use POST /api/proofs/canonicalize to get the exact string to hash"
+		return check
+	}
+
+	if !VerifyAttestation(executionHash, signatureB64, proofPublicKey) {
+		check.FailedStep = ProofStepSignatureInvalid
+		check.Message = "signature is not a valid Ed25519 signature of the execution_hash hex string (ASCII bytes) by public_key"
+		return check
+	}
+
+	check.Verified = true
+	check.Message = "Proof verified"
+	return check
+}
+
+// samePublicKey compares two PEM keys by fingerprint so whitespace and line
+// ending differences don't cause spurious mismatches.
+func samePublicKey(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	ka, err := auth.ParsePublicKeyPEM([]byte(a))
+	if err != nil {
+		return false
+	}
+	kb, err := auth.ParsePublicKeyPEM([]byte(b))
+	if err != nil {
+		return false
+	}
+	return auth.Fingerprint(ka) == auth.Fingerprint(kb)
+}
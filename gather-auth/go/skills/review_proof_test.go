@@ -0,0 +1,163 @@
+package skills
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	auth "gather.is/auth"
+)
+
+// Golden vectors for the canonical review form. Every signed review proof
+// depends on these bytes: if one of these tests fails, the change breaks
+// verification of reviews signed before it. The hashes were computed
+// independently of this package.
+var canonicalGolden = []struct {
+	name  string
+	claim ReviewClaim
+	json  string
+	hash  string
+}{
+	{
+		name:  "integral score",
+		claim: ReviewClaim{Score: 8, SkillID: "anthropics/pdf", Task: "Convert a PDF to text", WhatWorked: "Extracted all 12 pages"},
+		json:  `{"score":8,"skill_id":"anthropics/pdf","task":"Convert a PDF to text","what_failed":"","what_worked":"Extracted all 12 pages"}`,
+		hash:  "597e8591118d1577350ceb618752dbd34fe8e45522d60456a6d3a68c469163e8",
+	},
+	{
+		name:  "fractional score",
+		claim: ReviewClaim{Score: 7.5, SkillID: "owner/skill", Task: "t", WhatFailed: "f", WhatWorked: "w"},
+		json:  `{"score":7.5,"skill_id":"owner/skill","task":"t","what_failed":"f","what_worked":"w"}`,
+		hash:  "257a1604052f3b15f71b35e2bfdead5097db07d9efd4fb8db7b3e14b0bf08dbf",
+	},
+	{
+		name:  "empty claim keeps every key",
+		claim: ReviewClaim{},
+		json:  `{"score":0,"skill_id":"","task":"","what_failed":"","what_worked":""}`,
+		hash:  "01f33214be151c279524730ff99a6fab51fac4718d39b31112976939519e0127",
+	},
+	{
+		name:  "HTML characters unescaped, quotes and controls escaped",
+		claim: ReviewClaim{Score: 9, SkillID: "a/b", Task: "Render <b>bold</b> & 'quotes'", WhatFailed: "said \"no\"\n\ttabbed \\ path", WhatWorked: "ok"},
+		json:  `{"score":9,"skill_id":"a/b","task":"Render <b>bold</b> & 'quotes'","what_failed":"said \"no\"\n\ttabbed \\ path","what_worked":"ok"}`,
+		hash:  "66c62536311ef2c5f75526c211b5f166bff06464ba15c3cf079286cbdcc12960",
+	},
+	{
+		name:  "raw UTF-8, escaped line separator and control character",
+		claim: ReviewClaim{Score: 6, SkillID: "a/b", Task: "café — 日本語 🚀", WhatFailed: "line\u2028sep", WhatWorked: "ctl\x01"},
+		json:  `{"score":6,"skill_id":"a/b","task":"café — 日本語 🚀","what_failed":"line\u2028sep","what_worked":"ctl\u0001"}`,
+		hash:  "8c0cfa9f51ff37d20e49518590d75a5e9975c4e9ea390eb1d58673c3cf0b6c64",
+	},
+}
+
+func TestCanonicalReviewJSONGolden(t *testing.T) {
+	for _, g := range canonicalGolden {
+		if got := CanonicalReviewJSON(g.claim); got != g.json {
+			t.Errorf("%s:\n got %s\nwant %s", g.name, got, g.json)
+		}
+		if got := ReviewExecutionHash(g.claim); got != g.hash {
+			t.Errorf("%s: hash %s, want %s", g.name, got, g.hash)
+		}
+	}
+}
+
+func TestCanonicalReviewJSONHTMLEscapedVariant(t *testing.T) {
+	// Go clients that use json.Marshal escape <, > and &; the server accepts
+	// that hash too
+	claim := canonicalGolden[3].claim
+	want := `{"score":9,"skill_id":"a/b","task":"Render \u003cb\u003ebold\u003c/b\u003e \u0026 'quotes'","what_failed":"said \"no\"\n\ttabbed \\ path","what_worked":"ok"}`
+	if got := canonicalReviewJSON(claim, true); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if got := hashContent(want); got != "31614b34ba65d7e70095237f43497fcb7ff34090756defb4aacf3fa008dc99f5" {
+		t.Errorf("hash %s", got)
+	}
+}
+
+// goldenKey is a fixed Ed25519 key, so signatures are reproducible.
+func goldenKey(t *testing.T, seedByte byte) (ed25519.PrivateKey, string) {
+	t.Helper()
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = seedByte
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+	pubPEM, err := auth.EncodePEM(priv.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, string(pubPEM)
+}
+
+func TestVerifyReviewProof(t *testing.T) {
+	priv, pub := goldenKey(t, 1)
+	_, otherPub := goldenKey(t, 2)
+	g := canonicalGolden[0]
+	sign := func(hash string) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(hash)))
+	}
+	escapedHash := hashContent(canonicalReviewJSON(canonicalGolden[3].claim, true))
+	upperHash := strings.ToUpper(g.hash) // the signature covers the hash as sent
+
+	cases := []struct {
+		name       string
+		claim      ReviewClaim
+		hash, sig  string
+		proofKey   string
+		wantStep   string
+		wantVerify bool
+	}{
+		{"valid", g.claim, g.hash, sign(g.hash), pub, "", true},
+		{"uppercase hash", g.claim, upperHash, sign(upperHash), pub, "", true},
+		{"json.Marshal hash", canonicalGolden[3].claim, escapedHash, sign(escapedHash), pub, "", true},
+		{"someone else's key", g.claim, g.hash, sign(g.hash), otherPub, ProofStepKeyMismatch, false},
+		{"edited claim", ReviewClaim{Score: 9, SkillID: g.claim.SkillID, Task: g.claim.Task, WhatWorked: g.claim.WhatWorked}, g.hash, sign(g.hash), pub, ProofStepHashMismatch, false},
+		{"signature of another hash", g.claim, g.hash, sign(canonicalGolden[1].hash), pub, ProofStepSignatureInvalid, false},
+		{"garbage signature", g.claim, g.hash, "not base64", pub, ProofStepSignatureInvalid, false},
+	}
+	for _, tc := range cases {
+		check := VerifyReviewProof(tc.claim, tc.hash, tc.sig, tc.proofKey, pub)
+		if check.Verified != tc.wantVerify || check.FailedStep != tc.wantStep {
+			t.Errorf("%s: verified=%v step=%q (%s), want verified=%v step=%q",
+				tc.name, check.Verified, check.FailedStep, check.Message, tc.wantVerify, tc.wantStep)
+		}
+		if check.ExpectedHash != ReviewExecutionHash(tc.claim) {
+			t.Errorf("%s: expected hash %s", tc.name, check.ExpectedHash)
+		}
+	}
+}
+
+func TestMatchAgentKey(t *testing.T) {
+	_, current := goldenKey(t, 1)
+	_, old := goldenKey(t, 2)
+	_, stranger := goldenKey(t, 3)
+	rotated := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	keys := []AgentKey{
+		{PublicKey: current, From: rotated},
+		{PublicKey: old, From: rotated.AddDate(0, -6, 0), Until: rotated},
+	}
+
+	cases := []struct {
+		name     string
+		key      string
+		signedAt time.Time
+		want     bool
+		current  bool
+	}{
+		{"current key", current, rotated.AddDate(0, 1, 0), true, true},
+		{"old key inside its window", old, rotated.AddDate(0, -1, 0), true, false},
+		{"old key at its start", old, rotated.AddDate(0, -6, 0), true, false},
+		{"old key at rotation", old, rotated, false, false},
+		{"old key after rotation", old, rotated.AddDate(0, 1, 0), false, false},
+		{"old key before it existed", old, rotated.AddDate(-1, 0, 0), false, false},
+		{"unknown key", stranger, rotated, false, false},
+	}
+	for _, tc := range cases {
+		k, ok := MatchAgentKey(tc.key, keys, tc.signedAt)
+		if ok != tc.want || (ok && k.Current() != tc.current) {
+			t.Errorf("%s: ok=%v current=%v, want ok=%v current=%v", tc.name, ok, k.Current(), tc.want, tc.current)
+		}
+	}
+}
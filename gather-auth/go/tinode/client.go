@@ -342,6 +342,62 @@ func (c *Client) EnsureUser(ctx context.Context, login, password, displayName st
 	return uid, nil
 }
 
+// UpdateUserPublic logs in as an existing user and replaces their public
+// profile data (e.g. {"fn": "New Name"}). Unlike EnsureUser it never creates
+// the account.
+func (c *Client) UpdateUserPublic(ctx context.Context, login, password string, public map[string]interface{}) error {
+	c.mu.Lock()
+	if c.stream != nil {
+		c.stream.CloseSend()
+		c.stream = nil
+	}
+	c.mu.Unlock()
+
+	if err := c.Hello(ctx); err != nil {
+		return fmt.Errorf("hello failed: %w", err)
+	}
+	if _, err := c.Login(ctx, login, password); err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	return c.setMePublic(ctx, public)
+}
+
+// setMePublic replaces the logged-in user's public data.
+func (c *Client) setMePublic(ctx context.Context, public map[string]interface{}) error {
+	pubBytes, err := json.Marshal(public)
+	if err != nil {
+		return fmt.Errorf("failed to marshal public data: %w", err)
+	}
+
+	msg := &pb.ClientMsg{
+		Message: &pb.ClientMsg_Set{
+			Set: &pb.ClientSet{
+				Id:    c.nextMsgID(),
+				Topic: "me",
+				Query: &pb.SetQuery{
+					Desc: &pb.SetDesc{
+						Public: pubBytes,
+					},
+				},
+			},
+		},
+	}
+
+	resp, err := c.sendAndReceive(ctx, msg)
+	if err != nil {
+		return err
+	}
+
+	if ctrl := resp.GetCtrl(); ctrl != nil {
+		if ctrl.Code >= 200 && ctrl.Code < 400 {
+			return nil
+		}
+		return fmt.Errorf("set failed: %d %s", ctrl.Code, ctrl.Text)
+	}
+
+	return nil
+}
+
 // updateBotMetadata updates the current user's public data to include bot=true
 func (c *Client) updateBotMetadata(ctx context.Context, displayName string) error {
 	pub := map[string]interface{}{
@@ -551,40 +607,11 @@ func (c *Client) EnsureBotUser(ctx context.Context, login, password, displayName
 
 // updateBotMetadataWithHandle updates the current user's public data with bot flag and handle
 func (c *Client) updateBotMetadataWithHandle(ctx context.Context, displayName, handle string) error {
-	pub := map[string]interface{}{
+	return c.setMePublic(ctx, map[string]interface{}{
 		"fn":     displayName,
 		"bot":    true,
 		"handle": handle,
-	}
-	pubBytes, _ := json.Marshal(pub)
-
-	msg := &pb.ClientMsg{
-		Message: &pb.ClientMsg_Set{
-			Set: &pb.ClientSet{
-				Id:    c.nextMsgID(),
-				Topic: "me",
-				Query: &pb.SetQuery{
-					Desc: &pb.SetDesc{
-						Public: pubBytes,
-					},
-				},
-			},
-		},
-	}
-
-	resp, err := c.sendAndReceive(ctx, msg)
-	if err != nil {
-		return err
-	}
-
-	if ctrl := resp.GetCtrl(); ctrl != nil {
-		if ctrl.Code >= 200 && ctrl.Code < 400 {
-			return nil
-		}
-		return fmt.Errorf("set failed: %d %s", ctrl.Code, ctrl.Text)
-	}
-
-	return nil
+	})
 }
 
 // InviteUserToTopic invites a different user (by Tinode UID) to a group topic.
@@ -0,0 +1,164 @@
+package tinode
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/tinode/chat/pbx"
+)
+
+// fakeTinode is an in-memory Tinode node: basic-auth accounts and their
+// public data, spoken over the real gRPC message loop.
+type fakeTinode struct {
+	pb.UnimplementedNodeServer
+
+	mu       sync.Mutex
+	accounts map[string]*fakeAccount // "login:password" → account
+	setCode  int32                   // response code for {set}, 200 if zero
+}
+
+type fakeAccount struct {
+	uid    string
+	public map[string]any
+}
+
+func (f *fakeTinode) MessageLoop(stream pb.Node_MessageLoopServer) error {
+	var me *fakeAccount
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		f.mu.Lock()
+		var id string
+		code, params := int32(200), map[string][]byte{}
+		switch m := msg.Message.(type) {
+		case *pb.ClientMsg_Hi:
+			id, code = m.Hi.Id, 201
+		case *pb.ClientMsg_Login:
+			id = m.Login.Id
+			if me = f.accounts[string(m.Login.Secret)]; me == nil {
+				code = 401
+			} else {
+				params["user"], _ = json.Marshal(me.uid)
+			}
+		case *pb.ClientMsg_Acc:
+			id = m.Acc.Id
+			if f.accounts[string(m.Acc.Secret)] != nil {
+				code = 409
+				break
+			}
+			me = &fakeAccount{uid: "usr" + strings.Repeat("x", len(f.accounts)+1)}
+			json.Unmarshal(m.Acc.Desc.Public, &me.public)
+			f.accounts[string(m.Acc.Secret)] = me
+			params["user"], _ = json.Marshal(me.uid)
+		case *pb.ClientMsg_Set:
+			id = m.Set.Id
+			switch {
+			case me == nil:
+				code = 401
+			case f.setCode != 0:
+				code = f.setCode
+			default:
+				me.public = nil
+				json.Unmarshal(m.Set.Query.Desc.Public, &me.public)
+			}
+		}
+		f.mu.Unlock()
+		stream.Send(&pb.ServerMsg{Message: &pb.ServerMsg_Ctrl{Ctrl: &pb.ServerCtrl{Id: id, Code: code, Params: params}}})
+	}
+}
+
+func (f *fakeTinode) public(secret string) map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if a := f.accounts[secret]; a != nil {
+		return a.public
+	}
+	return nil
+}
+
+// newFakeClient returns a Client connected to a fakeTinode.
+func newFakeClient(t *testing.T) (*Client, *fakeTinode) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	fake := &fakeTinode{accounts: map[string]*fakeAccount{}}
+	srv := grpc.NewServer()
+	pb.RegisterNodeServer(srv, fake)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///tinode",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{conn: conn, stub: pb.NewNodeClient(conn), apiKey: "key"}
+	t.Cleanup(func() { c.Close() })
+	return c, fake
+}
+
+func TestUpdateUserPublic(t *testing.T) {
+	c, fake := newFakeClient(t)
+	ctx := context.Background()
+
+	uid, err := c.EnsureUser(ctx, "user_abc", "pw", "Old Name")
+	if err != nil || uid == "" {
+		t.Fatalf("EnsureUser: %q %v", uid, err)
+	}
+	if fn := fake.public("user_abc:pw")["fn"]; fn != "Old Name" {
+		t.Fatalf("created with fn %v", fn)
+	}
+
+	// EnsureUser alone never renames an existing account
+	if _, err := c.EnsureUser(ctx, "user_abc", "pw", "New Name"); err != nil {
+		t.Fatal(err)
+	}
+	if fn := fake.public("user_abc:pw")["fn"]; fn != "Old Name" {
+		t.Errorf("EnsureUser changed fn to %v", fn)
+	}
+
+	if err := c.UpdateUserPublic(ctx, "user_abc", "pw", map[string]interface{}{"fn": "New Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if fn := fake.public("user_abc:pw")["fn"]; fn != "New Name" {
+		t.Errorf("fn = %v after update", fn)
+	}
+}
+
+func TestUpdateUserPublicErrors(t *testing.T) {
+	c, fake := newFakeClient(t)
+	ctx := context.Background()
+
+	// A missing account is an error, not a signup
+	if err := c.UpdateUserPublic(ctx, "user_missing", "pw", map[string]interface{}{"fn": "x"}); err == nil {
+		t.Error("update of a missing account succeeded")
+	}
+	if len(fake.accounts) != 0 {
+		t.Errorf("update created %d accounts", len(fake.accounts))
+	}
+
+	if _, err := c.EnsureUser(ctx, "user_abc", "pw", "Name"); err != nil {
+		t.Fatal(err)
+	}
+	fake.setCode = 403
+	err := c.UpdateUserPublic(ctx, "user_abc", "pw", map[string]interface{}{"fn": "x"})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("refused set: %v", err)
+	}
+
+	// The client recovers for the next call
+	fake.setCode = 0
+	if err := c.UpdateUserPublic(ctx, "user_abc", "pw", map[string]interface{}{"fn": "Later"}); err != nil {
+		t.Errorf("retry: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package tinode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Credential derivation for the Tinode accounts gather-auth manages. Logins
+// and passwords are derived, never stored, so every caller must use these
+// functions: changing a formula (or a default secret) orphans every existing
+// account derived with the old one.
+
+const (
+	// defaultUserSecret is used for user and agent passwords when
+	// TINODE_PASSWORD_SECRET is unset.
+	defaultUserSecret = "agency_tinode_sync_v1"
+	// defaultBotSecret is used for SDK bot passwords when
+	// TINODE_PASSWORD_SECRET is unset.
+	defaultBotSecret = "agency_bot_password_v1"
+)
+
+// UserLogin is the Tinode login for a PocketBase user.
+func UserLogin(pbUserID string) string {
+	return "pb_" + pbUserID
+}
+
+// AgentLogin is the Tinode login for an agent.
+func AgentLogin(agentID string) string {
+	return "agent_" + agentID
+}
+
+// UserPassword derives the Tinode password for a user or agent ID.
+func UserPassword(id, secret string) string {
+	if secret == "" {
+		secret = defaultUserSecret
+	}
+	return shortHash(id + "_" + secret)
+}
+
+// BotLogin derives the Tinode login for an SDK bot: "bot", the first 8 hex
+// chars of the workspace hash, then the handle's ASCII letters and digits.
+func BotLogin(workspaceID, handle string) string {
+	wsHash := sha256.Sum256([]byte(workspaceID))
+	wsShort := hex.EncodeToString(wsHash[:])[:8]
+	cleanHandle := ""
+	for _, c := range handle {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			cleanHandle += string(c)
+		}
+	}
+	return "bot" + wsShort + cleanHandle
+}
+
+// BotPassword derives the Tinode password for an SDK bot.
+func BotPassword(workspaceID, handle, secret string) string {
+	if secret == "" {
+		secret = defaultBotSecret
+	}
+	return shortHash(workspaceID + "_" + handle + "_" + secret)
+}
+
+func shortHash(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])[:24]
+}
@@ -0,0 +1,23 @@
+package tinode
+
+import "testing"
+
+// Golden values: these are the credentials of existing accounts, so any
+// change here locks those accounts out.
+func TestCredentials(t *testing.T) {
+	cases := []struct{ name, got, want string }{
+		{"UserLogin", UserLogin("user1"), "pb_user1"},
+		{"AgentLogin", AgentLogin("agent1"), "agent_agent1"},
+		{"UserPassword default secret", UserPassword("user1", ""), "2feb9fd5bb5382c4f94f24d0"},
+		{"UserPassword", UserPassword("user1", "s3cret"), "e4e29c9a25571a96a8d8c6e7"},
+		{"BotLogin", BotLogin("ws1", "my-bot"), "botbc937ad7mybot"},
+		{"BotLogin non-ASCII handle", BotLogin("ws1", "Bøt_9!"), "botbc937ad7Bt9"},
+		{"BotPassword default secret", BotPassword("ws1", "my-bot", ""), "2793b4e40ceccc8cab1437f4"},
+		{"BotPassword", BotPassword("ws1", "my-bot", "s3cret"), "a0593ba2755e87ba9b70e916"},
+	}
+	for _, tc := range cases {
+		if tc.got != tc.want {
+			t.Errorf("%s = %q, want %q", tc.name, tc.got, tc.want)
+		}
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"syscall"
+	"time"
 
 	"clay/core/connectors"
 )
@@ -34,6 +37,32 @@ func main() {
 
 	mb := connectors.NewMatterbridgeConnector(adkURL)
 
+	// Persistent queue for messages that arrive while ADK is down
+	queuePath := os.Getenv("BRIDGE_QUEUE_DB")
+	if queuePath == "" {
+		dbPath := os.Getenv("CLAY_DB")
+		if dbPath == "" {
+			dbPath = "/app/data/messages.db"
+		}
+		queuePath = filepath.Join(filepath.Dir(dbPath), "bridge-queue.db")
+	}
+	queueMax := 200
+	if v, err := strconv.Atoi(os.Getenv("BRIDGE_QUEUE_MAX")); err == nil && v > 0 {
+		queueMax = v
+	}
+	queueMaxAge := 6 * time.Hour
+	if v, err := time.ParseDuration(os.Getenv("BRIDGE_QUEUE_MAX_AGE")); err == nil && v > 0 {
+		queueMaxAge = v
+	}
+	if queue, err := connectors.NewMessageQueue(queuePath, queueMax, queueMaxAge); err != nil {
+		log.Printf("message queue disabled: %v", err)
+	} else {
+		defer queue.Close()
+		mb.SetQueue(queue)
+		fmt.Printf("  Queue: %s (max %d, %s)\n", queuePath, queueMax, queueMaxAge)
+		go mb.StartQueueDrain(ctx)
+	}
+
 	// Internal heartbeat (agent-controlled interval)
 	go mb.StartHeartbeat(ctx)
 
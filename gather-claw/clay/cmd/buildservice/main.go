@@ -8,12 +8,20 @@
 //     Success: 200 + binary as application/octet-stream
 //     Failure: 400 + JSON error with compilation output
 //
+// /build and /check require "Authorization: Bearer $BUILD_AUTH_TOKEN" when
+// BUILD_AUTH_TOKEN is set; /health is always open.
+//
+// Target architecture: a goarch query/form parameter or an X-Target-Arch
+// header (amd64 or arm64), defaulting to this host's. Binaries are always
+// GOOS=linux. The architecture built for is echoed in X-Build-Arch.
+//
 // Build: cd clay && go build -o clay-buildservice ./cmd/buildservice
-// Usage: BUILD_ADDR=:9090 ./clay-buildservice
+// Usage: BUILD_ADDR=:9090 BUILD_AUTH_TOKEN=... ./clay-buildservice
 
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -21,15 +29,52 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"runtime"
+	"strings"
 	"sync"
 	"time"
+
+	"clay/core/svcauth"
 )
 
+// builderVersion is reported in X-Builder-Version so swap manifests record
+// which builder produced a binary.
+const builderVersion = "clay-buildservice/1"
+
+// supportedArchs are the GOARCH values claws run on.
+var supportedArchs = map[string]bool{"amd64": true, "arm64": true}
+
 var (
 	buildMu    sync.Mutex
 	listenAddr string
+	authToken  string
 )
 
+// targetArch is the architecture a request asks to build for: the goarch
+// parameter, else the X-Target-Arch header, else this host's.
+func targetArch(r *http.Request) (string, error) {
+	arch := r.URL.Query().Get("goarch")
+	if arch == "" && strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		arch = r.PostFormValue("goarch")
+	}
+	if arch == "" {
+		arch = r.Header.Get("X-Target-Arch")
+	}
+	arch = strings.ToLower(strings.TrimSpace(arch))
+	if arch == "" {
+		return runtime.GOARCH, nil
+	}
+	if !supportedArchs[arch] {
+		return "", fmt.Errorf("unsupported target architecture %q (supported: amd64, arm64)", arch)
+	}
+	return arch, nil
+}
+
+// buildEnv is the go build environment for a linux/arch binary.
+func buildEnv(arch string) []string {
+	return append(os.Environ(), "CGO_ENABLED=0", "GOOS=linux", "GOARCH="+arch)
+}
+
 type errorResponse struct {
 	Success bool   `json:"success"`
 	Output  string `json:"output"`
@@ -38,6 +83,7 @@ type errorResponse struct {
 
 func init() {
 	listenAddr = getEnv("BUILD_ADDR", ":9090")
+	authToken = os.Getenv("BUILD_AUTH_TOKEN")
 }
 
 func handleBuild(w http.ResponseWriter, r *http.Request) {
@@ -45,6 +91,12 @@ func handleBuild(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "POST only", http.StatusMethodNotAllowed)
 		return
 	}
+	arch, err := targetArch(r)
+	if err != nil {
+		sendError(w, err.Error(), "")
+		return
+	}
+	w.Header().Set("X-Build-Arch", arch)
 
 	// One build at a time
 	if !buildMu.TryLock() {
@@ -58,7 +110,7 @@ func handleBuild(w http.ResponseWriter, r *http.Request) {
 	}
 	defer buildMu.Unlock()
 
-	log.Printf("Build request received (%d bytes)", r.ContentLength)
+	log.Printf("Build request received (%d bytes, linux/%s)", r.ContentLength, arch)
 
 	// 1. Create temp directory for this build
 	tmpDir, err := os.MkdirTemp("", "claw-build-*")
@@ -85,7 +137,7 @@ func handleBuild(w http.ResponseWriter, r *http.Request) {
 	binaryPath := tmpDir + "/clay"
 	cmd := exec.Command("go", "build", "-ldflags=-s -w", "-o", binaryPath, ".")
 	cmd.Dir = srcDir
-	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS=linux")
+	cmd.Env = buildEnv(arch)
 
 	done := make(chan struct{})
 	var buildOutput []byte
@@ -120,10 +172,11 @@ func handleBuild(w http.ResponseWriter, r *http.Request) {
 	defer binary.Close()
 
 	info, _ := binary.Stat()
-	log.Printf("Build succeeded: %d bytes", info.Size())
+	log.Printf("Build succeeded: %d bytes (linux/%s)", info.Size(), arch)
 
 	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("X-Build-Output", "compilation successful")
+	w.Header().Set("X-Builder-Version", builderVersion+" "+goVersion())
 	w.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size()))
 	io.Copy(w, binary)
 }
@@ -148,6 +201,12 @@ func handleCheck(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "POST only", http.StatusMethodNotAllowed)
 		return
 	}
+	arch, err := targetArch(r)
+	if err != nil {
+		sendError(w, err.Error(), "")
+		return
+	}
+	w.Header().Set("X-Build-Arch", arch)
 
 	if !buildMu.TryLock() {
 		w.Header().Set("Content-Type", "application/json")
@@ -160,7 +219,7 @@ func handleCheck(w http.ResponseWriter, r *http.Request) {
 	}
 	defer buildMu.Unlock()
 
-	log.Printf("Check request received (%d bytes)", r.ContentLength)
+	log.Printf("Check request received (%d bytes, linux/%s)", r.ContentLength, arch)
 
 	tmpDir, err := os.MkdirTemp("", "claw-check-*")
 	if err != nil {
@@ -182,7 +241,7 @@ func handleCheck(w http.ResponseWriter, r *http.Request) {
 	// Compile ALL packages to surface every error at once
 	cmd := exec.Command("go", "build", "./...")
 	cmd.Dir = srcDir
-	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS=linux")
+	cmd.Env = buildEnv(arch)
 
 	done := make(chan struct{})
 	var buildOutput []byte
@@ -225,10 +284,13 @@ func handleCheck(w http.ResponseWriter, r *http.Request) {
 
 func main() {
 	log.Printf("Build service starting on %s", listenAddr)
+	if authToken == "" {
+		log.Printf("WARNING: BUILD_AUTH_TOKEN not set — /build and /check are open to anyone on the network")
+	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/build", handleBuild)
-	mux.HandleFunc("/check", handleCheck)
+	mux.Handle("/build", svcauth.RequireFunc(authToken, handleBuild))
+	mux.Handle("/check", svcauth.RequireFunc(authToken, handleCheck))
 	mux.HandleFunc("/health", handleHealth)
 
 	server := &http.Server{
@@ -243,6 +305,17 @@ func main() {
 	}
 }
 
+// goVersion is the toolchain that compiles agent builds, falling back to the
+// one this service was built with.
+func goVersion() string {
+	if out, err := exec.Command("go", "env", "GOVERSION").Output(); err == nil {
+		if v := string(bytes.TrimSpace(out)); v != "" {
+			return v
+		}
+	}
+	return runtime.Version()
+}
+
 func getEnv(key, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
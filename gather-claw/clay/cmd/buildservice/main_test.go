@@ -0,0 +1,118 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"debug/elf"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestTargetArch(t *testing.T) {
+	cases := []struct {
+		name, query, contentType, body, header string
+		want                                   string
+		wantErr                                bool
+	}{
+		{name: "default", want: runtime.GOARCH},
+		{name: "query", query: "goarch=arm64", want: "arm64"},
+		{name: "header", header: "amd64", want: "amd64"},
+		{name: "header, any case", header: " ARM64 ", want: "arm64"},
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: "goarch=arm64", want: "arm64"},
+		{name: "query wins over header", query: "goarch=amd64", header: "arm64", want: "amd64"},
+		{name: "form ignored for tarballs", contentType: "application/gzip", body: "goarch=arm64", want: runtime.GOARCH},
+		{name: "unsupported query", query: "goarch=386", wantErr: true},
+		{name: "unsupported header", header: "riscv64", wantErr: true},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("POST", "/build?"+tc.query, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			r.Header.Set("Content-Type", tc.contentType)
+		}
+		if tc.header != "" {
+			r.Header.Set("X-Target-Arch", tc.header)
+		}
+		got, err := targetArch(r)
+		switch {
+		case tc.wantErr && err == nil:
+			t.Errorf("%s: accepted as %q", tc.name, got)
+		case !tc.wantErr && (err != nil || got != tc.want):
+			t.Errorf("%s: %q %v, want %q", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestUnsupportedArchRefused(t *testing.T) {
+	for _, h := range []http.HandlerFunc{handleBuild, handleCheck} {
+		r := httptest.NewRequest("POST", "/build?goarch=mips", strings.NewReader(""))
+		w := httptest.NewRecorder()
+		h(w, r)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "unsupported target architecture") {
+			t.Errorf("status %d: %s", w.Code, w.Body.String())
+		}
+		if w.Header().Get("X-Build-Arch") != "" {
+			t.Errorf("X-Build-Arch set on a refused request")
+		}
+	}
+}
+
+// sourceTarball returns a gzipped tarball of a one-file main module.
+func sourceTarball(t *testing.T) []byte {
+	t.Helper()
+	files := map[string]string{
+		"go.mod":  "module hello\n\ngo 1.21\n",
+		"main.go": "package main\n\nfunc main() { println(\"hello\") }\n",
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestBuildForTargetArch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("cross-compiles a binary")
+	}
+	machines := map[string]elf.Machine{"amd64": elf.EM_X86_64, "arm64": elf.EM_AARCH64}
+
+	// The requested arch wins over a GOARCH in the service's own environment
+	t.Setenv("GOARCH", runtime.GOARCH)
+	for arch, machine := range machines {
+		r := httptest.NewRequest("POST", "/build", bytes.NewReader(sourceTarball(t)))
+		r.Header.Set("X-Target-Arch", arch)
+		w := httptest.NewRecorder()
+		handleBuild(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", arch, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("X-Build-Arch"); got != arch {
+			t.Errorf("%s: X-Build-Arch %q", arch, got)
+		}
+		bin, err := elf.NewFile(bytes.NewReader(w.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: %v", arch, err)
+		}
+		if bin.Machine != machine {
+			t.Errorf("%s: binary is for %v", arch, bin.Machine)
+		}
+
+		r = httptest.NewRequest("POST", "/check?goarch="+arch, bytes.NewReader(sourceTarball(t)))
+		w = httptest.NewRecorder()
+		handleCheck(w, r)
+		if w.Code != http.StatusOK || w.Header().Get("X-Build-Arch") != arch {
+			t.Errorf("%s check: status %d, X-Build-Arch %q", arch, w.Code, w.Header().Get("X-Build-Arch"))
+		}
+	}
+}
@@ -4,11 +4,23 @@
 // manages binary hot-swaps from the external build service.
 //
 // Hot-swap flow:
-//   1. Build service writes new binary to /app/builds/clay.new
-//   2. Medic detects the file, backs up current binary to .prev
-//   3. Medic replaces current binary and restarts the agent
+//   1. Build side writes clay.new.json (swap manifest), then /app/builds/clay.new
+//   2. Medic detects the binary and reads the manifest; it refuses the swap if
+//      the manifest is missing, doesn't match the binary, was built for another
+//      architecture, or names the source hash of the last build that had to be
+//      rolled back
+//   3. Medic backs up current binary to .prev, replaces it and restarts the agent
 //   4. If new binary crashes within 30s: revert to .prev, log failure
-//   5. Agent reads failure logs on next startup to learn what went wrong
+//   5. Agent reads data/build-failures/failures.json on next startup to learn
+//      what went wrong (latest failure per category; older logs are pruned)
+//
+// Manifests: clay.current.json describes the running binary, clay.prev.json
+// the one before it, and data/build-failures/clay.bad.json the last build
+// that was rolled back, so the agent can diff what went wrong.
+//
+// On startup each agent is checked for an executable binary and working dir.
+// Agents without one (a fresh container before the first build) are "not yet
+// installed" and skipped by crash handling until a hot-swap installs them.
 //
 // Build: cd clay && go build -o clay-medic ./cmd/medic
 // Usage: ./clay-medic
@@ -18,6 +30,10 @@ package main
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -26,6 +42,9 @@ import (
 	"os/signal"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -37,6 +56,7 @@ import (
 // ---------------------------------------------------------------------------
 
 type agentConfig struct {
+	Binary         string // must exist and be executable before the agent is supervised
 	LogFile        string
 	WorkingDir     string
 	HealthURL      string
@@ -62,6 +82,7 @@ func clayRestartCmd() string {
 
 var agents = map[string]agentConfig{
 	"clay": {
+		Binary:         binaryPath,
 		LogFile:        "/tmp/adk-go.log",
 		WorkingDir:     projectRoot(),
 		HealthURL:      "http://127.0.0.1:" + adkPort(),
@@ -69,6 +90,7 @@ var agents = map[string]agentConfig{
 		RestartCmd:     clayRestartCmd(),
 	},
 	"clay-bridge": {
+		Binary:         projectRoot() + "/clay-bridge",
 		LogFile:        "/tmp/bridge.log",
 		WorkingDir:     projectRoot(),
 		ProcessPattern: "clay-bridge",
@@ -94,6 +116,7 @@ const (
 	healthTimeout       = 5 * time.Second
 	logContextLines     = 80
 	startupWait         = 8 * time.Second
+	processStartWait    = 3 * time.Second
 	initialHealthDelay  = 30 * time.Second
 	hotSwapCheckInterval = 5 * time.Second
 	hotSwapStabilityWait = 30 * time.Second
@@ -105,6 +128,11 @@ var (
 	newBinaryPath = projectRoot() + "/builds/clay.new"
 	prevBinaryPath = projectRoot() + "/clay.prev"
 	failureLogDir  = projectRoot() + "/data/build-failures"
+
+	newManifestPath     = newBinaryPath + ".json"
+	currentManifestPath = projectRoot() + "/clay.current.json"
+	prevManifestPath    = projectRoot() + "/clay.prev.json"
+	badManifestPath     = failureLogDir + "/clay.bad.json"
 )
 
 func init() {
@@ -131,6 +159,14 @@ var (
 	lastActionMu sync.Mutex
 )
 
+// installed records which agents have a binary to run. Agents that are not
+// yet installed (a fresh container before the build service has produced
+// clay) are left alone by crash handling instead of being restarted forever.
+var (
+	installed   = make(map[string]bool)
+	installedMu sync.Mutex
+)
+
 // ---------------------------------------------------------------------------
 // Logging
 // ---------------------------------------------------------------------------
@@ -166,6 +202,54 @@ func checkHealth(cfg agentConfig) bool {
 	return resp.StatusCode > 0 && resp.StatusCode < 500
 }
 
+// ---------------------------------------------------------------------------
+// Startup self-check
+// ---------------------------------------------------------------------------
+
+// checkInstalled reports why an agent can't be run yet: a missing or
+// non-executable binary, or a missing working dir.
+func checkInstalled(cfg agentConfig) error {
+	info, err := os.Stat(cfg.Binary)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("%s is not an executable file", cfg.Binary)
+	}
+	if info, err := os.Stat(cfg.WorkingDir); err != nil {
+		return fmt.Errorf("working dir: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("working dir %s is not a directory", cfg.WorkingDir)
+	}
+	return nil
+}
+
+// classifyAgent re-checks whether an agent is installed and logs only when
+// the classification changes, so a missing binary is reported once.
+func classifyAgent(agentName string) bool {
+	err := checkInstalled(agents[agentName])
+	ok := err == nil
+
+	installedMu.Lock()
+	prev, seen := installed[agentName]
+	installed[agentName] = ok
+	installedMu.Unlock()
+
+	switch {
+	case ok && (!seen || !prev):
+		logMsg("%s: installed, should be running", agentName)
+	case !ok && (!seen || prev):
+		logMsg("%s: not yet installed (%v) — crash handling off until it is", agentName, err)
+	}
+	return ok
+}
+
+func isInstalled(agentName string) bool {
+	installedMu.Lock()
+	defer installedMu.Unlock()
+	return installed[agentName]
+}
+
 // ---------------------------------------------------------------------------
 // Error capture (replaces Claude Code diagnosis)
 // ---------------------------------------------------------------------------
@@ -195,6 +279,10 @@ func captureContext(logFile string) string {
 // Restart
 // ---------------------------------------------------------------------------
 
+func processRunning(cfg agentConfig) bool {
+	return exec.Command("pgrep", "-f", cfg.ProcessPattern).Run() == nil
+}
+
 func killAgent(cfg agentConfig) {
 	out, err := exec.Command("pgrep", "-f", cfg.ProcessPattern).Output()
 	if err != nil {
@@ -223,7 +311,25 @@ func startAgent(agentName string, cfg agentConfig) bool {
 		logMsg("Failed to start %s: %v", agentName, err)
 		return false
 	}
-	cmd.Process.Release()
+
+	// The restart command runs in the foreground of its shell, so the shell
+	// exiting means the agent did too. Otherwise confirm with pgrep.
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	select {
+	case err := <-exited:
+		if err == nil {
+			err = errors.New("exit status 0")
+		}
+		logMsg("%s exited right after starting (%v) — see %s", agentName, err, cfg.LogFile)
+		return false
+	case <-time.After(processStartWait):
+	}
+	if !processRunning(cfg) {
+		logMsg("%s not found by pgrep %v after starting", agentName, processStartWait)
+		return false
+	}
 	return true
 }
 
@@ -241,6 +347,11 @@ func handleCrash(ctx context.Context, agentName string, cfg agentConfig, trigger
 	lastAction[agentName] = now
 	lastActionMu.Unlock()
 
+	// Nothing to restart yet; classifyAgent already said so
+	if !isInstalled(agentName) {
+		return
+	}
+
 	logMsg("CRASH DETECTED: %s", agentName)
 	trimmed := trigger
 	if len(trimmed) > 200 {
@@ -258,7 +369,11 @@ func handleCrash(ctx context.Context, agentName string, cfg agentConfig, trigger
 	errContext := captureContext(cfg.LogFile)
 
 	// Log the crash for the agent to learn from on next startup
-	writeFailureLog(agentName, "crash", errContext)
+	var running *swapManifest
+	if agentName == "clay" {
+		running, _ = readSwapManifest(currentManifestPath)
+	}
+	writeFailureLog(agentName, "crash", errContext, running)
 
 	// Simple restart (up to maxRestartAttempts)
 	for attempt := 1; attempt <= maxRestartAttempts; attempt++ {
@@ -277,7 +392,7 @@ func handleCrash(ctx context.Context, agentName string, cfg agentConfig, trigger
 			return
 		}
 		if cfg.HealthURL == "" {
-			logMsg("SUCCESS: %s restarted (no health endpoint to verify)", agentName)
+			logMsg("SUCCESS: %s restarted (process running, no health endpoint to verify)", agentName)
 			return
 		}
 
@@ -315,42 +430,84 @@ func watchForNewBinary(ctx context.Context) {
 func performHotSwap(ctx context.Context) {
 	cfg := agents["clay"]
 
-	// 1. Backup current binary
-	logMsg("Backing up current binary to %s", prevBinaryPath)
-	if err := copyFile(binaryPath, prevBinaryPath); err != nil {
-		logMsg("Failed to backup binary: %v", err)
+	// 0. Read and check the swap manifest before touching anything
+	manifest, err := readSwapManifest(newManifestPath)
+	if err == nil {
+		bad, _ := readSwapManifest(badManifestPath)
+		err = checkSwapManifest(manifest, bad, newBinaryPath)
+	}
+	if err != nil {
+		logMsg("Refusing hot-swap: %v", err)
+		writeFailureLog("clay", "hot-swap-rejected", err.Error(), manifest)
 		os.Remove(newBinaryPath)
+		os.Remove(newManifestPath)
 		return
 	}
+	swapLog := func(format string, args ...any) {
+		logMsg("[%s] "+format, append([]any{manifest.summary()}, args...)...)
+	}
+	swapLog("Hot-swap starting (reason: %s)", manifest.Reason)
+
+	// 1. Backup current binary. On first install there is nothing to back up,
+	// and a leftover .prev from an earlier container must not be restored.
+	_, statErr := os.Stat(binaryPath)
+	firstInstall := errors.Is(statErr, os.ErrNotExist)
+	if firstInstall {
+		swapLog("No current binary — first install")
+		os.Remove(prevBinaryPath)
+		os.Remove(prevManifestPath)
+	} else {
+		swapLog("Backing up current binary to %s", prevBinaryPath)
+		if err := copyFile(binaryPath, prevBinaryPath); err != nil {
+			swapLog("Failed to backup binary: %v", err)
+			os.Remove(newBinaryPath)
+			os.Remove(newManifestPath)
+			return
+		}
+	}
 
 	// 2. Stop current agent
-	logMsg("Stopping current clay...")
+	swapLog("Stopping current clay...")
 	killAgent(cfg)
 
 	// 3. Replace binary
-	logMsg("Replacing binary with new version...")
+	swapLog("Replacing binary with new version...")
 	if err := copyFile(newBinaryPath, binaryPath); err != nil {
-		logMsg("Failed to replace binary: %v — reverting", err)
-		copyFile(prevBinaryPath, binaryPath)
+		swapLog("Failed to replace binary: %v — reverting", err)
 		os.Remove(newBinaryPath)
+		os.Remove(newManifestPath)
+		if firstInstall {
+			os.Remove(binaryPath)
+			return
+		}
+		copyFile(prevBinaryPath, binaryPath)
 		startAgent("clay", cfg)
 		return
 	}
 	os.Chmod(binaryPath, 0755)
 	os.Remove(newBinaryPath)
+	if firstInstall {
+		classifyAgent("clay")
+	}
+
+	// The running binary's manifest becomes the previous one
+	os.Remove(prevManifestPath)
+	os.Rename(currentManifestPath, prevManifestPath)
+	if err := os.Rename(newManifestPath, currentManifestPath); err != nil {
+		swapLog("Failed to record current manifest: %v", err)
+	}
 
 	// 4. Start new binary
-	logMsg("Starting new binary...")
+	swapLog("Starting new binary...")
 	if !startAgent("clay", cfg) {
-		logMsg("Failed to start new binary — reverting")
-		copyFile(prevBinaryPath, binaryPath)
-		startAgent("clay", cfg)
-		writeFailureLog("clay", "hot-swap", "Failed to start new binary")
+		swapLog("Failed to start new binary — reverting")
+		rollbackSwap(cfg, manifest)
+		writeFailureLog("clay", "hot-swap", "Failed to start new binary", manifest)
 		return
 	}
 
 	// 5. Wait for stability period
-	logMsg("Watching for stability (%v)...", hotSwapStabilityWait)
+	swapLog("Watching for stability (%v)...", hotSwapStabilityWait)
 	stableUntil := time.Now().Add(hotSwapStabilityWait)
 
 	for time.Now().Before(stableUntil) {
@@ -366,21 +523,158 @@ func performHotSwap(ctx context.Context) {
 				continue
 			}
 
-			logMsg("New binary appears dead during stability check — reverting")
+			swapLog("New binary appears dead during stability check — reverting")
 			errContext := captureContext(cfg.LogFile)
-			writeFailureLog("clay", "hot-swap-crash", errContext)
+			writeFailureLog("clay", "hot-swap-crash", errContext, manifest)
 
 			killAgent(cfg)
-			logMsg("Restoring previous binary...")
-			copyFile(prevBinaryPath, binaryPath)
-			os.Chmod(binaryPath, 0755)
-			startAgent("clay", cfg)
-			logMsg("Reverted to previous binary")
+			rollbackSwap(cfg, manifest)
+			swapLog("Reverted to previous binary")
 			return
 		}
 	}
 
-	logMsg("Hot-swap SUCCESS: new binary is stable")
+	swapLog("Hot-swap SUCCESS: new binary is stable")
+}
+
+// rollbackSwap restores the previous binary and its manifest, and keeps the
+// failed build's manifest as the known-bad one. After a failed first install
+// there is no previous binary, so the agent goes back to not installed.
+func rollbackSwap(cfg agentConfig, failed *swapManifest) {
+	logMsg("Restoring previous binary...")
+	restored := copyFile(prevBinaryPath, binaryPath) == nil
+	if restored {
+		os.Chmod(binaryPath, 0755)
+	} else {
+		logMsg("No previous binary to restore — removing the failed one")
+		os.Remove(binaryPath)
+	}
+
+	if err := writeSwapManifest(badManifestPath, failed); err != nil {
+		logMsg("Failed to record known-bad manifest: %v", err)
+	}
+	os.Remove(currentManifestPath)
+	if _, err := os.Stat(prevManifestPath); err == nil {
+		copyFile(prevManifestPath, currentManifestPath)
+	}
+
+	if !restored {
+		classifyAgent("clay")
+		return
+	}
+	startAgent("clay", cfg)
+}
+
+// ---------------------------------------------------------------------------
+// Swap manifests
+// ---------------------------------------------------------------------------
+
+// swapManifest is written by the build side next to clay.new. It ties a
+// binary back to the source and request that produced it.
+type swapManifest struct {
+	BuildTime      string `json:"build_time"`
+	SourceHash     string `json:"source_hash"`
+	Reason         string `json:"reason,omitempty"`
+	BuilderVersion string `json:"builder_version,omitempty"`
+	Arch           string `json:"arch,omitempty"`
+	BinarySHA256   string `json:"binary_sha256,omitempty"`
+	BinarySize     int64  `json:"binary_size,omitempty"`
+}
+
+var errManifestMissing = errors.New("swap manifest missing")
+
+// readSwapManifest loads a manifest. An absent file returns an error wrapping
+// errManifestMissing; unreadable JSON or a manifest without a source hash is
+// reported as corrupted.
+func readSwapManifest(path string) (*swapManifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", errManifestMissing, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read swap manifest %s: %w", path, err)
+	}
+	var m swapManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("corrupted swap manifest %s: %v", path, err)
+	}
+	if m.SourceHash == "" {
+		return nil, fmt.Errorf("corrupted swap manifest %s: no source_hash", path)
+	}
+	return &m, nil
+}
+
+func writeSwapManifest(path string, m *swapManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// checkSwapManifest rejects a build whose source hash matches the last
+// rolled-back build, that was built for another architecture than this
+// host's, or whose manifest describes a different binary than the one at
+// binPath (a stale manifest left by an earlier build). Manifests from before
+// builds recorded an architecture aren't checked for it.
+func checkSwapManifest(m, lastBad *swapManifest, binPath string) error {
+	if lastBad != nil && lastBad.SourceHash == m.SourceHash {
+		return fmt.Errorf("source %s is the build that was rolled back at %s; change the source before deploying again",
+			m.shortHash(), lastBad.BuildTime)
+	}
+	if m.Arch != "" && m.Arch != runtime.GOARCH {
+		return fmt.Errorf("source %s was built for linux/%s but this host is linux/%s; rebuild for %s",
+			m.shortHash(), m.Arch, runtime.GOARCH, runtime.GOARCH)
+	}
+	if m.BinarySHA256 == "" {
+		return nil
+	}
+	sum, err := fileSHA256(binPath)
+	if err != nil {
+		return fmt.Errorf("hash new binary: %w", err)
+	}
+	if sum != m.BinarySHA256 {
+		return fmt.Errorf("manifest for source %s does not match %s (binary sha256 %s, manifest says %s)",
+			m.shortHash(), binPath, sum[:12], m.BinarySHA256)
+	}
+	return nil
+}
+
+func (m *swapManifest) shortHash() string {
+	if len(m.SourceHash) > 12 {
+		return m.SourceHash[:12]
+	}
+	return m.SourceHash
+}
+
+// summary is the one-line form used in log lines and failure log headers.
+func (m *swapManifest) summary() string {
+	s := "src=" + m.shortHash() + " built=" + m.BuildTime
+	if m.BuilderVersion != "" {
+		s += " builder=" + m.BuilderVersion
+	}
+	if m.Arch != "" {
+		s += " arch=" + m.Arch
+	}
+	return s
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 func copyFile(src, dst string) error {
@@ -404,16 +698,192 @@ func copyFile(src, dst string) error {
 	return out.Close()
 }
 
-func writeFailureLog(agentName, category, content string) {
+// ---------------------------------------------------------------------------
+// Failure logs: retention + digest
+// ---------------------------------------------------------------------------
+
+// Failure logs are named <timestamp>_<agent>_<category>.log. Only the newest
+// failureLogKeep per agent/category are kept, and failures.json summarizes
+// the latest of each so the agent can read one file on startup.
+
+const (
+	defaultFailureLogKeep = 20
+	digestExcerptLines    = 20
+)
+
+var failureDigestPath = failureLogDir + "/failures.json"
+
+type failureDigestEntry struct {
+	Agent    string `json:"agent"`
+	Category string `json:"category"`
+	Time     string `json:"time"`
+	Build    string `json:"build,omitempty"` // swap manifest summary of the binary involved
+	File     string `json:"file"`
+	Count    int    `json:"count"` // failure logs retained for this agent/category
+	Excerpt  string `json:"excerpt"`
+}
+
+type failureDigest struct {
+	Generated string               `json:"generated"`
+	Failures  []failureDigestEntry `json:"failures"`
+}
+
+// failureLogKeep returns how many logs to keep per agent/category
+// (MEDIC_FAILURE_LOG_KEEP, default 20).
+func failureLogKeep() int {
+	if v, err := strconv.Atoi(os.Getenv("MEDIC_FAILURE_LOG_KEEP")); err == nil && v > 0 {
+		return v
+	}
+	return defaultFailureLogKeep
+}
+
+// writeFailureLog records a failure. manifest describes the binary involved
+// and may be nil (non-clay agents, or a binary deployed without one).
+func writeFailureLog(agentName, category, content string, manifest *swapManifest) {
 	os.MkdirAll(failureLogDir, 0755)
 	ts := time.Now().Format("2006-01-02T15-04-05")
 	filename := filepath.Join(failureLogDir, fmt.Sprintf("%s_%s_%s.log", ts, agentName, category))
 
-	header := fmt.Sprintf("Agent: %s\nCategory: %s\nTime: %s\n---\n\n",
+	header := fmt.Sprintf("Agent: %s\nCategory: %s\nTime: %s\n",
 		agentName, category, time.Now().Format(time.RFC3339))
+	if manifest != nil {
+		header += fmt.Sprintf("Build: %s\nSource-Hash: %s\nReason: %s\n",
+			manifest.summary(), manifest.SourceHash, manifest.Reason)
+	}
+	header += "---\n\n"
 
 	os.WriteFile(filename, []byte(header+content), 0644)
 	logMsg("Failure log written: %s", filename)
+
+	pruneFailureLogs()
+	writeFailureDigest()
+}
+
+// failureLogGroups returns failure log filenames grouped by "agent_category",
+// each group sorted oldest first (timestamps sort lexically).
+func failureLogGroups() map[string][]string {
+	entries, err := os.ReadDir(failureLogDir)
+	if err != nil {
+		return nil
+	}
+	groups := make(map[string][]string)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSuffix(name, ".log"), "_", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		groups[parts[1]] = append(groups[parts[1]], name)
+	}
+	for _, files := range groups {
+		sort.Strings(files)
+	}
+	return groups
+}
+
+// pruneFailureLogs deletes all but the newest failureLogKeep() logs in each
+// agent/category.
+func pruneFailureLogs() {
+	keep := failureLogKeep()
+	removed := 0
+	for _, files := range failureLogGroups() {
+		for i := 0; i < len(files)-keep; i++ {
+			if os.Remove(filepath.Join(failureLogDir, files[i])) == nil {
+				removed++
+			}
+		}
+	}
+	if removed > 0 {
+		logMsg("Pruned %d old failure logs (keeping %d per category)", removed, keep)
+	}
+}
+
+// writeFailureDigest rewrites failures.json with the latest failure per
+// agent/category. It writes a temp file and renames it so readers never see
+// a partial file.
+func writeFailureDigest() {
+	digest := failureDigest{
+		Generated: time.Now().Format(time.RFC3339),
+		Failures:  []failureDigestEntry{},
+	}
+	for _, files := range failureLogGroups() {
+		latest := files[len(files)-1]
+		entry := readFailureLog(filepath.Join(failureLogDir, latest))
+		entry.File = latest
+		entry.Count = len(files)
+		digest.Failures = append(digest.Failures, entry)
+	}
+	// Newest first
+	sort.Slice(digest.Failures, func(i, j int) bool {
+		return digest.Failures[i].File > digest.Failures[j].File
+	})
+
+	data, err := json.MarshalIndent(digest, "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := failureDigestPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		logMsg("Failed to write failure digest: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, failureDigestPath); err != nil {
+		logMsg("Failed to write failure digest: %v", err)
+		os.Remove(tmp)
+	}
+}
+
+// readFailureLog parses the header written by writeFailureLog and returns
+// the first digestExcerptLines lines of the body.
+func readFailureLog(path string) failureDigestEntry {
+	var entry failureDigestEntry
+	f, err := os.Open(path)
+	if err != nil {
+		return entry
+	}
+	defer f.Close()
+
+	var excerpt []string
+	inBody := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() && len(excerpt) < digestExcerptLines {
+		line := scanner.Text()
+		if !inBody {
+			switch {
+			case line == "---":
+				inBody = true
+			case strings.HasPrefix(line, "Agent: "):
+				entry.Agent = strings.TrimPrefix(line, "Agent: ")
+			case strings.HasPrefix(line, "Category: "):
+				entry.Category = strings.TrimPrefix(line, "Category: ")
+			case strings.HasPrefix(line, "Time: "):
+				entry.Time = strings.TrimPrefix(line, "Time: ")
+			case strings.HasPrefix(line, "Build: "):
+				entry.Build = strings.TrimPrefix(line, "Build: ")
+			}
+			continue
+		}
+		if len(excerpt) == 0 && line == "" {
+			continue // blank line after the header
+		}
+		excerpt = append(excerpt, line)
+	}
+	entry.Excerpt = strings.Join(excerpt, "\n")
+	return entry
+}
+
+// printFailures prints the digest for the "failures" stdin command.
+func printFailures() {
+	data, err := os.ReadFile(failureDigestPath)
+	if err != nil {
+		logMsg("No failure digest (%v)", err)
+		return
+	}
+	fmt.Println(string(data))
 }
 
 // ---------------------------------------------------------------------------
@@ -479,7 +949,7 @@ func periodicHealthCheck(ctx context.Context) {
 			return
 		case <-ticker.C:
 			for name, cfg := range agents {
-				if cfg.HealthURL == "" {
+				if cfg.HealthURL == "" || !isInstalled(name) {
 					continue
 				}
 				if !checkHealth(cfg) {
@@ -494,6 +964,9 @@ func periodicHealthCheck(ctx context.Context) {
 					handleCrash(ctx, name, cfg, "periodic health check — no response")
 				}
 			}
+			if q, ok := bridgeQueueStatus(); ok && q.Depth > 0 {
+				logMsg("Bridge backlog: %d message(s) queued, oldest %.0fs", q.Depth, q.OldestAgeSecs)
+			}
 		}
 	}
 }
@@ -506,14 +979,16 @@ func printStatus() {
 	logMsg("Agent status:")
 	for name, cfg := range agents {
 		status := "unknown"
-		if cfg.HealthURL != "" {
+		if !isInstalled(name) {
+			status = "not yet installed"
+		} else if cfg.HealthURL != "" {
 			if checkHealth(cfg) {
 				status = "UP"
 			} else {
 				status = "DOWN"
 			}
 		} else {
-			if err := exec.Command("pgrep", "-f", cfg.ProcessPattern).Run(); err == nil {
+			if processRunning(cfg) {
 				status = "running (no health URL)"
 			} else {
 				status = "not found"
@@ -521,6 +996,38 @@ func printStatus() {
 		}
 		logMsg("  %s: %s (log: %s)", name, status, cfg.LogFile)
 	}
+	if q, ok := bridgeQueueStatus(); ok {
+		logMsg("  bridge queue: %d message(s), oldest %.0fs", q.Depth, q.OldestAgeSecs)
+	}
+}
+
+// bridgeQueue is the queue section of the bridge's /status response.
+type bridgeQueue struct {
+	Depth         int     `json:"depth"`
+	OldestAgeSecs float64 `json:"oldest_age_seconds"`
+}
+
+// bridgeQueueStatus reads the bridge's undelivered-message queue from its
+// /status endpoint. ok is false if the bridge is unreachable or has no queue.
+func bridgeQueueStatus() (bridgeQueue, bool) {
+	statusURL := os.Getenv("BRIDGE_STATUS_URL")
+	if statusURL == "" {
+		statusURL = "http://127.0.0.1:8082/status"
+	}
+	client := &http.Client{Timeout: healthTimeout}
+	resp, err := client.Get(statusURL)
+	if err != nil {
+		return bridgeQueue{}, false
+	}
+	defer resp.Body.Close()
+
+	var status struct {
+		Queue *bridgeQueue `json:"queue"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil || status.Queue == nil {
+		return bridgeQueue{}, false
+	}
+	return *status.Queue, true
 }
 
 // ---------------------------------------------------------------------------
@@ -539,8 +1046,16 @@ func main() {
 	logMsg("Death signatures: %d", len(deathSignatures))
 	logMsg("Cooldown: %ds | Health check every: %v", cooldownSeconds, healthCheckInterval)
 
-	// Ensure failure log dir exists
+	// Ensure failure log dir exists, apply retention and refresh the digest
 	os.MkdirAll(failureLogDir, 0755)
+	pruneFailureLogs()
+	writeFailureDigest()
+	logMsg("Failure logs: %s (keeping %d per category)", failureLogDir, failureLogKeep())
+
+	// Self-check: which agents have a binary to run yet
+	for name := range agents {
+		classifyAgent(name)
+	}
 
 	// Start log watcher goroutines
 	for name, cfg := range agents {
@@ -572,8 +1087,11 @@ func main() {
 				}
 				continue
 			}
-			if strings.TrimSpace(line) == "status" {
+			switch strings.TrimSpace(line) {
+			case "status":
 				printStatus()
+			case "failures":
+				printFailures()
 			}
 		}
 	}()
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestReadSwapManifest(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	m, err := readSwapManifest(write("ok.json", `{"build_time":"2026-01-02T03:04:05Z","source_hash":"abc","reason":"fix bug"}`))
+	if err != nil || m.SourceHash != "abc" || m.Reason != "fix bug" {
+		t.Errorf("valid manifest: %+v %v", m, err)
+	}
+
+	if _, err := readSwapManifest(filepath.Join(dir, "absent.json")); !errors.Is(err, errManifestMissing) {
+		t.Errorf("absent: %v, want errManifestMissing", err)
+	}
+
+	corrupted := map[string]string{
+		"truncated.json": `{"build_time":"2026-01-02T03:04:05Z","source_ha`,
+		"empty.json":     ``,
+		"nohash.json":    `{"build_time":"2026-01-02T03:04:05Z"}`,
+		"wrongtype.json": `{"source_hash":42}`,
+	}
+	for name, content := range corrupted {
+		_, err := readSwapManifest(write(name, content))
+		if err == nil || errors.Is(err, errManifestMissing) || !strings.Contains(err.Error(), "corrupted") {
+			t.Errorf("%s: %v, want a corrupted-manifest error", name, err)
+		}
+	}
+}
+
+func TestSwapManifestRoundTrip(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "nested", "clay.current.json")
+	want := &swapManifest{BuildTime: "t", SourceHash: "abc", Arch: "arm64", BinarySize: 7}
+	if err := writeSwapManifest(p, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readSwapManifest(p)
+	if err != nil || *got != *want {
+		t.Errorf("round trip: %+v %v", got, err)
+	}
+}
+
+func TestCheckSwapManifest(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "clay.new")
+	if err := os.WriteFile(bin, []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := fileSHA256(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherArch := "amd64"
+	if runtime.GOARCH == "amd64" {
+		otherArch = "arm64"
+	}
+
+	cases := []struct {
+		name    string
+		m       swapManifest
+		bad     *swapManifest
+		wantErr string
+	}{
+		{"clean", swapManifest{SourceHash: "new", BinarySHA256: sum, Arch: runtime.GOARCH}, nil, ""},
+		{"legacy manifest without binary hash or arch", swapManifest{SourceHash: "new"}, nil, ""},
+		{"different from last bad", swapManifest{SourceHash: "new"}, &swapManifest{SourceHash: "old"}, ""},
+		{"same as last bad", swapManifest{SourceHash: "old"}, &swapManifest{SourceHash: "old", BuildTime: "then"}, "rolled back"},
+		{"other architecture", swapManifest{SourceHash: "new", Arch: otherArch}, nil, "built for linux/" + otherArch},
+		{"stale manifest", swapManifest{SourceHash: "new", BinarySHA256: strings.Repeat("0", 64)}, nil, "does not match"},
+	}
+	for _, tc := range cases {
+		err := checkSwapManifest(&tc.m, tc.bad, bin)
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("%s: %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+
+	m := &swapManifest{SourceHash: "new", BinarySHA256: sum}
+	if err := checkSwapManifest(m, nil, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("missing binary accepted")
+	}
+}
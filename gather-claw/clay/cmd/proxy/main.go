@@ -8,6 +8,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
@@ -71,6 +73,24 @@ func main() {
 		proxy.ServeHTTP(w, r)
 	})
 
+	// Health — gather-auth probes this to catch a wedged agent in a live container
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		checks := map[string]string{
+			"adk":    probe(adkURL.String() + "/api/list-apps"),
+			"bridge": probe(bridgeURL.String() + "/"),
+		}
+		status := http.StatusOK
+		for _, v := range checks {
+			if v != "ok" {
+				status = http.StatusServiceUnavailable
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(checks)
+	})
+
 	// Activity JSON with CORS for local dev
 	mux.HandleFunc("/activity.json", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -104,6 +124,22 @@ func main() {
 	}
 }
 
+var probeClient = &http.Client{Timeout: 2 * time.Second}
+
+// probe reports "ok" if the backend answers HTTP at all; any status below 500
+// means the server is up and serving requests.
+func probe(url string) string {
+	resp, err := probeClient.Get(url)
+	if err != nil {
+		return err.Error()
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Sprintf("status %d", resp.StatusCode)
+	}
+	return "ok"
+}
+
 func getEnv(key, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -134,8 +134,10 @@ The priority order: Go > Python stdlib > apk packages > pip (last resort)
 ## Key filesystem layout
 - /app/src/ — full Go source code (core/, extensions/, cmd/, main.go)
 - /app/data/ — persistent data (messages.db, extensions/, build-failures/)
+- /app/data/build-failures/failures.json — latest medic-recorded crash per category (read this after a restart)
 - /app/data/extensions/ — Starlark .star scripts (agent-writable)
 - /app/data/ops/ — handoff files (MANUAL.md, FEEDBACK.md)
+- /app/data/INSTRUCTIONS.md — your owner's instructions, if they left any (written by the platform, not agent-editable)
 - /app/public/ — website files (index.html, activity.json, blog posts)
 - /app/soul/ — identity files (SOUL.md, IDENTITY.md, USER.md)
 - /app/builds/ — hot-swap staging area (medic watches this)
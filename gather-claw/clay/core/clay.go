@@ -170,6 +170,7 @@ You are running inside an **Alpine Linux 3.19** container. This is a minimal env
 - ash/bash shell, apk package manager
 - Go source code at /app/src/ (your own codebase)
 - SQLite databases in /app/data/
+- /app/data/INSTRUCTIONS.md — your owner's instructions, if they left any. Follow them, and re-read the file when told they changed.
 
 ### What is NOT available by default
 - **pip / Python packages** — only the Python standard library
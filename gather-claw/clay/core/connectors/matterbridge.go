@@ -27,7 +27,9 @@ type MatterbridgeConnector struct {
 	httpClient    *http.Client
 	sessions      map[string]string // userID -> sessionID
 	mu            sync.Mutex
-	middleware    *Middleware // Token estimation + compaction pipeline
+	middleware    *Middleware   // Token estimation + compaction pipeline
+	queue         *MessageQueue // Undelivered messages (nil = redelivery disabled)
+	drainWake     chan struct{}
 }
 
 // MBMessage represents a Matterbridge message.
@@ -56,6 +58,7 @@ func NewMatterbridgeConnector(adkURL string) *MatterbridgeConnector {
 		httpClient:    &http.Client{Timeout: 120 * time.Second},
 		sessions:      make(map[string]string),
 		middleware:    NewMiddlewareForApp(adkURL, "clay"),
+		drainWake:     make(chan struct{}, 1),
 	}
 }
 
@@ -83,6 +86,13 @@ func (m *MatterbridgeConnector) Start(ctx context.Context) error {
 			response, err := m.routeToADK(ctx, msg)
 			if err != nil {
 				fmt.Printf("  error: %v\n", err)
+				// Agent restarting: keep the message and tell the sender
+				if queued := m.queuedFromMB(msg); isADKUnavailable(err) && m.queueForRetry(queued) {
+					if sendErr := m.sendToGateway(queued.ReplyTo, restartAck); sendErr != nil {
+						fmt.Printf("  ack reply failed: %v\n", sendErr)
+					}
+					continue
+				}
 				// Send a friendly error message back instead of silent failure
 				if friendly := friendlyError(err); friendly != "" {
 					if sendErr := m.SendMessage(friendly); sendErr != nil {
@@ -431,10 +441,15 @@ func clampHeartbeatInterval(d time.Duration) time.Duration {
 
 // SendMessage sends a message back to Matterbridge.
 func (m *MatterbridgeConnector) SendMessage(text string) error {
+	return m.sendToGateway(m.gateway, text)
+}
+
+// sendToGateway sends a message to a specific Matterbridge gateway.
+func (m *MatterbridgeConnector) sendToGateway(gateway, text string) error {
 	payload := map[string]string{
 		"text":     text,
 		"username": m.botName,
-		"gateway":  m.gateway,
+		"gateway":  gateway,
 	}
 
 	jsonPayload, _ := json.Marshal(payload)
@@ -470,6 +485,7 @@ type BridgeResponse struct {
 	Text   string     `json:"text"`
 	Events []ADKEvent `json:"events,omitempty"`
 	Error  string     `json:"error,omitempty"`
+	Queued bool       `json:"queued,omitempty"` // Agent is restarting; message saved for redelivery
 }
 
 // ServeHTTP starts an HTTP server for receiving messages from external sources.
@@ -510,6 +526,10 @@ func (m *MatterbridgeConnector) ServeHTTP(ctx context.Context, addr string) erro
 
 		sessionID, err := m.getOrCreateSession(userID)
 		if err != nil {
+			if isADKUnavailable(err) && m.queueForRetry(queuedFromRequest(userID, req)) {
+				writeQueuedAck(w)
+				return
+			}
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(BridgeResponse{Error: fmt.Sprintf("session: %v", err)})
@@ -540,6 +560,10 @@ func (m *MatterbridgeConnector) ServeHTTP(ctx context.Context, addr string) erro
 			// If still erroring after retry
 			if err != nil {
 				fmt.Printf("  error: %v\n", err)
+				if isADKUnavailable(err) && m.queueForRetry(queuedFromRequest(userID, req)) {
+					writeQueuedAck(w)
+					return
+				}
 				if friendly := friendlyError(err); friendly != "" {
 					w.Header().Set("Content-Type", "application/json")
 					json.NewEncoder(w).Encode(BridgeResponse{Text: friendly})
@@ -594,6 +618,11 @@ func (m *MatterbridgeConnector) ServeHTTP(ctx context.Context, addr string) erro
 
 		sessionID, err := m.getOrCreateSession(userID)
 		if err != nil {
+			if isADKUnavailable(err) && m.queueForRetry(queuedFromRequest(userID, req)) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				writeQueuedEvent(w)
+				return
+			}
 			http.Error(w, fmt.Sprintf(`{"error":"session: %v"}`, err), http.StatusInternalServerError)
 			return
 		}
@@ -641,6 +670,10 @@ func (m *MatterbridgeConnector) ServeHTTP(ctx context.Context, addr string) erro
 			}
 
 			if err != nil {
+				if isADKUnavailable(err) && m.queueForRetry(queuedFromRequest(userID, req)) {
+					writeQueuedEvent(w)
+					return
+				}
 				errEvt, _ := json.Marshal(map[string]string{"type": "error", "text": err.Error()})
 				fmt.Fprintf(w, "data: %s\n\n", errEvt)
 				flusher.Flush()
@@ -663,6 +696,20 @@ func (m *MatterbridgeConnector) ServeHTTP(ctx context.Context, addr string) erro
 		fmt.Printf("  -> streamed %d chars\n", len(result.Text))
 	})
 
+	// Status — queue depth lets the medic see a backlog building up
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		status := map[string]any{"adk": "down"}
+		if m.adkHealthy(r.Context()) {
+			status["adk"] = "ok"
+		}
+		if m.queue != nil {
+			status["queue"] = m.queue.Stats()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		json.NewEncoder(w).Encode(status)
+	})
+
 	server := &http.Server{Addr: addr, Handler: mux}
 
 	go func() {
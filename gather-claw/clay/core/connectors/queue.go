@@ -0,0 +1,169 @@
+package connectors
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrQueueFull is returned by Enqueue when the queue is at capacity.
+var ErrQueueFull = errors.New("message queue full")
+
+// MessageQueue persists inbound messages that couldn't be delivered to ADK
+// (agent mid-hot-swap or crashed) so they survive a bridge restart and can be
+// replayed once the agent is back.
+type MessageQueue struct {
+	db      *sql.DB
+	maxSize int
+	maxAge  time.Duration
+}
+
+// QueuedMessage is one undelivered inbound message.
+type QueuedMessage struct {
+	ID       int64
+	Protocol string
+	UserID   string
+	Username string
+	Channel  string // Originating chat (for typing indicators)
+	ReplyTo  string // Matterbridge gateway to answer on; empty for HTTP callers
+	Text     string
+	Attempts int
+	Created  time.Time
+}
+
+// QueueStats summarizes the queue for the bridge status endpoint.
+type QueueStats struct {
+	Depth         int     `json:"depth"`
+	OldestAgeSecs float64 `json:"oldest_age_seconds"`
+	MaxSize       int     `json:"max_size"`
+	MaxAge        string  `json:"max_age"`
+}
+
+// NewMessageQueue opens (or creates) the queue database at dbPath.
+func NewMessageQueue(dbPath string, maxSize int, maxAge time.Duration) (*MessageQueue, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, err
+	}
+	// Single writer: the drain loop and HTTP handlers share one connection
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS bridge_queue (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			protocol TEXT NOT NULL DEFAULT '',
+			user_id TEXT NOT NULL,
+			username TEXT NOT NULL DEFAULT '',
+			channel TEXT NOT NULL DEFAULT '',
+			reply_to TEXT NOT NULL DEFAULT '',
+			text TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create bridge_queue: %w", err)
+	}
+
+	return &MessageQueue{db: db, maxSize: maxSize, maxAge: maxAge}, nil
+}
+
+// Enqueue appends a message. Returns ErrQueueFull at capacity.
+func (q *MessageQueue) Enqueue(msg QueuedMessage) error {
+	if q.Depth() >= q.maxSize {
+		return ErrQueueFull
+	}
+	_, err := q.db.Exec(
+		`INSERT INTO bridge_queue (protocol, user_id, username, channel, reply_to, text, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		msg.Protocol, msg.UserID, msg.Username, msg.Channel, msg.ReplyTo, msg.Text, time.Now().Unix(),
+	)
+	return err
+}
+
+// Oldest returns the oldest queued message, or nil if the queue is empty.
+func (q *MessageQueue) Oldest() (*QueuedMessage, error) {
+	var msg QueuedMessage
+	var created int64
+	err := q.db.QueryRow(
+		`SELECT id, protocol, user_id, username, channel, reply_to, text, attempts, created_at
+		 FROM bridge_queue ORDER BY id LIMIT 1`,
+	).Scan(&msg.ID, &msg.Protocol, &msg.UserID, &msg.Username, &msg.Channel, &msg.ReplyTo, &msg.Text, &msg.Attempts, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	msg.Created = time.Unix(created, 0)
+	return &msg, nil
+}
+
+// Remove deletes a delivered (or abandoned) message.
+func (q *MessageQueue) Remove(id int64) error {
+	_, err := q.db.Exec(`DELETE FROM bridge_queue WHERE id = ?`, id)
+	return err
+}
+
+// MarkAttempt records a failed delivery attempt.
+func (q *MessageQueue) MarkAttempt(id int64) error {
+	_, err := q.db.Exec(`UPDATE bridge_queue SET attempts = attempts + 1 WHERE id = ?`, id)
+	return err
+}
+
+// Prune drops messages older than maxAge and returns them, so the caller can
+// tell senders their message expired.
+func (q *MessageQueue) Prune() ([]QueuedMessage, error) {
+	cutoff := time.Now().Add(-q.maxAge).Unix()
+	rows, err := q.db.Query(
+		`SELECT id, protocol, user_id, username, reply_to, created_at FROM bridge_queue WHERE created_at < ?`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	var expired []QueuedMessage
+	for rows.Next() {
+		var msg QueuedMessage
+		var created int64
+		if err := rows.Scan(&msg.ID, &msg.Protocol, &msg.UserID, &msg.Username, &msg.ReplyTo, &created); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		msg.Created = time.Unix(created, 0)
+		expired = append(expired, msg)
+	}
+	rows.Close()
+
+	if len(expired) > 0 {
+		if _, err := q.db.Exec(`DELETE FROM bridge_queue WHERE created_at < ?`, cutoff); err != nil {
+			return nil, err
+		}
+	}
+	return expired, nil
+}
+
+// Depth returns the number of queued messages.
+func (q *MessageQueue) Depth() int {
+	var n int
+	q.db.QueryRow(`SELECT COUNT(*) FROM bridge_queue`).Scan(&n)
+	return n
+}
+
+// Stats returns depth and age of the backlog.
+func (q *MessageQueue) Stats() QueueStats {
+	stats := QueueStats{MaxSize: q.maxSize, MaxAge: q.maxAge.String()}
+	var oldest sql.NullInt64
+	q.db.QueryRow(`SELECT COUNT(*), MIN(created_at) FROM bridge_queue`).Scan(&stats.Depth, &oldest)
+	if oldest.Valid {
+		stats.OldestAgeSecs = time.Since(time.Unix(oldest.Int64, 0)).Seconds()
+	}
+	return stats
+}
+
+// Close closes the database.
+func (q *MessageQueue) Close() error {
+	return q.db.Close()
+}
@@ -0,0 +1,250 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Redelivery: messages that fail because ADK is unreachable (connection
+// refused, 5xx) are queued instead of dropped. The sender gets an immediate
+// acknowledgment, and the drain loop replays the backlog in order once the
+// ADK health endpoint answers again.
+
+const (
+	// restartAck is sent to the originating channel when a message is queued.
+	restartAck = "I'm restarting right now — I've saved your message and I'll get back to you as soon as I'm back."
+
+	drainMinBackoff     = 2 * time.Second
+	drainMaxBackoff     = 60 * time.Second
+	maxDeliveryAttempts = 5
+)
+
+// adkStatusRe matches the HTTP status in middleware/session errors.
+var adkStatusRe = regexp.MustCompile(`HTTP (5\d\d)`)
+
+// isADKUnavailable reports whether err means ADK couldn't take the message
+// at all (down or restarting), as opposed to the agent failing on it.
+func isADKUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "connection refused") ||
+		strings.Contains(msg, "connection reset") ||
+		adkStatusRe.MatchString(msg)
+}
+
+// SetQueue enables persistent redelivery. Without a queue, undeliverable
+// messages get the friendly error reply and are dropped.
+func (m *MatterbridgeConnector) SetQueue(q *MessageQueue) {
+	m.queue = q
+}
+
+// queueForRetry stores an undeliverable message. Returns false if there is no
+// queue or it's full, in which case the caller falls back to an error reply.
+func (m *MatterbridgeConnector) queueForRetry(msg QueuedMessage) bool {
+	if m.queue == nil {
+		return false
+	}
+	if err := m.queue.Enqueue(msg); err != nil {
+		log.Printf("queue: could not store message from %s: %v", msg.UserID, err)
+		return false
+	}
+	log.Printf("queue: stored message from %s [%s] (depth %d)", msg.UserID, msg.Protocol, m.queue.Depth())
+	m.wakeDrain()
+	return true
+}
+
+// wakeDrain nudges the drain loop without blocking.
+func (m *MatterbridgeConnector) wakeDrain() {
+	select {
+	case m.drainWake <- struct{}{}:
+	default:
+	}
+}
+
+// adkHealthy probes the same endpoint the proxy health check uses.
+func (m *MatterbridgeConnector) adkHealthy(ctx context.Context) bool {
+	req, err := http.NewRequestWithContext(ctx, "GET", m.adkURL+"/api/list-apps", nil)
+	if err != nil {
+		return false
+	}
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// StartQueueDrain replays queued messages once ADK is healthy, backing off
+// exponentially while it isn't. Blocks until ctx is cancelled.
+func (m *MatterbridgeConnector) StartQueueDrain(ctx context.Context) {
+	if m.queue == nil {
+		return
+	}
+
+	backoff := drainMinBackoff
+	for {
+		m.pruneExpired()
+
+		if m.queue.Depth() > 0 {
+			if !m.adkHealthy(ctx) {
+				backoff = min(backoff*2, drainMaxBackoff)
+			} else if err := m.drainQueue(ctx); err != nil {
+				log.Printf("queue: drain paused: %v", err)
+				backoff = min(backoff*2, drainMaxBackoff)
+			} else {
+				backoff = drainMinBackoff
+			}
+		}
+
+		// Nothing queued: sleep until a message is stored (or the prune tick)
+		wait := backoff
+		if m.queue.Depth() == 0 {
+			wait = drainMaxBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.drainWake:
+			if m.queue.Depth() > 0 {
+				// A new failure means ADK just went down; give it a moment
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+			}
+		case <-time.After(wait):
+		}
+	}
+}
+
+// drainQueue delivers queued messages oldest-first. Returns an error (leaving
+// the rest queued) as soon as ADK is unavailable again.
+func (m *MatterbridgeConnector) drainQueue(ctx context.Context) error {
+	for {
+		msg, err := m.queue.Oldest()
+		if err != nil {
+			return err
+		}
+		if msg == nil {
+			return nil
+		}
+
+		log.Printf("queue: redelivering message from %s (queued %s ago)", msg.UserID, time.Since(msg.Created).Round(time.Second))
+		response, err := m.routeToADK(ctx, MBMessage{
+			Text:     msg.Text,
+			Username: msg.Username,
+			UserID:   msg.UserID,
+			Channel:  msg.Channel,
+			Protocol: msg.Protocol,
+			Gateway:  msg.ReplyTo,
+		})
+		if err != nil {
+			m.queue.MarkAttempt(msg.ID)
+			if isADKUnavailable(err) && msg.Attempts+1 < maxDeliveryAttempts {
+				return err
+			}
+			// The agent got it but failed on it, or it keeps failing: stop
+			// retrying so one bad message can't wedge the queue.
+			log.Printf("queue: giving up on message %d: %v", msg.ID, err)
+			m.queue.Remove(msg.ID)
+			if msg.ReplyTo != "" {
+				reply := friendlyError(err)
+				if reply == "" {
+					reply = "Sorry — I couldn't process the message you sent while I was restarting. Please send it again."
+				}
+				m.sendToGateway(msg.ReplyTo, reply)
+			}
+			continue
+		}
+
+		m.queue.Remove(msg.ID)
+		if msg.ReplyTo == "" {
+			// HTTP callers got their ack synchronously; the reply lives in
+			// the agent's session history.
+			log.Printf("queue: delivered message %d (%d chars, no reply channel)", msg.ID, len(response))
+			continue
+		}
+		if response != "" {
+			if err := m.sendToGateway(msg.ReplyTo, response); err != nil {
+				log.Printf("queue: reply for message %d failed: %v", msg.ID, err)
+			}
+		}
+	}
+}
+
+// pruneExpired drops messages older than the queue's max age, telling
+// Matterbridge senders their message expired.
+func (m *MatterbridgeConnector) pruneExpired() {
+	expired, err := m.queue.Prune()
+	if err != nil {
+		log.Printf("queue: prune failed: %v", err)
+		return
+	}
+	for _, msg := range expired {
+		log.Printf("queue: dropped expired message %d from %s", msg.ID, msg.UserID)
+		if msg.ReplyTo != "" {
+			m.sendToGateway(msg.ReplyTo, fmt.Sprintf(
+				"Sorry — I was down for too long and your message from %s expired. Please send it again.",
+				msg.Created.UTC().Format("15:04 UTC")))
+		}
+	}
+}
+
+// queuedFromMB builds a queue entry for a Matterbridge message; replies go
+// back to the gateway it arrived on.
+func (m *MatterbridgeConnector) queuedFromMB(msg MBMessage) QueuedMessage {
+	userID := msg.UserID
+	if userID == "" {
+		userID = msg.Username
+	}
+	gateway := msg.Gateway
+	if gateway == "" {
+		gateway = m.gateway
+	}
+	return QueuedMessage{
+		Protocol: msg.Protocol,
+		UserID:   userID,
+		Username: msg.Username,
+		Channel:  msg.Channel,
+		ReplyTo:  gateway,
+		Text:     msg.Text,
+	}
+}
+
+// queuedFromRequest builds a queue entry for an HTTP caller. There is no
+// address to reply to later, so the ack is the only answer they get.
+func queuedFromRequest(userID string, req BridgeRequest) QueuedMessage {
+	return QueuedMessage{
+		Protocol: req.Protocol,
+		UserID:   userID,
+		Username: req.Username,
+		Text:     req.Text,
+	}
+}
+
+// writeQueuedAck answers an HTTP caller whose message was queued.
+func writeQueuedAck(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(BridgeResponse{Text: restartAck, Queued: true})
+}
+
+// writeQueuedEvent ends an SSE stream with the ack for a queued message.
+func writeQueuedEvent(w http.ResponseWriter) {
+	evt, _ := json.Marshal(map[string]any{"type": "end", "text": restartAck, "queued": true})
+	fmt.Fprintf(w, "data: %s\n\n", evt)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
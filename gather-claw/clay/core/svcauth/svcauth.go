@@ -0,0 +1,39 @@
+// Package svcauth is the shared-secret check for clay's internal HTTP
+// services (build service, medic). Callers send the secret as a bearer token;
+// anything else gets a bare 401.
+package svcauth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// Require wraps next so it only runs for requests carrying token in the
+// Authorization header. An empty token disables the check.
+func Require(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	want := []byte(token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), want) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireFunc is Require for a handler function.
+func RequireFunc(token string, next http.HandlerFunc) http.Handler {
+	return Require(token, next)
+}
+
+// SetToken adds token to an outgoing request. No-op when token is empty.
+func SetToken(req *http.Request, token string) {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
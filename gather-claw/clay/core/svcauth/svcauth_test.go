@@ -0,0 +1,68 @@
+package svcauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequire(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("built"))
+	})
+	h := Require("s3cret", ok)
+
+	cases := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"right token", "Bearer s3cret", http.StatusOK},
+		{"no header", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer s3cre7", http.StatusUnauthorized},
+		{"token prefix", "Bearer s3c", http.StatusUnauthorized},
+		{"token with suffix", "Bearer s3cret2", http.StatusUnauthorized},
+		{"no scheme", "s3cret", http.StatusUnauthorized},
+		{"basic scheme", "Basic s3cret", http.StatusUnauthorized},
+		{"empty bearer", "Bearer ", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("POST", "/build", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.want)
+		}
+		if tc.want == http.StatusUnauthorized && rec.Body.Len() != 0 {
+			t.Errorf("%s: 401 body %q, want none", tc.name, rec.Body.String())
+		}
+	}
+}
+
+func TestRequireDisabled(t *testing.T) {
+	h := RequireFunc("", func(w http.ResponseWriter, r *http.Request) {})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/check", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status %d with auth disabled", rec.Code)
+	}
+}
+
+func TestSetToken(t *testing.T) {
+	req := httptest.NewRequest("POST", "/build", nil)
+	SetToken(req, "")
+	if req.Header.Get("Authorization") != "" {
+		t.Error("empty token set a header")
+	}
+
+	// A request from SetToken passes Require with the same token
+	SetToken(req, "s3cret")
+	rec := httptest.NewRecorder()
+	RequireFunc("s3cret", func(w http.ResponseWriter, r *http.Request) {}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("round trip: status %d", rec.Code)
+	}
+}
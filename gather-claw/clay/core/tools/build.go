@@ -2,14 +2,22 @@ package tools
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"time"
 
+	"clay/core/svcauth"
+
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
 )
@@ -56,6 +64,30 @@ func NewBuildTools() ([]tool.Tool, error) {
 	return out, nil
 }
 
+// postToBuildService sends a source tarball to the build service, with the
+// shared BUILD_AUTH_TOKEN if one is configured. It asks for a binary for this
+// claw's architecture, which may not be the build host's.
+func postToBuildService(url string, tarball []byte) (*http.Response, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(tarball))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+	req.Header.Set("X-Target-Arch", runtime.GOARCH)
+	svcauth.SetToken(req, os.Getenv("BUILD_AUTH_TOKEN"))
+
+	client := &http.Client{Timeout: buildTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, fmt.Errorf("rejected with 401 (BUILD_AUTH_TOKEN missing or wrong)")
+	}
+	return resp, nil
+}
+
 func requestCheck() (BuildRequestResult, error) {
 	buildURL := os.Getenv("BUILD_SERVICE_URL")
 	if buildURL == "" {
@@ -76,8 +108,7 @@ func requestCheck() (BuildRequestResult, error) {
 		}, fmt.Errorf("tarball failed: %w", err)
 	}
 
-	client := &http.Client{Timeout: buildTimeout}
-	resp, err := client.Post(buildURL+"/check", "application/gzip", bytes.NewReader(tarball))
+	resp, err := postToBuildService(buildURL+"/check", tarball)
 	if err != nil {
 		return BuildRequestResult{
 			Message: "Build service unreachable",
@@ -119,7 +150,14 @@ func requestBuild(reason string) (BuildRequestResult, error) {
 	}
 	srcDir = srcDir + "/src"
 
-	// 1. Tarball the source directory
+	// 1. Hash and tarball the source directory
+	sourceHash, err := hashSourceTree(srcDir)
+	if err != nil {
+		return BuildRequestResult{
+			Message: "Failed to hash source",
+			Output:  err.Error(),
+		}, fmt.Errorf("hash source: %w", err)
+	}
 	tarball, err := createTarball(srcDir)
 	if err != nil {
 		return BuildRequestResult{
@@ -129,8 +167,7 @@ func requestBuild(reason string) (BuildRequestResult, error) {
 	}
 
 	// 2. POST tarball to build service
-	client := &http.Client{Timeout: buildTimeout}
-	resp, err := client.Post(buildURL+"/build", "application/gzip", bytes.NewReader(tarball))
+	resp, err := postToBuildService(buildURL+"/build", tarball)
 	if err != nil {
 		return BuildRequestResult{
 			Message: "Build service unreachable",
@@ -149,9 +186,12 @@ func requestBuild(reason string) (BuildRequestResult, error) {
 			outDir = "/app"
 		}
 		outPath := outDir + "/builds/clay.new"
+		tmpPath := outPath + ".tmp"
 
+		// Stream to a temp file: medic polls for clay.new and must never
+		// see a partial binary.
 		os.MkdirAll(outDir+"/builds", 0755)
-		f, err := os.Create(outPath)
+		f, err := os.Create(tmpPath)
 		if err != nil {
 			return BuildRequestResult{
 				Message: "Build succeeded but failed to save binary",
@@ -159,21 +199,48 @@ func requestBuild(reason string) (BuildRequestResult, error) {
 			}, fmt.Errorf("save binary: %w", err)
 		}
 
-		n, err := io.Copy(f, resp.Body)
+		h := sha256.New()
+		n, err := io.Copy(io.MultiWriter(f, h), resp.Body)
 		f.Close()
 		if err != nil {
-			os.Remove(outPath)
+			os.Remove(tmpPath)
 			return BuildRequestResult{
 				Message: "Build succeeded but failed to save binary",
 				Output:  err.Error(),
 			}, fmt.Errorf("save binary: %w", err)
 		}
+		os.Chmod(tmpPath, 0755)
 
-		os.Chmod(outPath, 0755)
+		// The manifest goes down first so it is in place when medic sees
+		// clay.new.
+		manifest := swapManifest{
+			BuildTime:      time.Now().UTC().Format(time.RFC3339),
+			SourceHash:     sourceHash,
+			Reason:         reason,
+			BuilderVersion: resp.Header.Get("X-Builder-Version"),
+			Arch:           resp.Header.Get("X-Build-Arch"),
+			BinarySHA256:   hex.EncodeToString(h.Sum(nil)),
+			BinarySize:     n,
+		}
+		if err := writeSwapManifest(outPath+".json", manifest); err != nil {
+			os.Remove(tmpPath)
+			return BuildRequestResult{
+				Message: "Build succeeded but failed to write swap manifest",
+				Output:  err.Error(),
+			}, fmt.Errorf("write manifest: %w", err)
+		}
+		if err := os.Rename(tmpPath, outPath); err != nil {
+			os.Remove(tmpPath)
+			os.Remove(outPath + ".json")
+			return BuildRequestResult{
+				Message: "Build succeeded but failed to save binary",
+				Output:  err.Error(),
+			}, fmt.Errorf("save binary: %w", err)
+		}
 
 		buildOutput := resp.Header.Get("X-Build-Output")
 		return BuildRequestResult{
-			Message: fmt.Sprintf("Build succeeded (%d bytes). Medic will hot-swap shortly.", n),
+			Message: fmt.Sprintf("Build succeeded (%d bytes, source %s). Medic will hot-swap shortly.", n, sourceHash[:12]),
 			Output:  buildOutput,
 		}, nil
 	}
@@ -209,3 +276,66 @@ func createTarball(srcDir string) ([]byte, error) {
 	}
 	return stdout.Bytes(), nil
 }
+
+// swapManifest is written next to clay.new as clay.new.json. Medic reads it
+// before swapping and keeps it as clay.current.json, so failures can be
+// traced back to the source that produced the binary.
+type swapManifest struct {
+	BuildTime      string `json:"build_time"`
+	SourceHash     string `json:"source_hash"`
+	Reason         string `json:"reason,omitempty"`
+	BuilderVersion string `json:"builder_version,omitempty"`
+	Arch           string `json:"arch,omitempty"`
+	BinarySHA256   string `json:"binary_sha256"`
+	BinarySize     int64  `json:"binary_size"`
+}
+
+func writeSwapManifest(path string, m swapManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// hashSourceTree returns a sha256 over every regular file's relative path and
+// contents, in path order, so the same source always hashes the same
+// regardless of mtimes.
+func hashSourceTree(dir string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, path := range files {
+		rel, _ := filepath.Rel(dir, path)
+		io.WriteString(h, filepath.ToSlash(rel))
+		h.Write([]byte{0})
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
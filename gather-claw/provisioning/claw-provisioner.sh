@@ -13,6 +13,7 @@ set -euo pipefail
 #
 # Optional env:
 #   GATHER_API_URL        — default: http://127.0.0.1:8090
+#   PROVISIONER_ID        — unique per running instance; default: hostname-pid
 #   CLAW_ROOT             — default: /srv/claw
 #   ZAI_API_KEY           — z.ai API key for clawpoint-go
 #   TELEGRAM_BOT_TOKEN    — Telegram bot token
@@ -22,6 +23,7 @@ SCRIPT_DIR="$(cd "$(dirname "$0")" && pwd)"
 
 GATHER_API_URL="${GATHER_API_URL:-http://127.0.0.1:8090}"
 CLAW_ROOT="${CLAW_ROOT:-/srv/claw}"
+PROVISIONER_ID="${PROVISIONER_ID:-$(hostname)-$$}"
 USERS_DIR="$CLAW_ROOT/users"
 NGINX_DIR="/etc/nginx/claw-users"
 
@@ -39,14 +41,26 @@ fetch_pending() {
         "$GATHER_API_URL/api/claws/pending" 2>/dev/null || echo '{"claws":null,"total":0}'
 }
 
-# Report provisioning result back to the API
+# Claim a claw so no other provisioner instance works on it. Fails (409) if
+# another instance holds the claim; claiming again renews our 10-minute lease.
+claim_claw() {
+    local id="$1"
+    curl -sf -X POST \
+        -H "X-Provisioner-Key: $CLAW_PROVISIONER_KEY" \
+        -H "Content-Type: application/json" \
+        "$GATHER_API_URL/api/claws/$id/claim" \
+        -d "{\"provisioner_id\":\"$PROVISIONER_ID\"}" \
+        > /dev/null 2>&1
+}
+
+# Report provisioning result back to the API (we must hold the claim)
 report_result() {
     local id="$1" status="$2" container_id="${3:-}" error_msg="${4:-}"
     curl -sf -X POST \
         -H "X-Provisioner-Key: $CLAW_PROVISIONER_KEY" \
         -H "Content-Type: application/json" \
         "$GATHER_API_URL/api/claws/$id/provision-result" \
-        -d "{\"status\":\"$status\",\"container_id\":\"$container_id\",\"error_message\":\"$error_msg\"}" \
+        -d "{\"provisioner_id\":\"$PROVISIONER_ID\",\"status\":\"$status\",\"container_id\":\"$container_id\",\"error_message\":\"$error_msg\"}" \
         > /dev/null 2>&1
 }
 
@@ -120,6 +134,11 @@ process_pending() {
             continue
         fi
 
+        if ! claim_claw "$id"; then
+            log "Skipping $id — claimed by another provisioner"
+            continue
+        fi
+
         if provision_claw "$id" "$name" "$subdomain" "$port" "$instructions"; then
             report_result "$id" "running" "claw-$subdomain" ""
             log "Reported running: $id"
@@ -132,7 +151,7 @@ process_pending() {
 
 # Main
 if [ "${1:-}" = "--loop" ]; then
-    log "Starting provisioner loop (poll every 30s)"
+    log "Starting provisioner loop as $PROVISIONER_ID (poll every 30s)"
     while true; do
         process_pending || log "Error during processing (continuing)"
         sleep 30
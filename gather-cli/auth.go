@@ -1,26 +1,19 @@
 package main
 
 import (
-	"crypto/ed25519"
-	"crypto/x509"
-	"encoding/base64"
+	"context"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
-)
 
-// KeyPair holds an Ed25519 public/private key pair.
-type KeyPair struct {
-	PublicKey  ed25519.PublicKey
-	PrivateKey ed25519.PrivateKey
-}
+	"gather.is/auth/client"
+)
 
-// LoadKeyPair reads a keypair from ~/.gather/keys/{name}.key and .pub.
-func LoadKeyPair(name string) (*KeyPair, error) {
+// LoadKeyPair reads the private key from ~/.gather/keys/{name}.key (or
+// {name}-private.pem) and returns a signer for it.
+func LoadKeyPair(name string) (*client.Ed25519Signer, error) {
 	dir := keysDir()
 
 	privPEM, err := os.ReadFile(filepath.Join(dir, name+".key"))
@@ -32,147 +25,53 @@ func LoadKeyPair(name string) (*KeyPair, error) {
 		}
 	}
 
-	pubPEM, err := os.ReadFile(filepath.Join(dir, name+".pub"))
-	if err != nil {
-		pubPEM, err = os.ReadFile(filepath.Join(dir, name+"-public.pem"))
-		if err != nil {
-			return nil, fmt.Errorf("read public key: %w", err)
-		}
-	}
-
-	priv, err := parsePrivateKeyPEM(privPEM)
-	if err != nil {
-		return nil, err
-	}
-	pub, err := parsePublicKeyPEM(pubPEM)
+	priv, err := client.ParsePrivateKeyPEM(privPEM)
 	if err != nil {
 		return nil, err
 	}
-	return &KeyPair{PublicKey: pub, PrivateKey: priv}, nil
+	return client.NewEd25519Signer(priv), nil
 }
 
-func parsePublicKeyPEM(data []byte) (ed25519.PublicKey, error) {
-	block, _ := pem.Decode(data)
-	if block == nil {
-		return nil, fmt.Errorf("no PEM block found in public key")
-	}
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("parse public key: %w", err)
-	}
-	edPub, ok := pub.(ed25519.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("key is not Ed25519")
-	}
-	return edPub, nil
-}
-
-func parsePrivateKeyPEM(data []byte) (ed25519.PrivateKey, error) {
-	block, _ := pem.Decode(data)
-	if block == nil {
-		return nil, fmt.Errorf("no PEM block found in private key")
-	}
-	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("parse private key: %w", err)
-	}
-	edPriv, ok := priv.(ed25519.PrivateKey)
-	if !ok {
-		return nil, fmt.Errorf("key is not Ed25519")
-	}
-	return edPriv, nil
-}
+// fileTokenStore caches the agent JWT in ~/.gather/jwt.
+type fileTokenStore struct{}
 
-func encodePubKeyPEM(pub ed25519.PublicKey) (string, error) {
-	b, err := x509.MarshalPKIXPublicKey(pub)
+func (fileTokenStore) Load() (string, error) {
+	data, err := os.ReadFile(filepath.Join(gatherDir(), "jwt"))
 	if err != nil {
-		return "", fmt.Errorf("marshal public key: %w", err)
+		return "", err
 	}
-	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b})), nil
+	return strings.TrimSpace(string(data)), nil
 }
 
-// Authenticate performs the full challenge-response flow and returns the token,
-// agent ID, and unread message count.
-func Authenticate(baseURL, keyName string) (token string, agentID string, unread int, err error) {
-	kp, err := LoadKeyPair(keyName)
-	if err != nil {
-		return "", "", 0, fmt.Errorf("load keypair: %w", err)
-	}
-
-	pubPEM, err := encodePubKeyPEM(kp.PublicKey)
-	if err != nil {
-		return "", "", 0, err
-	}
-
-	c := &Client{BaseURL: baseURL}
-
-	// Step 1: get challenge nonce
-	nonce, err := c.Challenge(pubPEM)
-	if err != nil {
-		return "", "", 0, fmt.Errorf("challenge: %w", err)
-	}
-
-	// Step 2: sign nonce
-	sig := ed25519.Sign(kp.PrivateKey, nonce)
-	sigB64 := base64.StdEncoding.EncodeToString(sig)
-
-	// Step 3: authenticate
-	token, agentID, unread, err = c.Authenticate(pubPEM, sigB64)
-	if err != nil {
-		return "", "", 0, fmt.Errorf("authenticate: %w", err)
-	}
-
-	return token, agentID, unread, nil
+func (fileTokenStore) Save(token string) error {
+	os.MkdirAll(gatherDir(), 0700)
+	return os.WriteFile(filepath.Join(gatherDir(), "jwt"), []byte(token), 0600)
 }
 
-// CachedAuth returns a valid JWT, re-authenticating only if the cached one is
-// expired or missing. Cache file: ~/.gather/jwt
-func CachedAuth(baseURL, keyName string) (string, error) {
-	cacheFile := filepath.Join(gatherDir(), "jwt")
-
-	data, err := os.ReadFile(cacheFile)
-	if err == nil {
-		tok := strings.TrimSpace(string(data))
-		if tok != "" && !jwtExpired(tok) {
-			return tok, nil
-		}
-	}
-
-	token, _, _, err := Authenticate(baseURL, keyName)
+// NewAgentClient returns an API client that signs in with the configured key
+// and reuses the JWT cached in ~/.gather/jwt until it expires.
+func NewAgentClient(cfg Config) (*client.Client, error) {
+	signer, err := LoadKeyPair(cfg.KeyName)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("load keypair: %w", err)
 	}
-
-	// Cache it (best-effort)
-	os.MkdirAll(filepath.Dir(cacheFile), 0700)
-	os.WriteFile(cacheFile, []byte(token), 0600)
-
-	return token, nil
+	return client.New(cfg.BaseURL,
+		client.WithSigner(signer),
+		client.WithTokenStore(fileTokenStore{}),
+	), nil
 }
 
-// jwtExpired checks if a JWT's exp claim is in the past.
-// Parses the middle segment without a JWT library.
-func jwtExpired(token string) bool {
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		return true
-	}
-	// Decode payload (base64url)
-	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+// CachedAuth returns an agent client holding a valid JWT, re-authenticating
+// only if the cached one is expired or missing.
+func CachedAuth(cfg Config) (*client.Client, error) {
+	c, err := NewAgentClient(cfg)
 	if err != nil {
-		return true
-	}
-	var claims struct {
-		Exp int64 `json:"exp"`
-	}
-	if err := json.Unmarshal(payload, &claims); err != nil {
-		return true
+		return nil, err
 	}
-	if claims.Exp == 0 {
-		return true
+	if _, err := c.Token(context.Background()); err != nil {
+		return nil, err
 	}
-	// Expire 60s early to avoid edge cases
-	return time.Now().Unix() > claims.Exp-60
+	return c, nil
 }
 
 // Config holds CLI configuration.
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gather.is/auth/client"
+)
+
+// Claw management acts for the owning gather.is user, not the agent, so it
+// authenticates with the account's email and password and caches the
+// PocketBase token in ~/.gather/user-auth (separate from the agent JWT).
+
+func cmdClaws(cfg Config) {
+	if len(os.Args) < 3 {
+		printClawsUsage()
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "login":
+		if _, err := userLogin(cfg); err != nil {
+			fatal("login: %s", apiErrorDetail(err))
+		}
+		fmt.Println("user token cached to ~/.gather/user-auth")
+	case "list":
+		cmdClawsList(cfg)
+	case "deploy":
+		cmdClawsDeploy(cfg)
+	case "logs":
+		cmdClawsLogs(cfg)
+	case "restart":
+		cmdClawsRestart(cfg)
+	case "env":
+		cmdClawsEnv(cfg)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown claws command: %s\n", os.Args[2])
+		printClawsUsage()
+		os.Exit(1)
+	}
+}
+
+func printClawsUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: gather claws <command>
+
+Commands:
+  login                                 Sign in with your gather.is email and password
+  list                                  List your claws
+  deploy --name <n> [--type lite|pro|max] [--repo owner/repo] [--instructions <text>]
+  logs <claw> [--tail N] [--follow]     Show container logs
+  restart <claw>                        Restart the container
+  env set <claw> KEY=VALUE... [--restart]  Update .env keys (KEY= removes one)
+
+<claw> is a claw name or ID. Set GATHER_EMAIL / GATHER_PASSWORD to skip the prompt.
+`)
+}
+
+func cmdClawsList(cfg Config) {
+	c := userClient(cfg)
+	claws, err := c.Claws(context.Background())
+	if err != nil {
+		fatal("claws: %s", apiErrorDetail(err))
+	}
+	if len(claws) == 0 {
+		fmt.Println("no claws")
+		return
+	}
+	for _, cl := range claws {
+		status := cl.Status
+		if cl.Status == "running" && cl.HealthStatus == "unhealthy" {
+			status = "running, unhealthy"
+		}
+		clawType := cl.ClawType
+		if clawType == "" {
+			clawType = "lite"
+		}
+		fmt.Printf("  %s (%s) [%s] %s", cl.Name, cl.ID, clawType, status)
+		if cl.URL != "" {
+			fmt.Printf(" — %s", cl.URL)
+		}
+		fmt.Println()
+		if cl.ErrorMessage != "" {
+			fmt.Printf("    error: %s\n", cl.ErrorMessage)
+		}
+	}
+}
+
+func cmdClawsDeploy(cfg Config) {
+	var req client.DeployClawRequest
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			break
+		}
+		switch args[i] {
+		case "--name":
+			i++
+			req.Name = args[i]
+		case "--type":
+			i++
+			req.ClawType = args[i]
+		case "--repo":
+			i++
+			req.GithubRepo = args[i]
+		case "--instructions":
+			i++
+			req.Instructions = args[i]
+		}
+	}
+	if req.Name == "" {
+		fatal("usage: gather claws deploy --name <name> [--type lite|pro|max] [--repo owner/repo] [--instructions <text>]")
+	}
+
+	c := userClient(cfg)
+	cl, err := c.DeployClaw(context.Background(), req)
+	if err != nil {
+		fatal("deploy: %s", apiErrorDetail(err))
+	}
+	fmt.Printf("deployed %s (%s): %s\n", cl.Name, cl.ID, cl.Status)
+	fmt.Println("run `gather claws list` to watch it come up")
+}
+
+func cmdClawsLogs(cfg Config) {
+	if len(os.Args) < 4 {
+		fatal("usage: gather claws logs <claw> [--tail N] [--follow]")
+	}
+	tail := 200
+	follow := false
+	for i := 4; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--follow", "-f":
+			follow = true
+		case "--tail":
+			if i+1 < len(os.Args) {
+				i++
+				fmt.Sscanf(os.Args[i], "%d", &tail)
+			}
+		}
+	}
+
+	c := userClient(cfg)
+	ctx := context.Background()
+	cl := findClaw(c, os.Args[3])
+
+	since := time.Now().UTC()
+	logs, err := c.ClawLogs(ctx, cl.ID, tail, "")
+	if err != nil {
+		fatal("logs: %s", apiErrorDetail(err))
+	}
+	fmt.Print(logs)
+
+	// No streaming endpoint: poll for lines written since the previous read
+	for follow {
+		time.Sleep(3 * time.Second)
+		next := time.Now().UTC()
+		logs, err := c.ClawLogs(ctx, cl.ID, 1000, since.Format(time.RFC3339Nano))
+		if err != nil {
+			fatal("logs: %s", apiErrorDetail(err))
+		}
+		fmt.Print(logs)
+		since = next
+	}
+}
+
+func cmdClawsRestart(cfg Config) {
+	if len(os.Args) < 4 {
+		fatal("usage: gather claws restart <claw>")
+	}
+	c := userClient(cfg)
+	cl := findClaw(c, os.Args[3])
+	if err := c.RestartClaw(context.Background(), cl.ID); err != nil {
+		fatal("restart: %s", apiErrorDetail(err))
+	}
+	fmt.Printf("restarted %s\n", cl.Name)
+}
+
+func cmdClawsEnv(cfg Config) {
+	if len(os.Args) < 6 || os.Args[3] != "set" {
+		fatal("usage: gather claws env set <claw> KEY=VALUE... [--restart]")
+	}
+	restart := false
+	vars := map[string]string{}
+	for _, arg := range os.Args[5:] {
+		if arg == "--restart" {
+			restart = true
+			continue
+		}
+		k, v, ok := strings.Cut(arg, "=")
+		if !ok || k == "" {
+			fatal("expected KEY=VALUE, got %q", arg)
+		}
+		vars[k] = v
+	}
+	if len(vars) == 0 {
+		fatal("usage: gather claws env set <claw> KEY=VALUE... [--restart]")
+	}
+
+	c := userClient(cfg)
+	cl := findClaw(c, os.Args[4])
+	if err := c.SetClawEnv(context.Background(), cl.ID, vars, restart); err != nil {
+		fatal("env: %s", apiErrorDetail(err))
+	}
+	fmt.Printf("updated %d key(s) on %s", len(vars), cl.Name)
+	if restart {
+		fmt.Print(" and restarted")
+	}
+	fmt.Println()
+}
+
+// findClaw resolves a claw by ID or (case-insensitive) name.
+func findClaw(c *client.Client, nameOrID string) client.Claw {
+	claws, err := c.Claws(context.Background())
+	if err != nil {
+		fatal("claws: %s", apiErrorDetail(err))
+	}
+	for _, cl := range claws {
+		if cl.ID == nameOrID || strings.EqualFold(cl.Name, nameOrID) {
+			return cl
+		}
+	}
+	fatal("no claw named %q (see `gather claws list`)", nameOrID)
+	return client.Claw{}
+}
+
+// --- User auth ---
+
+func userAuthPath() string {
+	return filepath.Join(gatherDir(), "user-auth")
+}
+
+// userClient returns a client carrying the cached user token, signing in
+// first if there is none or it has expired.
+func userClient(cfg Config) *client.Client {
+	data, err := os.ReadFile(userAuthPath())
+	token := strings.TrimSpace(string(data))
+	if err != nil || token == "" || client.JWTExpired(token) {
+		if token, err = userLogin(cfg); err != nil {
+			fatal("login: %s", apiErrorDetail(err))
+		}
+	}
+	return client.New(cfg.BaseURL, client.WithToken(token))
+}
+
+// userLogin asks for the account email and password (or reads GATHER_EMAIL
+// and GATHER_PASSWORD) and caches the resulting token.
+func userLogin(cfg Config) (string, error) {
+	stdin := bufio.NewReader(os.Stdin)
+	email := os.Getenv("GATHER_EMAIL")
+	if email == "" {
+		fmt.Fprint(os.Stderr, "gather.is email: ")
+		line, err := stdin.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		email = strings.TrimSpace(line)
+	}
+	password := os.Getenv("GATHER_PASSWORD")
+	if password == "" {
+		fmt.Fprint(os.Stderr, "password: ")
+		setEcho(false)
+		line, err := stdin.ReadString('\n')
+		setEcho(true)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	token, err := client.New(cfg.BaseURL).UserLogin(context.Background(), email, password)
+	if err != nil {
+		return "", err
+	}
+	os.MkdirAll(gatherDir(), 0700)
+	if err := os.WriteFile(userAuthPath(), []byte(token), 0600); err != nil {
+		return "", fmt.Errorf("cache user token: %w", err)
+	}
+	return token, nil
+}
+
+// setEcho toggles terminal echo for password entry. Best effort: where stty
+// isn't available the password is echoed.
+func setEcho(on bool) {
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	cmd.Run()
+}
+
+// apiErrorDetail returns the server's own message for an API error (e.g. the
+// env allowlist rejection) rather than the truncated raw body.
+func apiErrorDetail(err error) string {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+	var body struct {
+		Detail  string `json:"detail"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal([]byte(apiErr.Body), &body) == nil {
+		if body.Detail != "" {
+			return fmt.Sprintf("%s (%d)", body.Detail, apiErr.StatusCode)
+		}
+		if body.Message != "" {
+			return fmt.Sprintf("%s (%d)", body.Message, apiErr.StatusCode)
+		}
+	}
+	return fmt.Sprintf("%d: %s", apiErr.StatusCode, apiErr.Body)
+}
@@ -1,3 +1,7 @@
 module gather.is/cli
 
 go 1.24.0
+
+require gather.is/auth v0.0.0
+
+replace gather.is/auth => ../gather-auth/go
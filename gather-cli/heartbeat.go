@@ -1,22 +1,33 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"time"
+
+	"gather.is/auth/client"
 )
 
 // RunHeartbeat runs the auth → check → sleep loop.
-func RunHeartbeat(baseURL, keyName string, interval time.Duration, claudeMD string) {
-	fmt.Printf("heartbeat: starting (interval %s, key %q)\n", interval, keyName)
+func RunHeartbeat(cfg Config, interval time.Duration, claudeMD string, claudeMDLines int) {
+	fmt.Printf("heartbeat: starting (interval %s, key %q)\n", interval, cfg.KeyName)
 	if claudeMD != "" {
 		fmt.Printf("heartbeat: will write notifications to %s\n", claudeMD)
 	}
 
-	lastCheck := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	// First check looks back 24h; after that each channel is polled from the
+	// server's cursor so local clock drift can't skip or repeat messages.
+	firstCheck := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	cursors := make(map[string]string)
 
 	for {
 		now := time.Now().Format("15:04")
-		token, agentID, unread, err := Authenticate(baseURL, keyName)
+		ctx := context.Background()
+		c, err := NewAgentClient(cfg)
+		var res *client.AuthResult
+		if err == nil {
+			res, err = c.AuthenticateWithActivity(ctx)
+		}
 		if err != nil {
 			fmt.Printf("[%s] auth FAILED: %v\n", now, err)
 			fmt.Printf("[%s] sleeping %s before retry\n", now, interval)
@@ -24,42 +35,57 @@ func RunHeartbeat(baseURL, keyName string, interval time.Duration, claudeMD stri
 			continue
 		}
 
-		c := &Client{BaseURL: baseURL, Token: token}
-
 		var summary []string
-		summary = append(summary, fmt.Sprintf("auth ok (agent %s)", agentID))
-		summary = append(summary, fmt.Sprintf("%d unread", unread))
+		summary = append(summary, fmt.Sprintf("auth ok (agent %s)", res.AgentID))
+		summary = append(summary, fmt.Sprintf("%d unread", res.UnreadMessages))
+		if res.Activity != nil && res.Activity.PendingReviewChallenges > 0 {
+			summary = append(summary, fmt.Sprintf("%d pending review challenges", res.Activity.PendingReviewChallenges))
+		}
 
 		// Fetch inbox if there are unread messages
-		var inboxMsgs []InboxMessage
-		if unread > 0 {
-			resp, err := c.Inbox(true)
+		var inboxMsgs []client.InboxMessage
+		if res.UnreadMessages > 0 {
+			resp, err := c.Inbox(ctx, true)
 			if err != nil {
 				fmt.Printf("[%s] inbox error: %v\n", now, err)
-			} else if resp.Messages != nil {
-				inboxMsgs = *resp.Messages
+			} else {
+				inboxMsgs = resp.Messages
 				for _, m := range inboxMsgs {
 					fmt.Printf("  inbox: [%s] %s\n", m.Type, m.Subject)
 				}
 			}
 		}
 
-		// Fetch channels and new messages
-		channelMsgs := make(map[string][]ChannelMsg)
-		chResp, err := c.Channels()
+		// Fetch channels and new messages, unless the auth response says
+		// none have anything unread
+		channelMsgs := make(map[string][]client.ChannelMessage)
+		var channels []client.Channel
+		if res.Activity == nil || res.Activity.ChannelsWithUnread > 0 {
+			channels, err = c.Channels(ctx)
+		}
 		if err != nil {
 			fmt.Printf("[%s] channels error: %v\n", now, err)
-		} else if chResp.Channels != nil {
+		} else {
 			newMsgCount := 0
-			for _, ch := range *chResp.Channels {
-				msgs, err := c.ChannelMessages(ch.Id, lastCheck)
-				if err != nil || msgs.Messages == nil {
+			for _, ch := range channels {
+				if ch.UnreadCount == 0 {
+					continue
+				}
+				var page *client.ChannelMessagePage
+				if cur, ok := cursors[ch.ID]; ok {
+					page, err = c.ChannelMessagesAfter(ctx, ch.ID, cur)
+				} else {
+					page, err = c.ChannelMessages(ctx, ch.ID, firstCheck)
+				}
+				if err != nil {
 					continue
 				}
-				if len(*msgs.Messages) > 0 {
-					channelMsgs[ch.Name] = *msgs.Messages
-					newMsgCount += len(*msgs.Messages)
-					for _, m := range *msgs.Messages {
+				cursors[ch.ID] = page.NextCursor
+				msgs := page.Messages
+				if len(msgs) > 0 {
+					channelMsgs[ch.Name] = msgs
+					newMsgCount += len(msgs)
+					for _, m := range msgs {
 						age := formatAge(m.Created)
 						fmt.Printf("  #%s: %s — %q (%s)\n", ch.Name, m.AuthorName, truncate(m.Body, 80), age)
 					}
@@ -72,12 +98,13 @@ func RunHeartbeat(baseURL, keyName string, interval time.Duration, claudeMD stri
 
 		// Write notifications to CLAUDE.md if requested
 		if claudeMD != "" {
-			WriteNotifications(claudeMD, inboxMsgs, channelMsgs)
+			if err := WriteNotifications(claudeMD, claudeMDLines, inboxMsgs, channelMsgs); err != nil {
+				fmt.Printf("[%s] notifications error: %v\n", now, err)
+			}
 		}
 
 		fmt.Printf("[%s] %s\n", now, joinParts(summary))
 
-		lastCheck = time.Now().UTC().Format(time.RFC3339)
 		time.Sleep(interval)
 	}
 }
@@ -93,14 +120,23 @@ func joinParts(parts []string) string {
 	return result
 }
 
-func formatAge(created string) string {
+// parseCreated parses a server timestamp in either PocketBase or RFC3339 form.
+func parseCreated(created string) (time.Time, bool) {
 	t, err := time.Parse("2006-01-02 15:04:05.000Z", created)
 	if err != nil {
 		t, err = time.Parse(time.RFC3339, created)
 		if err != nil {
-			return created
+			return time.Time{}, false
 		}
 	}
+	return t, true
+}
+
+func formatAge(created string) string {
+	t, ok := parseCreated(created)
+	if !ok {
+		return created
+	}
 	d := time.Since(t)
 	switch {
 	case d < time.Minute:
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
 	"time"
+
+	"gather.is/auth/client"
 )
 
 func main() {
@@ -33,6 +36,8 @@ func main() {
 		cmdHeartbeat(cfg)
 	case "notifications":
 		cmdNotifications(cfg)
+	case "claws":
+		cmdClaws(cfg)
 	case "help":
 		cmdHelp(cfg)
 	default:
@@ -54,37 +59,40 @@ Commands:
   messages <ch>    Read channel messages [--watch] [--since <ts>]
   feed             Feed digest (top posts, last 24h)
   post <ch> <msg>  Post a message to a channel
-  heartbeat        Run auth/check/sleep loop
-  notifications    One-shot check, optionally write to CLAUDE.md
+  heartbeat        Run auth/check/sleep loop [--claude-md <path>] [--claude-md-lines <n>]
+  notifications    One-shot check, optionally write to CLAUDE.md [--claude-md <path>] [--claude-md-lines <n>]
+  claws            Manage your claw deployments (list, deploy, logs, restart, env)
   help             Fetch /help from server
 
 Config: ~/.gather/config.json  {"base_url": "...", "key_name": "..."}
 Keys:   ~/.gather/keys/{name}.key + .pub (or {name}-private.pem + -public.pem)
-Cache:  ~/.gather/jwt
+Cache:  ~/.gather/jwt (agent), ~/.gather/user-auth (claws)
+State:  ~/.gather/notify-state.json (last notification written per CLAUDE.md)
 `)
 }
 
 func cmdAuth(cfg Config) {
-	token, agentID, unread, err := Authenticate(cfg.BaseURL, cfg.KeyName)
+	c, err := NewAgentClient(cfg)
+	if err != nil {
+		fatal("auth failed: %v", err)
+	}
+	// Authenticate also writes the token to ~/.gather/jwt
+	res, err := c.Authenticate(context.Background())
 	if err != nil {
 		fatal("auth failed: %v", err)
 	}
-	fmt.Printf("agent_id: %s\n", agentID)
-	fmt.Printf("unread:   %d\n", unread)
+	token := res.Token
+	fmt.Printf("agent_id: %s\n", res.AgentID)
+	fmt.Printf("unread:   %d\n", res.UnreadMessages)
 	fmt.Printf("token:    %s...%s\n", token[:20], token[len(token)-10:])
-
-	// Cache the token
-	os.MkdirAll(gatherDir(), 0700)
-	os.WriteFile(gatherDir()+"/jwt", []byte(token), 0600)
 	fmt.Println("jwt cached to ~/.gather/jwt")
 }
 
 func cmdInbox(cfg Config) {
-	token, err := CachedAuth(cfg.BaseURL, cfg.KeyName)
+	c, err := CachedAuth(cfg)
 	if err != nil {
 		fatal("auth: %v", err)
 	}
-	c := &Client{BaseURL: cfg.BaseURL, Token: token}
 
 	// Check for --all flag
 	unreadOnly := true
@@ -94,13 +102,13 @@ func cmdInbox(cfg Config) {
 		}
 	}
 
-	resp, err := c.Inbox(unreadOnly)
+	resp, err := c.Inbox(context.Background(), unreadOnly)
 	if err != nil {
 		fatal("inbox: %v", err)
 	}
 
 	fmt.Printf("inbox: %d messages (%d unread)\n", resp.Total, resp.Unread)
-	msgs := derefSlice(resp.Messages)
+	msgs := resp.Messages
 	for _, m := range msgs {
 		read := " "
 		if !m.Read {
@@ -114,18 +122,16 @@ func cmdInbox(cfg Config) {
 }
 
 func cmdChannels(cfg Config) {
-	token, err := CachedAuth(cfg.BaseURL, cfg.KeyName)
+	c, err := CachedAuth(cfg)
 	if err != nil {
 		fatal("auth: %v", err)
 	}
-	c := &Client{BaseURL: cfg.BaseURL, Token: token}
 
-	resp, err := c.Channels()
+	channels, err := c.Channels(context.Background())
 	if err != nil {
 		fatal("channels: %v", err)
 	}
 
-	channels := derefSlice(resp.Channels)
 	if len(channels) == 0 {
 		fmt.Println("no channels")
 		return
@@ -133,26 +139,33 @@ func cmdChannels(cfg Config) {
 
 	for _, ch := range channels {
 		desc := ""
-		if ch.Description != nil && *ch.Description != "" {
-			desc = " — " + *ch.Description
+		if ch.Description != "" {
+			desc = " — " + ch.Description
 		}
 		chType := ch.ChannelType
 		if chType == "" {
 			chType = "agent"
 		}
-		fmt.Printf("  [%s] #%s (%s) [%s]%s\n", chType, ch.Name, ch.Id, ch.Role, desc)
+		unread := ""
+		if ch.UnreadCount > 0 {
+			unread = fmt.Sprintf(" (%d unread)", ch.UnreadCount)
+		}
+		fmt.Printf("  [%s] #%s (%s) [%s]%s%s\n", chType, ch.Name, ch.ID, ch.Role, unread, desc)
+		if ch.LastMessageAt != "" {
+			fmt.Printf("       last message %s — %s: %s\n", formatAge(ch.LastMessageAt), ch.LastMessageAuthor, ch.LastMessagePreview)
+		}
 	}
 }
 
 func cmdFeed(cfg Config) {
-	c := &Client{BaseURL: cfg.BaseURL}
-	resp, err := c.FeedDigest()
+	c := client.New(cfg.BaseURL)
+	resp, err := c.FeedDigest(context.Background())
 	if err != nil {
 		fatal("feed: %v", err)
 	}
 
 	fmt.Printf("feed digest (%s)\n", resp.Period)
-	posts := derefSlice(resp.Posts)
+	posts := resp.Posts
 	if len(posts) == 0 {
 		fmt.Println("  (no posts)")
 		return
@@ -176,13 +189,12 @@ func cmdPost(cfg Config) {
 	channelID := os.Args[2]
 	message := os.Args[3]
 
-	token, err := CachedAuth(cfg.BaseURL, cfg.KeyName)
+	c, err := CachedAuth(cfg)
 	if err != nil {
 		fatal("auth: %v", err)
 	}
-	c := &Client{BaseURL: cfg.BaseURL, Token: token}
 
-	if err := c.PostChannelMessage(channelID, message); err != nil {
+	if _, err := c.PostChannelMessage(context.Background(), channelID, message); err != nil {
 		fatal("post: %v", err)
 	}
 	fmt.Printf("posted to channel %s\n", channelID)
@@ -208,37 +220,87 @@ func cmdMessages(cfg Config) {
 		}
 	}
 
-	token, err := CachedAuth(cfg.BaseURL, cfg.KeyName)
+	c, err := CachedAuth(cfg)
 	if err != nil {
 		fatal("auth: %v", err)
 	}
-	c := &Client{BaseURL: cfg.BaseURL, Token: token}
 
-	printMessages := func(since string) string {
-		resp, err := c.ChannelMessages(channelID, since)
-		if err != nil {
-			fatal("messages: %v", err)
-		}
-		msgs := derefSlice(resp.Messages)
-		var latest string
+	ctx := context.Background()
+	printMessages := func(msgs []client.ChannelMessage) {
 		for _, m := range msgs {
 			fmt.Printf("  [%s] %s: %s\n", formatAge(m.Created), m.AuthorName, m.Body)
-			latest = m.Created
-		}
-		if len(msgs) == 0 && since == "" {
-			fmt.Println("  (no messages)")
 		}
-		return latest
 	}
 
-	watermark := printMessages(since)
+	page, err := c.ChannelMessages(ctx, channelID, since)
+	if err != nil {
+		fatal("messages: %v", err)
+	}
+	msgs := page.Messages
+	if since == "" {
+		// Latest messages come newest first; print in reading order
+		for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+			msgs[i], msgs[j] = msgs[j], msgs[i]
+		}
+	}
+	printMessages(msgs)
+	if len(msgs) == 0 && since == "" {
+		fmt.Println("  (no messages)")
+	}
+	if len(msgs) > 0 {
+		if err := c.MarkChannelRead(ctx, channelID); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: mark read: %v\n", err)
+		}
+	}
 
 	if watch {
-		for {
-			time.Sleep(5 * time.Second)
-			if newWm := printMessages(watermark); newWm != "" {
-				watermark = newWm
-			}
+		watchMessages(ctx, c, channelID, page.NextCursor, printMessages)
+	}
+}
+
+// Watch timing: long-poll for watchWait; if the server answers an empty
+// long-poll much sooner it doesn't support ?wait=, so fall back to polling
+// every watchPollInterval. Errors back off up to watchMaxBackoff.
+const (
+	watchWait         = client.MaxChannelWait
+	watchPollInterval = 5 * time.Second
+	watchMaxBackoff   = time.Minute
+)
+
+// watchMessages prints new channel messages as they arrive, forever.
+func watchMessages(ctx context.Context, c *client.Client, channelID, cursor string, printMessages func([]client.ChannelMessage)) {
+	longPoll := true
+	backoff := watchPollInterval
+	for {
+		start := time.Now()
+		var page *client.ChannelMessagePage
+		var err error
+		if longPoll {
+			page, err = c.ChannelMessagesWait(ctx, channelID, cursor, watchWait)
+		} else {
+			page, err = c.ChannelMessagesAfter(ctx, channelID, cursor)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "messages: %v (retrying in %s)\n", err, backoff)
+			time.Sleep(backoff)
+			backoff = min(backoff*2, watchMaxBackoff)
+			continue
+		}
+		backoff = watchPollInterval
+
+		printMessages(page.Messages)
+		if len(page.Messages) > 0 {
+			c.MarkChannelRead(ctx, channelID)
+		}
+		if page.NextCursor != "" {
+			cursor = page.NextCursor
+		}
+
+		if longPoll && len(page.Messages) == 0 && time.Since(start) < watchWait/2 {
+			longPoll = false // server ignored ?wait=
+		}
+		if !longPoll {
+			time.Sleep(watchPollInterval)
 		}
 	}
 }
@@ -246,6 +308,7 @@ func cmdMessages(cfg Config) {
 func cmdHeartbeat(cfg Config) {
 	interval := 900 * time.Second
 	claudeMD := ""
+	claudeMDLines := defaultNotifyLines
 
 	for i := 2; i < len(os.Args); i++ {
 		switch os.Args[i] {
@@ -263,53 +326,81 @@ func cmdHeartbeat(cfg Config) {
 				i++
 				claudeMD = os.Args[i]
 			}
+		case "--claude-md-lines":
+			if i+1 < len(os.Args) {
+				i++
+				claudeMDLines = parseClaudeMDLines(os.Args[i])
+			}
 		}
 	}
 
-	RunHeartbeat(cfg.BaseURL, cfg.KeyName, interval, claudeMD)
+	RunHeartbeat(cfg, interval, claudeMD, claudeMDLines)
 }
 
 func cmdNotifications(cfg Config) {
 	claudeMD := ""
+	claudeMDLines := defaultNotifyLines
 	for i := 2; i < len(os.Args); i++ {
 		if os.Args[i] == "--claude-md" && i+1 < len(os.Args) {
 			i++
 			claudeMD = os.Args[i]
 		}
+		if os.Args[i] == "--claude-md-lines" && i+1 < len(os.Args) {
+			i++
+			claudeMDLines = parseClaudeMDLines(os.Args[i])
+		}
 	}
 
-	token, agentID, unread, err := Authenticate(cfg.BaseURL, cfg.KeyName)
+	ctx := context.Background()
+	c, err := NewAgentClient(cfg)
 	if err != nil {
 		fatal("auth: %v", err)
 	}
-	c := &Client{BaseURL: cfg.BaseURL, Token: token}
+	res, err := c.AuthenticateWithActivity(ctx)
+	if err != nil {
+		fatal("auth: %v", err)
+	}
+	unread := res.UnreadMessages
 
-	fmt.Printf("agent %s | %d unread\n", agentID, unread)
+	fmt.Printf("agent %s | %d unread\n", res.AgentID, unread)
 
-	var inboxMsgs []InboxMessage
+	var inboxMsgs []client.InboxMessage
 	if unread > 0 {
-		resp, err := c.Inbox(true)
+		resp, err := c.Inbox(ctx, true)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "inbox error: %v\n", err)
 		} else {
-			inboxMsgs = derefSlice(resp.Messages)
+			inboxMsgs = resp.Messages
 			for _, m := range inboxMsgs {
 				fmt.Printf("  inbox: [%s] %s\n", m.Type, m.Subject)
 			}
 		}
 	}
 
-	channelMsgs := make(map[string][]ChannelMsg)
-	chResp, err := c.Channels()
+	channelMsgs := make(map[string][]client.ChannelMessage)
+	var channels []client.Channel
+	if res.Activity == nil || res.Activity.ChannelsWithUnread > 0 {
+		channels, err = c.Channels(ctx)
+	}
 	if err == nil {
-		// Check last 24h of messages
-		since := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
-		for _, ch := range derefSlice(chResp.Channels) {
-			msgsResp, err := c.ChannelMessages(ch.Id, since)
+		// Check last 24h of messages, or since the last write to CLAUDE.md if
+		// that's later
+		sinceTime := time.Now().Add(-24 * time.Hour).UTC()
+		if claudeMD != "" {
+			if w := loadNotifyWatermark(claudeMD); w.After(sinceTime) {
+				sinceTime = w
+			}
+		}
+		since := sinceTime.Format(time.RFC3339)
+		for _, ch := range channels {
+			if ch.UnreadCount == 0 || channelQuietSince(ch, sinceTime) {
+				continue
+			}
+			page, err := c.ChannelMessages(ctx, ch.ID, since)
 			if err != nil {
 				continue
 			}
-			msgs := derefSlice(msgsResp.Messages)
+			msgs := page.Messages
 			if len(msgs) > 0 {
 				channelMsgs[ch.Name] = msgs
 				for _, m := range msgs {
@@ -320,14 +411,26 @@ func cmdNotifications(cfg Config) {
 	}
 
 	if claudeMD != "" {
-		WriteNotifications(claudeMD, inboxMsgs, channelMsgs)
+		if err := WriteNotifications(claudeMD, claudeMDLines, inboxMsgs, channelMsgs); err != nil {
+			fatal("notifications: %v", err)
+		}
 		fmt.Printf("wrote notifications to %s\n", claudeMD)
 	}
 }
 
+// parseClaudeMDLines parses --claude-md-lines, the cap on notification lines
+// written to CLAUDE.md.
+func parseClaudeMDLines(arg string) int {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 2 {
+		fatal("invalid --claude-md-lines: %s (need at least 2)", arg)
+	}
+	return n
+}
+
 func cmdHelp(cfg Config) {
-	c := &Client{BaseURL: cfg.BaseURL}
-	raw, err := c.Help()
+	c := client.New(cfg.BaseURL)
+	raw, err := c.Help(context.Background())
 	if err != nil {
 		fatal("help: %v", err)
 	}
@@ -345,10 +448,9 @@ func fatal(format string, args ...interface{}) {
 	os.Exit(1)
 }
 
-// derefSlice safely dereferences a nullable slice pointer from generated types.
-func derefSlice[T any](p *[]T) []T {
-	if p == nil {
-		return nil
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
 	}
-	return *p
+	return s[:n] + "..."
 }
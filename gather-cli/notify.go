@@ -1,71 +1,209 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"gather.is/auth/client"
 )
 
-const notifyHeader = "## Gather Notifications"
-const notifyMarker = "<!-- Auto-updated by gather-cli."
+// The notifications block lives between these markers and is replaced in
+// place on every write; nothing outside them is touched.
+const (
+	notifyBegin  = "<!-- GATHER:BEGIN -->"
+	notifyEnd    = "<!-- GATHER:END -->"
+	notifyHeader = "## Gather Notifications"
+	notifyMarker = "<!-- Auto-updated by gather-cli."
 
-// WriteNotifications finds or creates the ## Gather Notifications section in a
-// CLAUDE.md file and replaces its content with current notifications. The rest
-// of the file is untouched.
-func WriteNotifications(claudeMDPath string, inbox []InboxMessage, channelMsgs map[string][]ChannelMsg) {
-	// Build notification lines
-	var lines []string
+	// defaultNotifyLines caps the block's notification lines, trailer included.
+	defaultNotifyLines = 20
+)
+
+type notifyItem struct {
+	created time.Time
+	line    string
+}
+
+// WriteNotifications replaces the Gather block in a CLAUDE.md file with the
+// notifications newer than the last write to that file, newest first and at
+// most maxLines lines. The file is written atomically, so a crash can't
+// leave the user's CLAUDE.md half-written.
+func WriteNotifications(claudeMDPath string, maxLines int, inbox []client.InboxMessage, channelMsgs map[string][]client.ChannelMessage) error {
+	if maxLines < 2 {
+		maxLines = 2 // room for one item plus the trailer
+	}
+	watermark := loadNotifyWatermark(claudeMDPath)
+
+	var items []notifyItem
+	newest := watermark
+	add := func(created, line string) {
+		t, ok := parseCreated(created)
+		if ok && !t.After(watermark) {
+			return // reported on an earlier run
+		}
+		if t.After(newest) {
+			newest = t
+		}
+		items = append(items, notifyItem{created: t, line: fmt.Sprintf("- [%s] %s", formatAge(created), line)})
+	}
 	for name, msgs := range channelMsgs {
 		for _, m := range msgs {
-			age := formatAge(m.Created)
-			lines = append(lines, fmt.Sprintf("- [%s] #%s: %s — %q", age, name, m.AuthorName, truncate(m.Body, 100)))
+			add(m.Created, fmt.Sprintf("#%s: %s — %q", name, m.AuthorName, truncate(m.Body, 100)))
 		}
 	}
 	for _, m := range inbox {
-		age := formatAge(m.Created)
-		lines = append(lines, fmt.Sprintf("- [%s] inbox: %s", age, m.Subject))
+		add(m.Created, "inbox: "+m.Subject)
 	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].created.After(items[j].created) })
 
-	if len(lines) == 0 {
+	var lines []string
+	switch {
+	case len(items) == 0:
 		lines = append(lines, "- No new notifications.")
+	case len(items) > maxLines:
+		for _, it := range items[:maxLines-1] {
+			lines = append(lines, it.line)
+		}
+		lines = append(lines, fmt.Sprintf("- …and %d more, run `gather inbox`", len(items)-(maxLines-1)))
+	default:
+		for _, it := range items {
+			lines = append(lines, it.line)
+		}
 	}
 
 	timestamp := time.Now().UTC().Format(time.RFC3339)
-	section := notifyHeader + "\n" +
-		fmt.Sprintf("%s Last check: %s -->\n", notifyMarker, timestamp) +
-		strings.Join(lines, "\n") + "\n"
+	block := notifyBegin + "\n" +
+		notifyHeader + "\n" +
+		fmt.Sprintf("%s Last check: %s. Edits inside this block are overwritten. -->\n", notifyMarker, timestamp) +
+		strings.Join(lines, "\n") + "\n" +
+		notifyEnd
 
-	// Read existing file
-	existing, err := os.ReadFile(claudeMDPath)
-	if err != nil {
-		// File doesn't exist — create it with just the section
-		os.WriteFile(claudeMDPath, []byte(section), 0644)
-		return
-	}
-
-	content := string(existing)
-
-	// Find existing section and replace it
-	idx := strings.Index(content, notifyHeader)
-	if idx >= 0 {
-		// Find the end of the section: next ## heading or EOF
-		rest := content[idx+len(notifyHeader):]
-		endIdx := strings.Index(rest, "\n## ")
-		if endIdx >= 0 {
-			// Replace section, keep everything after
-			content = content[:idx] + section + rest[endIdx+1:]
-		} else {
-			// Section goes to EOF
-			content = content[:idx] + section
+	// Write through a symlinked CLAUDE.md rather than replacing the link
+	target := claudeMDPath
+	if resolved, err := filepath.EvalSymlinks(claudeMDPath); err == nil {
+		target = resolved
+	}
+
+	existing, err := os.ReadFile(target)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read %s: %w", target, err)
+	}
+	if err := writeFileAtomic(target, []byte(replaceNotifyBlock(string(existing), block))); err != nil {
+		return fmt.Errorf("write %s: %w", target, err)
+	}
+
+	if newest.After(watermark) {
+		saveNotifyWatermark(claudeMDPath, newest)
+	}
+	return nil
+}
+
+// channelQuietSince reports whether the channel list shows no message in ch
+// after t, so there's nothing to fetch. Servers that don't report
+// last_message_at never count as quiet.
+func channelQuietSince(ch client.Channel, t time.Time) bool {
+	last, ok := parseCreated(ch.LastMessageAt)
+	return ok && !last.After(t)
+}
+
+// replaceNotifyBlock swaps the Gather block in content for block. A section
+// written by older gather-cli versions (header + marker, no BEGIN/END) is
+// upgraded in place; otherwise the block is appended.
+func replaceNotifyBlock(content, block string) string {
+	if b := strings.Index(content, notifyBegin); b >= 0 {
+		if e := strings.Index(content[b:], notifyEnd); e >= 0 {
+			return content[:b] + block + content[b+e+len(notifyEnd):]
 		}
-	} else {
-		// Append section at end
-		if !strings.HasSuffix(content, "\n") {
-			content += "\n"
+		// Unterminated block: we can't tell where our lines end, so only
+		// the dangling marker is replaced and user content is kept.
+		return content[:b] + block + content[b+len(notifyBegin):]
+	}
+
+	if h := strings.Index(content, notifyHeader+"\n"+notifyMarker); h >= 0 {
+		rest := content[h+len(notifyHeader):]
+		if next := strings.Index(rest, "\n## "); next >= 0 {
+			return content[:h] + block + "\n" + rest[next+1:]
 		}
-		content += "\n" + section
+		return content[:h] + block + "\n"
 	}
 
-	os.WriteFile(claudeMDPath, []byte(content), 0644)
+	if content == "" {
+		return block + "\n"
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + "\n" + block + "\n"
+}
+
+// writeFileAtomic writes data to a temp file beside path and renames it into
+// place, keeping the existing file's permissions.
+func writeFileAtomic(path string, data []byte) error {
+	perm := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		perm = fi.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// notifyStatePath holds the per-file watermarks: the created time of the
+// newest notification written to each CLAUDE.md.
+func notifyStatePath() string {
+	return filepath.Join(gatherDir(), "notify-state.json")
+}
+
+func loadNotifyState() map[string]string {
+	state := map[string]string{}
+	if data, err := os.ReadFile(notifyStatePath()); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+func notifyStateKey(claudeMDPath string) string {
+	if abs, err := filepath.Abs(claudeMDPath); err == nil {
+		return abs
+	}
+	return claudeMDPath
+}
+
+func loadNotifyWatermark(claudeMDPath string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, loadNotifyState()[notifyStateKey(claudeMDPath)])
+	return t
+}
+
+func saveNotifyWatermark(claudeMDPath string, t time.Time) {
+	state := loadNotifyState()
+	state[notifyStateKey(claudeMDPath)] = t.UTC().Format(time.RFC3339Nano)
+	data, _ := json.MarshalIndent(state, "", "  ")
+	os.MkdirAll(gatherDir(), 0700)
+	if err := writeFileAtomic(notifyStatePath(), data); err != nil {
+		fmt.Fprintf(os.Stderr, "notifications: failed to save watermark: %v\n", err)
+	}
 }
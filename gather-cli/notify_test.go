@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gather.is/auth/client"
+)
+
+const (
+	userAbove = "# My project\n\nBuild with `make`.\n\n"
+	userBelow = "\n\n## Conventions\n\nTabs, not spaces.\n"
+)
+
+// notifyFixture points HOME at a temp dir, so watermarks don't leak between
+// tests, and writes a CLAUDE.md with content.
+func notifyFixture(t *testing.T, content string) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "CLAUDE.md")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func inboxAt(subject string, ago time.Duration) client.InboxMessage {
+	return client.InboxMessage{Subject: subject, Created: time.Now().Add(-ago).UTC().Format(time.RFC3339)}
+}
+
+// notifyBlock returns the text between the markers, failing unless content
+// has exactly one block.
+func notifyBlock(t *testing.T, content string) string {
+	t.Helper()
+	if n := strings.Count(content, notifyBegin); n != 1 || strings.Count(content, notifyEnd) != 1 {
+		t.Fatalf("%d blocks in:\n%s", n, content)
+	}
+	b := strings.Index(content, notifyBegin)
+	return content[b : strings.Index(content, notifyEnd)+len(notifyEnd)]
+}
+
+func TestWriteNotificationsKeepsUserContent(t *testing.T) {
+	path := notifyFixture(t, userAbove+notifyBegin+"\nstale line\n"+notifyEnd+userBelow)
+
+	inbox := []client.InboxMessage{inboxAt("first", time.Hour)}
+	channels := map[string][]client.ChannelMessage{
+		"general": {{AuthorName: "bob", Body: "hello", Created: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)}},
+	}
+	if err := WriteNotifications(path, 20, inbox, channels); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readFile(t, path)
+	block := notifyBlock(t, got)
+	if !strings.HasPrefix(got, userAbove) || !strings.HasSuffix(got, userBelow) {
+		t.Errorf("user content changed:\n%s", got)
+	}
+	if strings.Contains(got, "stale line") {
+		t.Error("old block content kept")
+	}
+	// Newest first
+	ch, in := strings.Index(block, "#general: bob"), strings.Index(block, "inbox: first")
+	if ch < 0 || in < 0 || ch > in {
+		t.Errorf("block:\n%s", block)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("permissions changed: %v", fi.Mode())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("%d files left beside CLAUDE.md", len(entries))
+	}
+}
+
+func TestWriteNotificationsDedupes(t *testing.T) {
+	path := notifyFixture(t, userAbove)
+	inbox := []client.InboxMessage{inboxAt("first", time.Hour)}
+
+	if err := WriteNotifications(path, 20, inbox, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(readFile(t, path), "inbox: first") {
+		t.Fatal("first run didn't report the message")
+	}
+
+	// The next run sees the same message again plus a new one
+	inbox = append(inbox, inboxAt("second", time.Minute))
+	if err := WriteNotifications(path, 20, inbox, nil); err != nil {
+		t.Fatal(err)
+	}
+	block := notifyBlock(t, readFile(t, path))
+	if strings.Contains(block, "inbox: first") || !strings.Contains(block, "inbox: second") {
+		t.Errorf("second run:\n%s", block)
+	}
+
+	// Nothing new: the block says so instead of repeating old items
+	if err := WriteNotifications(path, 20, inbox, nil); err != nil {
+		t.Fatal(err)
+	}
+	got := readFile(t, path)
+	if block := notifyBlock(t, got); !strings.Contains(block, "No new notifications") || strings.Contains(block, "inbox:") {
+		t.Errorf("third run:\n%s", block)
+	}
+	if !strings.HasPrefix(got, userAbove) {
+		t.Errorf("user content changed:\n%s", got)
+	}
+}
+
+func TestWriteNotificationsLineCap(t *testing.T) {
+	path := notifyFixture(t, "")
+	var inbox []client.InboxMessage
+	for i := 0; i < 10; i++ {
+		inbox = append(inbox, inboxAt(fmt.Sprintf("msg %d", i), time.Duration(i+1)*time.Minute))
+	}
+	if err := WriteNotifications(path, 4, inbox, nil); err != nil {
+		t.Fatal(err)
+	}
+	block := notifyBlock(t, readFile(t, path))
+	if n := strings.Count(block, "\n- "); n != 4 {
+		t.Errorf("%d lines, want 4:\n%s", n, block)
+	}
+	if !strings.Contains(block, "msg 0") || strings.Contains(block, "msg 3") {
+		t.Errorf("kept the wrong items:\n%s", block)
+	}
+	if !strings.Contains(block, "…and 7 more, run `gather inbox`") {
+		t.Errorf("missing trailer:\n%s", block)
+	}
+}
+
+func TestReplaceNotifyBlock(t *testing.T) {
+	block := notifyBegin + "\nnew\n" + notifyEnd
+	cases := []struct{ name, in, want string }{
+		{"empty file", "", block + "\n"},
+		{"no block", "# Notes", "# Notes\n\n" + block + "\n"},
+		{"existing block", userAbove + notifyBegin + "\nold\n" + notifyEnd + userBelow, userAbove + block + userBelow},
+		{
+			"legacy section",
+			userAbove + notifyHeader + "\n" + notifyMarker + " Last check: x. -->\n- old\n\n## Conventions\n\nTabs.\n",
+			userAbove + block + "\n## Conventions\n\nTabs.\n",
+		},
+		{"legacy section at the end", userAbove + notifyHeader + "\n" + notifyMarker + " -->\n- old\n", userAbove + block + "\n"},
+		{"unterminated block", userAbove + notifyBegin + "\nmine\n", userAbove + block + "\nmine\n"},
+	}
+	for _, tc := range cases {
+		if got := replaceNotifyBlock(tc.in, block); got != tc.want {
+			t.Errorf("%s:\n got %q\nwant %q", tc.name, got, tc.want)
+		}
+	}
+}
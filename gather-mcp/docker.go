@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"os"
 	"strings"
 
 	"github.com/docker/docker/api/types/container"
@@ -11,17 +14,41 @@ import (
 	"github.com/docker/docker/client"
 )
 
-// DockerTools registers claw management tools that use the Docker API.
-type DockerTools struct {
-	cli *client.Client
+// errClawNotPermitted is returned for any claw the caller doesn't own,
+// whether or not it exists, so it says nothing about other containers.
+var errClawNotPermitted = errors.New("permission denied: claw tools only work on your own claws")
+
+// DockerAPI is the part of the Docker client DockerTools calls.
+type DockerAPI interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error)
+	ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error)
+	ContainerStatsOneShot(ctx context.Context, containerID string) (container.StatsResponseReader, error)
 }
 
-func NewDockerTools() (*DockerTools, error) {
+// NewDockerClient connects to the Docker daemon configured in the environment.
+func NewDockerClient() (*client.Client, error) {
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, fmt.Errorf("docker client: %w", err)
 	}
-	return &DockerTools{cli: cli}, nil
+	return cli, nil
+}
+
+// DockerTools registers claw management tools that use the Docker API. Every
+// call is limited to the containers of the caller's own claws, unless
+// MCP_DOCKER_ADMIN=true (development only) opens up the whole host.
+type DockerTools struct {
+	cli    DockerAPI
+	owners OwnershipResolver
+	admin  bool
+}
+
+func NewDockerTools(cli DockerAPI, owners OwnershipResolver) *DockerTools {
+	admin := os.Getenv("MCP_DOCKER_ADMIN") == "true"
+	if admin {
+		log.Printf("WARNING: MCP_DOCKER_ADMIN=true — claw.* tools can reach every container on the host")
+	}
+	return &DockerTools{cli: cli, owners: owners, admin: admin}
 }
 
 // RegisterTools adds Docker-based tools to the registry.
@@ -30,16 +57,16 @@ func (d *DockerTools) RegisterTools(reg *Registry) {
 		ID:          "claw.list_all",
 		Category:    "claw",
 		Name:        "claw.list_all",
-		Description: "List all active claw containers (names only)",
+		Description: "List your active claw containers (names only)",
 		Source:      "docker",
 	})
 	reg.Register(&Tool{
 		ID:          "claw.logs",
 		Category:    "claw",
 		Name:        "claw.logs",
-		Description: "Get recent container logs for a claw (default: own container)",
+		Description: "Get recent container logs for one of your claws (default: your only claw)",
 		Params: []ToolParam{
-			{Name: "claw", Type: "string", Required: false, Description: "Claw name (default: self)"},
+			{Name: "claw", Type: "string", Required: false, Description: "Claw name (default: your only claw)"},
 			{Name: "lines", Type: "integer", Required: false, Description: "Number of lines (default: 100)"},
 		},
 		Source: "docker",
@@ -48,9 +75,9 @@ func (d *DockerTools) RegisterTools(reg *Registry) {
 		ID:          "claw.stats",
 		Category:    "claw",
 		Name:        "claw.stats",
-		Description: "Get container resource stats (CPU, memory) for a claw",
+		Description: "Get container resource stats (CPU, memory) for one of your claws",
 		Params: []ToolParam{
-			{Name: "claw", Type: "string", Required: false, Description: "Claw name (default: self)"},
+			{Name: "claw", Type: "string", Required: false, Description: "Claw name (default: your only claw)"},
 		},
 		Source: "docker",
 	})
@@ -58,26 +85,70 @@ func (d *DockerTools) RegisterTools(reg *Registry) {
 		ID:          "peer.list",
 		Category:    "peer",
 		Name:        "peer.list",
-		Description: "List active claw names and URLs for inter-claw communication",
+		Description: "List your active claws' names and URLs for inter-claw communication",
 		Source:      "docker",
 	})
 }
 
-// Execute runs a Docker-based tool.
-func (d *DockerTools) Execute(toolID string, params map[string]any) (any, error) {
+// Execute runs a Docker-based tool for the caller identified by jwt.
+func (d *DockerTools) Execute(toolID string, params map[string]any, jwt string) (any, error) {
+	owned, err := d.scope(jwt)
+	if err != nil {
+		return nil, err
+	}
 	switch toolID {
 	case "claw.list_all", "peer.list":
-		return d.listClaws()
+		return d.listClaws(owned)
 	case "claw.logs":
-		return d.getClawLogs(params)
+		return d.getClawLogs(params, owned)
 	case "claw.stats":
-		return d.getClawStats(params)
+		return d.getClawStats(params, owned)
 	default:
 		return nil, fmt.Errorf("unknown docker tool: %s", toolID)
 	}
 }
 
-func (d *DockerTools) listClaws() (any, error) {
+// scope returns the containers the caller owns, or nil in admin mode, where
+// every container is allowed.
+func (d *DockerTools) scope(jwt string) (map[string]bool, error) {
+	if d.admin {
+		return nil, nil
+	}
+	if jwt == "" {
+		return nil, fmt.Errorf("claw tools require authentication: provide the agent JWT")
+	}
+	owned, err := d.owners.OwnedContainers(context.Background(), jwt)
+	if err != nil {
+		log.Printf("Claw ownership lookup failed: %v", err)
+		return nil, fmt.Errorf("could not verify which claws you own; try again")
+	}
+	return owned, nil
+}
+
+// target is the container a claw param names, if the caller may use it.
+// Without one, a caller who owns a single claw gets that claw.
+func (d *DockerTools) target(params map[string]any, owned map[string]bool) (string, error) {
+	clawName, _ := params["claw"].(string)
+	if clawName == "" {
+		if owned == nil {
+			return "", fmt.Errorf("'claw' param required")
+		}
+		if len(owned) != 1 {
+			return "", fmt.Errorf("'claw' param required: you own %d claws", len(owned))
+		}
+		for name := range owned {
+			return name, nil
+		}
+	}
+
+	containerName := "claw-" + clawName
+	if owned != nil && !owned[containerName] {
+		return "", errClawNotPermitted
+	}
+	return containerName, nil
+}
+
+func (d *DockerTools) listClaws(owned map[string]bool) (any, error) {
 	ctx := context.Background()
 
 	// List containers with claw- prefix
@@ -100,6 +171,9 @@ func (d *DockerTools) listClaws() (any, error) {
 	for _, c := range containers {
 		for _, name := range c.Names {
 			name = strings.TrimPrefix(name, "/")
+			if owned != nil && !owned[name] {
+				continue
+			}
 			if strings.HasPrefix(name, "claw-") {
 				clawName := strings.TrimPrefix(name, "claw-")
 				claws = append(claws, clawInfo{
@@ -113,12 +187,12 @@ func (d *DockerTools) listClaws() (any, error) {
 	return map[string]any{"claws": claws, "count": len(claws)}, nil
 }
 
-func (d *DockerTools) getClawLogs(params map[string]any) (any, error) {
+func (d *DockerTools) getClawLogs(params map[string]any, owned map[string]bool) (any, error) {
 	ctx := context.Background()
 
-	clawName, _ := params["claw"].(string)
-	if clawName == "" {
-		clawName = "self" // placeholder — caller should set this
+	containerName, err := d.target(params, owned)
+	if err != nil {
+		return nil, err
 	}
 
 	lines := "100"
@@ -126,7 +200,6 @@ func (d *DockerTools) getClawLogs(params map[string]any) (any, error) {
 		lines = fmt.Sprintf("%v", l)
 	}
 
-	containerName := "claw-" + clawName
 	reader, err := d.cli.ContainerLogs(ctx, containerName, container.LogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
@@ -141,15 +214,14 @@ func (d *DockerTools) getClawLogs(params map[string]any) (any, error) {
 	return map[string]any{"logs": string(logBytes)}, nil
 }
 
-func (d *DockerTools) getClawStats(params map[string]any) (any, error) {
+func (d *DockerTools) getClawStats(params map[string]any, owned map[string]bool) (any, error) {
 	ctx := context.Background()
 
-	clawName, _ := params["claw"].(string)
-	if clawName == "" {
-		clawName = "self"
+	containerName, err := d.target(params, owned)
+	if err != nil {
+		return nil, err
 	}
 
-	containerName := "claw-" + clawName
 	stats, err := d.cli.ContainerStatsOneShot(ctx, containerName)
 	if err != nil {
 		return nil, fmt.Errorf("get stats: %w", err)
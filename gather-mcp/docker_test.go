@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// fakeDocker serves a fixed set of running containers and records which
+// containers were read.
+type fakeDocker struct {
+	names []string
+	read  []string
+}
+
+func (f *fakeDocker) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	var out []container.Summary
+	for _, n := range f.names {
+		out = append(out, container.Summary{Names: []string{"/" + n}})
+	}
+	return out, nil
+}
+
+func (f *fakeDocker) ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	f.read = append(f.read, containerID)
+	return io.NopCloser(strings.NewReader("logs of " + containerID)), nil
+}
+
+func (f *fakeDocker) ContainerStatsOneShot(ctx context.Context, containerID string) (container.StatsResponseReader, error) {
+	f.read = append(f.read, containerID)
+	return container.StatsResponseReader{Body: io.NopCloser(strings.NewReader(`{"id":"` + containerID + `"}`))}, nil
+}
+
+// fakeOwners maps JWTs to the containers they own.
+type fakeOwners map[string][]string
+
+func (o fakeOwners) OwnedContainers(ctx context.Context, jwt string) (map[string]bool, error) {
+	names, ok := o[jwt]
+	if !ok {
+		return nil, errors.New("gather-auth returned 401")
+	}
+	owned := map[string]bool{}
+	for _, n := range names {
+		owned[n] = true
+	}
+	return owned, nil
+}
+
+func newFakeDockerTools() (*DockerTools, *fakeDocker) {
+	docker := &fakeDocker{names: []string{"claw-alice", "claw-alice2", "claw-bob", "postgres"}}
+	owners := fakeOwners{
+		"alice-jwt": {"claw-alice", "claw-alice2"},
+		"bob-jwt":   {"claw-bob"},
+		"none-jwt":  {},
+	}
+	return &DockerTools{cli: docker, owners: owners}, docker
+}
+
+func clawNames(t *testing.T, result any) []string {
+	t.Helper()
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Claws []struct {
+			Name string `json:"name"`
+		} `json:"claws"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range out.Claws {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestDockerToolsListOnlyOwnClaws(t *testing.T) {
+	d, _ := newFakeDockerTools()
+	for jwt, want := range map[string]string{
+		"alice-jwt": "alice,alice2",
+		"bob-jwt":   "bob",
+		"none-jwt":  "",
+	} {
+		for _, tool := range []string{"claw.list_all", "peer.list"} {
+			result, err := d.Execute(tool, nil, jwt)
+			if err != nil {
+				t.Fatalf("%s %s: %v", jwt, tool, err)
+			}
+			if got := strings.Join(clawNames(t, result), ","); got != want {
+				t.Errorf("%s %s = %q, want %q", jwt, tool, got, want)
+			}
+		}
+	}
+}
+
+func TestDockerToolsRefuseOtherClaws(t *testing.T) {
+	for _, tool := range []string{"claw.logs", "claw.stats"} {
+		d, docker := newFakeDockerTools()
+		for _, claw := range []string{"bob", "postgres", "missing", "../bob", "alice/../bob"} {
+			_, err := d.Execute(tool, map[string]any{"claw": claw}, "alice-jwt")
+			if !errors.Is(err, errClawNotPermitted) {
+				t.Errorf("%s of %q: err = %v, want errClawNotPermitted", tool, claw, err)
+			}
+		}
+		if len(docker.read) != 0 {
+			t.Errorf("%s: Docker was asked for %v", tool, docker.read)
+		}
+
+		if _, err := d.Execute(tool, map[string]any{"claw": "alice2"}, "alice-jwt"); err != nil {
+			t.Errorf("%s of own claw: %v", tool, err)
+		}
+		if len(docker.read) != 1 || docker.read[0] != "claw-alice2" {
+			t.Errorf("%s read %v, want [claw-alice2]", tool, docker.read)
+		}
+	}
+}
+
+func TestDockerToolsDefaultClaw(t *testing.T) {
+	d, docker := newFakeDockerTools()
+	result, err := d.Execute("claw.logs", nil, "bob-jwt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logs := result.(map[string]any)["logs"]; logs != "logs of claw-bob" {
+		t.Errorf("logs = %v", logs)
+	}
+
+	if _, err := d.Execute("claw.stats", nil, "alice-jwt"); err == nil || !strings.Contains(err.Error(), "you own 2 claws") {
+		t.Errorf("owner of two claws without 'claw': %v", err)
+	}
+	if _, err := d.Execute("claw.stats", nil, "none-jwt"); err == nil {
+		t.Error("owner of no claws got a default claw")
+	}
+	if len(docker.read) != 1 {
+		t.Errorf("Docker reads = %v, want only claw-bob", docker.read)
+	}
+}
+
+func TestDockerToolsRequireAuth(t *testing.T) {
+	d, docker := newFakeDockerTools()
+	for _, jwt := range []string{"", "expired-jwt"} {
+		for _, tool := range []string{"claw.list_all", "claw.logs", "claw.stats", "peer.list"} {
+			if _, err := d.Execute(tool, map[string]any{"claw": "bob"}, jwt); err == nil {
+				t.Errorf("%s with jwt %q: no error", tool, jwt)
+			}
+		}
+	}
+	if len(docker.read) != 0 {
+		t.Errorf("Docker was asked for %v", docker.read)
+	}
+}
+
+func TestDockerToolsAdmin(t *testing.T) {
+	d, _ := newFakeDockerTools()
+	d.admin = true
+	result, err := d.Execute("claw.list_all", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(clawNames(t, result), ","); got != "alice,alice2,bob" {
+		t.Errorf("admin list = %q", got)
+	}
+	if _, err := d.Execute("claw.logs", map[string]any{"claw": "bob"}, ""); err != nil {
+		t.Errorf("admin logs: %v", err)
+	}
+}
@@ -32,7 +32,7 @@ func (e *Executor) Execute(tool *Tool, params map[string]any, jwt string) (any,
 	case "openapi":
 		return e.executeOpenAPI(tool, params, jwt)
 	case "docker":
-		return e.executeDocker(tool, params)
+		return e.executeDocker(tool, params, jwt)
 	case "interclaw":
 		return e.executeInterClaw(tool, params, jwt)
 	default:
@@ -41,6 +41,10 @@ func (e *Executor) Execute(tool *Tool, params map[string]any, jwt string) (any,
 }
 
 func (e *Executor) executeOpenAPI(tool *Tool, params map[string]any, jwt string) (any, error) {
+	if tool.Auth != "" && jwt == "" {
+		return nil, fmt.Errorf("%s requires authentication (%s): provide the agent JWT", tool.ID, tool.Auth)
+	}
+
 	// Build URL, substituting path params
 	path := tool.Endpoint
 	queryParams := make(map[string]string)
@@ -114,7 +118,10 @@ func (e *Executor) executeOpenAPI(tool *Tool, params map[string]any, jwt string)
 	if bodyReader != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
-	ForwardAuth(req, jwt)
+	// Public endpoints get no token, so a stale JWT can't turn them into 401s
+	if tool.Auth != "" {
+		ForwardAuth(req, jwt)
+	}
 
 	resp, err := e.client.Do(req)
 	if err != nil {
@@ -148,11 +155,11 @@ func (e *Executor) executeOpenAPI(tool *Tool, params map[string]any, jwt string)
 	return result, nil
 }
 
-func (e *Executor) executeDocker(tool *Tool, params map[string]any) (any, error) {
+func (e *Executor) executeDocker(tool *Tool, params map[string]any, jwt string) (any, error) {
 	if e.dockerTools == nil {
 		return nil, fmt.Errorf("docker tools unavailable (no Docker socket)")
 	}
-	return e.dockerTools.Execute(tool.ID, params)
+	return e.dockerTools.Execute(tool.ID, params, jwt)
 }
 
 func (e *Executor) executeInterClaw(tool *Tool, params map[string]any, jwt string) (any, error) {
@@ -36,11 +36,10 @@ func main() {
 
 	// Register manual tools (Docker, inter-claw)
 	var dockerTools *DockerTools
-	dt, err := NewDockerTools()
-	if err != nil {
+	if cli, err := NewDockerClient(); err != nil {
 		log.Printf("Docker tools unavailable: %v (claw.* and peer.list tools disabled)", err)
 	} else {
-		dockerTools = dt
+		dockerTools = NewDockerTools(cli, NewAuthOwnership(authURL))
 		dockerTools.RegisterTools(reg)
 	}
 	RegisterInterClawTools(reg)
@@ -88,13 +87,6 @@ func main() {
 			return
 		}
 
-		// Authenticate
-		jwt, err := auth.AuthenticateRequest(r, body)
-		if err != nil {
-			writeError(w, http.StatusUnauthorized, err.Error())
-			return
-		}
-
 		// Parse request
 		var req struct {
 			Tool   string         `json:"tool"`
@@ -120,6 +112,14 @@ func main() {
 			req.Params = make(map[string]any)
 		}
 
+		// Authenticate. Public OpenAPI tools (no security requirement in the
+		// spec) run without credentials.
+		jwt, err := auth.AuthenticateRequest(r, body)
+		if err != nil && (tool.Source != "openapi" || tool.Auth != "") {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
 		result, err := executor.Execute(tool, req.Params, jwt)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, err.Error())
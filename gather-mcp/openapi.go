@@ -71,7 +71,12 @@ func LoadFromOpenAPI(reg *Registry, baseURL string) error {
 				description = op.Description
 			}
 
+			if op.Deprecated {
+				description = deprecationNote(op) + " " + description
+			}
+
 			params := extractParams(op.Parameters, op.RequestBody)
+			auth := operationAuth(op, spec.Security)
 
 			tool := &Tool{
 				ID:          toolName,
@@ -81,7 +86,9 @@ func LoadFromOpenAPI(reg *Registry, baseURL string) error {
 				Method:      method,
 				Endpoint:    path,
 				Params:      params,
-				Source:       "openapi",
+				Source:      "openapi",
+				Auth:        auth,
+				Deprecated:  op.Deprecated,
 			}
 			reg.Register(tool)
 			count++
@@ -92,6 +99,37 @@ func LoadFromOpenAPI(reg *Registry, baseURL string) error {
 	return nil
 }
 
+// operationAuth returns the first security scheme the operation requires,
+// falling back to the spec's top-level requirement. An explicit empty list on
+// the operation means public.
+func operationAuth(op openAPIOperation, global []map[string][]string) string {
+	reqs := global
+	if op.Security != nil {
+		reqs = *op.Security
+	}
+	for _, req := range reqs {
+		for scheme := range req {
+			return scheme
+		}
+	}
+	return ""
+}
+
+// deprecationNote leads a deprecated tool's description, so an LLM picking
+// between tools sees the replacement first.
+func deprecationNote(op openAPIOperation) string {
+	note := "DEPRECATED"
+	if op.Sunset != "" {
+		note += " (removed after " + op.Sunset + ")"
+	}
+	if op.Replacement != "" {
+		note += ": use " + op.Replacement + " instead."
+	} else {
+		note += ": avoid unless there is no alternative."
+	}
+	return note
+}
+
 func categorize(tags []string) string {
 	for _, tag := range tags {
 		if cat, ok := tagToCategory[tag]; ok {
@@ -121,13 +159,21 @@ func buildToolName(category, operationID, method, path string) string {
 func extractParams(params []openAPIParam, reqBody *openAPIRequestBody) []ToolParam {
 	var out []ToolParam
 
-	// Path + query params
+	// Path + query params. The Authorization header is carried by the
+	// security scheme, not passed as a tool argument.
 	for _, p := range params {
+		if p.In == "header" && strings.EqualFold(p.Name, "Authorization") {
+			continue
+		}
+		desc := p.Description
+		if p.Deprecated && !strings.HasPrefix(desc, "Deprecated") {
+			desc = "Deprecated. " + desc
+		}
 		out = append(out, ToolParam{
 			Name:        p.Name,
 			Type:        schemaType(p.Schema),
 			Required:    p.Required,
-			Description: p.Description,
+			Description: desc,
 		})
 	}
 
@@ -169,7 +215,8 @@ func schemaType(s *openAPISchema) string {
 
 // Minimal OpenAPI spec structures — just enough to extract tools.
 type openAPISpec struct {
-	Paths map[string]map[string]openAPIOperation `json:"paths"`
+	Paths    map[string]map[string]openAPIOperation `json:"paths"`
+	Security []map[string][]string                  `json:"security"`
 }
 
 type openAPIOperation struct {
@@ -179,6 +226,13 @@ type openAPIOperation struct {
 	Tags        []string            `json:"tags"`
 	Parameters  []openAPIParam      `json:"parameters"`
 	RequestBody *openAPIRequestBody `json:"requestBody"`
+	// Security is a pointer so an explicit [] (public) differs from absent.
+	Security *[]map[string][]string `json:"security"`
+	// Deprecated operations carry gather-auth's x-sunset (YYYY-MM-DD) and
+	// x-replacement extensions.
+	Deprecated  bool   `json:"deprecated"`
+	Sunset      string `json:"x-sunset"`
+	Replacement string `json:"x-replacement"`
 }
 
 type openAPIParam struct {
@@ -186,6 +240,7 @@ type openAPIParam struct {
 	In          string        `json:"in"`
 	Required    bool          `json:"required"`
 	Description string        `json:"description"`
+	Deprecated  bool          `json:"deprecated"`
 	Schema      *openAPISchema `json:"schema"`
 }
 
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const securitySpec = `{
+  "security": [{"bearerAuth": []}],
+  "paths": {
+    "/api/posts": {
+      "get":  {"operationId": "list-posts", "tags": ["Social"], "security": [],
+               "parameters": [{"name": "Authorization", "in": "header"}, {"name": "limit", "in": "query"}]},
+      "post": {"operationId": "create-post", "tags": ["Social"], "security": [{"bearerAuth": []}],
+               "parameters": [{"name": "Authorization", "in": "header", "required": true}]}
+    },
+    "/api/inbox": {
+      "get": {"operationId": "list-inbox", "tags": ["Inbox"], "security": [{"bearerAuth": []}, {"userAuth": []}]}
+    },
+    "/api/tags": {
+      "get": {"operationId": "list-tags", "tags": ["Social"]}
+    }
+  }
+}`
+
+func TestLoadFromOpenAPISecurity(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(securitySpec))
+	}))
+	defer srv.Close()
+
+	reg := NewRegistry()
+	if err := LoadFromOpenAPI(reg, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := map[string]string{}
+	for _, tool := range reg.All("") {
+		auth[tool.Endpoint+" "+tool.Method] = tool.Auth
+		for _, p := range tool.Params {
+			if p.Name == "Authorization" {
+				t.Errorf("%s exposes the Authorization header as a parameter", tool.ID)
+			}
+		}
+	}
+	want := map[string]string{
+		"/api/posts GET":  "",           // explicit [] is public
+		"/api/posts POST": "bearerAuth", // operation-level
+		"/api/inbox GET":  "bearerAuth", // first alternative
+		"/api/tags GET":   "bearerAuth", // inherits the spec's default
+	}
+	for k, w := range want {
+		got, ok := auth[k]
+		if !ok {
+			t.Errorf("%s: no tool", k)
+		} else if got != w {
+			t.Errorf("%s: auth %q, want %q", k, got, w)
+		}
+	}
+}
+
+const deprecationSpec = `{
+  "paths": {
+    "/api/reviews": {
+      "post": {"operationId": "create-review", "tags": ["Reviews"], "summary": "Create a review",
+               "deprecated": true, "x-sunset": "2027-01-15", "x-replacement": "POST /api/reviews/submit"}
+    },
+    "/api/reviews/submit": {
+      "post": {"operationId": "submit-review", "tags": ["Reviews"], "summary": "Submit a review"}
+    },
+    "/api/reviews/old": {
+      "get": {"operationId": "list-review-old", "tags": ["Reviews"], "summary": "Old review list", "deprecated": true}
+    },
+    "/api/posts": {
+      "get": {"operationId": "list-posts", "tags": ["Social"], "summary": "Scan the feed",
+              "parameters": [{"name": "since", "in": "query", "deprecated": true, "description": "Only newer posts"}]}
+    }
+  }
+}`
+
+func TestLoadFromOpenAPIDeprecations(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(deprecationSpec))
+	}))
+	defer srv.Close()
+
+	reg := NewRegistry()
+	if err := LoadFromOpenAPI(reg, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	create := reg.Get("skills.create_review")
+	if create == nil || !create.Deprecated ||
+		create.Description != "DEPRECATED (removed after 2027-01-15): use POST /api/reviews/submit instead. Create a review" {
+		t.Fatalf("create_review: %+v", create)
+	}
+	if old := reg.Get("skills.list_review_old"); old == nil ||
+		old.Description != "DEPRECATED: avoid unless there is no alternative. Old review list" {
+		t.Errorf("list_review_old: %+v", old)
+	}
+	if submit := reg.Get("skills.submit_review"); submit == nil || submit.Deprecated || strings.Contains(submit.Description, "DEPRECATED") {
+		t.Errorf("submit_review: %+v", submit)
+	}
+
+	posts := reg.Get("social.list_posts")
+	if posts == nil || posts.Deprecated || len(posts.Params) != 1 || posts.Params[0].Description != "Deprecated. Only newer posts" {
+		t.Errorf("list_posts: %+v", posts)
+	}
+
+	// "create review" matches the deprecated tool's ID and name best, but the
+	// live replacement still comes first
+	results := reg.Search("create review", "")
+	if len(results) != 3 || results[0].ID != "skills.submit_review" {
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.ID)
+		}
+		t.Errorf("search order %v", ids)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// OwnershipResolver reports which claw containers a caller owns, keyed by
+// container name (claw-<name>).
+type OwnershipResolver interface {
+	OwnedContainers(ctx context.Context, jwt string) (map[string]bool, error)
+}
+
+// ownershipTTL is how long a caller's owned set is reused, so a burst of
+// tool calls costs one lookup.
+const ownershipTTL = 30 * time.Second
+
+// AuthOwnership resolves ownership through gather-auth's
+// GET /api/claws/containers, which accepts a claw's agent JWT or a user token.
+type AuthOwnership struct {
+	authURL string
+	client  *http.Client
+
+	mu    sync.Mutex
+	cache map[[32]byte]cachedOwnership // sha256(jwt) → owned containers
+}
+
+type cachedOwnership struct {
+	Containers map[string]bool
+	ExpiresAt  time.Time
+}
+
+func NewAuthOwnership(authURL string) *AuthOwnership {
+	return &AuthOwnership{
+		authURL: authURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		cache:   make(map[[32]byte]cachedOwnership),
+	}
+}
+
+func (o *AuthOwnership) OwnedContainers(ctx context.Context, jwt string) (map[string]bool, error) {
+	key := sha256.Sum256([]byte(jwt))
+	o.mu.Lock()
+	cached, ok := o.cache[key]
+	o.mu.Unlock()
+	if ok && time.Now().Before(cached.ExpiresAt) {
+		return cached.Containers, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", o.authURL+"/api/claws/containers", nil)
+	if err != nil {
+		return nil, err
+	}
+	ForwardAuth(req, jwt)
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("look up owned claws: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("look up owned claws: gather-auth returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Containers []struct {
+			Name      string `json:"name"`
+			Container string `json:"container"`
+		} `json:"containers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode owned claws: %w", err)
+	}
+	owned := make(map[string]bool, len(body.Containers))
+	for _, c := range body.Containers {
+		owned["claw-"+c.Name] = true
+		if c.Container != "" {
+			owned[c.Container] = true
+		}
+	}
+
+	o.mu.Lock()
+	now := time.Now()
+	for k, v := range o.cache {
+		if now.After(v.ExpiresAt) {
+			delete(o.cache, k)
+		}
+	}
+	o.cache[key] = cachedOwnership{Containers: owned, ExpiresAt: now.Add(ownershipTTL)}
+	o.mu.Unlock()
+	return owned, nil
+}
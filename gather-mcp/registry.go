@@ -15,6 +15,12 @@ type Tool struct {
 	Endpoint    string      `json:"endpoint,omitempty"`
 	Params      []ToolParam `json:"params,omitempty"`
 	Source      string      `json:"source"` // "openapi", "docker", "interclaw"
+	// Auth is the security scheme the endpoint requires ("bearerAuth" for
+	// the agent JWT), or "" for public endpoints.
+	Auth string `json:"auth,omitempty"`
+	// Deprecated tools are scheduled for removal; the description names the
+	// replacement and Search ranks them below live tools.
+	Deprecated bool `json:"deprecated,omitempty"`
 }
 
 // ToolParam describes a tool parameter.
@@ -90,6 +96,9 @@ func (r *Registry) Search(query, category string) []*Tool {
 			}
 		}
 
+		if score > 0 && t.Deprecated {
+			score = 1 // still findable, but after any live match
+		}
 		if score > 0 {
 			results = append(results, scored{tool: t, score: score})
 		}